@@ -0,0 +1,30 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+
+	infrav1alpha3 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+)
+
+func TestFuzzyConversion(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1alpha3.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("for MetalCluster", utilconversion.FuzzTestFunc(scheme, &infrav1alpha3.MetalCluster{}, &MetalCluster{}))
+	t.Run("for MetalMachine", utilconversion.FuzzTestFunc(scheme, &infrav1alpha3.MetalMachine{}, &MetalMachine{}))
+	t.Run("for MetalMachineTemplate", utilconversion.FuzzTestFunc(scheme, &infrav1alpha3.MetalMachineTemplate{}, &MetalMachineTemplate{}))
+}
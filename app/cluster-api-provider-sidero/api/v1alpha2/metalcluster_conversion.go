@@ -42,6 +42,12 @@ func (src *MetalCluster) ConvertTo(dstRaw conversion.Hub) error {
 		return err
 	}
 
+	dst.Spec.MachineDefaults = restored.Spec.MachineDefaults
+	dst.Spec.RemediationBudget = restored.Spec.RemediationBudget
+	dst.Status.PowerState = restored.Status.PowerState
+	dst.Status.RemediationActions = restored.Status.RemediationActions
+	dst.Status.Conditions = restored.Status.Conditions
+
 	return nil
 }
 
@@ -27,6 +27,17 @@ func (src *MetalMachine) ConvertTo(dstRaw conversion.Hub) error {
 		return err
 	}
 
+	dst.Spec.ServerClassRef = restored.Spec.ServerClassRef
+	dst.Spec.ConfigPatchSets = restored.Spec.ConfigPatchSets
+	dst.Spec.ProvisioningTimeout = restored.Spec.ProvisioningTimeout
+	dst.Spec.ProvisioningRetries = restored.Spec.ProvisioningRetries
+	dst.Spec.ReadinessGate = restored.Spec.ReadinessGate
+	dst.Spec.TalosHealthCheck = restored.Spec.TalosHealthCheck
+	dst.Status.ProvisioningStartTime = restored.Status.ProvisioningStartTime
+	dst.Status.ProvisioningTimeouts = restored.Status.ProvisioningTimeouts
+	dst.Status.FailedServers = restored.Status.FailedServers
+	dst.Status.Conditions = restored.Status.Conditions
+
 	return nil
 }
 
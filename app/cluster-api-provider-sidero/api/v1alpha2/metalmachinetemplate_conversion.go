@@ -25,6 +25,13 @@ func (src *MetalMachineTemplate) ConvertTo(dstRaw conversion.Hub) error {
 		return err
 	}
 
+	dst.Spec.Template.Spec.ServerClassRef = restored.Spec.Template.Spec.ServerClassRef
+	dst.Spec.Template.Spec.ConfigPatchSets = restored.Spec.Template.Spec.ConfigPatchSets
+	dst.Spec.Template.Spec.ProvisioningTimeout = restored.Spec.Template.Spec.ProvisioningTimeout
+	dst.Spec.Template.Spec.ProvisioningRetries = restored.Spec.Template.Spec.ProvisioningRetries
+	dst.Spec.Template.Spec.ReadinessGate = restored.Spec.Template.Spec.ReadinessGate
+	dst.Spec.Template.Spec.TalosHealthCheck = restored.Spec.Template.Spec.TalosHealthCheck
+
 	return nil
 }
 
@@ -239,6 +239,8 @@ func autoConvert_v1alpha2_MetalClusterSpec_To_v1alpha3_MetalClusterSpec(in *Meta
 
 func autoConvert_v1alpha3_MetalClusterSpec_To_v1alpha2_MetalClusterSpec(in *v1alpha3.MetalClusterSpec, out *MetalClusterSpec, s conversion.Scope) error {
 	// WARNING: in.ControlPlaneEndpoint requires manual conversion: does not exist in peer-type
+	// WARNING: in.MachineDefaults requires manual conversion: does not exist in peer-type
+	// WARNING: in.RemediationBudget requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -250,6 +252,9 @@ func autoConvert_v1alpha2_MetalClusterStatus_To_v1alpha3_MetalClusterStatus(in *
 
 func autoConvert_v1alpha3_MetalClusterStatus_To_v1alpha2_MetalClusterStatus(in *v1alpha3.MetalClusterStatus, out *MetalClusterStatus, s conversion.Scope) error {
 	out.Ready = in.Ready
+	// WARNING: in.PowerState requires manual conversion: does not exist in peer-type
+	// WARNING: in.RemediationActions requires manual conversion: does not exist in peer-type
+	// WARNING: in.Conditions requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -337,6 +342,11 @@ func autoConvert_v1alpha3_MetalMachineSpec_To_v1alpha2_MetalMachineSpec(in *v1al
 	out.ProviderID = (*string)(unsafe.Pointer(in.ProviderID))
 	out.ServerRef = (*v1.ObjectReference)(unsafe.Pointer(in.ServerRef))
 	// WARNING: in.ServerClassRef requires manual conversion: does not exist in peer-type
+	// WARNING: in.ConfigPatchSets requires manual conversion: does not exist in peer-type
+	// WARNING: in.ProvisioningTimeout requires manual conversion: does not exist in peer-type
+	// WARNING: in.ProvisioningRetries requires manual conversion: does not exist in peer-type
+	// WARNING: in.ReadinessGate requires manual conversion: does not exist in peer-type
+	// WARNING: in.TalosHealthCheck requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -351,6 +361,10 @@ func autoConvert_v1alpha3_MetalMachineStatus_To_v1alpha2_MetalMachineStatus(in *
 	out.Ready = in.Ready
 	// WARNING: in.FailureReason requires manual conversion: does not exist in peer-type
 	// WARNING: in.FailureMessage requires manual conversion: does not exist in peer-type
+	// WARNING: in.ProvisioningStartTime requires manual conversion: does not exist in peer-type
+	// WARNING: in.ProvisioningTimeouts requires manual conversion: does not exist in peer-type
+	// WARNING: in.FailedServers requires manual conversion: does not exist in peer-type
+	// WARNING: in.Conditions requires manual conversion: does not exist in peer-type
 	return nil
 }
 
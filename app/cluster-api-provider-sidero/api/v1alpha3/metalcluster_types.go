@@ -5,6 +5,9 @@
 package v1alpha3
 
 import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 )
@@ -14,22 +17,173 @@ const (
 	ClusterFinalizer = "metalcluster.infrastructure.cluster.x-k8s.io"
 )
 
+// PowerOffAnnotation requests all the cluster servers to be shut down, e.g. for the planned power event.
+//
+// Worker servers are shut down first, control plane servers are shut down once all the workers are powered off.
+// Removing the annotation powers the servers back on in the reverse order.
+const PowerOffAnnotation = "metal.sidero.dev/power-off"
+
+// Cluster power states.
+const (
+	PowerStatePoweringOff = "PoweringOff"
+	PowerStateOff         = "Off"
+	PowerStatePoweringOn  = "PoweringOn"
+)
+
+const (
+	// RemediationAllowedCondition is set to False when the remediation budget of the cluster is exhausted,
+	// and the automated remediation actions are paused.
+	RemediationAllowedCondition capiv1.ConditionType = "RemediationAllowed"
+
+	// RemediationBudgetExhaustedReason is used when the cluster had RemediationBudget.MaxActions remediation actions within the window.
+	RemediationBudgetExhaustedReason = "RemediationBudgetExhausted"
+)
+
+// Remediation actions.
+const (
+	// RemediationActionReprovision releases the server the machine failed to be provisioned on, and provisions it on another server.
+	RemediationActionReprovision = "Reprovision"
+	// RemediationActionReallocate marks the machine allocated the server which no longer matches its serverclass for the remediation.
+	RemediationActionReallocate = "Reallocate"
+	// RemediationActionPowerCycle power cycles the server again after the previous power cycle didn't bring it up (e.g. to retry the wipe).
+	RemediationActionPowerCycle = "PowerCycle"
+)
+
+// DefaultRemediationWindow is the default window of the remediation budget.
+const DefaultRemediationWindow = time.Hour
+
+// RemediationBudget limits the automated remediation actions in the cluster.
+//
+// Many remediations within a short time likely mean an infrastructure-wide issue (e.g. a network outage),
+// which is not fixed by churning more hardware.
+type RemediationBudget struct {
+	// MaxActions is the maximum number of the remediation actions within the window, further actions are paused.
+	// +kubebuilder:validation:Minimum=1
+	MaxActions int `json:"maxActions"`
+
+	// Window is the rolling window the remediation actions are counted in, defaults to 1h.
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+}
+
+// GetWindow returns the window of the budget, or the default window if not set.
+func (b *RemediationBudget) GetWindow() time.Duration {
+	if b.Window == nil {
+		return DefaultRemediationWindow
+	}
+
+	return b.Window.Duration
+}
+
+// RemediationAction records the automated remediation action in the cluster.
+type RemediationAction struct {
+	// Time is the time of the action.
+	Time metav1.Time `json:"time"`
+	// Action is the remediation action, e.g. "Reprovision".
+	Action string `json:"action"`
+	// Machine is the name of the machine remediated.
+	Machine string `json:"machine"`
+	// Server is the name of the server released or replaced.
+	// +optional
+	Server string `json:"server,omitempty"`
+}
+
+// RegistryMirror defines the endpoints used to pull images from the registry.
+type RegistryMirror struct {
+	// Endpoints lists mirror endpoints for the registry.
+	Endpoints []string `json:"endpoints"`
+}
+
+// RegistryConfig defines the credentials and the TLS settings used to pull images from the registry.
+type RegistryConfig struct {
+	// AuthSecretRef references the Secret in the MetalCluster namespace with the registry credentials.
+	//
+	// The Secret either has the "username" and "password" keys, or it's the image pull Secret
+	// (the "kubernetes.io/dockerconfigjson" type) with the entry of the registry.
+	// +optional
+	AuthSecretRef *corev1.LocalObjectReference `json:"authSecretRef,omitempty"`
+
+	// CASecretRef references the Secret in the MetalCluster namespace with the PEM encoded CA certificate of the registry in the "ca.crt" key.
+	// +optional
+	CASecretRef *corev1.LocalObjectReference `json:"caSecretRef,omitempty"`
+
+	// InsecureSkipVerify disables the verification of the registry TLS certificate.
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// MachineDefaults defines the settings rendered into machine configs of all the cluster machines.
+type MachineDefaults struct {
+	// TimeServers lists NTP servers.
+	// +optional
+	TimeServers []string `json:"timeServers,omitempty"`
+
+	// Nameservers lists DNS servers.
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// RegistryMirrors maps registry hostnames (e.g. "docker.io") to the mirrors.
+	// +optional
+	RegistryMirrors map[string]RegistryMirror `json:"registryMirrors,omitempty"`
+
+	// RegistryConfigs maps registry hostnames (e.g. "registry.example.com:5000") to the credentials and the TLS settings.
+	//
+	// Mirror endpoints are configured by the hostnames as well.
+	// +optional
+	RegistryConfigs map[string]RegistryConfig `json:"registryConfigs,omitempty"`
+
+	// PersistentIdentity enables the per-server identity (WireGuard keys) persisted in Secrets.
+	//
+	// Identity is generated once per server of the cluster, so that the machines rebuilt on the server keep it.
+	// Only the WireGuard keys are persisted: Talos runs no SSH daemon, and the node certificates are issued from the cluster CA on each boot.
+	// +optional
+	PersistentIdentity bool `json:"persistentIdentity,omitempty"`
+}
+
 // MetalClusterSpec defines the desired state of MetalCluster.
 type MetalClusterSpec struct {
 	// ControlPlaneEndpoint represents the endpoint used to communicate with the control plane.
 	// +optional
 	ControlPlaneEndpoint capiv1.APIEndpoint `json:"controlPlaneEndpoint"`
+
+	// MachineDefaults are applied to machine configs of all the cluster machines.
+	//
+	// ServerClass and Server config patches are applied on top of the defaults.
+	// +optional
+	MachineDefaults *MachineDefaults `json:"machineDefaults,omitempty"`
+
+	// RemediationBudget pauses the automated remediation actions (reprovisioning of the machines which fail to be provisioned,
+	// and the reallocation of the mismatched servers) once the cluster had too many of them within the window.
+	//
+	// If not set, the remediation actions are not limited.
+	// +optional
+	RemediationBudget *RemediationBudget `json:"remediationBudget,omitempty"`
 }
 
 // MetalClusterStatus defines the observed state of MetalCluster.
 type MetalClusterStatus struct {
 	Ready bool `json:"ready"`
+
+	// PowerState reports the progress of the cluster power off requested with PowerOffAnnotation.
+	//
+	// Empty value means the cluster servers are not managed by the cluster power off.
+	// +optional
+	PowerState string `json:"powerState,omitempty"`
+
+	// RemediationActions lists the remediation actions within the window of the remediation budget.
+	// +optional
+	RemediationActions []RemediationAction `json:"remediationActions,omitempty"`
+
+	// Conditions defines current service state of the MetalCluster.
+	// +optional
+	Conditions capiv1.Conditions `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=metalclusters,scope=Namespaced,categories=cluster-api
 // +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".metadata.labels.cluster\\.x-k8s\\.io/cluster-name",description="Cluster to which this MetalCluster belongs"
 // +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Power",type="string",priority=1,JSONPath=".status.powerState",description="Cluster power state"
 // +kubebuilder:printcolumn:name="Endpoint",type="string",priority=1,JSONPath=".spec.controlPlaneEndpoint.host",description="Control Plane Endpoint"
 // +kubebuilder:storageversion
 // +kubebuilder:subresource:status
@@ -52,6 +206,14 @@ type MetalClusterList struct {
 	Items           []MetalCluster `json:"items"`
 }
 
+func (c *MetalCluster) GetConditions() capiv1.Conditions {
+	return c.Status.Conditions
+}
+
+func (c *MetalCluster) SetConditions(conditions capiv1.Conditions) {
+	c.Status.Conditions = conditions
+}
+
 func init() {
 	SchemeBuilder.Register(&MetalCluster{}, &MetalClusterList{})
 }
@@ -7,6 +7,7 @@ package v1alpha3
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/errors"
 )
 
@@ -17,6 +18,61 @@ const (
 	MetalMachineServerRefField = "spec.serverRef.name"
 )
 
+const (
+	// BootstrapDataReadyCondition is set by the metadata server when the machine fetches its configuration.
+	//
+	// Condition is False if the bootstrap data is not available yet, if it's stale (doesn't belong to the machine bootstrap config),
+	// or if the machine configuration fails to render.
+	BootstrapDataReadyCondition capiv1.ConditionType = "BootstrapDataReady"
+
+	// WaitingForBootstrapDataReason is used when the bootstrap provider hasn't generated the bootstrap data yet.
+	WaitingForBootstrapDataReason = "WaitingForBootstrapData"
+	// BootstrapSecretMissingReason is used when the bootstrap data secret referenced by the machine doesn't exist.
+	BootstrapSecretMissingReason = "BootstrapSecretMissing"
+	// BootstrapSecretStaleReason is used when the bootstrap data secret was generated for another bootstrap config.
+	BootstrapSecretStaleReason = "BootstrapSecretStale"
+	// ConfigRenderErrorReason is used when the machine configuration can't be rendered from the bootstrap data
+	// (e.g. config patch doesn't apply).
+	ConfigRenderErrorReason = "ConfigRenderError"
+
+	// ServerAllocatedCondition is set to True when the server is allocated to the machine.
+	ServerAllocatedCondition capiv1.ConditionType = "ServerAllocated"
+
+	// NoMatchingServersReason is used when there are no available servers matching the ServerClass of the machine.
+	NoMatchingServersReason = "NoMatchingServers"
+	// ProvisioningRateLimitedReason is used when the ServerClass of the machine has MaxConcurrentProvisions servers being provisioned.
+	ProvisioningRateLimitedReason = "ProvisioningRateLimited"
+
+	// ReadinessGatePassedCondition is set to True when the readiness gate of the machine accepts the server.
+	ReadinessGatePassedCondition capiv1.ConditionType = "ReadinessGatePassed"
+
+	// ReadinessGateFailedReason is used when the readiness gate rejects the server or can't be reached.
+	ReadinessGateFailedReason = "ReadinessGateFailed"
+
+	// TalosHealthyCondition is set to True when the Talos services of the node are healthy.
+	//
+	// Condition is only set for the machines with the Talos health check enabled, once the node joins the cluster.
+	TalosHealthyCondition capiv1.ConditionType = "TalosHealthy"
+
+	// TalosUnreachableReason is used when the Talos API of the node can't be reached.
+	TalosUnreachableReason = "TalosUnreachable"
+	// TalosServicesUnhealthyReason is used when some of the Talos services of the node are not running or not healthy.
+	TalosServicesUnhealthyReason = "TalosServicesUnhealthy"
+)
+
+const (
+	// OrdinalLabel is set on the metal machines allocated with the Ordinal allocation strategy, the value is the ordinal of the machine
+	// within its control plane or MachineDeployment.
+	//
+	// The label is copied to the ServerBinding and registered as the node label.
+	OrdinalLabel = "metal.sidero.dev/ordinal"
+	// OrdinalAnnotation is set on the servers allocated with the Ordinal allocation strategy, so that the ordinal gets the same server back.
+	//
+	// The value is "<namespace>/<cluster>/<group>/<ordinal>", where the group is the MachineDeployment name or "control-plane".
+	// The annotation is kept when the server is released.
+	OrdinalAnnotation = "metal.sidero.dev/ordinal"
+)
+
 // MetalMachineSpec defines the desired state of MetalMachine.
 type MetalMachineSpec struct {
 	// ProviderID is the unique identifier as specified by the cloud provider.
@@ -25,6 +81,64 @@ type MetalMachineSpec struct {
 
 	ServerRef      *corev1.ObjectReference `json:"serverRef,omitempty"`
 	ServerClassRef *corev1.ObjectReference `json:"serverClassRef,omitempty"`
+
+	// ConfigPatchSets lists names of the ConfigPatchSets applied to the machine configuration
+	// after the patches of the ServerClass and the Server.
+	// +optional
+	ConfigPatchSets []string `json:"configPatchSets,omitempty"`
+
+	// ProvisioningTimeout is the deadline for the node to join the cluster after the server is allocated.
+	//
+	// If the node doesn't join the cluster in time, the server is released (and wiped), and another server
+	// is allocated from the ServerClass up to ProvisioningRetries times, after that the machine is marked as failed.
+	// If not set, the machine waits for the node to join indefinitely.
+	// +optional
+	ProvisioningTimeout *metav1.Duration `json:"provisioningTimeout,omitempty"`
+
+	// ProvisioningRetries is the number of times the provisioning is retried on another server after the timeout.
+	// +optional
+	ProvisioningRetries int `json:"provisioningRetries,omitempty"`
+
+	// ReadinessGate is the user-provided validation of the server run once the node joins the cluster.
+	//
+	// MetalMachine is not reported Ready until the readiness gate accepts the server.
+	// +optional
+	ReadinessGate *ReadinessGate `json:"readinessGate,omitempty"`
+
+	// TalosHealthCheck enables the periodic probing of the Talos services once the node joins the cluster.
+	//
+	// Results are recorded in the TalosHealthy condition, the machine readiness is not affected.
+	// +optional
+	TalosHealthCheck *TalosHealthCheck `json:"talosHealthCheck,omitempty"`
+}
+
+// ReadinessGate describes the webhook which validates the server before the machine is reported Ready.
+//
+// The webhook is called with the POST request describing the machine, it should respond with the 200 status code
+// to accept the server, any other response rejects it, and the response body is reported in the condition message.
+type ReadinessGate struct {
+	// URL of the webhook.
+	URL string `json:"url"`
+
+	// Timeout of the webhook request, defaults to 10 seconds.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// TalosHealthCheck describes the probing of the Talos API of the node.
+//
+// Talos API is accessed with the talosconfig generated by the bootstrap provider of the machine,
+// on the address of the node reported by the machine.
+type TalosHealthCheck struct {
+	// Services lists the services which should be running and healthy.
+	//
+	// If not set, every service reported by Talos is checked to be neither failed nor unhealthy.
+	// +optional
+	Services []string `json:"services,omitempty"`
+
+	// Interval between the probes, defaults to 1 minute.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
 }
 
 // MetalMachineStatus defines the observed state of MetalMachine.
@@ -68,6 +182,22 @@ type MetalMachineStatus struct {
 	// controller's output.
 	// +optional
 	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// ProvisioningStartTime is the time the server was allocated to the machine, it's reset once the node joins the cluster.
+	// +optional
+	ProvisioningStartTime *metav1.Time `json:"provisioningStartTime,omitempty"`
+
+	// ProvisioningTimeouts is the number of provisioning attempts which timed out.
+	// +optional
+	ProvisioningTimeouts int `json:"provisioningTimeouts,omitempty"`
+
+	// FailedServers lists the servers the provisioning timed out on, they are not allocated to the machine again.
+	// +optional
+	FailedServers []string `json:"failedServers,omitempty"`
+
+	// Conditions defines current service state of the MetalMachine.
+	// +optional
+	Conditions capiv1.Conditions `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -97,6 +227,14 @@ type MetalMachineList struct {
 	Items           []MetalMachine `json:"items"`
 }
 
+func (m *MetalMachine) GetConditions() capiv1.Conditions {
+	return m.Status.Conditions
+}
+
+func (m *MetalMachine) SetConditions(conditions capiv1.Conditions) {
+	m.Status.Conditions = conditions
+}
+
 func init() {
 	SchemeBuilder.Register(&MetalMachine{}, &MetalMachineList{})
 }
@@ -7,11 +7,26 @@ package v1alpha3
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 )
 
 // ServerBindingMetalMachineRefField is a reference to a field matching server binding to a metal machine.
 const ServerBindingMetalMachineRefField = "spec.metalMachineRef.name"
 
+// UpgradeInProgressAnnotation is set on the Machine while Talos on the machine is being upgraded.
+//
+// The annotation is mirrored to the ServerBinding, and the server always boots from disk while the annotation is set,
+// so that the reboots during the upgrade don't provision the server from the network again.
+const UpgradeInProgressAnnotation = "metal.sidero.dev/upgrade-in-progress"
+
+const (
+	// ServerClassMatchedCondition is set to False when the server no longer matches the ServerClass it was allocated from.
+	ServerClassMatchedCondition capiv1.ConditionType = "ServerClassMatched"
+
+	// ServerClassMismatchReason (Severity=Warning) documents the server which no longer matches the ServerClass.
+	ServerClassMismatchReason = "ServerClassMismatch"
+)
+
 // ServerBindingSpec defines the spec of the ServerBinding object.
 type ServerBindingSpec struct {
 	ServerClassRef  *corev1.ObjectReference `json:"serverClassRef,omitempty"`
@@ -23,6 +38,10 @@ type ServerBindingState struct {
 	// Ready is true when matching server is found.
 	// +optional
 	Ready bool `json:"ready"`
+
+	// Conditions defines current service state of the ServerBinding.
+	// +optional
+	Conditions capiv1.Conditions `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -56,6 +75,14 @@ type ServerBindingList struct {
 	Items           []ServerBinding `json:"items"`
 }
 
+func (b *ServerBinding) GetConditions() capiv1.Conditions {
+	return b.Status.Conditions
+}
+
+func (b *ServerBinding) SetConditions(conditions capiv1.Conditions) {
+	b.Status.Conditions = conditions
+}
+
 func init() {
 	SchemeBuilder.Register(&ServerBinding{}, &ServerBindingList{})
 }
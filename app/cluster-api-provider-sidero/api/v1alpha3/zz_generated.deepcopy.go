@@ -10,17 +10,58 @@ package v1alpha3
 
 import (
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	v1alpha3 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/errors"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineDefaults) DeepCopyInto(out *MachineDefaults) {
+	*out = *in
+	if in.TimeServers != nil {
+		in, out := &in.TimeServers, &out.TimeServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RegistryMirrors != nil {
+		in, out := &in.RegistryMirrors, &out.RegistryMirrors
+		*out = make(map[string]RegistryMirror, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.RegistryConfigs != nil {
+		in, out := &in.RegistryConfigs, &out.RegistryConfigs
+		*out = make(map[string]RegistryConfig, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineDefaults.
+func (in *MachineDefaults) DeepCopy() *MachineDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetalCluster) DeepCopyInto(out *MetalCluster) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetalCluster.
@@ -77,6 +118,16 @@ func (in *MetalClusterList) DeepCopyObject() runtime.Object {
 func (in *MetalClusterSpec) DeepCopyInto(out *MetalClusterSpec) {
 	*out = *in
 	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
+	if in.MachineDefaults != nil {
+		in, out := &in.MachineDefaults, &out.MachineDefaults
+		*out = new(MachineDefaults)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RemediationBudget != nil {
+		in, out := &in.RemediationBudget, &out.RemediationBudget
+		*out = new(RemediationBudget)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetalClusterSpec.
@@ -92,6 +143,20 @@ func (in *MetalClusterSpec) DeepCopy() *MetalClusterSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetalClusterStatus) DeepCopyInto(out *MetalClusterStatus) {
 	*out = *in
+	if in.RemediationActions != nil {
+		in, out := &in.RemediationActions, &out.RemediationActions
+		*out = make([]RemediationAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1alpha3.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetalClusterStatus.
@@ -181,6 +246,26 @@ func (in *MetalMachineSpec) DeepCopyInto(out *MetalMachineSpec) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.ConfigPatchSets != nil {
+		in, out := &in.ConfigPatchSets, &out.ConfigPatchSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ProvisioningTimeout != nil {
+		in, out := &in.ProvisioningTimeout, &out.ProvisioningTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ReadinessGate != nil {
+		in, out := &in.ReadinessGate, &out.ReadinessGate
+		*out = new(ReadinessGate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TalosHealthCheck != nil {
+		in, out := &in.TalosHealthCheck, &out.TalosHealthCheck
+		*out = new(TalosHealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetalMachineSpec.
@@ -206,6 +291,22 @@ func (in *MetalMachineStatus) DeepCopyInto(out *MetalMachineStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ProvisioningStartTime != nil {
+		in, out := &in.ProvisioningStartTime, &out.ProvisioningStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FailedServers != nil {
+		in, out := &in.FailedServers, &out.FailedServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1alpha3.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetalMachineStatus.
@@ -324,13 +425,114 @@ func (in *MetalMachineTemplateStatus) DeepCopy() *MetalMachineTemplateStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessGate) DeepCopyInto(out *ReadinessGate) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessGate.
+func (in *ReadinessGate) DeepCopy() *ReadinessGate {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryConfig) DeepCopyInto(out *RegistryConfig) {
+	*out = *in
+	if in.AuthSecretRef != nil {
+		in, out := &in.AuthSecretRef, &out.AuthSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.CASecretRef != nil {
+		in, out := &in.CASecretRef, &out.CASecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryConfig.
+func (in *RegistryConfig) DeepCopy() *RegistryConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryMirror) DeepCopyInto(out *RegistryMirror) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryMirror.
+func (in *RegistryMirror) DeepCopy() *RegistryMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemediationAction) DeepCopyInto(out *RemediationAction) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationAction.
+func (in *RemediationAction) DeepCopy() *RemediationAction {
+	if in == nil {
+		return nil
+	}
+	out := new(RemediationAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemediationBudget) DeepCopyInto(out *RemediationBudget) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationBudget.
+func (in *RemediationBudget) DeepCopy() *RemediationBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(RemediationBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServerBinding) DeepCopyInto(out *ServerBinding) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerBinding.
@@ -407,6 +609,13 @@ func (in *ServerBindingSpec) DeepCopy() *ServerBindingSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServerBindingState) DeepCopyInto(out *ServerBindingState) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1alpha3.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerBindingState.
@@ -418,3 +627,28 @@ func (in *ServerBindingState) DeepCopy() *ServerBindingState {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TalosHealthCheck) DeepCopyInto(out *TalosHealthCheck) {
+	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TalosHealthCheck.
+func (in *TalosHealthCheck) DeepCopy() *TalosHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(TalosHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
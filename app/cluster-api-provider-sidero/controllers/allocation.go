@@ -0,0 +1,61 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// provisioningServers counts the servers of the serverclass being provisioned: allocated to the machines
+// which don't have a node yet.
+//
+// Server bindings are listed bypassing the cache, so that the bindings just created are counted.
+func (r *MetalMachineReconciler) provisioningServers(ctx context.Context, serverClass *metalv1alpha1.ServerClass) (int, error) {
+	var serverBindings infrav1.ServerBindingList
+
+	if err := r.APIReader.List(ctx, &serverBindings); err != nil {
+		return 0, err
+	}
+
+	provisioning := 0
+
+	for _, serverBinding := range serverBindings.Items {
+		if ref := serverBinding.Spec.ServerClassRef; ref == nil || ref.Name != serverClass.Name || !serverBinding.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		var metalMachine infrav1.MetalMachine
+
+		if err := r.Get(ctx, types.NamespacedName{Namespace: serverBinding.Spec.MetalMachineRef.Namespace, Name: serverBinding.Spec.MetalMachineRef.Name}, &metalMachine); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return 0, err
+		}
+
+		machine, err := util.GetOwnerMachine(ctx, r.Client, metalMachine.ObjectMeta)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return 0, err
+		}
+
+		if machine == nil || machine.Status.NodeRef == nil {
+			provisioning++
+		}
+	}
+
+	return provisioning, nil
+}
@@ -0,0 +1,132 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func allocationScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		corev1.AddToScheme,
+		capiv1.AddToScheme,
+		infrav1.AddToScheme,
+		metalv1alpha1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return scheme
+}
+
+// slowClient delays the list responses, see slowReader.
+type slowClient struct {
+	client.Client
+}
+
+func (c slowClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	return slowReader{c.Client}.List(ctx, list, opts...)
+}
+
+func TestMaxConcurrentProvisionsConcurrent(t *testing.T) {
+	const (
+		machines = 6
+		limit    = 2
+	)
+
+	serverClass := &metalv1alpha1.ServerClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "workers"},
+		Spec:       metalv1alpha1.ServerClassSpec{MaxConcurrentProvisions: limit},
+	}
+
+	objects := []runtime.Object{serverClass}
+
+	for i := 0; i < machines; i++ {
+		name := fmt.Sprintf("server-%d", i)
+
+		serverClass.Status.ServersAvailable = append(serverClass.Status.ServersAvailable, name)
+
+		objects = append(objects,
+			&metalv1alpha1.Server{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Status:     metalv1alpha1.ServerStatus{IsClean: true},
+			},
+			&infrav1.MetalMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("worker-%d", i)},
+			},
+		)
+	}
+
+	scheme := allocationScheme(t)
+	c := fake.NewFakeClientWithScheme(scheme, objects...)
+
+	// cached reads are slow too, so that the concurrent allocations overlap without the lock
+	r := &MetalMachineReconciler{
+		Client:    slowClient{c},
+		Log:       log.NullLogger{},
+		Scheme:    scheme,
+		APIReader: slowReader{c},
+		Recorder:  record.NewFakeRecorder(machines),
+	}
+
+	ctx := context.Background()
+	classRef := &corev1.ObjectReference{Name: serverClass.Name}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		allocated int
+	)
+
+	for i := 0; i < machines; i++ {
+		metalMachine := &infrav1.MetalMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("worker-%d", i)},
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := r.fetchServerFromClass(ctx, r.Log, classRef, metalMachine, nil)
+
+			switch {
+			case err == nil:
+				mu.Lock()
+				allocated++
+				mu.Unlock()
+			case errors.Is(err, ErrProvisioningRateLimited):
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if allocated != limit {
+		t.Errorf("%d servers allocated concurrently, limit is %d", allocated, limit)
+	}
+}
@@ -0,0 +1,52 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import "sync"
+
+// keyLock serializes the concurrent reconciles working on the same key (e.g. the ordinal group).
+type keyLock struct {
+	mu    sync.Mutex
+	locks map[string]*keyLockEntry
+}
+
+type keyLockEntry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// Lock the key, returned function unlocks it.
+func (l *keyLock) Lock(key string) func() {
+	l.mu.Lock()
+
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLockEntry)
+	}
+
+	entry := l.locks[key]
+	if entry == nil {
+		entry = &keyLockEntry{}
+		l.locks[key] = entry
+	}
+
+	entry.refs++
+
+	l.mu.Unlock()
+
+	entry.mu.Lock()
+
+	return func() {
+		entry.mu.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		entry.refs--
+
+		if entry.refs == 0 {
+			delete(l.locks, key)
+		}
+	}
+}
@@ -9,10 +9,14 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -22,6 +26,7 @@ import (
 
 	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
 	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/constants"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
 )
 
 // MetalClusterReconciler reconciles a MetalCluster object.
@@ -35,6 +40,10 @@ type MetalClusterReconciler struct {
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=metalclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=metalclusters/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=metalmachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *MetalClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err error) {
 	ctx := context.TODO()
@@ -100,9 +109,183 @@ func (r *MetalClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err
 
 	metalCluster.Status.Ready = true
 
+	remediationRequeueAfter, err := r.reconcileRemediationBudget(ctx, metalCluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.reconcilePower(ctx, cluster, metalCluster)
+	if err == nil && remediationRequeueAfter > 0 && (result.RequeueAfter == 0 || remediationRequeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = remediationRequeueAfter
+	}
+
+	return result, err
+}
+
+// reconcilePower shuts down the cluster servers while the MetalCluster has PowerOffAnnotation,
+// and powers them back on once the annotation is removed.
+//
+// Servers are processed in groups: workers and then control plane on power off, in the reverse order on power on.
+// Next group is processed only when all the servers of the previous group reach the requested power state.
+func (r *MetalClusterReconciler) reconcilePower(ctx context.Context, cluster *capiv1.Cluster, metalCluster *infrav1.MetalCluster) (ctrl.Result, error) {
+	requester, powerOff := metalCluster.Annotations[infrav1.PowerOffAnnotation]
+
+	if !powerOff && metalCluster.Status.PowerState == "" {
+		return ctrl.Result{}, nil
+	}
+
+	controlPlane, workers, err := r.clusterServers(ctx, cluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	groups := [][]*metalv1alpha1.Server{workers, controlPlane}
+	if !powerOff {
+		groups = [][]*metalv1alpha1.Server{controlPlane, workers}
+	}
+
+	for _, group := range groups {
+		settled := true
+
+		for _, server := range group {
+			if err = r.requestServerPower(ctx, server, powerOff, requester); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			if !serverPowerSettled(server, powerOff) {
+				settled = false
+			}
+		}
+
+		if !settled {
+			// wait for the whole group to reach the power state before moving on to the next one
+			if powerOff {
+				metalCluster.Status.PowerState = infrav1.PowerStatePoweringOff
+			} else {
+				metalCluster.Status.PowerState = infrav1.PowerStatePoweringOn
+			}
+
+			return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+		}
+	}
+
+	switch {
+	case powerOff && metalCluster.Status.PowerState != infrav1.PowerStateOff:
+		metalCluster.Status.PowerState = infrav1.PowerStateOff
+
+		r.Recorder.Event(metalCluster, corev1.EventTypeNormal, "Cluster Power", "All cluster servers are powered off.")
+	case !powerOff:
+		metalCluster.Status.PowerState = ""
+
+		r.Recorder.Event(metalCluster, corev1.EventTypeNormal, "Cluster Power", "All cluster servers are powered on.")
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// clusterServers returns the servers allocated to the control plane and worker machines of the cluster.
+func (r *MetalClusterReconciler) clusterServers(ctx context.Context, cluster *capiv1.Cluster) (controlPlane, workers []*metalv1alpha1.Server, err error) {
+	var machineList capiv1.MachineList
+
+	if err = r.List(ctx, &machineList, client.InNamespace(cluster.Namespace), client.MatchingLabels{capiv1.ClusterLabelName: cluster.Name}); err != nil {
+		return nil, nil, err
+	}
+
+	for i := range machineList.Items {
+		machine := &machineList.Items[i]
+
+		if machine.Spec.InfrastructureRef.Kind != "MetalMachine" {
+			continue
+		}
+
+		var metalMachine infrav1.MetalMachine
+
+		if err = r.Get(ctx, types.NamespacedName{Namespace: machine.Namespace, Name: machine.Spec.InfrastructureRef.Name}, &metalMachine); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, nil, err
+		}
+
+		if metalMachine.Spec.ServerRef == nil {
+			continue
+		}
+
+		var server metalv1alpha1.Server
+
+		if err = r.Get(ctx, types.NamespacedName{Name: metalMachine.Spec.ServerRef.Name}, &server); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, nil, err
+		}
+
+		if util.IsControlPlaneMachine(machine) {
+			controlPlane = append(controlPlane, &server)
+		} else {
+			workers = append(workers, &server)
+		}
+	}
+
+	return controlPlane, workers, nil
+}
+
+// requestServerPower sets or removes the power off annotation of the server.
+//
+// Power off is requested on behalf of the user requesting the cluster power off (the MetalCluster webhook of the metal
+// controller manager records the user in the annotation), so that the user can't approve it (see --require-approval).
+func (r *MetalClusterReconciler) requestServerPower(ctx context.Context, server *metalv1alpha1.Server, powerOff bool, requester string) error {
+	if _, ok := server.Annotations[metalv1alpha1.PowerOffAnnotation]; ok == powerOff {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(server, r)
+	if err != nil {
+		return err
+	}
+
+	if powerOff {
+		if server.Annotations == nil {
+			server.Annotations = map[string]string{}
+		}
+
+		server.Annotations[metalv1alpha1.PowerOffAnnotation] = requester
+	} else {
+		delete(server.Annotations, metalv1alpha1.PowerOffAnnotation)
+	}
+
+	return patchHelper.Patch(ctx, server)
+}
+
+// serverPowerSettled checks whether the server reached the requested power state.
+func serverPowerSettled(server *metalv1alpha1.Server, powerOff bool) bool {
+	switch {
+	case server.Spec.ManualPowerManagement && powerOff:
+		// operator acknowledges the power off
+		return conditions.IsTrue(server, metalv1alpha1.ConditionManualPowerAction) &&
+			conditions.GetReason(server, metalv1alpha1.ConditionManualPowerAction) == metalv1alpha1.PowerOffRequiredReason
+	case server.Spec.ManualPowerManagement:
+		// server is off until the operator acknowledges powering it back on
+		switch conditions.GetReason(server, metalv1alpha1.ConditionManualPowerAction) {
+		case metalv1alpha1.PowerOffRequiredReason:
+			return conditions.IsFalse(server, metalv1alpha1.ConditionManualPowerAction)
+		case metalv1alpha1.PowerRestoreRequiredReason:
+			return conditions.IsTrue(server, metalv1alpha1.ConditionManualPowerAction)
+		default:
+			return true
+		}
+	case server.Spec.BMC == nil && server.Spec.ManagementAPI == nil:
+		// power of the server can't be controlled
+		return true
+	case powerOff:
+		return server.Status.Power == "off"
+	default:
+		return server.Status.Power == "on"
+	}
+}
+
 func (r *MetalClusterReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(options).
@@ -0,0 +1,160 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestReconcilePower(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{capiv1.AddToScheme, infrav1.AddToScheme, metalv1alpha1.AddToScheme} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cluster := &capiv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "management"},
+	}
+
+	objects := []runtime.Object{cluster}
+
+	for _, name := range []string{"control-plane", "worker"} {
+		labels := map[string]string{capiv1.ClusterLabelName: cluster.Name}
+
+		if name == "control-plane" {
+			labels[capiv1.MachineControlPlaneLabelName] = ""
+		}
+
+		objects = append(objects,
+			&capiv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels},
+				Spec: capiv1.MachineSpec{
+					InfrastructureRef: corev1.ObjectReference{Kind: "MetalMachine", Name: name},
+				},
+			},
+			&infrav1.MetalMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+				Spec: infrav1.MetalMachineSpec{
+					ServerRef: &corev1.ObjectReference{Kind: "Server", Name: name + "-server"},
+				},
+			},
+			&metalv1alpha1.Server{
+				ObjectMeta: metav1.ObjectMeta{Name: name + "-server"},
+				Spec: metalv1alpha1.ServerSpec{
+					BMC: &metalv1alpha1.BMC{Endpoint: "10.0.0.25"},
+				},
+				Status: metalv1alpha1.ServerStatus{Power: "on"},
+			},
+		)
+	}
+
+	ctx := context.Background()
+
+	r := &MetalClusterReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme, objects...),
+		Recorder: record.NewFakeRecorder(10),
+	}
+
+	metalCluster := &infrav1.MetalCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "management",
+			Annotations: map[string]string{infrav1.PowerOffAnnotation: "alice"},
+		},
+	}
+
+	server := func(name string) *metalv1alpha1.Server {
+		var s metalv1alpha1.Server
+
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, &s); err != nil {
+			t.Fatal(err)
+		}
+
+		return &s
+	}
+
+	setPower := func(name, power string) {
+		s := server(name)
+		s.Status.Power = power
+
+		if err := r.Update(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reconcile := func(expectedState string) {
+		if _, err := r.reconcilePower(ctx, cluster, metalCluster); err != nil {
+			t.Fatal(err)
+		}
+
+		if metalCluster.Status.PowerState != expectedState {
+			t.Fatalf("power state = %q, want %q", metalCluster.Status.PowerState, expectedState)
+		}
+	}
+
+	requester := func(name string) (string, bool) {
+		value, ok := server(name).Annotations[metalv1alpha1.PowerOffAnnotation]
+
+		return value, ok
+	}
+
+	// workers are powered off first, on behalf of the requester recorded on the MetalCluster
+	reconcile(infrav1.PowerStatePoweringOff)
+
+	if value, ok := requester("worker-server"); !ok || value != "alice" {
+		t.Fatalf("worker power off requester = %q, %v, want %q", value, ok, "alice")
+	}
+
+	if _, ok := requester("control-plane-server"); ok {
+		t.Fatal("control plane power off is requested before the workers are powered off")
+	}
+
+	setPower("worker-server", "off")
+	reconcile(infrav1.PowerStatePoweringOff)
+
+	if value, ok := requester("control-plane-server"); !ok || value != "alice" {
+		t.Fatalf("control plane power off requester = %q, %v, want %q", value, ok, "alice")
+	}
+
+	setPower("control-plane-server", "off")
+	reconcile(infrav1.PowerStateOff)
+
+	// control plane is powered on first
+	delete(metalCluster.Annotations, infrav1.PowerOffAnnotation)
+	reconcile(infrav1.PowerStatePoweringOn)
+
+	if _, ok := requester("control-plane-server"); ok {
+		t.Fatal("control plane power off is not lifted")
+	}
+
+	if _, ok := requester("worker-server"); !ok {
+		t.Fatal("worker power off is lifted before the control plane is powered on")
+	}
+
+	setPower("control-plane-server", "on")
+	reconcile(infrav1.PowerStatePoweringOn)
+
+	if _, ok := requester("worker-server"); ok {
+		t.Fatal("worker power off is not lifted")
+	}
+
+	setPower("worker-server", "on")
+	reconcile("")
+}
@@ -0,0 +1,90 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func Test_serverPowerSettled(t *testing.T) {
+	manualServer := func(status corev1.ConditionStatus, reason string) *metalv1alpha1.Server {
+		server := &metalv1alpha1.Server{
+			Spec: metalv1alpha1.ServerSpec{
+				ManualPowerManagement: true,
+			},
+		}
+
+		if status != "" {
+			server.Status.Conditions = capiv1.Conditions{
+				{
+					Type:   metalv1alpha1.ConditionManualPowerAction,
+					Status: status,
+					Reason: reason,
+				},
+			}
+		}
+
+		return server
+	}
+
+	for _, tt := range []struct {
+		name     string
+		server   *metalv1alpha1.Server
+		powerOff bool
+		expected bool
+	}{
+		{
+			name:     "power off requested",
+			server:   manualServer(corev1.ConditionFalse, metalv1alpha1.PowerOffRequiredReason),
+			powerOff: true,
+		},
+		{
+			name:     "power off acknowledged",
+			server:   manualServer(corev1.ConditionTrue, metalv1alpha1.PowerOffRequiredReason),
+			powerOff: true,
+			expected: true,
+		},
+		{
+			name:     "power restore acknowledged on power off",
+			server:   manualServer(corev1.ConditionTrue, metalv1alpha1.PowerRestoreRequiredReason),
+			powerOff: true,
+		},
+		{
+			name:   "powered off on power on",
+			server: manualServer(corev1.ConditionTrue, metalv1alpha1.PowerOffRequiredReason),
+		},
+		{
+			name:     "power off not acknowledged on power on",
+			server:   manualServer(corev1.ConditionFalse, metalv1alpha1.PowerOffRequiredReason),
+			expected: true,
+		},
+		{
+			name:   "power restore requested",
+			server: manualServer(corev1.ConditionFalse, metalv1alpha1.PowerRestoreRequiredReason),
+		},
+		{
+			name:     "power restore acknowledged",
+			server:   manualServer(corev1.ConditionTrue, metalv1alpha1.PowerRestoreRequiredReason),
+			expected: true,
+		},
+		{
+			name:     "no manual action",
+			server:   manualServer("", ""),
+			expected: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if settled := serverPowerSettled(tt.server, tt.powerOff); settled != tt.expected {
+				t.Errorf("serverPowerSettled() = %v, want %v", settled, tt.expected)
+			}
+		})
+	}
+}
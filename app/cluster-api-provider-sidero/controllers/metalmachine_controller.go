@@ -5,9 +5,16 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -22,7 +29,9 @@ import (
 	"k8s.io/client-go/tools/reference"
 	"k8s.io/utils/pointer"
 	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -30,18 +39,32 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/allocation"
 	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/constants"
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/remediation"
 	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
 )
 
 var ErrNoServersInServerClass = errors.New("no servers available in serverclass")
 
+// ErrProvisioningRateLimited is returned when the serverclass has the maximum number of the servers being provisioned.
+var ErrProvisioningRateLimited = errors.New("serverclass provisioning rate limit reached")
+
 // MetalMachineReconciler reconciles a MetalMachine object.
 type MetalMachineReconciler struct {
 	client.Client
-	Log      logr.Logger
-	Scheme   *runtime.Scheme
-	Recorder record.EventRecorder
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+
+	// NodeFeaturesInterval is the interval of importing the node feature discovery labels onto the servers, zero disables the import.
+	NodeFeaturesInterval time.Duration
+
+	// ordinalLocks serializes the ordinal assignment within the ordinal group.
+	ordinalLocks keyLock
+	// provisionLocks serializes the allocations from the serverclass with the concurrent provisions limit.
+	provisionLocks keyLock
 }
 
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=metalmachines,verbs=get;list;watch;create;update;patch;delete
@@ -49,10 +72,11 @@ type MetalMachineReconciler struct {
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclasses,verbs=get;list;watch;
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclasses/status,verbs=get;list;watch;
-// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;patch
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=talosconfigs,verbs=get;list;watch
 
 func (r *MetalMachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err error) {
 	ctx := context.Background()
@@ -130,6 +154,11 @@ func (r *MetalMachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err
 
 	controllerutil.AddFinalizer(metalMachine, infrav1.MachineFinalizer)
 
+	// Machine which failed to be provisioned is not allocated any more servers, it should be remediated instead.
+	if metalMachine.Status.FailureReason != nil {
+		return ctrl.Result{}, nil
+	}
+
 	// If server ref is already provided, server binding controller is going to reconcile matching server binding
 	// if server binding is missing, need to pick up a server
 	if metalMachine.Spec.ServerRef == nil {
@@ -137,9 +166,24 @@ func (r *MetalMachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err
 			return ctrl.Result{}, fmt.Errorf("either a server or serverclass ref must be supplied")
 		}
 
-		serverResource, err := r.fetchServerFromClass(ctx, logger, metalMachine.Spec.ServerClassRef, metalMachine)
+		serverResource, err := r.fetchServerFromClass(ctx, logger, metalMachine.Spec.ServerClassRef, metalMachine, machine)
 		if err != nil {
 			if errors.Is(err, ErrNoServersInServerClass) {
+				if conditions.GetReason(metalMachine, infrav1.ServerAllocatedCondition) != infrav1.NoMatchingServersReason {
+					r.Recorder.Event(metalMachine, corev1.EventTypeWarning, infrav1.NoMatchingServersReason,
+						fmt.Sprintf("No available servers in serverclass %q.", metalMachine.Spec.ServerClassRef.Name))
+				}
+
+				conditions.MarkFalse(metalMachine, infrav1.ServerAllocatedCondition, infrav1.NoMatchingServersReason, capiv1.ConditionSeverityWarning,
+					"No available servers in serverclass %q.", metalMachine.Spec.ServerClassRef.Name)
+
+				return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+			}
+
+			if errors.Is(err, ErrProvisioningRateLimited) {
+				conditions.MarkFalse(metalMachine, infrav1.ServerAllocatedCondition, infrav1.ProvisioningRateLimitedReason, capiv1.ConditionSeverityInfo,
+					"Serverclass %q has the maximum number of servers being provisioned.", metalMachine.Spec.ServerClassRef.Name)
+
 				return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
 			}
 
@@ -152,8 +196,22 @@ func (r *MetalMachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err
 		}
 	}
 
-	// Set the providerID, as its required in upstream capi for machine lifecycle
-	metalMachine.Spec.ProviderID = pointer.StringPtr(fmt.Sprintf("%s://%s", constants.ProviderID, metalMachine.Spec.ServerRef.Name))
+	conditions.MarkTrue(metalMachine, infrav1.ServerAllocatedCondition)
+
+	released, err := r.reconcileProvisioningTimeout(ctx, logger, cluster, metalMachine, machine)
+	if errors.Is(err, ErrRemediationPaused) {
+		logger.Info("provisioning timed out, but remediation is paused for the cluster")
+
+		return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+	}
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if released {
+		return ctrl.Result{Requeue: true}, nil
+	}
 
 	err = r.patchProviderID(ctx, cluster, metalMachine)
 	if err != nil {
@@ -162,9 +220,37 @@ func (r *MetalMachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err
 		return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
 	}
 
+	if metalMachine.Spec.ReadinessGate != nil && !conditions.IsTrue(metalMachine, infrav1.ReadinessGatePassedCondition) {
+		if !r.checkReadinessGate(ctx, cluster, machine, metalMachine) {
+			metalMachine.Status.Ready = false
+
+			return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+		}
+	}
+
+	// Set the providerID, as its required in upstream capi for machine lifecycle.
+	// CAPI copies it to the Machine once the MetalMachine is ready, and it can't be changed afterwards,
+	// so it's set only once the server is not going to be released on the provisioning timeout.
+	metalMachine.Spec.ProviderID = pointer.StringPtr(providerID(metalMachine.Spec.ServerRef.Name))
 	metalMachine.Status.Ready = true
 
-	return ctrl.Result{}, nil
+	var requeueAfter time.Duration
+
+	if r.NodeFeaturesInterval > 0 && machine.Status.NodeRef != nil {
+		if err = r.importNodeFeatures(ctx, cluster, machine, metalMachine); err != nil {
+			logger.Info("Failed to import node features", "error", err)
+		}
+
+		requeueAfter = r.NodeFeaturesInterval
+	}
+
+	if metalMachine.Spec.TalosHealthCheck != nil && machine.Status.NodeRef != nil {
+		if interval := r.checkTalosHealth(ctx, machine, metalMachine); requeueAfter == 0 || interval < requeueAfter {
+			requeueAfter = interval
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func (r *MetalMachineReconciler) reconcileDelete(ctx context.Context, metalMachine *infrav1.MetalMachine) (ctrl.Result, error) {
@@ -188,6 +274,87 @@ func (r *MetalMachineReconciler) reconcileDelete(ctx context.Context, metalMachi
 	return ctrl.Result{}, nil
 }
 
+// reconcileProvisioningTimeout releases the server if the node doesn't join the cluster within the provisioning timeout.
+//
+// Server is wiped once it's released, and the machine is either allocated another server from the ServerClass,
+// or it's marked as failed if the retries are exhausted.
+// Server is not released while the remediation budget of the cluster is exhausted, or once the provider ID is set.
+func (r *MetalMachineReconciler) reconcileProvisioningTimeout(ctx context.Context, logger logr.Logger, cluster *capiv1.Cluster, metalMachine *infrav1.MetalMachine, machine *capiv1.Machine) (bool, error) {
+	if metalMachine.Spec.ProvisioningTimeout == nil {
+		return false, nil
+	}
+
+	// provider ID (which can't be changed) is set once the machine is ready, so the server is never released afterwards
+	if machine.Status.NodeRef != nil || metalMachine.Spec.ProviderID != nil {
+		metalMachine.Status.ProvisioningStartTime = nil
+
+		return false, nil
+	}
+
+	if metalMachine.Status.ProvisioningStartTime == nil {
+		now := metav1.Now()
+		metalMachine.Status.ProvisioningStartTime = &now
+
+		return false, nil
+	}
+
+	if time.Since(metalMachine.Status.ProvisioningStartTime.Time) < metalMachine.Spec.ProvisioningTimeout.Duration {
+		return false, nil
+	}
+
+	serverName := metalMachine.Spec.ServerRef.Name
+
+	allowed, err := remediation.Allow(ctx, r.Client, r.Recorder, cluster, infrav1.RemediationActionReprovision, metalMachine.Name, serverName)
+	if err != nil {
+		return false, err
+	}
+
+	if !allowed {
+		return false, ErrRemediationPaused
+	}
+
+	var serverBinding infrav1.ServerBinding
+
+	err = r.Get(ctx, types.NamespacedName{Namespace: metalMachine.Spec.ServerRef.Namespace, Name: serverName}, &serverBinding)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, err
+	}
+
+	if err == nil && serverBinding.Spec.MetalMachineRef.Namespace == metalMachine.Namespace && serverBinding.Spec.MetalMachineRef.Name == metalMachine.Name {
+		if err = r.Delete(ctx, &serverBinding); err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+	}
+
+	metalMachine.Spec.ServerRef = nil
+	metalMachine.Status.Ready = false
+	metalMachine.Status.ProvisioningStartTime = nil
+	metalMachine.Status.ProvisioningTimeouts++
+	metalMachine.Status.FailedServers = append(metalMachine.Status.FailedServers, serverName)
+
+	conditions.Delete(metalMachine, infrav1.ReadinessGatePassedCondition)
+	conditions.Delete(metalMachine, infrav1.TalosHealthyCondition)
+
+	logger.Info("provisioning timed out, server released", "server", serverName, "timeouts", metalMachine.Status.ProvisioningTimeouts)
+
+	if metalMachine.Spec.ServerClassRef == nil || metalMachine.Status.ProvisioningTimeouts > metalMachine.Spec.ProvisioningRetries {
+		failureReason := capierrors.CreateMachineError
+		failureMessage := fmt.Sprintf("node didn't join the cluster within %s, provisioning timed out %d time(s)", metalMachine.Spec.ProvisioningTimeout.Duration, metalMachine.Status.ProvisioningTimeouts)
+
+		metalMachine.Status.FailureReason = &failureReason
+		metalMachine.Status.FailureMessage = &failureMessage
+
+		r.Recorder.Event(metalMachine, corev1.EventTypeWarning, "Provisioning Timeout", fmt.Sprintf("Provisioning timed out on server %q, machine is marked as failed.", serverName))
+
+		return true, nil
+	}
+
+	r.Recorder.Event(metalMachine, corev1.EventTypeWarning, "Provisioning Timeout",
+		fmt.Sprintf("Provisioning timed out on server %q, retrying on another server (retry %d of %d).", serverName, metalMachine.Status.ProvisioningTimeouts, metalMachine.Spec.ProvisioningRetries))
+
+	return true, nil
+}
+
 func (r *MetalMachineReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &infrav1.ServerBinding{}, infrav1.ServerBindingMetalMachineRefField, func(rawObj runtime.Object) []string {
 		serverBinding := rawObj.(*infrav1.ServerBinding)
@@ -203,7 +370,7 @@ func (r *MetalMachineReconciler) SetupWithManager(mgr ctrl.Manager, options cont
 		Complete(r)
 }
 
-func (r *MetalMachineReconciler) fetchServerFromClass(ctx context.Context, logger logr.Logger, classRef *corev1.ObjectReference, metalMachine *infrav1.MetalMachine) (*metalv1alpha1.Server, error) {
+func (r *MetalMachineReconciler) fetchServerFromClass(ctx context.Context, logger logr.Logger, classRef *corev1.ObjectReference, metalMachine *infrav1.MetalMachine, machine *capiv1.Machine) (*metalv1alpha1.Server, error) {
 	// First, check if there is already existing serverBinding for this metalmachine
 	var serverBindingList infrav1.ServerBindingList
 
@@ -212,6 +379,11 @@ func (r *MetalMachineReconciler) fetchServerFromClass(ctx context.Context, logge
 	}
 
 	for _, serverBinding := range serverBindingList.Items {
+		// server binding of the server which timed out might be still around
+		if !serverBinding.DeletionTimestamp.IsZero() || isFailedServer(metalMachine, serverBinding.Name) {
+			continue
+		}
+
 		if serverBinding.Spec.MetalMachineRef.Namespace == metalMachine.Namespace && serverBinding.Spec.MetalMachineRef.Name == metalMachine.Name {
 			// found existing serverBinding for this metalMachine
 			var server metalv1alpha1.Server
@@ -236,29 +408,61 @@ func (r *MetalMachineReconciler) fetchServerFromClass(ctx context.Context, logge
 		return nil, ErrNoServersInServerClass
 	}
 
-	// Fetch server from available list
-	// NB: we added this loop to double check that an available server isn't "in use" because
-	//     we saw raciness between server selection and it being removed from the ServersAvailable list.
-	for _, availServer := range serverClassResource.Status.ServersAvailable {
-		serverObj := &metalv1alpha1.Server{}
+	if limit := serverClassResource.Spec.MaxConcurrentProvisions; limit > 0 {
+		// servers are counted and bound under the lock, so that the concurrent allocations don't exceed the limit
+		unlock := r.provisionLocks.Lock(serverClassResource.Name)
+		defer unlock()
 
-		namespacedName := types.NamespacedName{
-			Namespace: "",
-			Name:      availServer,
+		provisioning, err := r.provisioningServers(ctx, serverClassResource)
+		if err != nil {
+			return nil, err
 		}
 
-		if err := r.Get(ctx, namespacedName, serverObj); err != nil {
+		if provisioning >= limit {
+			logger.Info("serverclass provisioning rate limit reached", "serverclass", serverClassResource.Name, "provisioning", provisioning, "limit", limit)
+
+			return nil, ErrProvisioningRateLimited
+		}
+	}
+
+	freeServers, _, err := allocation.FreeServers(ctx, r.Client, serverClassResource, func(name string) bool {
+		// provisioning already timed out on the server, try another one
+		return isFailedServer(metalMachine, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = allocation.Order(ctx, r.Client, serverClassResource, metalMachine, freeServers); err != nil {
+		return nil, err
+	}
+
+	if serverClassResource.Spec.AllocationStrategy == metalv1alpha1.AllocationStrategyOrdinal {
+		// the server allocated to the ordinal before is preferred even if the higher priority serverclasses offer it
+		key, ok, err := r.assignOrdinal(ctx, metalMachine)
+		if err != nil {
 			return nil, err
 		}
 
-		if serverObj.Status.InUse {
-			continue
+		if ok {
+			orderByOrdinal(freeServers, key)
 		}
+	}
 
-		if !serverObj.Status.IsClean {
-			continue
+	if spares := serverClassResource.Spec.RemediationSpares; spares > 0 && len(freeServers) <= spares {
+		remediation, err := r.isRemediationReplacement(ctx, machine)
+		if err != nil {
+			return nil, err
 		}
 
+		if !remediation {
+			logger.Info("remaining servers are reserved for remediation", "serverclass", serverClassResource.Name, "free", len(freeServers), "spares", spares)
+
+			return nil, ErrNoServersInServerClass
+		}
+	}
+
+	for _, serverObj := range freeServers {
 		if err := r.createServerBinding(ctx, serverClassResource, serverObj, metalMachine); err != nil {
 			// the server we picked was updated by another metalmachine before we finished.
 			// move on to the next one.
@@ -271,13 +475,152 @@ func (r *MetalMachineReconciler) fetchServerFromClass(ctx context.Context, logge
 
 		logger.Info("allocated new server", "metalmachine", metalMachine.Name, "server", serverObj.Name, "serverclass", serverClassResource.Name)
 
+		if serverClassResource.Spec.AllocationStrategy == metalv1alpha1.AllocationStrategyOrdinal {
+			// the server is already allocated, failing to reserve it only affects the next allocation of the ordinal
+			if err := r.reserveOrdinal(ctx, serverObj, metalMachine); err != nil {
+				logger.Error(err, "failed to reserve server for the machine ordinal", "server", serverObj.Name)
+			}
+		}
+
 		return serverObj, nil
 	}
 
 	return nil, ErrNoServersInServerClass
 }
 
-func (r *MetalMachineReconciler) patchProviderID(ctx context.Context, cluster *capiv1.Cluster, metalMachine *infrav1.MetalMachine) error {
+// isFailedServer checks whether the provisioning of the machine timed out on the server before.
+func isFailedServer(metalMachine *infrav1.MetalMachine, name string) bool {
+	for _, failed := range metalMachine.Status.FailedServers {
+		if failed == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isRemediationReplacement checks whether the machine replaces a machine remediated by MachineHealthCheck.
+//
+// Remediated machine is still around (being deleted) when its owner creates the replacement,
+// so the machine is considered to be a replacement if any other machine of the same owner is being remediated.
+func (r *MetalMachineReconciler) isRemediationReplacement(ctx context.Context, machine *capiv1.Machine) (bool, error) {
+	owner := metav1.GetControllerOf(machine)
+	if owner == nil {
+		return false, nil
+	}
+
+	var machineList capiv1.MachineList
+
+	if err := r.List(ctx, &machineList, client.InNamespace(machine.Namespace), client.MatchingLabels{capiv1.ClusterLabelName: machine.Spec.ClusterName}); err != nil {
+		return false, err
+	}
+
+	for i := range machineList.Items {
+		sibling := &machineList.Items[i]
+
+		if sibling.Name == machine.Name {
+			continue
+		}
+
+		if siblingOwner := metav1.GetControllerOf(sibling); siblingOwner == nil || siblingOwner.UID != owner.UID {
+			continue
+		}
+
+		if conditions.IsFalse(sibling, capiv1.MachineOwnerRemediatedCondition) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// readinessGateRequest is the body of the request sent to the readiness gate webhook.
+type readinessGateRequest struct {
+	Namespace    string `json:"namespace"`
+	Cluster      string `json:"cluster"`
+	Machine      string `json:"machine"`
+	MetalMachine string `json:"metalMachine"`
+	Server       string `json:"server"`
+	ProviderID   string `json:"providerID"`
+}
+
+// checkReadinessGate calls the readiness gate webhook of the machine, and records the result in the condition.
+//
+// Once the server is accepted, the readiness gate is not called again until another server is allocated to the machine.
+func (r *MetalMachineReconciler) checkReadinessGate(ctx context.Context, cluster *capiv1.Cluster, machine *capiv1.Machine, metalMachine *infrav1.MetalMachine) bool {
+	gate := metalMachine.Spec.ReadinessGate
+
+	timeout := constants.DefaultReadinessGateTimeout
+	if gate.Timeout != nil {
+		timeout = gate.Timeout.Duration
+	}
+
+	// webhook is an arbitrary URL, so the whole request (including the response body) is limited by the timeout
+	httpClient := &http.Client{Timeout: timeout}
+
+	err := callReadinessGate(ctx, httpClient, gate.URL, &readinessGateRequest{
+		Namespace:    metalMachine.Namespace,
+		Cluster:      cluster.Name,
+		Machine:      machine.Name,
+		MetalMachine: metalMachine.Name,
+		Server:       metalMachine.Spec.ServerRef.Name,
+		ProviderID:   providerID(metalMachine.Spec.ServerRef.Name),
+	})
+	if err != nil {
+		message := fmt.Sprintf("Readiness gate rejected server %q: %s.", metalMachine.Spec.ServerRef.Name, err)
+
+		if conditions.GetMessage(metalMachine, infrav1.ReadinessGatePassedCondition) != message {
+			r.Recorder.Event(metalMachine, corev1.EventTypeWarning, infrav1.ReadinessGateFailedReason, message)
+		}
+
+		conditions.MarkFalse(metalMachine, infrav1.ReadinessGatePassedCondition, infrav1.ReadinessGateFailedReason, capiv1.ConditionSeverityWarning, "%s", message)
+
+		return false
+	}
+
+	conditions.MarkTrue(metalMachine, infrav1.ReadinessGatePassedCondition)
+
+	r.Recorder.Event(metalMachine, corev1.EventTypeNormal, "Readiness Gate Passed", fmt.Sprintf("Readiness gate accepted server %q.", metalMachine.Spec.ServerRef.Name))
+
+	return true
+}
+
+func callReadinessGate(ctx context.Context, httpClient *http.Client, url string, gateRequest *readinessGateRequest) error {
+	body, err := json.Marshal(gateRequest)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close() //nolint: errcheck
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	// the response body explains why the server was rejected, it's truncated to keep the condition short
+	message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint: errcheck
+
+	if len(bytes.TrimSpace(message)) == 0 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return fmt.Errorf("status code %d: %s", resp.StatusCode, strings.TrimRight(string(bytes.TrimSpace(message)), "."))
+}
+
+// workloadClientset builds the client of the workload cluster from the cluster kubeconfig secret.
+func (r *MetalMachineReconciler) workloadClientset(ctx context.Context, cluster *capiv1.Cluster) (*kubernetes.Clientset, error) {
 	kubeconfigSecret := &corev1.Secret{}
 
 	err := r.Client.Get(ctx,
@@ -288,15 +631,19 @@ func (r *MetalMachineReconciler) patchProviderID(ctx context.Context, cluster *c
 		kubeconfigSecret,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigSecret.Data["value"])
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
+	return kubernetes.NewForConfig(config)
+}
+
+func (r *MetalMachineReconciler) patchProviderID(ctx context.Context, cluster *capiv1.Cluster, metalMachine *infrav1.MetalMachine) error {
+	clientset, err := r.workloadClientset(ctx, cluster)
 	if err != nil {
 		return err
 	}
@@ -323,18 +670,18 @@ func (r *MetalMachineReconciler) patchProviderID(ctx context.Context, cluster *c
 		return fmt.Errorf("multiple nodes found with same uuid label")
 	}
 
-	providerID := fmt.Sprintf("%s://%s", constants.ProviderID, metalMachine.Spec.ServerRef.Name)
+	id := providerID(metalMachine.Spec.ServerRef.Name)
 
-	r.Log.Info("Setting provider ID", "id", providerID)
+	r.Log.Info("Setting provider ID", "id", id)
 
 	for _, node := range nodes.Items {
 		node := node
 
-		if node.Spec.ProviderID == providerID {
+		if node.Spec.ProviderID == id {
 			continue
 		}
 
-		node.Spec.ProviderID = providerID
+		node.Spec.ProviderID = id
 
 		_, err = clientset.CoreV1().Nodes().Update(ctx, &node, metav1.UpdateOptions{})
 		if err != nil {
@@ -345,6 +692,11 @@ func (r *MetalMachineReconciler) patchProviderID(ctx context.Context, cluster *c
 	return nil
 }
 
+// providerID returns the provider ID of the node running on the server.
+func providerID(serverName string) string {
+	return fmt.Sprintf("%s://%s", constants.ProviderID, serverName)
+}
+
 // createServerBinding updates a server to mark it as "in use" via ServerBinding resource.
 func (r *MetalMachineReconciler) createServerBinding(ctx context.Context, serverClass *metalv1alpha1.ServerClass, serverObj *metalv1alpha1.Server, metalMachine *infrav1.MetalMachine) error {
 	serverRef, err := reference.GetReference(r.Scheme, serverObj)
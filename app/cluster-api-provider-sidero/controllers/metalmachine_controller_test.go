@@ -0,0 +1,397 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+)
+
+func TestMetalMachineReconcilerProvisioningTimeout(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		infrav1.AddToScheme,
+		capiv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired := metav1.NewTime(time.Now().Add(-time.Hour))
+	recent := metav1.NewTime(time.Now().Add(-time.Minute))
+
+	for _, tt := range []struct {
+		name           string
+		timeout        *metav1.Duration
+		startTime      *metav1.Time
+		providerID     *string
+		nodeRef        bool
+		fromClass      bool
+		timeouts       int
+		expectReleased bool
+		expectFailed   bool
+		expectStart    bool
+	}{
+		{
+			name: "no timeout",
+		},
+		{
+			name:        "provisioning started",
+			timeout:     &metav1.Duration{Duration: 30 * time.Minute},
+			expectStart: true,
+		},
+		{
+			name:        "within the timeout",
+			timeout:     &metav1.Duration{Duration: 30 * time.Minute},
+			startTime:   &recent,
+			expectStart: true,
+		},
+		{
+			name:      "node joined",
+			timeout:   &metav1.Duration{Duration: 30 * time.Minute},
+			startTime: &expired,
+			nodeRef:   true,
+		},
+		{
+			name:       "provider ID set",
+			timeout:    &metav1.Duration{Duration: 30 * time.Minute},
+			startTime:  &expired,
+			providerID: pointer.StringPtr(providerID("server")),
+		},
+		{
+			name:           "retried on another server",
+			timeout:        &metav1.Duration{Duration: 30 * time.Minute},
+			startTime:      &expired,
+			fromClass:      true,
+			expectReleased: true,
+		},
+		{
+			name:           "retries exhausted",
+			timeout:        &metav1.Duration{Duration: 30 * time.Minute},
+			startTime:      &expired,
+			fromClass:      true,
+			timeouts:       1,
+			expectReleased: true,
+			expectFailed:   true,
+		},
+		{
+			name:           "not allocated from a serverclass",
+			timeout:        &metav1.Duration{Duration: 30 * time.Minute},
+			startTime:      &expired,
+			expectReleased: true,
+			expectFailed:   true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			metalMachine := &infrav1.MetalMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine"},
+				Spec: infrav1.MetalMachineSpec{
+					ServerRef:           &corev1.ObjectReference{Kind: "Server", Name: "server"},
+					ProviderID:          tt.providerID,
+					ProvisioningTimeout: tt.timeout,
+					ProvisioningRetries: 1,
+				},
+				Status: infrav1.MetalMachineStatus{
+					ProvisioningStartTime: tt.startTime,
+					ProvisioningTimeouts:  tt.timeouts,
+				},
+			}
+
+			if tt.fromClass {
+				metalMachine.Spec.ServerClassRef = &corev1.ObjectReference{Kind: "ServerClass", Name: "default"}
+			}
+
+			machine := &capiv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine"}}
+
+			if tt.nodeRef {
+				machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "node"}
+			}
+
+			c := fake.NewFakeClientWithScheme(scheme, &infrav1.ServerBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "server"},
+				Spec: infrav1.ServerBindingSpec{
+					MetalMachineRef: corev1.ObjectReference{Namespace: "default", Name: "machine"},
+				},
+			})
+
+			r := &MetalMachineReconciler{
+				Client:   c,
+				Log:      log.NullLogger{},
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			ctx := context.Background()
+
+			// cluster without the MetalCluster infrastructure has no remediation budget
+			released, err := r.reconcileProvisioningTimeout(ctx, r.Log, &capiv1.Cluster{}, metalMachine, machine)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if released != tt.expectReleased {
+				t.Fatalf("released = %v, want %v", released, tt.expectReleased)
+			}
+
+			if !reflect.DeepEqual(metalMachine.Spec.ProviderID, tt.providerID) {
+				t.Errorf("provider ID changed to %v", metalMachine.Spec.ProviderID)
+			}
+
+			if (metalMachine.Status.FailureReason != nil) != tt.expectFailed {
+				t.Errorf("failure reason = %v, want failed = %v", metalMachine.Status.FailureReason, tt.expectFailed)
+			}
+
+			if (metalMachine.Status.ProvisioningStartTime != nil) != tt.expectStart {
+				t.Errorf("provisioning start time = %v, want set = %v", metalMachine.Status.ProvisioningStartTime, tt.expectStart)
+			}
+
+			err = c.Get(ctx, types.NamespacedName{Name: "server"}, &infrav1.ServerBinding{})
+
+			if !tt.expectReleased {
+				if err != nil {
+					t.Errorf("server binding is removed: %v", err)
+				}
+
+				if metalMachine.Spec.ServerRef == nil {
+					t.Error("server is released")
+				}
+
+				return
+			}
+
+			if !apierrors.IsNotFound(err) {
+				t.Errorf("server binding is not removed: %v", err)
+			}
+
+			if metalMachine.Spec.ServerRef != nil {
+				t.Error("server reference is not cleared")
+			}
+
+			if metalMachine.Status.ProvisioningTimeouts != tt.timeouts+1 {
+				t.Errorf("provisioning timeouts = %d, want %d", metalMachine.Status.ProvisioningTimeouts, tt.timeouts+1)
+			}
+
+			if !reflect.DeepEqual(metalMachine.Status.FailedServers, []string{"server"}) {
+				t.Errorf("failed servers = %v", metalMachine.Status.FailedServers)
+			}
+		})
+	}
+}
+
+func TestMetalMachineReconcilerReadinessGate(t *testing.T) {
+	var gateRequest readinessGateRequest
+
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&gateRequest); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		switch gateRequest.Server {
+		case "accepted":
+		case "rejected":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("server is not in the inventory.\n")) //nolint: errcheck
+		case "failed":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "hanging":
+			w.WriteHeader(http.StatusForbidden)
+			w.(http.Flusher).Flush()
+
+			// response body never completes
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}))
+	defer gate.Close()
+
+	for _, tt := range []struct {
+		server          string
+		expectedReady   bool
+		expectedMessage string
+	}{
+		{
+			server:        "accepted",
+			expectedReady: true,
+		},
+		{
+			server:          "rejected",
+			expectedMessage: `Readiness gate rejected server "rejected": status code 403: server is not in the inventory.`,
+		},
+		{
+			server:          "failed",
+			expectedMessage: `Readiness gate rejected server "failed": unexpected status code 500.`,
+		},
+		{
+			server: "hanging",
+		},
+	} {
+		t.Run(tt.server, func(t *testing.T) {
+			metalMachine := &infrav1.MetalMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine"},
+				Spec: infrav1.MetalMachineSpec{
+					ServerRef: &corev1.ObjectReference{Kind: "Server", Name: tt.server},
+					ReadinessGate: &infrav1.ReadinessGate{
+						URL:     gate.URL,
+						Timeout: &metav1.Duration{Duration: 100 * time.Millisecond},
+					},
+				},
+			}
+
+			r := &MetalMachineReconciler{
+				Log:      log.NullLogger{},
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			start := time.Now()
+
+			ready := r.checkReadinessGate(context.Background(), &capiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "management"}},
+				&capiv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine"}}, metalMachine)
+
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("readiness gate call took %s", elapsed)
+			}
+
+			if ready != tt.expectedReady {
+				t.Fatalf("ready = %v, want %v", ready, tt.expectedReady)
+			}
+
+			if conditions.IsTrue(metalMachine, infrav1.ReadinessGatePassedCondition) != tt.expectedReady {
+				t.Errorf("ReadinessGatePassed condition = %v", conditions.Get(metalMachine, infrav1.ReadinessGatePassedCondition))
+			}
+
+			if message := conditions.GetMessage(metalMachine, infrav1.ReadinessGatePassedCondition); tt.expectedMessage != "" && message != tt.expectedMessage {
+				t.Errorf("condition message = %q, want %q", message, tt.expectedMessage)
+			}
+
+			expectedRequest := readinessGateRequest{
+				Namespace:    "default",
+				Cluster:      "management",
+				Machine:      "machine",
+				MetalMachine: "machine",
+				Server:       tt.server,
+				ProviderID:   providerID(tt.server),
+			}
+
+			if gateRequest != expectedRequest {
+				t.Errorf("readiness gate request %+v, want %+v", gateRequest, expectedRequest)
+			}
+		})
+	}
+}
+
+func TestMetalMachineReconcilerRemediationReplacement(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := capiv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	machine := func(name, owner string, remediated bool) *capiv1.Machine {
+		m := &capiv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{capiv1.ClusterLabelName: "management"},
+			},
+			Spec: capiv1.MachineSpec{ClusterName: "management"},
+		}
+
+		if owner != "" {
+			m.OwnerReferences = []metav1.OwnerReference{
+				{
+					APIVersion: capiv1.GroupVersion.String(),
+					Kind:       "MachineSet",
+					Name:       owner,
+					UID:        types.UID(owner),
+					Controller: pointer.BoolPtr(true),
+				},
+			}
+		}
+
+		if remediated {
+			conditions.MarkFalse(m, capiv1.MachineOwnerRemediatedCondition, capiv1.WaitingForRemediationReason, capiv1.ConditionSeverityWarning, "")
+		}
+
+		return m
+	}
+
+	for _, tt := range []struct {
+		name     string
+		machine  *capiv1.Machine
+		siblings []*capiv1.Machine
+		expected bool
+	}{
+		{
+			name:     "sibling remediated",
+			machine:  machine("new", "workers", false),
+			siblings: []*capiv1.Machine{machine("old", "workers", true), machine("healthy", "workers", false)},
+			expected: true,
+		},
+		{
+			name:     "no remediation",
+			machine:  machine("new", "workers", false),
+			siblings: []*capiv1.Machine{machine("healthy", "workers", false)},
+		},
+		{
+			name:     "other owner remediated",
+			machine:  machine("new", "workers", false),
+			siblings: []*capiv1.Machine{machine("old", "control-plane", true)},
+		},
+		{
+			name:     "no owner",
+			machine:  machine("new", "", false),
+			siblings: []*capiv1.Machine{machine("old", "", true)},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []runtime.Object{tt.machine}
+
+			for _, sibling := range tt.siblings {
+				objs = append(objs, sibling)
+			}
+
+			r := &MetalMachineReconciler{
+				Client: fake.NewFakeClientWithScheme(scheme, objs...),
+				Log:    log.NullLogger{},
+				Scheme: scheme,
+			}
+
+			remediation, err := r.isRemediationReplacement(context.Background(), tt.machine)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if remediation != tt.expected {
+				t.Errorf("remediation replacement = %v, want %v", remediation, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// NodeFeatureLabelPrefix is the prefix of the labels set on the nodes by the Kubernetes node feature discovery.
+const NodeFeatureLabelPrefix = "feature.node.kubernetes.io/"
+
+// importNodeFeatures copies the node feature discovery labels of the workload node onto the server.
+//
+// Feature labels of the server are replaced with the ones of the node, so that the features which are gone are removed as well.
+// Labels are kept once the server is released, so that the serverclass qualifiers can match the servers by the detected features.
+func (r *MetalMachineReconciler) importNodeFeatures(ctx context.Context, cluster *capiv1.Cluster, machine *capiv1.Machine, metalMachine *infrav1.MetalMachine) error {
+	clientset, err := r.workloadClientset(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	node, err := clientset.CoreV1().Nodes().Get(ctx, machine.Status.NodeRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	var server metalv1alpha1.Server
+
+	if err = r.Get(ctx, types.NamespacedName{Name: metalMachine.Spec.ServerRef.Name}, &server); err != nil {
+		return err
+	}
+
+	features := nodeFeatureLabels(node.Labels)
+
+	if !featureLabelsChanged(server.Labels, features) {
+		return nil
+	}
+
+	patchHelper := client.MergeFrom(server.DeepCopy())
+
+	for key := range server.Labels {
+		if strings.HasPrefix(key, NodeFeatureLabelPrefix) {
+			delete(server.Labels, key)
+		}
+	}
+
+	if server.Labels == nil {
+		server.Labels = map[string]string{}
+	}
+
+	for key, value := range features {
+		server.Labels[key] = value
+	}
+
+	if err = r.Patch(ctx, &server, patchHelper); err != nil {
+		return fmt.Errorf("error patching server labels: %w", err)
+	}
+
+	r.Log.Info("Imported node features", "server", server.Name, "node", node.Name, "features", len(features))
+
+	return nil
+}
+
+// nodeFeatureLabels picks the node feature discovery labels.
+func nodeFeatureLabels(labels map[string]string) map[string]string {
+	features := map[string]string{}
+
+	for key, value := range labels {
+		if strings.HasPrefix(key, NodeFeatureLabelPrefix) {
+			features[key] = value
+		}
+	}
+
+	return features
+}
+
+// featureLabelsChanged checks whether the feature labels of the server differ from the node features.
+func featureLabelsChanged(serverLabels, features map[string]string) bool {
+	current := nodeFeatureLabels(serverLabels)
+
+	if len(current) != len(features) {
+		return true
+	}
+
+	for key, value := range features {
+		if v, ok := current[key]; !ok || v != value {
+			return true
+		}
+	}
+
+	return false
+}
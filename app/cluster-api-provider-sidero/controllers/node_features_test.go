@@ -0,0 +1,96 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_nodeFeatureLabels(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		labels   map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "no labels",
+			expected: map[string]string{},
+		},
+		{
+			name: "mixed labels",
+			labels: map[string]string{
+				"kubernetes.io/hostname":                       "worker-1",
+				"node-role.kubernetes.io/worker":               "",
+				"feature.node.kubernetes.io/cpu-cpuid.AVX512F": "true",
+				"feature.node.kubernetes.io/pci-10de.present":  "true",
+				"nfd.node.kubernetes.io/feature-labels":        "cpu-cpuid.AVX512F",
+			},
+			expected: map[string]string{
+				"feature.node.kubernetes.io/cpu-cpuid.AVX512F": "true",
+				"feature.node.kubernetes.io/pci-10de.present":  "true",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if features := nodeFeatureLabels(tt.labels); !reflect.DeepEqual(features, tt.expected) {
+				t.Errorf("features %v, want %v", features, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_featureLabelsChanged(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		serverLabels map[string]string
+		features     map[string]string
+		expected     bool
+	}{
+		{
+			name:     "no features",
+			features: map[string]string{},
+		},
+		{
+			name: "same features",
+			serverLabels: map[string]string{
+				"metal.sidero.dev/cpu-manufacturer":            "Intel-R-Corporation",
+				"feature.node.kubernetes.io/cpu-cpuid.AVX512F": "true",
+			},
+			features: map[string]string{"feature.node.kubernetes.io/cpu-cpuid.AVX512F": "true"},
+		},
+		{
+			name:         "new feature",
+			serverLabels: map[string]string{"metal.sidero.dev/cpu-manufacturer": "Intel-R-Corporation"},
+			features:     map[string]string{"feature.node.kubernetes.io/cpu-cpuid.AVX512F": "true"},
+			expected:     true,
+		},
+		{
+			name:         "feature gone",
+			serverLabels: map[string]string{"feature.node.kubernetes.io/cpu-cpuid.AVX512F": "true"},
+			features:     map[string]string{},
+			expected:     true,
+		},
+		{
+			name:         "feature value changed",
+			serverLabels: map[string]string{"feature.node.kubernetes.io/kernel-version.major": "4"},
+			features:     map[string]string{"feature.node.kubernetes.io/kernel-version.major": "5"},
+			expected:     true,
+		},
+		{
+			name:         "feature replaced",
+			serverLabels: map[string]string{"feature.node.kubernetes.io/pci-10de.present": "true"},
+			features:     map[string]string{"feature.node.kubernetes.io/pci-1002.present": "true"},
+			expected:     true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if changed := featureLabelsChanged(tt.serverLabels, tt.features); changed != tt.expected {
+				t.Errorf("changed = %v, want %v", changed, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// controlPlaneGroup is the ordinal group of the control plane machines.
+const controlPlaneGroup = "control-plane"
+
+// ordinalGroup returns the group the ordinal of the metal machine is assigned within: "<namespace>/<cluster>/<group>".
+//
+// Machines which are not part of the control plane or a MachineDeployment don't get an ordinal.
+func ordinalGroup(metalMachine *infrav1.MetalMachine) (string, bool) {
+	cluster, ok := metalMachine.Labels[capiv1.ClusterLabelName]
+	if !ok {
+		return "", false
+	}
+
+	group := metalMachine.Labels[capiv1.MachineDeploymentLabelName]
+
+	if _, ok := metalMachine.Labels[capiv1.MachineControlPlaneLabelName]; ok {
+		group = controlPlaneGroup
+	}
+
+	if group == "" {
+		return "", false
+	}
+
+	return metalMachine.Namespace + "/" + cluster + "/" + group, true
+}
+
+// assignOrdinal sets the lowest ordinal not used by the other machines of the group on the metal machine,
+// the machine keeps the ordinal once it's assigned.
+//
+// The ordinal label is persisted right away with the optimistic lock (and retried on conflict),
+// so that it's recorded before the server is reserved for the ordinal.
+// Assignments within the group are serialized, and the ordinals of the other machines are read bypassing the cache,
+// so that the concurrent reconciles of the group machines don't pick the same ordinal.
+//
+// Machines being deleted keep their ordinals, as their servers are not released yet.
+func (r *MetalMachineReconciler) assignOrdinal(ctx context.Context, metalMachine *infrav1.MetalMachine) (key string, ok bool, err error) {
+	group, ok := ordinalGroup(metalMachine)
+	if !ok {
+		return "", false, nil
+	}
+
+	if value, assigned := metalMachine.Labels[infrav1.OrdinalLabel]; assigned {
+		return group + "/" + value, true, nil
+	}
+
+	unlock := r.ordinalLocks.Lock(group)
+	defer unlock()
+
+	var value string
+
+	if err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &infrav1.MetalMachine{}
+
+		if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: metalMachine.Namespace, Name: metalMachine.Name}, latest); err != nil {
+			return err
+		}
+
+		var assigned bool
+
+		if value, assigned = latest.Labels[infrav1.OrdinalLabel]; assigned {
+			return nil
+		}
+
+		ordinal, err := r.lowestFreeOrdinal(ctx, latest, group)
+		if err != nil {
+			return err
+		}
+
+		value = strconv.Itoa(ordinal)
+
+		patchBase := latest.DeepCopy()
+
+		if latest.Labels == nil {
+			latest.Labels = map[string]string{}
+		}
+
+		latest.Labels[infrav1.OrdinalLabel] = value
+
+		return r.Patch(ctx, latest, client.MergeFromWithOptions(patchBase, client.MergeFromWithOptimisticLock{}))
+	}); err != nil {
+		return "", false, err
+	}
+
+	metalMachine.Labels[infrav1.OrdinalLabel] = value
+
+	return group + "/" + value, true, nil
+}
+
+// lowestFreeOrdinal returns the lowest ordinal not used by the other machines of the group.
+func (r *MetalMachineReconciler) lowestFreeOrdinal(ctx context.Context, metalMachine *infrav1.MetalMachine, group string) (int, error) {
+	var metalMachines infrav1.MetalMachineList
+
+	if err := r.APIReader.List(ctx, &metalMachines, client.InNamespace(metalMachine.Namespace), client.MatchingLabels{
+		capiv1.ClusterLabelName: metalMachine.Labels[capiv1.ClusterLabelName],
+	}); err != nil {
+		return 0, err
+	}
+
+	used := map[int]struct{}{}
+
+	for i := range metalMachines.Items {
+		other := &metalMachines.Items[i]
+
+		if other.Name == metalMachine.Name {
+			continue
+		}
+
+		if otherGroup, _ := ordinalGroup(other); otherGroup != group {
+			continue
+		}
+
+		if ordinal, err := strconv.Atoi(other.Labels[infrav1.OrdinalLabel]); err == nil {
+			used[ordinal] = struct{}{}
+		}
+	}
+
+	ordinal := 0
+
+	for {
+		if _, ok := used[ordinal]; !ok {
+			break
+		}
+
+		ordinal++
+	}
+
+	return ordinal, nil
+}
+
+// orderByOrdinal orders the free servers so that the server allocated to the ordinal before comes first,
+// and the servers reserved for the other ordinals of the group come last.
+func orderByOrdinal(servers []*metalv1alpha1.Server, key string) {
+	group := key[:strings.LastIndex(key, "/")+1]
+
+	rank := func(server *metalv1alpha1.Server) int {
+		switch value := server.Annotations[infrav1.OrdinalAnnotation]; {
+		case value == key:
+			return 0
+		case strings.HasPrefix(value, group):
+			return 2
+		default:
+			return 1
+		}
+	}
+
+	sort.SliceStable(servers, func(i, j int) bool {
+		return rank(servers[i]) < rank(servers[j])
+	})
+}
+
+// reserveOrdinal records the ordinal of the metal machine on the allocated server.
+func (r *MetalMachineReconciler) reserveOrdinal(ctx context.Context, server *metalv1alpha1.Server, metalMachine *infrav1.MetalMachine) error {
+	group, ok := ordinalGroup(metalMachine)
+	if !ok {
+		return nil
+	}
+
+	value, ok := metalMachine.Labels[infrav1.OrdinalLabel]
+	if !ok {
+		return nil
+	}
+
+	key := group + "/" + value
+
+	if server.Annotations[infrav1.OrdinalAnnotation] == key {
+		return nil
+	}
+
+	patchHelper := client.MergeFrom(server.DeepCopy())
+
+	if server.Annotations == nil {
+		server.Annotations = map[string]string{}
+	}
+
+	server.Annotations[infrav1.OrdinalAnnotation] = key
+
+	return r.Patch(ctx, server, patchHelper)
+}
@@ -0,0 +1,176 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+)
+
+func TestAssignOrdinal(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	metalMachine := func(name string, labels map[string]string) *infrav1.MetalMachine {
+		labels[capiv1.ClusterLabelName] = "management"
+		labels[capiv1.MachineDeploymentLabelName] = "workers"
+
+		return &infrav1.MetalMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "default",
+				Name:            name,
+				Labels:          labels,
+				ResourceVersion: "1",
+			},
+		}
+	}
+
+	ctx := context.Background()
+
+	c := fake.NewFakeClientWithScheme(scheme,
+		metalMachine("worker-a", map[string]string{infrav1.OrdinalLabel: "0"}),
+		metalMachine("worker-b", map[string]string{infrav1.OrdinalLabel: "2"}),
+		metalMachine("worker-c", map[string]string{}),
+	)
+
+	r := &MetalMachineReconciler{
+		Client:    c,
+		APIReader: c,
+	}
+
+	machine := metalMachine("worker-c", map[string]string{})
+
+	key, ok, err := r.assignOrdinal(ctx, machine)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok || key != "default/management/workers/1" {
+		t.Fatalf("assignOrdinal() = %q, %v, want %q", key, ok, "default/management/workers/1")
+	}
+
+	var persisted infrav1.MetalMachine
+
+	if err = r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "worker-c"}, &persisted); err != nil {
+		t.Fatal(err)
+	}
+
+	if persisted.Labels[infrav1.OrdinalLabel] != "1" {
+		t.Errorf("persisted ordinal = %q, want %q", persisted.Labels[infrav1.OrdinalLabel], "1")
+	}
+
+	// the ordinal is kept once it's assigned
+	key, _, err = r.assignOrdinal(ctx, &persisted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key != "default/management/workers/1" {
+		t.Errorf("assignOrdinal() = %q, want %q", key, "default/management/workers/1")
+	}
+}
+
+// slowReader delays the list responses, so that the concurrent reconciles overlap.
+type slowReader struct {
+	client.Reader
+}
+
+func (r slowReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	err := r.Reader.List(ctx, list, opts...)
+
+	time.Sleep(10 * time.Millisecond)
+
+	return err
+}
+
+func TestAssignOrdinalConcurrent(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	const machines = 10
+
+	objects := make([]runtime.Object, 0, machines)
+
+	for i := 0; i < machines; i++ {
+		objects = append(objects, &infrav1.MetalMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      fmt.Sprintf("worker-%d", i),
+				Labels: map[string]string{
+					capiv1.ClusterLabelName:           "management",
+					capiv1.MachineDeploymentLabelName: "workers",
+				},
+				ResourceVersion: "1",
+			},
+		})
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, objects...)
+
+	r := &MetalMachineReconciler{
+		Client:    c,
+		APIReader: slowReader{c},
+	}
+
+	ctx := context.Background()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		keys = map[string]string{}
+	)
+
+	for _, obj := range objects {
+		metalMachine := obj.(*infrav1.MetalMachine).DeepCopy()
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			key, _, err := r.assignOrdinal(ctx, metalMachine)
+			if err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if other, duplicate := keys[key]; duplicate {
+				t.Errorf("ordinal %q is assigned to both %q and %q", key, other, metalMachine.Name)
+			}
+
+			keys[key] = metalMachine.Name
+		}()
+	}
+
+	wg.Wait()
+
+	for i := 0; i < machines; i++ {
+		if _, ok := keys[fmt.Sprintf("default/management/workers/%d", i)]; !ok {
+			t.Errorf("ordinal %d is not assigned", i)
+		}
+	}
+}
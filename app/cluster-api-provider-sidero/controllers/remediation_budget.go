@@ -0,0 +1,60 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/remediation"
+)
+
+// ErrRemediationPaused is returned when the remediation budget of the cluster is exhausted.
+var ErrRemediationPaused = errors.New("remediation budget of the cluster is exhausted")
+
+// reconcileRemediationBudget resumes the remediation once the remediation actions fall out of the budget window.
+//
+// It returns the duration after which the budget should be checked again.
+func (r *MetalClusterReconciler) reconcileRemediationBudget(ctx context.Context, metalCluster *infrav1.MetalCluster) (time.Duration, error) {
+	now := time.Now()
+
+	// the actions are not pruned in place: the MetalCluster is patched without the optimistic lock at the end of the reconcile,
+	// which would drop the actions recorded concurrently
+	current := metalCluster.DeepCopy()
+	remediation.Prune(current, now)
+
+	if len(current.Status.RemediationActions) != len(metalCluster.Status.RemediationActions) {
+		if err := remediation.Expire(ctx, r.Client, types.NamespacedName{Namespace: metalCluster.Namespace, Name: metalCluster.Name}); err != nil {
+			return 0, err
+		}
+	}
+
+	if metalCluster.Spec.RemediationBudget == nil {
+		conditions.Delete(metalCluster, infrav1.RemediationAllowedCondition)
+
+		return 0, nil
+	}
+
+	if remediation.Exhausted(current) {
+		// budget frees up as soon as the oldest action falls out of the window
+		oldest := current.Status.RemediationActions[0].Time.Time
+
+		return oldest.Add(metalCluster.Spec.RemediationBudget.GetWindow()).Sub(now), nil
+	}
+
+	if conditions.IsFalse(metalCluster, infrav1.RemediationAllowedCondition) {
+		r.Recorder.Event(metalCluster, corev1.EventTypeNormal, "Remediation Resumed", "Remediation budget is available, automated remediation is resumed.")
+	}
+
+	conditions.MarkTrue(metalCluster, infrav1.RemediationAllowedCondition)
+
+	return 0, nil
+}
@@ -6,14 +6,20 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,6 +29,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/constants"
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/remediation"
 	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
 )
 
@@ -42,6 +50,7 @@ type ServerBindingReconciler struct {
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclasses/status,verbs=get;list;watch;
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;patch;update
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *ServerBindingReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err error) {
@@ -91,9 +100,198 @@ func (r *ServerBindingReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, er
 
 	serverBinding.Status.Ready = true
 
+	if err = r.reconcileUpgrade(ctx, logger, serverBinding); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return r.reconcileReallocation(ctx, logger, serverBinding, &server)
+}
+
+// reconcileReallocation checks whether the server still matches the ServerClass it was allocated from.
+//
+// If the server doesn't match anymore, and the ServerClass has reallocation enabled, the owning Machine is marked
+// for remediation, so that it gets replaced with a machine allocated from the up to date ServerClass.
+func (r *ServerBindingReconciler) reconcileReallocation(ctx context.Context, logger logr.Logger, serverBinding *infrav1.ServerBinding, server *metalv1alpha1.Server) (ctrl.Result, error) {
+	if serverBinding.Spec.ServerClassRef == nil {
+		return ctrl.Result{}, nil
+	}
+
+	var serverClass metalv1alpha1.ServerClass
+
+	if err := r.Get(ctx, types.NamespacedName{Name: serverBinding.Spec.ServerClassRef.Name}, &serverClass); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// server might be still listed as available if the ServerClass status wasn't updated yet after the allocation
+	for _, name := range append(append([]string{}, serverClass.Status.ServersInUse...), serverClass.Status.ServersAvailable...) {
+		if name == server.Name {
+			conditions.MarkTrue(serverBinding, infrav1.ServerClassMatchedCondition)
+
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// mismatch is only reported once, and not on every reconcile
+	mismatchReported := conditions.IsFalse(serverBinding, infrav1.ServerClassMatchedCondition)
+
+	conditions.MarkFalse(serverBinding, infrav1.ServerClassMatchedCondition, infrav1.ServerClassMismatchReason, capiv1.ConditionSeverityWarning,
+		"Server no longer matches serverclass %q", serverClass.Name)
+
+	machine, err := r.getOwnerMachine(ctx, serverBinding)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if machine == nil || !machine.DeletionTimestamp.IsZero() || conditions.IsFalse(machine, capiv1.MachineOwnerRemediatedCondition) {
+		// nothing to do, or machine is already being replaced
+		return ctrl.Result{}, nil
+	}
+
+	serverRef, err := reference.GetReference(r.Scheme, server)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if serverClass.Spec.Reallocation == nil {
+		if mismatchReported {
+			return ctrl.Result{}, nil
+		}
+
+		r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Allocation",
+			fmt.Sprintf("Server no longer matches serverclass %q, but reallocation is disabled for the serverclass.", serverClass.Name))
+
+		return ctrl.Result{}, nil
+	}
+
+	inFlight, err := r.countReallocationsInFlight(ctx, serverClass.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if inFlight >= serverClass.Spec.Reallocation.GetMaxInFlight() {
+		logger.Info("waiting for other machines to be replaced", "serverclass", serverClass.Name, "inFlight", inFlight)
+
+		return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+	}
+
+	cluster, err := util.GetClusterFromMetadata(ctx, r.Client, machine.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	allowed, err := remediation.Allow(ctx, r.Client, r.Recorder, cluster, infrav1.RemediationActionReallocate, serverBinding.Spec.MetalMachineRef.Name, server.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !allowed {
+		logger.Info("server no longer matches serverclass, but remediation is paused for the cluster", "serverclass", serverClass.Name)
+
+		return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(machine, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	conditions.MarkFalse(machine, capiv1.MachineOwnerRemediatedCondition, capiv1.WaitingForRemediationReason, capiv1.ConditionSeverityWarning,
+		"Server %q no longer matches serverclass %q", server.Name, serverClass.Name)
+
+	if err = patchHelper.Patch(ctx, machine); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Allocation",
+		fmt.Sprintf("Server no longer matches serverclass %q, machine %q marked for replacement.", serverClass.Name, machine.Name))
+
 	return ctrl.Result{}, nil
 }
 
+// reconcileUpgrade mirrors the upgrade in progress annotation from the Machine to the ServerBinding.
+//
+// iPXE server boots the server from disk while the annotation is set on the ServerBinding.
+func (r *ServerBindingReconciler) reconcileUpgrade(ctx context.Context, logger logr.Logger, serverBinding *infrav1.ServerBinding) error {
+	machine, err := r.getOwnerMachine(ctx, serverBinding)
+	if err != nil {
+		return err
+	}
+
+	_, upgrading := serverBinding.Annotations[infrav1.UpgradeInProgressAnnotation]
+
+	var machineUpgrading bool
+
+	if machine != nil {
+		_, machineUpgrading = machine.Annotations[infrav1.UpgradeInProgressAnnotation]
+	}
+
+	switch {
+	case machineUpgrading && !upgrading:
+		if serverBinding.Annotations == nil {
+			serverBinding.Annotations = map[string]string{}
+		}
+
+		serverBinding.Annotations[infrav1.UpgradeInProgressAnnotation] = machine.Annotations[infrav1.UpgradeInProgressAnnotation]
+
+		logger.Info("machine upgrade started, server is going to boot from disk", "machine", machine.Name)
+	case !machineUpgrading && upgrading:
+		delete(serverBinding.Annotations, infrav1.UpgradeInProgressAnnotation)
+
+		logger.Info("machine upgrade finished")
+	}
+
+	return nil
+}
+
+// countReallocationsInFlight returns the number of machines allocated from the ServerClass which are being replaced.
+func (r *ServerBindingReconciler) countReallocationsInFlight(ctx context.Context, serverClassName string) (int, error) {
+	var serverBindingList infrav1.ServerBindingList
+
+	if err := r.List(ctx, &serverBindingList); err != nil {
+		return 0, err
+	}
+
+	inFlight := 0
+
+	for i := range serverBindingList.Items {
+		serverBinding := &serverBindingList.Items[i]
+
+		if serverBinding.Spec.ServerClassRef == nil || serverBinding.Spec.ServerClassRef.Name != serverClassName {
+			continue
+		}
+
+		machine, err := r.getOwnerMachine(ctx, serverBinding)
+		if err != nil {
+			return 0, err
+		}
+
+		if machine == nil {
+			continue
+		}
+
+		if !machine.DeletionTimestamp.IsZero() || conditions.IsFalse(machine, capiv1.MachineOwnerRemediatedCondition) {
+			inFlight++
+		}
+	}
+
+	return inFlight, nil
+}
+
+// getOwnerMachine returns the Machine which owns the MetalMachine bound to the server.
+func (r *ServerBindingReconciler) getOwnerMachine(ctx context.Context, serverBinding *infrav1.ServerBinding) (*capiv1.Machine, error) {
+	var metalMachine infrav1.MetalMachine
+
+	if err := r.Get(ctx, types.NamespacedName{Namespace: serverBinding.Spec.MetalMachineRef.Namespace, Name: serverBinding.Spec.MetalMachineRef.Name}, &metalMachine); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return util.GetOwnerMachine(ctx, r.Client, metalMachine.ObjectMeta)
+}
+
 func (r *ServerBindingReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &infrav1.MetalMachine{}, infrav1.MetalMachineServerRefField, func(rawObj runtime.Object) []string {
 		metalMachine := rawObj.(*infrav1.MetalMachine)
@@ -130,15 +328,71 @@ func (r *ServerBindingReconciler) SetupWithManager(mgr ctrl.Manager, options con
 			}
 		})
 
+	// This mapServerClassRequests handler reconciles server bindings on ServerClass changes to check
+	// whether the servers still match the ServerClass.
+	mapServerClassRequests := handler.ToRequestsFunc(
+		func(a handler.MapObject) []reconcile.Request {
+			var serverBindingList infrav1.ServerBindingList
+
+			if err := r.List(context.Background(), &serverBindingList); err != nil {
+				return nil
+			}
+
+			reqList := []reconcile.Request{}
+
+			for _, serverBinding := range serverBindingList.Items {
+				if serverBinding.Spec.ServerClassRef == nil || serverBinding.Spec.ServerClassRef.Name != a.Meta.GetName() {
+					continue
+				}
+
+				reqList = append(reqList, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      serverBinding.Name,
+						Namespace: serverBinding.Namespace,
+					},
+				})
+			}
+
+			return reqList
+		})
+
+	// This mapMachineRequests handler reconciles server bindings on Machine changes to pick up upgrade annotations.
+	mapMachineRequests := handler.ToRequestsFunc(
+		func(a handler.MapObject) []reconcile.Request {
+			machine, ok := a.Object.(*capiv1.Machine)
+			if !ok || machine.Spec.InfrastructureRef.Kind != "MetalMachine" {
+				return nil
+			}
+
+			return mapRequests(handler.MapObject{
+				Meta: &metav1.ObjectMeta{
+					Namespace: machine.Namespace,
+					Name:      machine.Spec.InfrastructureRef.Name,
+				},
+			})
+		})
+
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(options).
 		For(&infrav1.ServerBinding{}).
+		Watches(
+			&source.Kind{Type: &capiv1.Machine{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: mapMachineRequests,
+			},
+		).
 		Watches(
 			&source.Kind{Type: &infrav1.MetalMachine{}},
 			&handler.EnqueueRequestsFromMapFunc{
 				ToRequests: mapRequests,
 			},
 		).
+		Watches(
+			&source.Kind{Type: &metalv1alpha1.ServerClass{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: mapServerClassRequests,
+			},
+		).
 		Complete(r)
 }
 
@@ -162,10 +416,17 @@ func (r *ServerBindingReconciler) reconcileTransition(logger logr.Logger, req ct
 	found := false
 
 	for _, metalMachine := range metalMachineList.Items {
+		metalMachine := metalMachine
+
 		if !metalMachine.DeletionTimestamp.IsZero() {
 			continue
 		}
 
+		// server was released after the provisioning timeout, and metalmachine might not be updated yet
+		if isFailedServer(&metalMachine, req.Name) {
+			continue
+		}
+
 		if metalMachine.Spec.ServerRef != nil {
 			if metalMachine.Spec.ServerRef.Name == serverBinding.Name && metalMachine.Spec.ServerRef.Namespace == serverBinding.Namespace {
 				found = true
@@ -0,0 +1,167 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	machineapi "github.com/talos-systems/talos/pkg/machinery/api/machine"
+	talosclient "github.com/talos-systems/talos/pkg/machinery/client"
+	talosconfig "github.com/talos-systems/talos/pkg/machinery/client/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/constants"
+)
+
+// checkTalosHealth probes the Talos services of the node, and records the result in the condition.
+//
+// It returns the interval until the next probe.
+func (r *MetalMachineReconciler) checkTalosHealth(ctx context.Context, machine *capiv1.Machine, metalMachine *infrav1.MetalMachine) time.Duration {
+	healthCheck := metalMachine.Spec.TalosHealthCheck
+
+	interval := constants.DefaultTalosHealthCheckInterval
+	if healthCheck.Interval != nil {
+		interval = healthCheck.Interval.Duration
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.DefaultTalosHealthCheckTimeout)
+	defer cancel()
+
+	services, err := r.talosServices(ctx, machine)
+	if err != nil {
+		r.markTalosUnhealthy(metalMachine, infrav1.TalosUnreachableReason,
+			fmt.Sprintf("Talos API of server %q can't be reached: %s.", metalMachine.Spec.ServerRef.Name, err))
+
+		return interval
+	}
+
+	if problems := servicesProblems(services, healthCheck.Services); len(problems) > 0 {
+		r.markTalosUnhealthy(metalMachine, infrav1.TalosServicesUnhealthyReason,
+			fmt.Sprintf("Talos services of server %q are unhealthy: %s.", metalMachine.Spec.ServerRef.Name, strings.Join(problems, ", ")))
+
+		return interval
+	}
+
+	if !conditions.IsTrue(metalMachine, infrav1.TalosHealthyCondition) {
+		r.Recorder.Event(metalMachine, corev1.EventTypeNormal, "Talos Healthy", fmt.Sprintf("Talos services of server %q are healthy.", metalMachine.Spec.ServerRef.Name))
+	}
+
+	conditions.MarkTrue(metalMachine, infrav1.TalosHealthyCondition)
+
+	return interval
+}
+
+func (r *MetalMachineReconciler) markTalosUnhealthy(metalMachine *infrav1.MetalMachine, reason, message string) {
+	if conditions.GetMessage(metalMachine, infrav1.TalosHealthyCondition) != message {
+		r.Recorder.Event(metalMachine, corev1.EventTypeWarning, reason, message)
+	}
+
+	conditions.MarkFalse(metalMachine, infrav1.TalosHealthyCondition, reason, capiv1.ConditionSeverityWarning, "%s", message)
+}
+
+// talosServices lists the services of the node via the Talos API.
+//
+// Talos API is accessed with the talosconfig from the status of the machine bootstrap config.
+func (r *MetalMachineReconciler) talosServices(ctx context.Context, machine *capiv1.Machine) ([]*machineapi.ServiceInfo, error) {
+	address := nodeAddress(machine)
+	if address == "" {
+		return nil, errors.New("machine has no node address")
+	}
+
+	configRef := machine.Spec.Bootstrap.ConfigRef
+	if configRef == nil {
+		return nil, errors.New("machine has no bootstrap config")
+	}
+
+	bootstrapConfig, err := external.Get(ctx, r.Client, configRef, machine.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching bootstrap config: %w", err)
+	}
+
+	talosConfigData, _, err := unstructured.NestedString(bootstrapConfig.Object, "status", "talosConfig")
+	if err != nil || talosConfigData == "" {
+		return nil, fmt.Errorf("%s %q has no talosconfig", configRef.Kind, configRef.Name)
+	}
+
+	cfg, err := talosconfig.FromString(talosConfigData)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing talosconfig: %w", err)
+	}
+
+	c, err := talosclient.New(ctx, talosclient.WithConfig(cfg), talosclient.WithEndpoints(address))
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.Close() //nolint: errcheck
+
+	resp, err := c.ServiceList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var services []*machineapi.ServiceInfo
+
+	for _, msg := range resp.GetMessages() {
+		services = append(services, msg.GetServices()...)
+	}
+
+	return services, nil
+}
+
+// servicesProblems describes the services which are failed or unhealthy, and the required services which are not running.
+func servicesProblems(services []*machineapi.ServiceInfo, required []string) []string {
+	var problems []string
+
+	// services are either running or reported as the problem
+	checked := map[string]bool{}
+
+	for _, svc := range services {
+		switch {
+		case svc.GetState() == "Failed":
+			problems = append(problems, fmt.Sprintf("%s failed", svc.GetId()))
+		case svc.GetHealth() != nil && !svc.GetHealth().GetUnknown() && !svc.GetHealth().GetHealthy():
+			problems = append(problems, fmt.Sprintf("%s unhealthy (%s)", svc.GetId(), svc.GetHealth().GetLastMessage()))
+		case svc.GetState() == "Running":
+		default:
+			continue
+		}
+
+		checked[svc.GetId()] = true
+	}
+
+	for _, id := range required {
+		if !checked[id] {
+			problems = append(problems, fmt.Sprintf("%s not running", id))
+		}
+	}
+
+	sort.Strings(problems)
+
+	return problems
+}
+
+// nodeAddress returns the internal address of the node, or the external one if there is no internal address.
+func nodeAddress(machine *capiv1.Machine) string {
+	for _, addressType := range []capiv1.MachineAddressType{capiv1.MachineInternalIP, capiv1.MachineExternalIP} {
+		for _, address := range machine.Status.Addresses {
+			if address.Type == addressType {
+				return address.Address
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,81 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	machineapi "github.com/talos-systems/talos/pkg/machinery/api/machine"
+)
+
+func Test_servicesProblems(t *testing.T) {
+	required := []string{"etcd", "kubelet"}
+
+	for _, tt := range []struct {
+		name     string
+		services []*machineapi.ServiceInfo
+		expected []string
+	}{
+		{
+			name: "healthy",
+			services: []*machineapi.ServiceInfo{
+				{Id: "etcd", State: "Running", Health: &machineapi.ServiceHealth{Healthy: true}},
+				{Id: "kubelet", State: "Running", Health: &machineapi.ServiceHealth{Healthy: true}},
+				{Id: "udevd", State: "Running"},
+			},
+		},
+		{
+			name: "health unknown",
+			services: []*machineapi.ServiceInfo{
+				{Id: "etcd", State: "Running", Health: &machineapi.ServiceHealth{Unknown: true}},
+				{Id: "kubelet", State: "Running"},
+			},
+		},
+		{
+			name: "unhealthy",
+			services: []*machineapi.ServiceInfo{
+				{Id: "etcd", State: "Running", Health: &machineapi.ServiceHealth{LastMessage: "no quorum"}},
+				{Id: "kubelet", State: "Running", Health: &machineapi.ServiceHealth{Healthy: true}},
+			},
+			expected: []string{"etcd unhealthy (no quorum)"},
+		},
+		{
+			name: "failed",
+			services: []*machineapi.ServiceInfo{
+				{Id: "etcd", State: "Running", Health: &machineapi.ServiceHealth{Healthy: true}},
+				{Id: "kubelet", State: "Failed"},
+				{Id: "udevd", State: "Failed"},
+			},
+			expected: []string{"kubelet failed", "udevd failed"},
+		},
+		{
+			name: "missing",
+			services: []*machineapi.ServiceInfo{
+				{Id: "kubelet", State: "Running", Health: &machineapi.ServiceHealth{Healthy: true}},
+			},
+			expected: []string{"etcd not running"},
+		},
+		{
+			name: "not running yet",
+			services: []*machineapi.ServiceInfo{
+				{Id: "etcd", State: "Preparing"},
+				{Id: "kubelet", State: "Running"},
+			},
+			expected: []string{"etcd not running"},
+		},
+		{
+			name:     "no services",
+			expected: []string{"etcd not running", "kubelet not running"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if problems := servicesProblems(tt.services, required); !reflect.DeepEqual(problems, tt.expected) {
+				t.Errorf("servicesProblems() = %v, want %v", problems, tt.expected)
+			}
+		})
+	}
+}
@@ -6,7 +6,11 @@ package main
 
 import (
 	"flag"
+	"math/rand"
+	"net/http"
+	"net/http/pprof"
 	"os"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -17,6 +21,7 @@ import (
 	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	infrav1alpha2 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha2"
@@ -44,16 +49,26 @@ func init() {
 func main() {
 	var (
 		metricsAddr          string
+		healthProbeAddr      string
+		enablePprof          bool
 		enableLeaderElection bool
 		webhookPort          int
+		nodeFeaturesInterval time.Duration
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&healthProbeAddr, "health-probe-addr", ":9440", "The address the health probe endpoints (/healthz, /readyz) bind to.")
+	flag.BoolVar(&enablePprof, "enable-pprof", false, "Serve pprof profiles under /debug/pprof/ along with the metrics.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&nodeFeaturesInterval, "node-features-import-interval", 0,
+		"Interval of importing the node feature discovery labels of the workload nodes onto their servers, disabled by default.")
 	flag.IntVar(&webhookPort, "webhook-port", 0, "Webhook Server port, disabled by default. When enabled, the manager will only work as webhook server, no reconcilers are installed.")
 	flag.Parse()
 
+	// Random allocation strategy shuffles the servers
+	rand.Seed(time.Now().UnixNano())
+
 	ctrl.SetLogger(zap.New(func(o *zap.Options) {
 		o.Development = true
 	}))
@@ -65,18 +80,44 @@ func main() {
 	})
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:             scheme,
-		MetricsBindAddress: metricsAddr,
-		LeaderElection:     enableLeaderElection,
-		LeaderElectionID:   "controller-leader-election-capm",
-		Port:               webhookPort,
-		EventBroadcaster:   broadcaster,
+		Scheme:                 scheme,
+		MetricsBindAddress:     metricsAddr,
+		HealthProbeBindAddress: healthProbeAddr,
+		LeaderElection:         enableLeaderElection,
+		LeaderElectionID:       "controller-leader-election-capm",
+		Port:                   webhookPort,
+		EventBroadcaster:       broadcaster,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	if err = mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to add health check")
+		os.Exit(1)
+	}
+
+	if err = mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to add readiness check")
+		os.Exit(1)
+	}
+
+	if enablePprof {
+		for path, handler := range map[string]http.HandlerFunc{
+			"/debug/pprof/":        pprof.Index,
+			"/debug/pprof/cmdline": pprof.Cmdline,
+			"/debug/pprof/profile": pprof.Profile,
+			"/debug/pprof/symbol":  pprof.Symbol,
+			"/debug/pprof/trace":   pprof.Trace,
+		} {
+			if err = mgr.AddMetricsExtraHandler(path, handler); err != nil {
+				setupLog.Error(err, "unable to add pprof handler")
+				os.Exit(1)
+			}
+		}
+	}
+
 	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
 	if err != nil {
 		setupLog.Error(err, "unable to create k8s client")
@@ -95,19 +136,22 @@ func main() {
 
 	if webhookPort == 0 {
 		if err = (&controllers.MetalClusterReconciler{
-			Client: mgr.GetClient(),
-			Log:    ctrl.Log.WithName("controllers").WithName("MetalCluster"),
-			Scheme: mgr.GetScheme(),
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("MetalCluster"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: recorder,
 		}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: 10}); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "MetalCluster")
 			os.Exit(1)
 		}
 
 		if err = (&controllers.MetalMachineReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("MetalMachine"),
-			Scheme:   mgr.GetScheme(),
-			Recorder: recorder,
+			Client:               mgr.GetClient(),
+			Log:                  ctrl.Log.WithName("controllers").WithName("MetalMachine"),
+			Scheme:               mgr.GetScheme(),
+			APIReader:            mgr.GetAPIReader(),
+			Recorder:             recorder,
+			NodeFeaturesInterval: nodeFeaturesInterval,
 		}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: 10}); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "MetalMachine")
 			os.Exit(1)
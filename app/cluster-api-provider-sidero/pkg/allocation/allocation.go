@@ -0,0 +1,247 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package allocation implements the selection of the servers allocated from the ServerClass,
+// shared by the MetalMachine controller and the allocation preview.
+package allocation
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// Reasons the server of the ServerClass can't be allocated.
+const (
+	ReasonInUse          = "InUse"
+	ReasonBound          = "Bound"
+	ReasonNotClean       = "NotClean"
+	ReasonCablingInvalid = "CablingInvalid"
+	ReasonDuplicateMAC   = "DuplicateMAC"
+	ReasonFaulty         = "Faulty"
+	ReasonProposed       = "Proposed"
+	ReasonFrozen         = "Frozen"
+)
+
+// Exclusion describes the server which matches the ServerClass, but can't be allocated.
+type Exclusion struct {
+	Server string `json:"server"`
+	Reason string `json:"reason"`
+}
+
+// FreeServers returns the servers of the ServerClass which can be allocated, along with the reasons the other servers
+// matching the ServerClass can't be allocated.
+//
+// Servers for which skip returns true are left out silently (skip might be nil).
+func FreeServers(ctx context.Context, c client.Reader, serverClass *metalv1alpha1.ServerClass, skip func(name string) bool) ([]*metalv1alpha1.Server, []Exclusion, error) {
+	var excluded []Exclusion
+
+	exclude := func(server, reason string) {
+		excluded = append(excluded, Exclusion{Server: server, Reason: reason})
+	}
+
+	for _, name := range serverClass.Status.ServersInUse {
+		exclude(name, ReasonInUse)
+	}
+
+	// NB: servers are checked again, because of the raciness between the server selection
+	//     and it being removed from the ServersAvailable list.
+	free := make([]*metalv1alpha1.Server, 0, len(serverClass.Status.ServersAvailable))
+
+	for _, name := range serverClass.Status.ServersAvailable {
+		if skip != nil && skip(name) {
+			continue
+		}
+
+		server := &metalv1alpha1.Server{}
+
+		if err := c.Get(ctx, types.NamespacedName{Name: name}, server); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, nil, err
+		}
+
+		var serverBinding infrav1.ServerBinding
+
+		err := c.Get(ctx, types.NamespacedName{Name: name}, &serverBinding)
+
+		switch {
+		case err != nil && !apierrors.IsNotFound(err):
+			return nil, nil, err
+		case server.Status.InUse:
+			exclude(name, ReasonInUse)
+		case err == nil:
+			// allocated, but the server is not marked as in use yet
+			exclude(name, ReasonBound)
+		case !server.Status.IsClean:
+			exclude(name, ReasonNotClean)
+		case conditions.IsTrue(server, metalv1alpha1.ConditionCablingInvalid):
+			exclude(name, ReasonCablingInvalid)
+		case conditions.IsTrue(server, metalv1alpha1.ConditionDuplicateMAC):
+			exclude(name, ReasonDuplicateMAC)
+		case server.IsFrozen(time.Now()):
+			exclude(name, ReasonFrozen)
+		case !serverClass.Tolerates(server):
+			exclude(name, ReasonFaulty)
+		default:
+			free = append(free, server)
+		}
+	}
+
+	return free, excluded, nil
+}
+
+// Order orders the free servers in the allocation order: by the allocation strategy of the ServerClass,
+// and then the servers also offered by the higher priority ServerClasses are moved last.
+//
+// The metal machine is used by the SpreadByLabel strategy to find the servers allocated to the same cluster, it might be nil.
+// The Ordinal strategy is applied by the MetalMachine controller on top of this order.
+func Order(ctx context.Context, c client.Reader, serverClass *metalv1alpha1.ServerClass, metalMachine *infrav1.MetalMachine, servers []*metalv1alpha1.Server) error {
+	if err := orderServers(ctx, c, serverClass, metalMachine, servers); err != nil {
+		return err
+	}
+
+	return preferServers(ctx, c, serverClass, servers)
+}
+
+// orderServers orders the free servers according to the allocation strategy of the serverclass.
+//
+// Free servers come in the order of their names, so the Sequential strategy keeps the order.
+func orderServers(ctx context.Context, c client.Reader, serverClass *metalv1alpha1.ServerClass, metalMachine *infrav1.MetalMachine, servers []*metalv1alpha1.Server) error {
+	switch serverClass.Spec.AllocationStrategy {
+	case metalv1alpha1.AllocationStrategyRandom:
+		rand.Shuffle(len(servers), func(i, j int) { servers[i], servers[j] = servers[j], servers[i] })
+	case metalv1alpha1.AllocationStrategySpreadByLabel:
+		if serverClass.Spec.SpreadLabel == "" {
+			return nil
+		}
+
+		domains, err := failureDomains(ctx, c, serverClass.Spec.SpreadLabel, metalMachine)
+		if err != nil {
+			return err
+		}
+
+		spread := func(server *metalv1alpha1.Server) (bool, int) {
+			domain, ok := server.Labels[serverClass.Spec.SpreadLabel]
+
+			return ok, domains[domain]
+		}
+
+		sort.SliceStable(servers, func(i, j int) bool {
+			labeledI, usedI := spread(servers[i])
+			labeledJ, usedJ := spread(servers[j])
+
+			if labeledI != labeledJ {
+				return labeledI
+			}
+
+			return usedI < usedJ
+		})
+	case metalv1alpha1.AllocationStrategySequential, metalv1alpha1.AllocationStrategyOrdinal, "":
+		// the Ordinal strategy is applied after the serverclass priorities, see orderByOrdinal
+	}
+
+	return nil
+}
+
+// failureDomains counts the servers allocated to the machines of the same cluster and role as the metal machine
+// by the value of the spread label.
+func failureDomains(ctx context.Context, c client.Reader, label string, metalMachine *infrav1.MetalMachine) (map[string]int, error) {
+	if metalMachine == nil {
+		return nil, nil
+	}
+
+	cluster, ok := metalMachine.Labels[capiv1.ClusterLabelName]
+	if !ok {
+		return nil, nil
+	}
+
+	_, controlPlane := metalMachine.Labels[capiv1.MachineControlPlaneLabelName]
+
+	var serverBindings infrav1.ServerBindingList
+
+	if err := c.List(ctx, &serverBindings, client.MatchingLabels{capiv1.ClusterLabelName: cluster}); err != nil {
+		return nil, err
+	}
+
+	domains := map[string]int{}
+
+	for _, serverBinding := range serverBindings.Items {
+		// server bindings are cluster-scoped, so the clusters of the same name in the other namespaces are filtered out
+		if serverBinding.Spec.MetalMachineRef.Namespace != metalMachine.Namespace {
+			continue
+		}
+
+		if _, ok := serverBinding.Labels[capiv1.MachineControlPlaneLabelName]; ok != controlPlane {
+			continue
+		}
+
+		var server metalv1alpha1.Server
+
+		if err := c.Get(ctx, types.NamespacedName{Name: serverBinding.Name}, &server); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if domain, ok := server.Labels[label]; ok {
+			domains[domain]++
+		}
+	}
+
+	return domains, nil
+}
+
+// preferServers orders the free servers so that the servers also offered by the higher priority serverclasses are allocated last,
+// in the order of the priority of those serverclasses.
+func preferServers(ctx context.Context, c client.Reader, serverClass *metalv1alpha1.ServerClass, servers []*metalv1alpha1.Server) error {
+	var serverClasses metalv1alpha1.ServerClassList
+
+	if err := c.List(ctx, &serverClasses); err != nil {
+		return err
+	}
+
+	// claims is the highest priority of the other serverclasses offering the server
+	claims := map[string]int{}
+
+	for _, other := range serverClasses.Items {
+		if other.Name == serverClass.Name || other.Spec.Priority <= serverClass.Spec.Priority {
+			continue
+		}
+
+		for _, name := range other.Status.ServersAvailable {
+			if priority, ok := claims[name]; !ok || other.Spec.Priority > priority {
+				claims[name] = other.Spec.Priority
+			}
+		}
+	}
+
+	claim := func(name string) int {
+		if priority, ok := claims[name]; ok {
+			return priority
+		}
+
+		return serverClass.Spec.Priority
+	}
+
+	sort.SliceStable(servers, func(i, j int) bool {
+		return claim(servers[i].Name) < claim(servers[j].Name)
+	})
+
+	return nil
+}
@@ -0,0 +1,216 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package allocation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func scheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		infrav1.AddToScheme,
+		metalv1alpha1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return scheme
+}
+
+func TestFreeServers(t *testing.T) {
+	server := func(name string, clean bool) *metalv1alpha1.Server {
+		return &metalv1alpha1.Server{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     metalv1alpha1.ServerStatus{IsClean: clean},
+		}
+	}
+
+	cablingInvalid := server("cabling-invalid", true)
+	conditions.MarkTrue(cablingInvalid, metalv1alpha1.ConditionCablingInvalid)
+
+	serverClass := &metalv1alpha1.ServerClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "workers"},
+		Status: metalv1alpha1.ServerClassStatus{
+			ServersAvailable: []string{"bound", "cabling-invalid", "dirty", "failed", "free-a", "free-b", "gone"},
+			ServersInUse:     []string{"in-use"},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme(t),
+		server("bound", true),
+		cablingInvalid,
+		server("dirty", false),
+		server("failed", true),
+		server("free-a", true),
+		server("free-b", true),
+		&infrav1.ServerBinding{ObjectMeta: metav1.ObjectMeta{Name: "bound"}},
+	)
+
+	free, excluded, err := FreeServers(context.Background(), c, serverClass, func(name string) bool { return name == "failed" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	for _, s := range free {
+		names = append(names, s.Name)
+	}
+
+	if expected := []string{"free-a", "free-b"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("free = %v, want %v", names, expected)
+	}
+
+	expectedExcluded := []Exclusion{
+		{Server: "in-use", Reason: ReasonInUse},
+		{Server: "bound", Reason: ReasonBound},
+		{Server: "cabling-invalid", Reason: ReasonCablingInvalid},
+		{Server: "dirty", Reason: ReasonNotClean},
+	}
+
+	if !reflect.DeepEqual(excluded, expectedExcluded) {
+		t.Errorf("excluded = %v, want %v", excluded, expectedExcluded)
+	}
+}
+
+func TestPreferServers(t *testing.T) {
+	c := fake.NewFakeClientWithScheme(scheme(t),
+		&metalv1alpha1.ServerClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "gpu"},
+			Spec:       metalv1alpha1.ServerClassSpec{Priority: 10},
+			Status:     metalv1alpha1.ServerClassStatus{ServersAvailable: []string{"server-a"}},
+		},
+		&metalv1alpha1.ServerClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "storage"},
+			Spec:       metalv1alpha1.ServerClassSpec{Priority: 5},
+			Status:     metalv1alpha1.ServerClassStatus{ServersAvailable: []string{"server-a", "server-b"}},
+		},
+	)
+
+	servers := []*metalv1alpha1.Server{
+		{ObjectMeta: metav1.ObjectMeta{Name: "server-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "server-b"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "server-c"}},
+	}
+
+	if err := Order(context.Background(), c, &metalv1alpha1.ServerClass{ObjectMeta: metav1.ObjectMeta{Name: "any"}}, nil, servers); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	for _, s := range servers {
+		names = append(names, s.Name)
+	}
+
+	// servers claimed by the higher priority serverclasses go last, the most claimed one the very last
+	if expected := []string{"server-c", "server-b", "server-a"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("order = %v, want %v", names, expected)
+	}
+}
+
+func TestOrderServersSpreadByLabel(t *testing.T) {
+	const zoneLabel = "topology.kubernetes.io/zone"
+
+	binding := func(name, namespace, cluster string, controlPlane bool) *infrav1.ServerBinding {
+		labels := map[string]string{capiv1.ClusterLabelName: cluster}
+
+		if controlPlane {
+			labels[capiv1.MachineControlPlaneLabelName] = ""
+		}
+
+		return &infrav1.ServerBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Spec: infrav1.ServerBindingSpec{
+				MetalMachineRef: corev1.ObjectReference{Namespace: namespace, Name: name},
+			},
+		}
+	}
+
+	server := func(name, zone string) *metalv1alpha1.Server {
+		s := &metalv1alpha1.Server{ObjectMeta: metav1.ObjectMeta{Name: name}}
+
+		if zone != "" {
+			s.Labels = map[string]string{zoneLabel: zone}
+		}
+
+		return s
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme(t),
+		server("allocated-a", "a"),
+		server("allocated-b", "b"),
+		server("allocated-c", "c"),
+		server("other-namespace", "c"),
+		server("other-cluster", "c"),
+		binding("allocated-a", "default", "management", false),
+		binding("allocated-b", "default", "management", false),
+		// control plane servers are spread separately
+		binding("allocated-c", "default", "management", true),
+		// cluster of the same name in the other namespace
+		binding("other-namespace", "staging", "management", false),
+		binding("other-cluster", "default", "workload", false),
+	)
+
+
+	metalMachine := &infrav1.MetalMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "worker",
+			Labels:    map[string]string{capiv1.ClusterLabelName: "management"},
+		},
+	}
+
+	ctx := context.Background()
+
+	domains, err := failureDomains(ctx, c, zoneLabel, metalMachine)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := map[string]int{"a": 1, "b": 1}; !reflect.DeepEqual(domains, expected) {
+		t.Errorf("failureDomains() = %v, want %v", domains, expected)
+	}
+
+	serverClass := &metalv1alpha1.ServerClass{
+		Spec: metalv1alpha1.ServerClassSpec{
+			AllocationStrategy: metalv1alpha1.AllocationStrategySpreadByLabel,
+			SpreadLabel:        zoneLabel,
+		},
+	}
+
+	servers := []*metalv1alpha1.Server{server("unlabeled", ""), server("free-a", "a"), server("free-c", "c"), server("free-b", "b")}
+
+	if err = orderServers(ctx, c, serverClass, metalMachine, servers); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	for _, s := range servers {
+		names = append(names, s.Name)
+	}
+
+	// least used zone first, servers without the label last
+	if expected := []string{"free-c", "free-a", "free-b", "unlabeled"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("orderServers() = %v, want %v", names, expected)
+	}
+}
@@ -7,6 +7,9 @@ package constants
 import "time"
 
 const (
-	ProviderID          = "sidero"
-	DefaultRequeueAfter = time.Second * 20
+	ProviderID                      = "sidero"
+	DefaultRequeueAfter             = time.Second * 20
+	DefaultReadinessGateTimeout     = time.Second * 10
+	DefaultTalosHealthCheckInterval = time.Minute
+	DefaultTalosHealthCheckTimeout  = time.Second * 30
 )
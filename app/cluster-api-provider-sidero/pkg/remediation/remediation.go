@@ -0,0 +1,205 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package remediation implements the remediation budget of the clusters.
+package remediation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+)
+
+// Prune drops the remediation actions which fell out of the budget window.
+func Prune(metalCluster *infrav1.MetalCluster, now time.Time) {
+	budget := metalCluster.Spec.RemediationBudget
+	if budget == nil {
+		metalCluster.Status.RemediationActions = nil
+
+		return
+	}
+
+	cutoff := now.Add(-budget.GetWindow())
+	actions := metalCluster.Status.RemediationActions[:0]
+
+	for _, action := range metalCluster.Status.RemediationActions {
+		if action.Time.Time.After(cutoff) {
+			actions = append(actions, action)
+		}
+	}
+
+	if len(actions) == 0 {
+		actions = nil
+	}
+
+	metalCluster.Status.RemediationActions = actions
+}
+
+// Exhausted returns true if the cluster has no remediation actions left within the window.
+func Exhausted(metalCluster *infrav1.MetalCluster) bool {
+	budget := metalCluster.Spec.RemediationBudget
+
+	return budget != nil && len(metalCluster.Status.RemediationActions) >= budget.MaxActions
+}
+
+// Allow records the remediation action against the budget of the cluster.
+//
+// If the budget is exhausted, the action is not recorded, RemediationAllowed condition of the MetalCluster is set to False
+// and false is returned: the caller should not remediate, and retry later.
+//
+// The action is recorded with the optimistic lock (and retried on conflict), so that the concurrent remediations
+// of the cluster machines can't overrun the budget.
+func Allow(ctx context.Context, c client.Client, recorder record.EventRecorder, cluster *capiv1.Cluster, action, machine, server string) (bool, error) {
+	return allow(ctx, c, recorder, cluster, action, machine, server, true)
+}
+
+// Check checks whether the budget of the cluster allows the remediation action, without recording it.
+//
+// It's used for the actions which might fail (e.g. the power actions), the performed action is recorded with Record,
+// so that the failed attempts are not charged. If the budget is exhausted, the remediation is paused the same way as with Allow.
+func Check(ctx context.Context, c client.Client, recorder record.EventRecorder, cluster *capiv1.Cluster, action, machine string) (bool, error) {
+	return allow(ctx, c, recorder, cluster, action, machine, "", false)
+}
+
+// Record records the performed remediation action against the budget of the cluster, the action checked with Check.
+func Record(ctx context.Context, c client.Client, cluster *capiv1.Cluster, action, machine, server string) error {
+	infraRef := cluster.Spec.InfrastructureRef
+	if infraRef == nil || infraRef.Kind != "MetalCluster" {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		metalCluster := &infrav1.MetalCluster{}
+
+		if err := c.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: infraRef.Name}, metalCluster); err != nil {
+			return err
+		}
+
+		if metalCluster.Spec.RemediationBudget == nil {
+			return nil
+		}
+
+		patchBase := metalCluster.DeepCopy()
+		now := time.Now()
+
+		Prune(metalCluster, now)
+
+		metalCluster.Status.RemediationActions = append(metalCluster.Status.RemediationActions, infrav1.RemediationAction{
+			Time:    metav1.NewTime(now),
+			Action:  action,
+			Machine: machine,
+			Server:  server,
+		})
+
+		return c.Status().Patch(ctx, metalCluster, client.MergeFromWithOptions(patchBase, client.MergeFromWithOptimisticLock{}))
+	})
+}
+
+// Expire drops the remediation actions which fell out of the budget window from the MetalCluster.
+//
+// Actions are dropped with the optimistic lock (and retried on conflict), so that the actions recorded concurrently are not lost.
+func Expire(ctx context.Context, c client.Client, key types.NamespacedName) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		metalCluster := &infrav1.MetalCluster{}
+
+		if err := c.Get(ctx, key, metalCluster); err != nil {
+			return err
+		}
+
+		patchBase := metalCluster.DeepCopy()
+
+		Prune(metalCluster, time.Now())
+
+		if len(metalCluster.Status.RemediationActions) == len(patchBase.Status.RemediationActions) {
+			return nil
+		}
+
+		return c.Status().Patch(ctx, metalCluster, client.MergeFromWithOptions(patchBase, client.MergeFromWithOptimisticLock{}))
+	})
+}
+
+func allow(ctx context.Context, c client.Client, recorder record.EventRecorder, cluster *capiv1.Cluster, action, machine, server string, record bool) (bool, error) {
+	infraRef := cluster.Spec.InfrastructureRef
+	if infraRef == nil || infraRef.Kind != "MetalCluster" {
+		return true, nil
+	}
+
+	var (
+		allowed     bool
+		pausedEvent func()
+	)
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		metalCluster := &infrav1.MetalCluster{}
+		pausedEvent = nil
+
+		if err := c.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: infraRef.Name}, metalCluster); err != nil {
+			return err
+		}
+
+		if metalCluster.Spec.RemediationBudget == nil {
+			allowed = true
+
+			return nil
+		}
+
+		patchBase := metalCluster.DeepCopy()
+		now := time.Now()
+
+		Prune(metalCluster, now)
+
+		allowed = !Exhausted(metalCluster)
+
+		if allowed && record {
+			metalCluster.Status.RemediationActions = append(metalCluster.Status.RemediationActions, infrav1.RemediationAction{
+				Time:    metav1.NewTime(now),
+				Action:  action,
+				Machine: machine,
+				Server:  server,
+			})
+		}
+
+		if allowed {
+			conditions.MarkTrue(metalCluster, infrav1.RemediationAllowedCondition)
+		} else {
+			budget := metalCluster.Spec.RemediationBudget
+
+			notify := !conditions.IsFalse(metalCluster, infrav1.RemediationAllowedCondition)
+
+			conditions.MarkFalse(metalCluster, infrav1.RemediationAllowedCondition, infrav1.RemediationBudgetExhaustedReason, capiv1.ConditionSeverityWarning,
+				"%d remediation actions within %s, automated remediation is paused", len(metalCluster.Status.RemediationActions), budget.GetWindow())
+
+			if notify {
+				pausedEvent = func() {
+					recorder.Event(metalCluster, corev1.EventTypeWarning, infrav1.RemediationBudgetExhaustedReason,
+						fmt.Sprintf("%d remediation actions within %s, automated remediation is paused: %s of machine %q is not performed.",
+							len(metalCluster.Status.RemediationActions), budget.GetWindow(), action, machine))
+				}
+			}
+		}
+
+		return c.Status().Patch(ctx, metalCluster, client.MergeFromWithOptions(patchBase, client.MergeFromWithOptimisticLock{}))
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// the event is emitted once the condition change is persisted, not on each conflicting attempt
+	if pausedEvent != nil {
+		pausedEvent()
+	}
+
+	return allowed, nil
+}
@@ -0,0 +1,189 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package remediation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/remediation"
+)
+
+func TestAllow(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	metalCluster := &infrav1.MetalCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "management",
+			ResourceVersion: "1",
+		},
+		Spec: infrav1.MetalClusterSpec{
+			RemediationBudget: &infrav1.RemediationBudget{
+				MaxActions: 2,
+			},
+		},
+	}
+
+	cluster := &capiv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "management",
+		},
+		Spec: capiv1.ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{
+				Kind: "MetalCluster",
+				Name: "management",
+			},
+		},
+	}
+
+	ctx := context.Background()
+	c := fake.NewFakeClientWithScheme(scheme, metalCluster)
+	recorder := record.NewFakeRecorder(10)
+
+	for i, expected := range []bool{true, true, false, false} {
+		allowed, err := remediation.Allow(ctx, c, recorder, cluster, infrav1.RemediationActionReprovision, "machine", "server")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if allowed != expected {
+			t.Errorf("remediation %d: allowed = %v, want %v", i, allowed, expected)
+		}
+	}
+
+	var persisted infrav1.MetalCluster
+
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "management"}, &persisted); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(persisted.Status.RemediationActions) != 2 {
+		t.Errorf("recorded %d remediation actions, want 2", len(persisted.Status.RemediationActions))
+	}
+
+	if !conditions.IsFalse(&persisted, infrav1.RemediationAllowedCondition) {
+		t.Error("remediation is not paused")
+	}
+
+	// the event is emitted once, when the remediation is paused
+	if len(recorder.Events) != 1 {
+		t.Errorf("emitted %d events, want 1", len(recorder.Events))
+	}
+}
+
+func TestCheckRecordExpire(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	expired := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+
+	metalCluster := &infrav1.MetalCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "management",
+			ResourceVersion: "1",
+		},
+		Spec: infrav1.MetalClusterSpec{
+			RemediationBudget: &infrav1.RemediationBudget{
+				MaxActions: 1,
+				Window:     &metav1.Duration{Duration: time.Hour},
+			},
+		},
+		Status: infrav1.MetalClusterStatus{
+			RemediationActions: []infrav1.RemediationAction{
+				{Time: expired, Action: infrav1.RemediationActionPowerCycle, Machine: "machine", Server: "server"},
+			},
+		},
+	}
+
+	cluster := &capiv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "management",
+		},
+		Spec: capiv1.ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{
+				Kind: "MetalCluster",
+				Name: "management",
+			},
+		},
+	}
+
+	ctx := context.Background()
+	c := fake.NewFakeClientWithScheme(scheme, metalCluster)
+	recorder := record.NewFakeRecorder(10)
+	key := types.NamespacedName{Namespace: "default", Name: "management"}
+
+	actions := func() []infrav1.RemediationAction {
+		var persisted infrav1.MetalCluster
+
+		if err := c.Get(ctx, key, &persisted); err != nil {
+			t.Fatal(err)
+		}
+
+		return persisted.Status.RemediationActions
+	}
+
+	if err := remediation.Expire(ctx, c, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actions()) != 0 {
+		t.Fatal("expired action is not dropped")
+	}
+
+	// checks don't charge the budget
+	for i := 0; i < 2; i++ {
+		allowed, err := remediation.Check(ctx, c, recorder, cluster, infrav1.RemediationActionPowerCycle, "machine")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !allowed {
+			t.Fatalf("check %d: remediation is not allowed", i)
+		}
+	}
+
+	if len(actions()) != 0 {
+		t.Fatal("check recorded the remediation action")
+	}
+
+	if err := remediation.Record(ctx, c, cluster, infrav1.RemediationActionPowerCycle, "machine", "server"); err != nil {
+		t.Fatal(err)
+	}
+
+	if recorded := actions(); len(recorded) != 1 || recorded[0].Server != "server" {
+		t.Fatalf("unexpected remediation actions %v", recorded)
+	}
+
+	allowed, err := remediation.Check(ctx, c, recorder, cluster, infrav1.RemediationActionPowerCycle, "machine")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if allowed {
+		t.Error("remediation is allowed with the exhausted budget")
+	}
+}
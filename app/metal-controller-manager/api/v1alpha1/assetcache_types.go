@@ -0,0 +1,110 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	"net"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AssetCacheSpec defines the desired state of AssetCache.
+type AssetCacheSpec struct {
+	// URL is the base URL the servers download the cached assets from, e.g. "http://10.5.1.2:8080/sidero".
+	//
+	// Assets are served as <URL>/<environment>/<asset>, only http URLs are supported by all the network bootloaders.
+	URL string `json:"url"`
+	// UploadURL is the base URL the assets are uploaded to with HTTP PUT, defaults to URL.
+	// +optional
+	UploadURL string `json:"uploadURL,omitempty"`
+	// Subnets lists the networks of the servers booting from the cache in CIDR notation, e.g. "10.5.1.0/24".
+	Subnets []string `json:"subnets"`
+}
+
+// CachedEnvironment is the Environment uploaded to the cache.
+type CachedEnvironment struct {
+	Name string `json:"name"`
+	// Kernel is the URL of the cached kernel asset.
+	Kernel string `json:"kernel"`
+	// Initrd is the URL of the cached initrd asset.
+	Initrd string `json:"initrd"`
+}
+
+// AssetCacheStatus defines the observed state of AssetCache.
+type AssetCacheStatus struct {
+	// Environments lists the Environments with the assets uploaded to the cache.
+	Environments []CachedEnvironment `json:"environments,omitempty"`
+
+	// Error is the last error encountered while uploading the assets.
+	Error string `json:"error,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url",description="cache URL"
+// +kubebuilder:printcolumn:name="Subnets",type="string",JSONPath=".spec.subnets",description="subnets of the servers"
+// +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.error",description="last upload error"
+
+// AssetCache is the Schema for the assetcaches API.
+//
+// AssetCache is an HTTP server close to the servers (e.g. in the same rack), which is pre-populated
+// with the Environment assets, so that the servers of its subnets boot without fetching the assets across the network.
+type AssetCache struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AssetCacheSpec   `json:"spec,omitempty"`
+	Status AssetCacheStatus `json:"status,omitempty"`
+}
+
+// Contains checks whether the IP address belongs to the subnets of the cache.
+func (cache *AssetCache) Contains(ip net.IP) bool {
+	for _, subnet := range cache.Spec.Subnets {
+		_, network, err := net.ParseCIDR(subnet)
+		if err != nil {
+			continue
+		}
+
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Caches checks whether the current assets of the Environment are uploaded to the cache.
+func (cache *AssetCache) Caches(env *Environment) bool {
+	for _, cached := range cache.Status.Environments {
+		if cached.Name == env.Name {
+			return cached.Kernel == env.Spec.Kernel.URL && cached.Initrd == env.Spec.Initrd.URL
+		}
+	}
+
+	return false
+}
+
+// GetUploadURL returns the base URL the assets are uploaded to.
+func (cache *AssetCache) GetUploadURL() string {
+	if cache.Spec.UploadURL != "" {
+		return cache.Spec.UploadURL
+	}
+
+	return cache.Spec.URL
+}
+
+// +kubebuilder:object:root=true
+
+// AssetCacheList contains a list of AssetCache.
+type AssetCacheList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AssetCache `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AssetCache{}, &AssetCacheList{})
+}
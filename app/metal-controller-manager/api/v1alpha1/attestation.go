@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WipeAttestation records the proof of the last disk wipe of the server.
+//
+// Payload is the JSON document with the server UUID, the timestamp and the disks, the signature is computed over the payload as is,
+// so that the attestation can be verified without re-encoding it.
+type WipeAttestation struct {
+	// Timestamp is the time the agent finished the wipe.
+	Timestamp metav1.Time `json:"timestamp"`
+	// Disks lists the wiped disks.
+	Disks []DiskWipe `json:"disks,omitempty"`
+	// Payload is the signed JSON document of the attestation.
+	Payload []byte `json:"payload,omitempty"`
+	// Signature is the Ed25519 signature of the payload, empty if the signing key is not configured
+	// or the report wasn't authenticated with the one-time agent token of the server.
+	//
+	// The signature proves that Sidero recorded the report of the agent booted for the server, not the wipe itself.
+	// +optional
+	Signature []byte `json:"signature,omitempty"`
+	// KeyID is the SHA-256 fingerprint of the public key the signature is verified with.
+	// +optional
+	KeyID string `json:"keyID,omitempty"`
+}
+
+// DiskWipe describes the wipe of a single disk.
+type DiskWipe struct {
+	// Name is the kernel name of the disk, e.g. "sda".
+	Name string `json:"name"`
+	// Serial is the serial number of the disk.
+	Serial string `json:"serial,omitempty"`
+	// Partition is the name of the wiped partition (e.g. "STATE"), empty if the whole disk was wiped.
+	// +optional
+	Partition string `json:"partition,omitempty"`
+	// Method is the wipe method, e.g. "blksecdiscard", "fast" for the insecure wipe, or "verified"
+	// for the disk of the diskless server which was not modified since the last wipe.
+	Method string `json:"method"`
+	// SampleHash is the SHA-256 hash of the blocks sampled across the disk after the wipe.
+	SampleHash string `json:"sampleHash,omitempty"`
+	// Samples is the number of the sampled blocks.
+	Samples int `json:"samples,omitempty"`
+	// Zeroed is true when all the sampled blocks read back as zeros.
+	Zeroed bool `json:"zeroed"`
+}
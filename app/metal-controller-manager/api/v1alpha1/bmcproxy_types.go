@@ -0,0 +1,71 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BMCProxyType defines how the connections to the BMCs are proxied.
+//
+// +kubebuilder:validation:Enum=SSH;SOCKS5;HTTPConnect
+type BMCProxyType string
+
+const (
+	// BMCProxySSH proxies the connections via the SSH jump host.
+	//
+	// IPMI runs over UDP, which can't be forwarded over SSH, so ipmitool is run on the jump host instead.
+	BMCProxySSH BMCProxyType = "SSH"
+	// BMCProxySOCKS5 proxies the connections via the SOCKS5 proxy, only the management API is supported.
+	BMCProxySOCKS5 BMCProxyType = "SOCKS5"
+	// BMCProxyHTTPConnect proxies the connections via the HTTP proxy with the CONNECT method, only the management API is supported.
+	BMCProxyHTTPConnect BMCProxyType = "HTTPConnect"
+)
+
+// BMCProxySpec defines the desired state of BMCProxy.
+type BMCProxySpec struct {
+	Type BMCProxyType `json:"type"`
+	// Address is the host:port of the jump host or the proxy.
+	Address string `json:"address"`
+	// User is the SSH user or the proxy user name.
+	// +optional
+	User string `json:"user,omitempty"`
+	// SecretRef references the Secret with the credentials of the proxy.
+	//
+	// SSH jump host requires the "ssh-privatekey" and the "host-key" (in the authorized_keys format) keys,
+	// SOCKS5 and HTTP proxies use the "password" key, if set.
+	// +optional
+	SecretRef *corev1.SecretReference `json:"secretRef,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type",description="proxy type"
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.address",description="proxy address"
+
+// BMCProxy is the Schema for the bmcproxies API.
+//
+// BMCProxy describes the jump host or the proxy used to reach the BMCs of a site,
+// it's referenced by name from the Servers.
+type BMCProxy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec BMCProxySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BMCProxyList contains a list of BMCProxy.
+type BMCProxyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BMCProxy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BMCProxy{}, &BMCProxyList{})
+}
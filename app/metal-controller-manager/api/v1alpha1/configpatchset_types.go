@@ -0,0 +1,47 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConfigPatchSetSpec defines the desired state of ConfigPatchSet.
+type ConfigPatchSetSpec struct {
+	// Description is a human-readable description of the patch set.
+	// +optional
+	Description string `json:"description,omitempty"`
+	// ConfigPatches is the list of patches applied to the machine configuration.
+	ConfigPatches []ConfigPatches `json:"configPatches"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Description",type="string",JSONPath=".spec.description",description="description of the patch set"
+// +kubebuilder:printcolumn:name="Generation",type="integer",JSONPath=".metadata.generation",description="version of the patch set"
+
+// ConfigPatchSet is the Schema for the configpatchsets API.
+//
+// ConfigPatchSet is a reusable bundle of configuration patches referenced by name
+// from Servers, ServerClasses and MetalMachines.
+type ConfigPatchSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ConfigPatchSetSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConfigPatchSetList contains a list of ConfigPatchSet.
+type ConfigPatchSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConfigPatchSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConfigPatchSet{}, &ConfigPatchSetList{})
+}
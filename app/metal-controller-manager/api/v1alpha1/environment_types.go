@@ -11,9 +11,17 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// ForceChangeAnnotation allows replacing the assets of the Environment (or deleting it) while it is used by the servers being provisioned.
+const ForceChangeAnnotation = "metal.sidero.dev/force-change"
+
 type Asset struct {
 	URL    string `json:"url,omitempty"`
 	SHA512 string `json:"sha512,omitempty"`
+	// Mirrors are the alternative URLs of the same asset.
+	//
+	// Mirrors are probed along with the URL, and the asset is downloaded from the first healthy source
+	// (URL first, then mirrors in order), failing over to the next one if the download fails.
+	Mirrors []string `json:"mirrors,omitempty"`
 }
 
 type Kernel struct {
@@ -36,6 +44,8 @@ type AssetCondition struct {
 	Asset  `json:",inline"`
 	Status string `json:"status"`
 	Type   string `json:"type"`
+	// Reason is set when the asset is not ready, e.g. AssetChecksumMismatch.
+	Reason string `json:"reason,omitempty"`
 }
 
 // EnvironmentStatus defines the observed state of Environment.
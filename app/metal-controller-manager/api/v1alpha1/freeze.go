@@ -0,0 +1,268 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FreezeWindow is the period of time Sidero doesn't perform power actions on the server and doesn't reprovision it.
+//
+// Window is either the fixed time range (Start and End), or the recurring one (Schedule and Duration).
+type FreezeWindow struct {
+	// Start is the start of the fixed window.
+	// +optional
+	Start *metav1.Time `json:"start,omitempty"`
+	// End is the end of the fixed window, window without the end never expires.
+	// +optional
+	End *metav1.Time `json:"end,omitempty"`
+	// Schedule is the cron expression (minute, hour, day of month, month, day of week) of the recurring window start in UTC,
+	// e.g. `0 22 * * 5` starts the window every Friday at 22:00.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// Duration is the length of the recurring window.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// Reason is the free-form explanation of the freeze, it's reported in the Frozen condition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// ActiveUntil returns the end of the window if it's active at the moment.
+//
+// Zero end time means the window never expires.
+func (w *FreezeWindow) ActiveUntil(now time.Time) (end time.Time, active bool, err error) {
+	if w.Schedule == "" {
+		if w.Start == nil && w.End == nil {
+			return time.Time{}, false, fmt.Errorf("either start and end, or schedule should be set")
+		}
+
+		if w.Start != nil && now.Before(w.Start.Time) {
+			return time.Time{}, false, nil
+		}
+
+		if w.End == nil {
+			return time.Time{}, true, nil
+		}
+
+		return w.End.Time, now.Before(w.End.Time), nil
+	}
+
+	if w.Duration == nil || w.Duration.Duration < time.Minute {
+		return time.Time{}, false, fmt.Errorf("recurring window should be at least a minute long")
+	}
+
+	schedule, err := parseSchedule(w.Schedule)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	// the latest start within the duration gives the latest end of the window
+	now = now.UTC()
+
+	for start := now.Truncate(time.Minute); now.Sub(start) < w.Duration.Duration; start = start.Add(-time.Minute) {
+		if schedule.matches(start) {
+			return start.Add(w.Duration.Duration), true, nil
+		}
+	}
+
+	return time.Time{}, false, nil
+}
+
+// FrozenUntil returns the latest end of the freeze windows of the server active at the moment.
+//
+// Zero end time means the server is frozen until the window is removed.
+// Invalid windows freeze the server, so that the typo doesn't lift the freeze.
+func (s *Server) FrozenUntil(now time.Time) (end time.Time, frozen bool, reason string, err error) {
+	for i := range s.Spec.FreezeWindows {
+		window := &s.Spec.FreezeWindows[i]
+
+		windowEnd, active, windowErr := window.ActiveUntil(now)
+		if windowErr != nil {
+			return time.Time{}, true, window.Reason, fmt.Errorf("freeze window %d is invalid: %w", i, windowErr)
+		}
+
+		if !active || (frozen && (end.IsZero() || (!windowEnd.IsZero() && !windowEnd.After(end)))) {
+			continue
+		}
+
+		end, frozen, reason = windowEnd, true, window.Reason
+	}
+
+	return end, frozen, reason, nil
+}
+
+// IsFrozen checks whether the server is frozen at the moment.
+func (s *Server) IsFrozen(now time.Time) bool {
+	_, frozen, _, _ := s.FrozenUntil(now) //nolint: dogsled
+
+	return frozen
+}
+
+// NextFreeze returns the start of the next freeze window of the server within the day, zero time if there's none.
+func (s *Server) NextFreeze(now time.Time) time.Time {
+	var next time.Time
+
+	for i := range s.Spec.FreezeWindows {
+		start := s.Spec.FreezeWindows[i].nextStart(now)
+
+		if !start.IsZero() && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+
+	return next
+}
+
+// nextStart returns the start of the window after the moment within the day, zero time if there's none.
+func (w *FreezeWindow) nextStart(now time.Time) time.Time {
+	if w.Schedule == "" {
+		if w.Start != nil && w.Start.After(now) && w.Start.Sub(now) <= 24*time.Hour {
+			return w.Start.Time
+		}
+
+		return time.Time{}
+	}
+
+	schedule, err := parseSchedule(w.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+
+	now = now.UTC()
+
+	for start := now.Truncate(time.Minute).Add(time.Minute); start.Sub(now) <= 24*time.Hour; start = start.Add(time.Minute) {
+		if schedule.matches(start) {
+			return start
+		}
+	}
+
+	return time.Time{}
+}
+
+// schedule is the parsed cron expression, each field lists the allowed values.
+type schedule struct {
+	minutes, hours, daysOfMonth, months, daysOfWeek map[int]bool
+
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+func (s *schedule) matches(t time.Time) bool {
+	if !s.minutes[t.Minute()] || !s.hours[t.Hour()] || !s.months[int(t.Month())] {
+		return false
+	}
+
+	dayOfMonth, dayOfWeek := s.daysOfMonth[t.Day()], s.daysOfWeek[int(t.Weekday())]
+
+	// as in cron, restricted day of month and day of week are matched if either of them matches
+	switch {
+	case s.anyDayOfMonth:
+		return dayOfWeek
+	case s.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
+
+// parseSchedule parses the five-field cron expression.
+//
+// Fields support `*`, values, ranges (`1-5`), steps (`*/15`, `0-30/10`) and lists of them.
+func parseSchedule(expr string) (*schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q should have 5 fields, got %d", expr, len(fields))
+	}
+
+	var (
+		s   schedule
+		err error
+	)
+
+	s.anyDayOfMonth = strings.HasPrefix(fields[2], "*")
+	s.anyDayOfWeek = strings.HasPrefix(fields[4], "*")
+
+	for _, field := range []struct {
+		values   *map[int]bool
+		min, max int
+	}{
+		{&s.minutes, 0, 59},
+		{&s.hours, 0, 23},
+		{&s.daysOfMonth, 1, 31},
+		{&s.months, 1, 12},
+		{&s.daysOfWeek, 0, 7},
+	} {
+		if *field.values, err = parseScheduleField(fields[0], field.min, field.max); err != nil {
+			return nil, fmt.Errorf("schedule %q is invalid: %w", expr, err)
+		}
+
+		fields = fields[1:]
+	}
+
+	// 7 is Sunday as well
+	if s.daysOfWeek[7] {
+		s.daysOfWeek[0] = true
+	}
+
+	return &s, nil
+}
+
+func parseScheduleField(field string, min, max int) (map[int]bool, error) {
+	values := map[int]bool{}
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+
+			part = part[:i]
+		}
+
+		from, to := min, max
+
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			var err error
+
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid value in %q", part)
+			}
+
+			to = from
+
+			if step > 1 {
+				// `5/10` is the same as `5-max/10`
+				to = max
+			}
+
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid value in %q", part)
+				}
+			}
+		}
+
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,137 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package v1alpha1_test
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestServerFrozenUntil(t *testing.T) {
+	// Friday
+	now := time.Date(2021, 4, 16, 23, 30, 0, 0, time.UTC)
+
+	at := func(d time.Duration) *metav1.Time {
+		t := metav1.NewTime(now.Add(d))
+
+		return &t
+	}
+
+	for _, tt := range []struct {
+		name       string
+		windows    []v1alpha1.FreezeWindow
+		wantFrozen bool
+		wantEnd    time.Time
+		wantErr    bool
+	}{
+		{
+			name: "no windows",
+		},
+		{
+			name:       "fixed window",
+			windows:    []v1alpha1.FreezeWindow{{Start: at(-time.Hour), End: at(time.Hour)}},
+			wantFrozen: true,
+			wantEnd:    now.Add(time.Hour),
+		},
+		{
+			name:    "fixed window ended",
+			windows: []v1alpha1.FreezeWindow{{Start: at(-2 * time.Hour), End: at(-time.Hour)}},
+		},
+		{
+			name:    "fixed window not started",
+			windows: []v1alpha1.FreezeWindow{{Start: at(time.Hour)}},
+		},
+		{
+			name:       "open window",
+			windows:    []v1alpha1.FreezeWindow{{Start: at(-time.Hour)}},
+			wantFrozen: true,
+		},
+		{
+			name:       "recurring window",
+			windows:    []v1alpha1.FreezeWindow{{Schedule: "0 22 * * 5", Duration: &metav1.Duration{Duration: 4 * time.Hour}}},
+			wantFrozen: true,
+			wantEnd:    time.Date(2021, 4, 17, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "recurring window on another day",
+			windows: []v1alpha1.FreezeWindow{{Schedule: "0 22 * * 1-4", Duration: &metav1.Duration{Duration: 4 * time.Hour}}},
+		},
+		{
+			name:    "recurring window too short",
+			windows: []v1alpha1.FreezeWindow{{Schedule: "0 22 * * 5", Duration: &metav1.Duration{Duration: time.Hour}}},
+		},
+		{
+			name:       "step schedule",
+			windows:    []v1alpha1.FreezeWindow{{Schedule: "*/20 1-23/2 * * *", Duration: &metav1.Duration{Duration: 15 * time.Minute}}},
+			wantFrozen: true,
+			wantEnd:    time.Date(2021, 4, 16, 23, 35, 0, 0, time.UTC),
+		},
+		{
+			name: "latest end wins",
+			windows: []v1alpha1.FreezeWindow{
+				{Start: at(-time.Hour), End: at(time.Hour)},
+				{Schedule: "0 22 16 4 *", Duration: &metav1.Duration{Duration: 4 * time.Hour}},
+			},
+			wantFrozen: true,
+			wantEnd:    time.Date(2021, 4, 17, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "invalid schedule freezes",
+			windows:    []v1alpha1.FreezeWindow{{Schedule: "0 25 * * *", Duration: &metav1.Duration{Duration: time.Hour}}},
+			wantFrozen: true,
+			wantErr:    true,
+		},
+		{
+			name:       "empty window freezes",
+			windows:    []v1alpha1.FreezeWindow{{}},
+			wantFrozen: true,
+			wantErr:    true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := v1alpha1.Server{
+				Spec: v1alpha1.ServerSpec{
+					FreezeWindows: tt.windows,
+				},
+			}
+
+			end, frozen, _, err := server.FrozenUntil(now)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if frozen != tt.wantFrozen {
+				t.Errorf("frozen = %v, want %v", frozen, tt.wantFrozen)
+			}
+
+			if !end.Equal(tt.wantEnd) {
+				t.Errorf("end = %s, want %s", end, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestServerNextFreeze(t *testing.T) {
+	now := time.Date(2021, 4, 16, 23, 30, 0, 0, time.UTC)
+
+	server := v1alpha1.Server{
+		Spec: v1alpha1.ServerSpec{
+			FreezeWindows: []v1alpha1.FreezeWindow{
+				{Schedule: "0 2 * * *", Duration: &metav1.Duration{Duration: time.Hour}},
+				{Schedule: "0 0 1 1 *", Duration: &metav1.Duration{Duration: time.Hour}},
+			},
+		},
+	}
+
+	if next := server.NextFreeze(now); !next.Equal(time.Date(2021, 4, 17, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected next freeze %s", next)
+	}
+}
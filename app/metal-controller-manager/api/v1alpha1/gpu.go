@@ -0,0 +1,83 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// GPUInformation describes the GPUs of the same model installed in the server.
+type GPUInformation struct {
+	// Vendor is the GPU vendor: NVIDIA, AMD or Intel, the PCI vendor ID for the other vendors.
+	Vendor string `json:"vendor"`
+	// Model is the GPU model, e.g. "A100-SXM4-40GB", or the PCI device ID if the model is not known to Sidero.
+	Model string `json:"model"`
+	// Count is the number of the GPUs of the model.
+	Count int `json:"count"`
+	// VRAM is the memory of a single GPU, if known for the model.
+	// +optional
+	VRAM *resource.Quantity `json:"vram,omitempty"`
+}
+
+// GPUQualifier requires the server to have the GPUs matching the qualifier.
+//
+// All the set fields should match, values prefixed with `~` are regular expressions matching the whole value.
+type GPUQualifier struct {
+	// Vendor is the GPU vendor, e.g. "NVIDIA".
+	// +optional
+	Vendor string `json:"vendor,omitempty"`
+	// Model is the GPU model, e.g. "~A100-.*".
+	// +optional
+	Model string `json:"model,omitempty"`
+	// MinVRAM is the minimum memory of a single GPU, GPUs with the unknown memory don't match.
+	// +optional
+	MinVRAM *resource.Quantity `json:"minVRAM,omitempty"`
+	// Count is the minimum number of the matching GPUs, defaults to 1.
+	// +optional
+	Count int `json:"count,omitempty"`
+}
+
+// MatchesGPU checks whether the GPU model satisfies the qualifier.
+func (q *GPUQualifier) MatchesGPU(gpu *GPUInformation) bool {
+	if !matchesValue(q.Vendor, gpu.Vendor) || !matchesValue(q.Model, gpu.Model) {
+		return false
+	}
+
+	if q.MinVRAM != nil && (gpu.VRAM == nil || gpu.VRAM.Cmp(*q.MinVRAM) < 0) {
+		return false
+	}
+
+	return true
+}
+
+// Matches checks whether there are enough GPUs matching the qualifier.
+func (q *GPUQualifier) Matches(gpus []GPUInformation) bool {
+	count := q.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	for i := range gpus {
+		if q.MatchesGPU(&gpus[i]) {
+			count -= gpus[i].Count
+		}
+	}
+
+	return count <= 0
+}
+
+// matchesValue checks whether the value equals the pattern, or matches it if it's the regular expression; empty pattern matches any value.
+func matchesValue(pattern, value string) bool {
+	switch {
+	case pattern == "":
+		return true
+	case strings.HasPrefix(pattern, RegexQualifierPrefix):
+		return matchesRegex(strings.TrimPrefix(pattern, RegexQualifierPrefix), value)
+	default:
+		return pattern == value
+	}
+}
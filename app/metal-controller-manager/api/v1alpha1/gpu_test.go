@@ -0,0 +1,73 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestGPUQualifierMatches(t *testing.T) {
+	vram := resource.MustParse("40Gi")
+	minVRAM := resource.MustParse("32Gi")
+	tooMuchVRAM := resource.MustParse("80Gi")
+
+	gpus := []v1alpha1.GPUInformation{
+		{Vendor: "NVIDIA", Model: "A100-SXM4-40GB", Count: 4, VRAM: &vram},
+		{Vendor: "NVIDIA", Model: "1234", Count: 2},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		qualifier v1alpha1.GPUQualifier
+		want      bool
+	}{
+		{
+			name: "any GPU",
+			want: true,
+		},
+		{
+			name:      "model regex and count",
+			qualifier: v1alpha1.GPUQualifier{Model: "~A100-.*", Count: 4},
+			want:      true,
+		},
+		{
+			name:      "not enough GPUs of the model",
+			qualifier: v1alpha1.GPUQualifier{Model: "~A100-.*", Count: 8},
+		},
+		{
+			name:      "vendor counts all models",
+			qualifier: v1alpha1.GPUQualifier{Vendor: "NVIDIA", Count: 6},
+			want:      true,
+		},
+		{
+			name:      "VRAM",
+			qualifier: v1alpha1.GPUQualifier{MinVRAM: &minVRAM, Count: 4},
+			want:      true,
+		},
+		{
+			name:      "unknown VRAM doesn't count",
+			qualifier: v1alpha1.GPUQualifier{MinVRAM: &minVRAM, Count: 5},
+		},
+		{
+			name:      "not enough VRAM",
+			qualifier: v1alpha1.GPUQualifier{MinVRAM: &tooMuchVRAM},
+		},
+		{
+			name:      "vendor mismatch",
+			qualifier: v1alpha1.GPUQualifier{Vendor: "AMD"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.qualifier.Matches(gpus); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// MemoryInformation describes the memory modules of the server discovered by the agent.
+type MemoryInformation struct {
+	// Size is the total size of the installed memory modules in bytes.
+	Size uint64 `json:"size,omitempty"`
+	// Modules is the number of the installed memory modules.
+	Modules int `json:"modules,omitempty"`
+}
+
+// MemoryQualifier selects the servers by the installed memory.
+//
+// All the set fields should match.
+type MemoryQualifier struct {
+	// MinSize is the minimum total size of the memory.
+	// +optional
+	MinSize *resource.Quantity `json:"minSize,omitempty"`
+	// MaxSize is the maximum total size of the memory.
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+	// MinModules is the minimum number of the memory modules.
+	// +optional
+	MinModules int `json:"minModules,omitempty"`
+	// MaxModules is the maximum number of the memory modules.
+	// +optional
+	MaxModules int `json:"maxModules,omitempty"`
+}
+
+// Matches checks whether the memory satisfies the qualifier, servers with the unknown memory don't match.
+func (q *MemoryQualifier) Matches(memory *MemoryInformation) bool {
+	if memory == nil {
+		return false
+	}
+
+	if q.MinSize != nil && memory.Size < uint64(q.MinSize.Value()) {
+		return false
+	}
+
+	if q.MaxSize != nil && memory.Size > uint64(q.MaxSize.Value()) {
+		return false
+	}
+
+	if q.MinModules > 0 && memory.Modules < q.MinModules {
+		return false
+	}
+
+	if q.MaxModules > 0 && memory.Modules > q.MaxModules {
+		return false
+	}
+
+	return true
+}
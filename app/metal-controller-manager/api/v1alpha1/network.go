@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+// NetworkInterfaceQualifier requires the server to have the network interfaces matching the qualifier.
+//
+// All the set fields should match.
+type NetworkInterfaceQualifier struct {
+	// MinSpeed is the minimum link speed of the interface in Mbps, e.g. 25000 for 25 Gbps.
+	// +optional
+	MinSpeed int `json:"minSpeed,omitempty"`
+	// Driver is the kernel driver of the interface, e.g. "mlx5_core".
+	// +optional
+	Driver string `json:"driver,omitempty"`
+	// VendorID is the PCI vendor ID of the interface, e.g. "15b3".
+	// +optional
+	VendorID string `json:"vendorID,omitempty"`
+	// DeviceID is the PCI device ID of the interface, e.g. "1017".
+	// +optional
+	DeviceID string `json:"deviceID,omitempty"`
+	// Count is the minimum number of the matching interfaces, defaults to 1.
+	// +optional
+	Count int `json:"count,omitempty"`
+}
+
+// MatchesInterface checks whether the network interface satisfies the qualifier.
+func (q *NetworkInterfaceQualifier) MatchesInterface(iface *NetworkInterface) bool {
+	if q.MinSpeed > 0 && iface.Speed < q.MinSpeed {
+		return false
+	}
+
+	if q.Driver != "" && iface.Driver != q.Driver {
+		return false
+	}
+
+	if q.VendorID != "" && iface.VendorID != q.VendorID {
+		return false
+	}
+
+	if q.DeviceID != "" && iface.DeviceID != q.DeviceID {
+		return false
+	}
+
+	return true
+}
+
+// Matches checks whether there are enough network interfaces matching the qualifier.
+func (q *NetworkInterfaceQualifier) Matches(interfaces []NetworkInterface) bool {
+	count := q.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	for i := range interfaces {
+		if q.MatchesInterface(&interfaces[i]) {
+			count--
+		}
+	}
+
+	return count <= 0
+}
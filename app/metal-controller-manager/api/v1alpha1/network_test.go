@@ -0,0 +1,59 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestNetworkInterfaceQualifierMatches(t *testing.T) {
+	interfaces := []v1alpha1.NetworkInterface{
+		{Name: "eno1", Speed: 1000, Driver: "igb", VendorID: "8086", DeviceID: "1521"},
+		{Name: "enp65s0f0", Speed: 25000, Driver: "mlx5_core", VendorID: "15b3", DeviceID: "1017"},
+		{Name: "enp65s0f1", Driver: "mlx5_core", VendorID: "15b3", DeviceID: "1017"},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		qualifier v1alpha1.NetworkInterfaceQualifier
+		want      bool
+	}{
+		{
+			name: "any interface",
+			want: true,
+		},
+		{
+			name:      "speed",
+			qualifier: v1alpha1.NetworkInterfaceQualifier{MinSpeed: 25000},
+			want:      true,
+		},
+		{
+			name:      "link down doesn't count",
+			qualifier: v1alpha1.NetworkInterfaceQualifier{MinSpeed: 25000, Count: 2},
+		},
+		{
+			name:      "vendor count",
+			qualifier: v1alpha1.NetworkInterfaceQualifier{VendorID: "15b3", DeviceID: "1017", Count: 2},
+			want:      true,
+		},
+		{
+			name:      "driver",
+			qualifier: v1alpha1.NetworkInterfaceQualifier{Driver: "ice"},
+		},
+		{
+			name:      "interface count",
+			qualifier: v1alpha1.NetworkInterfaceQualifier{Count: 4},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.qualifier.Matches(interfaces); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
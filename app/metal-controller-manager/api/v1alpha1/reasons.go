@@ -0,0 +1,89 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+// Failure reasons are set on the conditions and used as the reasons of the warning events,
+// so that alerts can be routed on the reason.
+const (
+	// BMCAuthFailedReason is used when the BMC rejects the credentials of the server.
+	BMCAuthFailedReason = "BMCAuthFailed"
+	// PowerManagementFailedReason is used when the BMC or the management API fails the power request for any other reason.
+	PowerManagementFailedReason = "PowerManagementFailed"
+	// AssetChecksumMismatchReason is used when the downloaded environment asset doesn't match its SHA512 checksum.
+	AssetChecksumMismatchReason = "AssetChecksumMismatch"
+	// AssetDownloadFailedReason is used when the environment asset can't be downloaded from any of its sources.
+	AssetDownloadFailedReason = "AssetDownloadFailed"
+	// WipeTimeoutReason is used when the server wasn't wiped within the wipe timeout after all the retries.
+	WipeTimeoutReason = "WipeTimeout"
+)
+
+// Approval reasons are set on the AwaitingApproval condition and used as the reasons of the events requesting the approval.
+const (
+	// PowerOffApprovalRequiredReason is used when the power off of the server waits for the approval.
+	PowerOffApprovalRequiredReason = "PowerOffApprovalRequired"
+	// WipeApprovalRequiredReason is used when the wipe of the server waits for the approval.
+	WipeApprovalRequiredReason = "WipeApprovalRequired"
+)
+
+// Manual power reasons are set on the ManualPowerAction condition of the Server, the reason is kept
+// once the operator acknowledges the action, so that it's known which action was performed.
+const (
+	// PowerOffRequiredReason is used when the operator should shut down the server.
+	PowerOffRequiredReason = "PowerOffRequired"
+	// PowerRestoreRequiredReason is used when the operator should power the server back on after the requested power off.
+	PowerRestoreRequiredReason = "PowerRestoreRequired"
+	// InventoryRefreshRequiredReason is used when the operator should reboot the server without power management to refresh the stale inventory.
+	InventoryRefreshRequiredReason = "InventoryRefreshRequired"
+)
+
+// Overlap reasons are set on the Overlapping condition of the ServerClass.
+const (
+	// OverlappingServerClassesReason is used when the servers are shared with the ServerClasses of different priorities.
+	OverlappingServerClassesReason = "OverlappingServerClasses"
+	// PriorityConflictReason is used when the servers are shared with the ServerClasses of the same priority,
+	// so the allocation order between them is not defined.
+	PriorityConflictReason = "PriorityConflict"
+)
+
+// Capacity reasons are set on the CapacityInsufficient and CapacityLow conditions of the ServerClass.
+const (
+	// CapacityShortfallReason is used when the desired replicas of the MachineDeployments exceed the available servers.
+	CapacityShortfallReason = "CapacityShortfall"
+
+	// CapacityLowReason is used when the number of the free servers of the ServerClass drops below MinimumAvailable.
+	CapacityLowReason = "CapacityLow"
+)
+
+// Qualifier reasons are set on the QualifierError and Ready conditions of the ServerClass.
+const (
+	// InvalidExpressionReason is used when the qualifier or the exclusion expression of the ServerClass can't be compiled.
+	InvalidExpressionReason = "InvalidExpression"
+	// IncludedClassNotFoundReason is used when some of the ServerClasses listed in IncludeClasses don't exist.
+	IncludedClassNotFoundReason = "IncludedClassNotFound"
+	// ServerClassProposedReason is used when the ServerClass is proposed by the hardware profiler and not approved yet.
+	ServerClassProposedReason = "Proposed"
+)
+
+// Availability reasons are set on the CapacityAvailable condition of the ServerClass.
+const (
+	// NoServersAvailableReason is used when all the servers of the ServerClass are in use (or the ServerClass matches no servers).
+	NoServersAvailableReason = "NoServersAvailable"
+	// ServersWipingReason is used when all the available servers of the ServerClass are still being wiped.
+	ServersWipingReason = "ServersWiping"
+)
+
+// Inventory reasons are set on the Stale condition of the Server.
+const (
+	// InventoryStaleReason is used when the last hardware inventory of the server is older than the inventory TTL.
+	InventoryStaleReason = "InventoryStale"
+)
+
+// Freeze reasons are set on the Frozen condition of the Server.
+const (
+	// FreezeWindowActiveReason is used when the freeze window of the server is active.
+	FreezeWindowActiveReason = "FreezeWindowActive"
+	// FreezeWindowInvalidReason is used when the freeze window of the server can't be parsed, such server stays frozen.
+	FreezeWindowInvalidReason = "FreezeWindowInvalid"
+)
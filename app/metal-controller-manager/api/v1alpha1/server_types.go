@@ -5,7 +5,10 @@
 package v1alpha1
 
 import (
+	"fmt"
 	"reflect"
+	"regexp"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -20,13 +23,59 @@ type BMC struct {
 	Endpoint string `json:"endpoint"`
 	User     string `json:"user"`
 	Pass     string `json:"pass"`
+	// Quirks selects the vendor quirks profile of the BMC, by default the profile is selected
+	// from the SMBIOS manufacturer of the server.
+	// +optional
+	Quirks BMCQuirks `json:"quirks,omitempty"`
+	// Interface selects the protocol the BMC is managed with, defaults to IPMI.
+	// +optional
+	Interface BMCInterface `json:"interface,omitempty"`
+	// FallbackInterface selects the protocol the BMC is managed with once the power management keeps failing
+	// over the Interface, e.g. Redfish for the BMCs with IPMI disabled.
+	// +optional
+	FallbackInterface BMCInterface `json:"fallbackInterface,omitempty"`
+	// InsecureSkipVerify skips the verification of the Redfish endpoint TLS certificate (BMCs usually have self-signed certificates).
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
 }
 
+// BMCInterface is the protocol the BMC is managed with.
+//
+// +kubebuilder:validation:Enum=ipmi;redfish
+type BMCInterface string
+
+const (
+	// BMCInterfaceIPMI manages the BMC over IPMI (lanplus).
+	BMCInterfaceIPMI BMCInterface = "ipmi"
+	// BMCInterfaceRedfish manages the BMC over the Redfish REST API.
+	BMCInterfaceRedfish BMCInterface = "redfish"
+)
+
+// BMCQuirks is the vendor quirks profile adjusting the power management sequences to the BMC peculiarities.
+//
+// +kubebuilder:validation:Enum=Generic;Supermicro;Dell;HPE
+type BMCQuirks string
+
+const (
+	// BMCQuirksGeneric uses the standard IPMI sequences.
+	BMCQuirksGeneric BMCQuirks = "Generic"
+	// BMCQuirksSupermicro waits for the boot device to settle before the power change.
+	BMCQuirksSupermicro BMCQuirks = "Supermicro"
+	// BMCQuirksDell power cycles the server as power off and power on, as iDRAC rejects the power cycle during POST.
+	BMCQuirksDell BMCQuirks = "Dell"
+	// BMCQuirksHPE sets the boot device without the EFI flag, which iLO ignores, and waits for the boot device to settle.
+	BMCQuirksHPE BMCQuirks = "HPE"
+)
+
 // ManagementAPI defines data about how to talk to the node via simple HTTP API.
 type ManagementAPI struct {
 	Endpoint string `json:"endpoint"`
 }
 
+// SystemInformation describes the SMBIOS system information of the server.
+//
+// When used as the qualifier, values prefixed with `~` are regular expressions matching the whole value,
+// e.g. `~PowerEdge R6.*` matches the whole family of the server models.
 type SystemInformation struct {
 	Manufacturer string `json:"manufacturer,omitempty"`
 	ProductName  string `json:"productName,omitempty"`
@@ -40,13 +89,65 @@ func (a *SystemInformation) PartialEqual(b *SystemInformation) bool {
 	return PartialEqual(a, b)
 }
 
+// CPUInformation describes the processors of the server.
+//
+// When used as the qualifier, range fields select the servers by the capability thresholds,
+// as the model strings drift across the firmware versions.
 type CPUInformation struct {
 	Manufacturer string `json:"manufacturer,omitempty"`
 	Version      string `json:"version,omitempty"`
+	// Cores is the total number of the cores of all the processors.
+	// +optional
+	Cores int `json:"cores,omitempty"`
+	// Threads is the total number of the threads of all the processors.
+	// +optional
+	Threads int `json:"threads,omitempty"`
+	// FrequencyMHz is the maximum frequency of the processors in MHz.
+	// +optional
+	FrequencyMHz int `json:"frequencyMHz,omitempty"`
+
+	// MinThreads is the minimum number of the threads, only used in the qualifiers.
+	// +optional
+	MinThreads int `json:"minThreads,omitempty"`
+	// MaxThreads is the maximum number of the threads, only used in the qualifiers.
+	// +optional
+	MaxThreads int `json:"maxThreads,omitempty"`
+	// MinCores is the minimum number of the cores, only used in the qualifiers.
+	// +optional
+	MinCores int `json:"minCores,omitempty"`
+	// MinFrequencyMHz is the minimum frequency in MHz, only used in the qualifiers.
+	// +optional
+	MinFrequencyMHz int `json:"minFrequencyMHz,omitempty"`
 }
 
+// PartialEqual checks whether the CPU b matches the qualifier a.
+//
+// Range fields are checked against the reported values, servers with the unknown values don't match the ranges.
 func (a *CPUInformation) PartialEqual(b *CPUInformation) bool {
-	return PartialEqual(a, b)
+	exact := *a
+	exact.MinThreads, exact.MaxThreads, exact.MinCores, exact.MinFrequencyMHz = 0, 0, 0, 0
+
+	if !PartialEqual(&exact, b) {
+		return false
+	}
+
+	if a.MinThreads > 0 && b.Threads < a.MinThreads {
+		return false
+	}
+
+	if a.MaxThreads > 0 && (b.Threads == 0 || b.Threads > a.MaxThreads) {
+		return false
+	}
+
+	if a.MinCores > 0 && b.Cores < a.MinCores {
+		return false
+	}
+
+	if a.MinFrequencyMHz > 0 && b.FrequencyMHz < a.MinFrequencyMHz {
+		return false
+	}
+
+	return true
 }
 
 func PartialEqual(a, b interface{}) bool {
@@ -71,6 +172,14 @@ func PartialEqual(a, b interface{}) bool {
 		f1 := old.Field(i).Interface()
 		f2 := new.Field(i).Interface()
 
+		if pattern, ok := f1.(string); ok && strings.HasPrefix(pattern, RegexQualifierPrefix) {
+			if !matchesRegex(strings.TrimPrefix(pattern, RegexQualifierPrefix), f2.(string)) {
+				return false
+			}
+
+			continue
+		}
+
 		if f1 != f2 {
 			return false
 		}
@@ -79,6 +188,158 @@ func PartialEqual(a, b interface{}) bool {
 	return true
 }
 
+// RegexQualifierPrefix marks the qualifier value as the regular expression.
+const RegexQualifierPrefix = "~"
+
+// ValidatePartial checks that the regular expressions in the values of the qualifier compile.
+func ValidatePartial(a interface{}) error {
+	v := reflect.ValueOf(a)
+
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		pattern, ok := v.Field(i).Interface().(string)
+		if !ok || !strings.HasPrefix(pattern, RegexQualifierPrefix) {
+			continue
+		}
+
+		if _, err := compileRegex(strings.TrimPrefix(pattern, RegexQualifierPrefix)); err != nil {
+			return fmt.Errorf("%s: %w", v.Type().Field(i).Name, err)
+		}
+	}
+
+	return nil
+}
+
+// matchesRegex checks whether the whole value matches the regular expression, invalid expressions don't match anything.
+func matchesRegex(pattern, value string) bool {
+	re, err := compileRegex(pattern)
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(value)
+}
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	// the pattern is checked as is, so that the errors don't mention the anchors
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, err
+	}
+
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// LLDPNeighbor describes the network peer seen by the server NIC via LLDP.
+type LLDPNeighbor struct {
+	// Interface is the name of the server network interface which received LLDP advertisement.
+	Interface string `json:"interface"`
+	// ChassisID is the chassis ID of the neighbor (usually the switch MAC address).
+	ChassisID string `json:"chassisId,omitempty"`
+	// PortID is the ID of the neighbor port the interface is connected to.
+	PortID string `json:"portId,omitempty"`
+	// PortDescription is the description of the neighbor port.
+	PortDescription string `json:"portDescription,omitempty"`
+	// SystemName is the name of the neighbor (usually the switch hostname).
+	SystemName string `json:"systemName,omitempty"`
+}
+
+// NetworkInterface describes the server network interface discovered by the agent.
+type NetworkInterface struct {
+	// Name is the name of the interface.
+	Name string `json:"name"`
+	// MAC is the hardware address of the interface.
+	MAC string `json:"mac"`
+	// Speed is the link speed of the interface in Mbps, zero if the link was down.
+	Speed int `json:"speed,omitempty"`
+	// Driver is the kernel driver of the interface, e.g. "ixgbe".
+	Driver string `json:"driver,omitempty"`
+	// VendorID is the PCI vendor ID of the interface, e.g. "15b3".
+	VendorID string `json:"vendorID,omitempty"`
+	// DeviceID is the PCI device ID of the interface, e.g. "1017".
+	DeviceID string `json:"deviceID,omitempty"`
+}
+
+// PCI device types.
+const (
+	PCIDeviceTypeNVMe         = "nvme"
+	PCIDeviceTypeSAS          = "sas"
+	PCIDeviceTypeRAID         = "raid"
+	PCIDeviceTypeFibreChannel = "fibre-channel"
+	PCIDeviceTypeNetwork      = "network"
+	PCIDeviceTypeGPU          = "gpu"
+	PCIDeviceTypeAccelerator  = "accelerator"
+	PCIDeviceTypeOther        = "other"
+)
+
+// PCIDeviceInformation describes the PCI device.
+//
+// IDs are lowercase hexadecimal numbers without the 0x prefix, e.g. "15b3".
+type PCIDeviceInformation struct {
+	// Type is the kind of the device derived from its class: nvme, sas, raid, fibre-channel, network, gpu, accelerator or other.
+	Type string `json:"type,omitempty"`
+	// Class is the PCI class code of the device (class, subclass and programming interface), e.g. "010802".
+	Class             string `json:"class,omitempty"`
+	VendorID          string `json:"vendorId,omitempty"`
+	DeviceID          string `json:"deviceId,omitempty"`
+	SubsystemVendorID string `json:"subsystemVendorId,omitempty"`
+	SubsystemDeviceID string `json:"subsystemDeviceId,omitempty"`
+	// Driver is the name of the kernel driver bound to the device, e.g. "mpt3sas".
+	Driver string `json:"driver,omitempty"`
+	// Model is the model name reported by the device, if available (e.g. NVMe controllers).
+	Model string `json:"model,omitempty"`
+}
+
+func (a *PCIDeviceInformation) PartialEqual(b *PCIDeviceInformation) bool {
+	return PartialEqual(a, b)
+}
+
+// PCIDevice describes the PCI device (add-in card) discovered by the agent.
+type PCIDevice struct {
+	// Address is the PCI address of the device, e.g. "0000:3b:00.0".
+	Address string `json:"address"`
+
+	PCIDeviceInformation `json:",inline"`
+
+	// Namespaces is the number of namespaces of the NVMe controller.
+	Namespaces int `json:"namespaces,omitempty"`
+}
+
+// CablingRule defines the set of neighbors the server interface is expected to be connected to.
+//
+// Rule is satisfied if the interface sees at least one neighbor matching any of the system names or chassis IDs.
+type CablingRule struct {
+	// Interface is the name of the server network interface.
+	Interface string `json:"interface"`
+	// SystemNames lists acceptable neighbor system names.
+	SystemNames []string `json:"systemNames,omitempty"`
+	// ChassisIDs lists acceptable neighbor chassis IDs.
+	ChassisIDs []string `json:"chassisIds,omitempty"`
+}
+
+// Matches checks whether the neighbor satisfies the rule.
+func (rule *CablingRule) Matches(neighbor *LLDPNeighbor) bool {
+	if neighbor.Interface != rule.Interface {
+		return false
+	}
+
+	for _, name := range rule.SystemNames {
+		if neighbor.SystemName == name {
+			return true
+		}
+	}
+
+	for _, id := range rule.ChassisIDs {
+		if strings.EqualFold(neighbor.ChassisID, id) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // ServerSpec defines the desired state of Server.
 type ServerSpec struct {
 	EnvironmentRef    *corev1.ObjectReference `json:"environmentRef,omitempty"`
@@ -88,15 +349,341 @@ type ServerSpec struct {
 	BMC               *BMC                    `json:"bmc,omitempty"`
 	ManagementAPI     *ManagementAPI          `json:"managementApi,omitempty"`
 	ConfigPatches     []ConfigPatches         `json:"configPatches,omitempty"`
+	ConfigPatchSets   []string                `json:"configPatchSets,omitempty"`
 	Accepted          bool                    `json:"accepted"`
 	PXEBootAlways     bool                    `json:"pxeBootAlways,omitempty"`
+	// GPUs lists the GPUs discovered by the agent, grouped by the model.
+	// +optional
+	GPUs []GPUInformation `json:"gpus,omitempty"`
+	// ManualPowerManagement marks the server as not having any power management (no BMC, no management API).
+	//
+	// Sidero skips power actions for such servers, and instead requests the operator to perform them
+	// via the ManualPowerAction condition and events.
+	// Operator acknowledges the action by setting the PowerActionAcknowledgedAnnotation on the server.
+	ManualPowerManagement bool `json:"manualPowerManagement,omitempty"`
+	// CablingRules are validated against LLDP neighbors reported by the agent.
+	//
+	// If any of the rules is not satisfied, the CablingInvalid condition is set and the server is not allocated.
+	CablingRules []CablingRule `json:"cablingRules,omitempty"`
+	// PXEMode defines how the server is made to PXE boot when Sidero needs it.
+	//
+	// If not set, the default mode of the controller manager is used.
+	PXEMode PXEMode `json:"pxeMode,omitempty"`
+	// ProvisioningInterface is the MAC address of the network interface used to provision the server.
+	//
+	// If not set, the interface the server PXE booted from is used.
+	ProvisioningInterface string `json:"provisioningInterface,omitempty"`
+	// Network separates the provisioning network from the workload network of the server.
+	//
+	// Overrides the network configuration of the ServerClass.
+	// +optional
+	Network *NetworkConfig `json:"network,omitempty"`
+	// Storage selects the install disk and the extra disks of the server.
+	//
+	// Overrides the storage configuration of the ServerClass.
+	// +optional
+	Storage *StorageConfig `json:"storage,omitempty"`
+	// BootMenu enables the interactive iPXE boot menu for the server, which lists available environments
+	// and the local boot option.
+	//
+	// The boot menu has to be enabled in the controller manager with the --boot-menu-timeout flag.
+	BootMenu bool `json:"bootMenu,omitempty"`
+	// WipeTimeout overrides the global timeout for the server to be wiped.
+	//
+	// Timeout covers the whole wipe attempt: power cycle, agent registration and the wipe itself.
+	WipeTimeout *metav1.Duration `json:"wipeTimeout,omitempty"`
+	// WipeMode defines how the server is cleaned up once it's released, defaults to Full.
+	WipeMode WipeMode `json:"wipeMode,omitempty"`
+	// Faults lists the known non-fatal hardware faults of the server (e.g. a failed DIMM or a degraded disk).
+	//
+	// Faulty servers are only allocated from the ServerClasses which tolerate all the faults.
+	// +optional
+	Faults []HardwareFault `json:"faults,omitempty"`
+	// BMCProxy is the name of the BMCProxy used to reach the BMC and the management API of the server,
+	// if the management cluster has no direct route to them.
+	//
+	// Overrides the BMCProxy of the Site the server is located at.
+	// +optional
+	BMCProxy string `json:"bmcProxy,omitempty"`
+	// RequestedAction is the one-shot action to perform on the server.
+	//
+	// The action is cleared once it's performed, the outcome is recorded in the LastAction status field.
+	// +optional
+	RequestedAction ServerAction `json:"requestedAction,omitempty"`
+	// FreezeWindows are the periods of time Sidero doesn't perform power actions on the server
+	// (including the requested actions and the wipe), and doesn't allocate it.
+	//
+	// Allocated server stays allocated during the freeze, the Frozen condition is set while any window is active.
+	// +optional
+	FreezeWindows []FreezeWindow `json:"freezeWindows,omitempty"`
 }
 
+// ServerAction is the one-shot action performed on the server.
+//
+// +kubebuilder:validation:Enum=PowerCycle
+type ServerAction string
+
+const (
+	// ServerActionPowerCycle power cycles the server (powered off server is powered on).
+	ServerActionPowerCycle ServerAction = "PowerCycle"
+)
+
+// Results of the server actions.
+const (
+	ServerActionSucceeded = "Succeeded"
+	ServerActionFailed    = "Failed"
+)
+
+// ServerActionRecord describes the outcome of the last action performed on the server.
+type ServerActionRecord struct {
+	// Action is the performed action.
+	Action ServerAction `json:"action"`
+	// Result is either "Succeeded" or "Failed".
+	Result string `json:"result"`
+	// Message describes the failure.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Time is the time the action was performed.
+	Time metav1.Time `json:"time"`
+}
+
+// HardwareFault describes the non-fatal hardware fault of the server, set by the operator or the hardware monitoring.
+type HardwareFault struct {
+	// Type is the kind of the fault matched by the ServerClass tolerations, e.g. "DIMMFailed" or "DiskDegraded".
+	Type string `json:"type"`
+	// Message is the human readable description of the fault.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// WipeMode defines how the server is cleaned up once it's released.
+//
+// +kubebuilder:validation:Enum=Full;Reinstall
+type WipeMode string
+
+const (
+	// WipeModeFull wipes all the disks of the server with the agent.
+	WipeModeFull WipeMode = "Full"
+	// WipeModeReinstall wipes only the Talos system partitions (STATE, EPHEMERAL) with the agent, the server is reinstalled
+	// with the machine configuration which doesn't wipe the install disk, so that the rest of the disks are preserved.
+	//
+	// Servers are still fully wiped when they are accepted.
+	WipeModeReinstall WipeMode = "Reinstall"
+)
+
+// WipeScope defines which part of the server disks the agent wipes.
+type WipeScope string
+
+const (
+	// WipeScopeFull wipes all the disks of the server.
+	WipeScopeFull WipeScope = "Full"
+	// WipeScopeSystem wipes the Talos system partitions (STATE, EPHEMERAL) of the server released in the Reinstall wipe mode.
+	WipeScopeSystem WipeScope = "System"
+	// WipeScopeVerify checks that the disks of the server released by the diskless machine were not modified:
+	// the disks are sampled and compared with the last wipe attestation, modified disks are wiped.
+	WipeScopeVerify WipeScope = "Verify"
+)
+
+// NetworkConfig describes the provisioning and the workload networks of the server, rendered into the machine configuration.
+//
+// By default, the server is provisioned and runs the workloads on the same interface (the provisioning interface).
+type NetworkConfig struct {
+	// ProvisioningVLAN is the VLAN ID of the provisioning network on the provisioning interface, if the network is tagged.
+	// +optional
+	ProvisioningVLAN int `json:"provisioningVLAN,omitempty"`
+	// Workload is the network used by the workloads once the server is provisioned.
+	// +optional
+	Workload *WorkloadNetwork `json:"workload,omitempty"`
+}
+
+// WorkloadNetwork describes the network used by the workloads, it's configured with DHCP.
+type WorkloadNetwork struct {
+	// Interfaces lists the names of the network interfaces of the workload network, several interfaces are bonded.
+	Interfaces []string `json:"interfaces"`
+	// BondMode is the bonding mode of the interfaces, defaults to 802.3ad.
+	// +optional
+	BondMode string `json:"bondMode,omitempty"`
+	// VLAN is the VLAN ID of the workload network, if the network is tagged.
+	// +optional
+	VLAN int `json:"vlan,omitempty"`
+}
+
+// PXEMode defines how the server is made to PXE boot.
+//
+// +kubebuilder:validation:Enum=BootOrder;OneShot
+type PXEMode string
+
+const (
+	// PXEModeBootOrder relies on the server boot order: network first, then disk.
+	//
+	// Servers which shouldn't be PXE booted are booted from disk by the iPXE script.
+	PXEModeBootOrder PXEMode = "BootOrder"
+	// PXEModeOneShot is used for servers which boot from disk by default.
+	//
+	// Sidero sets one-shot network boot via the BMC before powering on the server,
+	// and the agent sets the UEFI BootNext variable, so that the server PXE boots
+	// again to be provisioned after the wipe.
+	PXEModeOneShot PXEMode = "OneShot"
+)
+
 const (
 	// ConditionPowerCycle is used to control the powercycle flow.
 	ConditionPowerCycle clusterv1.ConditionType = "PowerCycle"
 	// ConditionPXEBooted is used to record the fact that server got PXE booted.
 	ConditionPXEBooted clusterv1.ConditionType = "PXEBooted"
+	// ConditionWiped is used to record the fact that server disks got wiped by the agent.
+	//
+	// LastTransitionTime of the condition is used as a timestamp of the last successful wipe.
+	ConditionWiped clusterv1.ConditionType = "Wiped"
+	// ConditionManualPowerAction is set to False when the server with manual power management
+	// requires the operator to perform a power action.
+	ConditionManualPowerAction clusterv1.ConditionType = "ManualPowerAction"
+	// ConditionCablingInvalid is set to True when LLDP neighbors reported by the agent don't satisfy the server cabling rules.
+	ConditionCablingInvalid clusterv1.ConditionType = "CablingInvalid"
+	// ConditionStalled is set to False while the server is being wiped, and to True when the wipe
+	// didn't complete within the wipe timeout after all the retries, and the server got quarantined.
+	//
+	// LastTransitionTime of the False condition is used as a start time of the current wipe attempt.
+	ConditionStalled clusterv1.ConditionType = "Stalled"
+	// ConditionDuplicateMAC is set to True when some of the server MAC addresses belong to other servers as well.
+	//
+	// Such servers (e.g. cloned VMs) can't be told apart while booting from the network, so they are not allocated.
+	ConditionDuplicateMAC clusterv1.ConditionType = "DuplicateMAC"
+	// ConditionClockSkewed is set to True when the hardware clock of the server reported by the agent
+	// is off by more than the allowed skew, and the agent failed to correct it.
+	ConditionClockSkewed clusterv1.ConditionType = "ClockSkewed"
+	// ConditionPowerManagementFailed is set to True when the last power management request to the BMC
+	// (or the management API) failed, the reason is either BMCAuthFailed or PowerManagementFailed.
+	ConditionPowerManagementFailed clusterv1.ConditionType = "PowerManagementFailed"
+	// ConditionManualInterventionRequired is set to True when power management kept failing on all the configured paths
+	// (BMC, management API) for the whole retry budget, Sidero stops performing power actions on such server.
+	ConditionManualInterventionRequired clusterv1.ConditionType = "ManualInterventionRequired"
+	// ConditionAwaitingApproval is set to True when the destructive action on the server (power off or wipe)
+	// is held until it's approved, the reason tells which approval is required.
+	ConditionAwaitingApproval clusterv1.ConditionType = "AwaitingApproval"
+	// ConditionStale is set to True when the last hardware inventory reported by the agent is older than the inventory TTL.
+	ConditionStale clusterv1.ConditionType = "Stale"
+	// ConditionFrozen is set to True while any freeze window of the server is active.
+	ConditionFrozen clusterv1.ConditionType = "Frozen"
+	// ConditionPoweredOff is set to True when the allocated server was shut down on request (PowerOffAnnotation),
+	// so that powering it back on is not accounted as the automated remediation.
+	ConditionPoweredOff clusterv1.ConditionType = "PoweredOff"
+	// ConditionAdopted is set to True by the ServerAdoption once the running Talos node is bound to its MetalMachine,
+	// the server is booted from disk (just like the PXE booted servers), and the condition is removed when the server is released.
+	ConditionAdopted clusterv1.ConditionType = "Adopted"
+)
+
+const (
+	// ServerMACAddressField is used to index Servers on the MAC addresses of their network interfaces.
+	ServerMACAddressField = "status.networkInterfaces.mac"
+	// ServerBMCEndpointField is used to index Servers on their BMC endpoint.
+	ServerBMCEndpointField = "spec.bmc.endpoint"
+	// ServerSerialNumberField is used to index Servers on their serial number.
+	ServerSerialNumberField = "spec.system.serialNumber"
+)
+
+// PowerActionAcknowledgedAnnotation is set by the operator to acknowledge that the requested manual power action was performed.
+const PowerActionAcknowledgedAnnotation = "metal.sidero.dev/power-action-acknowledged"
+
+// PowerOffAnnotation keeps the allocated server powered off (e.g. during the planned power event of the whole cluster).
+//
+// Server is powered back on once the annotation is removed.
+const PowerOffAnnotation = "metal.sidero.dev/power-off"
+
+// Approval annotations approve the destructive actions on the server, the value is the name of the approving user.
+//
+// Approvals are only required when the controller manager runs with --require-approval, each approval is used once:
+// it's removed once the power off is lifted or the server is wiped.
+const (
+	// PowerOffApprovedByAnnotation approves the power off requested with PowerOffAnnotation,
+	// the approver should be different from the user requesting the power off.
+	PowerOffApprovedByAnnotation = "metal.sidero.dev/power-off-approved-by"
+	// WipeApprovedByAnnotation approves the next wipe of the server disks.
+	WipeApprovedByAnnotation = "metal.sidero.dev/wipe-approved-by"
+	// DeleteApprovedByAnnotation approves the deletion of the server (e.g. the decommission),
+	// the approver should be different from the user deleting the server.
+	DeleteApprovedByAnnotation = "metal.sidero.dev/delete-approved-by"
+)
+
+// PowerOffApproved checks whether the requested power off is approved by another user.
+func (s *Server) PowerOffApproved() bool {
+	requester := s.Annotations[PowerOffAnnotation]
+	approver := s.Annotations[PowerOffApprovedByAnnotation]
+
+	return approver != "" && approver != requester
+}
+
+// DeleteApproved checks whether the deletion of the server by the user is approved by another user.
+func (s *Server) DeleteApproved(user string) bool {
+	approver := s.Annotations[DeleteApprovedByAnnotation]
+
+	return approver != "" && approver != user
+}
+
+// WipeApproved checks whether the wipe of the server is approved.
+func (s *Server) WipeApproved() bool {
+	return s.Annotations[WipeApprovedByAnnotation] != ""
+}
+
+// Provisioning network labels are set on the server from the DHCP lease it got while PXE booting.
+const (
+	// ProvisioningSubnetLabel is the subnet of the provisioning network, with "/" replaced by "-", e.g. "10.5.0.0-24".
+	ProvisioningSubnetLabel = "metal.sidero.dev/provisioning-subnet"
+	// ProvisioningGatewayLabel is the default gateway of the provisioning network (usually the DHCP relay address).
+	ProvisioningGatewayLabel = "metal.sidero.dev/provisioning-gateway"
+)
+
+// Hardware labels are set on the server from the reported SystemInformation and CPU, so that label selectors
+// can match the hardware attributes.
+//
+// Values are sanitized to be valid label values, e.g. "Dell Inc." becomes "Dell-Inc".
+const (
+	// ManufacturerLabel is the system manufacturer of the server.
+	ManufacturerLabel = "metal.sidero.dev/manufacturer"
+	// ProductLabel is the system product name of the server.
+	ProductLabel = "metal.sidero.dev/product"
+	// FamilyLabel is the system family of the server.
+	FamilyLabel = "metal.sidero.dev/family"
+	// CPUManufacturerLabel is the manufacturer of the processors of the server.
+	CPUManufacturerLabel = "metal.sidero.dev/cpu-manufacturer"
+	// CPUModelLabel is the version (model) of the processors of the server.
+	CPUModelLabel = "metal.sidero.dev/cpu-model"
+)
+
+// AllocationRecord describes a single allocation of the server to a cluster.
+type AllocationRecord struct {
+	// Cluster is the name of the cluster the server was allocated to.
+	Cluster string `json:"cluster,omitempty"`
+	// Namespace is the namespace of the machine the server was allocated to.
+	Namespace string `json:"namespace,omitempty"`
+	// Machine is the name of the machine the server was allocated to.
+	Machine string `json:"machine,omitempty"`
+	// MetalMachine is the name of the metal machine the server was allocated to.
+	MetalMachine string `json:"metalMachine,omitempty"`
+	// ServerClass is the name of the server class the server was allocated from.
+	ServerClass string `json:"serverClass,omitempty"`
+	// Diskless is set if the server was allocated from a diskless ServerClass, so that its disks were not used.
+	Diskless bool `json:"diskless,omitempty"`
+	// AllocatedAt is the time the server was allocated.
+	AllocatedAt metav1.Time `json:"allocatedAt"`
+	// ReleasedAt is the time the server was released, it is not set for the current allocation.
+	ReleasedAt *metav1.Time `json:"releasedAt,omitempty"`
+	// ReleaseReason describes how the server was released: "Released" or "Remediated".
+	ReleaseReason string `json:"releaseReason,omitempty"`
+	// Tags are the chargeback tags copied from the labels of the metal machine and its cluster, see ChargebackTagPrefix.
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// ChargebackTagPrefix marks the metal machine (or cluster) labels recorded as the chargeback tags of the allocation,
+// e.g. the label `chargeback.metal.sidero.dev/cost-center: ml` is recorded as the tag `cost-center: ml`.
+const ChargebackTagPrefix = "chargeback.metal.sidero.dev/"
+
+const (
+	// ReleaseReasonReleased is recorded when the machine was deleted normally.
+	ReleaseReasonReleased = "Released"
+	// ReleaseReasonRemediated is recorded when the machine was deleted by the remediation.
+	ReleaseReasonRemediated = "Remediated"
 )
 
 // ServerStatus defines the observed state of Server.
@@ -113,14 +700,81 @@ type ServerStatus struct {
 	// +optional
 	IsClean bool `json:"isClean"`
 
+	// PendingWipeScope is the scope of the next agent wipe of the released server, empty value wipes all the disks.
+	// +optional
+	PendingWipeScope WipeScope `json:"pendingWipeScope,omitempty"`
+
 	// Conditions defines current service state of the Server.
 	Conditions []clusterv1.Condition `json:"conditions,omitempty"`
 
+	// BMCHealth is the health of the server reported by the BMC (e.g. "OK", "Warning" or "Critical"), only Redfish BMCs report it.
+	// +optional
+	BMCHealth string `json:"bmcHealth,omitempty"`
+
 	// Addresses lists discovered node IPs.
 	Addresses []corev1.NodeAddress `json:"addresses,omitempty"`
 
+	// NetworkInterfaces lists network interfaces discovered by the agent.
+	NetworkInterfaces []NetworkInterface `json:"networkInterfaces,omitempty"`
+
+	// PXEInterface is the MAC address of the network interface the server PXE booted from last time.
+	PXEInterface string `json:"pxeInterface,omitempty"`
+
+	// PCIDevices lists add-in cards (storage controllers, network adapters, GPUs and accelerators) discovered by the agent.
+	PCIDevices []PCIDevice `json:"pciDevices,omitempty"`
+
+	// Disks lists disks discovered by the agent.
+	Disks []Disk `json:"disks,omitempty"`
+
+	// Memory describes the memory modules discovered by the agent.
+	Memory *MemoryInformation `json:"memory,omitempty"`
+
+	// LLDPNeighbors lists network neighbors discovered by the agent via LLDP.
+	LLDPNeighbors []LLDPNeighbor `json:"lldpNeighbors,omitempty"`
+
+	// LastInventoryTime is the time the agent last reported the hardware inventory of the server.
+	// +optional
+	LastInventoryTime *metav1.Time `json:"lastInventoryTime,omitempty"`
+
+	// AllocationHistory lists the most recent allocations of the server, the oldest first.
+	AllocationHistory []AllocationRecord `json:"allocationHistory,omitempty"`
+
+	// AllocationHistoryTrimmedUntil is the release time of the newest allocation removed from the allocation history,
+	// allocations of the server before that time are not fully recorded.
+	// +optional
+	AllocationHistoryTrimmedUntil *metav1.Time `json:"allocationHistoryTrimmedUntil,omitempty"`
+
+	// WipeAttestation is the proof of the last disk wipe reported by the agent.
+	// +optional
+	WipeAttestation *WipeAttestation `json:"wipeAttestation,omitempty"`
+
+	// AgentTokenHash is the SHA-256 hash of the one-time token issued to the last agent booted for the server,
+	// the token is consumed by the wipe report of the agent.
+	// +optional
+	AgentTokenHash string `json:"agentTokenHash,omitempty"`
+
+	// WipeAttempts is the number of wipe attempts which timed out since the server was last wiped.
+	WipeAttempts int `json:"wipeAttempts,omitempty"`
+
+	// PowerFailures is the number of consecutive power management failures on the current path.
+	PowerFailures int `json:"powerFailures,omitempty"`
+
+	// LastPowerFailure is the time of the last power management failure counted in PowerFailures.
+	// +optional
+	LastPowerFailure *metav1.Time `json:"lastPowerFailure,omitempty"`
+
+	// PowerPath is the index of the power management path in use, paths are used in the order:
+	// BMC interface, BMC fallback interface, management API.
+	//
+	// Path is switched to the next one once the retry budget of the current path is exhausted.
+	PowerPath int `json:"powerPath,omitempty"`
+
 	// Power is the current power state of the server: "on", "off" or "unknown".
 	Power string `json:"power,omitempty"`
+
+	// LastAction records the outcome of the last action requested with RequestedAction.
+	// +optional
+	LastAction *ServerActionRecord `json:"lastAction,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -141,6 +795,28 @@ type Server struct {
 	Status ServerStatus `json:"status,omitempty"`
 }
 
+// ProvisioningInterfaceName returns the name of the network interface used to provision the server.
+//
+// Empty string is returned if the interface is not known, or it wasn't discovered by the agent.
+func (s *Server) ProvisioningInterfaceName() string {
+	mac := s.Spec.ProvisioningInterface
+	if mac == "" {
+		mac = s.Status.PXEInterface
+	}
+
+	if mac == "" {
+		return ""
+	}
+
+	for _, iface := range s.Status.NetworkInterfaces {
+		if strings.EqualFold(iface.MAC, mac) {
+			return iface.Name
+		}
+	}
+
+	return ""
+}
+
 func (s *Server) GetConditions() clusterv1.Conditions {
 	return s.Status.Conditions
 }
@@ -71,6 +71,44 @@ func Test_PartialEqual(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "regex is matched",
+			args: args{
+				a: &v1alpha1.SystemInformation{
+					Manufacturer: "Dell Inc.",
+					ProductName:  "~PowerEdge R6.*",
+				},
+				b: &v1alpha1.SystemInformation{
+					Manufacturer: "Dell Inc.",
+					ProductName:  "PowerEdge R640",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "regex matches the whole value",
+			args: args{
+				a: &v1alpha1.SystemInformation{
+					ProductName: "~R6.*",
+				},
+				b: &v1alpha1.SystemInformation{
+					ProductName: "PowerEdge R640",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "invalid regex is not matched",
+			args: args{
+				a: &v1alpha1.SystemInformation{
+					ProductName: "~PowerEdge R6(",
+				},
+				b: &v1alpha1.SystemInformation{
+					ProductName: "PowerEdge R6(",
+				},
+			},
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -81,3 +119,59 @@ func Test_PartialEqual(t *testing.T) {
 		})
 	}
 }
+
+func TestCPUInformation_PartialEqual(t *testing.T) {
+	server := &v1alpha1.CPUInformation{
+		Manufacturer: "Intel(R) Corporation",
+		Version:      "Intel(R) Xeon(R) Gold 6230 CPU @ 2.10GHz",
+		Cores:        40,
+		Threads:      80,
+		FrequencyMHz: 3900,
+	}
+
+	for _, tt := range []struct {
+		name      string
+		qualifier v1alpha1.CPUInformation
+		cpu       *v1alpha1.CPUInformation
+		want      bool
+	}{
+		{
+			name:      "thread range",
+			qualifier: v1alpha1.CPUInformation{MinThreads: 64, MaxThreads: 128},
+			cpu:       server,
+			want:      true,
+		},
+		{
+			name:      "too many threads",
+			qualifier: v1alpha1.CPUInformation{MaxThreads: 64},
+			cpu:       server,
+		},
+		{
+			name:      "cores and frequency with manufacturer",
+			qualifier: v1alpha1.CPUInformation{Manufacturer: "~Intel.*", MinCores: 32, MinFrequencyMHz: 3000},
+			cpu:       server,
+			want:      true,
+		},
+		{
+			name:      "slow",
+			qualifier: v1alpha1.CPUInformation{MinFrequencyMHz: 4000},
+			cpu:       server,
+		},
+		{
+			name:      "manufacturer mismatch",
+			qualifier: v1alpha1.CPUInformation{Manufacturer: "AMD", MinCores: 32},
+			cpu:       server,
+		},
+		{
+			name:      "unknown threads",
+			qualifier: v1alpha1.CPUInformation{MaxThreads: 128},
+			cpu:       &v1alpha1.CPUInformation{Manufacturer: "QEMU"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.qualifier.PartialEqual(tt.cpu); got != tt.want {
+				t.Errorf("PartialEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
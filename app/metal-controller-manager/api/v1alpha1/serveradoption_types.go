@@ -0,0 +1,86 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AdoptedAnnotation is set on the servers registered via ServerAdoption.
+//
+// Value of the annotation is the name of the ServerAdoption.
+const AdoptedAnnotation = "metal.sidero.dev/adopted"
+
+// AdoptionMachine describes the Machine and MetalMachine created for the adopted server.
+type AdoptionMachine struct {
+	// ClusterName is the name of the Cluster the node belongs to.
+	ClusterName string `json:"clusterName"`
+	// Namespace is the namespace of the Cluster.
+	Namespace string `json:"namespace"`
+	// Version is the Kubernetes version running on the node.
+	Version string `json:"version"`
+	// ControlPlane should be set for control plane nodes.
+	ControlPlane bool `json:"controlPlane,omitempty"`
+}
+
+// ServerAdoptionSpec defines the desired state of ServerAdoption.
+type ServerAdoptionSpec struct {
+	// Endpoint is the address of the Talos API of the node to adopt.
+	Endpoint string `json:"endpoint"`
+	// TalosConfigSecretRef references the secret with talosconfig used to access the node.
+	//
+	// Talosconfig is read from the "talosconfig" key of the secret.
+	TalosConfigSecretRef corev1.SecretReference `json:"talosConfigSecretRef"`
+	// Machine describes the Machine to be created for the node.
+	//
+	// If not set, the server is only registered without being accepted.
+	Machine *AdoptionMachine `json:"machine,omitempty"`
+}
+
+// ServerAdoptionStatus defines the observed state of ServerAdoption.
+type ServerAdoptionStatus struct {
+	// Ready is true when the server is registered (and the machine is created, if requested).
+	// +optional
+	Ready bool `json:"ready"`
+
+	// ServerRef references the registered Server.
+	ServerRef *corev1.ObjectReference `json:"serverRef,omitempty"`
+
+	// MachineRef references the created Machine.
+	MachineRef *corev1.ObjectReference `json:"machineRef,omitempty"`
+
+	// Error is the last error encountered while adopting the node.
+	Error string `json:"error,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".spec.endpoint",description="Talos API endpoint of the node"
+// +kubebuilder:printcolumn:name="Server",type="string",JSONPath=".status.serverRef.name",description="registered server"
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready",description="indicates if the node is adopted"
+
+// ServerAdoption is the Schema for the serveradoptions API.
+type ServerAdoption struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServerAdoptionSpec   `json:"spec,omitempty"`
+	Status ServerAdoptionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ServerAdoptionList contains a list of ServerAdoption.
+type ServerAdoptionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServerAdoption `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServerAdoption{}, &ServerAdoptionList{})
+}
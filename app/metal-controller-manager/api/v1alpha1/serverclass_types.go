@@ -5,34 +5,364 @@
 package v1alpha1
 
 import (
+	"hash/fnv"
+	"sort"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 )
 
+// ProposedAnnotation marks the ServerClass proposed by the hardware profiler.
+//
+// Proposed ServerClass doesn't offer servers for allocation until the annotation is removed (i.e. the ServerClass is approved).
+const ProposedAnnotation = "metal.sidero.dev/proposed"
+
+// ConditionOverlapping is set to True when some of the servers of the ServerClass match other ServerClasses as well,
+// the reason tells whether the priorities of the ServerClasses settle the allocation order.
+const ConditionOverlapping clusterv1.ConditionType = "Overlapping"
+
+// ConditionCapacityInsufficient is set to True when the MachineDeployments allocating from the ServerClass need more servers
+// than the ServerClass has available, the message lists the shortfall per MachineDeployment.
+const ConditionCapacityInsufficient clusterv1.ConditionType = "CapacityInsufficient"
+
+// ConditionCapacityLow is set to True when the number of the free servers of the ServerClass drops below MinimumAvailable.
+const ConditionCapacityLow clusterv1.ConditionType = "CapacityLow"
+
+// ConditionCapacityAvailable is set to True when the ServerClass has wiped servers available for the allocation.
+const ConditionCapacityAvailable clusterv1.ConditionType = "CapacityAvailable"
+
+// ConditionQualifierError is set to True when the qualifiers of the ServerClass can't be evaluated, e.g. the expression is invalid,
+// such ServerClass matches no servers.
+//
+// Ready condition of the ServerClass is False while the qualifiers are invalid, or while the ServerClass is proposed.
+const ConditionQualifierError clusterv1.ConditionType = "QualifierError"
+
+// ServerClassSnapshotLabel is set on the ConfigMaps with the match snapshots of the ServerClass, the value is the name of the ServerClass.
+const ServerClassSnapshotLabel = "metal.sidero.dev/serverclass-snapshot"
+
 type Qualifiers struct {
 	CPU               []CPUInformation    `json:"cpu,omitempty"`
 	SystemInformation []SystemInformation `json:"systemInformation,omitempty"`
 	LabelSelectors    []map[string]string `json:"labelSelectors,omitempty"`
+	// LabelExpressions selects the servers with the set-based label requirements (In, NotIn, Exists, DoesNotExist).
+	//
+	// Every expression should be matched, in addition to LabelSelectors.
+	// +optional
+	LabelExpressions []metav1.LabelSelectorRequirement `json:"labelExpressions,omitempty"`
+	// PCIDevices lists the add-in cards the server should have.
+	//
+	// Unlike other qualifiers, every entry should be matched by some PCI device of the server.
+	PCIDevices []PCIDeviceInformation `json:"pciDevices,omitempty"`
+	// Memory selects the servers by the size and the number of the memory modules.
+	// +optional
+	Memory *MemoryQualifier `json:"memory,omitempty"`
+	// Disks lists the disks the server should have.
+	//
+	// Like PCIDevices, every entry should be satisfied, disks are counted for each entry independently.
+	// +optional
+	Disks []DiskQualifier `json:"disks,omitempty"`
+	// NetworkInterfaces lists the network interfaces the server should have.
+	//
+	// Like Disks, every entry should be satisfied, interfaces are counted for each entry independently.
+	// +optional
+	NetworkInterfaces []NetworkInterfaceQualifier `json:"networkInterfaces,omitempty"`
+	// GPUs lists the GPUs the server should have.
+	//
+	// Like Disks, every entry should be satisfied, GPUs are counted for each entry independently.
+	// +optional
+	GPUs []GPUQualifier `json:"gpus,omitempty"`
+	// ServerNames pins the ServerClass to the explicit list of servers, each entry is either the name (UUID) or the hostname of the Server.
+	// +optional
+	ServerNames []string `json:"serverNames,omitempty"`
+	// Expression is the CEL expression evaluated against the Server, e.g. `server.status.memory.modules >= 8`.
+	//
+	// Servers are matched if the expression evaluates to true, evaluation errors (e.g. missing fields) don't match.
+	// +optional
+	Expression string `json:"expression,omitempty"`
+	// Exclude removes the servers matching the exclusion qualifiers from the servers matched by the qualifiers above.
+	// +optional
+	Exclude *ExclusionQualifiers `json:"exclude,omitempty"`
+}
+
+// ExclusionQualifiers select the servers excluded from the ServerClass.
+//
+// Fields have the same meaning as in Qualifiers: server is excluded if it matches all the specified exclusion qualifiers.
+type ExclusionQualifiers struct {
+	CPU               []CPUInformation    `json:"cpu,omitempty"`
+	SystemInformation []SystemInformation `json:"systemInformation,omitempty"`
+	LabelSelectors    []map[string]string `json:"labelSelectors,omitempty"`
+	// +optional
+	LabelExpressions []metav1.LabelSelectorRequirement `json:"labelExpressions,omitempty"`
+	// +optional
+	PCIDevices []PCIDeviceInformation `json:"pciDevices,omitempty"`
+	// +optional
+	Memory *MemoryQualifier `json:"memory,omitempty"`
+	// +optional
+	Disks []DiskQualifier `json:"disks,omitempty"`
+	// +optional
+	NetworkInterfaces []NetworkInterfaceQualifier `json:"networkInterfaces,omitempty"`
+	// +optional
+	GPUs []GPUQualifier `json:"gpus,omitempty"`
+	// +optional
+	ServerNames []string `json:"serverNames,omitempty"`
+	// +optional
+	Expression string `json:"expression,omitempty"`
+}
+
+// IsEmpty checks whether no exclusion qualifiers are specified, empty exclusion doesn't exclude any servers.
+func (e *ExclusionQualifiers) IsEmpty() bool {
+	return len(e.CPU) == 0 && len(e.SystemInformation) == 0 && len(e.LabelSelectors) == 0 && len(e.LabelExpressions) == 0 &&
+		len(e.PCIDevices) == 0 && e.Memory == nil && len(e.Disks) == 0 && len(e.NetworkInterfaces) == 0 && len(e.GPUs) == 0 && len(e.ServerNames) == 0 && e.Expression == ""
+}
+
+// Qualifiers returns the exclusion as the qualifiers, so that the excluded servers are matched the same way.
+func (e *ExclusionQualifiers) Qualifiers() Qualifiers {
+	return Qualifiers{
+		CPU:               e.CPU,
+		SystemInformation: e.SystemInformation,
+		LabelSelectors:    e.LabelSelectors,
+		LabelExpressions:  e.LabelExpressions,
+		PCIDevices:        e.PCIDevices,
+		Memory:            e.Memory,
+		Disks:             e.Disks,
+		NetworkInterfaces: e.NetworkInterfaces,
+		GPUs:              e.GPUs,
+		ServerNames:       e.ServerNames,
+		Expression:        e.Expression,
+	}
+}
+
+// MatchesServerName checks whether the server is listed in ServerNames by the name or the hostname.
+func (q *Qualifiers) MatchesServerName(server *Server) bool {
+	if len(q.ServerNames) == 0 {
+		return true
+	}
+
+	for _, name := range q.ServerNames {
+		if name == server.Name || (server.Spec.Hostname != "" && name == server.Spec.Hostname) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchesLabelExpressions checks whether the server labels match all the label expressions.
+//
+// Invalid expressions (e.g. In without the values) don't match any labels.
+func (q *Qualifiers) MatchesLabelExpressions(set map[string]string) bool {
+	if len(q.LabelExpressions) == 0 {
+		return true
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchExpressions: q.LabelExpressions})
+	if err != nil {
+		return false
+	}
+
+	return selector.Matches(labels.Set(set))
+}
+
+// ReallocationPolicy defines how servers which are in use, but no longer match the ServerClass qualifiers, are handled.
+type ReallocationPolicy struct {
+	// MaxInFlight is the maximum number of machines being replaced at the same time.
+	// Defaults to 1.
+	// +optional
+	MaxInFlight int `json:"maxInFlight,omitempty"`
+}
+
+// GetMaxInFlight returns the number of machines which might be replaced at the same time.
+func (p *ReallocationPolicy) GetMaxInFlight() int {
+	if p.MaxInFlight <= 0 {
+		return 1
+	}
+
+	return p.MaxInFlight
 }
 
 // ServerClassSpec defines the desired state of ServerClass.
 type ServerClassSpec struct {
+	// EnvironmentRef is the Environment booted by the servers allocated from the serverclass.
+	//
+	// It overrides the site and the default environments, and it's overridden by the environment set on the Server.
+	// +optional
 	EnvironmentRef *corev1.ObjectReference `json:"environmentRef,omitempty"`
 	Qualifiers     Qualifiers              `json:"qualifiers"`
 	ConfigPatches  []ConfigPatches         `json:"configPatches,omitempty"`
+	// ConfigPatchSets lists names of the ConfigPatchSets applied to the machine configuration before ConfigPatches.
+	// +optional
+	ConfigPatchSets []string `json:"configPatchSets,omitempty"`
+	// ConfigMergePatches lists RFC 7386 merge patches (machine configuration fragments in YAML or JSON)
+	// applied to the machine configuration after ConfigPatches.
+	//
+	// Maps are merged, lists are replaced, and null values remove the keys.
+	// +optional
+	ConfigMergePatches []string `json:"configMergePatches,omitempty"`
+	// NodeLabels are set on the nodes provisioned from the ServerClass when they register in the workload cluster.
+	//
+	// Labels are passed to the kubelet, so the labels the kubelet isn't allowed to set (e.g. node-role.kubernetes.io/*) can't be used.
+	// +optional
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+	// NodeTaints are set on the nodes provisioned from the ServerClass when they register in the workload cluster.
+	// +optional
+	NodeTaints []corev1.Taint `json:"nodeTaints,omitempty"`
+	// Reallocation enables replacement of the machines allocated from the ServerClass
+	// when their servers no longer match the qualifiers.
+	//
+	// Machines are replaced in batches of at most MaxInFlight machines.
+	// If not set, mismatched servers are only reported via events.
+	// +optional
+	Reallocation *ReallocationPolicy `json:"reallocation,omitempty"`
+	// RemediationSpares is the number of available servers held back from the regular allocation.
+	//
+	// Spare servers are only allocated to the machines replacing the machines remediated
+	// by MachineHealthCheck, so that the clusters can self-heal even if the ServerClass is otherwise fully consumed.
+	// +optional
+	RemediationSpares int `json:"remediationSpares,omitempty"`
+	// MaxConcurrentProvisions limits the number of the servers of the ServerClass being provisioned at the same time
+	// (allocated, but the node hasn't joined the cluster yet), zero means no limit.
+	//
+	// Machines are not allocated servers while the limit is reached.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentProvisions int `json:"maxConcurrentProvisions,omitempty"`
+	// MinimumAvailable is the number of the free servers the ServerClass should keep.
+	//
+	// If the number of the free servers drops below it, the CapacityLow condition is set and a warning event is emitted.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinimumAvailable int `json:"minimumAvailable,omitempty"`
+	// Network separates the provisioning network from the workload network of the servers.
+	// +optional
+	Network *NetworkConfig `json:"network,omitempty"`
+	// Storage selects the install disk and the extra disks of the servers, resolved to the disks of each server.
+	// +optional
+	Storage *StorageConfig `json:"storage,omitempty"`
+	// Tolerations lists the hardware faults of the servers accepted by the ServerClass.
+	//
+	// By default, servers with any faults are not offered for allocation.
+	// +optional
+	Tolerations []FaultToleration `json:"tolerations,omitempty"`
+	// Diskless provisions the servers without the local disks: servers boot the Environment from the network
+	// on every boot, the storage configuration is not resolved, and the servers are not wiped on release.
+	//
+	// Talos configuration for the diskless operation (e.g. network storage) is supplied via the config patches.
+	// +optional
+	Diskless bool `json:"diskless,omitempty"`
+	// Canary boots a subset of the servers with the canary environment instead of EnvironmentRef.
+	// +optional
+	Canary *CanaryRollout `json:"canary,omitempty"`
+	// Priority orders the overlapping ServerClasses: servers matching several ServerClasses are allocated
+	// from the ServerClass with the highest priority first.
+	//
+	// ServerClasses of the lower priority fall back to such servers only when they have no other servers left.
+	// +optional
+	Priority int `json:"priority,omitempty"`
+	// AllocationStrategy selects the order the available servers are allocated in, defaults to Sequential.
+	// +optional
+	AllocationStrategy AllocationStrategy `json:"allocationStrategy,omitempty"`
+	// SpreadLabel is the label key of the failure domain (e.g. rack or chassis) the SpreadByLabel strategy spreads the servers across.
+	// +optional
+	SpreadLabel string `json:"spreadLabel,omitempty"`
+	// IncludeClasses lists the names of the ServerClasses the ServerClass is composed of.
+	//
+	// If set, the ServerClass matches the servers matched by any of the included ServerClasses,
+	// which also match the Qualifiers of the ServerClass.
+	// Included ServerClasses which don't exist (or include the ServerClass back) match no servers.
+	// +optional
+	IncludeClasses []string `json:"includeClasses,omitempty"`
+	// MaxServers is the maximum number of the servers the ServerClass lists (in use, available and pending acceptance), zero means no limit.
+	//
+	// Servers in use are always listed, matching servers beyond the limit are not offered for allocation.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxServers int `json:"maxServers,omitempty"`
+}
+
+// AllocationStrategy defines the order the available servers of the ServerClass are allocated in.
+//
+// +kubebuilder:validation:Enum=Sequential;Random;SpreadByLabel;Ordinal
+type AllocationStrategy string
+
+const (
+	// AllocationStrategySequential allocates the servers in the order of their names.
+	AllocationStrategySequential AllocationStrategy = "Sequential"
+	// AllocationStrategyRandom allocates the servers in the random order.
+	AllocationStrategyRandom AllocationStrategy = "Random"
+	// AllocationStrategySpreadByLabel allocates the servers from the failure domain (the value of the SpreadLabel)
+	// with the fewest servers of the same cluster and role (control plane or workers) first.
+	//
+	// Servers without the label are allocated last.
+	AllocationStrategySpreadByLabel AllocationStrategy = "SpreadByLabel"
+	// AllocationStrategyOrdinal assigns the machines of the same control plane or MachineDeployment the lowest free ordinal,
+	// and allocates the server the ordinal was allocated before first, so that each ordinal keeps its server.
+	//
+	// Servers reserved for the other ordinals of the same machines are allocated last.
+	AllocationStrategyOrdinal AllocationStrategy = "Ordinal"
+)
+
+// CanaryRollout selects the servers of the ServerClass which boot the canary Environment.
+//
+// Canary servers are listed in the ServerClass status, the rest of the servers keep booting the stable Environment.
+type CanaryRollout struct {
+	// EnvironmentRef is the canary Environment.
+	EnvironmentRef *corev1.ObjectReference `json:"environmentRef"`
+	// Percentage is the share of the ServerClass servers booting the canary Environment (rounded up).
+	//
+	// Servers are picked by the hash of their names, so that the same servers stay canaries
+	// as the percentage grows.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Percentage int `json:"percentage,omitempty"`
+	// Servers lists the names of the servers booting the canary Environment in addition to Percentage.
+	// +optional
+	Servers []string `json:"servers,omitempty"`
+}
+
+// FaultToleration accepts the servers with the hardware faults of the given type.
+type FaultToleration struct {
+	// Type is the type of the tolerated fault, empty type tolerates any fault.
+	// +optional
+	Type string `json:"type,omitempty"`
 }
 
 // ServerClassStatus defines the observed state of ServerClass.
 type ServerClassStatus struct {
 	ServersAvailable []string `json:"serversAvailable"`
 	ServersInUse     []string `json:"serversInUse"`
+	// ServersCanary lists the servers (both available and in use) booting the canary Environment.
+	// +optional
+	ServersCanary []string `json:"serversCanary,omitempty"`
+	// ServersPendingAcceptance lists the servers matching the qualifiers which are not accepted yet.
+	// +optional
+	ServersPendingAcceptance []string `json:"serversPendingAcceptance,omitempty"`
+	// ServersPendingAcceptanceCount is the number of the servers pending acceptance.
+	// +optional
+	ServersPendingAcceptanceCount int `json:"serversPendingAcceptanceCount,omitempty"`
+	// ServersWipingInProgress lists the available servers which are not wiped yet, they can't be allocated until wiped.
+	// +optional
+	ServersWipingInProgress []string `json:"serversWipingInProgress,omitempty"`
+	// ServersWipingInProgressCount is the number of the servers being wiped.
+	// +optional
+	ServersWipingInProgressCount int `json:"serversWipingInProgressCount,omitempty"`
+	// Conditions defines current state of the ServerClass.
+	// +optional
+	Conditions []clusterv1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="serverclass is valid and offered for allocation"
 // +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.serversAvailable",description="the number of available servers"
 // +kubebuilder:printcolumn:name="In Use",type="string",JSONPath=".status.serversInUse",description="the number of servers in use"
+// +kubebuilder:printcolumn:name="Canary",type="string",JSONPath=".status.serversCanary",description="the canary servers"
+// +kubebuilder:printcolumn:name="Pending",type="integer",JSONPath=".status.serversPendingAcceptanceCount",description="the number of servers pending acceptance",priority=1
+// +kubebuilder:printcolumn:name="Wiping",type="integer",JSONPath=".status.serversWipingInProgressCount",description="the number of servers being wiped",priority=1
+// +kubebuilder:printcolumn:name="Priority",type="integer",JSONPath=".spec.priority",description="the priority of the serverclass"
 
 // ServerClass is the Schema for the serverclasses API.
 type ServerClass struct {
@@ -43,6 +373,98 @@ type ServerClass struct {
 	Status ServerClassStatus `json:"status,omitempty"`
 }
 
+// IsProposed returns true if the ServerClass was proposed by the hardware profiler and is not approved yet.
+func (sc *ServerClass) IsProposed() bool {
+	_, ok := sc.Annotations[ProposedAnnotation]
+
+	return ok
+}
+
+// Tolerates returns true if all the hardware faults of the server are tolerated by the ServerClass.
+func (sc *ServerClass) Tolerates(server *Server) bool {
+	for _, fault := range server.Spec.Faults {
+		if !sc.toleratesFault(fault.Type) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (sc *ServerClass) toleratesFault(faultType string) bool {
+	for _, toleration := range sc.Spec.Tolerations {
+		if toleration.Type == "" || toleration.Type == faultType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanaryServers selects the canary servers out of the servers of the ServerClass.
+func (sc *ServerClass) CanaryServers(servers []string) []string {
+	canary := sc.Spec.Canary
+	if canary == nil || canary.EnvironmentRef == nil {
+		return nil
+	}
+
+	selected := map[string]struct{}{}
+
+	for _, name := range canary.Servers {
+		selected[name] = struct{}{}
+	}
+
+	ranked := append([]string(nil), servers...)
+
+	sort.Slice(ranked, func(i, j int) bool {
+		return canaryRank(sc.Name, ranked[i]) < canaryRank(sc.Name, ranked[j])
+	})
+
+	n := (len(ranked)*canary.Percentage + 99) / 100
+
+	result := []string{}
+
+	for i, name := range ranked {
+		if _, ok := selected[name]; ok || i < n {
+			result = append(result, name)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// EnvironmentFor returns the Environment of the ServerClass server, taking the canary rollout into account.
+func (sc *ServerClass) EnvironmentFor(server string) *corev1.ObjectReference {
+	if sc.Spec.Canary != nil && sc.Spec.Canary.EnvironmentRef != nil {
+		for _, name := range sc.Status.ServersCanary {
+			if name == server {
+				return sc.Spec.Canary.EnvironmentRef
+			}
+		}
+	}
+
+	return sc.Spec.EnvironmentRef
+}
+
+func (sc *ServerClass) GetConditions() clusterv1.Conditions {
+	return sc.Status.Conditions
+}
+
+func (sc *ServerClass) SetConditions(conditions clusterv1.Conditions) {
+	sc.Status.Conditions = conditions
+}
+
+// canaryRank orders the servers for the canary selection, the ServerClass name is mixed in
+// so that different ServerClasses pick different servers first.
+func canaryRank(serverClass, server string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(serverClass + "/" + server)) //nolint: errcheck
+
+	return h.Sum32()
+}
+
 // +kubebuilder:object:root=true
 
 // ServerClassList contains a list of ServerClass.
@@ -0,0 +1,264 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package v1alpha1_test
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestServerClassTolerates(t *testing.T) {
+	faulty := func(faultTypes ...string) *v1alpha1.Server {
+		server := &v1alpha1.Server{}
+
+		for _, faultType := range faultTypes {
+			server.Spec.Faults = append(server.Spec.Faults, v1alpha1.HardwareFault{Type: faultType})
+		}
+
+		return server
+	}
+
+	for _, tt := range []struct {
+		name        string
+		tolerations []v1alpha1.FaultToleration
+		server      *v1alpha1.Server
+		want        bool
+	}{
+		{
+			name:   "healthy server",
+			server: faulty(),
+			want:   true,
+		},
+		{
+			name:   "strict class",
+			server: faulty("DIMMFailed"),
+			want:   false,
+		},
+		{
+			name:        "tolerated fault",
+			tolerations: []v1alpha1.FaultToleration{{Type: "DiskDegraded"}, {Type: "DIMMFailed"}},
+			server:      faulty("DIMMFailed"),
+			want:        true,
+		},
+		{
+			name:        "one fault not tolerated",
+			tolerations: []v1alpha1.FaultToleration{{Type: "DIMMFailed"}},
+			server:      faulty("DIMMFailed", "DiskDegraded"),
+			want:        false,
+		},
+		{
+			name:        "any fault",
+			tolerations: []v1alpha1.FaultToleration{{}},
+			server:      faulty("DIMMFailed", "DiskDegraded"),
+			want:        true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &v1alpha1.ServerClass{
+				Spec: v1alpha1.ServerClassSpec{
+					Tolerations: tt.tolerations,
+				},
+			}
+
+			if got := sc.Tolerates(tt.server); got != tt.want {
+				t.Errorf("Tolerates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerClassCanaryServers(t *testing.T) {
+	servers := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+
+	for _, tt := range []struct {
+		name    string
+		canary  *v1alpha1.CanaryRollout
+		servers []string
+		want    int
+	}{
+		{
+			name: "no canary",
+			want: 0,
+		},
+		{
+			name:   "no environment",
+			canary: &v1alpha1.CanaryRollout{Percentage: 50},
+			want:   0,
+		},
+		{
+			name:   "percentage",
+			canary: &v1alpha1.CanaryRollout{EnvironmentRef: &corev1.ObjectReference{Name: "canary"}, Percentage: 20},
+			want:   2,
+		},
+		{
+			name:   "rounded up",
+			canary: &v1alpha1.CanaryRollout{EnvironmentRef: &corev1.ObjectReference{Name: "canary"}, Percentage: 1},
+			want:   1,
+		},
+		{
+			name:    "named servers",
+			canary:  &v1alpha1.CanaryRollout{EnvironmentRef: &corev1.ObjectReference{Name: "canary"}, Servers: []string{"c", "z"}},
+			servers: []string{"c"},
+			want:    1,
+		},
+		{
+			name:    "everything",
+			canary:  &v1alpha1.CanaryRollout{EnvironmentRef: &corev1.ObjectReference{Name: "canary"}, Percentage: 100, Servers: []string{"c"}},
+			servers: servers,
+			want:    10,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &v1alpha1.ServerClass{
+				Spec: v1alpha1.ServerClassSpec{
+					Canary: tt.canary,
+				},
+			}
+			sc.Name = "sc"
+
+			got := sc.CanaryServers(servers)
+			if len(got) != tt.want {
+				t.Fatalf("CanaryServers() = %v, want %d servers", got, tt.want)
+			}
+
+			for _, name := range tt.servers {
+				if !contains(got, name) {
+					t.Errorf("CanaryServers() = %v, missing %q", got, name)
+				}
+			}
+
+			if tt.canary != nil {
+				// the same servers stay canaries as the percentage grows
+				tt.canary.Percentage += 30
+
+				for _, name := range got {
+					if !contains(sc.CanaryServers(servers), name) {
+						t.Errorf("server %q is no longer a canary with the higher percentage", name)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestQualifiersMatchesLabelExpressions(t *testing.T) {
+	labels := map[string]string{"rack": "r1", "zone": "a"}
+
+	for _, tt := range []struct {
+		name        string
+		expressions []metav1.LabelSelectorRequirement
+		want        bool
+	}{
+		{
+			name: "no expressions",
+			want: true,
+		},
+		{
+			name: "in",
+			expressions: []metav1.LabelSelectorRequirement{
+				{Key: "rack", Operator: metav1.LabelSelectorOpIn, Values: []string{"r1", "r2"}},
+			},
+			want: true,
+		},
+		{
+			name: "not in",
+			expressions: []metav1.LabelSelectorRequirement{
+				{Key: "rack", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"r1", "r2"}},
+			},
+			want: false,
+		},
+		{
+			name: "exists and does not exist",
+			expressions: []metav1.LabelSelectorRequirement{
+				{Key: "zone", Operator: metav1.LabelSelectorOpExists},
+				{Key: "maintenance", Operator: metav1.LabelSelectorOpDoesNotExist},
+			},
+			want: true,
+		},
+		{
+			name: "all expressions should match",
+			expressions: []metav1.LabelSelectorRequirement{
+				{Key: "rack", Operator: metav1.LabelSelectorOpIn, Values: []string{"r1"}},
+				{Key: "zone", Operator: metav1.LabelSelectorOpIn, Values: []string{"b"}},
+			},
+			want: false,
+		},
+		{
+			name: "invalid expression",
+			expressions: []metav1.LabelSelectorRequirement{
+				{Key: "rack", Operator: metav1.LabelSelectorOpIn},
+			},
+			want: false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &v1alpha1.Qualifiers{LabelExpressions: tt.expressions}
+
+			if got := q.MatchesLabelExpressions(labels); got != tt.want {
+				t.Errorf("MatchesLabelExpressions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQualifiersMatchesServerName(t *testing.T) {
+	server := &v1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "4c4c4544-0035-5910-804b-b2c04f4e4d32",
+		},
+		Spec: v1alpha1.ServerSpec{
+			Hostname: "lab-node-1",
+		},
+	}
+
+	for _, tt := range []struct {
+		name        string
+		serverNames []string
+		want        bool
+	}{
+		{
+			name: "no names",
+			want: true,
+		},
+		{
+			name:        "uuid",
+			serverNames: []string{"00000000-0000-0000-0000-d05099d33360", "4c4c4544-0035-5910-804b-b2c04f4e4d32"},
+			want:        true,
+		},
+		{
+			name:        "hostname",
+			serverNames: []string{"lab-node-1"},
+			want:        true,
+		},
+		{
+			name:        "not listed",
+			serverNames: []string{"lab-node-2"},
+			want:        false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &v1alpha1.Qualifiers{ServerNames: tt.serverNames}
+
+			if got := q.MatchesServerName(server); got != tt.want {
+				t.Errorf("MatchesServerName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
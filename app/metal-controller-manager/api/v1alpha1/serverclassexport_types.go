@@ -0,0 +1,62 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ExportedAnnotation is set on the servers leased to another Sidero instance via ServerClassExport.
+//
+// Value of the annotation is the name of the ServerClassExport.
+const ExportedAnnotation = "metal.sidero.dev/exported"
+
+// ServerClassExportSpec defines the desired state of ServerClassExport.
+type ServerClassExportSpec struct {
+	// ServerClass is the name of the exported ServerClass.
+	ServerClass string `json:"serverClass"`
+	// Quota is the maximum number of servers leased via the export at the same time.
+	Quota int `json:"quota"`
+	// TokenSecretRef references the secret with the token importers authenticate with.
+	//
+	// Token is read from the "token" key of the secret.
+	TokenSecretRef corev1.SecretReference `json:"tokenSecretRef"`
+}
+
+// ServerClassExportStatus defines the observed state of ServerClassExport.
+type ServerClassExportStatus struct {
+	// ServersLeased lists the servers currently leased via the export.
+	ServersLeased []string `json:"serversLeased,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="ServerClass",type="string",JSONPath=".spec.serverClass",description="exported server class"
+// +kubebuilder:printcolumn:name="Quota",type="integer",JSONPath=".spec.quota",description="maximum number of leased servers"
+// +kubebuilder:printcolumn:name="Leased",type="string",JSONPath=".status.serversLeased",description="leased servers"
+
+// ServerClassExport is the Schema for the serverclassexports API.
+type ServerClassExport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServerClassExportSpec   `json:"spec,omitempty"`
+	Status ServerClassExportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ServerClassExportList contains a list of ServerClassExport.
+type ServerClassExportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServerClassExport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServerClassExport{}, &ServerClassExportList{})
+}
@@ -0,0 +1,75 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ImportedLabel is set on the servers leased from another Sidero instance via ServerClassImport.
+//
+// Value of the label is the name of the ServerClassImport, so that ServerClasses can select imported servers.
+const ImportedLabel = "metal.sidero.dev/imported"
+
+// ServerClassImportSpec defines the desired state of ServerClassImport.
+type ServerClassImportSpec struct {
+	// Endpoint is the URL of the export API of the exporting Sidero instance.
+	Endpoint string `json:"endpoint"`
+	// Export is the name of the ServerClassExport in the exporting management cluster.
+	Export string `json:"export"`
+	// TokenSecretRef references the secret with the token to authenticate with the export API.
+	//
+	// Token is read from the "token" key of the secret, optional "ca.crt" key holds
+	// the CA to verify the export API certificate.
+	TokenSecretRef corev1.SecretReference `json:"tokenSecretRef"`
+	// Servers is the number of servers to lease.
+	Servers int `json:"servers"`
+	// BMCCredentialsSecretRef references the secret with the BMC credentials of the imported servers,
+	// used when the exporter doesn't include the credentials in the leases.
+	//
+	// Credentials are read from the "user" and "pass" keys of the secret.
+	// Imported servers with the BMC but without the credentials are not accepted.
+	// +optional
+	BMCCredentialsSecretRef *corev1.SecretReference `json:"bmcCredentialsSecretRef,omitempty"`
+}
+
+// ServerClassImportStatus defines the observed state of ServerClassImport.
+type ServerClassImportStatus struct {
+	// ServersImported lists the servers currently leased via the import.
+	ServersImported []string `json:"serversImported,omitempty"`
+
+	// Error is the last error encountered while talking to the export API.
+	Error string `json:"error,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".spec.endpoint",description="export API endpoint"
+// +kubebuilder:printcolumn:name="Servers",type="integer",JSONPath=".spec.servers",description="number of servers to lease"
+// +kubebuilder:printcolumn:name="Imported",type="string",JSONPath=".status.serversImported",description="imported servers"
+
+// ServerClassImport is the Schema for the serverclassimports API.
+type ServerClassImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServerClassImportSpec   `json:"spec,omitempty"`
+	Status ServerClassImportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ServerClassImportList contains a list of ServerClassImport.
+type ServerClassImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServerClassImport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServerClassImport{}, &ServerClassImportList{})
+}
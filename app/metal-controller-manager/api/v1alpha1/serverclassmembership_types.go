@@ -0,0 +1,111 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Labels set on the ServerClassMemberships, so that the memberships of a ServerClass or a Server can be selected.
+//
+// Names longer than a label value allows are shortened, see MembershipLabelValue.
+const (
+	// MembershipServerClassLabel is the name of the ServerClass of the membership.
+	MembershipServerClassLabel = "metal.sidero.dev/serverclass"
+	// MembershipServerLabel is the name of the Server of the membership.
+	MembershipServerLabel = "metal.sidero.dev/server"
+)
+
+// MembershipState is the state of the server within the ServerClass.
+type MembershipState string
+
+// Membership states, they follow the ServerClass status lists.
+const (
+	// MembershipAvailable is the server listed in serversAvailable and not being wiped.
+	MembershipAvailable MembershipState = "Available"
+	// MembershipWiping is the server listed in serversAvailable and serversWipingInProgress.
+	MembershipWiping MembershipState = "Wiping"
+	// MembershipInUse is the server listed in serversInUse.
+	MembershipInUse MembershipState = "InUse"
+	// MembershipPendingAcceptance is the server listed in serversPendingAcceptance.
+	MembershipPendingAcceptance MembershipState = "PendingAcceptance"
+)
+
+// ServerClassMembershipSpec defines the server and the ServerClass of the membership.
+type ServerClassMembershipSpec struct {
+	// ServerClass is the name of the ServerClass.
+	ServerClass string `json:"serverClass"`
+	// Server is the name of the Server.
+	Server string `json:"server"`
+}
+
+// ServerClassMembershipStatus defines the observed state of ServerClassMembership.
+type ServerClassMembershipStatus struct {
+	// State is the state of the server within the ServerClass.
+	State MembershipState `json:"state"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="ServerClass",type="string",JSONPath=".spec.serverClass",description="server class"
+// +kubebuilder:printcolumn:name="Server",type="string",JSONPath=".spec.server",description="server"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state",description="state of the server within the server class"
+
+// ServerClassMembership records that the Server is a member of the ServerClass.
+//
+// Memberships are managed by the ServerClass controller, one per server listed in the ServerClass status,
+// so that the membership changes can be watched per server and class pair.
+// Membership is named `<serverclass>.<server>` (shortened if it's too long), it's removed along with the ServerClass or the Server.
+type ServerClassMembership struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServerClassMembershipSpec   `json:"spec,omitempty"`
+	Status ServerClassMembershipStatus `json:"status,omitempty"`
+}
+
+// membershipHashLength is the length of the hash suffix of the shortened names.
+const membershipHashLength = 10
+
+// MembershipName returns the name of the membership of the server in the ServerClass.
+func MembershipName(serverClass, server string) string {
+	return shortenName(serverClass+"."+server, validation.DNS1123SubdomainMaxLength)
+}
+
+// MembershipLabelValue returns the value of the membership label for the name of the ServerClass or the Server.
+func MembershipLabelValue(name string) string {
+	return shortenName(name, validation.LabelValueMaxLength)
+}
+
+// shortenName keeps the names up to the max length as is, longer names are truncated and suffixed with the hash of the full name,
+// so that the shortened names stay unique.
+func shortenName(name string, max int) string {
+	if len(name) <= max {
+		return name
+	}
+
+	hash := sha256.Sum256([]byte(name))
+
+	// separators are trimmed, as the name parts can't end with them
+	return strings.TrimRight(name[:max-membershipHashLength-1], "-._") + "-" + hex.EncodeToString(hash[:])[:membershipHashLength]
+}
+
+// +kubebuilder:object:root=true
+
+// ServerClassMembershipList contains a list of ServerClassMembership.
+type ServerClassMembershipList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServerClassMembership `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServerClassMembership{}, &ServerClassMembershipList{})
+}
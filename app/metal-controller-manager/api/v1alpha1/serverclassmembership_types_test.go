@@ -0,0 +1,69 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package v1alpha1_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestMembershipLabelValue(t *testing.T) {
+	long := strings.Repeat("a", 60) + ".workers"
+
+	for _, tt := range []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "short", value: "workers", expected: "workers"},
+		{name: "max length", value: strings.Repeat("a", 63), expected: strings.Repeat("a", 63)},
+		{name: "long", value: long},
+		{name: "separator at the cut", value: strings.Repeat("a", 51) + "." + strings.Repeat("b", 20)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			value := v1alpha1.MembershipLabelValue(tt.value)
+
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				t.Errorf("invalid label value %q: %v", value, errs)
+			}
+
+			if tt.expected != "" && value != tt.expected {
+				t.Errorf("MembershipLabelValue() = %q, want %q", value, tt.expected)
+			}
+		})
+	}
+
+	if v1alpha1.MembershipLabelValue(long) == v1alpha1.MembershipLabelValue(long+"-2") {
+		t.Error("shortened label values with the same prefix collide")
+	}
+}
+
+func TestMembershipName(t *testing.T) {
+	server := "4c4c4544-0035-5910-804b-b2c04f4e4d32"
+
+	if name := v1alpha1.MembershipName("workers", server); name != "workers."+server {
+		t.Errorf("MembershipName() = %q", name)
+	}
+
+	for _, serverClass := range []string{
+		strings.Repeat("a", 220),
+		strings.Repeat("a", 241) + "." + strings.Repeat("b", 30),
+	} {
+		name := v1alpha1.MembershipName(serverClass, server)
+
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			t.Errorf("invalid membership name %q: %v", name, errs)
+		}
+
+		if name == v1alpha1.MembershipName(serverClass, "other") {
+			t.Error("shortened membership names collide")
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	"net"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SiteLabel is set on the servers located at the site, the value is the name of the Site.
+//
+// The label is set automatically for the servers booted from the provisioning subnets of the site,
+// it can also be set manually.
+const SiteLabel = "metal.sidero.dev/site"
+
+// SiteSpec defines the desired state of Site.
+type SiteSpec struct {
+	// Subnets lists the provisioning networks of the site in CIDR notation, e.g. "10.5.0.0/16".
+	// +optional
+	Subnets []string `json:"subnets,omitempty"`
+	// APIEndpoint is the address of Sidero advertised to the servers of the site, overrides the --api-endpoint flag.
+	// +optional
+	APIEndpoint string `json:"apiEndpoint,omitempty"`
+	// EnvironmentRef is the Environment of the site servers which don't have the environment set by the Server or the ServerClass.
+	// +optional
+	EnvironmentRef *corev1.ObjectReference `json:"environmentRef,omitempty"`
+	// AgentAssetsURL is the base URL of the external server hosting the agent kernel and initramfs for the site servers,
+	// overrides the --agent-assets-url flag.
+	//
+	// Assets are fetched over HTTP from the "agent/vmlinuz" and "agent/initramfs.xz" paths under the URL.
+	// +optional
+	AgentAssetsURL string `json:"agentAssetsURL,omitempty"`
+	// BMCProxy is the name of the BMCProxy used to reach the BMCs and the management APIs of the site servers,
+	// the BMCProxy set on the Server overrides it.
+	// +optional
+	BMCProxy string `json:"bmcProxy,omitempty"`
+}
+
+// SiteStatus defines the observed state of Site.
+type SiteStatus struct {
+	// Servers is the number of the servers at the site.
+	Servers int `json:"servers"`
+	// ServersAvailable is the number of the accepted and clean servers which are not in use.
+	ServersAvailable int `json:"serversAvailable"`
+	// ServersInUse is the number of the servers in use.
+	ServersInUse int `json:"serversInUse"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".spec.apiEndpoint",description="advertised API endpoint"
+// +kubebuilder:printcolumn:name="Servers",type="integer",JSONPath=".status.servers",description="the number of servers"
+// +kubebuilder:printcolumn:name="Available",type="integer",JSONPath=".status.serversAvailable",description="the number of available servers"
+// +kubebuilder:printcolumn:name="In Use",type="integer",JSONPath=".status.serversInUse",description="the number of servers in use"
+
+// Site is the Schema for the sites API.
+//
+// Site groups the servers of a physical location (datacenter), so that a single management cluster
+// can serve several locations, each with its own boot endpoint and environment.
+type Site struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SiteSpec   `json:"spec,omitempty"`
+	Status SiteStatus `json:"status,omitempty"`
+}
+
+// Contains checks whether the IP address belongs to the provisioning subnets of the site.
+func (site *Site) Contains(ip net.IP) bool {
+	for _, subnet := range site.Spec.Subnets {
+		_, network, err := net.ParseCIDR(subnet)
+		if err != nil {
+			continue
+		}
+
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContainsSubnet checks whether the provisioning subnet of the server (see ProvisioningSubnetLabel)
+// is within the provisioning subnets of the site.
+func (site *Site) ContainsSubnet(label string) bool {
+	ip, network, err := net.ParseCIDR(strings.ReplaceAll(label, "-", "/"))
+	if err != nil {
+		return false
+	}
+
+	ones, _ := network.Mask.Size()
+
+	for _, subnet := range site.Spec.Subnets {
+		_, siteNetwork, err := net.ParseCIDR(subnet)
+		if err != nil {
+			continue
+		}
+
+		if siteOnes, _ := siteNetwork.Mask.Size(); siteOnes <= ones && siteNetwork.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// +kubebuilder:object:root=true
+
+// SiteList contains a list of Site.
+type SiteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Site `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Site{}, &SiteList{})
+}
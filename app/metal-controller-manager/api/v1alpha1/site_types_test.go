@@ -0,0 +1,37 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestSiteContainsSubnet(t *testing.T) {
+	site := &v1alpha1.Site{
+		Spec: v1alpha1.SiteSpec{
+			Subnets: []string{"10.5.0.0/16", "invalid"},
+		},
+	}
+
+	for _, tt := range []struct {
+		label string
+		want  bool
+	}{
+		{label: "10.5.3.0-24", want: true},
+		{label: "10.5.0.0-16", want: true},
+		{label: "10.4.0.0-14", want: false},
+		{label: "10.6.0.0-24", want: false},
+		{label: "", want: false},
+	} {
+		t.Run(tt.label, func(t *testing.T) {
+			if got := site.ContainsSubnet(tt.label); got != tt.want {
+				t.Errorf("ContainsSubnet(%q) = %v, want %v", tt.label, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1
+
+import (
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Disk types.
+const (
+	DiskTypeHDD  = "hdd"
+	DiskTypeSSD  = "ssd"
+	DiskTypeNVMe = "nvme"
+)
+
+// Disk describes the server disk discovered by the agent.
+type Disk struct {
+	// Name is the kernel name of the disk, e.g. "sda".
+	Name string `json:"name"`
+	// Size is the size of the disk in bytes.
+	Size uint64 `json:"size,omitempty"`
+	// Model is the model of the disk reported by the kernel.
+	Model string `json:"model,omitempty"`
+	// Serial is the serial number of the disk.
+	Serial string `json:"serial,omitempty"`
+	// WWID is the world wide identifier of the disk, e.g. "naa.5000c500a1b2c3d4".
+	WWID string `json:"wwid,omitempty"`
+	// Rotational is set for the spinning disks.
+	Rotational bool `json:"rotational,omitempty"`
+}
+
+// Type returns the type of the disk: hdd, ssd or nvme.
+func (disk *Disk) Type() string {
+	switch {
+	case strings.HasPrefix(disk.Name, "nvme"):
+		return DiskTypeNVMe
+	case disk.Rotational:
+		return DiskTypeHDD
+	default:
+		return DiskTypeSSD
+	}
+}
+
+// Path returns the path of the disk which is stable across the reboots, as created by udev in /dev/disk/by-id.
+//
+// If the stable path can't be derived from the WWID of the disk, the path with the kernel name is returned.
+func (disk *Disk) Path() string {
+	switch {
+	case strings.HasPrefix(disk.Name, "nvme") && disk.WWID != "":
+		return "/dev/disk/by-id/nvme-" + disk.WWID
+	case strings.HasPrefix(disk.WWID, "naa."):
+		return "/dev/disk/by-id/wwn-0x" + strings.TrimPrefix(disk.WWID, "naa.")
+	default:
+		return "/dev/" + disk.Name
+	}
+}
+
+// DiskSelector selects the server disk by the properties reported by the agent.
+//
+// All the set fields should match, empty selector matches any disk.
+type DiskSelector struct {
+	// Name is the kernel name of the disk, e.g. "sda".
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Model is the glob pattern of the disk model, e.g. "Samsung SSD 9*".
+	// +optional
+	Model string `json:"model,omitempty"`
+	// Serial is the serial number of the disk.
+	// +optional
+	Serial string `json:"serial,omitempty"`
+	// MinSize is the minimum size of the disk.
+	// +optional
+	MinSize *resource.Quantity `json:"minSize,omitempty"`
+	// MaxSize is the maximum size of the disk.
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+	// Rotational selects the rotational (HDD) or non-rotational (SSD, NVMe) disks.
+	// +optional
+	Rotational *bool `json:"rotational,omitempty"`
+	// Type selects the disks by type: hdd, ssd or nvme.
+	// +kubebuilder:validation:Enum=hdd;ssd;nvme
+	// +optional
+	Type string `json:"type,omitempty"`
+}
+
+// Matches checks whether the disk satisfies the selector.
+func (selector *DiskSelector) Matches(disk *Disk) bool {
+	if selector.Name != "" && selector.Name != disk.Name {
+		return false
+	}
+
+	if selector.Model != "" {
+		if matched, _ := path.Match(selector.Model, disk.Model); !matched { //nolint: errcheck
+			return false
+		}
+	}
+
+	if selector.Serial != "" && selector.Serial != disk.Serial {
+		return false
+	}
+
+	if selector.MinSize != nil && disk.Size < uint64(selector.MinSize.Value()) {
+		return false
+	}
+
+	if selector.MaxSize != nil && disk.Size > uint64(selector.MaxSize.Value()) {
+		return false
+	}
+
+	if selector.Rotational != nil && *selector.Rotational != disk.Rotational {
+		return false
+	}
+
+	if selector.Type != "" && selector.Type != disk.Type() {
+		return false
+	}
+
+	return true
+}
+
+// StorageConfig selects the install disk and the extra disks of the server, rendered into the machine configuration
+// with the stable disk paths.
+type StorageConfig struct {
+	// InstallDisk selects the disk Talos is installed to.
+	// +optional
+	InstallDisk *DiskSelector `json:"installDisk,omitempty"`
+	// ExtraDisks select the disks mounted by Talos as a single partition.
+	// +optional
+	ExtraDisks []ExtraDisk `json:"extraDisks,omitempty"`
+}
+
+// ExtraDisk selects the disk mounted by Talos.
+type ExtraDisk struct {
+	DiskSelector `json:",inline"`
+
+	// Mountpoint is the path the disk is mounted to, e.g. "/var/mnt/data".
+	Mountpoint string `json:"mountpoint"`
+}
+
+// DiskQualifier requires the server to have the disks matching the selector.
+type DiskQualifier struct {
+	DiskSelector `json:",inline"`
+
+	// Count is the minimum number of the matching disks, defaults to 1.
+	// +optional
+	Count int `json:"count,omitempty"`
+}
+
+// Matches checks whether there are enough disks matching the selector.
+func (q *DiskQualifier) Matches(disks []Disk) bool {
+	count := q.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	for i := range disks {
+		if q.DiskSelector.Matches(&disks[i]) {
+			count--
+		}
+	}
+
+	return count <= 0
+}
+
+// FindDisk returns the first disk of the server which matches the selector and is not used yet.
+//
+// Used disks are tracked by the name, the found disk is added to the used disks.
+func (s *Server) FindDisk(selector *DiskSelector, used map[string]struct{}) (*Disk, bool) {
+	for i := range s.Status.Disks {
+		disk := &s.Status.Disks[i]
+
+		if _, ok := used[disk.Name]; ok {
+			continue
+		}
+
+		if selector.Matches(disk) {
+			used[disk.Name] = struct{}{}
+
+			return disk, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,208 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/pointer"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestDiskPath(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		disk v1alpha1.Disk
+		want string
+	}{
+		{
+			name: "nvme",
+			disk: v1alpha1.Disk{Name: "nvme0n1", WWID: "eui.0025388b91b0c2a1"},
+			want: "/dev/disk/by-id/nvme-eui.0025388b91b0c2a1",
+		},
+		{
+			name: "scsi",
+			disk: v1alpha1.Disk{Name: "sda", WWID: "naa.5000c500a1b2c3d4"},
+			want: "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4",
+		},
+		{
+			name: "no wwid",
+			disk: v1alpha1.Disk{Name: "vda"},
+			want: "/dev/vda",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.disk.Path(); got != tt.want {
+				t.Errorf("Path() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerFindDisk(t *testing.T) {
+	server := &v1alpha1.Server{
+		Status: v1alpha1.ServerStatus{
+			Disks: []v1alpha1.Disk{
+				{Name: "sda", Size: 4000 * 1000 * 1000 * 1000, Model: "ST4000NM0035", Rotational: true},
+				{Name: "sdb", Size: 4000 * 1000 * 1000 * 1000, Model: "ST4000NM0035", Rotational: true},
+				{Name: "nvme0n1", Size: 480 * 1000 * 1000 * 1000, Model: "Samsung SSD 983 DCT"},
+			},
+		},
+	}
+
+	minSize := resource.MustParse("1T")
+	used := map[string]struct{}{}
+
+	for _, tt := range []struct {
+		name     string
+		selector v1alpha1.DiskSelector
+		want     string
+	}{
+		{
+			name:     "non-rotational",
+			selector: v1alpha1.DiskSelector{Rotational: pointer.BoolPtr(false)},
+			want:     "nvme0n1",
+		},
+		{
+			name:     "first large disk",
+			selector: v1alpha1.DiskSelector{MinSize: &minSize},
+			want:     "sda",
+		},
+		{
+			name:     "second large disk",
+			selector: v1alpha1.DiskSelector{Model: "ST4000*"},
+			want:     "sdb",
+		},
+		{
+			name:     "no disks left",
+			selector: v1alpha1.DiskSelector{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, ok := server.FindDisk(&tt.selector, used)
+
+			if tt.want == "" {
+				if ok {
+					t.Errorf("FindDisk() = %q, want no disk", disk.Name)
+				}
+
+				return
+			}
+
+			if !ok || disk.Name != tt.want {
+				t.Errorf("FindDisk() = %v, want %q", disk, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiskQualifierMatches(t *testing.T) {
+	disks := []v1alpha1.Disk{
+		{Name: "sda", Size: 4000 * 1000 * 1000 * 1000, Rotational: true},
+		{Name: "sdb", Size: 4000 * 1000 * 1000 * 1000, Rotational: true},
+		{Name: "sdc", Size: 960 * 1000 * 1000 * 1000},
+		{Name: "nvme0n1", Size: 480 * 1000 * 1000 * 1000},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		qualifier v1alpha1.DiskQualifier
+		want      bool
+	}{
+		{
+			name:      "any disk",
+			qualifier: v1alpha1.DiskQualifier{},
+			want:      true,
+		},
+		{
+			name:      "nvme",
+			qualifier: v1alpha1.DiskQualifier{DiskSelector: v1alpha1.DiskSelector{Type: v1alpha1.DiskTypeNVMe}},
+			want:      true,
+		},
+		{
+			name:      "two hdds",
+			qualifier: v1alpha1.DiskQualifier{DiskSelector: v1alpha1.DiskSelector{Type: v1alpha1.DiskTypeHDD}, Count: 2},
+			want:      true,
+		},
+		{
+			name:      "two ssds",
+			qualifier: v1alpha1.DiskQualifier{DiskSelector: v1alpha1.DiskSelector{Type: v1alpha1.DiskTypeSSD}, Count: 2},
+			want:      false,
+		},
+		{
+			name:      "large nvme",
+			qualifier: v1alpha1.DiskQualifier{DiskSelector: v1alpha1.DiskSelector{Type: v1alpha1.DiskTypeNVMe, MinSize: resourcePtr("1T")}},
+			want:      false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.qualifier.Matches(disks); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryQualifierMatches(t *testing.T) {
+	memory := &v1alpha1.MemoryInformation{Size: 64 << 30, Modules: 4}
+
+	for _, tt := range []struct {
+		name      string
+		qualifier v1alpha1.MemoryQualifier
+		memory    *v1alpha1.MemoryInformation
+		want      bool
+	}{
+		{
+			name:      "min size",
+			qualifier: v1alpha1.MemoryQualifier{MinSize: resourcePtr("64Gi")},
+			memory:    memory,
+			want:      true,
+		},
+		{
+			name:      "too small",
+			qualifier: v1alpha1.MemoryQualifier{MinSize: resourcePtr("128Gi")},
+			memory:    memory,
+			want:      false,
+		},
+		{
+			name:      "too large",
+			qualifier: v1alpha1.MemoryQualifier{MaxSize: resourcePtr("32Gi")},
+			memory:    memory,
+			want:      false,
+		},
+		{
+			name:      "modules",
+			qualifier: v1alpha1.MemoryQualifier{MinModules: 2, MaxModules: 4},
+			memory:    memory,
+			want:      true,
+		},
+		{
+			name:      "too few modules",
+			qualifier: v1alpha1.MemoryQualifier{MinModules: 8},
+			memory:    memory,
+			want:      false,
+		},
+		{
+			name:      "unknown memory",
+			qualifier: v1alpha1.MemoryQualifier{},
+			want:      false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.qualifier.Matches(tt.memory); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func resourcePtr(s string) *resource.Quantity {
+	q := resource.MustParse(s)
+
+	return &q
+}
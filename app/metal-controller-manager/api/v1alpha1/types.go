@@ -14,3 +14,8 @@ type ConfigPatches struct {
 	Path  string             `json:"path"`
 	Value apiextensions.JSON `json:"value,omitempty"`
 }
+
+// MirroredLabel marks the objects mirrored by the standby Sidero installation from the active one.
+//
+// Standby installation doesn't manage the mirrored servers until it's promoted.
+const MirroredLabel = "metal.sidero.dev/mirrored"
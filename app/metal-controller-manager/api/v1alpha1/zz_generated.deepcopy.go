@@ -1,3 +1,4 @@
+//go:build !ignore_autogenerated
 // +build !ignore_autogenerated
 
 // This Source Code Form is subject to the terms of the Mozilla Public
@@ -10,89 +11,75 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/cluster-api/api/v1alpha3"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Asset) DeepCopyInto(out *Asset) {
+func (in *AdoptionMachine) DeepCopyInto(out *AdoptionMachine) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Asset.
-func (in *Asset) DeepCopy() *Asset {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdoptionMachine.
+func (in *AdoptionMachine) DeepCopy() *AdoptionMachine {
 	if in == nil {
 		return nil
 	}
-	out := new(Asset)
+	out := new(AdoptionMachine)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AssetCondition) DeepCopyInto(out *AssetCondition) {
+func (in *AllocationRecord) DeepCopyInto(out *AllocationRecord) {
 	*out = *in
-	out.Asset = in.Asset
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssetCondition.
-func (in *AssetCondition) DeepCopy() *AssetCondition {
-	if in == nil {
-		return nil
+	in.AllocatedAt.DeepCopyInto(&out.AllocatedAt)
+	if in.ReleasedAt != nil {
+		in, out := &in.ReleasedAt, &out.ReleasedAt
+		*out = (*in).DeepCopy()
 	}
-	out := new(AssetCondition)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BMC) DeepCopyInto(out *BMC) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMC.
-func (in *BMC) DeepCopy() *BMC {
-	if in == nil {
-		return nil
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
-	out := new(BMC)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CPUInformation) DeepCopyInto(out *CPUInformation) {
-	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPUInformation.
-func (in *CPUInformation) DeepCopy() *CPUInformation {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationRecord.
+func (in *AllocationRecord) DeepCopy() *AllocationRecord {
 	if in == nil {
 		return nil
 	}
-	out := new(CPUInformation)
+	out := new(AllocationRecord)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ConfigPatches) DeepCopyInto(out *ConfigPatches) {
+func (in *Asset) DeepCopyInto(out *Asset) {
 	*out = *in
-	in.Value.DeepCopyInto(&out.Value)
+	if in.Mirrors != nil {
+		in, out := &in.Mirrors, &out.Mirrors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigPatches.
-func (in *ConfigPatches) DeepCopy() *ConfigPatches {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Asset.
+func (in *Asset) DeepCopy() *Asset {
 	if in == nil {
 		return nil
 	}
-	out := new(ConfigPatches)
+	out := new(Asset)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Environment) DeepCopyInto(out *Environment) {
+func (in *AssetCache) DeepCopyInto(out *AssetCache) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -100,18 +87,18 @@ func (in *Environment) DeepCopyInto(out *Environment) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Environment.
-func (in *Environment) DeepCopy() *Environment {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssetCache.
+func (in *AssetCache) DeepCopy() *AssetCache {
 	if in == nil {
 		return nil
 	}
-	out := new(Environment)
+	out := new(AssetCache)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Environment) DeepCopyObject() runtime.Object {
+func (in *AssetCache) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -119,31 +106,31 @@ func (in *Environment) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EnvironmentList) DeepCopyInto(out *EnvironmentList) {
+func (in *AssetCacheList) DeepCopyInto(out *AssetCacheList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Environment, len(*in))
+		*out = make([]AssetCache, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentList.
-func (in *EnvironmentList) DeepCopy() *EnvironmentList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssetCacheList.
+func (in *AssetCacheList) DeepCopy() *AssetCacheList {
 	if in == nil {
 		return nil
 	}
-	out := new(EnvironmentList)
+	out := new(AssetCacheList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *EnvironmentList) DeepCopyObject() runtime.Object {
+func (in *AssetCacheList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -151,180 +138,254 @@ func (in *EnvironmentList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EnvironmentSpec) DeepCopyInto(out *EnvironmentSpec) {
+func (in *AssetCacheSpec) DeepCopyInto(out *AssetCacheSpec) {
 	*out = *in
-	in.Kernel.DeepCopyInto(&out.Kernel)
-	out.Initrd = in.Initrd
+	if in.Subnets != nil {
+		in, out := &in.Subnets, &out.Subnets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentSpec.
-func (in *EnvironmentSpec) DeepCopy() *EnvironmentSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssetCacheSpec.
+func (in *AssetCacheSpec) DeepCopy() *AssetCacheSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(EnvironmentSpec)
+	out := new(AssetCacheSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EnvironmentStatus) DeepCopyInto(out *EnvironmentStatus) {
+func (in *AssetCacheStatus) DeepCopyInto(out *AssetCacheStatus) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]AssetCondition, len(*in))
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]CachedEnvironment, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentStatus.
-func (in *EnvironmentStatus) DeepCopy() *EnvironmentStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssetCacheStatus.
+func (in *AssetCacheStatus) DeepCopy() *AssetCacheStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(EnvironmentStatus)
+	out := new(AssetCacheStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Initrd) DeepCopyInto(out *Initrd) {
+func (in *AssetCondition) DeepCopyInto(out *AssetCondition) {
 	*out = *in
-	out.Asset = in.Asset
+	in.Asset.DeepCopyInto(&out.Asset)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Initrd.
-func (in *Initrd) DeepCopy() *Initrd {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssetCondition.
+func (in *AssetCondition) DeepCopy() *AssetCondition {
 	if in == nil {
 		return nil
 	}
-	out := new(Initrd)
+	out := new(AssetCondition)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Kernel) DeepCopyInto(out *Kernel) {
+func (in *BMC) DeepCopyInto(out *BMC) {
 	*out = *in
-	out.Asset = in.Asset
-	if in.Args != nil {
-		in, out := &in.Args, &out.Args
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMC.
+func (in *BMC) DeepCopy() *BMC {
+	if in == nil {
+		return nil
 	}
+	out := new(BMC)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Kernel.
-func (in *Kernel) DeepCopy() *Kernel {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BMCProxy) DeepCopyInto(out *BMCProxy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMCProxy.
+func (in *BMCProxy) DeepCopy() *BMCProxy {
 	if in == nil {
 		return nil
 	}
-	out := new(Kernel)
+	out := new(BMCProxy)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BMCProxy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagementAPI) DeepCopyInto(out *ManagementAPI) {
+func (in *BMCProxyList) DeepCopyInto(out *BMCProxyList) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BMCProxy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementAPI.
-func (in *ManagementAPI) DeepCopy() *ManagementAPI {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMCProxyList.
+func (in *BMCProxyList) DeepCopy() *BMCProxyList {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagementAPI)
+	out := new(BMCProxyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BMCProxyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BMCProxySpec) DeepCopyInto(out *BMCProxySpec) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMCProxySpec.
+func (in *BMCProxySpec) DeepCopy() *BMCProxySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BMCProxySpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Qualifiers) DeepCopyInto(out *Qualifiers) {
+func (in *CPUInformation) DeepCopyInto(out *CPUInformation) {
 	*out = *in
-	if in.CPU != nil {
-		in, out := &in.CPU, &out.CPU
-		*out = make([]CPUInformation, len(*in))
-		copy(*out, *in)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPUInformation.
+func (in *CPUInformation) DeepCopy() *CPUInformation {
+	if in == nil {
+		return nil
 	}
-	if in.SystemInformation != nil {
-		in, out := &in.SystemInformation, &out.SystemInformation
-		*out = make([]SystemInformation, len(*in))
+	out := new(CPUInformation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CablingRule) DeepCopyInto(out *CablingRule) {
+	*out = *in
+	if in.SystemNames != nil {
+		in, out := &in.SystemNames, &out.SystemNames
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.LabelSelectors != nil {
-		in, out := &in.LabelSelectors, &out.LabelSelectors
-		*out = make([]map[string]string, len(*in))
-		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = make(map[string]string, len(*in))
-				for key, val := range *in {
-					(*out)[key] = val
-				}
-			}
-		}
+	if in.ChassisIDs != nil {
+		in, out := &in.ChassisIDs, &out.ChassisIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Qualifiers.
-func (in *Qualifiers) DeepCopy() *Qualifiers {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CablingRule.
+func (in *CablingRule) DeepCopy() *CablingRule {
 	if in == nil {
 		return nil
 	}
-	out := new(Qualifiers)
+	out := new(CablingRule)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Server) DeepCopyInto(out *Server) {
+func (in *CachedEnvironment) DeepCopyInto(out *CachedEnvironment) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Server.
-func (in *Server) DeepCopy() *Server {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CachedEnvironment.
+func (in *CachedEnvironment) DeepCopy() *CachedEnvironment {
 	if in == nil {
 		return nil
 	}
-	out := new(Server)
+	out := new(CachedEnvironment)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Server) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryRollout) DeepCopyInto(out *CanaryRollout) {
+	*out = *in
+	if in.EnvironmentRef != nil {
+		in, out := &in.EnvironmentRef, &out.EnvironmentRef
+		*out = new(v1.ObjectReference)
+		**out = **in
 	}
-	return nil
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryRollout.
+func (in *CanaryRollout) DeepCopy() *CanaryRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryRollout)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServerClass) DeepCopyInto(out *ServerClass) {
+func (in *ConfigPatchSet) DeepCopyInto(out *ConfigPatchSet) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClass.
-func (in *ServerClass) DeepCopy() *ServerClass {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigPatchSet.
+func (in *ConfigPatchSet) DeepCopy() *ConfigPatchSet {
 	if in == nil {
 		return nil
 	}
-	out := new(ServerClass)
+	out := new(ConfigPatchSet)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ServerClass) DeepCopyObject() runtime.Object {
+func (in *ConfigPatchSet) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -332,31 +393,31 @@ func (in *ServerClass) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServerClassList) DeepCopyInto(out *ServerClassList) {
+func (in *ConfigPatchSetList) DeepCopyInto(out *ConfigPatchSetList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ServerClass, len(*in))
+		*out = make([]ConfigPatchSet, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassList.
-func (in *ServerClassList) DeepCopy() *ServerClassList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigPatchSetList.
+func (in *ConfigPatchSetList) DeepCopy() *ConfigPatchSetList {
 	if in == nil {
 		return nil
 	}
-	out := new(ServerClassList)
+	out := new(ConfigPatchSetList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ServerClassList) DeepCopyObject() runtime.Object {
+func (in *ConfigPatchSetList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -364,14 +425,8 @@ func (in *ServerClassList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServerClassSpec) DeepCopyInto(out *ServerClassSpec) {
+func (in *ConfigPatchSetSpec) DeepCopyInto(out *ConfigPatchSetSpec) {
 	*out = *in
-	if in.EnvironmentRef != nil {
-		in, out := &in.EnvironmentRef, &out.EnvironmentRef
-		*out = new(v1.ObjectReference)
-		**out = **in
-	}
-	in.Qualifiers.DeepCopyInto(&out.Qualifiers)
 	if in.ConfigPatches != nil {
 		in, out := &in.ConfigPatches, &out.ConfigPatches
 		*out = make([]ConfigPatches, len(*in))
@@ -381,37 +436,1302 @@ func (in *ServerClassSpec) DeepCopyInto(out *ServerClassSpec) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassSpec.
-func (in *ServerClassSpec) DeepCopy() *ServerClassSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigPatchSetSpec.
+func (in *ConfigPatchSetSpec) DeepCopy() *ConfigPatchSetSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ServerClassSpec)
+	out := new(ConfigPatchSetSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServerClassStatus) DeepCopyInto(out *ServerClassStatus) {
+func (in *ConfigPatches) DeepCopyInto(out *ConfigPatches) {
 	*out = *in
-	if in.ServersAvailable != nil {
-		in, out := &in.ServersAvailable, &out.ServersAvailable
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.ServersInUse != nil {
-		in, out := &in.ServersInUse, &out.ServersInUse
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
+	in.Value.DeepCopyInto(&out.Value)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassStatus.
-func (in *ServerClassStatus) DeepCopy() *ServerClassStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigPatches.
+func (in *ConfigPatches) DeepCopy() *ConfigPatches {
 	if in == nil {
 		return nil
 	}
-	out := new(ServerClassStatus)
+	out := new(ConfigPatches)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Disk) DeepCopyInto(out *Disk) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Disk.
+func (in *Disk) DeepCopy() *Disk {
+	if in == nil {
+		return nil
+	}
+	out := new(Disk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskQualifier) DeepCopyInto(out *DiskQualifier) {
+	*out = *in
+	in.DiskSelector.DeepCopyInto(&out.DiskSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskQualifier.
+func (in *DiskQualifier) DeepCopy() *DiskQualifier {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskQualifier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskSelector) DeepCopyInto(out *DiskSelector) {
+	*out = *in
+	if in.MinSize != nil {
+		in, out := &in.MinSize, &out.MinSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Rotational != nil {
+		in, out := &in.Rotational, &out.Rotational
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskSelector.
+func (in *DiskSelector) DeepCopy() *DiskSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskWipe) DeepCopyInto(out *DiskWipe) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskWipe.
+func (in *DiskWipe) DeepCopy() *DiskWipe {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskWipe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Environment) DeepCopyInto(out *Environment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Environment.
+func (in *Environment) DeepCopy() *Environment {
+	if in == nil {
+		return nil
+	}
+	out := new(Environment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Environment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentList) DeepCopyInto(out *EnvironmentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Environment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentList.
+func (in *EnvironmentList) DeepCopy() *EnvironmentList {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnvironmentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentSpec) DeepCopyInto(out *EnvironmentSpec) {
+	*out = *in
+	in.Kernel.DeepCopyInto(&out.Kernel)
+	in.Initrd.DeepCopyInto(&out.Initrd)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentSpec.
+func (in *EnvironmentSpec) DeepCopy() *EnvironmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentStatus) DeepCopyInto(out *EnvironmentStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]AssetCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentStatus.
+func (in *EnvironmentStatus) DeepCopy() *EnvironmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExclusionQualifiers) DeepCopyInto(out *ExclusionQualifiers) {
+	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		*out = make([]CPUInformation, len(*in))
+		copy(*out, *in)
+	}
+	if in.SystemInformation != nil {
+		in, out := &in.SystemInformation, &out.SystemInformation
+		*out = make([]SystemInformation, len(*in))
+		copy(*out, *in)
+	}
+	if in.LabelSelectors != nil {
+		in, out := &in.LabelSelectors, &out.LabelSelectors
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+	if in.LabelExpressions != nil {
+		in, out := &in.LabelExpressions, &out.LabelExpressions
+		*out = make([]metav1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PCIDevices != nil {
+		in, out := &in.PCIDevices, &out.PCIDevices
+		*out = make([]PCIDeviceInformation, len(*in))
+		copy(*out, *in)
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(MemoryQualifier)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = make([]DiskQualifier, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NetworkInterfaces != nil {
+		in, out := &in.NetworkInterfaces, &out.NetworkInterfaces
+		*out = make([]NetworkInterfaceQualifier, len(*in))
+		copy(*out, *in)
+	}
+	if in.GPUs != nil {
+		in, out := &in.GPUs, &out.GPUs
+		*out = make([]GPUQualifier, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ServerNames != nil {
+		in, out := &in.ServerNames, &out.ServerNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExclusionQualifiers.
+func (in *ExclusionQualifiers) DeepCopy() *ExclusionQualifiers {
+	if in == nil {
+		return nil
+	}
+	out := new(ExclusionQualifiers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtraDisk) DeepCopyInto(out *ExtraDisk) {
+	*out = *in
+	in.DiskSelector.DeepCopyInto(&out.DiskSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtraDisk.
+func (in *ExtraDisk) DeepCopy() *ExtraDisk {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtraDisk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FaultToleration) DeepCopyInto(out *FaultToleration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FaultToleration.
+func (in *FaultToleration) DeepCopy() *FaultToleration {
+	if in == nil {
+		return nil
+	}
+	out := new(FaultToleration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezeWindow) DeepCopyInto(out *FreezeWindow) {
+	*out = *in
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = (*in).DeepCopy()
+	}
+	if in.End != nil {
+		in, out := &in.End, &out.End
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezeWindow.
+func (in *FreezeWindow) DeepCopy() *FreezeWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezeWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUInformation) DeepCopyInto(out *GPUInformation) {
+	*out = *in
+	if in.VRAM != nil {
+		in, out := &in.VRAM, &out.VRAM
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUInformation.
+func (in *GPUInformation) DeepCopy() *GPUInformation {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUInformation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUQualifier) DeepCopyInto(out *GPUQualifier) {
+	*out = *in
+	if in.MinVRAM != nil {
+		in, out := &in.MinVRAM, &out.MinVRAM
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUQualifier.
+func (in *GPUQualifier) DeepCopy() *GPUQualifier {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUQualifier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareFault) DeepCopyInto(out *HardwareFault) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareFault.
+func (in *HardwareFault) DeepCopy() *HardwareFault {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareFault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Initrd) DeepCopyInto(out *Initrd) {
+	*out = *in
+	in.Asset.DeepCopyInto(&out.Asset)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Initrd.
+func (in *Initrd) DeepCopy() *Initrd {
+	if in == nil {
+		return nil
+	}
+	out := new(Initrd)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Kernel) DeepCopyInto(out *Kernel) {
+	*out = *in
+	in.Asset.DeepCopyInto(&out.Asset)
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Kernel.
+func (in *Kernel) DeepCopy() *Kernel {
+	if in == nil {
+		return nil
+	}
+	out := new(Kernel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LLDPNeighbor) DeepCopyInto(out *LLDPNeighbor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLDPNeighbor.
+func (in *LLDPNeighbor) DeepCopy() *LLDPNeighbor {
+	if in == nil {
+		return nil
+	}
+	out := new(LLDPNeighbor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagementAPI) DeepCopyInto(out *ManagementAPI) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementAPI.
+func (in *ManagementAPI) DeepCopy() *ManagementAPI {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagementAPI)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemoryInformation) DeepCopyInto(out *MemoryInformation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemoryInformation.
+func (in *MemoryInformation) DeepCopy() *MemoryInformation {
+	if in == nil {
+		return nil
+	}
+	out := new(MemoryInformation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemoryQualifier) DeepCopyInto(out *MemoryQualifier) {
+	*out = *in
+	if in.MinSize != nil {
+		in, out := &in.MinSize, &out.MinSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemoryQualifier.
+func (in *MemoryQualifier) DeepCopy() *MemoryQualifier {
+	if in == nil {
+		return nil
+	}
+	out := new(MemoryQualifier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkConfig) DeepCopyInto(out *NetworkConfig) {
+	*out = *in
+	if in.Workload != nil {
+		in, out := &in.Workload, &out.Workload
+		*out = new(WorkloadNetwork)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfig.
+func (in *NetworkConfig) DeepCopy() *NetworkConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterface) DeepCopyInto(out *NetworkInterface) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterface.
+func (in *NetworkInterface) DeepCopy() *NetworkInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterface)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterfaceQualifier) DeepCopyInto(out *NetworkInterfaceQualifier) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterfaceQualifier.
+func (in *NetworkInterfaceQualifier) DeepCopy() *NetworkInterfaceQualifier {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterfaceQualifier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PCIDevice) DeepCopyInto(out *PCIDevice) {
+	*out = *in
+	out.PCIDeviceInformation = in.PCIDeviceInformation
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PCIDevice.
+func (in *PCIDevice) DeepCopy() *PCIDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(PCIDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PCIDeviceInformation) DeepCopyInto(out *PCIDeviceInformation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PCIDeviceInformation.
+func (in *PCIDeviceInformation) DeepCopy() *PCIDeviceInformation {
+	if in == nil {
+		return nil
+	}
+	out := new(PCIDeviceInformation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Qualifiers) DeepCopyInto(out *Qualifiers) {
+	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		*out = make([]CPUInformation, len(*in))
+		copy(*out, *in)
+	}
+	if in.SystemInformation != nil {
+		in, out := &in.SystemInformation, &out.SystemInformation
+		*out = make([]SystemInformation, len(*in))
+		copy(*out, *in)
+	}
+	if in.LabelSelectors != nil {
+		in, out := &in.LabelSelectors, &out.LabelSelectors
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+	if in.LabelExpressions != nil {
+		in, out := &in.LabelExpressions, &out.LabelExpressions
+		*out = make([]metav1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PCIDevices != nil {
+		in, out := &in.PCIDevices, &out.PCIDevices
+		*out = make([]PCIDeviceInformation, len(*in))
+		copy(*out, *in)
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(MemoryQualifier)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = make([]DiskQualifier, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NetworkInterfaces != nil {
+		in, out := &in.NetworkInterfaces, &out.NetworkInterfaces
+		*out = make([]NetworkInterfaceQualifier, len(*in))
+		copy(*out, *in)
+	}
+	if in.GPUs != nil {
+		in, out := &in.GPUs, &out.GPUs
+		*out = make([]GPUQualifier, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ServerNames != nil {
+		in, out := &in.ServerNames, &out.ServerNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = new(ExclusionQualifiers)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Qualifiers.
+func (in *Qualifiers) DeepCopy() *Qualifiers {
+	if in == nil {
+		return nil
+	}
+	out := new(Qualifiers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReallocationPolicy) DeepCopyInto(out *ReallocationPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReallocationPolicy.
+func (in *ReallocationPolicy) DeepCopy() *ReallocationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ReallocationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Server) DeepCopyInto(out *Server) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Server.
+func (in *Server) DeepCopy() *Server {
+	if in == nil {
+		return nil
+	}
+	out := new(Server)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Server) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerActionRecord) DeepCopyInto(out *ServerActionRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerActionRecord.
+func (in *ServerActionRecord) DeepCopy() *ServerActionRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerActionRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerAdoption) DeepCopyInto(out *ServerAdoption) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerAdoption.
+func (in *ServerAdoption) DeepCopy() *ServerAdoption {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerAdoption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerAdoption) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerAdoptionList) DeepCopyInto(out *ServerAdoptionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServerAdoption, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerAdoptionList.
+func (in *ServerAdoptionList) DeepCopy() *ServerAdoptionList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerAdoptionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerAdoptionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerAdoptionSpec) DeepCopyInto(out *ServerAdoptionSpec) {
+	*out = *in
+	out.TalosConfigSecretRef = in.TalosConfigSecretRef
+	if in.Machine != nil {
+		in, out := &in.Machine, &out.Machine
+		*out = new(AdoptionMachine)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerAdoptionSpec.
+func (in *ServerAdoptionSpec) DeepCopy() *ServerAdoptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerAdoptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerAdoptionStatus) DeepCopyInto(out *ServerAdoptionStatus) {
+	*out = *in
+	if in.ServerRef != nil {
+		in, out := &in.ServerRef, &out.ServerRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.MachineRef != nil {
+		in, out := &in.MachineRef, &out.MachineRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerAdoptionStatus.
+func (in *ServerAdoptionStatus) DeepCopy() *ServerAdoptionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerAdoptionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClass) DeepCopyInto(out *ServerClass) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClass.
+func (in *ServerClass) DeepCopy() *ServerClass {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerClass) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassExport) DeepCopyInto(out *ServerClassExport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassExport.
+func (in *ServerClassExport) DeepCopy() *ServerClassExport {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerClassExport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassExportList) DeepCopyInto(out *ServerClassExportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServerClassExport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassExportList.
+func (in *ServerClassExportList) DeepCopy() *ServerClassExportList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassExportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerClassExportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassExportSpec) DeepCopyInto(out *ServerClassExportSpec) {
+	*out = *in
+	out.TokenSecretRef = in.TokenSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassExportSpec.
+func (in *ServerClassExportSpec) DeepCopy() *ServerClassExportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassExportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassExportStatus) DeepCopyInto(out *ServerClassExportStatus) {
+	*out = *in
+	if in.ServersLeased != nil {
+		in, out := &in.ServersLeased, &out.ServersLeased
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassExportStatus.
+func (in *ServerClassExportStatus) DeepCopy() *ServerClassExportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassExportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassImport) DeepCopyInto(out *ServerClassImport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassImport.
+func (in *ServerClassImport) DeepCopy() *ServerClassImport {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassImport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerClassImport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassImportList) DeepCopyInto(out *ServerClassImportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServerClassImport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassImportList.
+func (in *ServerClassImportList) DeepCopy() *ServerClassImportList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassImportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerClassImportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassImportSpec) DeepCopyInto(out *ServerClassImportSpec) {
+	*out = *in
+	out.TokenSecretRef = in.TokenSecretRef
+	if in.BMCCredentialsSecretRef != nil {
+		in, out := &in.BMCCredentialsSecretRef, &out.BMCCredentialsSecretRef
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassImportSpec.
+func (in *ServerClassImportSpec) DeepCopy() *ServerClassImportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassImportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassImportStatus) DeepCopyInto(out *ServerClassImportStatus) {
+	*out = *in
+	if in.ServersImported != nil {
+		in, out := &in.ServersImported, &out.ServersImported
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassImportStatus.
+func (in *ServerClassImportStatus) DeepCopy() *ServerClassImportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassImportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassList) DeepCopyInto(out *ServerClassList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServerClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassList.
+func (in *ServerClassList) DeepCopy() *ServerClassList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerClassList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassMembership) DeepCopyInto(out *ServerClassMembership) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassMembership.
+func (in *ServerClassMembership) DeepCopy() *ServerClassMembership {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassMembership)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerClassMembership) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassMembershipList) DeepCopyInto(out *ServerClassMembershipList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServerClassMembership, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassMembershipList.
+func (in *ServerClassMembershipList) DeepCopy() *ServerClassMembershipList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassMembershipList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServerClassMembershipList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassMembershipSpec) DeepCopyInto(out *ServerClassMembershipSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassMembershipSpec.
+func (in *ServerClassMembershipSpec) DeepCopy() *ServerClassMembershipSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassMembershipSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassMembershipStatus) DeepCopyInto(out *ServerClassMembershipStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassMembershipStatus.
+func (in *ServerClassMembershipStatus) DeepCopy() *ServerClassMembershipStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassMembershipStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassSpec) DeepCopyInto(out *ServerClassSpec) {
+	*out = *in
+	if in.EnvironmentRef != nil {
+		in, out := &in.EnvironmentRef, &out.EnvironmentRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	in.Qualifiers.DeepCopyInto(&out.Qualifiers)
+	if in.ConfigPatches != nil {
+		in, out := &in.ConfigPatches, &out.ConfigPatches
+		*out = make([]ConfigPatches, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConfigPatchSets != nil {
+		in, out := &in.ConfigPatchSets, &out.ConfigPatchSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigMergePatches != nil {
+		in, out := &in.ConfigMergePatches, &out.ConfigMergePatches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeLabels != nil {
+		in, out := &in.NodeLabels, &out.NodeLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeTaints != nil {
+		in, out := &in.NodeTaints, &out.NodeTaints
+		*out = make([]v1.Taint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Reallocation != nil {
+		in, out := &in.Reallocation, &out.Reallocation
+		*out = new(ReallocationPolicy)
+		**out = **in
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]FaultToleration, len(*in))
+		copy(*out, *in)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryRollout)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IncludeClasses != nil {
+		in, out := &in.IncludeClasses, &out.IncludeClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassSpec.
+func (in *ServerClassSpec) DeepCopy() *ServerClassSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerClassStatus) DeepCopyInto(out *ServerClassStatus) {
+	*out = *in
+	if in.ServersAvailable != nil {
+		in, out := &in.ServersAvailable, &out.ServersAvailable
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServersInUse != nil {
+		in, out := &in.ServersInUse, &out.ServersInUse
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServersCanary != nil {
+		in, out := &in.ServersCanary, &out.ServersCanary
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServersPendingAcceptance != nil {
+		in, out := &in.ServersPendingAcceptance, &out.ServersPendingAcceptance
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServersWipingInProgress != nil {
+		in, out := &in.ServersWipingInProgress, &out.ServersWipingInProgress
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1alpha3.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerClassStatus.
+func (in *ServerClassStatus) DeepCopy() *ServerClassStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerClassStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -483,6 +1803,52 @@ func (in *ServerSpec) DeepCopyInto(out *ServerSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ConfigPatchSets != nil {
+		in, out := &in.ConfigPatchSets, &out.ConfigPatchSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GPUs != nil {
+		in, out := &in.GPUs, &out.GPUs
+		*out = make([]GPUInformation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CablingRules != nil {
+		in, out := &in.CablingRules, &out.CablingRules
+		*out = make([]CablingRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WipeTimeout != nil {
+		in, out := &in.WipeTimeout, &out.WipeTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Faults != nil {
+		in, out := &in.Faults, &out.Faults
+		*out = make([]HardwareFault, len(*in))
+		copy(*out, *in)
+	}
+	if in.FreezeWindows != nil {
+		in, out := &in.FreezeWindows, &out.FreezeWindows
+		*out = make([]FreezeWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerSpec.
@@ -510,6 +1876,60 @@ func (in *ServerStatus) DeepCopyInto(out *ServerStatus) {
 		*out = make([]v1.NodeAddress, len(*in))
 		copy(*out, *in)
 	}
+	if in.NetworkInterfaces != nil {
+		in, out := &in.NetworkInterfaces, &out.NetworkInterfaces
+		*out = make([]NetworkInterface, len(*in))
+		copy(*out, *in)
+	}
+	if in.PCIDevices != nil {
+		in, out := &in.PCIDevices, &out.PCIDevices
+		*out = make([]PCIDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = make([]Disk, len(*in))
+		copy(*out, *in)
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(MemoryInformation)
+		**out = **in
+	}
+	if in.LLDPNeighbors != nil {
+		in, out := &in.LLDPNeighbors, &out.LLDPNeighbors
+		*out = make([]LLDPNeighbor, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastInventoryTime != nil {
+		in, out := &in.LastInventoryTime, &out.LastInventoryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.AllocationHistory != nil {
+		in, out := &in.AllocationHistory, &out.AllocationHistory
+		*out = make([]AllocationRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllocationHistoryTrimmedUntil != nil {
+		in, out := &in.AllocationHistoryTrimmedUntil, &out.AllocationHistoryTrimmedUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.WipeAttestation != nil {
+		in, out := &in.WipeAttestation, &out.WipeAttestation
+		*out = new(WipeAttestation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastPowerFailure != nil {
+		in, out := &in.LastPowerFailure, &out.LastPowerFailure
+		*out = (*in).DeepCopy()
+	}
+	if in.LastAction != nil {
+		in, out := &in.LastAction, &out.LastAction
+		*out = new(ServerActionRecord)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerStatus.
@@ -522,6 +1942,132 @@ func (in *ServerStatus) DeepCopy() *ServerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Site) DeepCopyInto(out *Site) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Site.
+func (in *Site) DeepCopy() *Site {
+	if in == nil {
+		return nil
+	}
+	out := new(Site)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Site) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SiteList) DeepCopyInto(out *SiteList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Site, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SiteList.
+func (in *SiteList) DeepCopy() *SiteList {
+	if in == nil {
+		return nil
+	}
+	out := new(SiteList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SiteList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SiteSpec) DeepCopyInto(out *SiteSpec) {
+	*out = *in
+	if in.Subnets != nil {
+		in, out := &in.Subnets, &out.Subnets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EnvironmentRef != nil {
+		in, out := &in.EnvironmentRef, &out.EnvironmentRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SiteSpec.
+func (in *SiteSpec) DeepCopy() *SiteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SiteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SiteStatus) DeepCopyInto(out *SiteStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SiteStatus.
+func (in *SiteStatus) DeepCopy() *SiteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SiteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
+	*out = *in
+	if in.InstallDisk != nil {
+		in, out := &in.InstallDisk, &out.InstallDisk
+		*out = new(DiskSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraDisks != nil {
+		in, out := &in.ExtraDisks, &out.ExtraDisks
+		*out = make([]ExtraDisk, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageConfig.
+func (in *StorageConfig) DeepCopy() *StorageConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SystemInformation) DeepCopyInto(out *SystemInformation) {
 	*out = *in
@@ -536,3 +2082,54 @@ func (in *SystemInformation) DeepCopy() *SystemInformation {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WipeAttestation) DeepCopyInto(out *WipeAttestation) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = make([]DiskWipe, len(*in))
+		copy(*out, *in)
+	}
+	if in.Payload != nil {
+		in, out := &in.Payload, &out.Payload
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.Signature != nil {
+		in, out := &in.Signature, &out.Signature
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WipeAttestation.
+func (in *WipeAttestation) DeepCopy() *WipeAttestation {
+	if in == nil {
+		return nil
+	}
+	out := new(WipeAttestation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadNetwork) DeepCopyInto(out *WorkloadNetwork) {
+	*out = *in
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadNetwork.
+func (in *WorkloadNetwork) DeepCopy() *WorkloadNetwork {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadNetwork)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,88 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path/filepath"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+const (
+	// wipeSamples is the number of the blocks read back after the wipe, spread evenly across the disk.
+	wipeSamples = 16
+	// wipeSampleSize is the size of the sampled block.
+	wipeSampleSize = 4096
+)
+
+// attestWipe reads back the samples of the wiped disk, so that the wipe attestation proves the disk reads as zeros.
+func attestWipe(bd *blockdevice.BlockDevice, path, method string) (*api.DiskWipe, error) {
+	size, err := bd.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	return attestRange(bd, path, "", method, 0, size)
+}
+
+// attestRange reads back the samples of the wiped range of the disk (e.g. the partition).
+func attestRange(bd *blockdevice.BlockDevice, path, partition, method string, start, size uint64) (*api.DiskWipe, error) {
+	name := filepath.Base(path)
+
+	result := &api.DiskWipe{
+		Name:      name,
+		Serial:    readSysfs(filepath.Join(blockDevicesDirectory, name), "device/serial"),
+		Partition: partition,
+		Method:    method,
+		Zeroed:    true,
+	}
+
+	if size < wipeSampleSize {
+		return result, nil
+	}
+
+	var (
+		hash   = sha256.New()
+		sample = make([]byte, wipeSampleSize)
+		zeros  = make([]byte, wipeSampleSize)
+		last   = (size - wipeSampleSize) / wipeSampleSize
+	)
+
+	for i := uint64(0); i < wipeSamples; i++ {
+		// samples are aligned to the block size, the first and the last blocks are always sampled
+		offset := int64(start + i*last/(wipeSamples-1)*wipeSampleSize)
+
+		if _, err := bd.Device().ReadAt(sample, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		hash.Write(sample) //nolint: errcheck
+
+		result.Samples++
+		result.Zeroed = result.Zeroed && bytes.Equal(sample, zeros)
+	}
+
+	result.SampleHash = hex.EncodeToString(hash.Sum(nil))
+
+	return result, nil
+}
+
+// failedAttestation is the attestation of the disk which was wiped, but couldn't be read back:
+// no blocks are sampled, and the disk is not reported as zeroed.
+func failedAttestation(path, method string) *api.DiskWipe {
+	name := filepath.Base(path)
+
+	return &api.DiskWipe{
+		Name:   name,
+		Serial: readSysfs(filepath.Join(blockDevicesDirectory, name), "device/serial"),
+		Method: method,
+	}
+}
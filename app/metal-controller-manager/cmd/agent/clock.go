@@ -0,0 +1,139 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/talos-systems/go-retry/retry"
+	"github.com/talos-systems/go-smbios/smbios"
+	"golang.org/x/sys/unix"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+const rtcDevice = "/dev/rtc0"
+
+// reconcileClock reports the hardware clock to Sidero, and corrects both the system and the hardware clock
+// if the skew is too big, as Talos fails to validate certificates on the first boot with the clock being way off.
+//
+// Sidero (management cluster) time is used as a reference, as the agent environment has no other time source.
+func reconcileClock(ctx context.Context, client api.AgentClient, s *smbios.Smbios) error {
+	uuid, err := s.SystemInformation().UUID()
+	if err != nil {
+		return err
+	}
+
+	report := func() (*api.ReconcileServerClockResponse, time.Time, error) {
+		var (
+			resp      *api.ReconcileServerClockResponse
+			reference time.Time
+		)
+
+		err := retry.Constant(5*time.Minute, retry.WithUnits(30*time.Second)).Retry(func() error {
+			ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+
+			start := time.Now()
+
+			resp, err = client.ReconcileServerClock(ctx, &api.ReconcileServerClockRequest{
+				Uuid: uuid.String(),
+				Time: readHardwareClock().UnixNano(),
+			})
+			if err != nil {
+				return retry.ExpectedError(err)
+			}
+
+			// assume that the response took half of the round trip
+			reference = time.Unix(0, resp.GetTime()).Add(time.Since(start) / 2)
+
+			return nil
+		})
+
+		return resp, reference, err
+	}
+
+	resp, reference, err := report()
+	if err != nil {
+		return err
+	}
+
+	skew := reference.Sub(readHardwareClock())
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew.Seconds() <= resp.GetMaxSkew() {
+		return nil
+	}
+
+	log.Printf("Clock is off by %s, correcting", skew.Round(time.Second))
+
+	setErr := setClock(reference)
+
+	if _, _, err = report(); err != nil {
+		return err
+	}
+
+	return setErr
+}
+
+// readHardwareClock returns the RTC time, or the system time if the RTC is not available.
+func readHardwareClock() time.Time {
+	f, err := os.Open(rtcDevice)
+	if err != nil {
+		log.Printf("Failed to open %s, using system time: %s", rtcDevice, err)
+
+		return time.Now()
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	rtc, err := unix.IoctlGetRTCTime(int(f.Fd()))
+	if err != nil {
+		log.Printf("Failed to read %s, using system time: %s", rtcDevice, err)
+
+		return time.Now()
+	}
+
+	return time.Date(int(rtc.Year)+1900, time.Month(rtc.Mon+1), int(rtc.Mday), int(rtc.Hour), int(rtc.Min), int(rtc.Sec), 0, time.UTC)
+}
+
+// setClock sets the system clock and the RTC (in UTC) to the specified time.
+func setClock(t time.Time) error {
+	tv := unix.NsecToTimeval(t.UnixNano())
+
+	if err := unix.Settimeofday(&tv); err != nil {
+		return fmt.Errorf("failed to set system clock: %w", err)
+	}
+
+	f, err := os.OpenFile(rtcDevice, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", rtcDevice, err)
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	t = t.UTC()
+
+	if err = unix.IoctlSetRTCTime(int(f.Fd()), &unix.RTCTime{
+		Sec:  int32(t.Second()),
+		Min:  int32(t.Minute()),
+		Hour: int32(t.Hour()),
+		Mday: int32(t.Day()),
+		Mon:  int32(t.Month()) - 1,
+		Year: int32(t.Year()) - 1900,
+		Wday: int32(t.Weekday()),
+		Yday: int32(t.YearDay()) - 1,
+	}); err != nil {
+		return fmt.Errorf("failed to set %s: %w", rtcDevice, err)
+	}
+
+	return nil
+}
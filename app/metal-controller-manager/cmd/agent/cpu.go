@@ -0,0 +1,57 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/binary"
+
+	"github.com/talos-systems/go-smbios/smbios"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+// processorType is the SMBIOS structure type of the processor (socket).
+const processorType = 4
+
+// cpu describes the processors reported by SMBIOS, cores and threads are summed up across the sockets.
+func cpu(s *smbios.Smbios) *api.CPU {
+	result := &api.CPU{
+		Manufacturer: s.ProcessorInformation().ProcessorManufacturer(),
+		Version:      s.ProcessorInformation().ProcessorVersion(),
+	}
+
+	for _, structure := range s.Structures {
+		// formatted area excludes the 4 byte header, so the offsets are shifted from the spec
+		if structure.Header.Type != processorType || len(structure.Formatted) < 34 {
+			continue
+		}
+
+		// populated socket status
+		if structure.Formatted[20]&0x40 == 0 {
+			continue
+		}
+
+		if speed := uint32(binary.LittleEndian.Uint16(structure.Formatted[16:18])); speed > result.FrequencyMhz {
+			result.FrequencyMhz = speed
+		}
+
+		cores := uint32(structure.Formatted[31])
+		threads := uint32(structure.Formatted[33])
+
+		// counts above 255 are reported in the SMBIOS 3.0 fields
+		if cores == 0xff && len(structure.Formatted) >= 40 {
+			cores = uint32(binary.LittleEndian.Uint16(structure.Formatted[38:40]))
+		}
+
+		if threads == 0xff && len(structure.Formatted) >= 44 {
+			threads = uint32(binary.LittleEndian.Uint16(structure.Formatted[42:44]))
+		}
+
+		result.Cores += cores
+		result.Threads += threads
+	}
+
+	return result
+}
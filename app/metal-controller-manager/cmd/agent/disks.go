@@ -0,0 +1,72 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+const blockDevicesDirectory = "/sys/block"
+
+// virtualDiskPrefixes lists kernel names of the block devices which are not physical disks.
+var virtualDiskPrefixes = []string{"loop", "ram", "zram", "dm-", "md", "nbd", "sr"}
+
+// disks lists the disks of the server from sysfs.
+//
+// Agent doesn't run udev, so the stable names of the disks are derived from the WWID by the controller.
+func disks() ([]*api.Disk, error) {
+	entries, err := ioutil.ReadDir(blockDevicesDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*api.Disk
+
+	for _, entry := range entries {
+		if isVirtualDisk(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(blockDevicesDirectory, entry.Name())
+
+		// size is always reported in 512-byte sectors
+		sectors, err := strconv.ParseUint(readSysfs(path, "size"), 10, 64)
+		if err != nil || sectors == 0 {
+			continue
+		}
+
+		disk := &api.Disk{
+			Name:       entry.Name(),
+			Size:       sectors * 512,
+			Model:      readSysfs(path, "device/model"),
+			Serial:     readSysfs(path, "device/serial"),
+			Wwid:       readSysfs(path, "wwid"),
+			Rotational: readSysfs(path, "queue/rotational") == "1",
+		}
+
+		if disk.Wwid == "" {
+			disk.Wwid = readSysfs(path, "device/wwid")
+		}
+
+		result = append(result, disk)
+	}
+
+	return result, nil
+}
+
+func isVirtualDisk(name string) bool {
+	for _, prefix := range virtualDiskPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	efiDirectory     = "/sys/firmware/efi"
+	efiVarsDirectory = "/sys/firmware/efi/efivars"
+
+	// EFI global variable GUID.
+	efiGlobalVariable = "8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+	// EFI_VARIABLE_NON_VOLATILE | EFI_VARIABLE_BOOTSERVICE_ACCESS | EFI_VARIABLE_RUNTIME_ACCESS.
+	efiVariableAttributes = 0x7
+
+	fsImmutableFlag = 0x10
+)
+
+// setBootNextToCurrent sets UEFI BootNext variable to the boot entry the server is booted from (BootCurrent).
+//
+// Agent is always PXE booted, so this makes the server PXE boot once more, even if it boots from disk by default.
+func setBootNextToCurrent() (uint16, error) {
+	if _, err := os.Stat(efiDirectory); err != nil {
+		return 0, fmt.Errorf("server is not booted in UEFI mode: %w", err)
+	}
+
+	if err := unix.Mount("efivarfs", efiVarsDirectory, "efivarfs", 0, ""); err != nil && !errors.Is(err, unix.EBUSY) {
+		return 0, fmt.Errorf("failed to mount efivarfs: %w", err)
+	}
+
+	current, err := ioutil.ReadFile(efiVariablePath("BootCurrent"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read BootCurrent: %w", err)
+	}
+
+	// variable data is prefixed with 4 bytes of attributes
+	if len(current) != 6 {
+		return 0, fmt.Errorf("unexpected BootCurrent length %d", len(current))
+	}
+
+	entry := binary.LittleEndian.Uint16(current[4:])
+
+	next := make([]byte, 6)
+	binary.LittleEndian.PutUint32(next, efiVariableAttributes)
+	binary.LittleEndian.PutUint16(next[4:], entry)
+
+	path := efiVariablePath("BootNext")
+
+	// efivarfs makes existing variables immutable to protect from accidental writes
+	if err = clearImmutable(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open BootNext: %w", err)
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	// efivarfs requires the variable to be written in a single write call
+	if _, err = f.Write(next); err != nil {
+		return 0, fmt.Errorf("failed to write BootNext: %w", err)
+	}
+
+	return entry, nil
+}
+
+func efiVariablePath(name string) string {
+	return filepath.Join(efiVarsDirectory, name+"-"+efiGlobalVariable)
+}
+
+func clearImmutable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	flags, err := unix.IoctlGetUint32(int(f.Fd()), unix.FS_IOC_GETFLAGS)
+	if err != nil {
+		return fmt.Errorf("failed to get %s flags: %w", path, err)
+	}
+
+	if flags&fsImmutableFlag == 0 {
+		return nil
+	}
+
+	if err = unix.IoctlSetPointerInt(int(f.Fd()), unix.FS_IOC_SETFLAGS, int(flags&^fsImmutableFlag)); err != nil {
+		return fmt.Errorf("failed to clear %s immutable flag: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+const (
+	lldpEtherType = 0x88cc
+
+	lldpTLVEnd             = 0
+	lldpTLVChassisID       = 1
+	lldpTLVPortID          = 2
+	lldpTLVTTL             = 3
+	lldpTLVPortDescription = 4
+	lldpTLVSystemName      = 5
+
+	lldpChassisIDSubtypeMAC = 4
+	lldpPortIDSubtypeMAC    = 3
+
+	// switches advertise LLDP every 30 seconds by default.
+	lldpListenTimeout = 35 * time.Second
+)
+
+// nearest bridge LLDP multicast address.
+var lldpMulticastAddr = [8]byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}
+
+// discoverLLDPNeighbors listens for LLDP advertisements on all physical interfaces.
+//
+// Interfaces which are down are brought up, as LLDP frames are only received over active links.
+func discoverLLDPNeighbors() ([]*api.LLDPNeighbor, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		neighbors []*api.LLDPNeighbor
+	)
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) != 6 {
+			continue
+		}
+
+		if iface.Flags&net.FlagUp == 0 {
+			if err = linkUp(iface.Name); err != nil {
+				log.Printf("Skipping LLDP on %s: %s", iface.Name, err)
+
+				continue
+			}
+		}
+
+		wg.Add(1)
+
+		go func(iface net.Interface) {
+			defer wg.Done()
+
+			neighbor, err := listenLLDP(iface)
+			if err != nil {
+				log.Printf("LLDP on %s: %s", iface.Name, err)
+
+				return
+			}
+
+			if neighbor == nil {
+				return
+			}
+
+			mu.Lock()
+			neighbors = append(neighbors, neighbor)
+			mu.Unlock()
+		}(iface)
+	}
+
+	wg.Wait()
+
+	return neighbors, nil
+}
+
+func listenLLDP(iface net.Interface) (*api.LLDPNeighbor, error) {
+	proto := htons(lldpEtherType)
+
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW|unix.SOCK_CLOEXEC, int(proto))
+	if err != nil {
+		return nil, fmt.Errorf("error opening socket: %w", err)
+	}
+
+	defer unix.Close(fd) //nolint: errcheck
+
+	if err = unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: proto, Ifindex: iface.Index}); err != nil {
+		return nil, fmt.Errorf("error binding socket: %w", err)
+	}
+
+	if err = unix.SetsockoptPacketMreq(fd, unix.SOL_PACKET, unix.PACKET_ADD_MEMBERSHIP, &unix.PacketMreq{
+		Ifindex: int32(iface.Index),
+		Type:    unix.PACKET_MR_MULTICAST,
+		Alen:    6,
+		Address: lldpMulticastAddr,
+	}); err != nil {
+		return nil, fmt.Errorf("error joining LLDP multicast group: %w", err)
+	}
+
+	deadline := time.Now().Add(lldpListenTimeout)
+	buf := make([]byte, 1500)
+
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, nil
+		}
+
+		tv := unix.NsecToTimeval(remaining.Nanoseconds())
+
+		if err = unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+			return nil, fmt.Errorf("error setting socket timeout: %w", err)
+		}
+
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if err == unix.EAGAIN || err == unix.EINTR {
+				continue
+			}
+
+			return nil, fmt.Errorf("error receiving frame: %w", err)
+		}
+
+		// SOCK_RAW frames include the ethernet header
+		if n < 14 || binary.BigEndian.Uint16(buf[12:14]) != lldpEtherType {
+			continue
+		}
+
+		if neighbor := parseLLDP(buf[14:n]); neighbor != nil {
+			neighbor.Interface = iface.Name
+
+			return neighbor, nil
+		}
+	}
+}
+
+// parseLLDP decodes the LLDPDU TLVs which are relevant for cabling validation.
+//
+// LLDPDU should start with the mandatory Chassis ID, Port ID and TTL TLVs (in this order),
+// malformed LLDPDUs are ignored.
+func parseLLDP(b []byte) *api.LLDPNeighbor {
+	neighbor := &api.LLDPNeighbor{}
+
+	// mandatory TLVs are expected as the first TLVs of the LLDPDU
+	mandatory := []uint16{lldpTLVChassisID, lldpTLVPortID, lldpTLVTTL}
+
+	for i := 0; len(b) >= 2; i++ {
+		header := binary.BigEndian.Uint16(b[:2])
+		typ, length := header>>9, int(header&0x1ff)
+
+		b = b[2:]
+
+		if len(b) < length {
+			return nil
+		}
+
+		value := b[:length]
+		b = b[length:]
+
+		if i < len(mandatory) && typ != mandatory[i] {
+			return nil
+		}
+
+		if typ == lldpTLVEnd {
+			break
+		}
+
+		switch typ {
+		case lldpTLVChassisID:
+			if length < 2 {
+				return nil
+			}
+
+			neighbor.ChassisId = formatLLDPID(value[0], lldpChassisIDSubtypeMAC, value[1:])
+		case lldpTLVPortID:
+			if length < 2 {
+				return nil
+			}
+
+			neighbor.PortId = formatLLDPID(value[0], lldpPortIDSubtypeMAC, value[1:])
+		case lldpTLVTTL:
+			if length != 2 {
+				return nil
+			}
+		case lldpTLVPortDescription:
+			neighbor.PortDescription = string(value)
+		case lldpTLVSystemName:
+			neighbor.SystemName = string(value)
+		}
+	}
+
+	if neighbor.ChassisId == "" || neighbor.PortId == "" {
+		return nil
+	}
+
+	return neighbor
+}
+
+func formatLLDPID(subtype, macSubtype byte, value []byte) string {
+	if subtype == macSubtype && len(value) == 6 {
+		return net.HardwareAddr(value).String()
+	}
+
+	return string(value)
+}
+
+func linkUp(name string) error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+
+	defer unix.Close(fd) //nolint: errcheck
+
+	var ifr struct {
+		name  [unix.IFNAMSIZ]byte
+		flags uint16
+		_     [22]byte
+	}
+
+	copy(ifr.name[:], name)
+
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.SIOCGIFFLAGS, uintptr(unsafe.Pointer(&ifr))); errno != 0 {
+		return errno
+	}
+
+	ifr.flags |= unix.IFF_UP
+
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.SIOCSIFFLAGS, uintptr(unsafe.Pointer(&ifr))); errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
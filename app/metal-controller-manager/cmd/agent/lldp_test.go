@@ -0,0 +1,115 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+func lldpTLV(typ uint16, value ...byte) []byte {
+	header := typ<<9 | uint16(len(value))
+
+	return append([]byte{byte(header >> 8), byte(header)}, value...)
+}
+
+func lldpDU(tlvs ...[]byte) []byte {
+	var b []byte
+
+	for _, tlv := range tlvs {
+		b = append(b, tlv...)
+	}
+
+	return b
+}
+
+func Test_parseLLDP(t *testing.T) {
+	var (
+		chassisID = lldpTLV(lldpTLVChassisID, lldpChassisIDSubtypeMAC, 0x00, 0x1c, 0x73, 0x01, 0x02, 0x03)
+		portID    = lldpTLV(lldpTLVPortID, 5, 'E', 't', 'h', '1')
+		ttl       = lldpTLV(lldpTLVTTL, 0x00, 0x78)
+		end       = lldpTLV(lldpTLVEnd)
+	)
+
+	for _, tt := range []struct {
+		name     string
+		b        []byte
+		expected *api.LLDPNeighbor
+	}{
+		{
+			name: "valid",
+			b: lldpDU(
+				chassisID, portID, ttl,
+				lldpTLV(lldpTLVPortDescription, []byte("uplink")...),
+				lldpTLV(lldpTLVSystemName, []byte("tor-1")...),
+				end,
+				// padding of the ethernet frame
+				[]byte{0, 0, 0, 0},
+			),
+			expected: &api.LLDPNeighbor{
+				ChassisId:       "00:1c:73:01:02:03",
+				PortId:          "Eth1",
+				PortDescription: "uplink",
+				SystemName:      "tor-1",
+			},
+		},
+		{
+			name: "port MAC address",
+			b:    lldpDU(chassisID, lldpTLV(lldpTLVPortID, lldpPortIDSubtypeMAC, 0x00, 0x1c, 0x73, 0x01, 0x02, 0x04), ttl, end),
+			expected: &api.LLDPNeighbor{
+				ChassisId: "00:1c:73:01:02:03",
+				PortId:    "00:1c:73:01:02:04",
+			},
+		},
+		{
+			name: "no end TLV",
+			b:    lldpDU(chassisID, portID, ttl),
+			expected: &api.LLDPNeighbor{
+				ChassisId: "00:1c:73:01:02:03",
+				PortId:    "Eth1",
+			},
+		},
+		{
+			name: "end before chassis ID",
+			b:    lldpDU(end, chassisID, portID, ttl),
+		},
+		{
+			name: "end before port ID",
+			b:    lldpDU(chassisID, end),
+		},
+		{
+			name: "end before TTL",
+			b:    lldpDU(chassisID, portID, end),
+		},
+		{
+			name: "out of order",
+			b:    lldpDU(portID, chassisID, ttl, end),
+		},
+		{
+			name: "empty chassis ID",
+			b:    lldpDU(lldpTLV(lldpTLVChassisID, lldpChassisIDSubtypeMAC), portID, ttl, end),
+		},
+		{
+			name: "invalid TTL",
+			b:    lldpDU(chassisID, portID, lldpTLV(lldpTLVTTL, 0x78), end),
+		},
+		{
+			name: "truncated",
+			b:    lldpDU(chassisID, portID, ttl)[:10],
+		},
+		{
+			name: "empty",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if neighbor := parseLLDP(tt.b); !reflect.DeepEqual(neighbor, tt.expected) {
+				t.Errorf("parseLLDP() = %v, want %v", neighbor, tt.expected)
+			}
+		})
+	}
+}
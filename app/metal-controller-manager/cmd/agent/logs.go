@@ -0,0 +1,109 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
+)
+
+// logBuffer keeps the tail of the agent logs, so that they can be shipped to the management cluster.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write implements io.Writer.
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+
+	if len(b.buf) > constants.AgentLogsSize {
+		tail := b.buf[len(b.buf)-constants.AgentLogsSize:]
+
+		// drop the partial line at the start
+		if i := bytes.IndexByte(tail, '\n'); i >= 0 {
+			tail = tail[i+1:]
+		}
+
+		b.buf = append([]byte(nil), tail...)
+	}
+
+	return len(p), nil
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return string(b.buf)
+}
+
+var agentLogs = &logBuffer{}
+
+// logShipper sends the agent logs to the management cluster, as they are lost once the server reboots.
+type logShipper struct {
+	client api.AgentClient
+	uuid   string
+	bootID string
+
+	mu sync.Mutex
+}
+
+func newLogShipper(client api.AgentClient, uuid string) *logShipper {
+	bootID, err := ioutil.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		// boot ID is only used to tell the logs of the previous boot apart
+		bootID = []byte(time.Now().String())
+	}
+
+	return &logShipper{
+		client: client,
+		uuid:   uuid,
+		bootID: strings.TrimSpace(string(bootID)),
+	}
+}
+
+// run ships the logs periodically until the context is canceled.
+func (s *logShipper) run(ctx context.Context) {
+	ticker := time.NewTicker(constants.AgentLogsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.ship(ctx) //nolint: errcheck
+	}
+}
+
+// ship sends all the logs of the current boot.
+func (s *logShipper) ship(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := s.client.ReportLogs(ctx, &api.ReportLogsRequest{
+		Uuid:   s.uuid,
+		BootId: s.bootID,
+		Logs:   agentLogs.String(),
+	})
+
+	return err
+}
@@ -7,13 +7,14 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/talos-systems/go-blockdevice/blockdevice"
 	"github.com/talos-systems/go-blockdevice/blockdevice/util"
 	"github.com/talos-systems/go-procfs/procfs"
 	"github.com/talos-systems/go-retry/retry"
@@ -22,6 +23,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sys/unix"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
@@ -73,7 +75,7 @@ func setup() error {
 		return fmt.Errorf("failed to open /dev/kmsg: %w", err)
 	}
 
-	log.SetOutput(kmsg)
+	log.SetOutput(io.MultiWriter(kmsg, agentLogs))
 	log.SetPrefix("[sidero]" + " ")
 	log.SetFlags(0)
 
@@ -96,10 +98,8 @@ func create(ctx context.Context, client api.AgentClient, s *smbios.Smbios) (*api
 			SkuNumber:    s.SystemInformation().SKUNumber(),
 			Family:       s.SystemInformation().Family(),
 		},
-		Cpu: &api.CPU{
-			Manufacturer: s.ProcessorInformation().ProcessorManufacturer(),
-			Version:      s.ProcessorInformation().ProcessorVersion(),
-		},
+		Cpu:    cpu(s),
+		Memory: memory(s),
 	}
 
 	hostname, err := os.Hostname()
@@ -109,6 +109,34 @@ func create(ctx context.Context, client api.AgentClient, s *smbios.Smbios) (*api
 		req.Hostname = hostname
 	}
 
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		log.Printf("encountered error fetching network interfaces: %q", err)
+	}
+
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) == 0 {
+			continue
+		}
+
+		req.NetworkInterface = append(req.NetworkInterface, networkInterface(iface))
+	}
+
+	req.ProvisioningAddress, req.ProvisioningGateway, err = provisioningNetwork()
+	if err != nil {
+		log.Printf("encountered error fetching provisioning network: %q", err)
+	}
+
+	req.PciDevice, err = pciDevices()
+	if err != nil {
+		log.Printf("encountered error fetching PCI devices: %q", err)
+	}
+
+	req.Disk, err = disks()
+	if err != nil {
+		log.Printf("encountered error fetching disks: %q", err)
+	}
+
 	var resp *api.CreateServerResponse
 
 	err = retry.Constant(5*time.Minute, retry.WithUnits(30*time.Second), retry.WithErrorLogging(true)).Retry(func() error {
@@ -126,17 +154,23 @@ func create(ctx context.Context, client api.AgentClient, s *smbios.Smbios) (*api
 	return resp, err
 }
 
-func wipe(ctx context.Context, client api.AgentClient, s *smbios.Smbios) error {
+func wipe(ctx context.Context, client api.AgentClient, s *smbios.Smbios, disks []*api.DiskWipe) error {
 	uuid, err := s.SystemInformation().UUID()
 	if err != nil {
 		return err
 	}
 
+	req := &api.MarkServerAsWipedRequest{
+		Uuid:      uuid.String(),
+		Disk:      disks,
+		Timestamp: time.Now().Unix(),
+	}
+
 	return retry.Constant(5*time.Minute, retry.WithUnits(30*time.Second), retry.WithErrorLogging(true)).Retry(func() error {
 		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 		defer cancel()
 
-		_, err = client.MarkServerAsWiped(ctx, &api.MarkServerAsWipedRequest{Uuid: uuid.String()})
+		_, err = client.MarkServerAsWiped(ctx, req)
 		if err != nil {
 			return retry.ExpectedError(err)
 		}
@@ -175,11 +209,47 @@ func reconcileIPs(ctx context.Context, client api.AgentClient, s *smbios.Smbios,
 	})
 }
 
+func reconcileLLDPNeighbors(ctx context.Context, client api.AgentClient, s *smbios.Smbios) error {
+	uuid, err := s.SystemInformation().UUID()
+	if err != nil {
+		return err
+	}
+
+	neighbors, err := discoverLLDPNeighbors()
+	if err != nil {
+		return err
+	}
+
+	return retry.Constant(5*time.Minute, retry.WithUnits(30*time.Second)).Retry(func() error {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		_, err = client.ReconcileServerLLDPNeighbors(ctx, &api.ReconcileServerLLDPNeighborsRequest{
+			Uuid:     uuid.String(),
+			Neighbor: neighbors,
+		})
+		if err != nil {
+			return retry.ExpectedError(err)
+		}
+
+		return nil
+	})
+}
+
+// shipper is set once the server UUID is known.
+var shipper *logShipper
+
 func shutdown(err error) {
 	if err != nil {
 		log.Println(err)
 	}
 
+	if shipper != nil {
+		if err = shipper.ship(context.Background()); err != nil {
+			log.Printf("failed to ship logs: %s", err)
+		}
+	}
+
 	for i := 10; i >= 0; i-- {
 		log.Printf("rebooting in %d seconds\n", i)
 		time.Sleep(1 * time.Second)
@@ -207,6 +277,8 @@ func mainFunc() error {
 		return err
 	}
 
+	loadModules()
+
 	var endpoint string
 	if found := procfs.ProcCmdline().Get(constants.AgentEndpointArg).First(); found != nil {
 		endpoint = *found
@@ -216,13 +288,12 @@ func mainFunc() error {
 
 	log.Printf("Using %q as API endpoint", endpoint)
 
+	// connection is not closed, as the logs are shipped on shutdown
 	conn, err := connect(ctx, endpoint)
 	if err != nil {
 		return err
 	}
 
-	defer conn.Close()
-
 	client := api.NewAgentClient(conn)
 
 	log.Println("Reading SMBIOS")
@@ -232,6 +303,12 @@ func mainFunc() error {
 		return err
 	}
 
+	if uuid, err := s.SystemInformation().UUID(); err == nil {
+		shipper = newLogShipper(client, uuid.String())
+
+		go shipper.run(ctx)
+	}
+
 	createResp, err := create(ctx, client, s)
 	if err != nil {
 		return err
@@ -239,6 +316,20 @@ func mainFunc() error {
 
 	log.Println("Registration complete")
 
+	if err = reconcileClock(ctx, client, s); err != nil {
+		log.Printf("failed to reconcile clock: %s", err)
+	} else {
+		log.Println("Reconciled clock")
+	}
+
+	if createResp.GetSetBootNext() {
+		if entry, err := setBootNextToCurrent(); err != nil {
+			log.Printf("failed to set next boot to the network: %s", err)
+		} else {
+			log.Printf("Set next boot to the network boot entry Boot%04X", entry)
+		}
+	}
+
 	ips, err := talosnet.IPAddrs()
 	if err != nil {
 		log.Println("failed to discover IPs")
@@ -250,6 +341,25 @@ func mainFunc() error {
 		log.Printf("Reconciled IPs")
 	}
 
+	lldpDone := make(chan struct{})
+
+	// LLDP discovery waits for switch advertisements, so run it in parallel with the wipe
+	go func() {
+		defer close(lldpDone)
+
+		if err := reconcileLLDPNeighbors(ctx, client, s); err != nil {
+			log.Printf("failed to reconcile LLDP neighbors: %s", err)
+
+			return
+		}
+
+		log.Println("Reconciled LLDP neighbors")
+	}()
+
+	defer func() {
+		<-lldpDone
+	}()
+
 	if createResp.GetWipe() {
 		disks, err := util.GetDisks()
 		if err != nil {
@@ -264,6 +374,9 @@ func mainFunc() error {
 		var (
 			eg errgroup.Group
 			wg sync.WaitGroup
+
+			wipedMu sync.Mutex
+			wiped   []*api.DiskWipe
 		)
 
 		heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
@@ -305,29 +418,16 @@ func mainFunc() error {
 				eg.Go(func() error {
 					log.Printf("Resetting %s", path)
 
-					bd, err := blockdevice.Open(path)
+					attestations, err := wipeDisk(path, createResp.GetWipeScope(), createResp.GetInsecureWipe(), createResp.GetVerifyDisk())
 					if err != nil {
-						log.Printf("Skipping %s: %s", path, err)
-
-						return nil
+						return err
 					}
 
-					if createResp.GetInsecureWipe() {
-						if err = bd.FastWipe(); err != nil {
-							return fmt.Errorf("failed wiping %q: %w", path, err)
-						}
-
-						log.Printf("Fast wiped %s", path)
-					} else {
-						method, err := bd.Wipe()
-						if err != nil {
-							return fmt.Errorf("failed wiping %q: %w", path, err)
-						}
+					wipedMu.Lock()
+					wiped = append(wiped, attestations...)
+					wipedMu.Unlock()
 
-						log.Printf("Wiped %s with %s", path, method)
-					}
-
-					return bd.Close()
+					return nil
 				})
 			}(disk.DeviceName)
 		}
@@ -336,7 +436,22 @@ func mainFunc() error {
 			shutdown(err)
 		}
 
-		if err := wipe(ctx, client, s); err != nil {
+		sort.Slice(wiped, func(i, j int) bool {
+			if wiped[i].Name != wiped[j].Name {
+				return wiped[i].Name < wiped[j].Name
+			}
+
+			return wiped[i].Partition < wiped[j].Partition
+		})
+
+		wipeCtx := ctx
+
+		if token := procfs.ProcCmdline().Get(constants.AgentTokenArg).First(); token != nil {
+			// the token authenticates the wipe report, so that Sidero signs the attestation
+			wipeCtx = metadata.AppendToOutgoingContext(ctx, constants.AgentTokenMetadataKey, *token)
+		}
+
+		if err := wipe(wipeCtx, client, s, wiped); err != nil {
 			shutdown(err)
 		}
 
@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/binary"
+
+	"github.com/talos-systems/go-smbios/smbios"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+// memoryDeviceType is the SMBIOS structure type of the memory device (module slot).
+const memoryDeviceType = 17
+
+// memory sums up the memory modules reported by SMBIOS.
+//
+// Installed memory is reported rather than the memory available to the kernel, so that the size matches the hardware spec.
+func memory(s *smbios.Smbios) *api.Memory {
+	result := &api.Memory{}
+
+	for _, structure := range s.Structures {
+		// formatted area excludes the 4 byte header, so the offsets are shifted from the spec
+		if structure.Header.Type != memoryDeviceType || len(structure.Formatted) < 10 {
+			continue
+		}
+
+		size := uint64(binary.LittleEndian.Uint16(structure.Formatted[8:10]))
+
+		switch {
+		case size == 0 || size == 0xffff:
+			// empty slot or unknown size
+			continue
+		case size == 0x7fff && len(structure.Formatted) >= 28:
+			// extended size in MiB
+			size = uint64(binary.LittleEndian.Uint32(structure.Formatted[24:28])&0x7fffffff) << 20
+		case size&0x8000 != 0:
+			// size in KiB
+			size = (size & 0x7fff) << 10
+		default:
+			size <<= 20
+		}
+
+		result.Size += size
+		result.Modules++
+	}
+
+	return result
+}
@@ -0,0 +1,78 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const modulesDirectory = "/lib/modules"
+
+// loadModules loads the kernel modules added to the agent initramfs by the overlays.
+//
+// The agent has no modules.dep, so the modules are loaded in passes: a module which fails
+// because of the missing dependency is retried as long as some other module got loaded.
+func loadModules() {
+	var modules []string
+
+	//nolint: errcheck
+	filepath.Walk(modulesDirectory, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() && strings.HasSuffix(path, ".ko") {
+			modules = append(modules, path)
+		}
+
+		return nil
+	})
+
+	failed := map[string]error{}
+
+	for len(modules) > 0 {
+		var pending []string
+
+		for _, module := range modules {
+			if err := loadModule(module); err != nil {
+				failed[module] = err
+				pending = append(pending, module)
+
+				continue
+			}
+
+			delete(failed, module)
+			log.Printf("Loaded kernel module %q", filepath.Base(module))
+		}
+
+		if len(pending) == len(modules) {
+			break
+		}
+
+		modules = pending
+	}
+
+	for module, err := range failed {
+		log.Printf("Failed to load kernel module %q: %s", filepath.Base(module), err)
+	}
+}
+
+func loadModule(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	err = unix.FinitModule(int(f.Fd()), "", 0)
+	if errors.Is(err, unix.EEXIST) {
+		return nil
+	}
+
+	return err
+}
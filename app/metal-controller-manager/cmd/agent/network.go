@@ -0,0 +1,101 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+const (
+	routesFile          = "/proc/net/route"
+	netDevicesDirectory = "/sys/class/net"
+)
+
+// networkInterface describes the interface with the link speed and the PCI device details from sysfs.
+//
+// Virtual interfaces have no PCI device, and the speed is only known while the link is up.
+func networkInterface(iface net.Interface) *api.NetworkInterface {
+	path := filepath.Join(netDevicesDirectory, iface.Name)
+
+	result := &api.NetworkInterface{
+		Name:     iface.Name,
+		Mac:      iface.HardwareAddr.String(),
+		VendorId: readSysfs(path, "device/vendor"),
+		DeviceId: readSysfs(path, "device/device"),
+	}
+
+	// speed is -1 (or can't be read) while the link is down
+	if speed, err := strconv.Atoi(readSysfs(path, "speed")); err == nil && speed > 0 {
+		result.Speed = uint32(speed)
+	}
+
+	if driver, err := os.Readlink(filepath.Join(path, "device", "driver")); err == nil {
+		result.Driver = filepath.Base(driver)
+	}
+
+	return result
+}
+
+// provisioningNetwork returns the IPv4 address (in CIDR notation) and the default gateway
+// of the interface the agent got the DHCP lease on (the one with the default route).
+func provisioningNetwork() (address, gateway string, err error) {
+	f, err := os.Open(routesFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+
+		gw, err := hex.DecodeString(fields[2])
+		if err != nil || len(gw) != net.IPv4len {
+			continue
+		}
+
+		iface, err := net.InterfaceByName(fields[0])
+		if err != nil {
+			return "", "", err
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return "", "", err
+		}
+
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				// routes file uses host byte order
+				gatewayIP := make(net.IP, net.IPv4len)
+				binary.BigEndian.PutUint32(gatewayIP, binary.LittleEndian.Uint32(gw))
+
+				return ipNet.String(), gatewayIP.String(), nil
+			}
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	return "", "", fmt.Errorf("default route not found")
+}
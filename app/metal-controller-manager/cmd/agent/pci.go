@@ -0,0 +1,102 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+const pciDevicesDirectory = "/sys/bus/pci/devices"
+
+// pciClassPrefixes lists PCI classes of the add-in cards reported to Sidero.
+//
+// Bridges, USB and other onboard system peripherals are skipped.
+var pciClassPrefixes = []string{
+	"0x01",   // mass storage controllers
+	"0x02",   // network controllers
+	"0x03",   // display controllers
+	"0x0b",   // processors (co-processors)
+	"0x0c04", // fibre channel
+	"0x12",   // processing accelerators
+	"0xff",   // unassigned class (e.g. FPGAs)
+}
+
+var nvmeNamespaceRegexp = regexp.MustCompile(`^nvme\d+n\d+$`)
+
+// pciDevices lists the add-in cards of the server from sysfs.
+func pciDevices() ([]*api.PCIDevice, error) {
+	entries, err := ioutil.ReadDir(pciDevicesDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []*api.PCIDevice
+
+	for _, entry := range entries {
+		path := filepath.Join(pciDevicesDirectory, entry.Name())
+
+		class := readSysfs(path, "class")
+		if !isAddInCard(class) {
+			continue
+		}
+
+		device := &api.PCIDevice{
+			Address:           entry.Name(),
+			Class:             class,
+			VendorId:          readSysfs(path, "vendor"),
+			DeviceId:          readSysfs(path, "device"),
+			SubsystemVendorId: readSysfs(path, "subsystem_vendor"),
+			SubsystemDeviceId: readSysfs(path, "subsystem_device"),
+		}
+
+		if driver, err := os.Readlink(filepath.Join(path, "driver")); err == nil {
+			device.Driver = filepath.Base(driver)
+		}
+
+		// NVMe controllers report the model and namespaces
+		controllers, _ := filepath.Glob(filepath.Join(path, "nvme", "nvme*")) //nolint: errcheck
+
+		for _, controller := range controllers {
+			device.Model = readSysfs(controller, "model")
+
+			namespaces, _ := ioutil.ReadDir(controller) //nolint: errcheck
+
+			for _, namespace := range namespaces {
+				if nvmeNamespaceRegexp.MatchString(namespace.Name()) {
+					device.Namespaces++
+				}
+			}
+		}
+
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
+func isAddInCard(class string) bool {
+	for _, prefix := range pciClassPrefixes {
+		if strings.HasPrefix(class, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readSysfs(path, name string) string {
+	contents, err := ioutil.ReadFile(filepath.Join(path, name))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(contents))
+}
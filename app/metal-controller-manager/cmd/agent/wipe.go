@@ -0,0 +1,140 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+// Wipe scopes, see metalv1alpha1.WipeScope.
+const (
+	wipeScopeSystem = "System"
+	wipeScopeVerify = "Verify"
+)
+
+// systemPartitions are the Talos partitions re-created by the installer in the Reinstall wipe mode.
+var systemPartitions = map[string]struct{}{
+	"STATE":     {},
+	"EPHEMERAL": {},
+}
+
+// wipeDisk wipes the disk in the wipe scope, it returns the attestations of the wiped disk (or its partitions).
+func wipeDisk(path, scope string, insecure bool, verify []*api.DiskWipe) ([]*api.DiskWipe, error) {
+	bd, err := blockdevice.Open(path)
+	if err != nil {
+		log.Printf("Skipping %s: %s", path, err)
+
+		return nil, nil
+	}
+
+	defer bd.Close() //nolint: errcheck
+
+	switch scope {
+	case wipeScopeSystem:
+		return wipeSystemPartitions(bd, path, insecure)
+	case wipeScopeVerify:
+		attestation, err := attestWipe(bd, path, "verified")
+		if err == nil && diskUnmodified(attestation, verify) {
+			log.Printf("Verified %s", path)
+
+			return []*api.DiskWipe{attestation}, nil
+		}
+
+		log.Printf("Failed verifying %s, wiping", path)
+	}
+
+	var method string
+
+	if insecure {
+		if err = bd.FastWipe(); err != nil {
+			return nil, fmt.Errorf("failed wiping %q: %w", path, err)
+		}
+
+		method = "fast"
+
+		log.Printf("Fast wiped %s", path)
+	} else {
+		method, err = bd.Wipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed wiping %q: %w", path, err)
+		}
+
+		log.Printf("Wiped %s with %s", path, method)
+	}
+
+	attestation, err := attestWipe(bd, path, method)
+	if err != nil {
+		// the disk is wiped, the attestation records that the wipe couldn't be verified
+		log.Printf("Failed attesting wipe of %s: %s", path, err)
+
+		attestation = failedAttestation(path, method)
+	}
+
+	return []*api.DiskWipe{attestation}, nil
+}
+
+// wipeSystemPartitions wipes the Talos system partitions of the disk, disks without the partition table are skipped.
+func wipeSystemPartitions(bd *blockdevice.BlockDevice, path string, insecure bool) ([]*api.DiskWipe, error) {
+	table, err := bd.PartitionTable()
+	if err != nil {
+		log.Printf("Skipping %s: %s", path, err)
+
+		return nil, nil
+	}
+
+	blockSize := uint64(table.Header().LogicalBlockSize)
+
+	var wiped []*api.DiskWipe
+
+	for _, partition := range table.Partitions().Items() {
+		if _, ok := systemPartitions[partition.Name]; !ok {
+			continue
+		}
+
+		start, length := partition.FirstLBA*blockSize, partition.Length()*blockSize
+
+		if insecure && length > blockdevice.FastWipeRange {
+			// same as the fast wipe of the whole disk, the filesystem superblocks are cleared
+			length = blockdevice.FastWipeRange
+		}
+
+		method, err := bd.WipeRange(start, length)
+		if err != nil {
+			return nil, fmt.Errorf("failed wiping partition %s of %q: %w", partition.Name, path, err)
+		}
+
+		log.Printf("Wiped partition %s of %s with %s", partition.Name, path, method)
+
+		attestation, err := attestRange(bd, path, partition.Name, method, start, length)
+		if err != nil {
+			log.Printf("Failed attesting wipe of partition %s of %s: %s", partition.Name, path, err)
+
+			attestation = failedAttestation(path, method)
+			attestation.Partition = partition.Name
+		}
+
+		wiped = append(wiped, attestation)
+	}
+
+	return wiped, nil
+}
+
+// diskUnmodified checks that the samples of the disk match the last wipe attestation of the disk.
+func diskUnmodified(attestation *api.DiskWipe, verify []*api.DiskWipe) bool {
+	for _, disk := range verify {
+		if disk.GetName() != attestation.GetName() || disk.GetSerial() != attestation.GetSerial() {
+			continue
+		}
+
+		return disk.GetSampleHash() != "" && disk.GetSampleHash() == attestation.GetSampleHash()
+	}
+
+	return false
+}
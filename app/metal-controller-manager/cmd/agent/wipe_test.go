@@ -0,0 +1,53 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package main
+
+import (
+	"testing"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+)
+
+func Test_diskUnmodified(t *testing.T) {
+	verify := []*api.DiskWipe{
+		{Name: "sda", Serial: "S4EVNX0N", SampleHash: "00ff"},
+		{Name: "sdb", Serial: "S4EVNX0M"},
+	}
+
+	for _, tt := range []struct {
+		name        string
+		attestation *api.DiskWipe
+		expected    bool
+	}{
+		{
+			name:        "unmodified",
+			attestation: &api.DiskWipe{Name: "sda", Serial: "S4EVNX0N", SampleHash: "00ff"},
+			expected:    true,
+		},
+		{
+			name:        "modified",
+			attestation: &api.DiskWipe{Name: "sda", Serial: "S4EVNX0N", SampleHash: "ff00"},
+		},
+		{
+			name:        "replaced disk",
+			attestation: &api.DiskWipe{Name: "sda", Serial: "S4EVNX0X", SampleHash: "00ff"},
+		},
+		{
+			name:        "no sample hash",
+			attestation: &api.DiskWipe{Name: "sdb", Serial: "S4EVNX0M"},
+		},
+		{
+			name:        "new disk",
+			attestation: &api.DiskWipe{Name: "sdc", SampleHash: "00ff"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if unmodified := diskUnmodified(tt.attestation, verify); unmodified != tt.expected {
+				t.Errorf("diskUnmodified() = %v, want %v", unmodified, tt.expected)
+			}
+		})
+	}
+}
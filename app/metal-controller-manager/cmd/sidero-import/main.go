@@ -0,0 +1,89 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Command sidero-import converts the inventories of other provisioners into Server resources.
+//
+// Servers are written to stdout as multi-document YAML, ready to be reviewed and applied with `kubectl apply -f -`.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/importer"
+)
+
+func main() {
+	var (
+		format string
+		accept bool
+	)
+
+	flag.StringVar(&format, "format", "", fmt.Sprintf("Format of the inventory, one of %v.", importer.Formats))
+	flag.BoolVar(&accept, "accept", false, "Mark the imported servers as accepted.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s --format <format> [--accept] <file>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if format == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(importer.Format(format), flag.Args(), importer.Options{Accept: accept}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(format importer.Format, paths []string, opts importer.Options) error {
+	documents := make([][]byte, 0, len(paths))
+
+	for _, path := range paths {
+		var (
+			data []byte
+			err  error
+		)
+
+		if path == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(path)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		documents = append(documents, data)
+	}
+
+	servers, warnings, err := importer.Import(format, documents, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range warnings {
+		log.Print(warning)
+	}
+
+	for i := range servers {
+		data, err := yaml.Marshal(&servers[i])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("---\n%s", data)
+	}
+
+	log.Printf("imported %d servers", len(servers))
+
+	return nil
+}
@@ -0,0 +1,230 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	multierror "github.com/hashicorp/go-multierror"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
+)
+
+// AssetCacheReconciler uploads the assets of the ready Environments to the asset caches.
+type AssetCacheReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=assetcaches,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=assetcaches/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=environments,verbs=get;list;watch
+
+func (r *AssetCacheReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("assetcache", req.NamespacedName)
+
+	var cache metalv1alpha1.AssetCache
+
+	if err = r.Get(ctx, req.NamespacedName, &cache); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	patchHelper, err := patch.NewHelper(&cache, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		cache.Status.Error = ""
+
+		if err != nil {
+			cache.Status.Error = err.Error()
+		}
+
+		if e := patchHelper.Patch(ctx, &cache); e != nil {
+			log.Error(e, "failed to patch assetcache")
+
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
+	var envs metalv1alpha1.EnvironmentList
+
+	if err = r.List(ctx, &envs); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	sort.Slice(envs.Items, func(i, j int) bool { return envs.Items[i].Name < envs.Items[j].Name })
+
+	var (
+		cached []metalv1alpha1.CachedEnvironment
+		result *multierror.Error
+	)
+
+	for i := range envs.Items {
+		env := &envs.Items[i]
+
+		previous := cachedEnvironment(&cache, env.Name)
+
+		switch {
+		case cache.Caches(env):
+		case !environmentReady(env):
+			// assets are not downloaded yet, the previous version (if any) is still in the cache
+		default:
+			log.Info("uploading environment", "environment", env.Name)
+
+			if err = uploadEnvironment(ctx, cache.GetUploadURL(), env.Name); err != nil {
+				result = multierror.Append(result, fmt.Errorf("error uploading environment %q: %w", env.Name, err))
+
+				// the previous version might be partially overwritten
+				continue
+			}
+
+			previous = &metalv1alpha1.CachedEnvironment{
+				Name:   env.Name,
+				Kernel: env.Spec.Kernel.URL,
+				Initrd: env.Spec.Initrd.URL,
+			}
+		}
+
+		if previous != nil {
+			cached = append(cached, *previous)
+		}
+	}
+
+	cache.Status.Environments = cached
+
+	if err = result.ErrorOrNil(); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func cachedEnvironment(cache *metalv1alpha1.AssetCache, name string) *metalv1alpha1.CachedEnvironment {
+	for i := range cache.Status.Environments {
+		if cache.Status.Environments[i].Name == name {
+			return &cache.Status.Environments[i]
+		}
+	}
+
+	return nil
+}
+
+// environmentReady checks whether the current assets of the environment are downloaded.
+func environmentReady(env *metalv1alpha1.Environment) bool {
+	ready := map[string]bool{}
+
+	for _, condition := range env.Status.Conditions {
+		if condition.Type == "Ready" && condition.Status == "True" {
+			ready[condition.URL] = true
+		}
+	}
+
+	return ready[env.Spec.Kernel.URL] && ready[env.Spec.Initrd.URL]
+}
+
+// uploadEnvironment uploads the assets of the environment to the cache with HTTP PUT.
+func uploadEnvironment(ctx context.Context, baseURL, name string) error {
+	for _, asset := range []string{constants.KernelAsset, constants.InitrdAsset} {
+		url := strings.TrimSuffix(baseURL, "/") + "/" + name + "/" + asset
+
+		if err := upload(ctx, filepath.Join(constants.DataDirectory, "env", name, asset), url); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func upload(ctx context.Context, file, url string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	requestContext, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(requestContext, http.MethodPut, url, f)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = info.Size()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close() //nolint: errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to upload %q: %d", url, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (r *AssetCacheReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	// every cache holds every environment, so all the caches are reconciled on environment changes
+	mapRequests := handler.ToRequestsFunc(
+		func(a handler.MapObject) []reconcile.Request {
+			var caches metalv1alpha1.AssetCacheList
+
+			if err := r.List(context.Background(), &caches); err != nil {
+				return nil
+			}
+
+			reqList := make([]reconcile.Request, 0, len(caches.Items))
+
+			for _, cache := range caches.Items {
+				reqList = append(reqList, reconcile.Request{NamespacedName: types.NamespacedName{Name: cache.Name}})
+			}
+
+			return reqList
+		})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&metalv1alpha1.AssetCache{}).
+		Watches(
+			&source.Kind{Type: &metalv1alpha1.Environment{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: mapRequests,
+			},
+		).
+		Complete(r)
+}
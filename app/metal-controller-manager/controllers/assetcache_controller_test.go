@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestAssetCacheReconcilerStatusOrder(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	env := func(name string) *metalv1alpha1.Environment {
+		return &metalv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: metalv1alpha1.EnvironmentSpec{
+				Kernel: metalv1alpha1.Kernel{Asset: metalv1alpha1.Asset{URL: "http://example.com/" + name + "/vmlinuz"}},
+				Initrd: metalv1alpha1.Initrd{Asset: metalv1alpha1.Asset{URL: "http://example.com/" + name + "/initramfs.xz"}},
+			},
+		}
+	}
+
+	cached := func(name string) metalv1alpha1.CachedEnvironment {
+		return metalv1alpha1.CachedEnvironment{
+			Name:   name,
+			Kernel: "http://example.com/" + name + "/vmlinuz",
+			Initrd: "http://example.com/" + name + "/initramfs.xz",
+		}
+	}
+
+	cache := &metalv1alpha1.AssetCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "cache",
+			ResourceVersion: "1",
+		},
+		Spec: metalv1alpha1.AssetCacheSpec{
+			URL: "http://cache.example.com",
+		},
+		Status: metalv1alpha1.AssetCacheStatus{
+			// written in a different order by an older version, "deleted" has no environment anymore
+			Environments: []metalv1alpha1.CachedEnvironment{cached("deleted"), cached("b"), cached("a")},
+		},
+	}
+
+	r := &AssetCacheReconciler{
+		// "c" is neither cached nor ready, so it is not uploaded
+		Client: fake.NewFakeClientWithScheme(scheme, cache, env("c"), env("b"), env("a")),
+		Log:    log.NullLogger{},
+		Scheme: scheme,
+	}
+
+	expected := []metalv1alpha1.CachedEnvironment{cached("a"), cached("b")}
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "cache"}}); err != nil {
+			t.Fatal(err)
+		}
+
+		var updated metalv1alpha1.AssetCache
+
+		if err := r.Get(context.Background(), types.NamespacedName{Name: "cache"}, &updated); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(updated.Status.Environments, expected) {
+			t.Errorf("environments = %v, want %v", updated.Status.Environments, expected)
+		}
+
+		if i > 0 && updated.ResourceVersion != cache.ResourceVersion {
+			t.Errorf("unchanged status is written again: resource version %q, want %q", updated.ResourceVersion, cache.ResourceVersion)
+		}
+
+		cache = &updated
+	}
+}
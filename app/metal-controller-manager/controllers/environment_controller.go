@@ -6,12 +6,16 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha512"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,6 +31,9 @@ import (
 	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
 )
 
+// errChecksumMismatch is returned when the downloaded asset doesn't match the checksum.
+var errChecksumMismatch = errors.New("checksum mismatch")
+
 // EnvironmentReconciler reconciles a Environment object.
 type EnvironmentReconciler struct {
 	client.Client
@@ -71,14 +78,7 @@ func (r *EnvironmentReconciler) reconcile(req ctrl.Request) (ctrl.Result, error)
 		}
 	}
 
-	var (
-		conditions = []metalv1alpha1.AssetCondition{}
-		wg         sync.WaitGroup
-		mu         sync.Mutex
-		result     *multierror.Error
-	)
-
-	for _, assetTask := range []struct {
+	assetTasks := []struct {
 		BaseName string
 		Asset    metalv1alpha1.Asset
 	}{
@@ -90,12 +90,22 @@ func (r *EnvironmentReconciler) reconcile(req ctrl.Request) (ctrl.Result, error)
 			BaseName: constants.InitrdAsset,
 			Asset:    env.Spec.Initrd.Asset,
 		},
-	} {
-		assetTask := assetTask
+	}
+
+	var (
+		// conditions are kept in the order of the assets, as they are updated concurrently
+		conditions = make([]metalv1alpha1.AssetCondition, len(assetTasks))
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		result     *multierror.Error
+	)
+
+	for i, assetTask := range assetTasks {
+		i, assetTask := i, assetTask
 
 		file := filepath.Join(envs, assetTask.BaseName)
 
-		setReady := func(ready bool) {
+		setReady := func(ready bool, reason string) {
 			status := "False"
 			if ready {
 				status = "True"
@@ -105,10 +115,11 @@ func (r *EnvironmentReconciler) reconcile(req ctrl.Request) (ctrl.Result, error)
 				Asset:  assetTask.Asset,
 				Status: status,
 				Type:   "Ready",
+				Reason: reason,
 			}
 
 			mu.Lock()
-			conditions = append(conditions, condition)
+			conditions[i] = condition
 			mu.Unlock()
 		}
 
@@ -118,13 +129,23 @@ func (r *EnvironmentReconciler) reconcile(req ctrl.Request) (ctrl.Result, error)
 			go func() {
 				defer wg.Done()
 
-				if err := save(ctx, assetTask.Asset, file); err != nil {
-					setReady(false)
+				if err := save(ctx, l, assetTask.Asset, file); err != nil {
+					reason := metalv1alpha1.AssetDownloadFailedReason
+
+					if errors.Is(err, errChecksumMismatch) {
+						reason = metalv1alpha1.AssetChecksumMismatchReason
+					}
 
+					setReady(false, reason)
+
+					mu.Lock()
 					result = multierror.Append(result, fmt.Errorf("error saving %q: %w", assetTask.Asset.URL, err))
+					mu.Unlock()
+
+					return
 				}
 
-				setReady(true)
+				setReady(true, "")
 				l.Info("saved asset", "url", assetTask.Asset.URL)
 			}()
 		}
@@ -144,14 +165,14 @@ func (r *EnvironmentReconciler) reconcile(req ctrl.Request) (ctrl.Result, error)
 		ready := false
 
 		for _, condition := range env.Status.Conditions {
-			if assetTask.Asset.URL == condition.URL {
+			if assetTask.Asset.URL == condition.URL && condition.Status == "True" {
 				ready = true
 			}
 		}
 
 		if ready {
 			l.Info("update not required", "file", file)
-			setReady(true)
+			setReady(true, "")
 
 			continue
 		}
@@ -161,33 +182,90 @@ func (r *EnvironmentReconciler) reconcile(req ctrl.Request) (ctrl.Result, error)
 		// At this point the file exists, but the URL for the file has changed. We
 		// need to update the file using the new URL.
 
-		wg.Add(1)
 		l.Info("updating asset", "url", assetTask.Asset.URL)
 		saveAsset(file)
 	}
 
 	wg.Wait()
 
-	if result.ErrorOrNil() != nil {
-		return ctrl.Result{}, result.ErrorOrNil()
+	// failed assets are recorded as well, so that the failure reason is visible in the status
+	if !reflect.DeepEqual(env.Status.Conditions, conditions) {
+		env.Status.Conditions = conditions
+
+		if err := r.Status().Update(ctx, &env); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
-	env.Status.Conditions = conditions
+	return ctrl.Result{}, result.ErrorOrNil()
+}
+
+// save downloads the asset from the first healthy source, failing over to the next source on failure.
+func save(ctx context.Context, l logr.Logger, asset metalv1alpha1.Asset, file string) error {
+	if asset.URL == "" {
+		return errors.New("missing URL")
+	}
+
+	sources := append([]string{asset.URL}, asset.Mirrors...)
+
+	var result *multierror.Error
+
+	for _, url := range sources {
+		if err := probe(ctx, url); err != nil {
+			l.Info("skipping unhealthy asset source", "url", url, "error", err.Error())
+
+			result = multierror.Append(result, fmt.Errorf("%q is unhealthy: %w", url, err))
+
+			continue
+		}
+
+		if err := download(ctx, url, asset.SHA512, file); err != nil {
+			l.Info("failed to download asset, trying next source", "url", url, "error", err.Error())
+
+			result = multierror.Append(result, fmt.Errorf("error downloading %q: %w", url, err))
+
+			continue
+		}
+
+		if url != asset.URL {
+			l.Info("saved asset from mirror", "url", asset.URL, "mirror", url)
+		}
 
-	if err := r.Status().Update(ctx, &env); err != nil {
-		return ctrl.Result{}, err
+		return nil
 	}
 
-	return ctrl.Result{}, nil
+	return result.ErrorOrNil()
 }
 
-func save(ctx context.Context, asset metalv1alpha1.Asset, file string) error {
-	url := asset.URL
+// probe checks whether the asset is available at the URL.
+//
+// Mirrors lagging behind the release return 404 for the new assets, so they are skipped before the download.
+func probe(ctx context.Context, url string) error {
+	requestContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-	if url == "" {
-		return errors.New("missing URL")
+	req, err := http.NewRequestWithContext(requestContext, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close() //nolint: errcheck
+
+	// some servers don't implement HEAD, so the download is attempted anyways
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
+	return nil
+}
+
+// download saves the asset to the temporary file first, so that failed download doesn't overwrite the existing asset.
+func download(ctx context.Context, url, checksum, file string) error {
 	requestContext, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
@@ -205,22 +283,34 @@ func save(ctx context.Context, asset metalv1alpha1.Asset, file string) error {
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
-		w, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY, 0o666)
-		if err != nil {
-			return err
-		}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to download asset: %d", resp.StatusCode)
+	}
 
-		defer w.Close()
+	tmp := file + ".part"
 
-		r := resp.Body
+	w, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o666)
+	if err != nil {
+		return err
+	}
 
-		if _, err := io.Copy(w, r); err != nil {
-			return err
-		}
-	} else {
-		return fmt.Errorf("failed to download asset: %d", resp.StatusCode)
+	defer os.Remove(tmp) //nolint: errcheck
+
+	hash := sha512.New()
+
+	_, err = io.Copy(io.MultiWriter(w, hash), resp.Body)
+
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
 	}
 
-	return nil
+	if err != nil {
+		return err
+	}
+
+	if checksum != "" && !strings.EqualFold(hex.EncodeToString(hash.Sum(nil)), checksum) {
+		return fmt.Errorf("%w: expected %s", errChecksumMismatch, checksum)
+	}
+
+	return os.Rename(tmp, file)
 }
@@ -0,0 +1,120 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// operatorInitiated checks whether the server update was made by the operator.
+//
+// Operator changes the spec (e.g. accepts the server or changes power management), acknowledges manual
+// power actions, requests the server to be powered off, approves the destructive actions or deletes the server; everything else is done by the controllers.
+func operatorInitiated(e event.UpdateEvent) bool {
+	if e.MetaOld == nil || e.MetaNew == nil {
+		return false
+	}
+
+	if e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() {
+		return true
+	}
+
+	if e.MetaOld.GetDeletionTimestamp().IsZero() && !e.MetaNew.GetDeletionTimestamp().IsZero() {
+		return true
+	}
+
+	_, acknowledgedOld := e.MetaOld.GetAnnotations()[metalv1alpha1.PowerActionAcknowledgedAnnotation]
+	_, acknowledgedNew := e.MetaNew.GetAnnotations()[metalv1alpha1.PowerActionAcknowledgedAnnotation]
+
+	if !acknowledgedOld && acknowledgedNew {
+		return true
+	}
+
+	for _, annotation := range []string{metalv1alpha1.PowerOffApprovedByAnnotation, metalv1alpha1.WipeApprovedByAnnotation} {
+		_, approvedOld := e.MetaOld.GetAnnotations()[annotation]
+		_, approvedNew := e.MetaNew.GetAnnotations()[annotation]
+
+		if !approvedOld && approvedNew {
+			return true
+		}
+	}
+
+	_, powerOffOld := e.MetaOld.GetAnnotations()[metalv1alpha1.PowerOffAnnotation]
+	_, powerOffNew := e.MetaNew.GetAnnotations()[metalv1alpha1.PowerOffAnnotation]
+
+	return powerOffOld != powerOffNew
+}
+
+// priorityPredicates routes operator-initiated updates to the priority queue.
+//
+// Each event is accepted by exactly one of the two returned predicates: the first one
+// is used by the priority controller, the second one by the regular controller.
+func priorityPredicates() (priority, background predicate.Predicate) {
+	priority = predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc:  operatorInitiated,
+	}
+
+	background = predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !operatorInitiated(e)
+		},
+	}
+
+	return priority, background
+}
+
+// keyLock serializes reconciles of the same object coming from different queues.
+type keyLock struct {
+	mu    sync.Mutex
+	locks map[types.NamespacedName]*keyLockEntry
+}
+
+type keyLockEntry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// Lock the key, returned function unlocks it.
+func (l *keyLock) Lock(key types.NamespacedName) func() {
+	l.mu.Lock()
+
+	if l.locks == nil {
+		l.locks = make(map[types.NamespacedName]*keyLockEntry)
+	}
+
+	entry := l.locks[key]
+	if entry == nil {
+		entry = &keyLockEntry{}
+		l.locks[key] = entry
+	}
+
+	entry.refs++
+
+	l.mu.Unlock()
+
+	entry.mu.Lock()
+
+	return func() {
+		entry.mu.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		entry.refs--
+
+		if entry.refs == 0 {
+			delete(l.locks, key)
+		}
+	}
+}
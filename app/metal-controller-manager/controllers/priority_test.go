@@ -0,0 +1,150 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func Test_priorityPredicates(t *testing.T) {
+	base := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "server",
+			Generation:  1,
+			Annotations: map[string]string{metalv1alpha1.PowerOffAnnotation: "admin"},
+		},
+	}
+
+	now := metav1.Now()
+
+	priority, background := priorityPredicates()
+
+	for _, tt := range []struct {
+		name     string
+		update   func(s *metalv1alpha1.Server)
+		expected bool
+	}{
+		{
+			name:   "status update",
+			update: func(s *metalv1alpha1.Server) { s.Status.Power = "on" },
+		},
+		{
+			name:   "label update",
+			update: func(s *metalv1alpha1.Server) { s.Labels = map[string]string{"rack": "a"} },
+		},
+		{
+			name:     "spec update",
+			update:   func(s *metalv1alpha1.Server) { s.Generation = 2 },
+			expected: true,
+		},
+		{
+			name:     "deletion",
+			update:   func(s *metalv1alpha1.Server) { s.DeletionTimestamp = &now },
+			expected: true,
+		},
+		{
+			name:     "power action acknowledged",
+			update:   func(s *metalv1alpha1.Server) { s.Annotations[metalv1alpha1.PowerActionAcknowledgedAnnotation] = "" },
+			expected: true,
+		},
+		{
+			name:     "power off approved",
+			update:   func(s *metalv1alpha1.Server) { s.Annotations[metalv1alpha1.PowerOffApprovedByAnnotation] = "ops" },
+			expected: true,
+		},
+		{
+			name:     "wipe approved",
+			update:   func(s *metalv1alpha1.Server) { s.Annotations[metalv1alpha1.WipeApprovedByAnnotation] = "ops" },
+			expected: true,
+		},
+		{
+			name:     "power off cancelled",
+			update:   func(s *metalv1alpha1.Server) { delete(s.Annotations, metalv1alpha1.PowerOffAnnotation) },
+			expected: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := base.DeepCopy()
+			tt.update(updated)
+
+			e := event.UpdateEvent{
+				MetaOld:   base,
+				ObjectOld: base,
+				MetaNew:   updated,
+				ObjectNew: updated,
+			}
+
+			if got := priority.Update(e); got != tt.expected {
+				t.Errorf("priority = %v, want %v", got, tt.expected)
+			}
+
+			if background.Update(e) == priority.Update(e) {
+				t.Error("update is not routed to exactly one of the queues")
+			}
+		})
+	}
+
+	// creations, deletions and resyncs are never prioritized
+	if priority.Create(event.CreateEvent{Meta: base, Object: base}) || !background.Create(event.CreateEvent{Meta: base, Object: base}) {
+		t.Error("create event is routed to the priority queue")
+	}
+
+	if priority.Generic(event.GenericEvent{Meta: base, Object: base}) || !background.Generic(event.GenericEvent{Meta: base, Object: base}) {
+		t.Error("generic event is routed to the priority queue")
+	}
+}
+
+func Test_keyLock(t *testing.T) {
+	var (
+		l       keyLock
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		active  = map[string]int{}
+		overlap bool
+	)
+
+	for i := 0; i < 20; i++ {
+		name := []string{"a", "b"}[i%2]
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			unlock := l.Lock(types.NamespacedName{Name: name})
+			defer unlock()
+
+			mu.Lock()
+			active[name]++
+			overlap = overlap || active[name] > 1
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			active[name]--
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	if overlap {
+		t.Error("same key is locked concurrently")
+	}
+
+	if len(l.locks) != 0 {
+		t.Errorf("%d locks are left after unlocking", len(l.locks))
+	}
+}
@@ -0,0 +1,77 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/metal"
+)
+
+// performRequestedAction performs the one-shot action requested on the server and clears the request.
+//
+// Actions are not retried: the outcome is recorded in the server status and reported with the event.
+// Returned value is the power state of the server after the action.
+func (r *ServerReconciler) performRequestedAction(s *metalv1alpha1.Server, serverRef *corev1.ObjectReference, mgmtClient metal.ManagementClient,
+	poweredOn bool, powerErr error) bool {
+	action := s.Spec.RequestedAction
+	s.Spec.RequestedAction = ""
+
+	record := &metalv1alpha1.ServerActionRecord{
+		Action: action,
+		Result: metalv1alpha1.ServerActionSucceeded,
+		Time:   metav1.Now(),
+	}
+
+	if err := performAction(s, action, mgmtClient, poweredOn, powerErr); err != nil {
+		record.Result = metalv1alpha1.ServerActionFailed
+		record.Message = err.Error()
+
+		r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Action", fmt.Sprintf("Action %s failed: %s.", action, err))
+	} else {
+		poweredOn = true
+		s.Status.Power = "on"
+
+		r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Action", fmt.Sprintf("Action %s performed.", action))
+	}
+
+	s.Status.LastAction = record
+
+	return poweredOn
+}
+
+func performAction(s *metalv1alpha1.Server, action metalv1alpha1.ServerAction, mgmtClient metal.ManagementClient, poweredOn bool, powerErr error) error {
+	if s.Spec.ManualPowerManagement || mgmtClient.IsFake() {
+		return fmt.Errorf("server has no power management")
+	}
+
+	if conditions.IsTrue(s, metalv1alpha1.ConditionFrozen) {
+		return fmt.Errorf("server is frozen")
+	}
+
+	if _, ok := s.Annotations[metalv1alpha1.PowerOffAnnotation]; ok && s.Status.InUse {
+		return fmt.Errorf("server is kept powered off with the %q annotation", metalv1alpha1.PowerOffAnnotation)
+	}
+
+	if powerErr != nil {
+		return fmt.Errorf("failed to determine power status: %w", powerErr)
+	}
+
+	switch action {
+	case metalv1alpha1.ServerActionPowerCycle:
+		if !poweredOn {
+			return mgmtClient.PowerOn()
+		}
+
+		return mgmtClient.PowerCycle()
+	default:
+		return fmt.Errorf("unsupported action %q", action)
+	}
+}
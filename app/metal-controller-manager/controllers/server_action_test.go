@@ -0,0 +1,168 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// recordingMgmtClient records the power actions.
+type recordingMgmtClient struct {
+	fake  bool
+	err   error
+	calls []string
+}
+
+func (c *recordingMgmtClient) record(call string) error {
+	c.calls = append(c.calls, call)
+
+	return c.err
+}
+
+func (c *recordingMgmtClient) PowerOn() error             { return c.record("PowerOn") }
+func (c *recordingMgmtClient) PowerOff() error            { return c.record("PowerOff") }
+func (c *recordingMgmtClient) Shutdown() error            { return c.record("Shutdown") }
+func (c *recordingMgmtClient) PowerCycle() error          { return c.record("PowerCycle") }
+func (c *recordingMgmtClient) IsPoweredOn() (bool, error) { return true, nil }
+func (c *recordingMgmtClient) SetPXE() error              { return c.record("SetPXE") }
+func (c *recordingMgmtClient) IsFake() bool               { return c.fake }
+
+func TestServerReconcilerRequestedAction(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		action          metalv1alpha1.ServerAction
+		update          func(s *metalv1alpha1.Server)
+		client          *recordingMgmtClient
+		poweredOn       bool
+		powerErr        error
+		expectedCalls   []string
+		expectedMessage string
+	}{
+		{
+			name:          "power cycle",
+			action:        metalv1alpha1.ServerActionPowerCycle,
+			client:        &recordingMgmtClient{},
+			poweredOn:     true,
+			expectedCalls: []string{"PowerCycle"},
+		},
+		{
+			name:          "powered off server is powered on",
+			action:        metalv1alpha1.ServerActionPowerCycle,
+			client:        &recordingMgmtClient{},
+			expectedCalls: []string{"PowerOn"},
+		},
+		{
+			name:            "power management failure",
+			action:          metalv1alpha1.ServerActionPowerCycle,
+			client:          &recordingMgmtClient{err: errors.New("BMC is unreachable")},
+			poweredOn:       true,
+			expectedCalls:   []string{"PowerCycle"},
+			expectedMessage: "BMC is unreachable",
+		},
+		{
+			name:            "unknown power state",
+			action:          metalv1alpha1.ServerActionPowerCycle,
+			client:          &recordingMgmtClient{},
+			powerErr:        errors.New("timeout"),
+			expectedMessage: "failed to determine power status: timeout",
+		},
+		{
+			name:            "manual power management",
+			action:          metalv1alpha1.ServerActionPowerCycle,
+			update:          func(s *metalv1alpha1.Server) { s.Spec.ManualPowerManagement = true },
+			client:          &recordingMgmtClient{},
+			expectedMessage: "server has no power management",
+		},
+		{
+			name:            "no power management",
+			action:          metalv1alpha1.ServerActionPowerCycle,
+			client:          &recordingMgmtClient{fake: true},
+			expectedMessage: "server has no power management",
+		},
+		{
+			name:   "frozen",
+			action: metalv1alpha1.ServerActionPowerCycle,
+			update: func(s *metalv1alpha1.Server) {
+				s.Status.Conditions = capiv1.Conditions{{Type: metalv1alpha1.ConditionFrozen, Status: corev1.ConditionTrue}}
+			},
+			client:          &recordingMgmtClient{},
+			expectedMessage: "server is frozen",
+		},
+		{
+			name:   "kept powered off",
+			action: metalv1alpha1.ServerActionPowerCycle,
+			update: func(s *metalv1alpha1.Server) {
+				s.Annotations = map[string]string{metalv1alpha1.PowerOffAnnotation: "admin"}
+				s.Status.InUse = true
+			},
+			client:          &recordingMgmtClient{},
+			expectedMessage: `server is kept powered off with the "metal.sidero.dev/power-off" annotation`,
+		},
+		{
+			name:            "unsupported action",
+			action:          "Reset",
+			client:          &recordingMgmtClient{},
+			poweredOn:       true,
+			expectedMessage: `unsupported action "Reset"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &metalv1alpha1.Server{
+				ObjectMeta: metav1.ObjectMeta{Name: "server"},
+				Spec:       metalv1alpha1.ServerSpec{RequestedAction: tt.action},
+			}
+
+			if tt.update != nil {
+				tt.update(s)
+			}
+
+			r := &ServerReconciler{
+				Log:      log.NullLogger{},
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			poweredOn := r.performRequestedAction(s, &corev1.ObjectReference{Kind: "Server", Name: "server"}, tt.client, tt.poweredOn, tt.powerErr)
+
+			if s.Spec.RequestedAction != "" {
+				t.Error("requested action is not cleared")
+			}
+
+			if !reflect.DeepEqual(tt.client.calls, tt.expectedCalls) {
+				t.Errorf("power calls %v, want %v", tt.client.calls, tt.expectedCalls)
+			}
+
+			if s.Status.LastAction == nil || s.Status.LastAction.Action != tt.action {
+				t.Fatalf("last action %+v", s.Status.LastAction)
+			}
+
+			if tt.expectedMessage == "" {
+				if s.Status.LastAction.Result != metalv1alpha1.ServerActionSucceeded || !poweredOn || s.Status.Power != "on" {
+					t.Errorf("last action %+v, powered on = %v", s.Status.LastAction, poweredOn)
+				}
+
+				return
+			}
+
+			if s.Status.LastAction.Result != metalv1alpha1.ServerActionFailed || s.Status.LastAction.Message != tt.expectedMessage {
+				t.Errorf("last action %+v, want failure %q", s.Status.LastAction, tt.expectedMessage)
+			}
+
+			if poweredOn != tt.poweredOn {
+				t.Errorf("powered on = %v, want %v", poweredOn, tt.poweredOn)
+			}
+		})
+	}
+}
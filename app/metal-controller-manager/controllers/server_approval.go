@@ -0,0 +1,69 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// expireApprovals removes the approvals of the destructive actions which are not pending anymore, so that each approval is used once.
+func expireApprovals(s *metalv1alpha1.Server) {
+	_, powerOff := s.Annotations[metalv1alpha1.PowerOffAnnotation]
+
+	if !powerOff {
+		delete(s.Annotations, metalv1alpha1.PowerOffApprovedByAnnotation)
+
+		if conditions.GetReason(s, metalv1alpha1.ConditionAwaitingApproval) == metalv1alpha1.PowerOffApprovalRequiredReason {
+			conditions.Delete(s, metalv1alpha1.ConditionAwaitingApproval)
+		}
+	}
+
+	if s.Status.IsClean {
+		delete(s.Annotations, metalv1alpha1.WipeApprovedByAnnotation)
+	}
+
+	if s.Status.IsClean || s.Status.InUse {
+		if conditions.GetReason(s, metalv1alpha1.ConditionAwaitingApproval) == metalv1alpha1.WipeApprovalRequiredReason {
+			conditions.Delete(s, metalv1alpha1.ConditionAwaitingApproval)
+		}
+	}
+}
+
+// awaitingApproval checks whether the destructive action on the server should wait for the approval.
+//
+// Approval is requested with the condition and the event once per action.
+func (r *ServerReconciler) awaitingApproval(s *metalv1alpha1.Server, serverRef *corev1.ObjectReference, approved bool, reason, action, annotation string) bool {
+	if !r.RequireApproval || approved {
+		if conditions.GetReason(s, metalv1alpha1.ConditionAwaitingApproval) == reason {
+			conditions.Delete(s, metalv1alpha1.ConditionAwaitingApproval)
+		}
+
+		return false
+	}
+
+	if conditions.GetReason(s, metalv1alpha1.ConditionAwaitingApproval) == reason {
+		// already requested
+		return true
+	}
+
+	conditions.Set(s, &clusterv1.Condition{
+		Type:     metalv1alpha1.ConditionAwaitingApproval,
+		Status:   corev1.ConditionTrue,
+		Severity: clusterv1.ConditionSeverityWarning,
+		Reason:   reason,
+		Message:  fmt.Sprintf("Server %s requires approval.", action),
+	})
+
+	r.Recorder.Event(serverRef, corev1.EventTypeWarning, reason,
+		fmt.Sprintf("Server %s requires approval, set annotation %q on the server to approve.", action, annotation))
+
+	return true
+}
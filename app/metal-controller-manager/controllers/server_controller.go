@@ -7,6 +7,8 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -23,15 +25,19 @@ import (
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/remediation"
 	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/chargeback"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/metal"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
 )
@@ -49,6 +55,60 @@ type ServerReconciler struct {
 	Recorder  record.EventRecorder
 
 	RebootTimeout time.Duration
+
+	// CleanVerificationInterval is the maximum age of the last wipe of a clean server.
+	//
+	// Clean servers which were wiped longer than this interval ago are wiped again
+	// to make sure nothing was installed on the server out of band.
+	// Zero value disables the verification.
+	CleanVerificationInterval time.Duration
+
+	// WipeTimeout is the maximum duration of a single wipe attempt, zero value disables the timeout.
+	//
+	// Server can override the timeout with the WipeTimeout field.
+	WipeTimeout time.Duration
+
+	// WipeRetries is the number of times the server is power cycled to retry the timed out wipe
+	// before it gets quarantined.
+	WipeRetries int
+
+	// PowerRetries is the number of consecutive power management failures after which the next
+	// power management path is used, once all the paths fail, manual intervention is requested.
+	// Zero value retries indefinitely.
+	PowerRetries int
+
+	// PowerRetryInterval is the minimum interval between the counted power management failures, doubled with each failure.
+	//
+	// Failures within the interval (e.g. of the reconciles triggered by the server updates) are not counted,
+	// so that the retries are not exhausted by a short BMC outage.
+	PowerRetryInterval time.Duration
+
+	// AllocationHistorySize is the number of allocations kept in the server allocation history.
+	AllocationHistorySize int
+
+	// PriorityMaxConcurrentReconciles is the number of workers processing operator-initiated changes.
+	//
+	// Operator-initiated changes are queued separately from the background reconciles,
+	// so that they are processed without waiting for the regular queue.
+	PriorityMaxConcurrentReconciles int
+
+	// RequireApproval holds the destructive actions (power off of the allocated server, wipe) until they are approved
+	// with the approval annotations.
+	RequireApproval bool
+
+	// Standby leaves the servers mirrored from the active Sidero installation to the active installation.
+	Standby bool
+
+	// InventoryTTL is the maximum age of the hardware inventory before the server is marked as stale, zero value disables the check.
+	InventoryTTL time.Duration
+
+	// InventoryRefresh boots the stale idle servers into the agent to refresh the inventory.
+	InventoryRefresh bool
+
+	// ChargebackArchive keeps the allocation history of the deleted servers for the chargeback report, nil disables the archive.
+	ChargebackArchive *chargeback.Archive
+
+	locks keyLock
 }
 
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;create;update;patch;delete
@@ -57,9 +117,23 @@ type ServerReconciler struct {
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=serverbindings/status,verbs=get
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=metalmachines,verbs=get;list;watch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=metalmachines/status,verbs=get
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=metalclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=metalclusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=bmcproxies,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	// same server might be queued both in the regular and in the priority queue
+	unlock := r.locks.Lock(req.NamespacedName)
+	defer unlock()
+
+	return r.reconcile(req)
+}
+
+func (r *ServerReconciler) reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
 	log := r.Log.WithValues("server", req.NamespacedName)
 
@@ -69,6 +143,11 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	if _, mirrored := s.Labels[metalv1alpha1.MirroredLabel]; mirrored && r.Standby {
+		// power management and wipes of the mirrored servers are performed by the active installation
+		return ctrl.Result{}, nil
+	}
+
 	patchHelper, err := patch.NewHelper(&s, r)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -79,10 +158,27 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
-	mgmtClient, err := metal.NewManagementClient(&s.Spec)
+	if !s.Spec.Accepted || s.Spec.ManualPowerManagement {
+		// re-accepting the server resets the power management retry budget
+		s.Status.PowerFailures = 0
+		s.Status.LastPowerFailure = nil
+		s.Status.PowerPath = 0
+
+		conditions.Delete(&s, metalv1alpha1.ConditionManualInterventionRequired)
+	}
+
+	bmcProxy, err := metal.LoadProxy(ctx, r.APIReader, &s)
+	if err != nil {
+		log.Error(err, "failed to load BMC proxy")
+		r.Recorder.Event(serverRef, corev1.EventTypeWarning, metalv1alpha1.PowerManagementFailedReason, fmt.Sprintf("Failed to load BMC proxy: %s.", err))
+
+		return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, err
+	}
+
+	mgmtClient, err := metal.NewManagementClient(&s.Spec, s.Status.PowerPath, bmcProxy)
 	if err != nil {
 		log.Error(err, "failed to create management client")
-		r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Failed to initialize management client: %s.", err))
+		r.Recorder.Event(serverRef, corev1.EventTypeWarning, metal.FailureReason(err), fmt.Sprintf("Failed to initialize management client: %s.", err))
 
 		return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, err
 	}
@@ -98,11 +194,74 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		s.Status.Power = "on"
 	}
 
+	if s.Spec.ManualPowerManagement {
+		// power state can't be observed without power management
+		s.Status.Power = "unknown"
+	}
+
+	s.Status.BMCHealth = ""
+
+	if reporter, ok := mgmtClient.(metal.HealthReporter); ok && powerErr == nil {
+		health, err := reporter.Health()
+		if err != nil {
+			log.Info("failed to read BMC health", "error", err.Error())
+		}
+
+		s.Status.BMCHealth = health
+	}
+
+	if powerErr == nil || s.Spec.ManualPowerManagement {
+		// power management works again (or it's not used anymore), failed power actions set the condition back below
+		conditions.Delete(&s, metalv1alpha1.ConditionPowerManagementFailed)
+	}
+
+	frozenUntil, frozen := r.checkFreeze(&s, serverRef)
+
+	if s.Spec.RequestedAction != "" {
+		poweredOn = r.performRequestedAction(&s, serverRef, mgmtClient, poweredOn, powerErr)
+
+		// the action is recorded right away, so that it's not performed again
+		if err = patchHelper.Patch(ctx, &s); err != nil {
+			return ctrl.Result{}, errors.WithStack(err)
+		}
+	}
+
+	var inventoryStaleAfter time.Duration
+
 	f := func(ready bool, result ctrl.Result) (ctrl.Result, error) {
 		s.Status.Ready = ready
 
+		if inventoryStaleAfter > 0 && !result.Requeue && (result.RequeueAfter == 0 || result.RequeueAfter > inventoryStaleAfter) {
+			// requeue to mark the server stale once the inventory expires
+			result.RequeueAfter = inventoryStaleAfter
+		}
+
+		if next := freezeRequeue(&s, frozenUntil, frozen); next > 0 && !result.Requeue && (result.RequeueAfter == 0 || result.RequeueAfter > next) {
+			// requeue to update the Frozen condition once the freeze starts or ends
+			result.RequeueAfter = next
+		}
+
+		if !conditions.IsTrue(&s, metalv1alpha1.ConditionPowerManagementFailed) {
+			s.Status.PowerFailures = 0
+			s.Status.LastPowerFailure = nil
+		}
+
 		if err := patchHelper.Patch(ctx, &s, patch.WithOwnedConditions{
-			Conditions: []clusterv1.ConditionType{metalv1alpha1.ConditionPowerCycle, metalv1alpha1.ConditionPXEBooted},
+			Conditions: []clusterv1.ConditionType{
+				metalv1alpha1.ConditionPowerCycle,
+				metalv1alpha1.ConditionPXEBooted,
+				metalv1alpha1.ConditionWiped,
+				metalv1alpha1.ConditionManualPowerAction,
+				metalv1alpha1.ConditionCablingInvalid,
+				metalv1alpha1.ConditionStalled,
+				metalv1alpha1.ConditionDuplicateMAC,
+				metalv1alpha1.ConditionPowerManagementFailed,
+				metalv1alpha1.ConditionManualInterventionRequired,
+				metalv1alpha1.ConditionAwaitingApproval,
+				metalv1alpha1.ConditionStale,
+				metalv1alpha1.ConditionFrozen,
+				metalv1alpha1.ConditionPoweredOff,
+			},
 		}); err != nil {
 			return result, errors.WithStack(err)
 		}
@@ -119,12 +278,39 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		if s.Status.InUse {
 			// transitioning to false
 			r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Allocation", "Server marked as unallocated.")
+
+			if err = r.recordRelease(ctx, &s); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			// server is still running the workload, it's power cycled into the agent to be wiped (or verified), and powered off
+			switch n := len(s.Status.AllocationHistory); {
+			case n > 0 && s.Status.AllocationHistory[n-1].Diskless:
+				// disks of the server were not supposed to be used by the diskless machine
+				s.Status.PendingWipeScope = metalv1alpha1.WipeScopeVerify
+
+				r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Wipe", "Server was provisioned diskless, only the modified disks are going to be wiped.")
+			case s.Spec.WipeMode == metalv1alpha1.WipeModeReinstall:
+				// Talos installer re-creates the system partitions on the next allocation, the rest of the disks is preserved
+				s.Status.PendingWipeScope = metalv1alpha1.WipeScopeSystem
+
+				r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Wipe", "Server is going to be reinstalled, only the Talos system partitions are going to be wiped.")
+			}
 		}
 
 		s.Status.InUse = false
 
 		conditions.Delete(&s, metalv1alpha1.ConditionPXEBooted)
+		conditions.Delete(&s, metalv1alpha1.ConditionPoweredOff)
+		// released adopted server is wiped, and provisioned over the network like any other server
+		conditions.Delete(&s, metalv1alpha1.ConditionAdopted)
 	} else {
+		if !s.Status.InUse {
+			if err = r.recordAllocation(ctx, &s); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
 		s.Status.InUse = true
 		s.Status.IsClean = false
 
@@ -147,14 +333,76 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	} else {
 		// remove the finalizer from the server if it is not allocated
 		if hasFinalizer && !allocated {
+			if err = r.ChargebackArchive.Store(ctx, r.Client, &s); err != nil {
+				return ctrl.Result{}, fmt.Errorf("error archiving allocation history: %w", err)
+			}
+
 			controllerutil.RemoveFinalizer(&s, serverBindingFinalizer)
 		}
 	}
 
+	if !s.Status.IsClean || !s.Spec.Accepted {
+		// wipe attestation is only valid while the server stays clean
+		conditions.Delete(&s, metalv1alpha1.ConditionWiped)
+	}
+
+	var verifyCleanAfter time.Duration
+
+	// disks of the servers in the reinstall mode are not wiped on release in the first place,
+	// frozen servers keep their state, so the attestation is verified once the freeze ends
+	if r.CleanVerificationInterval > 0 && s.Spec.Accepted && !s.Status.InUse && s.Status.IsClean && s.Spec.WipeMode != metalv1alpha1.WipeModeReinstall && !frozen {
+		verifyCleanAfter = r.CleanVerificationInterval
+
+		if conditions.IsTrue(&s, metalv1alpha1.ConditionWiped) {
+			verifyCleanAfter -= time.Since(conditions.GetLastTransitionTime(&s, metalv1alpha1.ConditionWiped).Time)
+		} else {
+			verifyCleanAfter = 0
+		}
+
+		if verifyCleanAfter <= 0 {
+			// server was wiped too long ago (or wipe was never recorded), so it might have been modified out of band
+			s.Status.IsClean = false
+
+			conditions.Delete(&s, metalv1alpha1.ConditionWiped)
+
+			r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Wipe", "Server wipe attestation expired, server is going to be wiped again.")
+		}
+	}
+
+	inventoryStaleAfter = r.checkInventoryFreshness(&s, serverRef, frozen)
+
+	expireApprovals(&s)
+
+	r.trimAllocationHistory(&s)
+
+	r.validateCabling(&s, serverRef)
+
+	if err = r.validateMACs(ctx, &s, serverRef); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if s.Status.InUse || s.Status.IsClean || !s.Spec.Accepted || s.Spec.ManualPowerManagement {
+		// server is not being wiped, re-accepting the server lifts the quarantine
+		conditions.Delete(&s, metalv1alpha1.ConditionStalled)
+
+		s.Status.WipeAttempts = 0
+	}
+
+	if conditions.IsTrue(&s, metalv1alpha1.ConditionManualInterventionRequired) {
+		// retry budget is exhausted on all the power management paths, operator should fix it and re-accept the server
+		return f(false, ctrl.Result{})
+	}
+
+	if frozen && s.Spec.Accepted {
+		// no power actions during the freeze, server keeps its state until the freeze ends
+		return f(s.Status.Ready, ctrl.Result{})
+	}
+
 	switch {
 	case !s.Spec.Accepted:
 		// if server is not accepted, Sidero doesn't control server lifecycle, so we can't assume that server is (still) clean
 		s.Status.IsClean = false
+		s.Status.PendingWipeScope = ""
 
 		return f(false, ctrl.Result{})
 	case s.Status.InUse && s.Status.IsClean:
@@ -162,9 +410,16 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 		return f(false, ctrl.Result{})
 	case !s.Status.InUse && s.Status.IsClean:
+		if s.Spec.ManualPowerManagement {
+			// agent reboots the server after the wipe, so there's nothing to ask the operator for
+			conditions.Delete(&s, metalv1alpha1.ConditionManualPowerAction)
+
+			return f(true, ctrl.Result{RequeueAfter: verifyCleanAfter})
+		}
+
 		if powerErr != nil {
 			log.Error(powerErr, "failed to check power state")
-			r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Failed to determine power status: %s.", powerErr))
+			r.powerManagementFailed(&s, serverRef, powerErr, "Failed to determine power status")
 
 			return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
 		}
@@ -173,7 +428,7 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			err = mgmtClient.PowerOff()
 			if err != nil {
 				log.Error(err, "failed to power off")
-				r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Failed to power off: %s.", err))
+				r.powerManagementFailed(&s, serverRef, err, "Failed to power off")
 
 				return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
 			}
@@ -183,21 +438,63 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			}
 		}
 
-		return f(true, ctrl.Result{})
+		// requeue to verify that the server is still clean once the wipe attestation expires
+		return f(true, ctrl.Result{RequeueAfter: verifyCleanAfter})
 	case s.Status.InUse && !s.Status.IsClean:
+		if _, ok := s.Annotations[metalv1alpha1.PowerOffAnnotation]; ok &&
+			!r.awaitingApproval(&s, serverRef, s.PowerOffApproved(), metalv1alpha1.PowerOffApprovalRequiredReason, "power off", metalv1alpha1.PowerOffApprovedByAnnotation) {
+			return r.reconcilePowerOff(&s, serverRef, mgmtClient, poweredOn, powerErr, f)
+		}
+
+		if s.Spec.ManualPowerManagement {
+			switch reason := conditions.GetReason(&s, metalv1alpha1.ConditionManualPowerAction); {
+			case reason == metalv1alpha1.PowerOffRequiredReason && conditions.IsTrue(&s, metalv1alpha1.ConditionManualPowerAction):
+				// server was shut down on request, and the power off is no longer requested
+				conditions.Delete(&s, metalv1alpha1.ConditionManualPowerAction)
+
+				return f(false, r.requestManualPowerAction(&s, serverRef, metalv1alpha1.PowerRestoreRequiredReason, "power the server back on"))
+			case reason == metalv1alpha1.PowerRestoreRequiredReason && conditions.IsFalse(&s, metalv1alpha1.ConditionManualPowerAction):
+				return f(false, r.requestManualPowerAction(&s, serverRef, metalv1alpha1.PowerRestoreRequiredReason, "power the server back on"))
+			}
+
+			if booted(&s) {
+				conditions.Delete(&s, metalv1alpha1.ConditionManualPowerAction)
+
+				return f(true, ctrl.Result{})
+			}
+
+			return f(false, r.requestManualPowerAction(&s, serverRef, "PowerOnRequired", "power on the server (or power cycle it if it's running) and make sure it boots from the network"))
+		}
+
 		if powerErr != nil {
 			log.Error(powerErr, "failed to check power state")
-			r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Failed to determine power status: %s.", powerErr))
+			r.powerManagementFailed(&s, serverRef, powerErr, "Failed to determine power status")
 
 			return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
 		}
 
 		if !poweredOn {
+			// server went down after it was booted (and not on request), powering it on again is the automated remediation
+			remediate := booted(&s) && !conditions.IsTrue(&s, metalv1alpha1.ConditionPoweredOff)
+
+			if remediate {
+				allowed, budgetErr := r.checkPowerCycleRemediation(ctx, &s)
+				if budgetErr != nil {
+					log.Error(budgetErr, "failed to check remediation budget")
+
+					return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
+				}
+
+				if !allowed {
+					return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
+				}
+			}
+
 			// it's safe to set server to PXE boot even if it's already installed, as PXE server makes sure server is PXE booted only once
 			err = mgmtClient.SetPXE()
 			if err != nil {
 				log.Error(err, "failed to set PXE")
-				r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Failed to set to PXE boot once: %s.", err))
+				r.powerManagementFailed(&s, serverRef, err, "Failed to set to PXE boot once")
 
 				return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
 			}
@@ -205,18 +502,43 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			err = mgmtClient.PowerOn()
 			if err != nil {
 				log.Error(err, "failed to power on")
-				r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Failed to power on: %s.", err))
+				r.powerManagementFailed(&s, serverRef, err, "Failed to power on")
 
 				return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
 			}
 
+			if remediate {
+				r.recordPowerCycleRemediation(ctx, &s)
+			}
+
 			if !mgmtClient.IsFake() {
 				r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Management", "Server powered on and set PXE boot once into the environment.")
 			}
 		}
 
+		conditions.Delete(&s, metalv1alpha1.ConditionPoweredOff)
+
 		return f(true, ctrl.Result{})
 	case !s.Status.InUse && !s.Status.IsClean:
+		if r.awaitingApproval(&s, serverRef, s.WipeApproved(), metalv1alpha1.WipeApprovalRequiredReason, "wipe", metalv1alpha1.WipeApprovedByAnnotation) {
+			return f(false, ctrl.Result{})
+		}
+
+		if s.Spec.ManualPowerManagement {
+			return f(false, r.requestManualPowerAction(&s, serverRef, "PowerCycleRequired", "power cycle the server and make sure it boots from the network to be wiped"))
+		}
+
+		if len(metal.Paths(&s.Spec)) == 0 && conditions.IsTrue(&s, metalv1alpha1.ConditionStale) {
+			// server can't be power cycled, so the inventory refresh waits for the operator
+			return f(false, r.requestManualPowerAction(&s, serverRef, metalv1alpha1.InventoryRefreshRequiredReason,
+				"power cycle the server and make sure it boots from the network to refresh the hardware inventory"))
+		}
+
+		if r.checkWipeStalled(&s, serverRef) {
+			// quarantined, operator should investigate the server
+			return f(false, ctrl.Result{})
+		}
+
 		// when server is set to PXE boot to be wiped, ConditionPowerCycle is set to mark server
 		// as power cycled to avoid duplicate reboot attempts from subsequent Reconciles
 		//
@@ -231,15 +553,31 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 		if powerErr != nil {
 			log.Error(powerErr, "failed to check power state")
-			r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Failed to determine power status: %s.", powerErr))
+			r.powerManagementFailed(&s, serverRef, powerErr, "Failed to determine power status")
 
 			return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
 		}
 
+		// previous power cycle didn't get the server wiped, retrying it is the automated remediation
+		remediate := conditions.IsFalse(&s, metalv1alpha1.ConditionPowerCycle) || s.Status.WipeAttempts > 0
+
+		if remediate {
+			allowed, budgetErr := r.checkPowerCycleRemediation(ctx, &s)
+			if budgetErr != nil {
+				log.Error(budgetErr, "failed to check remediation budget")
+
+				return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
+			}
+
+			if !allowed {
+				return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
+			}
+		}
+
 		err = mgmtClient.SetPXE()
 		if err != nil {
 			log.Error(err, "failed to set PXE")
-			r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Failed to set to PXE boot once: %s.", err))
+			r.powerManagementFailed(&s, serverRef, err, "Failed to set to PXE boot once")
 
 			return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
 		}
@@ -248,7 +586,7 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			err = mgmtClient.PowerCycle()
 			if err != nil {
 				log.Error(err, "failed to power cycle")
-				r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Failed to power cycle: %s.", err))
+				r.powerManagementFailed(&s, serverRef, err, "Failed to power cycle")
 
 				return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
 			}
@@ -256,12 +594,16 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			err = mgmtClient.PowerOn()
 			if err != nil {
 				log.Error(err, "failed to power on")
-				r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Failed to power on: %s.", err))
+				r.powerManagementFailed(&s, serverRef, err, "Failed to power on")
 
 				return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
 			}
 		}
 
+		if remediate {
+			r.recordPowerCycleRemediation(ctx, &s)
+		}
+
 		if !mgmtClient.IsFake() {
 			if poweredOn {
 				r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Management", "Server power cycled and set to PXE boot once.")
@@ -274,6 +616,11 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			conditions.MarkFalse(&s, metalv1alpha1.ConditionPowerCycle, "InProgress", clusterv1.ConditionSeverityInfo, "Server power cycled for wiping.")
 		}
 
+		if !conditions.Has(&s, metalv1alpha1.ConditionStalled) {
+			// start of the wipe attempt
+			conditions.MarkFalse(&s, metalv1alpha1.ConditionStalled, "WipeInProgress", clusterv1.ConditionSeverityInfo, "Waiting for the server to be wiped.")
+		}
+
 		// requeue to check for wipe timeout
 		return f(false, ctrl.Result{RequeueAfter: r.RebootTimeout / 3})
 	}
@@ -281,6 +628,577 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	return f(false, ctrl.Result{})
 }
 
+// reconcilePowerOff gracefully shuts down the allocated server while it has PowerOffAnnotation.
+func (r *ServerReconciler) reconcilePowerOff(s *metalv1alpha1.Server, serverRef *corev1.ObjectReference, mgmtClient metal.ManagementClient, poweredOn bool, powerErr error,
+	f func(bool, ctrl.Result) (ctrl.Result, error)) (ctrl.Result, error) {
+	if s.Spec.ManualPowerManagement {
+		if conditions.IsTrue(s, metalv1alpha1.ConditionManualPowerAction) &&
+			conditions.GetReason(s, metalv1alpha1.ConditionManualPowerAction) == metalv1alpha1.PowerOffRequiredReason {
+			// power off was acknowledged by the operator
+			return f(false, ctrl.Result{})
+		}
+
+		if conditions.IsTrue(s, metalv1alpha1.ConditionManualPowerAction) {
+			// previous action was acknowledged, request the power off right away
+			conditions.Delete(s, metalv1alpha1.ConditionManualPowerAction)
+		}
+
+		return f(false, r.requestManualPowerAction(s, serverRef, metalv1alpha1.PowerOffRequiredReason, "shut down the server"))
+	}
+
+	if powerErr != nil {
+		r.Log.Error(powerErr, "failed to check power state", "server", s.Name)
+		r.powerManagementFailed(s, serverRef, powerErr, "Failed to determine power status")
+
+		return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
+	}
+
+	if !poweredOn {
+		conditions.MarkTrue(s, metalv1alpha1.ConditionPoweredOff)
+
+		return f(false, ctrl.Result{})
+	}
+
+	if mgmtClient.IsFake() {
+		return f(false, ctrl.Result{})
+	}
+
+	if err := mgmtClient.Shutdown(); err != nil {
+		r.Log.Error(err, "failed to shut down", "server", s.Name)
+		r.powerManagementFailed(s, serverRef, err, "Failed to shut down")
+
+		return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
+	}
+
+	r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Management", "Server requested to shut down.")
+
+	// requeue to observe the power state once the server shuts down
+	return f(false, ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter})
+}
+
+// powerManagementFailed records the failed power management request on the server.
+//
+// Condition and event reason tell the rejected BMC credentials from the other failures.
+func (r *ServerReconciler) powerManagementFailed(s *metalv1alpha1.Server, serverRef *corev1.ObjectReference, err error, message string) {
+	reason := metal.FailureReason(err)
+	message = fmt.Sprintf("%s: %s.", message, err)
+
+	conditions.Set(s, &clusterv1.Condition{
+		Type:     metalv1alpha1.ConditionPowerManagementFailed,
+		Status:   corev1.ConditionTrue,
+		Severity: clusterv1.ConditionSeverityError,
+		Reason:   reason,
+		Message:  message,
+	})
+
+	r.Recorder.Event(serverRef, corev1.EventTypeWarning, reason, message)
+
+	if s.Status.LastPowerFailure != nil && time.Since(s.Status.LastPowerFailure.Time) < powerRetryBackoff(r.PowerRetryInterval, s.Status.PowerFailures) {
+		// failure is retried too early to be counted
+		return
+	}
+
+	now := v1.Now()
+
+	s.Status.PowerFailures++
+	s.Status.LastPowerFailure = &now
+
+	if r.PowerRetries <= 0 || s.Status.PowerFailures < r.PowerRetries {
+		return
+	}
+
+	paths := metal.Paths(&s.Spec)
+
+	s.Status.PowerFailures = 0
+	s.Status.LastPowerFailure = nil
+
+	if s.Status.PowerPath+1 < len(paths) {
+		s.Status.PowerPath++
+
+		r.Recorder.Event(serverRef, corev1.EventTypeWarning, reason,
+			fmt.Sprintf("Power management failed %d times in a row, switching to %s.", r.PowerRetries, paths[s.Status.PowerPath]))
+
+		return
+	}
+
+	conditions.Set(s, &clusterv1.Condition{
+		Type:     metalv1alpha1.ConditionManualInterventionRequired,
+		Status:   corev1.ConditionTrue,
+		Severity: clusterv1.ConditionSeverityError,
+		Reason:   reason,
+		Message:  fmt.Sprintf("Power management failed %d times in a row on all the paths (%s): %s", r.PowerRetries, strings.Join(paths, ", "), message),
+	})
+
+	r.Recorder.Event(serverRef, corev1.EventTypeWarning, reason,
+		"Power management retries are exhausted, fix power management of the server and re-accept it to retry.")
+}
+
+// maxPowerRetryBackoff caps the interval between the counted power management failures.
+const maxPowerRetryBackoff = 10 * time.Minute
+
+// powerRetryBackoff returns the minimum interval after the last counted failure for the next failure to be counted:
+// the interval is doubled with each failure.
+func powerRetryBackoff(interval time.Duration, failures int) time.Duration {
+	backoff := interval
+
+	for i := 1; i < failures && backoff < maxPowerRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxPowerRetryBackoff {
+		backoff = maxPowerRetryBackoff
+	}
+
+	return backoff
+}
+
+// requestManualPowerAction asks the operator to perform the power action on the server without power management.
+//
+// Operator acknowledges the action by setting the annotation on the server, after that the action is
+// not requested again until the reboot timeout elapses.
+func (r *ServerReconciler) requestManualPowerAction(s *metalv1alpha1.Server, serverRef *corev1.ObjectReference, reason, action string) ctrl.Result {
+	if _, acknowledged := s.Annotations[metalv1alpha1.PowerActionAcknowledgedAnnotation]; acknowledged {
+		delete(s.Annotations, metalv1alpha1.PowerActionAcknowledgedAnnotation)
+
+		// keep the reason of the requested action, so that it's known which action was acknowledged
+		acknowledgedReason := conditions.GetReason(s, metalv1alpha1.ConditionManualPowerAction)
+
+		// remove the condition in case it was already set to make sure LastTransitionTime will be updated
+		conditions.Delete(s, metalv1alpha1.ConditionManualPowerAction)
+		conditions.Set(s, &clusterv1.Condition{
+			Type:   metalv1alpha1.ConditionManualPowerAction,
+			Status: corev1.ConditionTrue,
+			Reason: acknowledgedReason,
+		})
+
+		r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Management", "Manual power action acknowledged.")
+
+		return ctrl.Result{RequeueAfter: r.RebootTimeout / 3}
+	}
+
+	if conditions.IsTrue(s, metalv1alpha1.ConditionManualPowerAction) {
+		if remaining := r.RebootTimeout - time.Since(conditions.GetLastTransitionTime(s, metalv1alpha1.ConditionManualPowerAction).Time); remaining > 0 {
+			// action was acknowledged recently, wait for the server to boot
+			return ctrl.Result{RequeueAfter: remaining}
+		}
+	}
+
+	if conditions.GetReason(s, metalv1alpha1.ConditionManualPowerAction) == reason {
+		// already requested
+		return ctrl.Result{}
+	}
+
+	conditions.MarkFalse(s, metalv1alpha1.ConditionManualPowerAction, reason, clusterv1.ConditionSeverityWarning, "Manual action required: %s.", action)
+
+	r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management",
+		fmt.Sprintf("Manual action required: %s, then set annotation %q on the server.", action, metalv1alpha1.PowerActionAcknowledgedAnnotation))
+
+	return ctrl.Result{}
+}
+
+// checkPowerCycleRemediation checks whether the remediation budget of the cluster the server is (or was last) allocated to
+// allows the automated power cycle of the server, it returns false if the budget is exhausted.
+func (r *ServerReconciler) checkPowerCycleRemediation(ctx context.Context, s *metalv1alpha1.Server) (bool, error) {
+	cluster, allocation, err := r.lastCluster(ctx, s)
+	if err != nil || cluster == nil {
+		return err == nil, err
+	}
+
+	return remediation.Check(ctx, r.Client, r.Recorder, cluster, infrav1.RemediationActionPowerCycle, allocation.Machine)
+}
+
+// recordPowerCycleRemediation charges the performed automated power cycle of the server against the remediation budget.
+//
+// The power action is already performed, so the failure to record it is only logged.
+func (r *ServerReconciler) recordPowerCycleRemediation(ctx context.Context, s *metalv1alpha1.Server) {
+	cluster, allocation, err := r.lastCluster(ctx, s)
+	if err == nil && cluster != nil {
+		err = remediation.Record(ctx, r.Client, cluster, infrav1.RemediationActionPowerCycle, allocation.Machine, s.Name)
+	}
+
+	if err != nil {
+		r.Log.Error(err, "failed to record remediation action", "server", s.Name)
+	}
+}
+
+// lastCluster returns the cluster the server is (or was last) allocated to, nil if there is none (or it's gone).
+func (r *ServerReconciler) lastCluster(ctx context.Context, s *metalv1alpha1.Server) (*clusterv1.Cluster, metalv1alpha1.AllocationRecord, error) {
+	if len(s.Status.AllocationHistory) == 0 {
+		return nil, metalv1alpha1.AllocationRecord{}, nil
+	}
+
+	allocation := s.Status.AllocationHistory[len(s.Status.AllocationHistory)-1]
+	if allocation.Cluster == "" {
+		return nil, allocation, nil
+	}
+
+	var cluster clusterv1.Cluster
+
+	if err := r.Get(ctx, types.NamespacedName{Namespace: allocation.Namespace, Name: allocation.Cluster}, &cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			// cluster is gone, so there is no budget to account the power cycle against
+			return nil, allocation, nil
+		}
+
+		return nil, allocation, err
+	}
+
+	return &cluster, allocation, nil
+}
+
+// checkWipeStalled checks whether the current wipe attempt timed out.
+//
+// Timed out attempts are retried by power cycling the server, once the retries are exhausted,
+// the server is quarantined: the Stalled condition is set to True and no more power actions are performed.
+func (r *ServerReconciler) checkWipeStalled(s *metalv1alpha1.Server, serverRef *corev1.ObjectReference) (quarantined bool) {
+	timeout := r.WipeTimeout
+
+	if s.Spec.WipeTimeout != nil {
+		timeout = s.Spec.WipeTimeout.Duration
+	}
+
+	if timeout <= 0 {
+		conditions.Delete(s, metalv1alpha1.ConditionStalled)
+
+		return false
+	}
+
+	if conditions.IsTrue(s, metalv1alpha1.ConditionStalled) {
+		return true
+	}
+
+	if !conditions.Has(s, metalv1alpha1.ConditionStalled) {
+		// wipe attempt is not started yet
+		return false
+	}
+
+	if time.Since(conditions.GetLastTransitionTime(s, metalv1alpha1.ConditionStalled).Time) < timeout {
+		return false
+	}
+
+	s.Status.WipeAttempts++
+
+	if s.Status.WipeAttempts > r.WipeRetries {
+		conditions.Set(s, &clusterv1.Condition{
+			Type:     metalv1alpha1.ConditionStalled,
+			Status:   corev1.ConditionTrue,
+			Severity: clusterv1.ConditionSeverityError,
+			Reason:   metalv1alpha1.WipeTimeoutReason,
+			Message:  fmt.Sprintf("Server wasn't wiped within %s after %d attempt(s).", timeout, s.Status.WipeAttempts),
+		})
+
+		r.Recorder.Event(serverRef, corev1.EventTypeWarning, metalv1alpha1.WipeTimeoutReason,
+			fmt.Sprintf("Server wipe stalled after %d attempt(s), server is quarantined, re-accept the server to retry.", s.Status.WipeAttempts))
+
+		return true
+	}
+
+	r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Wipe",
+		fmt.Sprintf("Server wipe stalled, retrying (attempt %d of %d).", s.Status.WipeAttempts+1, r.WipeRetries+1))
+
+	// force the power cycle and start the new attempt
+	conditions.Delete(s, metalv1alpha1.ConditionPowerCycle)
+	conditions.Delete(s, metalv1alpha1.ConditionStalled)
+
+	return false
+}
+
+// validateCabling checks LLDP neighbors reported by the agent against the server cabling rules.
+func (r *ServerReconciler) validateCabling(s *metalv1alpha1.Server, serverRef *corev1.ObjectReference) {
+	var invalid []string
+
+	for i := range s.Spec.CablingRules {
+		rule := &s.Spec.CablingRules[i]
+
+		matched := false
+
+		for j := range s.Status.LLDPNeighbors {
+			if rule.Matches(&s.Status.LLDPNeighbors[j]) {
+				matched = true
+
+				break
+			}
+		}
+
+		if !matched {
+			invalid = append(invalid, rule.Interface)
+		}
+	}
+
+	if len(invalid) == 0 {
+		conditions.Delete(s, metalv1alpha1.ConditionCablingInvalid)
+
+		return
+	}
+
+	message := fmt.Sprintf("Interfaces are not connected to the expected neighbors: %s.", strings.Join(invalid, ", "))
+
+	if conditions.IsTrue(s, metalv1alpha1.ConditionCablingInvalid) && conditions.Get(s, metalv1alpha1.ConditionCablingInvalid).Message == message {
+		return
+	}
+
+	conditions.Set(s, &clusterv1.Condition{
+		Type:    metalv1alpha1.ConditionCablingInvalid,
+		Status:  corev1.ConditionTrue,
+		Reason:  "RulesViolated",
+		Message: message,
+	})
+
+	r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Cabling validation failed: %s", message))
+}
+
+// validateMACs checks whether the server MAC addresses are unique across the servers.
+func (r *ServerReconciler) validateMACs(ctx context.Context, s *metalv1alpha1.Server, serverRef *corev1.ObjectReference) error {
+	duplicates := map[string]struct{}{}
+
+	for _, iface := range s.Status.NetworkInterfaces {
+		var serverList metalv1alpha1.ServerList
+
+		if err := r.List(ctx, &serverList, client.MatchingFields(fields.Set{metalv1alpha1.ServerMACAddressField: iface.MAC})); err != nil {
+			return err
+		}
+
+		for _, server := range serverList.Items {
+			if server.Name != s.Name {
+				duplicates[server.Name] = struct{}{}
+			}
+		}
+	}
+
+	if len(duplicates) == 0 {
+		conditions.Delete(s, metalv1alpha1.ConditionDuplicateMAC)
+
+		return nil
+	}
+
+	names := make([]string, 0, len(duplicates))
+
+	for name := range duplicates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	message := fmt.Sprintf("MAC addresses are shared with servers: %s.", strings.Join(names, ", "))
+
+	if conditions.IsTrue(s, metalv1alpha1.ConditionDuplicateMAC) && conditions.Get(s, metalv1alpha1.ConditionDuplicateMAC).Message == message {
+		return nil
+	}
+
+	conditions.Set(s, &clusterv1.Condition{
+		Type:     metalv1alpha1.ConditionDuplicateMAC,
+		Status:   corev1.ConditionTrue,
+		Severity: clusterv1.ConditionSeverityError,
+		Reason:   "DuplicateMAC",
+		Message:  message,
+	})
+
+	r.Recorder.Event(serverRef, corev1.EventTypeWarning, "Server Management", fmt.Sprintf("Duplicate MAC addresses detected: %s", message))
+
+	return nil
+}
+
+// recordAllocation appends the new allocation to the server allocation history.
+func (r *ServerReconciler) recordAllocation(ctx context.Context, s *metalv1alpha1.Server) error {
+	var metalMachineList infrav1.MetalMachineList
+
+	if err := r.List(ctx, &metalMachineList, client.MatchingFields(fields.Set{infrav1.MetalMachineServerRefField: s.Name})); err != nil {
+		return err
+	}
+
+	record := metalv1alpha1.AllocationRecord{
+		AllocatedAt: v1.Now(),
+	}
+
+	for _, metalMachine := range metalMachineList.Items {
+		if !metalMachine.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		record.Namespace = metalMachine.Namespace
+		record.MetalMachine = metalMachine.Name
+		record.Cluster = metalMachine.Labels[clusterv1.ClusterLabelName]
+
+		record.Tags = chargebackTags(record.Tags, metalMachine.Labels)
+
+		// control plane providers don't propagate the labels to the metal machines, so the cluster labels are the defaults
+		if record.Cluster != "" {
+			var cluster clusterv1.Cluster
+
+			if err := r.Get(ctx, types.NamespacedName{Namespace: record.Namespace, Name: record.Cluster}, &cluster); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return err
+				}
+			}
+
+			record.Tags = chargebackTags(record.Tags, cluster.Labels)
+		}
+
+		if metalMachine.Spec.ServerClassRef != nil {
+			record.ServerClass = metalMachine.Spec.ServerClassRef.Name
+
+			var serverClass metalv1alpha1.ServerClass
+
+			if err := r.Get(ctx, types.NamespacedName{Name: record.ServerClass}, &serverClass); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return err
+				}
+			}
+
+			record.Diskless = serverClass.Spec.Diskless
+		}
+
+		for _, ref := range metalMachine.OwnerReferences {
+			if ref.Kind == "Machine" {
+				record.Machine = ref.Name
+			}
+		}
+
+		break
+	}
+
+	s.Status.AllocationHistory = append(s.Status.AllocationHistory, record)
+
+	r.trimAllocationHistory(s)
+
+	return nil
+}
+
+// chargebackTags adds the chargeback tags from the labels, keeping the tags which are already set.
+func chargebackTags(tags, labels map[string]string) map[string]string {
+	for key, value := range labels {
+		if !strings.HasPrefix(key, metalv1alpha1.ChargebackTagPrefix) {
+			continue
+		}
+
+		key = strings.TrimPrefix(key, metalv1alpha1.ChargebackTagPrefix)
+
+		if _, ok := tags[key]; ok {
+			continue
+		}
+
+		if tags == nil {
+			tags = map[string]string{}
+		}
+
+		tags[key] = value
+	}
+
+	return tags
+}
+
+// checkInventoryFreshness sets the Stale condition if the last hardware inventory of the server is older than InventoryTTL,
+// it returns the time left until the inventory gets stale.
+//
+// If the inventory refresh is enabled, stale idle servers are wiped again, as the agent records the inventory on every boot.
+// Frozen servers are only marked stale, they are wiped once the freeze ends.
+// Servers without power management are rebooted by the operator, the manual action is requested along with the wipe.
+func (r *ServerReconciler) checkInventoryFreshness(s *metalv1alpha1.Server, serverRef *corev1.ObjectReference, frozen bool) time.Duration {
+	if r.InventoryTTL <= 0 {
+		inventoryRefreshed(s)
+
+		return 0
+	}
+
+	message := "No hardware inventory was recorded for the server."
+
+	if s.Status.LastInventoryTime != nil {
+		if left := r.InventoryTTL - time.Since(s.Status.LastInventoryTime.Time); left > 0 {
+			inventoryRefreshed(s)
+
+			return left
+		}
+
+		message = fmt.Sprintf("Hardware inventory was recorded at %s, more than %s ago.", s.Status.LastInventoryTime.UTC().Format(time.RFC3339), r.InventoryTTL)
+	}
+
+	conditions.Set(s, &clusterv1.Condition{
+		Type:     metalv1alpha1.ConditionStale,
+		Status:   corev1.ConditionTrue,
+		Severity: clusterv1.ConditionSeverityInfo,
+		Reason:   metalv1alpha1.InventoryStaleReason,
+		Message:  message,
+	})
+
+	if r.InventoryRefresh && s.Spec.Accepted && !s.Status.InUse && s.Status.IsClean && !frozen {
+		s.Status.IsClean = false
+
+		conditions.Delete(s, metalv1alpha1.ConditionWiped)
+
+		r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Inventory", "Server hardware inventory is stale, server is going to be wiped again to refresh it.")
+	}
+
+	return 0
+}
+
+// inventoryRefreshed clears the Stale condition, and the manual action requested to refresh the inventory.
+func inventoryRefreshed(s *metalv1alpha1.Server) {
+	conditions.Delete(s, metalv1alpha1.ConditionStale)
+
+	if conditions.GetReason(s, metalv1alpha1.ConditionManualPowerAction) == metalv1alpha1.InventoryRefreshRequiredReason {
+		conditions.Delete(s, metalv1alpha1.ConditionManualPowerAction)
+	}
+}
+
+// booted checks whether the allocated server was booted into the cluster, either over the network or before it got adopted.
+func booted(s *metalv1alpha1.Server) bool {
+	return conditions.IsTrue(s, metalv1alpha1.ConditionPXEBooted) || conditions.IsTrue(s, metalv1alpha1.ConditionAdopted)
+}
+
+// trimAllocationHistory removes the oldest allocations beyond AllocationHistorySize.
+//
+// History is trimmed on every reconcile, so that lowering the limit applies to all the servers right away.
+// The end of the removed allocations is recorded, so that the usage reports covering it are marked partial.
+func (r *ServerReconciler) trimAllocationHistory(s *metalv1alpha1.Server) {
+	if r.AllocationHistorySize > 0 && len(s.Status.AllocationHistory) > r.AllocationHistorySize {
+		trimmed := len(s.Status.AllocationHistory) - r.AllocationHistorySize
+
+		for _, record := range s.Status.AllocationHistory[:trimmed] {
+			// the current allocation is never trimmed, so the removed allocations are released
+			if record.ReleasedAt != nil && (s.Status.AllocationHistoryTrimmedUntil == nil || record.ReleasedAt.After(s.Status.AllocationHistoryTrimmedUntil.Time)) {
+				s.Status.AllocationHistoryTrimmedUntil = record.ReleasedAt.DeepCopy()
+			}
+		}
+
+		s.Status.AllocationHistory = append([]metalv1alpha1.AllocationRecord(nil), s.Status.AllocationHistory[trimmed:]...)
+	}
+}
+
+// recordRelease marks the current allocation in the server allocation history as released.
+func (r *ServerReconciler) recordRelease(ctx context.Context, s *metalv1alpha1.Server) error {
+	if len(s.Status.AllocationHistory) == 0 {
+		return nil
+	}
+
+	record := &s.Status.AllocationHistory[len(s.Status.AllocationHistory)-1]
+
+	if record.ReleasedAt != nil {
+		return nil
+	}
+
+	now := v1.Now()
+
+	record.ReleasedAt = &now
+	record.ReleaseReason = metalv1alpha1.ReleaseReasonReleased
+
+	if record.Machine == "" {
+		return nil
+	}
+
+	var machine clusterv1.Machine
+
+	// machine is still around while the infrastructure is being deleted
+	if err := r.Get(ctx, types.NamespacedName{Namespace: record.Namespace, Name: record.Machine}, &machine); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if conditions.IsFalse(&machine, clusterv1.MachineOwnerRemediatedCondition) {
+		record.ReleaseReason = metalv1alpha1.ReleaseReasonRemediated
+	}
+
+	return nil
+}
+
 func (r *ServerReconciler) checkBinding(ctx context.Context, req ctrl.Request) (allocated, serverBindingPresent bool, err error) {
 	var serverBinding infrav1.ServerBinding
 
@@ -328,6 +1246,73 @@ func (r *ServerReconciler) SetupWithManager(mgr ctrl.Manager, options controller
 		return err
 	}
 
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &metalv1alpha1.Server{}, metalv1alpha1.ServerMACAddressField, func(rawObj runtime.Object) []string {
+		server := rawObj.(*metalv1alpha1.Server)
+
+		macs := make([]string, 0, len(server.Status.NetworkInterfaces))
+
+		for _, iface := range server.Status.NetworkInterfaces {
+			if iface.MAC != "" {
+				macs = append(macs, iface.MAC)
+			}
+		}
+
+		return macs
+	}); err != nil {
+		return err
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &metalv1alpha1.Server{}, metalv1alpha1.ServerBMCEndpointField, func(rawObj runtime.Object) []string {
+		server := rawObj.(*metalv1alpha1.Server)
+
+		if server.Spec.BMC == nil || server.Spec.BMC.Endpoint == "" {
+			return nil
+		}
+
+		return []string{server.Spec.BMC.Endpoint}
+	}); err != nil {
+		return err
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &metalv1alpha1.Server{}, metalv1alpha1.ServerSerialNumberField, func(rawObj runtime.Object) []string {
+		server := rawObj.(*metalv1alpha1.Server)
+
+		if server.Spec.SystemInformation == nil || server.Spec.SystemInformation.SerialNumber == "" {
+			return nil
+		}
+
+		return []string{server.Spec.SystemInformation.SerialNumber}
+	}); err != nil {
+		return err
+	}
+
+	// servers sharing MAC addresses should be revalidated when any of them changes
+	mapDuplicateMACs := handler.ToRequestsFunc(
+		func(a handler.MapObject) []reconcile.Request {
+			server, ok := a.Object.(*metalv1alpha1.Server)
+			if !ok {
+				return nil
+			}
+
+			var reqList []reconcile.Request
+
+			for _, iface := range server.Status.NetworkInterfaces {
+				var serverList metalv1alpha1.ServerList
+
+				if err := r.List(context.Background(), &serverList, client.MatchingFields(fields.Set{metalv1alpha1.ServerMACAddressField: iface.MAC})); err != nil {
+					continue
+				}
+
+				for _, duplicate := range serverList.Items {
+					if duplicate.Name != server.Name {
+						reqList = append(reqList, reconcile.Request{NamespacedName: types.NamespacedName{Name: duplicate.Name}})
+					}
+				}
+			}
+
+			return reqList
+		})
+
 	mapRequests := handler.ToRequestsFunc(
 		func(a handler.MapObject) []reconcile.Request {
 			// servers and serverbindings always have matching names
@@ -341,14 +1326,34 @@ func (r *ServerReconciler) SetupWithManager(mgr ctrl.Manager, options controller
 			}
 		})
 
+	priority, background := priorityPredicates()
+
+	if r.PriorityMaxConcurrentReconciles > 0 {
+		if err := ctrl.NewControllerManagedBy(mgr).
+			Named("server-priority").
+			WithOptions(controller.Options{MaxConcurrentReconciles: r.PriorityMaxConcurrentReconciles}).
+			For(&metalv1alpha1.Server{}, builder.WithPredicates(priority)).
+			Complete(r); err != nil {
+			return err
+		}
+	} else {
+		background = predicate.Funcs{}
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(options).
-		For(&metalv1alpha1.Server{}).
+		For(&metalv1alpha1.Server{}, builder.WithPredicates(background)).
 		Watches(
 			&source.Kind{Type: &infrav1.ServerBinding{}},
 			&handler.EnqueueRequestsFromMapFunc{
 				ToRequests: mapRequests,
 			},
 		).
+		Watches(
+			&source.Kind{Type: &metalv1alpha1.Server{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: mapDuplicateMACs,
+			},
+		).
 		Complete(r)
 }
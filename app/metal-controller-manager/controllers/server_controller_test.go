@@ -0,0 +1,777 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestServerReconcilerPowerOnRemediation(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		corev1.AddToScheme,
+		metalv1alpha1.AddToScheme,
+		infrav1.AddToScheme,
+		capiv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		name       string
+		adopted    bool
+		poweredOff bool
+		failures   int
+		charged    []bool
+	}{
+		{
+			name:    "server went down",
+			charged: []bool{true},
+		},
+		{
+			name:    "adopted server went down",
+			adopted: true,
+			charged: []bool{true},
+		},
+		{
+			name:     "power on fails",
+			failures: 1,
+			charged:  []bool{false, true},
+		},
+		{
+			name:       "server powered off on request",
+			poweredOff: true,
+			charged:    []bool{false},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				poweredOn bool
+				failures  = tt.failures
+			)
+
+			mgmtAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/status":
+					if poweredOn {
+						w.Write([]byte(`{"PoweredOn":true}`)) //nolint: errcheck
+					} else {
+						w.Write([]byte(`{"PoweredOn":false}`)) //nolint: errcheck
+					}
+				case "/poweron":
+					if failures > 0 {
+						failures--
+
+						w.WriteHeader(http.StatusInternalServerError)
+
+						return
+					}
+
+					poweredOn = true
+				}
+			}))
+			defer mgmtAPI.Close()
+
+			server := &metalv1alpha1.Server{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "server",
+					ResourceVersion: "1",
+				},
+				Spec: metalv1alpha1.ServerSpec{
+					Accepted:      true,
+					ManagementAPI: &metalv1alpha1.ManagementAPI{Endpoint: strings.TrimPrefix(mgmtAPI.URL, "http://")},
+				},
+				Status: metalv1alpha1.ServerStatus{
+					InUse: true,
+					AllocationHistory: []metalv1alpha1.AllocationRecord{
+						{Cluster: "management", Namespace: "default", Machine: "machine"},
+					},
+				},
+			}
+
+			if tt.adopted {
+				conditions.MarkTrue(server, metalv1alpha1.ConditionAdopted)
+			} else {
+				conditions.MarkTrue(server, metalv1alpha1.ConditionPXEBooted)
+			}
+
+			if tt.poweredOff {
+				conditions.MarkTrue(server, metalv1alpha1.ConditionPoweredOff)
+			}
+
+			c := fake.NewFakeClientWithScheme(scheme,
+				server,
+				&infrav1.ServerBinding{ObjectMeta: metav1.ObjectMeta{Name: "server"}},
+				&capiv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "management"},
+					Spec: capiv1.ClusterSpec{
+						InfrastructureRef: &corev1.ObjectReference{Kind: "MetalCluster", Name: "management"},
+					},
+				},
+				&infrav1.MetalCluster{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "management", ResourceVersion: "1"},
+					Spec: infrav1.MetalClusterSpec{
+						RemediationBudget: &infrav1.RemediationBudget{MaxActions: 5},
+					},
+				},
+			)
+
+			r := &ServerReconciler{
+				Client:    c,
+				Log:       log.NullLogger{},
+				Scheme:    scheme,
+				APIReader: c,
+				Recorder:  record.NewFakeRecorder(100),
+			}
+
+			ctx := context.Background()
+
+			for i, charged := range tt.charged {
+				if _, err := r.reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "server"}}); err != nil {
+					t.Fatal(err)
+				}
+
+				var metalCluster infrav1.MetalCluster
+
+				if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "management"}, &metalCluster); err != nil {
+					t.Fatal(err)
+				}
+
+				if actions := len(metalCluster.Status.RemediationActions); (actions > 0) != charged {
+					t.Errorf("reconcile %d: %d remediation actions recorded, charged = %v", i, actions, charged)
+				}
+			}
+
+			if !poweredOn {
+				t.Error("server is not powered on")
+			}
+
+			var s metalv1alpha1.Server
+
+			if err := c.Get(ctx, types.NamespacedName{Name: "server"}, &s); err != nil {
+				t.Fatal(err)
+			}
+
+			if conditions.Has(&s, metalv1alpha1.ConditionPoweredOff) {
+				t.Error("PoweredOff condition is not cleared once the server is powered on")
+			}
+		})
+	}
+}
+
+func Test_powerRetryBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 0, expected: 20 * time.Second},
+		{failures: 1, expected: 20 * time.Second},
+		{failures: 2, expected: 40 * time.Second},
+		{failures: 4, expected: 160 * time.Second},
+		{failures: 6, expected: 10 * time.Minute},
+		{failures: 100, expected: 10 * time.Minute},
+	} {
+		t.Run(fmt.Sprintf("%d", tt.failures), func(t *testing.T) {
+			if backoff := powerRetryBackoff(20*time.Second, tt.failures); backoff != tt.expected {
+				t.Errorf("powerRetryBackoff() = %s, want %s", backoff, tt.expected)
+			}
+		})
+	}
+}
+
+func TestServerReconcilerPowerManagementFailed(t *testing.T) {
+	r := &ServerReconciler{
+		Recorder:           record.NewFakeRecorder(100),
+		PowerRetries:       3,
+		PowerRetryInterval: time.Minute,
+	}
+
+	ago := func(d time.Duration) *metav1.Time {
+		t := metav1.NewTime(time.Now().Add(-d))
+
+		return &t
+	}
+
+	for _, tt := range []struct {
+		name        string
+		failures    int
+		lastFailure *metav1.Time
+
+		expectedFailures int
+		expectedPath     int
+	}{
+		{
+			name:             "first failure",
+			expectedFailures: 1,
+		},
+		{
+			name:             "failure within the interval",
+			failures:         1,
+			lastFailure:      ago(10 * time.Second),
+			expectedFailures: 1,
+		},
+		{
+			name:             "failure after the interval",
+			failures:         1,
+			lastFailure:      ago(2 * time.Minute),
+			expectedFailures: 2,
+		},
+		{
+			name:             "failure within the doubled interval",
+			failures:         2,
+			lastFailure:      ago(90 * time.Second),
+			expectedFailures: 2,
+		},
+		{
+			name:         "retries exhausted",
+			failures:     2,
+			lastFailure:  ago(3 * time.Minute),
+			expectedPath: 1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &metalv1alpha1.Server{
+				Spec: metalv1alpha1.ServerSpec{
+					BMC:           &metalv1alpha1.BMC{Endpoint: "10.0.0.25"},
+					ManagementAPI: &metalv1alpha1.ManagementAPI{Endpoint: "10.0.0.25:8080"},
+				},
+				Status: metalv1alpha1.ServerStatus{
+					PowerFailures:    tt.failures,
+					LastPowerFailure: tt.lastFailure,
+				},
+			}
+
+			r.powerManagementFailed(s, &corev1.ObjectReference{}, errors.New("connection refused"), "Failed to power on")
+
+			if s.Status.PowerFailures != tt.expectedFailures {
+				t.Errorf("power failures = %d, want %d", s.Status.PowerFailures, tt.expectedFailures)
+			}
+
+			if s.Status.PowerPath != tt.expectedPath {
+				t.Errorf("power path = %d, want %d", s.Status.PowerPath, tt.expectedPath)
+			}
+
+			if !conditions.IsTrue(s, metalv1alpha1.ConditionPowerManagementFailed) {
+				t.Error("failure is not recorded in the condition")
+			}
+		})
+	}
+}
+
+func Test_chargebackTags(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		tags     map[string]string
+		labels   map[string]string
+		expected map[string]string
+	}{
+		{
+			name:   "no chargeback labels",
+			labels: map[string]string{capiv1.ClusterLabelName: "ml"},
+		},
+		{
+			name:     "prefix is trimmed",
+			labels:   map[string]string{metalv1alpha1.ChargebackTagPrefix + "cost-center": "research", "team": "ml"},
+			expected: map[string]string{"cost-center": "research"},
+		},
+		{
+			name:     "metal machine tags take precedence over the cluster labels",
+			tags:     map[string]string{"cost-center": "research"},
+			labels:   map[string]string{metalv1alpha1.ChargebackTagPrefix + "cost-center": "ml", metalv1alpha1.ChargebackTagPrefix + "env": "prod"},
+			expected: map[string]string{"cost-center": "research", "env": "prod"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if tags := chargebackTags(tt.tags, tt.labels); !reflect.DeepEqual(tags, tt.expected) {
+				t.Errorf("chargebackTags() = %v, want %v", tags, tt.expected)
+			}
+		})
+	}
+}
+
+func TestServerReconcilerInventoryRefresh(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		corev1.AddToScheme,
+		metalv1alpha1.AddToScheme,
+		infrav1.AddToScheme,
+		capiv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inventoried := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+
+	for _, tt := range []struct {
+		name string
+		spec metalv1alpha1.ServerSpec
+
+		manualAction string
+	}{
+		{
+			name: "power management",
+			spec: metalv1alpha1.ServerSpec{
+				Accepted:      true,
+				ManagementAPI: &metalv1alpha1.ManagementAPI{Endpoint: "127.0.0.1:1"},
+			},
+		},
+		{
+			name:         "no power management",
+			spec:         metalv1alpha1.ServerSpec{Accepted: true},
+			manualAction: metalv1alpha1.InventoryRefreshRequiredReason,
+		},
+		{
+			name:         "manual power management",
+			spec:         metalv1alpha1.ServerSpec{Accepted: true, ManualPowerManagement: true},
+			manualAction: "PowerCycleRequired",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, &metalv1alpha1.Server{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "server",
+					ResourceVersion: "1",
+				},
+				Spec: tt.spec,
+				Status: metalv1alpha1.ServerStatus{
+					IsClean:           true,
+					LastInventoryTime: &inventoried,
+				},
+			})
+
+			r := &ServerReconciler{
+				Client:           c,
+				Log:              log.NullLogger{},
+				Scheme:           scheme,
+				APIReader:        c,
+				Recorder:         record.NewFakeRecorder(100),
+				RebootTimeout:    time.Minute,
+				InventoryTTL:     time.Hour,
+				InventoryRefresh: true,
+			}
+
+			ctx := context.Background()
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "server"}}
+
+			if _, err := r.reconcile(req); err != nil {
+				t.Fatal(err)
+			}
+
+			var s metalv1alpha1.Server
+
+			if err := c.Get(ctx, req.NamespacedName, &s); err != nil {
+				t.Fatal(err)
+			}
+
+			if s.Status.IsClean {
+				t.Error("server with stale inventory is not wiped")
+			}
+
+			if reason := conditions.GetReason(&s, metalv1alpha1.ConditionManualPowerAction); reason != tt.manualAction {
+				t.Errorf("manual action = %q, want %q", reason, tt.manualAction)
+			}
+
+			if tt.manualAction != metalv1alpha1.InventoryRefreshRequiredReason {
+				return
+			}
+
+			// operator reboots the server, agent records the inventory and wipes the server
+			now := metav1.Now()
+
+			s.Status.LastInventoryTime = &now
+			s.Status.IsClean = true
+
+			if err := c.Status().Update(ctx, &s); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := r.reconcile(req); err != nil {
+				t.Fatal(err)
+			}
+
+			var refreshed metalv1alpha1.Server
+
+			if err := c.Get(ctx, req.NamespacedName, &refreshed); err != nil {
+				t.Fatal(err)
+			}
+
+			if conditions.Has(&refreshed, metalv1alpha1.ConditionManualPowerAction) || conditions.Has(&refreshed, metalv1alpha1.ConditionStale) {
+				t.Error("conditions are not cleared once the inventory is refreshed")
+			}
+		})
+	}
+}
+
+func TestServerReconcilerWipeStalled(t *testing.T) {
+	started := func(ago time.Duration) capiv1.Conditions {
+		return capiv1.Conditions{
+			{
+				Type:               metalv1alpha1.ConditionPowerCycle,
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-ago)),
+			},
+			{
+				Type:               metalv1alpha1.ConditionStalled,
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-ago)),
+			},
+		}
+	}
+
+	for _, tt := range []struct {
+		name               string
+		wipeTimeout        time.Duration
+		serverWipeTimeout  *metav1.Duration
+		conditions         capiv1.Conditions
+		attempts           int
+		expectQuarantined  bool
+		expectAttempts     int
+		expectStalled      *corev1.ConditionStatus
+		expectRetried      bool
+		expectEventsLength int
+	}{
+		{
+			name:       "timeout disabled",
+			conditions: started(time.Hour),
+		},
+		{
+			name:        "wipe not started",
+			wipeTimeout: 30 * time.Minute,
+		},
+		{
+			name:          "within the timeout",
+			wipeTimeout:   30 * time.Minute,
+			conditions:    started(time.Minute),
+			expectStalled: conditionStatus(corev1.ConditionFalse),
+		},
+		{
+			name:               "timed out",
+			wipeTimeout:        30 * time.Minute,
+			conditions:         started(time.Hour),
+			expectAttempts:     1,
+			expectRetried:      true,
+			expectEventsLength: 1,
+		},
+		{
+			name:               "server timeout override",
+			serverWipeTimeout:  &metav1.Duration{Duration: 30 * time.Minute},
+			conditions:         started(time.Hour),
+			expectAttempts:     1,
+			expectRetried:      true,
+			expectEventsLength: 1,
+		},
+		{
+			name:              "server timeout override within the timeout",
+			wipeTimeout:       30 * time.Minute,
+			serverWipeTimeout: &metav1.Duration{Duration: 2 * time.Hour},
+			conditions:        started(time.Hour),
+			expectStalled:     conditionStatus(corev1.ConditionFalse),
+		},
+		{
+			name:               "retries exhausted",
+			wipeTimeout:        30 * time.Minute,
+			conditions:         started(time.Hour),
+			attempts:           1,
+			expectQuarantined:  true,
+			expectAttempts:     2,
+			expectStalled:      conditionStatus(corev1.ConditionTrue),
+			expectEventsLength: 1,
+		},
+		{
+			name:        "quarantined",
+			wipeTimeout: 30 * time.Minute,
+			conditions: capiv1.Conditions{
+				{
+					Type:   metalv1alpha1.ConditionStalled,
+					Status: corev1.ConditionTrue,
+					Reason: metalv1alpha1.WipeTimeoutReason,
+				},
+			},
+			attempts:          2,
+			expectQuarantined: true,
+			expectAttempts:    2,
+			expectStalled:     conditionStatus(corev1.ConditionTrue),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(100)
+
+			r := &ServerReconciler{
+				Log:         log.NullLogger{},
+				Recorder:    recorder,
+				WipeTimeout: tt.wipeTimeout,
+				WipeRetries: 1,
+			}
+
+			s := &metalv1alpha1.Server{
+				ObjectMeta: metav1.ObjectMeta{Name: "server"},
+				Spec: metalv1alpha1.ServerSpec{
+					WipeTimeout: tt.serverWipeTimeout,
+				},
+				Status: metalv1alpha1.ServerStatus{
+					Conditions:   tt.conditions,
+					WipeAttempts: tt.attempts,
+				},
+			}
+
+			powerCycled := conditions.Has(s, metalv1alpha1.ConditionPowerCycle)
+
+			if quarantined := r.checkWipeStalled(s, &corev1.ObjectReference{Kind: "Server", Name: "server"}); quarantined != tt.expectQuarantined {
+				t.Errorf("quarantined = %v, want %v", quarantined, tt.expectQuarantined)
+			}
+
+			if s.Status.WipeAttempts != tt.expectAttempts {
+				t.Errorf("wipe attempts = %d, want %d", s.Status.WipeAttempts, tt.expectAttempts)
+			}
+
+			if tt.expectStalled == nil {
+				if conditions.Has(s, metalv1alpha1.ConditionStalled) {
+					t.Errorf("Stalled condition is not removed: %v", conditions.Get(s, metalv1alpha1.ConditionStalled))
+				}
+			} else if stalled := conditions.Get(s, metalv1alpha1.ConditionStalled); stalled == nil || stalled.Status != *tt.expectStalled {
+				t.Errorf("Stalled condition = %v, want status %s", stalled, *tt.expectStalled)
+			}
+
+			// timed out attempt is retried with a new power cycle
+			if conditions.Has(s, metalv1alpha1.ConditionPowerCycle) != (powerCycled && !tt.expectRetried) {
+				t.Errorf("PowerCycle condition = %v, want retried = %v", conditions.Get(s, metalv1alpha1.ConditionPowerCycle), tt.expectRetried)
+			}
+
+			if len(recorder.Events) != tt.expectEventsLength {
+				t.Errorf("%d events recorded, want %d", len(recorder.Events), tt.expectEventsLength)
+			}
+		})
+	}
+}
+
+func conditionStatus(status corev1.ConditionStatus) *corev1.ConditionStatus {
+	return &status
+}
+
+func TestServerReconcilerAllocationHistory(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		metalv1alpha1.AddToScheme,
+		infrav1.AddToScheme,
+		capiv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		name           string
+		remediated     bool
+		machineDeleted bool
+		expectedReason string
+	}{
+		{
+			name:           "released",
+			expectedReason: metalv1alpha1.ReleaseReasonReleased,
+		},
+		{
+			name:           "remediated",
+			remediated:     true,
+			expectedReason: metalv1alpha1.ReleaseReasonRemediated,
+		},
+		{
+			name:           "machine already deleted",
+			machineDeleted: true,
+			expectedReason: metalv1alpha1.ReleaseReasonReleased,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := &capiv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "worker-1"}}
+
+			if tt.remediated {
+				conditions.MarkFalse(machine, capiv1.MachineOwnerRemediatedCondition, capiv1.WaitingForRemediationReason, capiv1.ConditionSeverityWarning, "")
+			}
+
+			objs := []runtime.Object{
+				&infrav1.MetalMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:       "default",
+						Name:            "worker-1-abcde",
+						Labels:          map[string]string{capiv1.ClusterLabelName: "management"},
+						OwnerReferences: []metav1.OwnerReference{{APIVersion: capiv1.GroupVersion.String(), Kind: "Machine", Name: "worker-1"}},
+					},
+					Spec: infrav1.MetalMachineSpec{
+						ServerRef:      &corev1.ObjectReference{Kind: "Server", Name: "server"},
+						ServerClassRef: &corev1.ObjectReference{Kind: "ServerClass", Name: "workers"},
+					},
+				},
+			}
+
+			if !tt.machineDeleted {
+				objs = append(objs, machine)
+			}
+
+			r := &ServerReconciler{
+				Client:                fake.NewFakeClientWithScheme(scheme, objs...),
+				Log:                   log.NullLogger{},
+				Scheme:                scheme,
+				Recorder:              record.NewFakeRecorder(100),
+				AllocationHistorySize: 10,
+			}
+
+			ctx := context.Background()
+
+			s := &metalv1alpha1.Server{ObjectMeta: metav1.ObjectMeta{Name: "server"}}
+
+			if err := r.recordAllocation(ctx, s); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(s.Status.AllocationHistory) != 1 {
+				t.Fatalf("allocation history %v", s.Status.AllocationHistory)
+			}
+
+			allocation := s.Status.AllocationHistory[0]
+
+			if allocation.Cluster != "management" || allocation.Namespace != "default" || allocation.Machine != "worker-1" ||
+				allocation.MetalMachine != "worker-1-abcde" || allocation.ServerClass != "workers" {
+				t.Errorf("allocation record %+v", allocation)
+			}
+
+			if allocation.AllocatedAt.IsZero() || allocation.ReleasedAt != nil {
+				t.Errorf("allocation record times %v - %v", allocation.AllocatedAt, allocation.ReleasedAt)
+			}
+
+			if err := r.recordRelease(ctx, s); err != nil {
+				t.Fatal(err)
+			}
+
+			released := s.Status.AllocationHistory[0]
+
+			if released.ReleasedAt == nil {
+				t.Fatal("release is not recorded")
+			}
+
+			if released.ReleaseReason != tt.expectedReason {
+				t.Errorf("release reason = %q, want %q", released.ReleaseReason, tt.expectedReason)
+			}
+
+			// repeated release keeps the recorded one
+			releasedAt := *released.ReleasedAt
+
+			if err := r.recordRelease(ctx, s); err != nil {
+				t.Fatal(err)
+			}
+
+			if !s.Status.AllocationHistory[0].ReleasedAt.Equal(&releasedAt) {
+				t.Errorf("release time changed to %v", s.Status.AllocationHistory[0].ReleasedAt)
+			}
+		})
+	}
+}
+
+func TestServerReconcilerTrimAllocationHistory(t *testing.T) {
+	allocation := func(machine string, released *metav1.Time) metalv1alpha1.AllocationRecord {
+		return metalv1alpha1.AllocationRecord{Machine: machine, ReleasedAt: released}
+	}
+
+	first := metav1.NewTime(time.Now().Add(-3 * time.Hour))
+	second := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	earlier := metav1.NewTime(time.Now().Add(-4 * time.Hour))
+
+	for _, tt := range []struct {
+		name             string
+		size             int
+		history          []metalv1alpha1.AllocationRecord
+		trimmedUntil     *metav1.Time
+		expectedMachines []string
+		expectedTrimmed  *metav1.Time
+	}{
+		{
+			name:             "within the limit",
+			size:             3,
+			history:          []metalv1alpha1.AllocationRecord{allocation("a", &first), allocation("b", nil)},
+			expectedMachines: []string{"a", "b"},
+		},
+		{
+			name:             "unlimited",
+			history:          []metalv1alpha1.AllocationRecord{allocation("a", &first), allocation("b", &second), allocation("c", nil)},
+			expectedMachines: []string{"a", "b", "c"},
+		},
+		{
+			name:             "limit lowered",
+			size:             1,
+			history:          []metalv1alpha1.AllocationRecord{allocation("a", &first), allocation("b", &second), allocation("c", nil)},
+			expectedMachines: []string{"c"},
+			expectedTrimmed:  &second,
+		},
+		{
+			name:             "trimmed before",
+			size:             2,
+			history:          []metalv1alpha1.AllocationRecord{allocation("a", &first), allocation("b", &second), allocation("c", nil)},
+			trimmedUntil:     &earlier,
+			expectedMachines: []string{"b", "c"},
+			expectedTrimmed:  &first,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ServerReconciler{AllocationHistorySize: tt.size}
+
+			s := &metalv1alpha1.Server{
+				Status: metalv1alpha1.ServerStatus{
+					AllocationHistory:             tt.history,
+					AllocationHistoryTrimmedUntil: tt.trimmedUntil,
+				},
+			}
+
+			r.trimAllocationHistory(s)
+
+			machines := make([]string, 0, len(s.Status.AllocationHistory))
+
+			for _, record := range s.Status.AllocationHistory {
+				machines = append(machines, record.Machine)
+			}
+
+			if !reflect.DeepEqual(machines, tt.expectedMachines) {
+				t.Errorf("allocation history %v, want %v", machines, tt.expectedMachines)
+			}
+
+			expectedTrimmed := tt.expectedTrimmed
+			if expectedTrimmed == nil {
+				expectedTrimmed = tt.trimmedUntil
+			}
+
+			if !reflect.DeepEqual(s.Status.AllocationHistoryTrimmedUntil, expectedTrimmed) {
+				t.Errorf("trimmed until %v, want %v", s.Status.AllocationHistoryTrimmedUntil, expectedTrimmed)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// checkFreeze records whether the server is frozen in the condition, the freeze start and end are reported with the events.
+func (r *ServerReconciler) checkFreeze(s *metalv1alpha1.Server, serverRef *corev1.ObjectReference) (until time.Time, frozen bool) {
+	until, frozen, reason, err := s.FrozenUntil(time.Now())
+	if !frozen {
+		if conditions.IsTrue(s, metalv1alpha1.ConditionFrozen) {
+			r.Recorder.Event(serverRef, corev1.EventTypeNormal, "Server Freeze", "Server freeze ended.")
+		}
+
+		conditions.Delete(s, metalv1alpha1.ConditionFrozen)
+
+		return until, false
+	}
+
+	condition := &clusterv1.Condition{
+		Type:     metalv1alpha1.ConditionFrozen,
+		Status:   corev1.ConditionTrue,
+		Severity: clusterv1.ConditionSeverityInfo,
+		Reason:   metalv1alpha1.FreezeWindowActiveReason,
+		Message:  "Server is frozen until the freeze window is removed.",
+	}
+
+	switch {
+	case err != nil:
+		condition.Severity = clusterv1.ConditionSeverityWarning
+		condition.Reason = metalv1alpha1.FreezeWindowInvalidReason
+		condition.Message = fmt.Sprintf("Server is frozen, as the %s.", err)
+	case !until.IsZero():
+		condition.Message = fmt.Sprintf("Server is frozen until %s.", until.UTC().Format(time.RFC3339))
+	}
+
+	if reason != "" {
+		condition.Message = fmt.Sprintf("%s Reason: %s.", condition.Message, reason)
+	}
+
+	if conditions.GetMessage(s, metalv1alpha1.ConditionFrozen) != condition.Message {
+		eventType := corev1.EventTypeNormal
+		if err != nil {
+			eventType = corev1.EventTypeWarning
+		}
+
+		r.Recorder.Event(serverRef, eventType, condition.Reason, condition.Message)
+	}
+
+	conditions.Set(s, condition)
+
+	return until, true
+}
+
+// freezeRequeue returns the delay until the freeze of the server starts or ends, zero if there's nothing to wait for.
+func freezeRequeue(s *metalv1alpha1.Server, until time.Time, frozen bool) time.Duration {
+	if frozen {
+		if until.IsZero() {
+			return 0
+		}
+
+		return time.Until(until)
+	}
+
+	next := s.NextFreeze(time.Now())
+	if next.IsZero() {
+		if len(s.Spec.FreezeWindows) > 0 {
+			// windows far in the future are checked daily
+			return 24 * time.Hour
+		}
+
+		return 0
+	}
+
+	return time.Until(next)
+}
@@ -0,0 +1,329 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	"k8s.io/utils/pointer"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	serverpkg "github.com/talos-systems/sidero/app/metal-controller-manager/internal/server"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/talos"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
+)
+
+// ServerAdoptionReconciler reconciles a ServerAdoption object.
+type ServerAdoptionReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serveradoptions,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serveradoptions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=metalmachines,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=serverbindings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;create
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *ServerAdoptionReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("serveradoption", req.NamespacedName)
+
+	var adoption metalv1alpha1.ServerAdoption
+
+	if err = r.Get(ctx, req.NamespacedName, &adoption); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if adoption.Status.Ready {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(&adoption, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		adoption.Status.Error = ""
+
+		if err != nil {
+			adoption.Status.Error = err.Error()
+		}
+
+		if e := patchHelper.Patch(ctx, &adoption); e != nil {
+			log.Error(e, "failed to patch serveradoption")
+
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
+	adoptionRef, err := reference.GetReference(r.Scheme, &adoption)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var server metalv1alpha1.Server
+
+	if adoption.Status.ServerRef == nil {
+		if err = r.registerServer(ctx, &adoption, &server); err != nil {
+			r.Recorder.Event(adoptionRef, corev1.EventTypeWarning, "Server Adoption", fmt.Sprintf("Failed to register server: %s.", err))
+
+			return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, err
+		}
+
+		adoption.Status.ServerRef = &corev1.ObjectReference{
+			Kind: "Server",
+			Name: server.Name,
+		}
+
+		r.Recorder.Event(adoptionRef, corev1.EventTypeNormal, "Server Adoption", fmt.Sprintf("Server %q registered.", server.Name))
+	} else if err = r.Get(ctx, types.NamespacedName{Name: adoption.Status.ServerRef.Name}, &server); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if adoption.Spec.Machine == nil {
+		adoption.Status.Ready = true
+
+		return ctrl.Result{}, nil
+	}
+
+	if adoption.Status.MachineRef == nil {
+		machine, err := r.createMachine(ctx, &adoption, &server)
+		if err != nil {
+			r.Recorder.Event(adoptionRef, corev1.EventTypeWarning, "Server Adoption", fmt.Sprintf("Failed to create machine: %s.", err))
+
+			return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, err
+		}
+
+		adoption.Status.MachineRef = &corev1.ObjectReference{
+			Kind:       "Machine",
+			APIVersion: capiv1.GroupVersion.String(),
+			Namespace:  machine.Namespace,
+			Name:       machine.Name,
+		}
+
+		r.Recorder.Event(adoptionRef, corev1.EventTypeNormal, "Server Adoption", fmt.Sprintf("Machine %q created.", machine.Name))
+	}
+
+	// server is accepted only once it is bound to the machine, as accepted unallocated servers get wiped
+	var serverBinding infrav1.ServerBinding
+
+	if err = r.Get(ctx, types.NamespacedName{Name: server.Name}, &serverBinding); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("waiting for the server to be bound", "server", server.Name)
+
+			return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	serverPatchHelper, err := patch.NewHelper(&server, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	server.Spec.Accepted = true
+
+	// node is already installed, so it should never be PXE booted into the cluster environment
+	conditions.MarkTrue(&server, metalv1alpha1.ConditionAdopted)
+
+	if err = serverPatchHelper.Patch(ctx, &server, patch.WithOwnedConditions{
+		Conditions: []capiv1.ConditionType{metalv1alpha1.ConditionAdopted},
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	adoption.Status.Ready = true
+
+	r.Recorder.Event(adoptionRef, corev1.EventTypeNormal, "Server Adoption", "Server adopted.")
+
+	return ctrl.Result{}, nil
+}
+
+// registerServer creates the Server from the hardware information reported by Talos.
+func (r *ServerAdoptionReconciler) registerServer(ctx context.Context, adoption *metalv1alpha1.ServerAdoption, server *metalv1alpha1.Server) error {
+	var secret corev1.Secret
+
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: adoption.Spec.TalosConfigSecretRef.Namespace, Name: adoption.Spec.TalosConfigSecretRef.Name}, &secret); err != nil {
+		return err
+	}
+
+	talosConfig, ok := secret.Data["talosconfig"]
+	if !ok {
+		return fmt.Errorf("secret %s/%s doesn't have talosconfig", secret.Namespace, secret.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	talosClient, err := talos.NewClient(ctx, adoption.Spec.Endpoint, talosConfig)
+	if err != nil {
+		return err
+	}
+
+	defer talosClient.Close() //nolint: errcheck
+
+	info, err := talosClient.HardwareInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = r.Get(ctx, types.NamespacedName{Name: info.UUID}, server); err == nil {
+		if server.Annotations[metalv1alpha1.AdoptedAnnotation] != adoption.Name {
+			return fmt.Errorf("server %q is already registered", info.UUID)
+		}
+
+		return nil
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	*server = metalv1alpha1.Server{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Server",
+			APIVersion: metalv1alpha1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: info.UUID,
+			Annotations: map[string]string{
+				metalv1alpha1.AdoptedAnnotation: adoption.Name,
+			},
+		},
+		Spec: metalv1alpha1.ServerSpec{
+			Hostname:          info.Hostname,
+			SystemInformation: &info.SystemInformation,
+			CPU:               &info.CPU,
+			// accepting the server makes Sidero wipe it, unless it is allocated
+			Accepted: false,
+		},
+	}
+
+	if adoption.Spec.Machine != nil {
+		// keep the running machine config, so that the node gets the same config if it is ever PXE booted
+		machineConfig, err := talosClient.MachineConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err = r.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: adoption.Spec.Machine.Namespace,
+				Name:      bootstrapDataSecretName(adoption),
+				Labels: map[string]string{
+					capiv1.ClusterLabelName: adoption.Spec.Machine.ClusterName,
+				},
+			},
+			Type: capiv1.ClusterSecretType,
+			Data: map[string][]byte{
+				"value": machineConfig,
+			},
+		}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	serverpkg.UpdateHardwareLabels(server)
+
+	return r.Create(ctx, server)
+}
+
+// createMachine creates the Machine and MetalMachine pair bound to the adopted server.
+func (r *ServerAdoptionReconciler) createMachine(ctx context.Context, adoption *metalv1alpha1.ServerAdoption, server *metalv1alpha1.Server) (*capiv1.Machine, error) {
+	spec := adoption.Spec.Machine
+
+	labels := map[string]string{
+		capiv1.ClusterLabelName: spec.ClusterName,
+	}
+
+	if spec.ControlPlane {
+		labels[capiv1.MachineControlPlaneLabelName] = ""
+	}
+
+	metalMachine := &infrav1.MetalMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: spec.Namespace,
+			Name:      adoption.Name,
+			Labels:    labels,
+		},
+		Spec: infrav1.MetalMachineSpec{
+			ServerRef: &corev1.ObjectReference{
+				Kind: "Server",
+				Name: server.Name,
+			},
+		},
+	}
+
+	if err := r.Create(ctx, metalMachine); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	machine := &capiv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: spec.Namespace,
+			Name:      adoption.Name,
+			Labels:    labels,
+		},
+		Spec: capiv1.MachineSpec{
+			ClusterName: spec.ClusterName,
+			Version:     pointer.StringPtr(spec.Version),
+			Bootstrap: capiv1.Bootstrap{
+				DataSecretName: pointer.StringPtr(bootstrapDataSecretName(adoption)),
+			},
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "MetalMachine",
+				Namespace:  spec.Namespace,
+				Name:       metalMachine.Name,
+			},
+		},
+	}
+
+	if err := r.Create(ctx, machine); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	return machine, nil
+}
+
+func bootstrapDataSecretName(adoption *metalv1alpha1.ServerAdoption) string {
+	return adoption.Name + "-bootstrap-data"
+}
+
+func (r *ServerAdoptionReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&metalv1alpha1.ServerAdoption{}).
+		Complete(r)
+}
@@ -0,0 +1,148 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestServerAdoptionReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		corev1.AddToScheme,
+		metalv1alpha1.AddToScheme,
+		infrav1.AddToScheme,
+		capiv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "server",
+			ResourceVersion: "1",
+			Annotations: map[string]string{
+				metalv1alpha1.AdoptedAnnotation: "brownfield",
+			},
+		},
+	}
+
+	machine := &metalv1alpha1.AdoptionMachine{
+		ClusterName: "brownfield",
+		Namespace:   "default",
+		Version:     "v1.20.5",
+	}
+
+	for _, tt := range []struct {
+		name          string
+		machine       *metalv1alpha1.AdoptionMachine
+		bound         bool
+		expectedReady bool
+	}{
+		{
+			name:          "registration only",
+			expectedReady: true,
+		},
+		{
+			name:    "waiting for the binding",
+			machine: machine,
+		},
+		{
+			name:          "bound",
+			machine:       machine,
+			bound:         true,
+			expectedReady: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			adoption := &metalv1alpha1.ServerAdoption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "brownfield",
+					ResourceVersion: "1",
+				},
+				Spec: metalv1alpha1.ServerAdoptionSpec{
+					Machine: tt.machine,
+				},
+				Status: metalv1alpha1.ServerAdoptionStatus{
+					// server is already registered, so Talos API is not queried
+					ServerRef: &corev1.ObjectReference{Kind: "Server", Name: "server"},
+				},
+			}
+
+			if tt.machine != nil {
+				adoption.Status.MachineRef = &corev1.ObjectReference{Kind: "Machine", Namespace: "default", Name: "brownfield"}
+			}
+
+			objects := []runtime.Object{adoption, server.DeepCopy()}
+
+			if tt.bound {
+				objects = append(objects, &infrav1.ServerBinding{ObjectMeta: metav1.ObjectMeta{Name: "server"}})
+			}
+
+			c := fake.NewFakeClientWithScheme(scheme, objects...)
+
+			r := &ServerAdoptionReconciler{
+				Client:    c,
+				Log:       log.NullLogger{},
+				Scheme:    scheme,
+				APIReader: c,
+				Recorder:  record.NewFakeRecorder(100),
+			}
+
+			if _, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "brownfield"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+
+			var updated metalv1alpha1.ServerAdoption
+
+			if err := c.Get(ctx, types.NamespacedName{Name: "brownfield"}, &updated); err != nil {
+				t.Fatal(err)
+			}
+
+			if updated.Status.Ready != tt.expectedReady {
+				t.Errorf("ready = %v, want %v", updated.Status.Ready, tt.expectedReady)
+			}
+
+			var s metalv1alpha1.Server
+
+			if err := c.Get(ctx, types.NamespacedName{Name: "server"}, &s); err != nil {
+				t.Fatal(err)
+			}
+
+			if s.Spec.Accepted != tt.bound {
+				t.Errorf("accepted = %v, want %v", s.Spec.Accepted, tt.bound)
+			}
+
+			if conditions.IsTrue(&s, metalv1alpha1.ConditionAdopted) != tt.bound {
+				t.Errorf("adopted condition = %v, want %v", conditions.IsTrue(&s, metalv1alpha1.ConditionAdopted), tt.bound)
+			}
+
+			if conditions.Has(&s, metalv1alpha1.ConditionPXEBooted) {
+				t.Error("PXEBooted condition owned by the ServerReconciler is set by the adoption")
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+var (
+	serverClassFreeServers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sidero_serverclass_servers_free",
+		Help: "Number of the free servers of the serverclass.",
+	}, []string{"serverclass"})
+	serverClassCapacityLow = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sidero_serverclass_capacity_low",
+		Help: "Whether the number of the free servers of the serverclass is below the minimum available.",
+	}, []string{"serverclass"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(serverClassFreeServers, serverClassCapacityLow)
+}
+
+// ServerClassCapacityReconciler forecasts the ServerClass consumption by the MachineDeployments,
+// and watches the ServerClass free servers against the minimum available.
+type ServerClassCapacityReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// deploymentDemand is the number of the servers the MachineDeployment still needs from the serverclass.
+type deploymentDemand struct {
+	name    string
+	servers int
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclasses/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=metalmachinetemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=serverbindings,verbs=get;list;watch
+
+func (r *ServerClassCapacityReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("serverclass", req.NamespacedName)
+
+	var sc metalv1alpha1.ServerClass
+
+	if err := r.Get(ctx, req.NamespacedName, &sc); err != nil {
+		if apierrors.IsNotFound(err) {
+			serverClassFreeServers.DeleteLabelValues(req.Name)
+			serverClassCapacityLow.DeleteLabelValues(req.Name)
+		}
+
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	patchHelper, err := patch.NewHelper(&sc, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	demands, err := r.demands(ctx, &sc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	insufficientChanged := r.setCapacityInsufficient(log, &sc, demands)
+	lowChanged := r.setCapacityLow(log, &sc)
+
+	if !insufficientChanged && !lowChanged {
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, patchHelper.Patch(ctx, &sc)
+}
+
+// setCapacityInsufficient sets the CapacityInsufficient condition if the MachineDeployments need more servers than available.
+//
+// It returns true if the condition was changed.
+func (r *ServerClassCapacityReconciler) setCapacityInsufficient(log logr.Logger, sc *metalv1alpha1.ServerClass, demands []deploymentDemand) bool {
+	needed := 0
+
+	for _, demand := range demands {
+		needed += demand.servers
+	}
+
+	available := len(sc.Status.ServersAvailable) - sc.Spec.RemediationSpares
+	if available < 0 {
+		available = 0
+	}
+
+	shortfall := needed - available
+
+	if shortfall <= 0 {
+		if !conditions.Has(sc, metalv1alpha1.ConditionCapacityInsufficient) {
+			return false
+		}
+
+		conditions.Delete(sc, metalv1alpha1.ConditionCapacityInsufficient)
+
+		log.Info("serverclass capacity is sufficient again")
+
+		return true
+	}
+
+	deployments := make([]string, 0, len(demands))
+
+	for _, demand := range demands {
+		deployments = append(deployments, fmt.Sprintf("%s (%d)", demand.name, demand.servers))
+	}
+
+	message := fmt.Sprintf("MachineDeployments need %d more servers, %d available, shortfall is %d: %s.",
+		needed, available, shortfall, strings.Join(deployments, ", "))
+
+	if conditions.GetMessage(sc, metalv1alpha1.ConditionCapacityInsufficient) == message {
+		return false
+	}
+
+	conditions.Set(sc, &capiv1.Condition{
+		Type:     metalv1alpha1.ConditionCapacityInsufficient,
+		Status:   corev1.ConditionTrue,
+		Severity: capiv1.ConditionSeverityWarning,
+		Reason:   metalv1alpha1.CapacityShortfallReason,
+		Message:  message,
+	})
+
+	log.Info("serverclass capacity is insufficient", "needed", needed, "available", available, "shortfall", shortfall)
+
+	return true
+}
+
+// setCapacityLow sets the CapacityLow condition if the number of the free servers drops below the minimum available.
+//
+// Servers still being wiped are not free. It returns true if the condition was changed.
+func (r *ServerClassCapacityReconciler) setCapacityLow(log logr.Logger, sc *metalv1alpha1.ServerClass) bool {
+	free := len(sc.Status.ServersAvailable) - len(sc.Status.ServersWipingInProgress)
+	low := sc.Spec.MinimumAvailable > 0 && free < sc.Spec.MinimumAvailable
+
+	serverClassFreeServers.WithLabelValues(sc.Name).Set(float64(free))
+
+	if low {
+		serverClassCapacityLow.WithLabelValues(sc.Name).Set(1)
+	} else {
+		serverClassCapacityLow.WithLabelValues(sc.Name).Set(0)
+	}
+
+	if !low {
+		if !conditions.Has(sc, metalv1alpha1.ConditionCapacityLow) {
+			return false
+		}
+
+		conditions.Delete(sc, metalv1alpha1.ConditionCapacityLow)
+
+		log.Info("serverclass capacity is back to the minimum available", "free", free)
+		r.Recorder.Event(sc, corev1.EventTypeNormal, metalv1alpha1.CapacityLowReason,
+			fmt.Sprintf("Serverclass has %d free servers, minimum available is %d.", free, sc.Spec.MinimumAvailable))
+
+		return true
+	}
+
+	message := fmt.Sprintf("Serverclass has %d free servers, minimum available is %d.", free, sc.Spec.MinimumAvailable)
+
+	if conditions.GetMessage(sc, metalv1alpha1.ConditionCapacityLow) == message {
+		return false
+	}
+
+	if !conditions.Has(sc, metalv1alpha1.ConditionCapacityLow) {
+		// event is only emitted when the capacity drops below the minimum, not on every change of the free servers
+		r.Recorder.Event(sc, corev1.EventTypeWarning, metalv1alpha1.CapacityLowReason, message)
+	}
+
+	conditions.Set(sc, &capiv1.Condition{
+		Type:     metalv1alpha1.ConditionCapacityLow,
+		Status:   corev1.ConditionTrue,
+		Severity: capiv1.ConditionSeverityWarning,
+		Reason:   metalv1alpha1.CapacityLowReason,
+		Message:  message,
+	})
+
+	log.Info("serverclass capacity is low", "free", free, "minimumAvailable", sc.Spec.MinimumAvailable)
+
+	return true
+}
+
+// demands lists the MachineDeployments allocating from the serverclass which still need servers.
+//
+// Servers already allocated to the machines of the MachineDeployment (via the deployment name label propagated
+// to the ServerBindings) count towards its replicas.
+func (r *ServerClassCapacityReconciler) demands(ctx context.Context, sc *metalv1alpha1.ServerClass) ([]deploymentDemand, error) {
+	var (
+		deployments    capiv1.MachineDeploymentList
+		templates      infrav1.MetalMachineTemplateList
+		serverBindings infrav1.ServerBindingList
+	)
+
+	if err := r.List(ctx, &deployments); err != nil {
+		return nil, err
+	}
+
+	if err := r.List(ctx, &templates); err != nil {
+		return nil, err
+	}
+
+	if err := r.List(ctx, &serverBindings); err != nil {
+		return nil, err
+	}
+
+	classTemplates := map[types.NamespacedName]struct{}{}
+
+	for _, template := range templates.Items {
+		if ref := template.Spec.Template.Spec.ServerClassRef; ref != nil && ref.Name == sc.Name {
+			classTemplates[types.NamespacedName{Namespace: template.Namespace, Name: template.Name}] = struct{}{}
+		}
+	}
+
+	allocated := map[types.NamespacedName]int{}
+
+	for _, serverBinding := range serverBindings.Items {
+		if ref := serverBinding.Spec.ServerClassRef; ref == nil || ref.Name != sc.Name {
+			continue
+		}
+
+		if deployment, ok := serverBinding.Labels[capiv1.MachineDeploymentLabelName]; ok {
+			allocated[types.NamespacedName{Namespace: serverBinding.Spec.MetalMachineRef.Namespace, Name: deployment}]++
+		}
+	}
+
+	var demands []deploymentDemand
+
+	for _, deployment := range deployments.Items {
+		infraRef := deployment.Spec.Template.Spec.InfrastructureRef
+
+		if !deployment.DeletionTimestamp.IsZero() || infraRef.Kind != "MetalMachineTemplate" {
+			continue
+		}
+
+		if _, ok := classTemplates[types.NamespacedName{Namespace: deployment.Namespace, Name: infraRef.Name}]; !ok {
+			continue
+		}
+
+		replicas := 1
+		if deployment.Spec.Replicas != nil {
+			replicas = int(*deployment.Spec.Replicas)
+		}
+
+		key := types.NamespacedName{Namespace: deployment.Namespace, Name: deployment.Name}
+
+		if servers := replicas - allocated[key]; servers > 0 {
+			demands = append(demands, deploymentDemand{name: key.String(), servers: servers})
+		}
+	}
+
+	sort.Slice(demands, func(i, j int) bool { return demands[i].name < demands[j].name })
+
+	return demands, nil
+}
+
+func (r *ServerClassCapacityReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	// any MachineDeployment might reference any serverclass via its template
+	mapRequests := handler.ToRequestsFunc(
+		func(a handler.MapObject) []reconcile.Request {
+			var serverClasses metalv1alpha1.ServerClassList
+
+			if err := r.List(context.Background(), &serverClasses); err != nil {
+				return nil
+			}
+
+			reqList := make([]reconcile.Request, 0, len(serverClasses.Items))
+
+			for _, serverClass := range serverClasses.Items {
+				reqList = append(reqList, reconcile.Request{NamespacedName: types.NamespacedName{Name: serverClass.Name}})
+			}
+
+			return reqList
+		})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("serverclass-capacity").
+		WithOptions(options).
+		For(&metalv1alpha1.ServerClass{}).
+		Watches(
+			&source.Kind{Type: &capiv1.MachineDeployment{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: mapRequests,
+			},
+		).
+		Watches(
+			&source.Kind{Type: &infrav1.MetalMachineTemplate{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: mapRequests,
+			},
+		).
+		Complete(r)
+}
@@ -0,0 +1,300 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestServerClassCapacityReconcilerDemands(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		capiv1.AddToScheme,
+		infrav1.AddToScheme,
+		metalv1alpha1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	template := func(name, serverClass string) *infrav1.MetalMachineTemplate {
+		return &infrav1.MetalMachineTemplate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: infrav1.MetalMachineTemplateSpec{
+				Template: infrav1.MetalMachineTemplateResource{
+					Spec: infrav1.MetalMachineSpec{
+						ServerClassRef: &corev1.ObjectReference{Name: serverClass},
+					},
+				},
+			},
+		}
+	}
+
+	deployment := func(name, kind, template string, replicas *int32) *capiv1.MachineDeployment {
+		return &capiv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: capiv1.MachineDeploymentSpec{
+				ClusterName: "management",
+				Replicas:    replicas,
+				Template: capiv1.MachineTemplateSpec{
+					Spec: capiv1.MachineSpec{
+						ClusterName:       "management",
+						InfrastructureRef: corev1.ObjectReference{Kind: kind, Name: template},
+					},
+				},
+			},
+		}
+	}
+
+	serverBinding := func(name, serverClass, deployment string) *infrav1.ServerBinding {
+		return &infrav1.ServerBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{capiv1.MachineDeploymentLabelName: deployment},
+			},
+			Spec: infrav1.ServerBindingSpec{
+				ServerClassRef:  &corev1.ObjectReference{Name: serverClass},
+				MetalMachineRef: corev1.ObjectReference{Namespace: "default", Name: name},
+			},
+		}
+	}
+
+	deleting := deployment("deleting", "MetalMachineTemplate", "workers", pointer.Int32Ptr(2))
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+
+	r := &ServerClassCapacityReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme,
+			template("workers", "workers"),
+			template("storage", "storage"),
+			deployment("workers", "MetalMachineTemplate", "workers", pointer.Int32Ptr(3)),
+			deployment("default-replicas", "MetalMachineTemplate", "workers", nil),
+			deployment("satisfied", "MetalMachineTemplate", "workers", pointer.Int32Ptr(1)),
+			deployment("storage", "MetalMachineTemplate", "storage", pointer.Int32Ptr(5)),
+			deployment("docker", "DockerMachineTemplate", "workers", pointer.Int32Ptr(5)),
+			deleting,
+			serverBinding("a", "workers", "workers"),
+			serverBinding("b", "workers", "satisfied"),
+			serverBinding("c", "storage", "workers"),
+		),
+		Log:    log.NullLogger{},
+		Scheme: scheme,
+	}
+
+	demands, err := r.demands(context.Background(), &metalv1alpha1.ServerClass{ObjectMeta: metav1.ObjectMeta{Name: "workers"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []deploymentDemand{
+		{name: "default/default-replicas", servers: 1},
+		{name: "default/workers", servers: 2},
+	}
+
+	if !reflect.DeepEqual(demands, expected) {
+		t.Errorf("demands %+v, want %+v", demands, expected)
+	}
+}
+
+func TestServerClassCapacityReconcilerCapacityInsufficient(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		available       []string
+		spares          int
+		demands         []deploymentDemand
+		expectedMessage string
+	}{
+		{
+			name:      "no demands",
+			available: []string{"a"},
+		},
+		{
+			name:      "sufficient",
+			available: []string{"a", "b", "c"},
+			demands:   []deploymentDemand{{name: "default/workers", servers: 3}},
+		},
+		{
+			name:            "shortfall",
+			available:       []string{"a"},
+			demands:         []deploymentDemand{{name: "default/storage", servers: 1}, {name: "default/workers", servers: 2}},
+			expectedMessage: "MachineDeployments need 3 more servers, 1 available, shortfall is 2: default/storage (1), default/workers (2).",
+		},
+		{
+			name:            "spares are not available",
+			available:       []string{"a", "b"},
+			spares:          3,
+			demands:         []deploymentDemand{{name: "default/workers", servers: 1}},
+			expectedMessage: "MachineDeployments need 1 more servers, 0 available, shortfall is 1: default/workers (1).",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ServerClassCapacityReconciler{
+				Log: log.NullLogger{},
+			}
+
+			sc := &metalv1alpha1.ServerClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "workers"},
+				Spec:       metalv1alpha1.ServerClassSpec{RemediationSpares: tt.spares},
+				Status:     metalv1alpha1.ServerClassStatus{ServersAvailable: tt.available},
+			}
+
+			changed := r.setCapacityInsufficient(log.NullLogger{}, sc, tt.demands)
+
+			if changed != (tt.expectedMessage != "") {
+				t.Errorf("changed = %v", changed)
+			}
+
+			if tt.expectedMessage == "" {
+				if conditions.Has(sc, metalv1alpha1.ConditionCapacityInsufficient) {
+					t.Errorf("CapacityInsufficient condition = %v", conditions.Get(sc, metalv1alpha1.ConditionCapacityInsufficient))
+				}
+
+				return
+			}
+
+			condition := conditions.Get(sc, metalv1alpha1.ConditionCapacityInsufficient)
+			if condition == nil || condition.Reason != metalv1alpha1.CapacityShortfallReason || condition.Message != tt.expectedMessage {
+				t.Errorf("CapacityInsufficient condition = %v, want message %q", condition, tt.expectedMessage)
+			}
+
+			if r.setCapacityInsufficient(log.NullLogger{}, sc, tt.demands) {
+				t.Error("unchanged condition is reported as changed")
+			}
+
+			// servers were added to the serverclass
+			sc.Status.ServersAvailable = append(sc.Status.ServersAvailable, "d", "e", "f", "g", "h")
+
+			if !r.setCapacityInsufficient(log.NullLogger{}, sc, tt.demands) || conditions.Has(sc, metalv1alpha1.ConditionCapacityInsufficient) {
+				t.Error("CapacityInsufficient condition is not cleared")
+			}
+		})
+	}
+}
+
+func TestServerClassCapacityReconcilerCapacityLow(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		minimumAvailable int
+		available        []string
+		wiping           []string
+		expectedFree     float64
+		expectedMessage  string
+	}{
+		{
+			name:         "no minimum",
+			expectedFree: 0,
+		},
+		{
+			name:             "enough free servers",
+			minimumAvailable: 2,
+			available:        []string{"a", "b", "c"},
+			wiping:           []string{"c"},
+			expectedFree:     2,
+		},
+		{
+			name:             "wiping servers are not free",
+			minimumAvailable: 2,
+			available:        []string{"a", "b", "c"},
+			wiping:           []string{"b", "c"},
+			expectedFree:     1,
+			expectedMessage:  "Serverclass has 1 free servers, minimum available is 2.",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+
+			r := &ServerClassCapacityReconciler{
+				Log:      log.NullLogger{},
+				Recorder: recorder,
+			}
+
+			sc := &metalv1alpha1.ServerClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "capacity-low-test"},
+				Spec:       metalv1alpha1.ServerClassSpec{MinimumAvailable: tt.minimumAvailable},
+				Status: metalv1alpha1.ServerClassStatus{
+					ServersAvailable:        tt.available,
+					ServersWipingInProgress: tt.wiping,
+				},
+			}
+
+			defer serverClassFreeServers.DeleteLabelValues(sc.Name)
+			defer serverClassCapacityLow.DeleteLabelValues(sc.Name)
+
+			changed := r.setCapacityLow(log.NullLogger{}, sc)
+
+			if changed != (tt.expectedMessage != "") {
+				t.Errorf("changed = %v", changed)
+			}
+
+			if free := testutil.ToFloat64(serverClassFreeServers.WithLabelValues(sc.Name)); free != tt.expectedFree {
+				t.Errorf("free servers gauge = %v, want %v", free, tt.expectedFree)
+			}
+
+			if tt.expectedMessage == "" {
+				if conditions.Has(sc, metalv1alpha1.ConditionCapacityLow) || len(recorder.Events) > 0 {
+					t.Errorf("CapacityLow condition = %v", conditions.Get(sc, metalv1alpha1.ConditionCapacityLow))
+				}
+
+				if low := testutil.ToFloat64(serverClassCapacityLow.WithLabelValues(sc.Name)); low != 0 {
+					t.Errorf("capacity low gauge = %v", low)
+				}
+
+				return
+			}
+
+			condition := conditions.Get(sc, metalv1alpha1.ConditionCapacityLow)
+			if condition == nil || condition.Reason != metalv1alpha1.CapacityLowReason || condition.Message != tt.expectedMessage {
+				t.Errorf("CapacityLow condition = %v, want message %q", condition, tt.expectedMessage)
+			}
+
+			if low := testutil.ToFloat64(serverClassCapacityLow.WithLabelValues(sc.Name)); low != 1 {
+				t.Errorf("capacity low gauge = %v", low)
+			}
+
+			if event := <-recorder.Events; !strings.HasPrefix(event, corev1.EventTypeWarning) {
+				t.Errorf("event = %q", event)
+			}
+
+			// the event is emitted only when the capacity drops below the minimum
+			sc.Status.ServersWipingInProgress = []string{"a"}
+			sc.Status.ServersAvailable = []string{"a"}
+
+			if !r.setCapacityLow(log.NullLogger{}, sc) || len(recorder.Events) > 0 {
+				t.Error("free servers change is not handled")
+			}
+
+			sc.Status.ServersWipingInProgress = nil
+			sc.Status.ServersAvailable = []string{"a", "b", "c"}
+
+			if !r.setCapacityLow(log.NullLogger{}, sc) || conditions.Has(sc, metalv1alpha1.ConditionCapacityLow) {
+				t.Error("CapacityLow condition is not cleared")
+			}
+
+			if event := <-recorder.Events; !strings.HasPrefix(event, corev1.EventTypeNormal) {
+				t.Errorf("event = %q", event)
+			}
+		})
+	}
+}
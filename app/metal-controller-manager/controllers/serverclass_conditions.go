@@ -0,0 +1,128 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/expression"
+)
+
+// qualifierError describes why the qualifiers of the ServerClass can't be evaluated.
+type qualifierError struct {
+	reason  string
+	message string
+}
+
+// checkQualifiers returns the error of the qualifiers of the ServerClass, if any.
+func checkQualifiers(sc *metalv1alpha1.ServerClass, classes []metalv1alpha1.ServerClass) *qualifierError {
+	for _, source := range []string{sc.Spec.Qualifiers.Expression, exclusionExpression(sc.Spec.Qualifiers.Exclude)} {
+		if source == "" {
+			continue
+		}
+
+		if _, err := expression.Compile(source); err != nil {
+			return &qualifierError{
+				reason:  metalv1alpha1.InvalidExpressionReason,
+				message: fmt.Sprintf("Invalid qualifier expression: %s.", err),
+			}
+		}
+	}
+
+	var missing []string
+
+	for _, name := range sc.Spec.IncludeClasses {
+		found := false
+
+		for i := range classes {
+			if classes[i].Name == name {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &qualifierError{
+			reason:  metalv1alpha1.IncludedClassNotFoundReason,
+			message: fmt.Sprintf("Included ServerClasses %s don't exist, only the servers of the other included ServerClasses are matched.", strings.Join(missing, ", ")),
+		}
+	}
+
+	return nil
+}
+
+// setStatusConditions sets the Ready, QualifierError and CapacityAvailable conditions of the ServerClass,
+// it returns true if any of the conditions changed.
+func setStatusConditions(sc *metalv1alpha1.ServerClass, qualifierErr *qualifierError, avail, wiping []string) bool {
+	types := []clusterv1.ConditionType{clusterv1.ReadyCondition, metalv1alpha1.ConditionQualifierError, metalv1alpha1.ConditionCapacityAvailable}
+
+	old := make([]*clusterv1.Condition, len(types))
+
+	for i, t := range types {
+		old[i] = conditions.Get(sc, t)
+	}
+
+	switch {
+	case qualifierErr != nil:
+		conditions.Set(sc, &clusterv1.Condition{
+			Type:     metalv1alpha1.ConditionQualifierError,
+			Status:   corev1.ConditionTrue,
+			Severity: clusterv1.ConditionSeverityError,
+			Reason:   qualifierErr.reason,
+			Message:  qualifierErr.message,
+		})
+
+		conditions.MarkFalse(sc, clusterv1.ReadyCondition, qualifierErr.reason, clusterv1.ConditionSeverityError, "%s", qualifierErr.message)
+	case sc.IsProposed():
+		conditions.Delete(sc, metalv1alpha1.ConditionQualifierError)
+		conditions.MarkFalse(sc, clusterv1.ReadyCondition, metalv1alpha1.ServerClassProposedReason, clusterv1.ConditionSeverityInfo,
+			"ServerClass is proposed by the hardware profiler and not approved yet.")
+	default:
+		conditions.Delete(sc, metalv1alpha1.ConditionQualifierError)
+		conditions.MarkTrue(sc, clusterv1.ReadyCondition)
+	}
+
+	switch {
+	case len(avail) == 0:
+		conditions.MarkFalse(sc, metalv1alpha1.ConditionCapacityAvailable, metalv1alpha1.NoServersAvailableReason, clusterv1.ConditionSeverityWarning,
+			"No servers are available for the allocation.")
+	case len(wiping) == len(avail):
+		conditions.MarkFalse(sc, metalv1alpha1.ConditionCapacityAvailable, metalv1alpha1.ServersWipingReason, clusterv1.ConditionSeverityInfo,
+			"%d available servers are being wiped.", len(wiping))
+	default:
+		conditions.MarkTrue(sc, metalv1alpha1.ConditionCapacityAvailable)
+	}
+
+	changed := false
+
+	for i, t := range types {
+		if !sameCondition(old[i], conditions.Get(sc, t)) {
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// sameCondition compares the conditions ignoring the transition time.
+func sameCondition(a, b *clusterv1.Condition) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Status == b.Status && a.Reason == b.Reason && a.Severity == b.Severity && a.Message == b.Message
+}
@@ -0,0 +1,163 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func Test_checkQualifiers(t *testing.T) {
+	classes := []metalv1alpha1.ServerClass{
+		testClass("dell", labelQualifiers("vendor", "dell")),
+		testClass("hpe", labelQualifiers("vendor", "hpe")),
+	}
+
+	for _, tt := range []struct {
+		name            string
+		serverClass     metalv1alpha1.ServerClass
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:        "valid",
+			serverClass: testClass("any", metalv1alpha1.Qualifiers{Expression: "server.status.memory.size > 0"}, "dell", "hpe"),
+		},
+		{
+			name:           "invalid expression",
+			serverClass:    testClass("any", metalv1alpha1.Qualifiers{Expression: "server.status.memory.size >"}),
+			expectedReason: metalv1alpha1.InvalidExpressionReason,
+		},
+		{
+			name: "invalid exclusion expression",
+			serverClass: testClass("any", metalv1alpha1.Qualifiers{
+				Exclude: &metalv1alpha1.ExclusionQualifiers{Expression: "server.status.memory.size >"},
+			}),
+			expectedReason: metalv1alpha1.InvalidExpressionReason,
+		},
+		{
+			name:            "missing included classes",
+			serverClass:     testClass("any", metalv1alpha1.Qualifiers{}, "dell", "lenovo", "supermicro"),
+			expectedReason:  metalv1alpha1.IncludedClassNotFoundReason,
+			expectedMessage: "Included ServerClasses lenovo, supermicro don't exist, only the servers of the other included ServerClasses are matched.",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			qualifierErr := checkQualifiers(&tt.serverClass, classes)
+
+			if tt.expectedReason == "" {
+				if qualifierErr != nil {
+					t.Fatalf("unexpected qualifier error %+v", qualifierErr)
+				}
+
+				return
+			}
+
+			if qualifierErr == nil {
+				t.Fatal("qualifier error is not reported")
+			}
+
+			if qualifierErr.reason != tt.expectedReason {
+				t.Errorf("reason = %q, want %q", qualifierErr.reason, tt.expectedReason)
+			}
+
+			if tt.expectedMessage != "" && qualifierErr.message != tt.expectedMessage {
+				t.Errorf("message = %q, want %q", qualifierErr.message, tt.expectedMessage)
+			}
+		})
+	}
+}
+
+func Test_setStatusConditions(t *testing.T) {
+	for _, tt := range []struct {
+		name              string
+		proposed          bool
+		qualifierErr      *qualifierError
+		avail             []string
+		wiping            []string
+		expectedReady     string
+		expectedCapacity  string
+		expectedQualifier bool
+	}{
+		{
+			name:   "available servers",
+			avail:  []string{"a", "b"},
+			wiping: []string{"b"},
+		},
+		{
+			name:             "no servers",
+			expectedCapacity: metalv1alpha1.NoServersAvailableReason,
+		},
+		{
+			name:             "all servers wiping",
+			avail:            []string{"a"},
+			wiping:           []string{"a"},
+			expectedCapacity: metalv1alpha1.ServersWipingReason,
+		},
+		{
+			name:              "qualifier error",
+			qualifierErr:      &qualifierError{reason: metalv1alpha1.InvalidExpressionReason, message: "Invalid qualifier expression."},
+			expectedReady:     metalv1alpha1.InvalidExpressionReason,
+			expectedCapacity:  metalv1alpha1.NoServersAvailableReason,
+			expectedQualifier: true,
+		},
+		{
+			name:          "proposed",
+			proposed:      true,
+			avail:         []string{"a"},
+			expectedReady: metalv1alpha1.ServerClassProposedReason,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClass := testClass("workers", metalv1alpha1.Qualifiers{})
+
+			if tt.proposed {
+				serverClass.Annotations = map[string]string{metalv1alpha1.ProposedAnnotation: ""}
+			}
+
+			// start from the stale error to check that it's cleared
+			conditions.Set(&serverClass, &clusterv1.Condition{
+				Type:   metalv1alpha1.ConditionQualifierError,
+				Status: corev1.ConditionTrue,
+				Reason: metalv1alpha1.IncludedClassNotFoundReason,
+			})
+
+			if !setStatusConditions(&serverClass, tt.qualifierErr, tt.avail, tt.wiping) {
+				t.Error("conditions change is not reported")
+			}
+
+			if tt.expectedReady == "" {
+				if !conditions.IsTrue(&serverClass, clusterv1.ReadyCondition) {
+					t.Errorf("Ready condition = %v", conditions.Get(&serverClass, clusterv1.ReadyCondition))
+				}
+			} else if reason := conditions.GetReason(&serverClass, clusterv1.ReadyCondition); !conditions.IsFalse(&serverClass, clusterv1.ReadyCondition) || reason != tt.expectedReady {
+				t.Errorf("Ready condition = %v, want reason %q", conditions.Get(&serverClass, clusterv1.ReadyCondition), tt.expectedReady)
+			}
+
+			if tt.expectedCapacity == "" {
+				if !conditions.IsTrue(&serverClass, metalv1alpha1.ConditionCapacityAvailable) {
+					t.Errorf("CapacityAvailable condition = %v", conditions.Get(&serverClass, metalv1alpha1.ConditionCapacityAvailable))
+				}
+			} else if reason := conditions.GetReason(&serverClass, metalv1alpha1.ConditionCapacityAvailable); reason != tt.expectedCapacity {
+				t.Errorf("CapacityAvailable reason = %q, want %q", reason, tt.expectedCapacity)
+			}
+
+			if conditions.Has(&serverClass, metalv1alpha1.ConditionQualifierError) != tt.expectedQualifier {
+				t.Errorf("QualifierError condition = %v", conditions.Get(&serverClass, metalv1alpha1.ConditionQualifierError))
+			}
+
+			// transition time is ignored by the comparison
+			if setStatusConditions(&serverClass, tt.qualifierErr, tt.avail, tt.wiping) {
+				t.Error("unchanged conditions are reported as changed")
+			}
+		})
+	}
+}
@@ -8,19 +8,25 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/allocation"
 	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/expression"
 )
 
 // ServerClassReconciler reconciles a ServerClass object.
@@ -28,12 +34,42 @@ type ServerClassReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	// StatusUpdateInterval is the minimum interval between the status updates of a ServerClass.
+	//
+	// Servers changing within the interval (e.g. many servers registering at once) are batched into a single update.
+	StatusUpdateInterval time.Duration
+
+	// SnapshotNamespace is the namespace of the ConfigMaps with the serverclass match snapshots, empty disables the snapshots.
+	SnapshotNamespace string
+	// SnapshotHistory is the number of the snapshots kept per serverclass.
+	SnapshotHistory int
+	// APIReader reads the snapshots bypassing the cache, so that the ConfigMaps are not watched.
+	APIReader client.Reader
+
+	// Memberships enables the ServerClassMembership resources for each server listed in the serverclass status.
+	Memberships bool
+
+	lastUpdateMu sync.Mutex
+	lastUpdate   map[string]time.Time
+
+	lastSnapshotMu sync.Mutex
+	lastSnapshot   map[string]string
 }
 
 type serverFilter interface {
 	filterCPU([]metalv1alpha1.CPUInformation) serverFilter
 	filterSysInfo([]metalv1alpha1.SystemInformation) serverFilter
 	filterLabels([]map[string]string) serverFilter
+	filterLabelExpressions(*metalv1alpha1.Qualifiers) serverFilter
+	filterPCIDevices([]metalv1alpha1.PCIDeviceInformation) serverFilter
+	filterMemory(*metalv1alpha1.MemoryQualifier) serverFilter
+	filterDisks([]metalv1alpha1.DiskQualifier) serverFilter
+	filterNetworkInterfaces([]metalv1alpha1.NetworkInterfaceQualifier) serverFilter
+	filterGPUs([]metalv1alpha1.GPUQualifier) serverFilter
+	filterServerNames(*metalv1alpha1.Qualifiers) serverFilter
+	filterExpression(string) serverFilter
+	filterExclusions(*metalv1alpha1.ExclusionQualifiers) serverFilter
 	fetchItems() map[string]metalv1alpha1.Server
 }
 
@@ -56,6 +92,21 @@ func newServerFilter(sl *metalv1alpha1.ServerList) serverFilter {
 	return newSF
 }
 
+// newPendingServerFilter seeds the results with the servers which are not accepted yet.
+func newPendingServerFilter(sl *metalv1alpha1.ServerList) serverFilter {
+	newSF := &serverResults{
+		items: make(map[string]metalv1alpha1.Server),
+	}
+
+	for _, server := range sl.Items {
+		if !server.Spec.Accepted {
+			newSF.items[server.Name] = server
+		}
+	}
+
+	return newSF
+}
+
 func (sr *serverResults) filterCPU(filters []metalv1alpha1.CPUInformation) serverFilter {
 	if len(filters) == 0 {
 		return sr
@@ -133,10 +184,290 @@ func (sr *serverResults) filterLabels(filters []map[string]string) serverFilter
 	return sr
 }
 
+func (sr *serverResults) filterLabelExpressions(qualifiers *metalv1alpha1.Qualifiers) serverFilter {
+	if len(qualifiers.LabelExpressions) == 0 {
+		return sr
+	}
+
+	for _, server := range sr.items {
+		if !qualifiers.MatchesLabelExpressions(server.Labels) {
+			delete(sr.items, server.Name)
+		}
+	}
+
+	return sr
+}
+
+func (sr *serverResults) filterPCIDevices(filters []metalv1alpha1.PCIDeviceInformation) serverFilter {
+	if len(filters) == 0 {
+		return sr
+	}
+
+	for _, server := range sr.items {
+		for _, pciDevice := range filters {
+			pciDevice := pciDevice
+
+			var match bool
+
+			for i := range server.Status.PCIDevices {
+				if pciDevice.PartialEqual(&server.Status.PCIDevices[i].PCIDeviceInformation) {
+					match = true
+
+					break
+				}
+			}
+
+			if !match {
+				// Remove from results list since the server lacks one of the required devices
+				delete(sr.items, server.ObjectMeta.Name)
+
+				break
+			}
+		}
+	}
+
+	return sr
+}
+
+func (sr *serverResults) filterMemory(filter *metalv1alpha1.MemoryQualifier) serverFilter {
+	if filter == nil {
+		return sr
+	}
+
+	for _, server := range sr.items {
+		if !filter.Matches(server.Status.Memory) {
+			// Remove from results list since the server memory is out of the range
+			delete(sr.items, server.ObjectMeta.Name)
+		}
+	}
+
+	return sr
+}
+
+func (sr *serverResults) filterDisks(filters []metalv1alpha1.DiskQualifier) serverFilter {
+	if len(filters) == 0 {
+		return sr
+	}
+
+	for _, server := range sr.items {
+		for i := range filters {
+			if !filters[i].Matches(server.Status.Disks) {
+				// Remove from results list since the server lacks some of the required disks
+				delete(sr.items, server.ObjectMeta.Name)
+
+				break
+			}
+		}
+	}
+
+	return sr
+}
+
+func (sr *serverResults) filterNetworkInterfaces(filters []metalv1alpha1.NetworkInterfaceQualifier) serverFilter {
+	if len(filters) == 0 {
+		return sr
+	}
+
+	for _, server := range sr.items {
+		for i := range filters {
+			if !filters[i].Matches(server.Status.NetworkInterfaces) {
+				// Remove from results list since the server lacks some of the required network interfaces
+				delete(sr.items, server.ObjectMeta.Name)
+
+				break
+			}
+		}
+	}
+
+	return sr
+}
+
+func (sr *serverResults) filterGPUs(filters []metalv1alpha1.GPUQualifier) serverFilter {
+	if len(filters) == 0 {
+		return sr
+	}
+
+	for _, server := range sr.items {
+		for i := range filters {
+			if !filters[i].Matches(server.Spec.GPUs) {
+				// Remove from results list since the server lacks some of the required GPUs
+				delete(sr.items, server.ObjectMeta.Name)
+
+				break
+			}
+		}
+	}
+
+	return sr
+}
+
 func (sr *serverResults) fetchItems() map[string]metalv1alpha1.Server {
 	return sr.items
 }
 
+func (sr *serverResults) filterServerNames(qualifiers *metalv1alpha1.Qualifiers) serverFilter {
+	if len(qualifiers.ServerNames) == 0 {
+		return sr
+	}
+
+	for _, server := range sr.items {
+		server := server
+
+		if !qualifiers.MatchesServerName(&server) {
+			delete(sr.items, server.Name)
+		}
+	}
+
+	return sr
+}
+
+func (sr *serverResults) filterExpression(source string) serverFilter {
+	if source == "" {
+		return sr
+	}
+
+	// invalid expression doesn't match any servers, the error is logged by the reconciler
+	expr, err := expression.Compile(source)
+
+	for _, server := range sr.items {
+		server := server
+
+		if err != nil {
+			delete(sr.items, server.Name)
+
+			continue
+		}
+
+		if matched, evalErr := expr.Matches(&server); evalErr != nil || !matched {
+			delete(sr.items, server.Name)
+		}
+	}
+
+	return sr
+}
+
+// filterExclusions removes the servers matching the exclusion qualifiers.
+func (sr *serverResults) filterExclusions(exclude *metalv1alpha1.ExclusionQualifiers) serverFilter {
+	if exclude == nil || exclude.IsEmpty() {
+		return sr
+	}
+
+	excluded := &serverResults{
+		items: make(map[string]metalv1alpha1.Server, len(sr.items)),
+	}
+
+	for name, server := range sr.items {
+		excluded.items[name] = server
+	}
+
+	qualifiers := exclude.Qualifiers()
+	// expression is evaluated below, as the exclusion fails closed
+	qualifiers.Expression = ""
+
+	var expr *expression.Expression
+
+	if exclude.Expression != "" {
+		// invalid expression (nil expr) excludes all the servers, the error is reported in the QualifierError condition
+		expr, _ = expression.Compile(exclude.Expression)
+	}
+
+	for name, server := range filterQualifiers(excluded, &qualifiers).fetchItems() {
+		server := server
+
+		if expr != nil {
+			// evaluation errors exclude the server as well
+			if matched, evalErr := expr.Matches(&server); evalErr == nil && !matched {
+				continue
+			}
+		}
+
+		delete(sr.items, name)
+	}
+
+	return sr
+}
+
+func exclusionExpression(exclude *metalv1alpha1.ExclusionQualifiers) string {
+	if exclude == nil {
+		return ""
+	}
+
+	return exclude.Expression
+}
+
+// filterQualifiers filters the servers down to the ones matching all the qualifiers.
+func filterQualifiers(results serverFilter, qualifiers *metalv1alpha1.Qualifiers) serverFilter {
+	return results.
+		filterCPU(qualifiers.CPU).
+		filterSysInfo(qualifiers.SystemInformation).
+		filterLabels(qualifiers.LabelSelectors).
+		filterLabelExpressions(qualifiers).
+		filterPCIDevices(qualifiers.PCIDevices).
+		filterMemory(qualifiers.Memory).
+		filterDisks(qualifiers.Disks).
+		filterNetworkInterfaces(qualifiers.NetworkInterfaces).
+		filterGPUs(qualifiers.GPUs).
+		filterServerNames(qualifiers).
+		filterExpression(qualifiers.Expression).
+		filterExclusions(qualifiers.Exclude)
+}
+
+// filterServerClass filters the servers down to the ones matched by the serverclass, including the serverclasses it's composed of.
+func filterServerClass(results serverFilter, sc *metalv1alpha1.ServerClass, classes []metalv1alpha1.ServerClass) serverFilter {
+	return filterIncludedClasses(filterQualifiers(results, &sc.Spec.Qualifiers), sc, classes, map[string]struct{}{})
+}
+
+// filterIncludedClasses keeps the servers matched by any of the serverclasses included by the serverclass.
+//
+// Including lists the serverclasses being matched up the inclusion chain, so that the inclusion cycles match no servers.
+func filterIncludedClasses(results serverFilter, sc *metalv1alpha1.ServerClass, classes []metalv1alpha1.ServerClass, including map[string]struct{}) serverFilter {
+	if len(sc.Spec.IncludeClasses) == 0 {
+		return results
+	}
+
+	including[sc.Name] = struct{}{}
+	defer delete(including, sc.Name)
+
+	items := results.fetchItems()
+	included := make(map[string]struct{}, len(items))
+
+	for _, name := range sc.Spec.IncludeClasses {
+		if _, ok := including[name]; ok {
+			continue
+		}
+
+		for i := range classes {
+			other := &classes[i]
+
+			if other.Name != name {
+				continue
+			}
+
+			candidates := &serverResults{
+				items: make(map[string]metalv1alpha1.Server, len(items)),
+			}
+
+			for name, server := range items {
+				if _, ok := included[name]; !ok {
+					candidates.items[name] = server
+				}
+			}
+
+			for name := range filterIncludedClasses(filterQualifiers(candidates, &other.Spec.Qualifiers), other, classes, including).fetchItems() {
+				included[name] = struct{}{}
+			}
+		}
+	}
+
+	for name := range items {
+		if _, ok := included[name]; !ok {
+			delete(items, name)
+		}
+	}
+
+	return results
+}
+
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclasses,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclasses/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;create;update;patch;delete
@@ -165,77 +496,258 @@ func (r *ServerClassReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return ctrl.Result{}, fmt.Errorf("unable to get serverclass: %w", err)
 	}
 
+	// included serverclasses are matched as well, and the other serverclasses are checked for the overlaps
+	scList := &metalv1alpha1.ServerClassList{}
+
+	if err := r.List(ctx, scList); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to get serverclasses: %w", err)
+	}
+
+	qualifierErr := checkQualifiers(&sc, scList.Items)
+	if qualifierErr != nil {
+		l.Info("invalid qualifiers, no servers are matched", "reason", qualifierErr.reason, "message", qualifierErr.message)
+	}
+
 	// Create serverResults struct and seed items with all known, accepted servers
 	results := newServerFilter(sl)
 
 	// Filter servers down based on qualifiers
-	results = results.filterCPU(sc.Spec.Qualifiers.CPU)
-	results = results.filterSysInfo(sc.Spec.Qualifiers.SystemInformation)
-	results = results.filterLabels(sc.Spec.Qualifiers.LabelSelectors)
+	results = filterServerClass(results, &sc, scList.Items)
 
 	avail := []string{}
 	used := []string{}
 
+	var wiping, pending []string
+
 	for _, server := range results.fetchItems() {
+		server := server
+
 		if server.Status.InUse {
 			used = append(used, server.Name)
 			continue
 		}
 
+		if unavailableReason(&sc, &server) != "" {
+			continue
+		}
+
 		avail = append(avail, server.Name)
 	}
 
+	// servers matching the qualifiers, but not accepted yet, are only reported
+	for name := range filterServerClass(newPendingServerFilter(sl), &sc, scList.Items).fetchItems() {
+		pending = append(pending, name)
+	}
+
 	// sort lists to avoid spurious updates due to `map` key ordering
 	sort.Strings(avail)
 	sort.Strings(used)
+	sort.Strings(pending)
+
+	avail, pending = capServers(&sc, used, avail, pending)
+
+	for _, name := range avail {
+		if !results.fetchItems()[name].Status.IsClean {
+			wiping = append(wiping, name)
+		}
+	}
+
+	if r.SnapshotNamespace != "" {
+		if err := r.snapshot(ctx, &sc, sl, scList.Items); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error writing serverclass snapshot: %w", err)
+		}
+	}
+
+	if r.Memberships {
+		if err := r.syncMemberships(ctx, &sc, sl, membershipStates(avail, used, wiping, pending)); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error updating serverclass memberships: %w", err)
+		}
+	}
+
+	canary := sc.CanaryServers(append(append([]string(nil), avail...), used...))
+
+	overlapChanged := setOverlappingCondition(&sc, overlappingClasses(&sc, results.fetchItems(), scList.Items))
+	conditionsChanged := setStatusConditions(&sc, qualifierErr, avail, wiping)
+
+	if sameServers(sc.Status.ServersAvailable, avail) && sameServers(sc.Status.ServersInUse, used) && sameServers(sc.Status.ServersCanary, canary) &&
+		sameServers(sc.Status.ServersPendingAcceptance, pending) && sameServers(sc.Status.ServersWipingInProgress, wiping) && !overlapChanged && !conditionsChanged {
+		return ctrl.Result{}, nil
+	}
+
+	if wait := r.statusUpdateDelay(sc.Name); wait > 0 {
+		// more servers might change in the meantime, they are picked up by the delayed reconcile
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
 
 	sc.Status.ServersAvailable = avail
 	sc.Status.ServersInUse = used
+	sc.Status.ServersCanary = canary
+	sc.Status.ServersPendingAcceptance = pending
+	sc.Status.ServersPendingAcceptanceCount = len(pending)
+	sc.Status.ServersWipingInProgress = wiping
+	sc.Status.ServersWipingInProgressCount = len(wiping)
 
 	if err := patchHelper.Patch(ctx, &sc); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	r.lastUpdateMu.Lock()
+	r.lastUpdate[sc.Name] = time.Now()
+	r.lastUpdateMu.Unlock()
+
 	return ctrl.Result{}, nil
 }
 
-func (r *ServerClassReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
-	// This mapRequests handler allows us to add a watch on server resources. Upon a server resource update,
-	// we will dump all server classes and issue a reconcile against them so that they will get updated statuses
-	// for available/in-use servers that match.
-	mapRequests := handler.ToRequestsFunc(
-		func(a handler.MapObject) []reconcile.Request {
-			reqList := []reconcile.Request{}
+// unavailableReason returns the reason the matching server is not offered for allocation (if it's not in use), if any.
+func unavailableReason(sc *metalv1alpha1.ServerClass, server *metalv1alpha1.Server) string {
+	switch {
+	case sc.IsProposed():
+		// proposed serverclasses are not offered for allocation until approved
+		return allocation.ReasonProposed
+	case conditions.IsTrue(server, metalv1alpha1.ConditionCablingInvalid):
+		// mis-cabled servers are not offered for allocation
+		return allocation.ReasonCablingInvalid
+	case conditions.IsTrue(server, metalv1alpha1.ConditionDuplicateMAC):
+		// servers which can't be told apart are not offered for allocation
+		return allocation.ReasonDuplicateMAC
+	case server.IsFrozen(time.Now()):
+		// frozen servers are not reprovisioned until the freeze ends
+		return allocation.ReasonFrozen
+	case !sc.Tolerates(server):
+		// faulty servers are only offered by the serverclasses tolerating the faults
+		return allocation.ReasonFaulty
+	default:
+		return ""
+	}
+}
 
-			scList := &metalv1alpha1.ServerClassList{}
+// capServers limits the servers listed by the serverclass to MaxServers, in use servers are always listed.
+//
+// Available servers listed before are admitted first, so that the admitted servers don't change as the other matching servers
+// come and go, then the rest in the order of the names; servers pending acceptance are only listed if there are slots left.
+func capServers(sc *metalv1alpha1.ServerClass, used, avail, pending []string) (cappedAvail, cappedPending []string) {
+	if sc.Spec.MaxServers <= 0 {
+		return avail, pending
+	}
 
-			if err := r.List(context.Background(), scList); err != nil {
-				return reqList
-			}
+	slots := sc.Spec.MaxServers - len(used)
 
-			for _, serverClass := range scList.Items {
-				reqList = append(
-					reqList,
-					reconcile.Request{
-						NamespacedName: types.NamespacedName{
-							Name:      serverClass.Name,
-							Namespace: serverClass.Namespace,
-						},
-					},
-				)
-			}
+	listed := make(map[string]struct{}, len(sc.Status.ServersAvailable))
+
+	for _, name := range sc.Status.ServersAvailable {
+		listed[name] = struct{}{}
+	}
+
+	ordered := make([]string, 0, len(avail))
+
+	for _, name := range avail {
+		if _, ok := listed[name]; ok {
+			ordered = append(ordered, name)
+		}
+	}
+
+	for _, name := range avail {
+		if _, ok := listed[name]; !ok {
+			ordered = append(ordered, name)
+		}
+	}
+
+	cappedAvail = []string{}
+
+	for _, name := range ordered {
+		if len(cappedAvail) >= slots {
+			break
+		}
+
+		cappedAvail = append(cappedAvail, name)
+	}
+
+	sort.Strings(cappedAvail)
+
+	for _, name := range pending {
+		if len(cappedAvail)+len(cappedPending) >= slots {
+			break
+		}
+
+		cappedPending = append(cappedPending, name)
+	}
+
+	return cappedAvail, cappedPending
+}
+
+// statusUpdateDelay returns the time left until the status of the serverclass can be updated again.
+func (r *ServerClassReconciler) statusUpdateDelay(name string) time.Duration {
+	r.lastUpdateMu.Lock()
+	defer r.lastUpdateMu.Unlock()
+
+	if r.lastUpdate == nil {
+		r.lastUpdate = make(map[string]time.Time)
+	}
+
+	lastUpdate, ok := r.lastUpdate[name]
+	if !ok {
+		return 0
+	}
+
+	return r.StatusUpdateInterval - time.Since(lastUpdate)
+}
+
+// sameServers compares lists of the server names ignoring the order (nil and empty lists are equal).
+func sameServers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+
+	sort.Strings(a)
+	sort.Strings(b)
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
 
-			return reqList
-		})
+func (r *ServerClassReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	b := ctrl.NewControllerManagedBy(mgr)
+
+	if r.Memberships {
+		// removed or modified memberships are restored
+		b = b.Owns(&metalv1alpha1.ServerClassMembership{})
+	} else if err := mgr.Add(manager.RunnableFunc(func(<-chan struct{}) error {
+		// failed cleanup doesn't stop the controller, it's retried on the next start
+		if err := r.removeMemberships(context.Background()); err != nil {
+			r.Log.Error(err, "error removing serverclass memberships")
+		}
+
+		return nil
+	})); err != nil {
+		return err
+	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	return b.
 		WithOptions(options).
 		For(&metalv1alpha1.ServerClass{}).
+		// server changes only requeue the serverclasses the server belongs (or belonged) to
 		Watches(
 			&source.Kind{Type: &metalv1alpha1.Server{}},
 			&handler.EnqueueRequestsFromMapFunc{
-				ToRequests: mapRequests,
+				ToRequests: handler.ToRequestsFunc(r.affectedServerClasses),
+			},
+			builder.WithPredicates(serverMatchingChanged),
+		).
+		// qualifier and priority changes of a serverclass change the overlaps of the other serverclasses
+		Watches(
+			&source.Kind{Type: &metalv1alpha1.ServerClass{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: handler.ToRequestsFunc(r.allServerClasses),
 			},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
 		).
 		Complete(r)
 }
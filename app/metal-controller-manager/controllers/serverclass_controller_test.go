@@ -0,0 +1,309 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func testClass(name string, qualifiers metalv1alpha1.Qualifiers, includeClasses ...string) metalv1alpha1.ServerClass {
+	return metalv1alpha1.ServerClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: metalv1alpha1.ServerClassSpec{
+			Qualifiers:     qualifiers,
+			IncludeClasses: includeClasses,
+		},
+	}
+}
+
+func labelQualifiers(key, value string) metalv1alpha1.Qualifiers {
+	return metalv1alpha1.Qualifiers{
+		LabelSelectors: []map[string]string{{key: value}},
+	}
+}
+
+func Test_filterIncludedClasses(t *testing.T) {
+	servers := &metalv1alpha1.ServerList{}
+
+	for name, labels := range map[string]map[string]string{
+		"dell-ssd":  {"vendor": "dell", "disk": "ssd", "rack": "a"},
+		"dell-hdd":  {"vendor": "dell", "disk": "hdd", "rack": "b"},
+		"hpe-ssd":   {"vendor": "hpe", "disk": "ssd", "rack": "a"},
+		"hpe-hdd":   {"vendor": "hpe", "disk": "hdd", "rack": "b"},
+		"other-ssd": {"vendor": "other", "disk": "ssd", "rack": "a"},
+	} {
+		servers.Items = append(servers.Items, metalv1alpha1.Server{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+			Spec: metalv1alpha1.ServerSpec{
+				Accepted: true,
+			},
+		})
+	}
+
+	excludeRackB := metalv1alpha1.Qualifiers{
+		Exclude: &metalv1alpha1.ExclusionQualifiers{
+			LabelSelectors: []map[string]string{{"rack": "b"}},
+		},
+	}
+
+	classes := []metalv1alpha1.ServerClass{
+		testClass("dell", labelQualifiers("vendor", "dell")),
+		testClass("hpe", labelQualifiers("vendor", "hpe")),
+		testClass("dell-not-b", excludeRackB, "dell"),
+		testClass("cycle-a", metalv1alpha1.Qualifiers{}, "cycle-b"),
+		testClass("cycle-b", metalv1alpha1.Qualifiers{}, "cycle-a"),
+	}
+
+	for _, tt := range []struct {
+		name     string
+		class    metalv1alpha1.ServerClass
+		expected []string
+	}{
+		{
+			name:     "no includes",
+			class:    testClass("any", metalv1alpha1.Qualifiers{}),
+			expected: []string{"dell-hdd", "dell-ssd", "hpe-hdd", "hpe-ssd", "other-ssd"},
+		},
+		{
+			name:     "single include",
+			class:    testClass("vendors", metalv1alpha1.Qualifiers{}, "dell"),
+			expected: []string{"dell-hdd", "dell-ssd"},
+		},
+		{
+			name:     "union of includes",
+			class:    testClass("vendors", metalv1alpha1.Qualifiers{}, "dell", "hpe"),
+			expected: []string{"dell-hdd", "dell-ssd", "hpe-hdd", "hpe-ssd"},
+		},
+		{
+			name:     "includes and qualifiers",
+			class:    testClass("vendors-ssd", labelQualifiers("disk", "ssd"), "dell", "hpe"),
+			expected: []string{"dell-ssd", "hpe-ssd"},
+		},
+		{
+			name:     "includes and exclusion",
+			class:    testClass("vendors-not-b", excludeRackB, "dell", "hpe"),
+			expected: []string{"dell-ssd", "hpe-ssd"},
+		},
+		{
+			name:     "included class with exclusion",
+			class:    testClass("nested", metalv1alpha1.Qualifiers{}, "dell-not-b", "hpe"),
+			expected: []string{"dell-ssd", "hpe-hdd", "hpe-ssd"},
+		},
+		{
+			name: "exclusion of the included class",
+			class: testClass("nested-hdd", metalv1alpha1.Qualifiers{
+				Exclude: &metalv1alpha1.ExclusionQualifiers{
+					LabelSelectors: []map[string]string{{"disk": "ssd"}},
+				},
+			}, "dell-not-b"),
+		},
+		{
+			name:  "missing include",
+			class: testClass("missing", metalv1alpha1.Qualifiers{}, "missing"),
+		},
+		{
+			name:     "missing and existing includes",
+			class:    testClass("missing", metalv1alpha1.Qualifiers{}, "missing", "hpe"),
+			expected: []string{"hpe-hdd", "hpe-ssd"},
+		},
+		{
+			name:  "cycle",
+			class: classes[3],
+		},
+		{
+			name:  "self include",
+			class: testClass("dell", metalv1alpha1.Qualifiers{}, "dell"),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+
+			for name := range filterServerClass(newServerFilter(servers), &tt.class, classes).fetchItems() {
+				names = append(names, name)
+			}
+
+			sort.Strings(names)
+
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("matched servers = %v, want %v", names, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_filterExclusions(t *testing.T) {
+	servers := &metalv1alpha1.ServerList{
+		Items: []metalv1alpha1.Server{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "with-bmc", Labels: map[string]string{"rack": "a"}},
+				Spec:       metalv1alpha1.ServerSpec{Accepted: true, BMC: &metalv1alpha1.BMC{Endpoint: "10.0.0.25"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "without-bmc", Labels: map[string]string{"rack": "b"}},
+				Spec:       metalv1alpha1.ServerSpec{Accepted: true},
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		exclude  *metalv1alpha1.ExclusionQualifiers
+		expected []string
+	}{
+		{
+			name:     "no exclusion",
+			expected: []string{"with-bmc", "without-bmc"},
+		},
+		{
+			name:     "expression",
+			exclude:  &metalv1alpha1.ExclusionQualifiers{Expression: `server.metadata.labels.rack == "a"`},
+			expected: []string{"without-bmc"},
+		},
+		{
+			name: "expression and labels",
+			exclude: &metalv1alpha1.ExclusionQualifiers{
+				LabelSelectors: []map[string]string{{"rack": "b"}},
+				Expression:     `server.metadata.labels.rack == "a"`,
+			},
+			expected: []string{"with-bmc", "without-bmc"},
+		},
+		{
+			name:     "evaluation error",
+			exclude:  &metalv1alpha1.ExclusionQualifiers{Expression: `server.spec.bmc.endpoint == "10.0.0.26"`},
+			expected: []string{"with-bmc"},
+		},
+		{
+			name:    "invalid expression",
+			exclude: &metalv1alpha1.ExclusionQualifiers{Expression: `server.metadata.labels.rack ==`},
+		},
+		{
+			name: "invalid expression and labels",
+			exclude: &metalv1alpha1.ExclusionQualifiers{
+				LabelSelectors: []map[string]string{{"rack": "b"}},
+				Expression:     `server.metadata.labels.rack ==`,
+			},
+			expected: []string{"with-bmc"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+
+			for name := range newServerFilter(servers).filterExclusions(tt.exclude).fetchItems() {
+				names = append(names, name)
+			}
+
+			sort.Strings(names)
+
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("matched servers = %v, want %v", names, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_capServers(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		maxServers int
+		listed     []string
+		used       []string
+		avail      []string
+		pending    []string
+
+		expectedAvail   []string
+		expectedPending []string
+	}{
+		{
+			name:            "no cap",
+			avail:           []string{"a", "b", "c"},
+			pending:         []string{"d"},
+			expectedAvail:   []string{"a", "b", "c"},
+			expectedPending: []string{"d"},
+		},
+		{
+			name:          "cap in the order of the names",
+			maxServers:    2,
+			avail:         []string{"a", "b", "c"},
+			expectedAvail: []string{"a", "b"},
+		},
+		{
+			name:          "listed servers are admitted first",
+			maxServers:    2,
+			listed:        []string{"c", "b"},
+			avail:         []string{"a", "b", "c"},
+			expectedAvail: []string{"b", "c"},
+		},
+		{
+			name:          "listed server left",
+			maxServers:    2,
+			listed:        []string{"c", "d"},
+			avail:         []string{"a", "b", "c"},
+			expectedAvail: []string{"a", "c"},
+		},
+		{
+			name:          "used servers take the slots",
+			maxServers:    3,
+			used:          []string{"x", "y"},
+			avail:         []string{"a", "b", "c"},
+			expectedAvail: []string{"a"},
+		},
+		{
+			name:          "used servers over the cap",
+			maxServers:    2,
+			used:          []string{"x", "y", "z"},
+			avail:         []string{"a", "b"},
+			expectedAvail: []string{},
+		},
+		{
+			name:            "pending servers get the slots left",
+			maxServers:      3,
+			used:            []string{"x"},
+			avail:           []string{"a"},
+			pending:         []string{"p", "q"},
+			expectedAvail:   []string{"a"},
+			expectedPending: []string{"p"},
+		},
+		{
+			name:          "no slots for the pending servers",
+			maxServers:    2,
+			avail:         []string{"a", "b", "c"},
+			pending:       []string{"p"},
+			expectedAvail: []string{"a", "b"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &metalv1alpha1.ServerClass{
+				Spec: metalv1alpha1.ServerClassSpec{
+					MaxServers: tt.maxServers,
+				},
+				Status: metalv1alpha1.ServerClassStatus{
+					ServersAvailable: tt.listed,
+				},
+			}
+
+			avail, pending := capServers(sc, tt.used, tt.avail, tt.pending)
+
+			if !reflect.DeepEqual(avail, tt.expectedAvail) {
+				t.Errorf("available servers = %v, want %v", avail, tt.expectedAvail)
+			}
+
+			if !reflect.DeepEqual(pending, tt.expectedPending) {
+				t.Errorf("pending servers = %v, want %v", pending, tt.expectedPending)
+			}
+		})
+	}
+}
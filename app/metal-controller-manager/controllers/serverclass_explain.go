@@ -0,0 +1,110 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// ExplainNotAccepted is the exclusion reason of the server which is not accepted, such servers are not matched at all.
+const ExplainNotAccepted = "NotAccepted"
+
+// NewExplainHandler returns HTTP handler of the serverclass explain API.
+//
+// The response is the match snapshot of the serverclass computed on the fly, it lists the qualifiers each server fails.
+// Query parameters: serverclass, server (optional, explains a single server).
+func NewExplainHandler(c client.Reader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := r.URL.Query()
+
+		serverClassName := values.Get("serverclass")
+		if serverClassName == "" {
+			http.Error(w, "serverclass is required", http.StatusBadRequest)
+
+			return
+		}
+
+		serverName := values.Get("server")
+
+		var sc metalv1alpha1.ServerClass
+
+		if err := c.Get(r.Context(), types.NamespacedName{Name: serverClassName}, &sc); err != nil {
+			if apierrors.IsNotFound(err) {
+				http.Error(w, fmt.Sprintf("serverclass %q not found", serverClassName), http.StatusNotFound)
+
+				return
+			}
+
+			log.Printf("serverclass explain failed: %s", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		var sl metalv1alpha1.ServerList
+
+		if err := c.List(r.Context(), &sl); err != nil {
+			log.Printf("serverclass explain failed: %s", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		var scList metalv1alpha1.ServerClassList
+
+		if err := c.List(r.Context(), &scList); err != nil {
+			log.Printf("serverclass explain failed: %s", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		snapshot := buildSnapshot(&sc, &sl, scList.Items)
+
+		if serverName != "" {
+			result, ok := explainServer(snapshot, &sl, serverName)
+			if !ok {
+				http.Error(w, fmt.Sprintf("server %q not found", serverName), http.StatusNotFound)
+
+				return
+			}
+
+			snapshot.Servers = []SnapshotServer{result}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+			log.Printf("failed to write serverclass explanation: %s", err)
+		}
+	})
+}
+
+// explainServer picks the server from the snapshot, servers which are not accepted are not in the snapshot,
+// so they are reported as excluded.
+func explainServer(snapshot *Snapshot, sl *metalv1alpha1.ServerList, name string) (SnapshotServer, bool) {
+	for _, server := range snapshot.Servers {
+		if server.Name == name {
+			return server, true
+		}
+	}
+
+	for _, server := range sl.Items {
+		if server.Name == name {
+			return SnapshotServer{Name: name, Excluded: ExplainNotAccepted}, true
+		}
+	}
+
+	return SnapshotServer{}, false
+}
@@ -0,0 +1,124 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/allocation"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestExplainHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	server := func(name, vendor string, accepted bool) *metalv1alpha1.Server {
+		return &metalv1alpha1.Server{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"vendor": vendor}},
+			Spec:       metalv1alpha1.ServerSpec{Accepted: accepted},
+		}
+	}
+
+	duplicate := server("dell-duplicate", "dell", true)
+	duplicate.Status.Conditions = capiv1.Conditions{{Type: metalv1alpha1.ConditionDuplicateMAC, Status: corev1.ConditionTrue}}
+
+	dell := testClass("dell", labelQualifiers("vendor", "dell"))
+
+	handler := NewExplainHandler(fake.NewFakeClientWithScheme(scheme,
+		&dell,
+		server("dell-a", "dell", true),
+		server("hpe-b", "hpe", true),
+		server("dell-pending", "dell", false),
+		duplicate,
+	))
+
+	for _, tt := range []struct {
+		name            string
+		query           string
+		expectedStatus  int
+		expectedServers []SnapshotServer
+	}{
+		{
+			name:           "no serverclass",
+			query:          "",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing serverclass",
+			query:          "serverclass=hpe",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "all servers",
+			query:          "serverclass=dell",
+			expectedStatus: http.StatusOK,
+			expectedServers: []SnapshotServer{
+				{Name: "dell-a", Matched: true},
+				{Name: "dell-duplicate", Matched: true, Excluded: allocation.ReasonDuplicateMAC},
+				{Name: "hpe-b", Mismatched: []string{"labelSelectors"}},
+			},
+		},
+		{
+			name:            "mismatched server",
+			query:           "serverclass=dell&server=hpe-b",
+			expectedStatus:  http.StatusOK,
+			expectedServers: []SnapshotServer{{Name: "hpe-b", Mismatched: []string{"labelSelectors"}}},
+		},
+		{
+			name:            "not accepted server",
+			query:           "serverclass=dell&server=dell-pending",
+			expectedStatus:  http.StatusOK,
+			expectedServers: []SnapshotServer{{Name: "dell-pending", Excluded: ExplainNotAccepted}},
+		},
+		{
+			name:           "missing server",
+			query:          "serverclass=dell&server=missing",
+			expectedStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/explain?"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var snapshot Snapshot
+
+			if err := json.Unmarshal(w.Body.Bytes(), &snapshot); err != nil {
+				t.Fatal(err)
+			}
+
+			if snapshot.ServerClass != "dell" {
+				t.Errorf("serverclass = %q", snapshot.ServerClass)
+			}
+
+			if !reflect.DeepEqual(snapshot.Servers, tt.expectedServers) {
+				t.Errorf("servers %+v, want %+v", snapshot.Servers, tt.expectedServers)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// membershipStates maps the servers listed in the serverclass status to their membership states.
+func membershipStates(avail, used, wiping, pending []string) map[string]metalv1alpha1.MembershipState {
+	states := make(map[string]metalv1alpha1.MembershipState, len(avail)+len(used)+len(pending))
+
+	for _, name := range avail {
+		states[name] = metalv1alpha1.MembershipAvailable
+	}
+
+	for _, name := range wiping {
+		states[name] = metalv1alpha1.MembershipWiping
+	}
+
+	for _, name := range used {
+		states[name] = metalv1alpha1.MembershipInUse
+	}
+
+	for _, name := range pending {
+		states[name] = metalv1alpha1.MembershipPendingAcceptance
+	}
+
+	return states
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclassmemberships,verbs=get;list;watch;create;update;patch;delete
+
+// syncMemberships creates, updates and removes the memberships of the serverclass, so that they follow the states of the servers.
+//
+// Membership is owned by both the serverclass and the server, so that it's garbage collected along with either of them.
+func (r *ServerClassReconciler) syncMemberships(ctx context.Context, sc *metalv1alpha1.ServerClass, sl *metalv1alpha1.ServerList,
+	states map[string]metalv1alpha1.MembershipState) error {
+	var memberships metalv1alpha1.ServerClassMembershipList
+
+	if err := r.List(ctx, &memberships, client.MatchingLabels{metalv1alpha1.MembershipServerClassLabel: metalv1alpha1.MembershipLabelValue(sc.Name)}); err != nil {
+		return err
+	}
+
+	existing := make(map[string]*metalv1alpha1.ServerClassMembership, len(memberships.Items))
+
+	for i := range memberships.Items {
+		membership := &memberships.Items[i]
+
+		// shortened label values might be shared with another serverclass
+		if membership.Spec.ServerClass != sc.Name {
+			continue
+		}
+
+		state, ok := states[membership.Spec.Server]
+		if !ok {
+			if err := r.Delete(ctx, membership); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+
+			continue
+		}
+
+		existing[membership.Spec.Server] = membership
+
+		if membership.Status.State != state {
+			membership.Status.State = state
+
+			if err := r.Update(ctx, membership); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := range sl.Items {
+		server := &sl.Items[i]
+
+		state, ok := states[server.Name]
+		if !ok || existing[server.Name] != nil {
+			continue
+		}
+
+		membership := &metalv1alpha1.ServerClassMembership{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: metalv1alpha1.MembershipName(sc.Name, server.Name),
+				Labels: map[string]string{
+					metalv1alpha1.MembershipServerClassLabel: metalv1alpha1.MembershipLabelValue(sc.Name),
+					metalv1alpha1.MembershipServerLabel:      metalv1alpha1.MembershipLabelValue(server.Name),
+				},
+			},
+			Spec: metalv1alpha1.ServerClassMembershipSpec{
+				ServerClass: sc.Name,
+				Server:      server.Name,
+			},
+			Status: metalv1alpha1.ServerClassMembershipStatus{
+				State: state,
+			},
+		}
+
+		if err := controllerutil.SetControllerReference(sc, membership, r.Scheme); err != nil {
+			return err
+		}
+
+		if err := controllerutil.SetOwnerReference(server, membership, r.Scheme); err != nil {
+			return err
+		}
+
+		if err := r.Create(ctx, membership); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeMemberships removes the memberships left over from the time the memberships were enabled.
+//
+// Memberships are otherwise only removed along with the ServerClass or the Server, so they would go stale.
+func (r *ServerClassReconciler) removeMemberships(ctx context.Context) error {
+	var memberships metalv1alpha1.ServerClassMembershipList
+
+	if err := r.APIReader.List(ctx, &memberships, client.HasLabels{metalv1alpha1.MembershipServerClassLabel}); err != nil {
+		return err
+	}
+
+	for i := range memberships.Items {
+		if err := r.Delete(ctx, &memberships.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if len(memberships.Items) > 0 {
+		r.Log.Info("removed serverclass memberships", "count", len(memberships.Items))
+	}
+
+	return nil
+}
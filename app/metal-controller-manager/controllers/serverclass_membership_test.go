@@ -0,0 +1,126 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func membershipScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	return scheme
+}
+
+func TestServerClassReconcilerSyncMemberships(t *testing.T) {
+	scheme := membershipScheme(t)
+
+	sc := &metalv1alpha1.ServerClass{
+		ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("workers-", 10), UID: "1"},
+	}
+
+	// shares the shortened label value, but belongs to another serverclass
+	foreign := &metalv1alpha1.ServerClassMembership{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "foreign",
+			Labels: map[string]string{metalv1alpha1.MembershipServerClassLabel: metalv1alpha1.MembershipLabelValue(sc.Name)},
+		},
+		Spec: metalv1alpha1.ServerClassMembershipSpec{ServerClass: "other", Server: "server-2"},
+	}
+
+	sl := &metalv1alpha1.ServerList{
+		Items: []metalv1alpha1.Server{
+			{ObjectMeta: metav1.ObjectMeta{Name: "server-1", UID: "2"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "server-2", UID: "3"}},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, foreign)
+
+	r := &ServerClassReconciler{
+		Client: c,
+		Log:    log.NullLogger{},
+		Scheme: scheme,
+	}
+
+	ctx := context.Background()
+
+	if err := r.syncMemberships(ctx, sc, sl, map[string]metalv1alpha1.MembershipState{"server-1": metalv1alpha1.MembershipAvailable}); err != nil {
+		t.Fatal(err)
+	}
+
+	var membership metalv1alpha1.ServerClassMembership
+
+	if err := c.Get(ctx, types.NamespacedName{Name: metalv1alpha1.MembershipName(sc.Name, "server-1")}, &membership); err != nil {
+		t.Fatal(err)
+	}
+
+	if value := membership.Labels[metalv1alpha1.MembershipServerClassLabel]; value != metalv1alpha1.MembershipLabelValue(sc.Name) || len(value) > 63 {
+		t.Errorf("unexpected serverclass label %q", value)
+	}
+
+	if membership.Spec.ServerClass != sc.Name {
+		t.Errorf("membership serverclass = %q, want the full name", membership.Spec.ServerClass)
+	}
+
+	if err := c.Get(ctx, types.NamespacedName{Name: foreign.Name}, &membership); err != nil {
+		t.Errorf("membership of the other serverclass is removed: %s", err)
+	}
+}
+
+func TestServerClassReconcilerRemoveMemberships(t *testing.T) {
+	scheme := membershipScheme(t)
+
+	objects := []runtime.Object{}
+
+	for _, server := range []string{"server-1", "server-2"} {
+		objects = append(objects, &metalv1alpha1.ServerClassMembership{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   metalv1alpha1.MembershipName("workers", server),
+				Labels: map[string]string{metalv1alpha1.MembershipServerClassLabel: "workers"},
+			},
+		})
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, objects...)
+
+	r := &ServerClassReconciler{
+		Client:    c,
+		Log:       log.NullLogger{},
+		Scheme:    scheme,
+		APIReader: c,
+	}
+
+	ctx := context.Background()
+
+	if err := r.removeMemberships(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var memberships metalv1alpha1.ServerClassMembershipList
+
+	if err := c.List(ctx, &memberships); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(memberships.Items) > 0 {
+		t.Errorf("%d memberships left", len(memberships.Items))
+	}
+}
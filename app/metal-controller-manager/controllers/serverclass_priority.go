@@ -0,0 +1,84 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// overlappingClasses returns the other serverclasses matching any of the servers of the serverclass.
+//
+// Proposed serverclasses don't offer servers for allocation, so they don't overlap.
+func overlappingClasses(sc *metalv1alpha1.ServerClass, matched map[string]metalv1alpha1.Server, classes []metalv1alpha1.ServerClass) []metalv1alpha1.ServerClass {
+	var overlapping []metalv1alpha1.ServerClass
+
+	for i := range classes {
+		other := &classes[i]
+
+		if other.Name == sc.Name || other.IsProposed() {
+			continue
+		}
+
+		results := &serverResults{
+			items: make(map[string]metalv1alpha1.Server, len(matched)),
+		}
+
+		for name, server := range matched {
+			results.items[name] = server
+		}
+
+		if len(filterServerClass(results, other, classes).fetchItems()) > 0 {
+			overlapping = append(overlapping, *other)
+		}
+	}
+
+	sort.Slice(overlapping, func(i, j int) bool { return overlapping[i].Name < overlapping[j].Name })
+
+	return overlapping
+}
+
+// setOverlappingCondition reports the overlapping serverclasses, it returns true if the condition changed.
+func setOverlappingCondition(sc *metalv1alpha1.ServerClass, overlapping []metalv1alpha1.ServerClass) bool {
+	old := conditions.Get(sc, metalv1alpha1.ConditionOverlapping)
+
+	if len(overlapping) == 0 {
+		conditions.Delete(sc, metalv1alpha1.ConditionOverlapping)
+
+		return old != nil
+	}
+
+	reason := metalv1alpha1.OverlappingServerClassesReason
+	severity := clusterv1.ConditionSeverityInfo
+	names := make([]string, 0, len(overlapping))
+
+	for _, other := range overlapping {
+		if other.Spec.Priority == sc.Spec.Priority {
+			reason = metalv1alpha1.PriorityConflictReason
+			severity = clusterv1.ConditionSeverityWarning
+		}
+
+		names = append(names, fmt.Sprintf("%s (priority %d)", other.Name, other.Spec.Priority))
+	}
+
+	condition := &clusterv1.Condition{
+		Type:     metalv1alpha1.ConditionOverlapping,
+		Status:   corev1.ConditionTrue,
+		Severity: severity,
+		Reason:   reason,
+		Message:  fmt.Sprintf("Servers are also matched by ServerClasses %s.", strings.Join(names, ", ")),
+	}
+
+	conditions.Set(sc, condition)
+
+	return old == nil || old.Reason != condition.Reason || old.Severity != condition.Severity || old.Message != condition.Message
+}
@@ -0,0 +1,121 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func Test_overlappingClasses(t *testing.T) {
+	matched := map[string]metalv1alpha1.Server{}
+
+	for name, labels := range map[string]map[string]string{
+		"dell-ssd": {"vendor": "dell", "disk": "ssd"},
+		"dell-hdd": {"vendor": "dell", "disk": "hdd"},
+	} {
+		matched[name] = metalv1alpha1.Server{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		}
+	}
+
+	proposed := testClass("proposed-ssd", labelQualifiers("disk", "ssd"))
+	proposed.Annotations = map[string]string{metalv1alpha1.ProposedAnnotation: ""}
+
+	classes := []metalv1alpha1.ServerClass{
+		testClass("dell", labelQualifiers("vendor", "dell")),
+		testClass("ssd", labelQualifiers("disk", "ssd")),
+		testClass("hpe", labelQualifiers("vendor", "hpe")),
+		testClass("any", metalv1alpha1.Qualifiers{}),
+		testClass("dell-or-hpe", metalv1alpha1.Qualifiers{}, "hpe", "dell"),
+		proposed,
+	}
+
+	overlapping := overlappingClasses(&classes[0], matched, classes)
+
+	names := make([]string, 0, len(overlapping))
+
+	for _, other := range overlapping {
+		names = append(names, other.Name)
+	}
+
+	expected := []string{"any", "dell-or-hpe", "ssd"}
+
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("overlapping classes %v, want %v", names, expected)
+	}
+
+	// the filtering of the other serverclasses works on the copy
+	if len(matched) != 2 {
+		t.Errorf("matched servers are modified: %v", matched)
+	}
+}
+
+func Test_setOverlappingCondition(t *testing.T) {
+	withPriority := func(name string, priority int) metalv1alpha1.ServerClass {
+		sc := testClass(name, metalv1alpha1.Qualifiers{})
+		sc.Spec.Priority = priority
+
+		return sc
+	}
+
+	for _, tt := range []struct {
+		name             string
+		overlapping      []metalv1alpha1.ServerClass
+		expectedReason   string
+		expectedSeverity clusterv1.ConditionSeverity
+		expectedMessage  string
+	}{
+		{
+			name: "no overlaps",
+		},
+		{
+			name:             "settled by priority",
+			overlapping:      []metalv1alpha1.ServerClass{withPriority("ssd", 10), withPriority("any", -1)},
+			expectedReason:   metalv1alpha1.OverlappingServerClassesReason,
+			expectedSeverity: clusterv1.ConditionSeverityInfo,
+			expectedMessage:  "Servers are also matched by ServerClasses ssd (priority 10), any (priority -1).",
+		},
+		{
+			name:             "same priority",
+			overlapping:      []metalv1alpha1.ServerClass{withPriority("ssd", 10), withPriority("any", 5)},
+			expectedReason:   metalv1alpha1.PriorityConflictReason,
+			expectedSeverity: clusterv1.ConditionSeverityWarning,
+			expectedMessage:  "Servers are also matched by ServerClasses ssd (priority 10), any (priority 5).",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := withPriority("dell", 5)
+
+			// start from the stale overlap to check that it's replaced
+			conditions.MarkTrue(&sc, metalv1alpha1.ConditionOverlapping)
+
+			if !setOverlappingCondition(&sc, tt.overlapping) {
+				t.Error("condition change is not reported")
+			}
+
+			condition := conditions.Get(&sc, metalv1alpha1.ConditionOverlapping)
+
+			if tt.expectedReason == "" {
+				if condition != nil {
+					t.Errorf("Overlapping condition = %v", condition)
+				}
+			} else if condition == nil || condition.Reason != tt.expectedReason || condition.Severity != tt.expectedSeverity || condition.Message != tt.expectedMessage {
+				t.Errorf("Overlapping condition = %v, want reason %q, severity %q, message %q", condition, tt.expectedReason, tt.expectedSeverity, tt.expectedMessage)
+			}
+
+			if setOverlappingCondition(&sc, tt.overlapping) {
+				t.Error("unchanged condition is reported as changed")
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// SnapshotKey is the ConfigMap key of the serverclass match snapshot.
+const SnapshotKey = "snapshot.yaml"
+
+// snapshotHashLength is the number of the hex digits of the snapshot hash in the ConfigMap name.
+const snapshotHashLength = 10
+
+// Snapshot captures which servers match the serverclass and why.
+//
+// Snapshot only depends on the serverclass policy and the server hardware, not on the allocations,
+// so that the snapshot (and its hash) only changes when the outcome of the policy changes.
+type Snapshot struct {
+	ServerClass    string                          `json:"serverClass"`
+	Qualifiers     metalv1alpha1.Qualifiers        `json:"qualifiers"`
+	Tolerations    []metalv1alpha1.FaultToleration `json:"tolerations,omitempty"`
+	IncludeClasses []string                        `json:"includeClasses,omitempty"`
+	Servers        []SnapshotServer                `json:"servers"`
+}
+
+// SnapshotServer describes how the qualifiers of the serverclass apply to the accepted server.
+type SnapshotServer struct {
+	Name string `json:"name"`
+	// Matched is set if the server matches all the qualifiers.
+	Matched bool `json:"matched"`
+	// Mismatched lists the qualifiers the server doesn't match.
+	Mismatched []string `json:"mismatched,omitempty"`
+	// Excluded is the reason the matching server is not offered for allocation.
+	Excluded string `json:"excluded,omitempty"`
+}
+
+// qualifierFilters applies each qualifier of the serverclass separately, so that the mismatches can be reported one by one.
+func qualifierFilters(q *metalv1alpha1.Qualifiers) map[string]func(serverFilter) serverFilter {
+	return map[string]func(serverFilter) serverFilter{
+		"cpu":               func(f serverFilter) serverFilter { return f.filterCPU(q.CPU) },
+		"systemInformation": func(f serverFilter) serverFilter { return f.filterSysInfo(q.SystemInformation) },
+		"labelSelectors":    func(f serverFilter) serverFilter { return f.filterLabels(q.LabelSelectors) },
+		"labelExpressions":  func(f serverFilter) serverFilter { return f.filterLabelExpressions(q) },
+		"pciDevices":        func(f serverFilter) serverFilter { return f.filterPCIDevices(q.PCIDevices) },
+		"memory":            func(f serverFilter) serverFilter { return f.filterMemory(q.Memory) },
+		"disks":             func(f serverFilter) serverFilter { return f.filterDisks(q.Disks) },
+		"networkInterfaces": func(f serverFilter) serverFilter { return f.filterNetworkInterfaces(q.NetworkInterfaces) },
+		"gpus":              func(f serverFilter) serverFilter { return f.filterGPUs(q.GPUs) },
+		"serverNames":       func(f serverFilter) serverFilter { return f.filterServerNames(q) },
+		"expression":        func(f serverFilter) serverFilter { return f.filterExpression(q.Expression) },
+		"exclude":           func(f serverFilter) serverFilter { return f.filterExclusions(q.Exclude) },
+	}
+}
+
+// buildSnapshot matches the accepted servers against the serverclass.
+func buildSnapshot(sc *metalv1alpha1.ServerClass, sl *metalv1alpha1.ServerList, classes []metalv1alpha1.ServerClass) *Snapshot {
+	snapshot := &Snapshot{
+		ServerClass:    sc.Name,
+		Qualifiers:     sc.Spec.Qualifiers,
+		Tolerations:    sc.Spec.Tolerations,
+		IncludeClasses: sc.Spec.IncludeClasses,
+		Servers:        []SnapshotServer{},
+	}
+
+	matches := map[string]map[string]metalv1alpha1.Server{}
+
+	for name, filter := range qualifierFilters(&sc.Spec.Qualifiers) {
+		matches[name] = filter(newServerFilter(sl)).fetchItems()
+	}
+
+	if len(sc.Spec.IncludeClasses) > 0 {
+		matches["includeClasses"] = filterIncludedClasses(newServerFilter(sl), sc, classes, map[string]struct{}{}).fetchItems()
+	}
+
+	for name, server := range newServerFilter(sl).fetchItems() {
+		server := server
+		result := SnapshotServer{Name: name}
+
+		for qualifier, matched := range matches {
+			if _, ok := matched[name]; !ok {
+				result.Mismatched = append(result.Mismatched, qualifier)
+			}
+		}
+
+		sort.Strings(result.Mismatched)
+
+		result.Matched = len(result.Mismatched) == 0
+
+		if result.Matched {
+			result.Excluded = unavailableReason(sc, &server)
+		}
+
+		snapshot.Servers = append(snapshot.Servers, result)
+	}
+
+	sort.Slice(snapshot.Servers, func(i, j int) bool { return snapshot.Servers[i].Name < snapshot.Servers[j].Name })
+
+	return snapshot
+}
+
+// snapshotConfigMap marshals the snapshot into the ConfigMap named after the snapshot hash.
+func snapshotConfigMap(snapshot *Snapshot, namespace string) (*corev1.ConfigMap, error) {
+	data, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(data)
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      snapshot.ServerClass + "-" + hex.EncodeToString(hash[:])[:snapshotHashLength],
+			Labels: map[string]string{
+				metalv1alpha1.ServerClassSnapshotLabel: snapshot.ServerClass,
+			},
+		},
+		Data: map[string]string{
+			SnapshotKey: string(data),
+		},
+	}, nil
+}
+
+// +kubebuilder:rbac:groups="",namespace=sidero-system,resources=configmaps,verbs=get;list;create;delete
+
+// snapshot writes the match snapshot of the serverclass, if the snapshot changed since the last one.
+//
+// Snapshots are immutable, the oldest ones are removed to keep at most SnapshotHistory snapshots per serverclass.
+func (r *ServerClassReconciler) snapshot(ctx context.Context, sc *metalv1alpha1.ServerClass, sl *metalv1alpha1.ServerList, classes []metalv1alpha1.ServerClass) error {
+	cm, err := snapshotConfigMap(buildSnapshot(sc, sl, classes), r.SnapshotNamespace)
+	if err != nil {
+		return err
+	}
+
+	r.lastSnapshotMu.Lock()
+
+	if r.lastSnapshot == nil {
+		r.lastSnapshot = make(map[string]string)
+	}
+
+	unchanged := r.lastSnapshot[sc.Name] == cm.Name
+
+	r.lastSnapshotMu.Unlock()
+
+	if unchanged {
+		return nil
+	}
+
+	err = r.APIReader.Get(ctx, types.NamespacedName{Namespace: cm.Namespace, Name: cm.Name}, &corev1.ConfigMap{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		if err = controllerutil.SetOwnerReference(sc, cm, r.Scheme); err != nil {
+			return err
+		}
+
+		if err = r.Create(ctx, cm); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+
+		r.Log.Info("wrote serverclass snapshot", "serverclass", sc.Name, "snapshot", cm.Name)
+	case err != nil:
+		return err
+	}
+
+	if err = r.pruneSnapshots(ctx, sc.Name, cm.Name); err != nil {
+		return err
+	}
+
+	r.lastSnapshotMu.Lock()
+	r.lastSnapshot[sc.Name] = cm.Name
+	r.lastSnapshotMu.Unlock()
+
+	return nil
+}
+
+// pruneSnapshots removes the oldest snapshots of the serverclass beyond SnapshotHistory, the current snapshot is always kept.
+func (r *ServerClassReconciler) pruneSnapshots(ctx context.Context, serverClass, current string) error {
+	var snapshots corev1.ConfigMapList
+
+	if err := r.APIReader.List(ctx, &snapshots,
+		client.InNamespace(r.SnapshotNamespace),
+		client.MatchingLabels{metalv1alpha1.ServerClassSnapshotLabel: serverClass},
+	); err != nil {
+		return err
+	}
+
+	old := make([]corev1.ConfigMap, 0, len(snapshots.Items))
+
+	for _, cm := range snapshots.Items {
+		if cm.Name != current {
+			old = append(old, cm)
+		}
+	}
+
+	sort.Slice(old, func(i, j int) bool {
+		return old[j].CreationTimestamp.Before(&old[i].CreationTimestamp)
+	})
+
+	keep := r.SnapshotHistory - 1
+	if keep < 0 {
+		keep = 0
+	}
+
+	for i := keep; i < len(old); i++ {
+		if err := r.Delete(ctx, &old[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
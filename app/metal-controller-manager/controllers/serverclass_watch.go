@@ -0,0 +1,153 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// allServerClasses maps the event to the reconciles of all the serverclasses.
+func (r *ServerClassReconciler) allServerClasses(a handler.MapObject) []reconcile.Request {
+	scList := &metalv1alpha1.ServerClassList{}
+
+	if err := r.List(context.Background(), scList); err != nil {
+		return nil
+	}
+
+	reqList := make([]reconcile.Request, 0, len(scList.Items))
+
+	for i := range scList.Items {
+		reqList = append(reqList, serverClassRequest(&scList.Items[i]))
+	}
+
+	return reqList
+}
+
+// affectedServerClasses maps the server event to the reconciles of the serverclasses the server might belong to.
+//
+// The handler maps both the old and the new object of the update, so the serverclasses the server leaves are reconciled as well.
+// Snapshots list every accepted server, so all the serverclasses are reconciled if the snapshots are enabled.
+func (r *ServerClassReconciler) affectedServerClasses(a handler.MapObject) []reconcile.Request {
+	server, ok := a.Object.(*metalv1alpha1.Server)
+	if !ok || r.SnapshotNamespace != "" {
+		return r.allServerClasses(a)
+	}
+
+	scList := &metalv1alpha1.ServerClassList{}
+
+	if err := r.List(context.Background(), scList); err != nil {
+		return nil
+	}
+
+	var reqList []reconcile.Request
+
+	for i := range scList.Items {
+		sc := &scList.Items[i]
+
+		if listsServer(sc, server.Name) || matchesServer(sc, server, scList.Items) {
+			reqList = append(reqList, serverClassRequest(sc))
+		}
+	}
+
+	return reqList
+}
+
+// listsServer checks whether the server is in any of the serverclass status lists.
+func listsServer(sc *metalv1alpha1.ServerClass, name string) bool {
+	for _, list := range [][]string{
+		sc.Status.ServersAvailable,
+		sc.Status.ServersInUse,
+		sc.Status.ServersPendingAcceptance,
+	} {
+		for _, item := range list {
+			if item == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchesServer checks whether the server matches the qualifiers of the serverclass, whether it's accepted or not.
+func matchesServer(sc *metalv1alpha1.ServerClass, server *metalv1alpha1.Server, classes []metalv1alpha1.ServerClass) bool {
+	results := &serverResults{
+		items: map[string]metalv1alpha1.Server{
+			server.Name: *server,
+		},
+	}
+
+	return len(filterServerClass(results, sc, classes).fetchItems()) > 0
+}
+
+func serverClassRequest(sc *metalv1alpha1.ServerClass) reconcile.Request {
+	return reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      sc.Name,
+			Namespace: sc.Namespace,
+		},
+	}
+}
+
+// serverMatchingChanged skips the server updates which can't change the serverclass membership,
+// e.g. the power state, the action outcome or the condition timestamps.
+var serverMatchingChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldServer, ok := e.ObjectOld.(*metalv1alpha1.Server)
+		if !ok {
+			return true
+		}
+
+		newServer, ok := e.ObjectNew.(*metalv1alpha1.Server)
+		if !ok {
+			return true
+		}
+
+		return !reflect.DeepEqual(matchingView(oldServer), matchingView(newServer))
+	},
+}
+
+// matchingView strips the server down to the fields the serverclass matching depends on.
+func matchingView(server *metalv1alpha1.Server) *metalv1alpha1.Server {
+	view := server.DeepCopy()
+
+	view.ObjectMeta = metav1.ObjectMeta{
+		Name:              server.Name,
+		Labels:            view.Labels,
+		Annotations:       view.Annotations,
+		DeletionTimestamp: view.DeletionTimestamp,
+	}
+
+	view.Status.Power = ""
+	view.Status.PowerFailures = 0
+	view.Status.PowerPath = 0
+	view.Status.WipeAttempts = 0
+	view.Status.LastAction = nil
+	view.Status.LastInventoryTime = nil
+	view.Status.AllocationHistory = nil
+
+	// only the condition statuses are matched, messages and timestamps change without affecting the membership
+	view.Status.Conditions = make(clusterv1.Conditions, 0, len(server.Status.Conditions))
+
+	for _, condition := range server.Status.Conditions {
+		view.Status.Conditions = append(view.Status.Conditions, clusterv1.Condition{
+			Type:   condition.Type,
+			Status: condition.Status,
+		})
+	}
+
+	return view
+}
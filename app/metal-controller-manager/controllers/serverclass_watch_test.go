@@ -0,0 +1,223 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func Test_serverMatchingChanged(t *testing.T) {
+	base := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "server",
+			Labels:          map[string]string{"rack": "a"},
+			ResourceVersion: "1",
+		},
+		Spec: metalv1alpha1.ServerSpec{
+			Accepted: true,
+		},
+		Status: metalv1alpha1.ServerStatus{
+			Power: "on",
+			Conditions: clusterv1.Conditions{
+				{Type: metalv1alpha1.ConditionPXEBooted, Status: corev1.ConditionTrue, Message: "booted"},
+			},
+		},
+	}
+
+	now := metav1.Now()
+
+	for _, tt := range []struct {
+		name     string
+		update   func(s *metalv1alpha1.Server)
+		expected bool
+	}{
+		{
+			name:   "resource version",
+			update: func(s *metalv1alpha1.Server) { s.ResourceVersion = "2" },
+		},
+		{
+			name:   "power state",
+			update: func(s *metalv1alpha1.Server) { s.Status.Power = "off" },
+		},
+		{
+			name: "power failures",
+			update: func(s *metalv1alpha1.Server) {
+				s.Status.PowerFailures = 2
+				s.Status.PowerPath = 1
+			},
+		},
+		{
+			name:   "last action",
+			update: func(s *metalv1alpha1.Server) { s.Status.LastAction = &metalv1alpha1.ServerActionRecord{} },
+		},
+		{
+			name:   "inventory time",
+			update: func(s *metalv1alpha1.Server) { s.Status.LastInventoryTime = &now },
+		},
+		{
+			name: "condition message and timestamp",
+			update: func(s *metalv1alpha1.Server) {
+				s.Status.Conditions[0].Message = "booted again"
+				s.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(time.Minute))
+			},
+		},
+		{
+			name:     "labels",
+			update:   func(s *metalv1alpha1.Server) { s.Labels["rack"] = "b" },
+			expected: true,
+		},
+		{
+			name:     "annotations",
+			update:   func(s *metalv1alpha1.Server) { s.Annotations = map[string]string{"note": "spare"} },
+			expected: true,
+		},
+		{
+			name:     "deletion",
+			update:   func(s *metalv1alpha1.Server) { s.DeletionTimestamp = &now },
+			expected: true,
+		},
+		{
+			name:     "spec",
+			update:   func(s *metalv1alpha1.Server) { s.Spec.Accepted = false },
+			expected: true,
+		},
+		{
+			name:     "in use",
+			update:   func(s *metalv1alpha1.Server) { s.Status.InUse = true },
+			expected: true,
+		},
+		{
+			name:     "condition status",
+			update:   func(s *metalv1alpha1.Server) { s.Status.Conditions[0].Status = corev1.ConditionFalse },
+			expected: true,
+		},
+		{
+			name: "condition added",
+			update: func(s *metalv1alpha1.Server) {
+				s.Status.Conditions = append(s.Status.Conditions, clusterv1.Condition{Type: metalv1alpha1.ConditionPoweredOff, Status: corev1.ConditionTrue})
+			},
+			expected: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := base.DeepCopy()
+			tt.update(updated)
+
+			e := event.UpdateEvent{
+				MetaOld:   base,
+				ObjectOld: base,
+				MetaNew:   updated,
+				ObjectNew: updated,
+			}
+
+			if changed := serverMatchingChanged.Update(e); changed != tt.expected {
+				t.Errorf("serverMatchingChanged = %v, want %v", changed, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_matchingView(t *testing.T) {
+	server := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{Name: "server"},
+		Status: metalv1alpha1.ServerStatus{
+			Conditions: clusterv1.Conditions{
+				{Type: metalv1alpha1.ConditionPXEBooted, Status: corev1.ConditionTrue, Reason: "Booted"},
+			},
+		},
+	}
+
+	view := matchingView(server)
+
+	if view.Status.Conditions[0].Reason != "" {
+		t.Error("condition reason is kept in the matching view")
+	}
+
+	if server.Status.Conditions[0].Reason != "Booted" {
+		t.Error("matching view modifies the server")
+	}
+}
+
+func TestServerClassReconcilerAffectedServerClasses(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	listed := testClass("listed", labelQualifiers("rack", "b"))
+	listed.Status.ServersAvailable = []string{"server"}
+
+	rackA := testClass("rack-a", labelQualifiers("rack", "a"))
+	rackB := testClass("rack-b", labelQualifiers("rack", "b"))
+	composed := testClass("composed", metalv1alpha1.Qualifiers{}, "rack-a")
+
+	server := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "server",
+			Labels: map[string]string{"rack": "a"},
+		},
+		Spec: metalv1alpha1.ServerSpec{
+			Accepted: true,
+		},
+	}
+
+	for _, tt := range []struct {
+		name              string
+		object            runtime.Object
+		snapshotNamespace string
+		expected          []string
+	}{
+		{
+			name:     "listed and matching serverclasses",
+			object:   server,
+			expected: []string{"composed", "listed", "rack-a"},
+		},
+		{
+			name:              "snapshots enabled",
+			object:            server,
+			snapshotNamespace: "sidero-system",
+			expected:          []string{"composed", "listed", "rack-a", "rack-b"},
+		},
+		{
+			name:     "not a server",
+			object:   &metalv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			expected: []string{"composed", "listed", "rack-a", "rack-b"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ServerClassReconciler{
+				Client:            fake.NewFakeClientWithScheme(scheme, listed.DeepCopy(), rackA.DeepCopy(), rackB.DeepCopy(), composed.DeepCopy()),
+				SnapshotNamespace: tt.snapshotNamespace,
+			}
+
+			var names []string
+
+			for _, req := range r.affectedServerClasses(handler.MapObject{Object: tt.object}) {
+				names = append(names, req.Name)
+			}
+
+			sort.Strings(names)
+
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("affectedServerClasses() = %v, want %v", names, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/export"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
+)
+
+const (
+	serverClassImportFinalizer = "serverclassimport.metal.sidero.dev"
+
+	// leases are resynced periodically to pick up servers released on the exporter side.
+	serverClassImportResyncInterval = time.Minute
+)
+
+// ServerClassImportReconciler reconciles a ServerClassImport object.
+type ServerClassImportReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclassimports,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclassimports/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Export API (see internal/export) runs with the permissions of the controller manager.
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclassexports,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclassexports/status,verbs=get;update;patch
+
+func (r *ServerClassImportReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("serverclassimport", req.NamespacedName)
+
+	var imp metalv1alpha1.ServerClassImport
+
+	if err = r.Get(ctx, req.NamespacedName, &imp); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	patchHelper, err := patch.NewHelper(&imp, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		imp.Status.Error = ""
+
+		if err != nil {
+			imp.Status.Error = err.Error()
+		}
+
+		if e := patchHelper.Patch(ctx, &imp); e != nil {
+			log.Error(e, "failed to patch serverclassimport")
+
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
+	exportClient, err := r.exportClient(ctx, &imp)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var serverList metalv1alpha1.ServerList
+
+	if err = r.List(ctx, &serverList, client.MatchingLabels{metalv1alpha1.ImportedLabel: imp.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	servers := make(map[string]*metalv1alpha1.Server, len(serverList.Items))
+
+	for i := range serverList.Items {
+		servers[serverList.Items[i].Name] = &serverList.Items[i]
+	}
+
+	if !imp.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, &imp, exportClient, servers)
+	}
+
+	controllerutil.AddFinalizer(&imp, serverClassImportFinalizer)
+
+	leases, err := exportClient.List(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	leased := make(map[string]struct{}, len(leases))
+
+	for i := range leases {
+		leased[leases[i].Server] = struct{}{}
+
+		if err = r.ensureServer(ctx, &imp, &leases[i], servers[leases[i].Server]); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// lease was revoked on the exporter side
+	for name, server := range servers {
+		if _, ok := leased[name]; ok {
+			continue
+		}
+
+		if server.Status.InUse {
+			r.event(&imp, corev1.EventTypeWarning, fmt.Sprintf("Lease of server %q was revoked while the server is in use.", name))
+
+			continue
+		}
+
+		if err = r.Delete(ctx, server); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+	}
+
+	for len(leased) < imp.Spec.Servers {
+		lease, err := exportClient.Lease(ctx)
+		if err != nil {
+			if errors.Is(err, export.ErrNoServersAvailable) {
+				log.Info("no more servers available for lease", "leased", len(leased), "requested", imp.Spec.Servers)
+
+				break
+			}
+
+			return ctrl.Result{}, err
+		}
+
+		leased[lease.Server] = struct{}{}
+
+		if err = r.ensureServer(ctx, &imp, lease, nil); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		r.event(&imp, corev1.EventTypeNormal, fmt.Sprintf("Server %q imported.", lease.Server))
+	}
+
+	// release extra servers which are not in use
+	for name := range leased {
+		if len(leased) <= imp.Spec.Servers {
+			break
+		}
+
+		server := servers[name]
+		if server == nil || server.Status.InUse {
+			continue
+		}
+
+		if err = r.releaseServer(ctx, exportClient, server); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		delete(leased, name)
+
+		r.event(&imp, corev1.EventTypeNormal, fmt.Sprintf("Server %q released.", name))
+	}
+
+	imp.Status.ServersImported = make([]string, 0, len(leased))
+
+	for name := range leased {
+		imp.Status.ServersImported = append(imp.Status.ServersImported, name)
+	}
+
+	sort.Strings(imp.Status.ServersImported)
+
+	return ctrl.Result{RequeueAfter: serverClassImportResyncInterval}, nil
+}
+
+func (r *ServerClassImportReconciler) reconcileDelete(ctx context.Context, imp *metalv1alpha1.ServerClassImport, exportClient *export.Client, servers map[string]*metalv1alpha1.Server) (ctrl.Result, error) {
+	inUse := false
+
+	for _, server := range servers {
+		if server.Status.InUse {
+			inUse = true
+
+			continue
+		}
+
+		if err := r.releaseServer(ctx, exportClient, server); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if inUse {
+		// servers are released once they are no longer allocated
+		return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+	}
+
+	controllerutil.RemoveFinalizer(imp, serverClassImportFinalizer)
+
+	return ctrl.Result{}, nil
+}
+
+// ensureServer creates the local Server for the leased server.
+//
+// Leased server is accepted once it has the BMC credentials (either included in the lease, or set from the BMCCredentialsSecretRef),
+// as the server is wiped before it gets allocated, which requires the power management.
+func (r *ServerClassImportReconciler) ensureServer(ctx context.Context, imp *metalv1alpha1.ServerClassImport, lease *export.Lease, server *metalv1alpha1.Server) error {
+	if server != nil {
+		if server.Spec.Accepted || !missingCredentials(server) {
+			return nil
+		}
+
+		// credentials might have been added to the secret since the server was imported
+		patchHelper := client.MergeFrom(server.DeepCopy())
+
+		if err := r.setCredentials(ctx, imp, server); err != nil || missingCredentials(server) {
+			return err
+		}
+
+		server.Spec.Accepted = true
+
+		return r.Patch(ctx, server, patchHelper)
+	}
+
+	server = &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: lease.Server,
+			Labels: map[string]string{
+				metalv1alpha1.ImportedLabel: imp.Name,
+			},
+		},
+		Spec: lease.Spec,
+	}
+
+	if err := r.setCredentials(ctx, imp, server); err != nil {
+		return err
+	}
+
+	server.Spec.Accepted = !missingCredentials(server)
+
+	if !server.Spec.Accepted {
+		r.event(imp, corev1.EventTypeWarning, fmt.Sprintf("Server %q is imported without the BMC credentials, it's not accepted until the credentials are set.", lease.Server))
+	}
+
+	err := r.Create(ctx, server)
+	if apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("server %q already exists and is not imported via %q", lease.Server, imp.Name)
+	}
+
+	return err
+}
+
+// setCredentials sets the BMC credentials of the imported server from the BMCCredentialsSecretRef, if the lease doesn't include them.
+func (r *ServerClassImportReconciler) setCredentials(ctx context.Context, imp *metalv1alpha1.ServerClassImport, server *metalv1alpha1.Server) error {
+	ref := imp.Spec.BMCCredentialsSecretRef
+
+	if ref == nil || !missingCredentials(server) {
+		return nil
+	}
+
+	var secret corev1.Secret
+
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, &secret); err != nil {
+		return err
+	}
+
+	server.Spec.BMC.User = string(secret.Data["user"])
+	server.Spec.BMC.Pass = string(secret.Data["pass"])
+
+	return nil
+}
+
+// missingCredentials checks whether the server has the BMC without the credentials.
+func missingCredentials(server *metalv1alpha1.Server) bool {
+	return server.Spec.BMC != nil && server.Spec.BMC.User == "" && server.Spec.BMC.Pass == ""
+}
+
+// releaseServer deletes the local Server and releases the lease.
+func (r *ServerClassImportReconciler) releaseServer(ctx context.Context, exportClient *export.Client, server *metalv1alpha1.Server) error {
+	if err := r.Delete(ctx, server); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return exportClient.Release(ctx, server.Name)
+}
+
+func (r *ServerClassImportReconciler) exportClient(ctx context.Context, imp *metalv1alpha1.ServerClassImport) (*export.Client, error) {
+	var secret corev1.Secret
+
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: imp.Spec.TokenSecretRef.Namespace, Name: imp.Spec.TokenSecretRef.Name}, &secret); err != nil {
+		return nil, err
+	}
+
+	token, ok := secret.Data["token"]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s doesn't have token", secret.Namespace, secret.Name)
+	}
+
+	return export.NewClient(imp.Spec.Endpoint, imp.Spec.Export, string(token), secret.Data["ca.crt"])
+}
+
+func (r *ServerClassImportReconciler) event(imp *metalv1alpha1.ServerClassImport, eventType, message string) {
+	ref, err := reference.GetReference(r.Scheme, imp)
+	if err != nil {
+		return
+	}
+
+	r.Recorder.Event(ref, eventType, "Server Import", message)
+}
+
+func (r *ServerClassImportReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&metalv1alpha1.ServerClassImport{}).
+		Complete(r)
+}
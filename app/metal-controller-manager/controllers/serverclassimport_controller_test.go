@@ -0,0 +1,149 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/export"
+)
+
+func TestServerClassImportEnsureServer(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bmc"},
+		Data: map[string][]byte{
+			"user": []byte("admin"),
+			"pass": []byte("secret"),
+		},
+	}
+
+	secretRef := &corev1.SecretReference{Namespace: "default", Name: "bmc"}
+
+	withBMC := func(user, pass string) metalv1alpha1.ServerSpec {
+		return metalv1alpha1.ServerSpec{
+			BMC: &metalv1alpha1.BMC{Endpoint: "10.0.0.25", User: user, Pass: pass},
+		}
+	}
+
+	for _, tt := range []struct {
+		name      string
+		spec      metalv1alpha1.ServerSpec
+		secretRef *corev1.SecretReference
+		existing  bool
+
+		accepted bool
+		user     string
+	}{
+		{
+			name:     "credentials included in the lease",
+			spec:     withBMC("root", "calvin"),
+			accepted: true,
+			user:     "root",
+		},
+		{
+			name:      "credentials included in the lease take precedence",
+			spec:      withBMC("root", "calvin"),
+			secretRef: secretRef,
+			accepted:  true,
+			user:      "root",
+		},
+		{
+			name:      "credentials from the secret",
+			spec:      withBMC("", ""),
+			secretRef: secretRef,
+			accepted:  true,
+			user:      "admin",
+		},
+		{
+			name: "no credentials",
+			spec: withBMC("", ""),
+		},
+		{
+			name:     "no BMC",
+			spec:     metalv1alpha1.ServerSpec{ManagementAPI: &metalv1alpha1.ManagementAPI{Endpoint: "10.0.0.25:8080"}},
+			accepted: true,
+		},
+		{
+			name:      "imported server gets the credentials set later",
+			spec:      withBMC("", ""),
+			secretRef: secretRef,
+			existing:  true,
+			accepted:  true,
+			user:      "admin",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []runtime.Object{credentials}
+
+			var existing *metalv1alpha1.Server
+
+			if tt.existing {
+				existing = &metalv1alpha1.Server{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "4c4c4544-0035-5910-804b-b2c04f4e4d32",
+						Labels: map[string]string{metalv1alpha1.ImportedLabel: "shared-pool"},
+					},
+					Spec: tt.spec,
+				}
+
+				objects = append(objects, existing.DeepCopy())
+			}
+
+			c := fake.NewFakeClientWithScheme(scheme, objects...)
+
+			r := &ServerClassImportReconciler{
+				Client:    c,
+				Scheme:    scheme,
+				APIReader: c,
+				Recorder:  record.NewFakeRecorder(10),
+			}
+
+			imp := &metalv1alpha1.ServerClassImport{
+				ObjectMeta: metav1.ObjectMeta{Name: "shared-pool"},
+				Spec:       metalv1alpha1.ServerClassImportSpec{BMCCredentialsSecretRef: tt.secretRef},
+			}
+
+			lease := &export.Lease{Server: "4c4c4544-0035-5910-804b-b2c04f4e4d32", Spec: tt.spec}
+
+			if err := r.ensureServer(context.Background(), imp, lease, existing); err != nil {
+				t.Fatal(err)
+			}
+
+			var server metalv1alpha1.Server
+
+			if err := c.Get(context.Background(), types.NamespacedName{Name: lease.Server}, &server); err != nil {
+				t.Fatal(err)
+			}
+
+			if server.Spec.Accepted != tt.accepted {
+				t.Errorf("accepted = %v, want %v", server.Spec.Accepted, tt.accepted)
+			}
+
+			if server.Spec.BMC != nil && server.Spec.BMC.User != tt.user {
+				t.Errorf("BMC user = %q, want %q", server.Spec.BMC.User, tt.user)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// ServerProfilerReconciler groups servers not matched by any ServerClass by the hardware profile,
+// and proposes a ServerClass for each group.
+type ServerProfilerReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// serverProfilerRequest is the name of the request enqueued on the ServerClass changes.
+const serverProfilerRequest = "serverclasses"
+
+// hardwareProfile is the hardware fingerprint of the server.
+type hardwareProfile struct {
+	CPU               metalv1alpha1.CPUInformation
+	SystemInformation metalv1alpha1.SystemInformation
+}
+
+// newHardwareProfile returns the hardware profile of the server, or false if the server hardware is not known yet.
+func newHardwareProfile(server *metalv1alpha1.Server) (hardwareProfile, bool) {
+	if server.Spec.CPU == nil || server.Spec.SystemInformation == nil {
+		return hardwareProfile{}, false
+	}
+
+	return hardwareProfile{
+		CPU: metalv1alpha1.CPUInformation{
+			Manufacturer: server.Spec.CPU.Manufacturer,
+			Version:      server.Spec.CPU.Version,
+		},
+		SystemInformation: metalv1alpha1.SystemInformation{
+			Manufacturer: server.Spec.SystemInformation.Manufacturer,
+			ProductName:  server.Spec.SystemInformation.ProductName,
+		},
+	}, true
+}
+
+// Name returns stable ServerClass name for the profile.
+func (p hardwareProfile) Name() string {
+	h := sha256.New()
+
+	for _, s := range []string{p.CPU.Manufacturer, p.CPU.Version, p.SystemInformation.Manufacturer, p.SystemInformation.ProductName} {
+		h.Write([]byte(s)) //nolint: errcheck
+		h.Write([]byte{0}) //nolint: errcheck
+	}
+
+	return "profile-" + hex.EncodeToString(h.Sum(nil))[:10]
+}
+
+// Qualifiers returns ServerClass qualifiers matching the profile.
+func (p hardwareProfile) Qualifiers() metalv1alpha1.Qualifiers {
+	return metalv1alpha1.Qualifiers{
+		CPU:               []metalv1alpha1.CPUInformation{p.CPU},
+		SystemInformation: []metalv1alpha1.SystemInformation{p.SystemInformation},
+	}
+}
+
+func (p hardwareProfile) String() string {
+	return strings.Join([]string{p.SystemInformation.Manufacturer, p.SystemInformation.ProductName, p.CPU.Version}, " ")
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclasses,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *ServerProfilerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+
+	var serverList metalv1alpha1.ServerList
+
+	if err := r.List(ctx, &serverList); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var serverClassList metalv1alpha1.ServerClassList
+
+	if err := r.List(ctx, &serverClassList); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// servers which are not matched by any serverclass (including the proposed ones)
+	unclassified := newServerFilter(&serverList).fetchItems()
+
+	for i := range serverClassList.Items {
+		qualifiers := serverClassList.Items[i].Spec.Qualifiers
+
+		// catch-all serverclasses don't classify the servers
+		if len(qualifiers.CPU) == 0 && len(qualifiers.SystemInformation) == 0 && len(qualifiers.LabelSelectors) == 0 && len(qualifiers.LabelExpressions) == 0 &&
+			len(qualifiers.PCIDevices) == 0 && qualifiers.Memory == nil && len(qualifiers.Disks) == 0 && len(qualifiers.NetworkInterfaces) == 0 && len(qualifiers.GPUs) == 0 &&
+			len(qualifiers.ServerNames) == 0 && qualifiers.Expression == "" && len(serverClassList.Items[i].Spec.IncludeClasses) == 0 {
+			continue
+		}
+
+		for name := range filterServerClass(newServerFilter(&serverList), &serverClassList.Items[i], serverClassList.Items).fetchItems() {
+			delete(unclassified, name)
+		}
+	}
+
+	groups := map[hardwareProfile][]string{}
+
+	for name, server := range unclassified {
+		server := server
+
+		profile, ok := newHardwareProfile(&server)
+		if !ok {
+			continue
+		}
+
+		groups[profile] = append(groups[profile], name)
+	}
+
+	for profile, servers := range groups {
+		if err := r.propose(ctx, profile, servers); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ServerProfilerReconciler) propose(ctx context.Context, profile hardwareProfile, servers []string) error {
+	serverClass := &metalv1alpha1.ServerClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: profile.Name(),
+			Annotations: map[string]string{
+				metalv1alpha1.ProposedAnnotation: profile.String(),
+			},
+		},
+		Spec: metalv1alpha1.ServerClassSpec{
+			Qualifiers: profile.Qualifiers(),
+		},
+	}
+
+	if err := r.Create(ctx, serverClass); err != nil {
+		// serverclass was proposed before, and it was since edited by the operator
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	sort.Strings(servers)
+
+	r.Log.Info("proposed serverclass", "serverclass", serverClass.Name, "profile", profile.String(), "servers", servers)
+
+	if ref, err := reference.GetReference(r.Scheme, serverClass); err == nil {
+		r.Recorder.Event(ref, corev1.EventTypeNormal, "Hardware Profiler", fmt.Sprintf("ServerClass proposed for %d server(s) with hardware profile %q.", len(servers), profile.String()))
+	}
+
+	return nil
+}
+
+func (r *ServerProfilerReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	// all the servers are profiled on every reconcile, so the serverclass changes are coalesced into a single request;
+	// deleted (or edited) serverclasses might leave the servers unclassified, so that the serverclass is proposed again
+	mapRequests := handler.ToRequestsFunc(
+		func(a handler.MapObject) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: serverProfilerRequest}}}
+		})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("serverprofiler").
+		WithOptions(options).
+		For(&metalv1alpha1.Server{}).
+		Watches(
+			&source.Kind{Type: &metalv1alpha1.ServerClass{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: mapRequests,
+			},
+		).
+		Complete(r)
+}
@@ -0,0 +1,190 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func profiledServer(name, product string, labels map[string]string) *metalv1alpha1.Server {
+	return &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: metalv1alpha1.ServerSpec{
+			Accepted: true,
+			CPU: &metalv1alpha1.CPUInformation{
+				Manufacturer: "Intel(R) Corporation",
+				Version:      "Intel(R) Xeon(R) Silver 4114 CPU @ 2.20GHz",
+			},
+			SystemInformation: &metalv1alpha1.SystemInformation{
+				Manufacturer: "Dell Inc.",
+				ProductName:  product,
+				SerialNumber: name,
+			},
+		},
+	}
+}
+
+func Test_hardwareProfile(t *testing.T) {
+	r640, ok := newHardwareProfile(profiledServer("a", "PowerEdge R640", nil))
+	if !ok {
+		t.Fatal("hardware profile is not known")
+	}
+
+	// serial numbers are not a part of the profile
+	if other, _ := newHardwareProfile(profiledServer("b", "PowerEdge R640", nil)); other.Name() != r640.Name() {
+		t.Errorf("servers with the same hardware got different profiles %q and %q", r640.Name(), other.Name())
+	}
+
+	if other, _ := newHardwareProfile(profiledServer("c", "PowerEdge R740", nil)); other.Name() == r640.Name() {
+		t.Errorf("servers with the different hardware got the same profile %q", r640.Name())
+	}
+
+	if _, ok = newHardwareProfile(&metalv1alpha1.Server{}); ok {
+		t.Error("hardware profile of the server without the inventory is known")
+	}
+
+	servers := &metalv1alpha1.ServerList{
+		Items: []metalv1alpha1.Server{
+			*profiledServer("a", "PowerEdge R640", nil),
+			*profiledServer("c", "PowerEdge R740", nil),
+		},
+	}
+
+	class := testClass(r640.Name(), r640.Qualifiers())
+
+	matched := filterServerClass(newServerFilter(servers), &class, nil).fetchItems()
+
+	if _, ok := matched["a"]; !ok || len(matched) != 1 {
+		t.Errorf("profile qualifiers matched %v", matched)
+	}
+}
+
+func TestServerProfilerReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	r640, _ := newHardwareProfile(profiledServer("a", "PowerEdge R640", nil))
+	r740, _ := newHardwareProfile(profiledServer("c", "PowerEdge R740", nil))
+
+	rackB := testClass("rack-b", labelQualifiers("rack", "b"))
+	catchAll := testClass("any", metalv1alpha1.Qualifiers{})
+
+	c := fake.NewFakeClientWithScheme(scheme,
+		profiledServer("a", "PowerEdge R640", nil),
+		profiledServer("b", "PowerEdge R640", nil),
+		profiledServer("c", "PowerEdge R740", map[string]string{"rack": "b"}),
+		&metalv1alpha1.Server{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}, Spec: metalv1alpha1.ServerSpec{Accepted: true}},
+		&rackB,
+		&catchAll,
+	)
+
+	r := &ServerProfilerReconciler{
+		Client:   c,
+		Log:      log.NullLogger{},
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(100),
+	}
+
+	ctx := context.Background()
+
+	proposed := func() []string {
+		var serverClasses metalv1alpha1.ServerClassList
+
+		if err := c.List(ctx, &serverClasses); err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+
+		for _, serverClass := range serverClasses.Items {
+			if _, ok := serverClass.Annotations[metalv1alpha1.ProposedAnnotation]; ok {
+				names = append(names, serverClass.Name)
+			}
+		}
+
+		sort.Strings(names)
+
+		return names
+	}
+
+	reconcile := func() {
+		if _, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: serverProfilerRequest}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reconcile()
+
+	// server "c" is classified by "rack-b", catch-all serverclass doesn't classify the servers
+	if names := proposed(); !reflect.DeepEqual(names, []string{r640.Name()}) {
+		t.Fatalf("proposed serverclasses %v, want %v", names, []string{r640.Name()})
+	}
+
+	var proposal metalv1alpha1.ServerClass
+
+	if err := c.Get(ctx, types.NamespacedName{Name: r640.Name()}, &proposal); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(proposal.Spec.Qualifiers, r640.Qualifiers()) {
+		t.Errorf("proposed qualifiers %v, want %v", proposal.Spec.Qualifiers, r640.Qualifiers())
+	}
+
+	// proposal edited by the operator is kept as is
+	proposal.Spec.Qualifiers.LabelSelectors = []map[string]string{{"rack": "a"}}
+
+	if err := c.Update(ctx, &proposal); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcile()
+
+	var edited metalv1alpha1.ServerClass
+
+	if err := c.Get(ctx, types.NamespacedName{Name: r640.Name()}, &edited); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(edited.Spec.Qualifiers.LabelSelectors) != 1 {
+		t.Error("edited proposal is overwritten")
+	}
+
+	// deleted serverclass leaves the servers unclassified
+	if err := c.Delete(ctx, &rackB); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Delete(ctx, &edited); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcile()
+
+	expected := []string{r640.Name(), r740.Name()}
+	sort.Strings(expected)
+
+	if names := proposed(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("proposed serverclasses %v, want %v", names, expected)
+	}
+}
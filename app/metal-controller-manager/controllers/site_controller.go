@@ -0,0 +1,137 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// SiteReconciler reconciles a Site object.
+type SiteReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=sites,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=sites/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;update;patch
+
+func (r *SiteReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("site", req.NamespacedName)
+
+	var site metalv1alpha1.Site
+
+	if err := r.Get(ctx, req.NamespacedName, &site); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	patchHelper, err := patch.NewHelper(&site, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var servers metalv1alpha1.ServerList
+
+	if err = r.List(ctx, &servers); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to list servers: %w", err)
+	}
+
+	status := metalv1alpha1.SiteStatus{}
+
+	for i := range servers.Items {
+		server := &servers.Items[i]
+
+		if _, ok := server.Labels[metalv1alpha1.SiteLabel]; !ok && site.ContainsSubnet(server.Labels[metalv1alpha1.ProvisioningSubnetLabel]) {
+			if err = r.labelServer(ctx, server, site.Name); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			log.Info("server is located at the site", "server", server.Name)
+		}
+
+		if server.Labels[metalv1alpha1.SiteLabel] != site.Name {
+			continue
+		}
+
+		status.Servers++
+
+		switch {
+		case server.Status.InUse:
+			status.ServersInUse++
+		case server.Spec.Accepted && server.Status.IsClean:
+			status.ServersAvailable++
+		}
+	}
+
+	if site.Status == status {
+		return ctrl.Result{}, nil
+	}
+
+	site.Status = status
+
+	return ctrl.Result{}, patchHelper.Patch(ctx, &site)
+}
+
+func (r *SiteReconciler) labelServer(ctx context.Context, server *metalv1alpha1.Server, site string) error {
+	patchHelper, err := patch.NewHelper(server, r)
+	if err != nil {
+		return err
+	}
+
+	if server.Labels == nil {
+		server.Labels = map[string]string{}
+	}
+
+	server.Labels[metalv1alpha1.SiteLabel] = site
+
+	return patchHelper.Patch(ctx, server)
+}
+
+func (r *SiteReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	// servers can be located at any site, so all the sites are reconciled on server changes
+	mapRequests := handler.ToRequestsFunc(
+		func(a handler.MapObject) []reconcile.Request {
+			var sites metalv1alpha1.SiteList
+
+			if err := r.List(context.Background(), &sites); err != nil {
+				return nil
+			}
+
+			reqList := make([]reconcile.Request, 0, len(sites.Items))
+
+			for _, site := range sites.Items {
+				reqList = append(reqList, reconcile.Request{NamespacedName: types.NamespacedName{Name: site.Name}})
+			}
+
+			return reqList
+		})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&metalv1alpha1.Site{}).
+		Watches(
+			&source.Kind{Type: &metalv1alpha1.Server{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: mapRequests,
+			},
+		).
+		Complete(r)
+}
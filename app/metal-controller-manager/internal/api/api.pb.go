@@ -121,6 +121,9 @@ func (m *SystemInformation) GetFamily() string {
 type CPU struct {
 	Manufacturer         string   `protobuf:"bytes,1,opt,name=manufacturer,proto3" json:"manufacturer,omitempty"`
 	Version              string   `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Cores                uint32   `protobuf:"varint,3,opt,name=cores,proto3" json:"cores,omitempty"`
+	Threads              uint32   `protobuf:"varint,4,opt,name=threads,proto3" json:"threads,omitempty"`
+	FrequencyMhz         uint32   `protobuf:"varint,5,opt,name=frequency_mhz,json=frequencyMhz,proto3" json:"frequency_mhz,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -169,20 +172,371 @@ func (m *CPU) GetVersion() string {
 	return ""
 }
 
+func (m *CPU) GetCores() uint32 {
+	if m != nil {
+		return m.Cores
+	}
+	return 0
+}
+
+func (m *CPU) GetThreads() uint32 {
+	if m != nil {
+		return m.Threads
+	}
+	return 0
+}
+
+func (m *CPU) GetFrequencyMhz() uint32 {
+	if m != nil {
+		return m.FrequencyMhz
+	}
+	return 0
+}
+
+type NetworkInterface struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Mac                  string   `protobuf:"bytes,2,opt,name=mac,proto3" json:"mac,omitempty"`
+	Speed                uint32   `protobuf:"varint,3,opt,name=speed,proto3" json:"speed,omitempty"`
+	Driver               string   `protobuf:"bytes,4,opt,name=driver,proto3" json:"driver,omitempty"`
+	VendorId             string   `protobuf:"bytes,5,opt,name=vendor_id,json=vendorId,proto3" json:"vendor_id,omitempty"`
+	DeviceId             string   `protobuf:"bytes,6,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NetworkInterface) Reset()         { *m = NetworkInterface{} }
+func (m *NetworkInterface) String() string { return proto.CompactTextString(m) }
+func (*NetworkInterface) ProtoMessage()    {}
+func (*NetworkInterface) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{2}
+}
+
+func (m *NetworkInterface) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NetworkInterface.Unmarshal(m, b)
+}
+
+func (m *NetworkInterface) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NetworkInterface.Marshal(b, m, deterministic)
+}
+
+func (m *NetworkInterface) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NetworkInterface.Merge(m, src)
+}
+
+func (m *NetworkInterface) XXX_Size() int {
+	return xxx_messageInfo_NetworkInterface.Size(m)
+}
+
+func (m *NetworkInterface) XXX_DiscardUnknown() {
+	xxx_messageInfo_NetworkInterface.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NetworkInterface proto.InternalMessageInfo
+
+func (m *NetworkInterface) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *NetworkInterface) GetMac() string {
+	if m != nil {
+		return m.Mac
+	}
+	return ""
+}
+
+func (m *NetworkInterface) GetSpeed() uint32 {
+	if m != nil {
+		return m.Speed
+	}
+	return 0
+}
+
+func (m *NetworkInterface) GetDriver() string {
+	if m != nil {
+		return m.Driver
+	}
+	return ""
+}
+
+func (m *NetworkInterface) GetVendorId() string {
+	if m != nil {
+		return m.VendorId
+	}
+	return ""
+}
+
+func (m *NetworkInterface) GetDeviceId() string {
+	if m != nil {
+		return m.DeviceId
+	}
+	return ""
+}
+
+type PCIDevice struct {
+	Address              string   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Class                string   `protobuf:"bytes,2,opt,name=class,proto3" json:"class,omitempty"`
+	VendorId             string   `protobuf:"bytes,3,opt,name=vendor_id,json=vendorId,proto3" json:"vendor_id,omitempty"`
+	DeviceId             string   `protobuf:"bytes,4,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	SubsystemVendorId    string   `protobuf:"bytes,5,opt,name=subsystem_vendor_id,json=subsystemVendorId,proto3" json:"subsystem_vendor_id,omitempty"`
+	SubsystemDeviceId    string   `protobuf:"bytes,6,opt,name=subsystem_device_id,json=subsystemDeviceId,proto3" json:"subsystem_device_id,omitempty"`
+	Driver               string   `protobuf:"bytes,7,opt,name=driver,proto3" json:"driver,omitempty"`
+	Model                string   `protobuf:"bytes,8,opt,name=model,proto3" json:"model,omitempty"`
+	Namespaces           uint32   `protobuf:"varint,9,opt,name=namespaces,proto3" json:"namespaces,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PCIDevice) Reset()         { *m = PCIDevice{} }
+func (m *PCIDevice) String() string { return proto.CompactTextString(m) }
+func (*PCIDevice) ProtoMessage()    {}
+func (*PCIDevice) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{3}
+}
+
+func (m *PCIDevice) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PCIDevice.Unmarshal(m, b)
+}
+
+func (m *PCIDevice) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PCIDevice.Marshal(b, m, deterministic)
+}
+
+func (m *PCIDevice) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PCIDevice.Merge(m, src)
+}
+
+func (m *PCIDevice) XXX_Size() int {
+	return xxx_messageInfo_PCIDevice.Size(m)
+}
+
+func (m *PCIDevice) XXX_DiscardUnknown() {
+	xxx_messageInfo_PCIDevice.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PCIDevice proto.InternalMessageInfo
+
+func (m *PCIDevice) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *PCIDevice) GetClass() string {
+	if m != nil {
+		return m.Class
+	}
+	return ""
+}
+
+func (m *PCIDevice) GetVendorId() string {
+	if m != nil {
+		return m.VendorId
+	}
+	return ""
+}
+
+func (m *PCIDevice) GetDeviceId() string {
+	if m != nil {
+		return m.DeviceId
+	}
+	return ""
+}
+
+func (m *PCIDevice) GetSubsystemVendorId() string {
+	if m != nil {
+		return m.SubsystemVendorId
+	}
+	return ""
+}
+
+func (m *PCIDevice) GetSubsystemDeviceId() string {
+	if m != nil {
+		return m.SubsystemDeviceId
+	}
+	return ""
+}
+
+func (m *PCIDevice) GetDriver() string {
+	if m != nil {
+		return m.Driver
+	}
+	return ""
+}
+
+func (m *PCIDevice) GetModel() string {
+	if m != nil {
+		return m.Model
+	}
+	return ""
+}
+
+func (m *PCIDevice) GetNamespaces() uint32 {
+	if m != nil {
+		return m.Namespaces
+	}
+	return 0
+}
+
+type Disk struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Size                 uint64   `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Model                string   `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	Serial               string   `protobuf:"bytes,4,opt,name=serial,proto3" json:"serial,omitempty"`
+	Wwid                 string   `protobuf:"bytes,5,opt,name=wwid,proto3" json:"wwid,omitempty"`
+	Rotational           bool     `protobuf:"varint,6,opt,name=rotational,proto3" json:"rotational,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Disk) Reset()         { *m = Disk{} }
+func (m *Disk) String() string { return proto.CompactTextString(m) }
+func (*Disk) ProtoMessage()    {}
+func (*Disk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{4}
+}
+
+func (m *Disk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Disk.Unmarshal(m, b)
+}
+
+func (m *Disk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Disk.Marshal(b, m, deterministic)
+}
+
+func (m *Disk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Disk.Merge(m, src)
+}
+
+func (m *Disk) XXX_Size() int {
+	return xxx_messageInfo_Disk.Size(m)
+}
+
+func (m *Disk) XXX_DiscardUnknown() {
+	xxx_messageInfo_Disk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Disk proto.InternalMessageInfo
+
+func (m *Disk) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Disk) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *Disk) GetModel() string {
+	if m != nil {
+		return m.Model
+	}
+	return ""
+}
+
+func (m *Disk) GetSerial() string {
+	if m != nil {
+		return m.Serial
+	}
+	return ""
+}
+
+func (m *Disk) GetWwid() string {
+	if m != nil {
+		return m.Wwid
+	}
+	return ""
+}
+
+func (m *Disk) GetRotational() bool {
+	if m != nil {
+		return m.Rotational
+	}
+	return false
+}
+
+type Memory struct {
+	Size                 uint64   `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Modules              uint32   `protobuf:"varint,2,opt,name=modules,proto3" json:"modules,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Memory) Reset()         { *m = Memory{} }
+func (m *Memory) String() string { return proto.CompactTextString(m) }
+func (*Memory) ProtoMessage()    {}
+func (*Memory) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{5}
+}
+
+func (m *Memory) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Memory.Unmarshal(m, b)
+}
+
+func (m *Memory) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Memory.Marshal(b, m, deterministic)
+}
+
+func (m *Memory) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Memory.Merge(m, src)
+}
+
+func (m *Memory) XXX_Size() int {
+	return xxx_messageInfo_Memory.Size(m)
+}
+
+func (m *Memory) XXX_DiscardUnknown() {
+	xxx_messageInfo_Memory.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Memory proto.InternalMessageInfo
+
+func (m *Memory) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *Memory) GetModules() uint32 {
+	if m != nil {
+		return m.Modules
+	}
+	return 0
+}
+
 type CreateServerRequest struct {
-	SystemInformation    *SystemInformation `protobuf:"bytes,1,opt,name=system_information,json=systemInformation,proto3" json:"system_information,omitempty"`
-	Cpu                  *CPU               `protobuf:"bytes,2,opt,name=cpu,proto3" json:"cpu,omitempty"`
-	Hostname             string             `protobuf:"bytes,3,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
-	XXX_unrecognized     []byte             `json:"-"`
-	XXX_sizecache        int32              `json:"-"`
+	SystemInformation    *SystemInformation  `protobuf:"bytes,1,opt,name=system_information,json=systemInformation,proto3" json:"system_information,omitempty"`
+	Cpu                  *CPU                `protobuf:"bytes,2,opt,name=cpu,proto3" json:"cpu,omitempty"`
+	Hostname             string              `protobuf:"bytes,3,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	NetworkInterface     []*NetworkInterface `protobuf:"bytes,4,rep,name=network_interface,json=networkInterface,proto3" json:"network_interface,omitempty"`
+	PciDevice            []*PCIDevice        `protobuf:"bytes,5,rep,name=pci_device,json=pciDevice,proto3" json:"pci_device,omitempty"`
+	ProvisioningAddress  string              `protobuf:"bytes,6,opt,name=provisioning_address,json=provisioningAddress,proto3" json:"provisioning_address,omitempty"`
+	ProvisioningGateway  string              `protobuf:"bytes,7,opt,name=provisioning_gateway,json=provisioningGateway,proto3" json:"provisioning_gateway,omitempty"`
+	Disk                 []*Disk             `protobuf:"bytes,8,rep,name=disk,proto3" json:"disk,omitempty"`
+	Memory               *Memory             `protobuf:"bytes,9,opt,name=memory,proto3" json:"memory,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
 }
 
 func (m *CreateServerRequest) Reset()         { *m = CreateServerRequest{} }
 func (m *CreateServerRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateServerRequest) ProtoMessage()    {}
 func (*CreateServerRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{2}
+	return fileDescriptor_00212fb1f9d3bf1c, []int{6}
 }
 
 func (m *CreateServerRequest) XXX_Unmarshal(b []byte) error {
@@ -228,6 +582,48 @@ func (m *CreateServerRequest) GetHostname() string {
 	return ""
 }
 
+func (m *CreateServerRequest) GetNetworkInterface() []*NetworkInterface {
+	if m != nil {
+		return m.NetworkInterface
+	}
+	return nil
+}
+
+func (m *CreateServerRequest) GetPciDevice() []*PCIDevice {
+	if m != nil {
+		return m.PciDevice
+	}
+	return nil
+}
+
+func (m *CreateServerRequest) GetProvisioningAddress() string {
+	if m != nil {
+		return m.ProvisioningAddress
+	}
+	return ""
+}
+
+func (m *CreateServerRequest) GetProvisioningGateway() string {
+	if m != nil {
+		return m.ProvisioningGateway
+	}
+	return ""
+}
+
+func (m *CreateServerRequest) GetDisk() []*Disk {
+	if m != nil {
+		return m.Disk
+	}
+	return nil
+}
+
+func (m *CreateServerRequest) GetMemory() *Memory {
+	if m != nil {
+		return m.Memory
+	}
+	return nil
+}
+
 type Address struct {
 	Type                 string   `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
 	Address              string   `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
@@ -240,7 +636,7 @@ func (m *Address) Reset()         { *m = Address{} }
 func (m *Address) String() string { return proto.CompactTextString(m) }
 func (*Address) ProtoMessage()    {}
 func (*Address) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{3}
+	return fileDescriptor_00212fb1f9d3bf1c, []int{7}
 }
 
 func (m *Address) XXX_Unmarshal(b []byte) error {
@@ -280,19 +676,22 @@ func (m *Address) GetAddress() string {
 }
 
 type CreateServerResponse struct {
-	Wipe                 bool     `protobuf:"varint,1,opt,name=wipe,proto3" json:"wipe,omitempty"`
-	InsecureWipe         bool     `protobuf:"varint,2,opt,name=insecure_wipe,json=insecureWipe,proto3" json:"insecure_wipe,omitempty"`
-	RebootTimeout        float64  `protobuf:"fixed64,3,opt,name=reboot_timeout,json=rebootTimeout,proto3" json:"reboot_timeout,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Wipe                 bool        `protobuf:"varint,1,opt,name=wipe,proto3" json:"wipe,omitempty"`
+	InsecureWipe         bool        `protobuf:"varint,2,opt,name=insecure_wipe,json=insecureWipe,proto3" json:"insecure_wipe,omitempty"`
+	RebootTimeout        float64     `protobuf:"fixed64,3,opt,name=reboot_timeout,json=rebootTimeout,proto3" json:"reboot_timeout,omitempty"`
+	SetBootNext          bool        `protobuf:"varint,4,opt,name=set_boot_next,json=setBootNext,proto3" json:"set_boot_next,omitempty"`
+	WipeScope            string      `protobuf:"bytes,5,opt,name=wipe_scope,json=wipeScope,proto3" json:"wipe_scope,omitempty"`
+	VerifyDisk           []*DiskWipe `protobuf:"bytes,6,rep,name=verify_disk,json=verifyDisk,proto3" json:"verify_disk,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
 }
 
 func (m *CreateServerResponse) Reset()         { *m = CreateServerResponse{} }
 func (m *CreateServerResponse) String() string { return proto.CompactTextString(m) }
 func (*CreateServerResponse) ProtoMessage()    {}
 func (*CreateServerResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{4}
+	return fileDescriptor_00212fb1f9d3bf1c, []int{8}
 }
 
 func (m *CreateServerResponse) XXX_Unmarshal(b []byte) error {
@@ -307,291 +706,788 @@ func (m *CreateServerResponse) XXX_Merge(src proto.Message) {
 	xxx_messageInfo_CreateServerResponse.Merge(m, src)
 }
 
-func (m *CreateServerResponse) XXX_Size() int {
-	return xxx_messageInfo_CreateServerResponse.Size(m)
+func (m *CreateServerResponse) XXX_Size() int {
+	return xxx_messageInfo_CreateServerResponse.Size(m)
+}
+
+func (m *CreateServerResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateServerResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateServerResponse proto.InternalMessageInfo
+
+func (m *CreateServerResponse) GetWipe() bool {
+	if m != nil {
+		return m.Wipe
+	}
+	return false
+}
+
+func (m *CreateServerResponse) GetInsecureWipe() bool {
+	if m != nil {
+		return m.InsecureWipe
+	}
+	return false
+}
+
+func (m *CreateServerResponse) GetRebootTimeout() float64 {
+	if m != nil {
+		return m.RebootTimeout
+	}
+	return 0
+}
+
+func (m *CreateServerResponse) GetSetBootNext() bool {
+	if m != nil {
+		return m.SetBootNext
+	}
+	return false
+}
+
+func (m *CreateServerResponse) GetWipeScope() string {
+	if m != nil {
+		return m.WipeScope
+	}
+	return ""
+}
+
+func (m *CreateServerResponse) GetVerifyDisk() []*DiskWipe {
+	if m != nil {
+		return m.VerifyDisk
+	}
+	return nil
+}
+
+type DiskWipe struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Serial               string   `protobuf:"bytes,2,opt,name=serial,proto3" json:"serial,omitempty"`
+	Method               string   `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`
+	SampleHash           string   `protobuf:"bytes,4,opt,name=sample_hash,json=sampleHash,proto3" json:"sample_hash,omitempty"`
+	Samples              uint32   `protobuf:"varint,5,opt,name=samples,proto3" json:"samples,omitempty"`
+	Zeroed               bool     `protobuf:"varint,6,opt,name=zeroed,proto3" json:"zeroed,omitempty"`
+	Partition            string   `protobuf:"bytes,7,opt,name=partition,proto3" json:"partition,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DiskWipe) Reset()         { *m = DiskWipe{} }
+func (m *DiskWipe) String() string { return proto.CompactTextString(m) }
+func (*DiskWipe) ProtoMessage()    {}
+func (*DiskWipe) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{9}
+}
+
+func (m *DiskWipe) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DiskWipe.Unmarshal(m, b)
+}
+
+func (m *DiskWipe) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DiskWipe.Marshal(b, m, deterministic)
+}
+
+func (m *DiskWipe) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DiskWipe.Merge(m, src)
+}
+
+func (m *DiskWipe) XXX_Size() int {
+	return xxx_messageInfo_DiskWipe.Size(m)
+}
+
+func (m *DiskWipe) XXX_DiscardUnknown() {
+	xxx_messageInfo_DiskWipe.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DiskWipe proto.InternalMessageInfo
+
+func (m *DiskWipe) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *DiskWipe) GetSerial() string {
+	if m != nil {
+		return m.Serial
+	}
+	return ""
+}
+
+func (m *DiskWipe) GetMethod() string {
+	if m != nil {
+		return m.Method
+	}
+	return ""
+}
+
+func (m *DiskWipe) GetSampleHash() string {
+	if m != nil {
+		return m.SampleHash
+	}
+	return ""
+}
+
+func (m *DiskWipe) GetSamples() uint32 {
+	if m != nil {
+		return m.Samples
+	}
+	return 0
+}
+
+func (m *DiskWipe) GetZeroed() bool {
+	if m != nil {
+		return m.Zeroed
+	}
+	return false
+}
+
+func (m *DiskWipe) GetPartition() string {
+	if m != nil {
+		return m.Partition
+	}
+	return ""
+}
+
+type MarkServerAsWipedRequest struct {
+	Uuid                 string      `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Disk                 []*DiskWipe `protobuf:"bytes,2,rep,name=disk,proto3" json:"disk,omitempty"`
+	Timestamp            int64       `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *MarkServerAsWipedRequest) Reset()         { *m = MarkServerAsWipedRequest{} }
+func (m *MarkServerAsWipedRequest) String() string { return proto.CompactTextString(m) }
+func (*MarkServerAsWipedRequest) ProtoMessage()    {}
+func (*MarkServerAsWipedRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{10}
+}
+
+func (m *MarkServerAsWipedRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MarkServerAsWipedRequest.Unmarshal(m, b)
+}
+
+func (m *MarkServerAsWipedRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MarkServerAsWipedRequest.Marshal(b, m, deterministic)
+}
+
+func (m *MarkServerAsWipedRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MarkServerAsWipedRequest.Merge(m, src)
+}
+
+func (m *MarkServerAsWipedRequest) XXX_Size() int {
+	return xxx_messageInfo_MarkServerAsWipedRequest.Size(m)
+}
+
+func (m *MarkServerAsWipedRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MarkServerAsWipedRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MarkServerAsWipedRequest proto.InternalMessageInfo
+
+func (m *MarkServerAsWipedRequest) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *MarkServerAsWipedRequest) GetDisk() []*DiskWipe {
+	if m != nil {
+		return m.Disk
+	}
+	return nil
+}
+
+func (m *MarkServerAsWipedRequest) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+type HeartbeatRequest struct {
+	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HeartbeatRequest) Reset()         { *m = HeartbeatRequest{} }
+func (m *HeartbeatRequest) String() string { return proto.CompactTextString(m) }
+func (*HeartbeatRequest) ProtoMessage()    {}
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{11}
+}
+
+func (m *HeartbeatRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HeartbeatRequest.Unmarshal(m, b)
+}
+
+func (m *HeartbeatRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HeartbeatRequest.Marshal(b, m, deterministic)
+}
+
+func (m *HeartbeatRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeartbeatRequest.Merge(m, src)
+}
+
+func (m *HeartbeatRequest) XXX_Size() int {
+	return xxx_messageInfo_HeartbeatRequest.Size(m)
+}
+
+func (m *HeartbeatRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeartbeatRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeartbeatRequest proto.InternalMessageInfo
+
+func (m *HeartbeatRequest) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+type MarkServerAsWipedResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MarkServerAsWipedResponse) Reset()         { *m = MarkServerAsWipedResponse{} }
+func (m *MarkServerAsWipedResponse) String() string { return proto.CompactTextString(m) }
+func (*MarkServerAsWipedResponse) ProtoMessage()    {}
+func (*MarkServerAsWipedResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{12}
+}
+
+func (m *MarkServerAsWipedResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MarkServerAsWipedResponse.Unmarshal(m, b)
+}
+
+func (m *MarkServerAsWipedResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MarkServerAsWipedResponse.Marshal(b, m, deterministic)
+}
+
+func (m *MarkServerAsWipedResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MarkServerAsWipedResponse.Merge(m, src)
+}
+
+func (m *MarkServerAsWipedResponse) XXX_Size() int {
+	return xxx_messageInfo_MarkServerAsWipedResponse.Size(m)
+}
+
+func (m *MarkServerAsWipedResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MarkServerAsWipedResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MarkServerAsWipedResponse proto.InternalMessageInfo
+
+type HeartbeatResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HeartbeatResponse) Reset()         { *m = HeartbeatResponse{} }
+func (m *HeartbeatResponse) String() string { return proto.CompactTextString(m) }
+func (*HeartbeatResponse) ProtoMessage()    {}
+func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{13}
+}
+
+func (m *HeartbeatResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HeartbeatResponse.Unmarshal(m, b)
+}
+
+func (m *HeartbeatResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HeartbeatResponse.Marshal(b, m, deterministic)
+}
+
+func (m *HeartbeatResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeartbeatResponse.Merge(m, src)
+}
+
+func (m *HeartbeatResponse) XXX_Size() int {
+	return xxx_messageInfo_HeartbeatResponse.Size(m)
+}
+
+func (m *HeartbeatResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeartbeatResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeartbeatResponse proto.InternalMessageInfo
+
+type ReconcileServerAddressesRequest struct {
+	Uuid                 string     `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Address              []*Address `protobuf:"bytes,2,rep,name=address,proto3" json:"address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *ReconcileServerAddressesRequest) Reset()         { *m = ReconcileServerAddressesRequest{} }
+func (m *ReconcileServerAddressesRequest) String() string { return proto.CompactTextString(m) }
+func (*ReconcileServerAddressesRequest) ProtoMessage()    {}
+func (*ReconcileServerAddressesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{14}
+}
+
+func (m *ReconcileServerAddressesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReconcileServerAddressesRequest.Unmarshal(m, b)
+}
+
+func (m *ReconcileServerAddressesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReconcileServerAddressesRequest.Marshal(b, m, deterministic)
+}
+
+func (m *ReconcileServerAddressesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReconcileServerAddressesRequest.Merge(m, src)
+}
+
+func (m *ReconcileServerAddressesRequest) XXX_Size() int {
+	return xxx_messageInfo_ReconcileServerAddressesRequest.Size(m)
+}
+
+func (m *ReconcileServerAddressesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReconcileServerAddressesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReconcileServerAddressesRequest proto.InternalMessageInfo
+
+func (m *ReconcileServerAddressesRequest) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *ReconcileServerAddressesRequest) GetAddress() []*Address {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+type ReconcileServerAddressesResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReconcileServerAddressesResponse) Reset()         { *m = ReconcileServerAddressesResponse{} }
+func (m *ReconcileServerAddressesResponse) String() string { return proto.CompactTextString(m) }
+func (*ReconcileServerAddressesResponse) ProtoMessage()    {}
+func (*ReconcileServerAddressesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{15}
+}
+
+func (m *ReconcileServerAddressesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReconcileServerAddressesResponse.Unmarshal(m, b)
+}
+
+func (m *ReconcileServerAddressesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReconcileServerAddressesResponse.Marshal(b, m, deterministic)
+}
+
+func (m *ReconcileServerAddressesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReconcileServerAddressesResponse.Merge(m, src)
+}
+
+func (m *ReconcileServerAddressesResponse) XXX_Size() int {
+	return xxx_messageInfo_ReconcileServerAddressesResponse.Size(m)
+}
+
+func (m *ReconcileServerAddressesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReconcileServerAddressesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReconcileServerAddressesResponse proto.InternalMessageInfo
+
+type LLDPNeighbor struct {
+	Interface            string   `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	ChassisId            string   `protobuf:"bytes,2,opt,name=chassis_id,json=chassisId,proto3" json:"chassis_id,omitempty"`
+	PortId               string   `protobuf:"bytes,3,opt,name=port_id,json=portId,proto3" json:"port_id,omitempty"`
+	PortDescription      string   `protobuf:"bytes,4,opt,name=port_description,json=portDescription,proto3" json:"port_description,omitempty"`
+	SystemName           string   `protobuf:"bytes,5,opt,name=system_name,json=systemName,proto3" json:"system_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LLDPNeighbor) Reset()         { *m = LLDPNeighbor{} }
+func (m *LLDPNeighbor) String() string { return proto.CompactTextString(m) }
+func (*LLDPNeighbor) ProtoMessage()    {}
+func (*LLDPNeighbor) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{16}
+}
+
+func (m *LLDPNeighbor) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LLDPNeighbor.Unmarshal(m, b)
+}
+
+func (m *LLDPNeighbor) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LLDPNeighbor.Marshal(b, m, deterministic)
+}
+
+func (m *LLDPNeighbor) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LLDPNeighbor.Merge(m, src)
+}
+
+func (m *LLDPNeighbor) XXX_Size() int {
+	return xxx_messageInfo_LLDPNeighbor.Size(m)
+}
+
+func (m *LLDPNeighbor) XXX_DiscardUnknown() {
+	xxx_messageInfo_LLDPNeighbor.DiscardUnknown(m)
 }
 
-func (m *CreateServerResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_CreateServerResponse.DiscardUnknown(m)
+var xxx_messageInfo_LLDPNeighbor proto.InternalMessageInfo
+
+func (m *LLDPNeighbor) GetInterface() string {
+	if m != nil {
+		return m.Interface
+	}
+	return ""
 }
 
-var xxx_messageInfo_CreateServerResponse proto.InternalMessageInfo
+func (m *LLDPNeighbor) GetChassisId() string {
+	if m != nil {
+		return m.ChassisId
+	}
+	return ""
+}
 
-func (m *CreateServerResponse) GetWipe() bool {
+func (m *LLDPNeighbor) GetPortId() string {
 	if m != nil {
-		return m.Wipe
+		return m.PortId
 	}
-	return false
+	return ""
 }
 
-func (m *CreateServerResponse) GetInsecureWipe() bool {
+func (m *LLDPNeighbor) GetPortDescription() string {
 	if m != nil {
-		return m.InsecureWipe
+		return m.PortDescription
 	}
-	return false
+	return ""
 }
 
-func (m *CreateServerResponse) GetRebootTimeout() float64 {
+func (m *LLDPNeighbor) GetSystemName() string {
 	if m != nil {
-		return m.RebootTimeout
+		return m.SystemName
 	}
-	return 0
+	return ""
 }
 
-type MarkServerAsWipedRequest struct {
-	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+type ReconcileServerLLDPNeighborsRequest struct {
+	Uuid                 string          `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Neighbor             []*LLDPNeighbor `protobuf:"bytes,2,rep,name=neighbor,proto3" json:"neighbor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
 }
 
-func (m *MarkServerAsWipedRequest) Reset()         { *m = MarkServerAsWipedRequest{} }
-func (m *MarkServerAsWipedRequest) String() string { return proto.CompactTextString(m) }
-func (*MarkServerAsWipedRequest) ProtoMessage()    {}
-func (*MarkServerAsWipedRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{5}
+func (m *ReconcileServerLLDPNeighborsRequest) Reset()         { *m = ReconcileServerLLDPNeighborsRequest{} }
+func (m *ReconcileServerLLDPNeighborsRequest) String() string { return proto.CompactTextString(m) }
+func (*ReconcileServerLLDPNeighborsRequest) ProtoMessage()    {}
+func (*ReconcileServerLLDPNeighborsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{17}
 }
 
-func (m *MarkServerAsWipedRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_MarkServerAsWipedRequest.Unmarshal(m, b)
+func (m *ReconcileServerLLDPNeighborsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReconcileServerLLDPNeighborsRequest.Unmarshal(m, b)
 }
 
-func (m *MarkServerAsWipedRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_MarkServerAsWipedRequest.Marshal(b, m, deterministic)
+func (m *ReconcileServerLLDPNeighborsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReconcileServerLLDPNeighborsRequest.Marshal(b, m, deterministic)
 }
 
-func (m *MarkServerAsWipedRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_MarkServerAsWipedRequest.Merge(m, src)
+func (m *ReconcileServerLLDPNeighborsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReconcileServerLLDPNeighborsRequest.Merge(m, src)
 }
 
-func (m *MarkServerAsWipedRequest) XXX_Size() int {
-	return xxx_messageInfo_MarkServerAsWipedRequest.Size(m)
+func (m *ReconcileServerLLDPNeighborsRequest) XXX_Size() int {
+	return xxx_messageInfo_ReconcileServerLLDPNeighborsRequest.Size(m)
 }
 
-func (m *MarkServerAsWipedRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_MarkServerAsWipedRequest.DiscardUnknown(m)
+func (m *ReconcileServerLLDPNeighborsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReconcileServerLLDPNeighborsRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_MarkServerAsWipedRequest proto.InternalMessageInfo
+var xxx_messageInfo_ReconcileServerLLDPNeighborsRequest proto.InternalMessageInfo
 
-func (m *MarkServerAsWipedRequest) GetUuid() string {
+func (m *ReconcileServerLLDPNeighborsRequest) GetUuid() string {
 	if m != nil {
 		return m.Uuid
 	}
 	return ""
 }
 
-type HeartbeatRequest struct {
-	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+func (m *ReconcileServerLLDPNeighborsRequest) GetNeighbor() []*LLDPNeighbor {
+	if m != nil {
+		return m.Neighbor
+	}
+	return nil
+}
+
+type ReconcileServerLLDPNeighborsResponse struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *HeartbeatRequest) Reset()         { *m = HeartbeatRequest{} }
-func (m *HeartbeatRequest) String() string { return proto.CompactTextString(m) }
-func (*HeartbeatRequest) ProtoMessage()    {}
-func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{6}
+func (m *ReconcileServerLLDPNeighborsResponse) Reset()         { *m = ReconcileServerLLDPNeighborsResponse{} }
+func (m *ReconcileServerLLDPNeighborsResponse) String() string { return proto.CompactTextString(m) }
+func (*ReconcileServerLLDPNeighborsResponse) ProtoMessage()    {}
+func (*ReconcileServerLLDPNeighborsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{18}
 }
 
-func (m *HeartbeatRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_HeartbeatRequest.Unmarshal(m, b)
+func (m *ReconcileServerLLDPNeighborsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReconcileServerLLDPNeighborsResponse.Unmarshal(m, b)
 }
 
-func (m *HeartbeatRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_HeartbeatRequest.Marshal(b, m, deterministic)
+func (m *ReconcileServerLLDPNeighborsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReconcileServerLLDPNeighborsResponse.Marshal(b, m, deterministic)
 }
 
-func (m *HeartbeatRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_HeartbeatRequest.Merge(m, src)
+func (m *ReconcileServerLLDPNeighborsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReconcileServerLLDPNeighborsResponse.Merge(m, src)
 }
 
-func (m *HeartbeatRequest) XXX_Size() int {
-	return xxx_messageInfo_HeartbeatRequest.Size(m)
+func (m *ReconcileServerLLDPNeighborsResponse) XXX_Size() int {
+	return xxx_messageInfo_ReconcileServerLLDPNeighborsResponse.Size(m)
 }
 
-func (m *HeartbeatRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_HeartbeatRequest.DiscardUnknown(m)
+func (m *ReconcileServerLLDPNeighborsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReconcileServerLLDPNeighborsResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_HeartbeatRequest proto.InternalMessageInfo
-
-func (m *HeartbeatRequest) GetUuid() string {
-	if m != nil {
-		return m.Uuid
-	}
-	return ""
-}
+var xxx_messageInfo_ReconcileServerLLDPNeighborsResponse proto.InternalMessageInfo
 
-type MarkServerAsWipedResponse struct {
+type ReconcileServerClockRequest struct {
+	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Time                 int64    `protobuf:"varint,2,opt,name=time,proto3" json:"time,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *MarkServerAsWipedResponse) Reset()         { *m = MarkServerAsWipedResponse{} }
-func (m *MarkServerAsWipedResponse) String() string { return proto.CompactTextString(m) }
-func (*MarkServerAsWipedResponse) ProtoMessage()    {}
-func (*MarkServerAsWipedResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{7}
+func (m *ReconcileServerClockRequest) Reset()         { *m = ReconcileServerClockRequest{} }
+func (m *ReconcileServerClockRequest) String() string { return proto.CompactTextString(m) }
+func (*ReconcileServerClockRequest) ProtoMessage()    {}
+func (*ReconcileServerClockRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{19}
 }
 
-func (m *MarkServerAsWipedResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_MarkServerAsWipedResponse.Unmarshal(m, b)
+func (m *ReconcileServerClockRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReconcileServerClockRequest.Unmarshal(m, b)
 }
 
-func (m *MarkServerAsWipedResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_MarkServerAsWipedResponse.Marshal(b, m, deterministic)
+func (m *ReconcileServerClockRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReconcileServerClockRequest.Marshal(b, m, deterministic)
 }
 
-func (m *MarkServerAsWipedResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_MarkServerAsWipedResponse.Merge(m, src)
+func (m *ReconcileServerClockRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReconcileServerClockRequest.Merge(m, src)
 }
 
-func (m *MarkServerAsWipedResponse) XXX_Size() int {
-	return xxx_messageInfo_MarkServerAsWipedResponse.Size(m)
+func (m *ReconcileServerClockRequest) XXX_Size() int {
+	return xxx_messageInfo_ReconcileServerClockRequest.Size(m)
 }
 
-func (m *MarkServerAsWipedResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_MarkServerAsWipedResponse.DiscardUnknown(m)
+func (m *ReconcileServerClockRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReconcileServerClockRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_MarkServerAsWipedResponse proto.InternalMessageInfo
+var xxx_messageInfo_ReconcileServerClockRequest proto.InternalMessageInfo
 
-type HeartbeatResponse struct {
+func (m *ReconcileServerClockRequest) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *ReconcileServerClockRequest) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+type ReconcileServerClockResponse struct {
+	Time                 int64    `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`
+	MaxSkew              float64  `protobuf:"fixed64,2,opt,name=max_skew,json=maxSkew,proto3" json:"max_skew,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *HeartbeatResponse) Reset()         { *m = HeartbeatResponse{} }
-func (m *HeartbeatResponse) String() string { return proto.CompactTextString(m) }
-func (*HeartbeatResponse) ProtoMessage()    {}
-func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{8}
+func (m *ReconcileServerClockResponse) Reset()         { *m = ReconcileServerClockResponse{} }
+func (m *ReconcileServerClockResponse) String() string { return proto.CompactTextString(m) }
+func (*ReconcileServerClockResponse) ProtoMessage()    {}
+func (*ReconcileServerClockResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{20}
 }
 
-func (m *HeartbeatResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_HeartbeatResponse.Unmarshal(m, b)
+func (m *ReconcileServerClockResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReconcileServerClockResponse.Unmarshal(m, b)
 }
 
-func (m *HeartbeatResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_HeartbeatResponse.Marshal(b, m, deterministic)
+func (m *ReconcileServerClockResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReconcileServerClockResponse.Marshal(b, m, deterministic)
 }
 
-func (m *HeartbeatResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_HeartbeatResponse.Merge(m, src)
+func (m *ReconcileServerClockResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReconcileServerClockResponse.Merge(m, src)
 }
 
-func (m *HeartbeatResponse) XXX_Size() int {
-	return xxx_messageInfo_HeartbeatResponse.Size(m)
+func (m *ReconcileServerClockResponse) XXX_Size() int {
+	return xxx_messageInfo_ReconcileServerClockResponse.Size(m)
 }
 
-func (m *HeartbeatResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_HeartbeatResponse.DiscardUnknown(m)
+func (m *ReconcileServerClockResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReconcileServerClockResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_HeartbeatResponse proto.InternalMessageInfo
+var xxx_messageInfo_ReconcileServerClockResponse proto.InternalMessageInfo
 
-type ReconcileServerAddressesRequest struct {
-	Uuid                 string     `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
-	Address              []*Address `protobuf:"bytes,2,rep,name=address,proto3" json:"address,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
-	XXX_unrecognized     []byte     `json:"-"`
-	XXX_sizecache        int32      `json:"-"`
+func (m *ReconcileServerClockResponse) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
 }
 
-func (m *ReconcileServerAddressesRequest) Reset()         { *m = ReconcileServerAddressesRequest{} }
-func (m *ReconcileServerAddressesRequest) String() string { return proto.CompactTextString(m) }
-func (*ReconcileServerAddressesRequest) ProtoMessage()    {}
-func (*ReconcileServerAddressesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{9}
+func (m *ReconcileServerClockResponse) GetMaxSkew() float64 {
+	if m != nil {
+		return m.MaxSkew
+	}
+	return 0
 }
 
-func (m *ReconcileServerAddressesRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ReconcileServerAddressesRequest.Unmarshal(m, b)
+type ReportLogsRequest struct {
+	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	BootId               string   `protobuf:"bytes,2,opt,name=boot_id,json=bootId,proto3" json:"boot_id,omitempty"`
+	Logs                 string   `protobuf:"bytes,3,opt,name=logs,proto3" json:"logs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ReconcileServerAddressesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ReconcileServerAddressesRequest.Marshal(b, m, deterministic)
+func (m *ReportLogsRequest) Reset()         { *m = ReportLogsRequest{} }
+func (m *ReportLogsRequest) String() string { return proto.CompactTextString(m) }
+func (*ReportLogsRequest) ProtoMessage()    {}
+func (*ReportLogsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{21}
 }
 
-func (m *ReconcileServerAddressesRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ReconcileServerAddressesRequest.Merge(m, src)
+func (m *ReportLogsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReportLogsRequest.Unmarshal(m, b)
 }
 
-func (m *ReconcileServerAddressesRequest) XXX_Size() int {
-	return xxx_messageInfo_ReconcileServerAddressesRequest.Size(m)
+func (m *ReportLogsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReportLogsRequest.Marshal(b, m, deterministic)
 }
 
-func (m *ReconcileServerAddressesRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_ReconcileServerAddressesRequest.DiscardUnknown(m)
+func (m *ReportLogsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReportLogsRequest.Merge(m, src)
 }
 
-var xxx_messageInfo_ReconcileServerAddressesRequest proto.InternalMessageInfo
+func (m *ReportLogsRequest) XXX_Size() int {
+	return xxx_messageInfo_ReportLogsRequest.Size(m)
+}
 
-func (m *ReconcileServerAddressesRequest) GetUuid() string {
+func (m *ReportLogsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReportLogsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReportLogsRequest proto.InternalMessageInfo
+
+func (m *ReportLogsRequest) GetUuid() string {
 	if m != nil {
 		return m.Uuid
 	}
 	return ""
 }
 
-func (m *ReconcileServerAddressesRequest) GetAddress() []*Address {
+func (m *ReportLogsRequest) GetBootId() string {
 	if m != nil {
-		return m.Address
+		return m.BootId
 	}
-	return nil
+	return ""
 }
 
-type ReconcileServerAddressesResponse struct {
+func (m *ReportLogsRequest) GetLogs() string {
+	if m != nil {
+		return m.Logs
+	}
+	return ""
+}
+
+type ReportLogsResponse struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ReconcileServerAddressesResponse) Reset()         { *m = ReconcileServerAddressesResponse{} }
-func (m *ReconcileServerAddressesResponse) String() string { return proto.CompactTextString(m) }
-func (*ReconcileServerAddressesResponse) ProtoMessage()    {}
-func (*ReconcileServerAddressesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{10}
+func (m *ReportLogsResponse) Reset()         { *m = ReportLogsResponse{} }
+func (m *ReportLogsResponse) String() string { return proto.CompactTextString(m) }
+func (*ReportLogsResponse) ProtoMessage()    {}
+func (*ReportLogsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{22}
 }
 
-func (m *ReconcileServerAddressesResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ReconcileServerAddressesResponse.Unmarshal(m, b)
+func (m *ReportLogsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReportLogsResponse.Unmarshal(m, b)
 }
 
-func (m *ReconcileServerAddressesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ReconcileServerAddressesResponse.Marshal(b, m, deterministic)
+func (m *ReportLogsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReportLogsResponse.Marshal(b, m, deterministic)
 }
 
-func (m *ReconcileServerAddressesResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ReconcileServerAddressesResponse.Merge(m, src)
+func (m *ReportLogsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReportLogsResponse.Merge(m, src)
 }
 
-func (m *ReconcileServerAddressesResponse) XXX_Size() int {
-	return xxx_messageInfo_ReconcileServerAddressesResponse.Size(m)
+func (m *ReportLogsResponse) XXX_Size() int {
+	return xxx_messageInfo_ReportLogsResponse.Size(m)
 }
 
-func (m *ReconcileServerAddressesResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_ReconcileServerAddressesResponse.DiscardUnknown(m)
+func (m *ReportLogsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReportLogsResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ReconcileServerAddressesResponse proto.InternalMessageInfo
+var xxx_messageInfo_ReportLogsResponse proto.InternalMessageInfo
 
 func init() {
 	proto.RegisterType((*SystemInformation)(nil), "api.SystemInformation")
 	proto.RegisterType((*CPU)(nil), "api.CPU")
+	proto.RegisterType((*NetworkInterface)(nil), "api.NetworkInterface")
+	proto.RegisterType((*PCIDevice)(nil), "api.PCIDevice")
+	proto.RegisterType((*Disk)(nil), "api.Disk")
+	proto.RegisterType((*Memory)(nil), "api.Memory")
 	proto.RegisterType((*CreateServerRequest)(nil), "api.CreateServerRequest")
 	proto.RegisterType((*Address)(nil), "api.Address")
 	proto.RegisterType((*CreateServerResponse)(nil), "api.CreateServerResponse")
+	proto.RegisterType((*DiskWipe)(nil), "api.DiskWipe")
 	proto.RegisterType((*MarkServerAsWipedRequest)(nil), "api.MarkServerAsWipedRequest")
 	proto.RegisterType((*HeartbeatRequest)(nil), "api.HeartbeatRequest")
 	proto.RegisterType((*MarkServerAsWipedResponse)(nil), "api.MarkServerAsWipedResponse")
 	proto.RegisterType((*HeartbeatResponse)(nil), "api.HeartbeatResponse")
 	proto.RegisterType((*ReconcileServerAddressesRequest)(nil), "api.ReconcileServerAddressesRequest")
 	proto.RegisterType((*ReconcileServerAddressesResponse)(nil), "api.ReconcileServerAddressesResponse")
+	proto.RegisterType((*LLDPNeighbor)(nil), "api.LLDPNeighbor")
+	proto.RegisterType((*ReconcileServerLLDPNeighborsRequest)(nil), "api.ReconcileServerLLDPNeighborsRequest")
+	proto.RegisterType((*ReconcileServerLLDPNeighborsResponse)(nil), "api.ReconcileServerLLDPNeighborsResponse")
+	proto.RegisterType((*ReconcileServerClockRequest)(nil), "api.ReconcileServerClockRequest")
+	proto.RegisterType((*ReconcileServerClockResponse)(nil), "api.ReconcileServerClockResponse")
+	proto.RegisterType((*ReportLogsRequest)(nil), "api.ReportLogsRequest")
+	proto.RegisterType((*ReportLogsResponse)(nil), "api.ReportLogsResponse")
 }
 
 func init() {
@@ -599,45 +1495,97 @@ func init() {
 }
 
 var fileDescriptor_00212fb1f9d3bf1c = []byte{
-	// 595 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x54, 0xdd, 0x6e, 0xd3, 0x30,
-	0x18, 0x55, 0xda, 0xfd, 0xf5, 0x6b, 0x87, 0xa8, 0x07, 0x53, 0x16, 0x34, 0x18, 0x81, 0x4d, 0xbb,
-	0x59, 0x23, 0x95, 0x0b, 0x24, 0xee, 0x46, 0x85, 0xc4, 0x84, 0x98, 0xa6, 0x8c, 0x09, 0x09, 0x09,
-	0x55, 0x6e, 0xf2, 0xad, 0xb3, 0x96, 0xd8, 0xc1, 0x3f, 0x43, 0x7d, 0x0f, 0x5e, 0x89, 0x37, 0xe1,
-	0x41, 0x50, 0x1c, 0x67, 0x6b, 0xd7, 0x76, 0xbb, 0xb3, 0xcf, 0xf9, 0x7e, 0xcf, 0x71, 0x02, 0x2d,
-	0x5a, 0xb0, 0x5e, 0x21, 0x85, 0x16, 0xa4, 0x49, 0x0b, 0x16, 0xfe, 0xf3, 0xa0, 0x7b, 0x3e, 0x51,
-	0x1a, 0xf3, 0x13, 0x7e, 0x29, 0x64, 0x4e, 0x35, 0x13, 0x9c, 0x10, 0x58, 0x31, 0x86, 0xa5, 0xbe,
-	0xb7, 0xe7, 0x1d, 0xb6, 0x62, 0x7b, 0x26, 0x21, 0x74, 0x72, 0xca, 0xcd, 0x25, 0x4d, 0xb4, 0x91,
-	0x28, 0xfd, 0x86, 0xe5, 0x66, 0x30, 0xf2, 0x1a, 0x3a, 0x85, 0x14, 0xa9, 0x49, 0xf4, 0x90, 0xd3,
-	0x1c, 0xfd, 0xa6, 0x8d, 0x69, 0x3b, 0xec, 0x94, 0xe6, 0x48, 0x7c, 0x58, 0xbf, 0x41, 0xa9, 0x98,
-	0xe0, 0xfe, 0x8a, 0x65, 0xeb, 0x2b, 0x79, 0x03, 0x9b, 0x0a, 0x25, 0xa3, 0xd9, 0x90, 0x9b, 0x7c,
-	0x84, 0xd2, 0x5f, 0xad, 0x3a, 0x54, 0xe0, 0xa9, 0xc5, 0xc8, 0x2e, 0x80, 0xba, 0x36, 0x75, 0xc4,
-	0x9a, 0x8d, 0x68, 0xa9, 0x6b, 0xe3, 0xe8, 0x6d, 0x58, 0xbb, 0xa4, 0x39, 0xcb, 0x26, 0xfe, 0xba,
-	0xa5, 0xdc, 0x2d, 0x1c, 0x40, 0x73, 0x70, 0x76, 0x31, 0xb7, 0x83, 0xb7, 0x60, 0x87, 0xa9, 0x01,
-	0x1b, 0x33, 0x03, 0x86, 0x7f, 0x3c, 0xd8, 0x1a, 0x48, 0xa4, 0x1a, 0xcf, 0x51, 0xde, 0xa0, 0x8c,
-	0xf1, 0x97, 0x41, 0xa5, 0xc9, 0x27, 0x20, 0xca, 0x4a, 0x38, 0x64, 0x77, 0x1a, 0xda, 0xda, 0xed,
-	0xfe, 0x76, 0xaf, 0x14, 0x7c, 0x4e, 0xe1, 0xb8, 0xab, 0xe6, 0x44, 0x0f, 0xa0, 0x99, 0x14, 0xc6,
-	0x36, 0x6d, 0xf7, 0x37, 0x6c, 0xde, 0xe0, 0xec, 0x22, 0x2e, 0x41, 0x12, 0xc0, 0xc6, 0x95, 0x50,
-	0x7a, 0x4a, 0xd4, 0xdb, 0x7b, 0xf8, 0x1e, 0xd6, 0x8f, 0xd3, 0x54, 0xa2, 0x52, 0xa5, 0x6f, 0x7a,
-	0x52, 0x60, 0xed, 0x5b, 0x79, 0x2e, 0xf7, 0xa1, 0x15, 0x5d, 0xef, 0xe3, 0xae, 0xe1, 0x0d, 0x3c,
-	0x9b, 0x5d, 0x47, 0x15, 0x82, 0x2b, 0x2c, 0xab, 0xfc, 0x66, 0xae, 0xca, 0x46, 0x6c, 0xcf, 0xa5,
-	0x39, 0x8c, 0x2b, 0x4c, 0x8c, 0xc4, 0xa1, 0x25, 0x1b, 0x96, 0xec, 0xd4, 0xe0, 0xf7, 0x32, 0x68,
-	0x1f, 0x9e, 0x48, 0x1c, 0x09, 0xa1, 0x87, 0x9a, 0xe5, 0x28, 0x8c, 0xb6, 0xb3, 0x7a, 0xf1, 0x66,
-	0x85, 0x7e, 0xab, 0xc0, 0xb0, 0x07, 0xfe, 0x57, 0x2a, 0xaf, 0xab, 0xae, 0xc7, 0xaa, 0x4c, 0x4d,
-	0x6b, 0x2d, 0x17, 0xbc, 0xbc, 0xf0, 0x00, 0x9e, 0x7e, 0x46, 0x2a, 0xf5, 0x08, 0xa9, 0x7e, 0x28,
-	0xee, 0x05, 0xec, 0x2c, 0xa8, 0x5b, 0x2d, 0x15, 0x6e, 0x41, 0x77, 0xaa, 0x88, 0x03, 0x7f, 0xc2,
-	0xab, 0x18, 0x13, 0xc1, 0x13, 0x96, 0x39, 0x11, 0x9c, 0x92, 0xa8, 0x1e, 0x68, 0x44, 0x0e, 0xa6,
-	0x25, 0x6d, 0x1e, 0xb6, 0xfb, 0x1d, 0xeb, 0x96, 0xcb, 0xbd, 0x13, 0x38, 0x84, 0xbd, 0xe5, 0xe5,
-	0xab, 0x11, 0xfa, 0x7f, 0x1b, 0xb0, 0x7a, 0x3c, 0x46, 0xae, 0xc9, 0x00, 0x3a, 0xd3, 0x76, 0x10,
-	0xbf, 0x7a, 0x02, 0xf3, 0x0f, 0x2e, 0xd8, 0x59, 0xc0, 0x38, 0xef, 0x62, 0xe8, 0xce, 0x69, 0x40,
-	0x76, 0x6d, 0xfc, 0x32, 0xcd, 0x83, 0x97, 0xcb, 0x68, 0x57, 0x73, 0x0c, 0xfe, 0xb2, 0x35, 0xc8,
-	0x5b, 0x9b, 0xfb, 0x88, 0x88, 0xc1, 0xfe, 0x23, 0x51, 0xae, 0xd1, 0x07, 0x68, 0xdd, 0x7a, 0x44,
-	0x9e, 0xdb, 0x9c, 0xfb, 0xc6, 0x07, 0xdb, 0xf7, 0xe1, 0x2a, 0xf7, 0xe3, 0x97, 0x1f, 0x27, 0x63,
-	0xa6, 0xaf, 0xcc, 0xa8, 0x97, 0x88, 0x3c, 0xd2, 0x34, 0x13, 0xea, 0xa8, 0xfa, 0xc6, 0x54, 0xa4,
-	0x58, 0x8a, 0x52, 0x44, 0xb4, 0x28, 0xa2, 0x1c, 0x35, 0xcd, 0x8e, 0x12, 0xc1, 0xb5, 0x14, 0x59,
-	0x86, 0xf2, 0x28, 0xa7, 0x9c, 0x8e, 0x51, 0x46, 0x8c, 0x6b, 0x94, 0x9c, 0x66, 0x11, 0x2d, 0xd8,
-	0x68, 0xcd, 0xfe, 0x21, 0xdf, 0xfd, 0x0f, 0x00, 0x00, 0xff, 0xff, 0x1f, 0x22, 0xab, 0xa4, 0x2e,
-	0x05, 0x00, 0x00,
+	// 1432 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x57, 0xdb, 0x6e, 0x1b, 0x37,
+	0x13, 0x86, 0x2c, 0x59, 0x87, 0x91, 0x9d, 0xdf, 0xa2, 0xfd, 0x3b, 0x1b, 0xe5, 0xe4, 0x6c, 0x0e,
+	0x48, 0x2e, 0x6c, 0xe3, 0xf7, 0x0f, 0xb4, 0x40, 0x81, 0x5e, 0x24, 0x76, 0xd0, 0x08, 0x8d, 0x0d,
+	0x63, 0x9d, 0xb4, 0x40, 0x8b, 0x42, 0xa0, 0x77, 0x69, 0x89, 0x90, 0x76, 0xb9, 0x25, 0xb9, 0x3e,
+	0x3d, 0x45, 0x2f, 0x7b, 0x59, 0xa0, 0x2f, 0xd0, 0x3e, 0x41, 0xdf, 0xa2, 0x4f, 0xd0, 0x9b, 0xbe,
+	0x45, 0xc1, 0x21, 0x57, 0xbb, 0x92, 0x65, 0xa5, 0x77, 0x9c, 0x6f, 0x86, 0x73, 0xe2, 0x37, 0xb3,
+	0x12, 0xb4, 0x68, 0xca, 0x77, 0x52, 0x29, 0xb4, 0x20, 0x55, 0x9a, 0x72, 0xff, 0xaf, 0x0a, 0x74,
+	0x4e, 0xae, 0x94, 0x66, 0x71, 0x2f, 0x39, 0x13, 0x32, 0xa6, 0x9a, 0x8b, 0x84, 0x10, 0xa8, 0x65,
+	0x19, 0x8f, 0xbc, 0xca, 0x56, 0xe5, 0x65, 0x2b, 0xc0, 0x33, 0xf1, 0x61, 0x25, 0xa6, 0x49, 0x76,
+	0x46, 0x43, 0x9d, 0x49, 0x26, 0xbd, 0x25, 0xd4, 0x4d, 0x61, 0xe4, 0x09, 0xac, 0xa4, 0x52, 0x44,
+	0x59, 0xa8, 0xfb, 0x09, 0x8d, 0x99, 0x57, 0x45, 0x9b, 0xb6, 0xc3, 0x8e, 0x68, 0xcc, 0x88, 0x07,
+	0x8d, 0x73, 0x26, 0x15, 0x17, 0x89, 0x57, 0x43, 0x6d, 0x2e, 0x92, 0xa7, 0xb0, 0xaa, 0x98, 0xe4,
+	0x74, 0xdc, 0x4f, 0xb2, 0xf8, 0x94, 0x49, 0x6f, 0xd9, 0x46, 0xb0, 0xe0, 0x11, 0x62, 0xe4, 0x21,
+	0x80, 0x1a, 0x65, 0xb9, 0x45, 0x1d, 0x2d, 0x5a, 0x6a, 0x94, 0x39, 0xf5, 0x26, 0xd4, 0xcf, 0x68,
+	0xcc, 0xc7, 0x57, 0x5e, 0x03, 0x55, 0x4e, 0xf2, 0x7f, 0xae, 0x40, 0x75, 0xff, 0xf8, 0xe3, 0x8d,
+	0x22, 0x2a, 0x73, 0x8a, 0x28, 0x65, 0xb8, 0x34, 0x9d, 0xe1, 0x06, 0x2c, 0x87, 0x42, 0x32, 0x85,
+	0x75, 0xad, 0x06, 0x56, 0x30, 0xf6, 0x7a, 0x28, 0x19, 0x8d, 0x14, 0x56, 0xb4, 0x1a, 0xe4, 0xa2,
+	0xa9, 0xe8, 0x4c, 0xb2, 0x1f, 0x33, 0x96, 0x84, 0x57, 0xfd, 0x78, 0x78, 0x8d, 0x15, 0xad, 0x06,
+	0x2b, 0x13, 0xf0, 0x70, 0x78, 0xed, 0xff, 0x52, 0x81, 0xb5, 0x23, 0xa6, 0x2f, 0x84, 0x1c, 0xf5,
+	0x12, 0xcd, 0xe4, 0x19, 0x0d, 0x99, 0x79, 0x00, 0x6c, 0xa0, 0x7b, 0x00, 0x73, 0x26, 0x6b, 0x50,
+	0x8d, 0x69, 0xe8, 0x72, 0x32, 0x47, 0x93, 0x8f, 0x4a, 0x19, 0x8b, 0xf2, 0x7c, 0x50, 0x30, 0x3d,
+	0x88, 0x24, 0x3f, 0x67, 0xd2, 0x35, 0xd8, 0x49, 0xe4, 0x3e, 0xb4, 0xce, 0x59, 0x12, 0x09, 0xd9,
+	0xe7, 0x91, 0xeb, 0x6d, 0xd3, 0x02, 0xbd, 0xc8, 0x28, 0x23, 0x76, 0xce, 0x43, 0x66, 0x94, 0xb6,
+	0xad, 0x4d, 0x0b, 0xf4, 0x22, 0xff, 0xd7, 0x25, 0x68, 0x1d, 0xef, 0xf7, 0x0e, 0x50, 0x36, 0xf5,
+	0xd2, 0x28, 0x92, 0x4c, 0x29, 0x97, 0x5e, 0x2e, 0x62, 0x7f, 0xc6, 0x54, 0x29, 0x97, 0xa3, 0x15,
+	0xa6, 0xe3, 0x56, 0x17, 0xc5, 0xad, 0x4d, 0xc7, 0x25, 0x3b, 0xb0, 0xae, 0xb2, 0x53, 0x85, 0xf4,
+	0xec, 0xcf, 0xe6, 0xde, 0x99, 0xa8, 0xbe, 0xc9, 0x9d, 0x4d, 0xd9, 0xcf, 0x96, 0x53, 0xd8, 0x1f,
+	0xe4, 0xfe, 0x8b, 0x4e, 0x35, 0xa6, 0x3a, 0xb5, 0x01, 0xcb, 0xb1, 0x88, 0xd8, 0xd8, 0x6b, 0xda,
+	0x3a, 0x50, 0x20, 0x8f, 0x00, 0xcc, 0x3b, 0xa8, 0x94, 0x86, 0x4c, 0x79, 0x2d, 0x6c, 0x79, 0x09,
+	0xf1, 0x7f, 0xaa, 0x40, 0xed, 0x80, 0xab, 0xd1, 0xdc, 0xc7, 0x23, 0x50, 0x53, 0xfc, 0x9a, 0x61,
+	0x67, 0x6a, 0x01, 0x9e, 0x8b, 0x30, 0xd5, 0x72, 0x98, 0x4d, 0xa8, 0x5b, 0xc6, 0xe7, 0xcf, 0x67,
+	0x25, 0xe3, 0xe1, 0xe2, 0x62, 0x52, 0x3d, 0x9e, 0x4d, 0x4a, 0x52, 0x68, 0x9c, 0x59, 0x3a, 0xc6,
+	0x3a, 0x9b, 0x41, 0x09, 0xf1, 0x3f, 0x83, 0xfa, 0x21, 0x8b, 0x85, 0xbc, 0x9a, 0xc4, 0xaf, 0x94,
+	0xe2, 0x7b, 0xd0, 0x88, 0x45, 0x94, 0x8d, 0x99, 0x7d, 0xb0, 0xd5, 0x20, 0x17, 0xfd, 0xdf, 0xab,
+	0xb0, 0xbe, 0x2f, 0x19, 0xd5, 0xec, 0x84, 0xc9, 0x73, 0x26, 0x03, 0xc3, 0x57, 0xa5, 0xc9, 0x5b,
+	0x20, 0xae, 0xbb, 0xbc, 0xd8, 0x16, 0xe8, 0xb3, 0xbd, 0xb7, 0xb9, 0x63, 0x56, 0xcb, 0x8d, 0x5d,
+	0x12, 0x74, 0xd4, 0x2c, 0x44, 0xba, 0x50, 0x0d, 0xd3, 0x0c, 0x83, 0xb6, 0xf7, 0x9a, 0x78, 0x6f,
+	0xff, 0xf8, 0x63, 0x60, 0x40, 0xd2, 0x85, 0xe6, 0x50, 0x28, 0x5d, 0x5a, 0x1f, 0x13, 0x99, 0xbc,
+	0x81, 0x4e, 0x62, 0x27, 0xa5, 0xcf, 0xf3, 0x51, 0xf1, 0x6a, 0x5b, 0xd5, 0x97, 0xed, 0xbd, 0xff,
+	0xa2, 0x97, 0xd9, 0x39, 0x0a, 0xd6, 0x92, 0x19, 0x84, 0x6c, 0x03, 0xa4, 0x21, 0x77, 0xec, 0xf0,
+	0x96, 0xf1, 0xf2, 0x1d, 0xbc, 0x3c, 0x61, 0x78, 0xd0, 0x4a, 0x43, 0x6e, 0x8f, 0xe4, 0x7f, 0xb0,
+	0x91, 0x4a, 0x71, 0xce, 0xcd, 0xfc, 0xf3, 0x64, 0xd0, 0xcf, 0x99, 0x6f, 0x39, 0xb5, 0x5e, 0xd6,
+	0xbd, 0xb6, 0xaa, 0x1b, 0x57, 0x06, 0x54, 0xb3, 0x0b, 0x9a, 0x6f, 0xa4, 0xa9, 0x2b, 0x5f, 0x59,
+	0x15, 0x79, 0x08, 0xb5, 0x88, 0xab, 0x91, 0xd7, 0xc4, 0x74, 0x5a, 0x98, 0x8e, 0xa1, 0x52, 0x80,
+	0x30, 0x79, 0x0a, 0xf5, 0x18, 0x9f, 0x11, 0x59, 0xd7, 0xde, 0x6b, 0xa3, 0x81, 0x7d, 0xd9, 0xc0,
+	0xa9, 0xfc, 0xcf, 0xa1, 0x91, 0x67, 0x40, 0xa0, 0xa6, 0xaf, 0xd2, 0x09, 0x01, 0xcd, 0xb9, 0x3c,
+	0xb5, 0x4b, 0x53, 0x53, 0xeb, 0xff, 0x5d, 0x81, 0x8d, 0xe9, 0xc7, 0x56, 0xa9, 0x48, 0x14, 0x72,
+	0xf6, 0x82, 0x3b, 0x37, 0xcd, 0x00, 0xcf, 0x66, 0xa5, 0xf1, 0x44, 0xb1, 0x30, 0x93, 0xac, 0x8f,
+	0xca, 0x25, 0x54, 0xae, 0xe4, 0xe0, 0xb7, 0xc6, 0xe8, 0x39, 0xdc, 0x91, 0xec, 0x54, 0x08, 0xdd,
+	0xd7, 0x3c, 0x66, 0x22, 0xd3, 0xf8, 0x92, 0x95, 0x60, 0xd5, 0xa2, 0x1f, 0x2c, 0x48, 0x7c, 0xb3,
+	0xf0, 0x75, 0x1f, 0x0d, 0x13, 0x76, 0xa9, 0x91, 0xf0, 0xcd, 0xa0, 0xad, 0x98, 0x7e, 0x23, 0x84,
+	0x3e, 0x62, 0x97, 0xda, 0xec, 0x7b, 0x13, 0xa6, 0xaf, 0x42, 0x91, 0x32, 0xc7, 0xfd, 0x96, 0x41,
+	0x4e, 0x0c, 0x40, 0x76, 0xa0, 0x7d, 0xce, 0x24, 0x3f, 0xbb, 0xea, 0x63, 0xff, 0xea, 0xd8, 0xbf,
+	0xd5, 0x49, 0xff, 0x4c, 0x36, 0x01, 0x58, 0x0b, 0x23, 0xfb, 0x7f, 0x54, 0xa0, 0x99, 0x2b, 0xe6,
+	0xce, 0x69, 0x31, 0x7d, 0x4b, 0x53, 0xd3, 0xb7, 0x69, 0x9e, 0x40, 0x0f, 0x45, 0xbe, 0xc1, 0x9c,
+	0x44, 0x1e, 0x43, 0x5b, 0xd1, 0x38, 0x1d, 0xb3, 0xfe, 0x90, 0xaa, 0xa1, 0x1b, 0x59, 0xb0, 0xd0,
+	0x3b, 0xaa, 0x86, 0xa6, 0xef, 0x56, 0x52, 0x6e, 0xfb, 0xe7, 0xa2, 0x71, 0x79, 0xcd, 0xa4, 0x60,
+	0x91, 0x1b, 0x5c, 0x27, 0x91, 0x07, 0xd0, 0x4a, 0xa9, 0xd4, 0x1c, 0x67, 0xcb, 0x92, 0xa6, 0x00,
+	0x7c, 0x01, 0xde, 0x21, 0x95, 0x23, 0xfb, 0x54, 0xaf, 0x95, 0x29, 0x24, 0xca, 0xc7, 0x73, 0xde,
+	0x67, 0xfb, 0x89, 0xa3, 0xd6, 0xd2, 0xbc, 0xd6, 0xa0, 0xca, 0x04, 0x34, 0xef, 0xa4, 0x34, 0x8d,
+	0x53, 0x2c, 0xaf, 0x1a, 0x14, 0x80, 0xff, 0x02, 0xd6, 0xde, 0x31, 0x2a, 0xf5, 0x29, 0xa3, 0x7a,
+	0x41, 0x20, 0xff, 0x3e, 0xdc, 0x9b, 0x93, 0x98, 0xa5, 0x92, 0xbf, 0x0e, 0x9d, 0x92, 0x13, 0x07,
+	0xfe, 0x00, 0x8f, 0x03, 0x16, 0x8a, 0x24, 0xe4, 0x63, 0x47, 0x3d, 0x47, 0x60, 0xa6, 0x16, 0x55,
+	0xf4, 0xa2, 0xcc, 0x64, 0x53, 0xd4, 0x0a, 0x16, 0xe5, 0xee, 0x16, 0xbc, 0xf6, 0x61, 0xeb, 0x76,
+	0xf7, 0x2e, 0x85, 0xdf, 0x2a, 0xb0, 0xf2, 0xfe, 0xfd, 0xc1, 0xf1, 0x11, 0xe3, 0x83, 0xe1, 0xa9,
+	0x90, 0xa6, 0x17, 0xc5, 0x6a, 0xb1, 0x51, 0x0b, 0xc0, 0xb0, 0x31, 0x1c, 0x52, 0xa5, 0xb8, 0x32,
+	0xdf, 0x15, 0xcb, 0x90, 0x96, 0x43, 0x7a, 0x11, 0xb9, 0x0b, 0x8d, 0x54, 0x48, 0x5d, 0x7c, 0xe7,
+	0xea, 0x46, 0xec, 0x45, 0xe4, 0x15, 0xac, 0xa1, 0x22, 0x62, 0x2a, 0x94, 0x3c, 0xd5, 0xc5, 0xaf,
+	0x9f, 0xff, 0x18, 0xfc, 0xa0, 0x80, 0x91, 0x50, 0x76, 0xc5, 0x22, 0x37, 0x97, 0x1d, 0xa1, 0x10,
+	0x32, 0x3f, 0xa0, 0xfc, 0x21, 0x3c, 0x9d, 0x29, 0xab, 0x5c, 0xc0, 0xc2, 0xce, 0x6d, 0x43, 0x33,
+	0x71, 0x76, 0xae, 0x75, 0x1d, 0x6c, 0x5d, 0xd9, 0x41, 0x30, 0x31, 0xf1, 0x5f, 0xc0, 0xb3, 0xc5,
+	0x91, 0x5c, 0x13, 0xdf, 0xc2, 0xfd, 0x19, 0xbb, 0xfd, 0xb1, 0x08, 0x47, 0x8b, 0x32, 0x31, 0x1b,
+	0x8a, 0xc7, 0x76, 0x7b, 0x54, 0x03, 0x3c, 0xfb, 0x87, 0xf0, 0x60, 0xbe, 0x9b, 0x62, 0x1d, 0xe1,
+	0x9d, 0x4a, 0x71, 0x87, 0xdc, 0x83, 0x66, 0x4c, 0x2f, 0xfb, 0x6a, 0xc4, 0x2e, 0xd0, 0x57, 0x25,
+	0x68, 0xc4, 0xf4, 0xf2, 0x64, 0xc4, 0x2e, 0xfc, 0x0f, 0xd0, 0x09, 0x98, 0xe9, 0xee, 0x7b, 0x31,
+	0x58, 0xd8, 0x95, 0xbb, 0xd0, 0xc0, 0x15, 0x34, 0x79, 0xd1, 0xba, 0x11, 0x7b, 0x98, 0xe4, 0x58,
+	0x0c, 0x94, 0x7b, 0x4b, 0x3c, 0xfb, 0x1b, 0x40, 0xca, 0x5e, 0x6d, 0x6a, 0x7b, 0x7f, 0xd6, 0x60,
+	0xf9, 0xf5, 0x80, 0x25, 0x9a, 0xec, 0xc3, 0x4a, 0x79, 0x97, 0x12, 0xcf, 0x7e, 0xdd, 0x6e, 0x7e,
+	0x4b, 0xbb, 0xf7, 0xe6, 0x68, 0x5c, 0xa5, 0x01, 0x74, 0x6e, 0x8c, 0x12, 0x79, 0x68, 0x97, 0xfe,
+	0x2d, 0xb3, 0xdf, 0x7d, 0x74, 0x9b, 0xda, 0xf9, 0x1c, 0x80, 0x77, 0xdb, 0x34, 0x90, 0x67, 0x78,
+	0xf7, 0x13, 0xb3, 0xd8, 0x7d, 0xfe, 0x09, 0x2b, 0x17, 0xe8, 0x0b, 0x68, 0x4d, 0x46, 0x9d, 0xd8,
+	0xcf, 0xf2, 0xec, 0xfe, 0xe8, 0x6e, 0xce, 0xc2, 0xee, 0xae, 0x82, 0x07, 0x8b, 0x18, 0x47, 0x5e,
+	0xce, 0x4b, 0x61, 0x1e, 0xfd, 0xbb, 0xaf, 0xfe, 0x85, 0xa5, 0x0b, 0xfa, 0x3d, 0x6c, 0xcc, 0xe3,
+	0x1d, 0xd9, 0x9a, 0xe7, 0xa2, 0xcc, 0xec, 0xee, 0x93, 0x05, 0x16, 0xce, 0xf9, 0x97, 0x00, 0x05,
+	0x5f, 0xc8, 0xa6, 0xbb, 0x30, 0x43, 0xcb, 0xee, 0xdd, 0x1b, 0xb8, 0xbd, 0xfe, 0xe6, 0xeb, 0xef,
+	0x7a, 0x03, 0xae, 0x87, 0xd9, 0xe9, 0x4e, 0x28, 0xe2, 0x5d, 0x4d, 0xc7, 0x42, 0x6d, 0xdb, 0x5d,
+	0xa0, 0x76, 0x15, 0x8f, 0x98, 0x14, 0xbb, 0x34, 0x4d, 0x77, 0x63, 0xa6, 0xe9, 0x78, 0x3b, 0x14,
+	0x89, 0x96, 0x62, 0x3c, 0x66, 0x72, 0x3b, 0xa6, 0x09, 0x1d, 0x30, 0xb9, 0x8b, 0xab, 0x2b, 0xa1,
+	0xe3, 0x5d, 0x9a, 0xf2, 0xd3, 0x3a, 0xfe, 0xef, 0xfb, 0xff, 0x3f, 0x01, 0x00, 0x00, 0xff, 0xff,
+	0x7c, 0xf3, 0x89, 0xba, 0x04, 0x0e, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -658,6 +1606,9 @@ type AgentClient interface {
 	MarkServerAsWiped(ctx context.Context, in *MarkServerAsWipedRequest, opts ...grpc.CallOption) (*MarkServerAsWipedResponse, error)
 	ReconcileServerAddresses(ctx context.Context, in *ReconcileServerAddressesRequest, opts ...grpc.CallOption) (*ReconcileServerAddressesResponse, error)
 	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	ReconcileServerLLDPNeighbors(ctx context.Context, in *ReconcileServerLLDPNeighborsRequest, opts ...grpc.CallOption) (*ReconcileServerLLDPNeighborsResponse, error)
+	ReconcileServerClock(ctx context.Context, in *ReconcileServerClockRequest, opts ...grpc.CallOption) (*ReconcileServerClockResponse, error)
+	ReportLogs(ctx context.Context, in *ReportLogsRequest, opts ...grpc.CallOption) (*ReportLogsResponse, error)
 }
 
 type agentClient struct {
@@ -704,12 +1655,42 @@ func (c *agentClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts
 	return out, nil
 }
 
+func (c *agentClient) ReconcileServerLLDPNeighbors(ctx context.Context, in *ReconcileServerLLDPNeighborsRequest, opts ...grpc.CallOption) (*ReconcileServerLLDPNeighborsResponse, error) {
+	out := new(ReconcileServerLLDPNeighborsResponse)
+	err := c.cc.Invoke(ctx, "/api.Agent/ReconcileServerLLDPNeighbors", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) ReconcileServerClock(ctx context.Context, in *ReconcileServerClockRequest, opts ...grpc.CallOption) (*ReconcileServerClockResponse, error) {
+	out := new(ReconcileServerClockResponse)
+	err := c.cc.Invoke(ctx, "/api.Agent/ReconcileServerClock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) ReportLogs(ctx context.Context, in *ReportLogsRequest, opts ...grpc.CallOption) (*ReportLogsResponse, error) {
+	out := new(ReportLogsResponse)
+	err := c.cc.Invoke(ctx, "/api.Agent/ReportLogs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AgentServer is the server API for Agent service.
 type AgentServer interface {
 	CreateServer(context.Context, *CreateServerRequest) (*CreateServerResponse, error)
 	MarkServerAsWiped(context.Context, *MarkServerAsWipedRequest) (*MarkServerAsWipedResponse, error)
 	ReconcileServerAddresses(context.Context, *ReconcileServerAddressesRequest) (*ReconcileServerAddressesResponse, error)
 	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	ReconcileServerLLDPNeighbors(context.Context, *ReconcileServerLLDPNeighborsRequest) (*ReconcileServerLLDPNeighborsResponse, error)
+	ReconcileServerClock(context.Context, *ReconcileServerClockRequest) (*ReconcileServerClockResponse, error)
+	ReportLogs(context.Context, *ReportLogsRequest) (*ReportLogsResponse, error)
 }
 
 // UnimplementedAgentServer can be embedded to have forward compatible implementations.
@@ -732,6 +1713,18 @@ func (*UnimplementedAgentServer) Heartbeat(ctx context.Context, req *HeartbeatRe
 	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
 }
 
+func (*UnimplementedAgentServer) ReconcileServerLLDPNeighbors(ctx context.Context, req *ReconcileServerLLDPNeighborsRequest) (*ReconcileServerLLDPNeighborsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReconcileServerLLDPNeighbors not implemented")
+}
+
+func (*UnimplementedAgentServer) ReconcileServerClock(ctx context.Context, req *ReconcileServerClockRequest) (*ReconcileServerClockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReconcileServerClock not implemented")
+}
+
+func (*UnimplementedAgentServer) ReportLogs(ctx context.Context, req *ReportLogsRequest) (*ReportLogsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportLogs not implemented")
+}
+
 func RegisterAgentServer(s *grpc.Server, srv AgentServer) {
 	s.RegisterService(&_Agent_serviceDesc, srv)
 }
@@ -808,6 +1801,60 @@ func _Agent_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Agent_ReconcileServerLLDPNeighbors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReconcileServerLLDPNeighborsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).ReconcileServerLLDPNeighbors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Agent/ReconcileServerLLDPNeighbors",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).ReconcileServerLLDPNeighbors(ctx, req.(*ReconcileServerLLDPNeighborsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Agent_ReconcileServerClock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReconcileServerClockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).ReconcileServerClock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Agent/ReconcileServerClock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).ReconcileServerClock(ctx, req.(*ReconcileServerClockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Agent_ReportLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).ReportLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Agent/ReportLogs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).ReportLogs(ctx, req.(*ReportLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Agent_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "api.Agent",
 	HandlerType: (*AgentServer)(nil),
@@ -828,6 +1875,18 @@ var _Agent_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Heartbeat",
 			Handler:    _Agent_Heartbeat_Handler,
 		},
+		{
+			MethodName: "ReconcileServerLLDPNeighbors",
+			Handler:    _Agent_ReconcileServerLLDPNeighbors_Handler,
+		},
+		{
+			MethodName: "ReconcileServerClock",
+			Handler:    _Agent_ReconcileServerClock_Handler,
+		},
+		{
+			MethodName: "ReportLogs",
+			Handler:    _Agent_ReportLogs_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
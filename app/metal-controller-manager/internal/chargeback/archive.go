@@ -0,0 +1,103 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package chargeback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// ArchiveLabel marks the ConfigMaps with the allocation history of the deleted servers, the value is the server name.
+const ArchiveLabel = "metal.sidero.dev/allocation-history"
+
+const archiveKey = "history"
+
+type archivedHistory struct {
+	History      []metalv1alpha1.AllocationRecord `json:"history"`
+	TrimmedUntil *metav1.Time                     `json:"trimmedUntil,omitempty"`
+}
+
+// Archive keeps the allocation history of the deleted servers, so that their usage stays in the report.
+type Archive struct {
+	// Reader reads the archived histories bypassing the cache, so that the ConfigMaps are not watched.
+	Reader client.Reader
+	// Namespace is the namespace of the ConfigMaps with the archived histories.
+	Namespace string
+}
+
+// +kubebuilder:rbac:groups="",namespace=sidero-system,resources=configmaps,verbs=get;list;create
+
+// Store archives the allocation history of the server being deleted.
+//
+// Each deleted server gets its own ConfigMap, so a server registered again under the same name is archived separately.
+func (a *Archive) Store(ctx context.Context, c client.Writer, s *metalv1alpha1.Server) error {
+	if a == nil || len(s.Status.AllocationHistory) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(archivedHistory{
+		History:      s.Status.AllocationHistory,
+		TrimmedUntil: s.Status.AllocationHistoryTrimmedUntil,
+	})
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: a.Namespace,
+			Name:      fmt.Sprintf("allocation-history-%s-%s", s.Name, s.UID),
+			Labels:    map[string]string{ArchiveLabel: s.Name},
+		},
+		Data: map[string]string{archiveKey: string(data)},
+	}
+
+	if err = c.Create(ctx, cm); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Load returns the deleted servers with the archived allocation history.
+func (a *Archive) Load(ctx context.Context) ([]metalv1alpha1.Server, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	var configMaps corev1.ConfigMapList
+
+	if err := a.Reader.List(ctx, &configMaps, client.InNamespace(a.Namespace), client.HasLabels{ArchiveLabel}); err != nil {
+		return nil, err
+	}
+
+	servers := make([]metalv1alpha1.Server, 0, len(configMaps.Items))
+
+	for _, cm := range configMaps.Items {
+		var archived archivedHistory
+
+		if err := json.Unmarshal([]byte(cm.Data[archiveKey]), &archived); err != nil {
+			return nil, fmt.Errorf("error decoding archived allocation history %q: %w", cm.Name, err)
+		}
+
+		servers = append(servers, metalv1alpha1.Server{
+			ObjectMeta: metav1.ObjectMeta{Name: cm.Labels[ArchiveLabel]},
+			Status: metalv1alpha1.ServerStatus{
+				AllocationHistory:             archived.History,
+				AllocationHistoryTrimmedUntil: archived.TrimmedUntil,
+			},
+		})
+	}
+
+	return servers, nil
+}
@@ -0,0 +1,87 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package chargeback_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/chargeback"
+)
+
+func TestArchive(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme)
+	archive := &chargeback.Archive{Reader: c, Namespace: "sidero-system"}
+
+	ctx := context.Background()
+	allocatedAt := metav1.NewTime(time.Now().Add(-3 * time.Hour).Truncate(time.Second))
+	releasedAt := metav1.NewTime(allocatedAt.Add(2 * time.Hour))
+
+	deleted := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{Name: "server", UID: "uid-1"},
+		Status: metalv1alpha1.ServerStatus{
+			AllocationHistory: []metalv1alpha1.AllocationRecord{
+				{Namespace: "default", Cluster: "ml", AllocatedAt: allocatedAt, ReleasedAt: &releasedAt},
+			},
+		},
+	}
+
+	registeredAgain := deleted.DeepCopy()
+	registeredAgain.UID = "uid-2"
+
+	// server registered again under the same name is archived separately, retries are no-op
+	for _, s := range []*metalv1alpha1.Server{deleted, deleted, registeredAgain, {ObjectMeta: metav1.ObjectMeta{Name: "never-allocated"}}} {
+		if err := archive.Store(ctx, c, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	servers, err := archive.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(servers) != 2 || servers[0].Name != "server" || len(servers[0].Status.AllocationHistory) != 1 {
+		t.Fatalf("unexpected archived servers %+v", servers)
+	}
+
+	w := httptest.NewRecorder()
+
+	chargeback.NewHandler(c, archive).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/chargeback", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+
+	var report chargeback.Report
+
+	if err = json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Clusters) != 1 || report.Clusters[0].Cluster != "ml" || report.Clusters[0].ServerHours != 4 {
+		t.Errorf("usage of the deleted server is not reported: %+v", report.Clusters)
+	}
+}
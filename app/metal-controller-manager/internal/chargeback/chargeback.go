@@ -0,0 +1,318 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package chargeback implements the server usage report and metrics per cluster, used for the internal chargeback.
+package chargeback
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// DefaultPeriod is the report period if the start of the period is not set.
+const DefaultPeriod = 30 * 24 * time.Hour
+
+// Usage is the server usage of a single cluster.
+type Usage struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	// Tags are the chargeback tags of the most recent allocation of the cluster.
+	Tags map[string]string `json:"tags,omitempty"`
+	// ServerHours is the total time the servers were allocated to the cluster within the period.
+	ServerHours float64 `json:"serverHours"`
+	// Allocations is the number of the allocations overlapping the period.
+	Allocations int `json:"allocations"`
+	// Servers is the number of the distinct servers allocated to the cluster within the period.
+	Servers int `json:"servers"`
+}
+
+// Report is the server usage of all the clusters.
+type Report struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Partial is set if allocations within the period were trimmed from the allocation history of some servers,
+	// so the usage is under-reported.
+	Partial bool `json:"partial,omitempty"`
+	// TrimmedServers lists the servers with the allocations within the period trimmed from the history.
+	TrimmedServers []string `json:"trimmedServers,omitempty"`
+	Clusters       []Usage  `json:"clusters"`
+}
+
+type usageKey struct {
+	namespace string
+	cluster   string
+}
+
+// Compute builds the usage report of the period from the allocation history of the servers.
+//
+// Current allocations are accounted until now (or until the end of the period, whichever is earlier).
+// If the allocation history of some servers was trimmed within the period, the report is marked partial.
+func Compute(servers []metalv1alpha1.Server, from, to, now time.Time) *Report {
+	var (
+		usage      = map[usageKey]*Usage{}
+		taggedAt   = map[usageKey]time.Time{}
+		allocated  = map[usageKey]map[string]struct{}{}
+		openEnding = to
+	)
+
+	if now.Before(openEnding) {
+		openEnding = now
+	}
+
+	var trimmed []string
+
+	for i := range servers {
+		server := &servers[i]
+
+		if trimmedUntil := server.Status.AllocationHistoryTrimmedUntil; trimmedUntil != nil && trimmedUntil.Time.After(from) {
+			trimmed = append(trimmed, server.Name)
+		}
+
+		for j := range server.Status.AllocationHistory {
+			record := &server.Status.AllocationHistory[j]
+
+			start := record.AllocatedAt.Time
+			end := openEnding
+
+			if record.ReleasedAt != nil {
+				end = record.ReleasedAt.Time
+			}
+
+			if start.Before(from) {
+				start = from
+			}
+
+			if end.After(to) {
+				end = to
+			}
+
+			if !end.After(start) {
+				continue
+			}
+
+			key := usageKey{namespace: record.Namespace, cluster: record.Cluster}
+
+			u, ok := usage[key]
+			if !ok {
+				u = &Usage{Namespace: record.Namespace, Cluster: record.Cluster}
+				usage[key] = u
+				allocated[key] = map[string]struct{}{}
+			}
+
+			u.ServerHours += end.Sub(start).Hours()
+			u.Allocations++
+			allocated[key][server.Name] = struct{}{}
+
+			if !record.AllocatedAt.Time.Before(taggedAt[key]) {
+				u.Tags = record.Tags
+				taggedAt[key] = record.AllocatedAt.Time
+			}
+		}
+	}
+
+	sort.Strings(trimmed)
+
+	report := &Report{
+		From:           from.UTC(),
+		To:             to.UTC(),
+		Partial:        len(trimmed) > 0,
+		TrimmedServers: trimmed,
+		Clusters:       make([]Usage, 0, len(usage)),
+	}
+
+	for key, u := range usage {
+		u.Servers = len(allocated[key])
+
+		report.Clusters = append(report.Clusters, *u)
+	}
+
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		if report.Clusters[i].Namespace != report.Clusters[j].Namespace {
+			return report.Clusters[i].Namespace < report.Clusters[j].Namespace
+		}
+
+		return report.Clusters[i].Cluster < report.Clusters[j].Cluster
+	})
+
+	return report
+}
+
+// WriteCSV writes the report as CSV, one line per cluster; tags are formatted as `key=value` pairs separated by `;`.
+func (r *Report) WriteCSV(w *csv.Writer) error {
+	if err := w.Write([]string{"namespace", "cluster", "serverHours", "allocations", "servers", "tags"}); err != nil {
+		return err
+	}
+
+	for _, u := range r.Clusters {
+		tags := make([]string, 0, len(u.Tags))
+
+		for key, value := range u.Tags {
+			tags = append(tags, key+"="+value)
+		}
+
+		sort.Strings(tags)
+
+		if err := w.Write([]string{
+			u.Namespace,
+			u.Cluster,
+			strconv.FormatFloat(u.ServerHours, 'f', 2, 64),
+			strconv.Itoa(u.Allocations),
+			strconv.Itoa(u.Servers),
+			strings.Join(tags, ";"),
+		}); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+// PartialHeader is set on the CSV responses if the report is partial (see Report.Partial).
+const PartialHeader = "X-Sidero-Chargeback-Partial"
+
+// NewHandler returns the HTTP handler serving the usage report.
+//
+// The period is set with the `from` and `to` query parameters (RFC 3339), it defaults to the last DefaultPeriod;
+// `format=csv` returns the report as CSV instead of JSON.
+// Deleted servers are included from the archive, if set.
+func NewHandler(c client.Reader, archive *Archive) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := r.URL.Query()
+
+		now := time.Now()
+		to := now
+
+		if v := values.Get("to"); v != "" {
+			var err error
+
+			if to, err = time.Parse(time.RFC3339, v); err != nil {
+				http.Error(w, fmt.Sprintf("invalid to: %s", err), http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		from := to.Add(-DefaultPeriod)
+
+		if v := values.Get("from"); v != "" {
+			var err error
+
+			if from, err = time.Parse(time.RFC3339, v); err != nil {
+				http.Error(w, fmt.Sprintf("invalid from: %s", err), http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		if !from.Before(to) {
+			http.Error(w, "from should be before to", http.StatusBadRequest)
+
+			return
+		}
+
+		format := values.Get("format")
+		if format != "" && format != "json" && format != "csv" {
+			http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+
+			return
+		}
+
+		var servers metalv1alpha1.ServerList
+
+		if err := c.List(r.Context(), &servers); err != nil {
+			log.Printf("failed to list servers: %s", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		deleted, err := archive.Load(r.Context())
+		if err != nil {
+			log.Printf("failed to load archived allocation history: %s", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		report := Compute(append(servers.Items, deleted...), from, to, now)
+
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+
+			if report.Partial {
+				w.Header().Set(PartialHeader, "true")
+			}
+
+			if err := report.WriteCSV(csv.NewWriter(w)); err != nil {
+				log.Printf("failed to write chargeback report: %s", err)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			log.Printf("failed to write chargeback report: %s", err)
+		}
+	})
+}
+
+var allocatedDesc = prometheus.NewDesc("sidero_cluster_allocated_servers", "Number of the servers allocated to the cluster.", []string{"namespace", "cluster"}, nil)
+
+// Collector publishes the number of the servers allocated to each cluster as Prometheus metrics.
+//
+// Servers are counted from the cache on each scrape, server-hours are derived from the gauge in Prometheus.
+type Collector struct {
+	Client client.Reader
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- allocatedDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	var servers metalv1alpha1.ServerList
+
+	if err := c.Client.List(context.Background(), &servers); err != nil {
+		ch <- prometheus.NewInvalidMetric(allocatedDesc, err)
+
+		return
+	}
+
+	allocated := map[usageKey]int{}
+
+	for i := range servers.Items {
+		history := servers.Items[i].Status.AllocationHistory
+
+		if !servers.Items[i].Status.InUse || len(history) == 0 || history[len(history)-1].ReleasedAt != nil {
+			continue
+		}
+
+		record := &history[len(history)-1]
+
+		allocated[usageKey{namespace: record.Namespace, cluster: record.Cluster}]++
+	}
+
+	for key, count := range allocated {
+		ch <- prometheus.MustNewConstMetric(allocatedDesc, prometheus.GaugeValue, float64(count), key.namespace, key.cluster)
+	}
+}
@@ -0,0 +1,122 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package chargeback_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/chargeback"
+)
+
+func TestCompute(t *testing.T) {
+	from := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	now := from.Add(20 * time.Hour)
+
+	record := func(cluster string, allocatedAt time.Duration, releasedAt *time.Duration, tags map[string]string) metalv1alpha1.AllocationRecord {
+		r := metalv1alpha1.AllocationRecord{
+			Cluster:     cluster,
+			Namespace:   "default",
+			AllocatedAt: metav1.NewTime(from.Add(allocatedAt)),
+			Tags:        tags,
+		}
+
+		if releasedAt != nil {
+			t := metav1.NewTime(from.Add(*releasedAt))
+			r.ReleasedAt = &t
+		}
+
+		return r
+	}
+
+	hours := func(h int) *time.Duration {
+		d := time.Duration(h) * time.Hour
+
+		return &d
+	}
+
+	servers := []metalv1alpha1.Server{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a"},
+			Status: metalv1alpha1.ServerStatus{
+				AllocationHistory: []metalv1alpha1.AllocationRecord{
+					// released before the period
+					record("ml", -48*time.Hour, hours(-24), nil),
+					// started before the period
+					record("ml", -2*time.Hour, hours(4), map[string]string{"cost-center": "research"}),
+					// current allocation, accounted until now
+					record("ml", 10*time.Hour, nil, map[string]string{"cost-center": "ml"}),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b"},
+			Status: metalv1alpha1.ServerStatus{
+				AllocationHistory: []metalv1alpha1.AllocationRecord{
+					record("ml", 2*time.Hour, hours(3), nil),
+					record("web", 12*time.Hour, hours(30), nil),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "c"},
+			Status: metalv1alpha1.ServerStatus{
+				// history was trimmed before the period
+				AllocationHistoryTrimmedUntil: &metav1.Time{Time: from.Add(-time.Hour)},
+			},
+		},
+	}
+
+	report := chargeback.Compute(servers, from, to, now)
+
+	expected := []chargeback.Usage{
+		{
+			Namespace:   "default",
+			Cluster:     "ml",
+			Tags:        map[string]string{"cost-center": "ml"},
+			ServerHours: 4 + 10 + 1,
+			Allocations: 3,
+			Servers:     2,
+		},
+		{
+			Namespace:   "default",
+			Cluster:     "web",
+			ServerHours: 12,
+			Allocations: 1,
+			Servers:     1,
+		},
+	}
+
+	if !reflect.DeepEqual(report.Clusters, expected) {
+		t.Fatalf("unexpected report %+v", report.Clusters)
+	}
+
+	if report.Partial {
+		t.Error("report is partial")
+	}
+
+	servers[2].Status.AllocationHistoryTrimmedUntil = &metav1.Time{Time: from.Add(time.Hour)}
+
+	if partial := chargeback.Compute(servers, from, to, now); !partial.Partial || !reflect.DeepEqual(partial.TrimmedServers, []string{"c"}) {
+		t.Errorf("report is not partial: %v %v", partial.Partial, partial.TrimmedServers)
+	}
+
+	var buf bytes.Buffer
+
+	if err := report.WriteCSV(csv.NewWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "namespace,cluster,serverHours,allocations,servers,tags\ndefault,ml,15.00,3,2,cost-center=ml\ndefault,web,12.00,1,1,\n"; buf.String() != want {
+		t.Errorf("unexpected CSV %q", buf.String())
+	}
+}
@@ -0,0 +1,151 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package export
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNoServersAvailable is returned when the export can't lease more servers.
+var ErrNoServersAvailable = errors.New("no servers available for lease")
+
+var errNotFound = errors.New("not found")
+
+// Client of the export API.
+type Client struct {
+	endpoint string
+	export   string
+	token    string
+	client   *http.Client
+}
+
+// NewClient creates the export API client.
+//
+// If the CA is set, it is used to verify the export API certificate.
+func NewClient(endpoint, export, token string, ca []byte) (*Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to load CA")
+		}
+
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return &Client{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		export:   export,
+		token:    token,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+	}, nil
+}
+
+// List the servers leased via the export.
+func (c *Client) List(ctx context.Context) ([]Lease, error) {
+	var leases []Lease
+
+	if err := c.do(ctx, http.MethodGet, c.leasesPath(""), http.StatusOK, &leases); err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// Lease a server.
+func (c *Client) Lease(ctx context.Context) (*Lease, error) {
+	var lease Lease
+
+	if err := c.do(ctx, http.MethodPost, c.leasesPath(""), http.StatusCreated, &lease); err != nil {
+		return nil, err
+	}
+
+	return &lease, nil
+}
+
+// Release the leased server.
+//
+// Releasing the server which is not leased is not an error.
+func (c *Client) Release(ctx context.Context, server string) error {
+	err := c.do(ctx, http.MethodDelete, c.leasesPath("/"+url.PathEscape(server)), http.StatusNoContent, nil)
+	if errors.Is(err, errNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// Inventory fetches the inventory mirrored by the standby Sidero installation.
+//
+// Export of the client is not used, the token is the inventory token.
+func (c *Client) Inventory(ctx context.Context) (*Inventory, error) {
+	var inventory Inventory
+
+	if err := c.do(ctx, http.MethodGet, inventoryPath, http.StatusOK, &inventory); err != nil {
+		return nil, err
+	}
+
+	return &inventory, nil
+}
+
+func (c *Client) leasesPath(suffix string) string {
+	return leasesPath + url.PathEscape(c.export) + "/leases" + suffix
+}
+
+func (c *Client) do(ctx context.Context, method, path string, expectedStatus int, out interface{}) error {
+	u := c.endpoint + path
+
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close() //nolint: errcheck
+
+	if resp.StatusCode != expectedStatus {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint: errcheck
+
+		if resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusConflict {
+			return fmt.Errorf("%w: %s", ErrNoServersAvailable, strings.TrimSpace(string(body)))
+		}
+
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s", errNotFound, strings.TrimSpace(string(body)))
+		}
+
+		return fmt.Errorf("export API request %s %s failed with status %d: %s", method, u, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,411 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package export implements the API to lease servers of the exported ServerClasses to other Sidero instances.
+package export
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// Lease describes the server leased via the export.
+type Lease struct {
+	// Server is the name (UUID) of the server.
+	Server string `json:"server"`
+	// Spec is the server spec, including power management settings.
+	//
+	// BMC credentials are only included if the export API is started with the credentials export enabled.
+	Spec metalv1alpha1.ServerSpec `json:"spec"`
+}
+
+const leasesPath = "/v1/exports/"
+
+type exportServer struct {
+	c        client.Client
+	reader   client.Reader
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+
+	// inventorySecret holds the token of the standby Sidero installation, empty name disables the inventory
+	inventorySecret types.NamespacedName
+
+	// includeCredentials enables the BMC credentials in the leases and the inventory
+	includeCredentials bool
+
+	// leases of the same export are serialized to enforce the quota
+	mu sync.Mutex
+}
+
+// Serve the export API.
+//
+// API is served over TLS if the certificate and key files are set.
+// Inventory is served to the standby Sidero installation if the inventory secret is set.
+// BMC credentials are stripped from the exported servers, unless includeCredentials is set, which requires TLS.
+func Serve(c client.Client, reader client.Reader, recorder record.EventRecorder, scheme *runtime.Scheme, addr, certFile, keyFile string,
+	inventorySecret types.NamespacedName, includeCredentials bool) error {
+	tlsEnabled := certFile != "" && keyFile != ""
+
+	if includeCredentials && !tlsEnabled {
+		return fmt.Errorf("BMC credentials can only be exported over TLS")
+	}
+
+	s := &exportServer{
+		c:                  c,
+		reader:             reader,
+		scheme:             scheme,
+		recorder:           recorder,
+		inventorySecret:    inventorySecret,
+		includeCredentials: includeCredentials,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(leasesPath, s.handleLeases)
+
+	if inventorySecret.Name != "" {
+		mux.HandleFunc(inventoryPath, s.handleInventory)
+	}
+
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	if tlsEnabled {
+		return srv.ListenAndServeTLS(certFile, keyFile)
+	}
+
+	return srv.ListenAndServe()
+}
+
+// handleLeases routes the lease requests:
+//
+//   GET /v1/exports/<export>/leases
+//   POST /v1/exports/<export>/leases
+//   DELETE /v1/exports/<export>/leases/<server>
+func (s *exportServer) handleLeases(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, leasesPath), "/")
+
+	if len(parts) < 2 || parts[1] != "leases" || len(parts) > 3 {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	ctx := r.Context()
+
+	export, status, err := s.authenticate(ctx, r, parts[0])
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		log.Printf("export API request rejected: %s", err)
+
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 2:
+		var leases []Lease
+
+		leases, err = s.list(ctx, export)
+		if err == nil {
+			writeJSON(w, http.StatusOK, leases)
+		}
+	case r.Method == http.MethodPost && len(parts) == 2:
+		var lease *Lease
+
+		lease, status, err = s.lease(ctx, export)
+		if err == nil {
+			writeJSON(w, http.StatusCreated, lease)
+		}
+	case r.Method == http.MethodDelete && len(parts) == 3:
+		status, err = s.release(ctx, export, parts[2])
+		if err == nil {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if err != nil {
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+
+		http.Error(w, err.Error(), status)
+		log.Printf("export API request failed: %s", err)
+	}
+}
+
+// authenticate checks the bearer token against the export token.
+func (s *exportServer) authenticate(ctx context.Context, r *http.Request, name string) (*metalv1alpha1.ServerClassExport, int, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return nil, http.StatusUnauthorized, fmt.Errorf("missing token for export %q", name)
+	}
+
+	var export metalv1alpha1.ServerClassExport
+
+	if err := s.c.Get(ctx, types.NamespacedName{Name: name}, &export); err != nil {
+		if apierrors.IsNotFound(err) {
+			// don't reveal whether the export exists
+			return nil, http.StatusUnauthorized, fmt.Errorf("export %q not found", name)
+		}
+
+		return nil, http.StatusInternalServerError, err
+	}
+
+	var secret corev1.Secret
+
+	if err := s.reader.Get(ctx, types.NamespacedName{Namespace: export.Spec.TokenSecretRef.Namespace, Name: export.Spec.TokenSecretRef.Name}, &secret); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	expected := secret.Data["token"]
+
+	if len(expected) == 0 || subtle.ConstantTimeCompare(expected, []byte(token)) != 1 {
+		return nil, http.StatusUnauthorized, fmt.Errorf("invalid token for export %q", name)
+	}
+
+	return &export, 0, nil
+}
+
+func (s *exportServer) list(ctx context.Context, export *metalv1alpha1.ServerClassExport) ([]Lease, error) {
+	leased, err := s.leasedServers(ctx, export)
+	if err != nil {
+		return nil, err
+	}
+
+	leases := make([]Lease, 0, len(leased))
+
+	for _, server := range leased {
+		leases = append(leases, Lease{
+			Server: server.Name,
+			Spec:   s.exportedSpec(&server.Spec),
+		})
+	}
+
+	return leases, nil
+}
+
+func (s *exportServer) lease(ctx context.Context, export *metalv1alpha1.ServerClassExport) (*Lease, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	leased, err := s.leasedServers(ctx, export)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(leased) >= export.Spec.Quota {
+		return nil, http.StatusConflict, fmt.Errorf("export %q quota of %d servers exceeded", export.Name, export.Spec.Quota)
+	}
+
+	var serverClass metalv1alpha1.ServerClass
+
+	if err = s.c.Get(ctx, types.NamespacedName{Name: export.Spec.ServerClass}, &serverClass); err != nil {
+		return nil, 0, err
+	}
+
+	for _, name := range serverClass.Status.ServersAvailable {
+		var server metalv1alpha1.Server
+
+		if err = s.c.Get(ctx, types.NamespacedName{Name: name}, &server); err != nil {
+			return nil, 0, err
+		}
+
+		if !server.Spec.Accepted || server.Status.InUse || !server.Status.IsClean {
+			continue
+		}
+
+		if _, exported := server.Annotations[metalv1alpha1.ExportedAnnotation]; exported {
+			continue
+		}
+
+		if conditions.IsTrue(&server, metalv1alpha1.ConditionCablingInvalid) {
+			continue
+		}
+
+		if conditions.IsTrue(&server, metalv1alpha1.ConditionDuplicateMAC) {
+			continue
+		}
+
+		if server.IsFrozen(time.Now()) {
+			continue
+		}
+
+		if !serverClass.Tolerates(&server) {
+			continue
+		}
+
+		if server.Annotations == nil {
+			server.Annotations = map[string]string{}
+		}
+
+		server.Annotations[metalv1alpha1.ExportedAnnotation] = export.Name
+
+		// leased server is managed by the importer, so it shouldn't be touched (powered off or wiped) locally
+		server.Spec.Accepted = false
+
+		// update (vs. patch) fails if the server was modified (e.g. allocated) concurrently
+		if err = s.c.Update(ctx, &server); err != nil {
+			if apierrors.IsConflict(err) {
+				continue
+			}
+
+			return nil, 0, err
+		}
+
+		s.event(&server, fmt.Sprintf("Server leased via export %q.", export.Name))
+
+		lease := &Lease{
+			Server: server.Name,
+			Spec:   s.exportedSpec(&server.Spec),
+		}
+
+		// importer accepts the server on its own
+		lease.Spec.Accepted = false
+
+		return lease, 0, s.updateStatus(ctx, export, append(leased, server))
+	}
+
+	return nil, http.StatusServiceUnavailable, fmt.Errorf("no servers available in serverclass %q", serverClass.Name)
+}
+
+// exportedSpec returns the copy of the server spec, with the BMC credentials stripped unless they are exported.
+func (s *exportServer) exportedSpec(spec *metalv1alpha1.ServerSpec) metalv1alpha1.ServerSpec {
+	exported := *spec.DeepCopy()
+
+	if exported.BMC != nil && !s.includeCredentials {
+		exported.BMC.User = ""
+		exported.BMC.Pass = ""
+	}
+
+	return exported
+}
+
+func (s *exportServer) release(ctx context.Context, export *metalv1alpha1.ServerClassExport, name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var server metalv1alpha1.Server
+
+	if err := s.c.Get(ctx, types.NamespacedName{Name: name}, &server); err != nil {
+		if apierrors.IsNotFound(err) {
+			return http.StatusNotFound, err
+		}
+
+		return 0, err
+	}
+
+	if server.Annotations[metalv1alpha1.ExportedAnnotation] != export.Name {
+		return http.StatusNotFound, fmt.Errorf("server %q is not leased via export %q", name, export.Name)
+	}
+
+	patchHelper, err := patch.NewHelper(&server, s.c)
+	if err != nil {
+		return 0, err
+	}
+
+	delete(server.Annotations, metalv1alpha1.ExportedAnnotation)
+
+	// accepting the server back makes it wiped before it gets allocated again
+	server.Spec.Accepted = true
+
+	if err = patchHelper.Patch(ctx, &server); err != nil {
+		return 0, err
+	}
+
+	s.event(&server, fmt.Sprintf("Server released via export %q.", export.Name))
+
+	leased, err := s.leasedServers(ctx, export)
+	if err != nil {
+		return 0, err
+	}
+
+	return 0, s.updateStatus(ctx, export, leased)
+}
+
+// leasedServers returns the servers leased via the export.
+//
+// Servers are read bypassing the cache, as the cache might not yet reflect the recent leases.
+func (s *exportServer) leasedServers(ctx context.Context, export *metalv1alpha1.ServerClassExport) ([]metalv1alpha1.Server, error) {
+	var serverList metalv1alpha1.ServerList
+
+	if err := s.reader.List(ctx, &serverList); err != nil {
+		return nil, err
+	}
+
+	var leased []metalv1alpha1.Server
+
+	for _, server := range serverList.Items {
+		if server.Annotations[metalv1alpha1.ExportedAnnotation] == export.Name {
+			leased = append(leased, server)
+		}
+	}
+
+	return leased, nil
+}
+
+func (s *exportServer) updateStatus(ctx context.Context, export *metalv1alpha1.ServerClassExport, leased []metalv1alpha1.Server) error {
+	patchHelper, err := patch.NewHelper(export, s.c)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(leased))
+
+	for _, server := range leased {
+		if server.Annotations[metalv1alpha1.ExportedAnnotation] == export.Name {
+			names = append(names, server.Name)
+		}
+	}
+
+	sort.Strings(names)
+
+	export.Status.ServersLeased = names
+
+	return patchHelper.Patch(ctx, export)
+}
+
+func (s *exportServer) event(server *metalv1alpha1.Server, message string) {
+	ref, err := reference.GetReference(s.scheme, server)
+	if err != nil {
+		log.Printf("failed to get server reference: %s", err)
+
+		return
+	}
+
+	s.recorder.Event(ref, corev1.EventTypeNormal, "Server Export", message)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write response: %s", err)
+	}
+}
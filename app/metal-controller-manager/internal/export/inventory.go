@@ -0,0 +1,129 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package export
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+const inventoryPath = "/v1/inventory"
+
+// Inventory is the boot state of the servers mirrored by the standby Sidero installation.
+//
+// Status is only meaningful for the servers, the status of the other resources is derived by the standby itself.
+type Inventory struct {
+	Servers        []metalv1alpha1.Server      `json:"servers"`
+	ServerBindings []infrav1.ServerBinding     `json:"serverBindings"`
+	ServerClasses  []metalv1alpha1.ServerClass `json:"serverClasses"`
+	Environments   []metalv1alpha1.Environment `json:"environments"`
+	Sites          []metalv1alpha1.Site        `json:"sites"`
+}
+
+// handleInventory serves the inventory to the standby Sidero installation:
+//
+//   GET /v1/inventory
+func (s *exportServer) handleInventory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	ctx := r.Context()
+
+	if status, err := s.authenticateStandby(ctx, r); err != nil {
+		http.Error(w, err.Error(), status)
+		log.Printf("inventory request rejected: %s", err)
+
+		return
+	}
+
+	inventory, err := s.inventory(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("inventory request failed: %s", err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, inventory)
+}
+
+// authenticateStandby checks the bearer token against the token of the inventory secret.
+func (s *exportServer) authenticateStandby(ctx context.Context, r *http.Request) (int, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return http.StatusUnauthorized, fmt.Errorf("missing inventory token")
+	}
+
+	var secret corev1.Secret
+
+	if err := s.reader.Get(ctx, s.inventorySecret, &secret); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	expected := secret.Data["token"]
+
+	if len(expected) == 0 || subtle.ConstantTimeCompare(expected, []byte(token)) != 1 {
+		return http.StatusUnauthorized, fmt.Errorf("invalid inventory token")
+	}
+
+	return 0, nil
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=environments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=sites,verbs=get;list;watch
+
+func (s *exportServer) inventory(ctx context.Context) (*Inventory, error) {
+	var (
+		servers        metalv1alpha1.ServerList
+		serverBindings infrav1.ServerBindingList
+		serverClasses  metalv1alpha1.ServerClassList
+		environments   metalv1alpha1.EnvironmentList
+		sites          metalv1alpha1.SiteList
+	)
+
+	if err := s.c.List(ctx, &servers); err != nil {
+		return nil, err
+	}
+
+	if err := s.c.List(ctx, &serverBindings); err != nil {
+		return nil, err
+	}
+
+	if err := s.c.List(ctx, &serverClasses); err != nil {
+		return nil, err
+	}
+
+	if err := s.c.List(ctx, &environments); err != nil {
+		return nil, err
+	}
+
+	if err := s.c.List(ctx, &sites); err != nil {
+		return nil, err
+	}
+
+	for i := range servers.Items {
+		servers.Items[i].Spec = s.exportedSpec(&servers.Items[i].Spec)
+	}
+
+	return &Inventory{
+		Servers:        servers.Items,
+		ServerBindings: serverBindings.Items,
+		ServerClasses:  serverClasses.Items,
+		Environments:   environments.Items,
+		Sites:          sites.Items,
+	}, nil
+}
@@ -0,0 +1,273 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package expression evaluates the CEL expressions of the ServerClass qualifiers against the servers.
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// serverVariable is the name of the variable the server is bound to in the expression.
+const serverVariable = "server"
+
+// Expression is the compiled qualifier expression.
+type Expression struct {
+	program cel.Program
+}
+
+// Compile parses and checks the expression, the expression should evaluate to a boolean.
+func Compile(source string) (*Expression, error) {
+	env, err := cel.NewEnv(
+		cel.Declarations(decls.NewVar(serverVariable, decls.NewMapType(decls.String, decls.Dyn))),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(source)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("error compiling expression %q: %w", source, issues.Err())
+	}
+
+	// fields of the server are dynamically typed, so that the result type might be only known on evaluation
+	if !proto.Equal(ast.ResultType(), decls.Bool) && !proto.Equal(ast.ResultType(), decls.Dyn) {
+		return nil, fmt.Errorf("expression %q should evaluate to a boolean", source)
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Expression{program: program}, nil
+}
+
+// Matches evaluates the expression against the server.
+//
+// Server is presented to the expression the same way it's serialized, e.g. `server.status.memory.size`.
+func (e *Expression) Matches(server *metalv1alpha1.Server) (bool, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(server)
+	if err != nil {
+		return false, err
+	}
+
+	val, _, err := e.program.Eval(map[string]interface{}{serverVariable: normalize(obj)})
+	if err != nil {
+		return false, err
+	}
+
+	matched, ok := val.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluated to %v, not a boolean", val.Value())
+	}
+
+	return matched, nil
+}
+
+// normalize converts the unsigned integers to int64, so that all the integer fields are compared with the integer literals.
+//
+// CEL doesn't compare int and uint values.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = normalize(val)
+		}
+
+		return v
+	case []interface{}:
+		for i, val := range v {
+			v[i] = normalize(val)
+		}
+
+		return v
+	case uint64:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	default:
+		return v
+	}
+}
+
+// UnknownFields returns the fields of the server referenced by the expression which don't exist in the Server resource,
+// e.g. `server.status.memroy.size`.
+//
+// Such references fail the evaluation, so that the expression doesn't match any servers.
+func UnknownFields(source string) ([]string, error) {
+	env, err := cel.NewEnv(
+		cel.Declarations(decls.NewVar(serverVariable, decls.NewMapType(decls.String, decls.Dyn))),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Parse(source)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("error parsing expression %q: %w", source, issues.Err())
+	}
+
+	unknown := map[string]struct{}{}
+
+	walk(ast.Expr(), func(e *exprpb.Expr) {
+		path, ok := serverPath(e)
+		if !ok || len(path) == 0 {
+			return
+		}
+
+		if !fieldExists(reflect.TypeOf(metalv1alpha1.Server{}), path) {
+			unknown[serverVariable+"."+strings.Join(path, ".")] = struct{}{}
+		}
+	})
+
+	fields := make([]string, 0, len(unknown))
+
+	for field := range unknown {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	// only the shortest unknown path is reported, e.g. `server.foo` for `server.foo.bar`
+	result := fields[:0]
+
+	for _, field := range fields {
+		if len(result) > 0 && strings.HasPrefix(field, result[len(result)-1]+".") {
+			continue
+		}
+
+		result = append(result, field)
+	}
+
+	return result, nil
+}
+
+// serverPath returns the chain of the fields selected on the server variable.
+func serverPath(e *exprpb.Expr) ([]string, bool) {
+	switch {
+	case e.GetIdentExpr() != nil:
+		return nil, e.GetIdentExpr().GetName() == serverVariable
+	case e.GetSelectExpr() != nil:
+		path, ok := serverPath(e.GetSelectExpr().GetOperand())
+		if !ok {
+			return nil, false
+		}
+
+		return append(path, e.GetSelectExpr().GetField()), true
+	default:
+		return nil, false
+	}
+}
+
+// walk calls the function for the expression and all its subexpressions.
+func walk(e *exprpb.Expr, fn func(*exprpb.Expr)) {
+	if e == nil {
+		return
+	}
+
+	fn(e)
+
+	switch {
+	case e.GetSelectExpr() != nil:
+		walk(e.GetSelectExpr().GetOperand(), fn)
+	case e.GetCallExpr() != nil:
+		walk(e.GetCallExpr().GetTarget(), fn)
+
+		for _, arg := range e.GetCallExpr().GetArgs() {
+			walk(arg, fn)
+		}
+	case e.GetListExpr() != nil:
+		for _, elem := range e.GetListExpr().GetElements() {
+			walk(elem, fn)
+		}
+	case e.GetStructExpr() != nil:
+		for _, entry := range e.GetStructExpr().GetEntries() {
+			walk(entry.GetMapKey(), fn)
+			walk(entry.GetValue(), fn)
+		}
+	case e.GetComprehensionExpr() != nil:
+		comprehension := e.GetComprehensionExpr()
+
+		for _, sub := range []*exprpb.Expr{
+			comprehension.GetIterRange(),
+			comprehension.GetAccuInit(),
+			comprehension.GetLoopCondition(),
+			comprehension.GetLoopStep(),
+			comprehension.GetResult(),
+		} {
+			walk(sub, fn)
+		}
+	}
+}
+
+var jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// fieldExists checks whether the path of the serialized field names exists in the type.
+//
+// Keys of the maps and the fields of the lists and free-form values are not checked.
+func fieldExists(t reflect.Type, path []string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if len(path) == 0 {
+		return true
+	}
+
+	switch t.Kind() { //nolint: exhaustive
+	case reflect.Map:
+		return fieldExists(t.Elem(), path[1:])
+	case reflect.Slice, reflect.Array, reflect.Interface:
+		return true
+	case reflect.Struct:
+		// types with the custom serialization (e.g. metav1.Time, resource.Quantity) are scalars
+		if t.Implements(jsonMarshaler) || reflect.PtrTo(t).Implements(jsonMarshaler) {
+			return false
+		}
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+			switch {
+			case name == "-" || field.PkgPath != "":
+				continue
+			case name == "" && field.Anonymous:
+				if fieldExists(field.Type, path) {
+					return true
+				}
+
+				continue
+			case name == "":
+				name = field.Name
+			}
+
+			if name == path[0] {
+				return fieldExists(field.Type, path[1:])
+			}
+		}
+
+		return false
+	default:
+		return false
+	}
+}
@@ -0,0 +1,138 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package expression_test
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/expression"
+)
+
+func TestExpression(t *testing.T) {
+	server := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "server-1",
+			Labels: map[string]string{"rack": "r1"},
+		},
+		Spec: metalv1alpha1.ServerSpec{
+			CPU: &metalv1alpha1.CPUInformation{
+				Manufacturer: "Intel(R) Corporation",
+			},
+		},
+		Status: metalv1alpha1.ServerStatus{
+			Memory: &metalv1alpha1.MemoryInformation{
+				Size:    128 << 30,
+				Modules: 8,
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		name       string
+		expression string
+		want       bool
+		compileErr bool
+		evalErr    bool
+	}{
+		{
+			name:       "numeric comparison",
+			expression: "server.status.memory.size >= 128 * 1024 * 1024 * 1024 && server.status.memory.modules > 4",
+			want:       true,
+		},
+		{
+			name:       "boolean logic across fields",
+			expression: `server.spec.cpu.manufacturer.startsWith("AMD") || server.metadata.labels.rack in ["r2", "r3"]`,
+			want:       false,
+		},
+		{
+			name:       "guarded missing field",
+			expression: "has(server.spec.bmc) && server.spec.bmc.port == 623",
+			want:       false,
+		},
+		{
+			name:       "missing field",
+			expression: "server.spec.bmc.port == 623",
+			evalErr:    true,
+		},
+		{
+			name:       "not a boolean",
+			expression: "1 + 2",
+			compileErr: true,
+		},
+		{
+			name:       "syntax error",
+			expression: "server.spec.cpu ==",
+			compileErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := expression.Compile(tt.expression)
+			if (err != nil) != tt.compileErr {
+				t.Fatalf("Compile() error = %v, want error %v", err, tt.compileErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got, err := expr.Matches(server)
+			if (err != nil) != tt.evalErr {
+				t.Fatalf("Matches() error = %v, want error %v", err, tt.evalErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		expression string
+		want       []string
+	}{
+		{
+			name:       "known fields",
+			expression: `server.status.memory.size > 1 && server.metadata.labels.rack == "r1" && has(server.spec.cpu.manufacturer)`,
+		},
+		{
+			name:       "inline fields",
+			expression: `server.kind == "Server" && server.status.pciDevices.exists(d, d.vendorId == "15b3")`,
+		},
+		{
+			name:       "typo",
+			expression: `server.status.memroy.size > 1 || server.spec.cpu.vendor == "Intel"`,
+			want:       []string{"server.spec.cpu.vendor", "server.status.memroy"},
+		},
+		{
+			name:       "field of scalar",
+			expression: `server.metadata.creationTimestamp.seconds > 0`,
+			want:       []string{"server.metadata.creationTimestamp.seconds"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expression.UnknownFields(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("UnknownFields() = %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("UnknownFields() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package health implements the health checks of the boot services.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// probeTimeout is the timeout of the liveness probe of the listener.
+const probeTimeout = 5 * time.Second
+
+// Listener tracks the bind status of the boot service.
+type Listener struct {
+	Name string
+
+	// Probe checks whether the service responds, if set.
+	Probe func(ctx context.Context) error
+
+	mu        sync.Mutex
+	listening bool
+}
+
+// Boot service listeners.
+var (
+	TFTP = &Listener{Name: "tftp"}
+	IPXE = &Listener{Name: "ipxe", Probe: httpProbe("http://127.0.0.1:8081/healthz")}
+	API  = &Listener{Name: "api"}
+)
+
+// Listeners lists all the boot service listeners.
+var Listeners = []*Listener{TFTP, IPXE, API}
+
+// Serving gates the boot services, they only answer the requests while the gate is open.
+//
+// The gate is always open, unless it tracks the state of the standby installation.
+var Serving = &Gate{}
+
+// Gate reports whether the boot services should answer the requests.
+type Gate struct {
+	mu   sync.Mutex
+	open func() bool
+}
+
+// Track makes the gate follow the state reported by the function.
+func (g *Gate) Track(open func() bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.open = open
+}
+
+// Open reports whether the boot services should answer the requests.
+func (g *Gate) Open() bool {
+	g.mu.Lock()
+	open := g.open
+	g.mu.Unlock()
+
+	return open == nil || open()
+}
+
+// Listening marks the service as bound to its address.
+func (l *Listener) Listening() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.listening = true
+}
+
+func (l *Listener) isListening() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.listening
+}
+
+// Ready implements healthz.Checker, it fails until the service is bound to its address.
+func (l *Listener) Ready(_ *http.Request) error {
+	if !l.isListening() {
+		return fmt.Errorf("%s is not listening", l.Name)
+	}
+
+	return nil
+}
+
+// Live implements healthz.Checker, it fails if the service is listening, but doesn't respond to the probe.
+//
+// Services which are not listening yet are covered by the readiness check.
+func (l *Listener) Live(req *http.Request) error {
+	if l.Probe == nil || !l.isListening() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), probeTimeout)
+	defer cancel()
+
+	if err := l.Probe(ctx); err != nil {
+		return fmt.Errorf("%s doesn't respond: %w", l.Name, err)
+	}
+
+	return nil
+}
+
+func httpProbe(url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		resp.Body.Close() //nolint: errcheck
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+
+		return nil
+	}
+}
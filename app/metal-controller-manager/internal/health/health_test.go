@@ -0,0 +1,33 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package health_test
+
+import (
+	"testing"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/health"
+)
+
+func TestGate(t *testing.T) {
+	var g health.Gate
+
+	if !g.Open() {
+		t.Error("gate is closed by default")
+	}
+
+	active := false
+
+	g.Track(func() bool { return active })
+
+	if g.Open() {
+		t.Error("gate is open while the standby is not active")
+	}
+
+	active = true
+
+	if !g.Open() {
+		t.Error("gate is closed while the standby is active")
+	}
+}
@@ -0,0 +1,180 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package importer converts the inventories of other provisioners into Servers.
+package importer
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// MigratedAnnotation is set on the servers converted from the inventory of another provisioner.
+//
+// Value of the annotation is the format and the ID of the server in the original inventory, e.g. `maas/4y3h7n`.
+const MigratedAnnotation = "metal.sidero.dev/migrated-from"
+
+// Format is the inventory format of the other provisioner.
+type Format string
+
+// Supported inventory formats.
+const (
+	// FormatTinkerbell is the Tinkerbell Hardware resources (along with the Rufio Machine resources and their Secrets for the BMC).
+	FormatTinkerbell Format = "tinkerbell"
+	// FormatMaaS is the output of `maas machines read` (along with the output of `maas machines power-parameters`).
+	FormatMaaS Format = "maas"
+	// FormatIronic is the output of the Ironic API `/v1/nodes/detail` (along with `/v1/ports/detail`).
+	FormatIronic Format = "ironic"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{FormatTinkerbell, FormatMaaS, FormatIronic}
+
+// entry is the server of the other provisioner inventory.
+type entry struct {
+	// ID identifies the server in the original inventory.
+	ID string
+	// UUID is the SMBIOS UUID of the server, it becomes the name of the Server.
+	UUID     string
+	Hostname string
+	// BootMAC is the MAC address of the interface the server PXE boots from.
+	BootMAC string
+	BMC     *metalv1alpha1.BMC
+	Labels  map[string]string
+}
+
+// Options controls the conversion of the inventory.
+type Options struct {
+	// Accept marks the imported servers as accepted.
+	Accept bool
+}
+
+// Import converts the inventory documents into Servers.
+//
+// Servers which can't be converted (e.g. without the SMBIOS UUID) are skipped, the reasons are returned as the warnings.
+func Import(format Format, documents [][]byte, opts Options) (servers []metalv1alpha1.Server, warnings []string, err error) {
+	var entries []entry
+
+	switch format {
+	case FormatTinkerbell:
+		entries, warnings, err = parseTinkerbell(documents)
+	case FormatMaaS:
+		entries, warnings, err = parseMaaS(documents)
+	case FormatIronic:
+		entries, warnings, err = parseIronic(documents)
+	default:
+		return nil, nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := map[string]string{}
+
+	for _, e := range entries {
+		server, problems := e.server(format, opts)
+
+		if server != nil {
+			if other, ok := seen[server.Name]; ok {
+				problems = append(problems, fmt.Sprintf("UUID %s is already used by %s", server.Name, other))
+				server = nil
+			}
+		}
+
+		for _, problem := range problems {
+			warnings = append(warnings, fmt.Sprintf("%s %s: %s", format, e.ID, problem))
+		}
+
+		if server == nil {
+			continue
+		}
+
+		seen[server.Name] = e.ID
+
+		servers = append(servers, *server)
+	}
+
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+
+	return servers, warnings, nil
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// server converts the entry into the Server, problems which don't prevent the conversion are reported along with the Server.
+func (e *entry) server(format Format, opts Options) (*metalv1alpha1.Server, []string) {
+	uuid := strings.ToLower(strings.TrimSpace(e.UUID))
+
+	if uuid == "" {
+		return nil, []string{"no system UUID, the server is skipped"}
+	}
+
+	if !uuidRegexp.MatchString(uuid) {
+		return nil, []string{fmt.Sprintf("system UUID %q is invalid, the server is skipped", e.UUID)}
+	}
+
+	var problems []string
+
+	server := &metalv1alpha1.Server{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: metalv1alpha1.GroupVersion.String(),
+			Kind:       "Server",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: uuid,
+			Annotations: map[string]string{
+				MigratedAnnotation: string(format) + "/" + e.ID,
+			},
+		},
+		Spec: metalv1alpha1.ServerSpec{
+			Hostname: e.Hostname,
+			BMC:      e.BMC,
+			Accepted: opts.Accept,
+		},
+	}
+
+	if e.BootMAC != "" {
+		mac, err := net.ParseMAC(e.BootMAC)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("boot MAC address %q is invalid", e.BootMAC))
+		} else {
+			server.Spec.ProvisioningInterface = mac.String()
+		}
+	}
+
+	keys := make([]string, 0, len(e.Labels))
+
+	for key := range e.Labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := e.Labels[key]
+
+		if errs := append(validation.IsQualifiedName(key), validation.IsValidLabelValue(value)...); len(errs) > 0 {
+			problems = append(problems, fmt.Sprintf("label %q is skipped: %s", key, strings.Join(errs, ", ")))
+
+			continue
+		}
+
+		if server.Labels == nil {
+			server.Labels = map[string]string{}
+		}
+
+		server.Labels[key] = value
+	}
+
+	return server, problems
+}
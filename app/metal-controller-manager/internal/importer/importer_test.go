@@ -0,0 +1,199 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package importer_test
+
+import (
+	"reflect"
+	"testing"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/importer"
+)
+
+const tinkerbellInventory = `
+apiVersion: tinkerbell.org/v1alpha1
+kind: Hardware
+metadata:
+  name: worker-1
+  namespace: tink
+  labels:
+    rack: r1
+spec:
+  bmcRef:
+    kind: Machine
+    name: worker-1-bmc
+  interfaces:
+    - dhcp:
+        mac: "52:54:00:AB:CD:01"
+        hostname: worker-1
+      netboot:
+        allowPXE: true
+  metadata:
+    instance:
+      id: 4C4C4544-0035-5910-804B-B2C04F4E4D32
+---
+apiVersion: bmc.tinkerbell.org/v1alpha1
+kind: Machine
+metadata:
+  name: worker-1-bmc
+  namespace: tink
+spec:
+  connection:
+    host: 10.2.3.4
+    authSecretRef:
+      name: worker-1-bmc
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: worker-1-bmc
+  namespace: tink
+data:
+  username: YWRtaW4=
+  password: c2VjcmV0
+---
+apiVersion: tinkerbell.org/v1alpha1
+kind: Hardware
+metadata:
+  name: worker-2
+  namespace: tink
+spec:
+  interfaces:
+    - dhcp:
+        mac: "52:54:00:ab:cd:02"
+`
+
+const maasMachines = `[
+  {"system_id": "4y3h7n", "hostname": "node-1", "hardware_uuid": "00000000-0000-0000-0000-d05099d33360", "power_type": "ipmi",
+   "boot_interface": {"mac_address": "52:54:00:ab:cd:03"}, "tag_names": ["gpu", "not a label"]},
+  {"system_id": "8c7vnb", "hostname": "node-2", "hardware_uuid": "00000000-0000-0000-0000-d05099d33361", "power_type": "manual"}
+]`
+
+const maasPower = `{"4y3h7n": {"power_address": "10.2.3.5", "power_user": "maas", "power_pass": "pass"}}`
+
+const ironicNodes = `{"nodes": [
+  {"uuid": "1be26c0b-03f2-4d2e-ae87-c02d7f33c123", "name": "node-3", "driver": "ipmi",
+   "driver_info": {"ipmi_address": "10.2.3.6", "ipmi_username": "root", "ipmi_password": "******"},
+   "properties": {"capabilities": "boot_mode:uefi,profile:compute"},
+   "extra": {"system_uuid": "00000000-0000-0000-0000-d05099d33362"}}
+]}`
+
+const ironicPorts = `{"ports": [
+  {"address": "52:54:00:ab:cd:04", "node_uuid": "1be26c0b-03f2-4d2e-ae87-c02d7f33c123", "pxe_enabled": false},
+  {"address": "52:54:00:ab:cd:05", "node_uuid": "1be26c0b-03f2-4d2e-ae87-c02d7f33c123", "pxe_enabled": true}
+]}`
+
+func TestImport(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		format       importer.Format
+		documents    []string
+		opts         importer.Options
+		wantServers  map[string]metalv1alpha1.ServerSpec
+		wantLabels   map[string]map[string]string
+		wantWarnings int
+	}{
+		{
+			name:      "tinkerbell",
+			format:    importer.FormatTinkerbell,
+			documents: []string{tinkerbellInventory},
+			opts:      importer.Options{Accept: true},
+			wantServers: map[string]metalv1alpha1.ServerSpec{
+				"4c4c4544-0035-5910-804b-b2c04f4e4d32": {
+					Hostname:              "worker-1",
+					BMC:                   &metalv1alpha1.BMC{Endpoint: "10.2.3.4", User: "admin", Pass: "secret"},
+					ProvisioningInterface: "52:54:00:ab:cd:01",
+					Accepted:              true,
+				},
+			},
+			wantLabels: map[string]map[string]string{
+				"4c4c4544-0035-5910-804b-b2c04f4e4d32": {"rack": "r1"},
+			},
+			// worker-2 has no UUID
+			wantWarnings: 1,
+		},
+		{
+			name:      "maas",
+			format:    importer.FormatMaaS,
+			documents: []string{maasMachines, maasPower},
+			wantServers: map[string]metalv1alpha1.ServerSpec{
+				"00000000-0000-0000-0000-d05099d33360": {
+					Hostname:              "node-1",
+					BMC:                   &metalv1alpha1.BMC{Endpoint: "10.2.3.5", User: "maas", Pass: "pass"},
+					ProvisioningInterface: "52:54:00:ab:cd:03",
+				},
+				"00000000-0000-0000-0000-d05099d33361": {
+					Hostname: "node-2",
+				},
+			},
+			wantLabels: map[string]map[string]string{
+				"00000000-0000-0000-0000-d05099d33360": {"gpu": "true"},
+			},
+			// invalid tag, manual power type
+			wantWarnings: 2,
+		},
+		{
+			name:      "ironic",
+			format:    importer.FormatIronic,
+			documents: []string{ironicNodes, ironicPorts},
+			wantServers: map[string]metalv1alpha1.ServerSpec{
+				"00000000-0000-0000-0000-d05099d33362": {
+					Hostname:              "node-3",
+					ProvisioningInterface: "52:54:00:ab:cd:05",
+				},
+			},
+			wantLabels: map[string]map[string]string{
+				"00000000-0000-0000-0000-d05099d33362": {"boot_mode": "uefi", "profile": "compute"},
+			},
+			// masked password
+			wantWarnings: 1,
+		},
+		{
+			name:         "duplicate UUID",
+			format:       importer.FormatMaaS,
+			documents:    []string{`[{"system_id": "a", "hardware_uuid": "00000000-0000-0000-0000-d05099d33360"}]`, `[{"system_id": "b", "hardware_uuid": "00000000-0000-0000-0000-D05099D33360"}]`},
+			wantServers:  map[string]metalv1alpha1.ServerSpec{"00000000-0000-0000-0000-d05099d33360": {}},
+			wantWarnings: 3,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			documents := make([][]byte, 0, len(tt.documents))
+
+			for _, document := range tt.documents {
+				documents = append(documents, []byte(document))
+			}
+
+			servers, warnings, err := importer.Import(tt.format, documents, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("unexpected warnings: %q", warnings)
+			}
+
+			if len(servers) != len(tt.wantServers) {
+				t.Fatalf("expected %d servers, got %d", len(tt.wantServers), len(servers))
+			}
+
+			for _, server := range servers {
+				if !reflect.DeepEqual(server.Spec, tt.wantServers[server.Name]) {
+					t.Errorf("unexpected spec of %s: %+v", server.Name, server.Spec)
+				}
+
+				if len(server.Labels) > 0 || len(tt.wantLabels[server.Name]) > 0 {
+					if !reflect.DeepEqual(server.Labels, tt.wantLabels[server.Name]) {
+						t.Errorf("unexpected labels of %s: %v", server.Name, server.Labels)
+					}
+				}
+
+				if server.Annotations[importer.MigratedAnnotation] == "" {
+					t.Errorf("server %s is not annotated", server.Name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// ironicMaskedPassword is returned by the Ironic API instead of the passwords, unless the policy allows to read them.
+const ironicMaskedPassword = "******"
+
+// ironicInventory is the subset of the Ironic node and port lists used by the import.
+type ironicInventory struct {
+	Nodes []struct {
+		UUID       string `json:"uuid"`
+		Name       string `json:"name"`
+		Driver     string `json:"driver"`
+		DriverInfo struct {
+			IPMIAddress  string `json:"ipmi_address"`
+			IPMIUsername string `json:"ipmi_username"`
+			IPMIPassword string `json:"ipmi_password"`
+		} `json:"driver_info"`
+		Properties struct {
+			Capabilities string `json:"capabilities"`
+		} `json:"properties"`
+		Extra struct {
+			SystemUUID string `json:"system_uuid"`
+		} `json:"extra"`
+	} `json:"nodes"`
+	Ports []struct {
+		Address    string `json:"address"`
+		NodeUUID   string `json:"node_uuid"`
+		PXEEnabled *bool  `json:"pxe_enabled"`
+	} `json:"ports"`
+}
+
+// parseIronic converts the Ironic nodes, capabilities become the labels.
+//
+// SMBIOS UUID is read from `system_uuid` of the node extra, as the node UUID is generated by Ironic.
+// Documents are the responses of the Ironic API `/v1/nodes/detail` and `/v1/ports/detail`.
+func parseIronic(documents [][]byte) ([]entry, []string, error) {
+	var inventory ironicInventory
+
+	for _, document := range documents {
+		var part ironicInventory
+
+		if err := json.Unmarshal(document, &part); err != nil {
+			return nil, nil, fmt.Errorf("error parsing Ironic inventory: %w", err)
+		}
+
+		inventory.Nodes = append(inventory.Nodes, part.Nodes...)
+		inventory.Ports = append(inventory.Ports, part.Ports...)
+	}
+
+	bootMACs := map[string]string{}
+
+	for _, port := range inventory.Ports {
+		if port.PXEEnabled != nil && !*port.PXEEnabled {
+			continue
+		}
+
+		if _, ok := bootMACs[port.NodeUUID]; !ok {
+			bootMACs[port.NodeUUID] = port.Address
+		}
+	}
+
+	var (
+		entries  []entry
+		warnings []string
+	)
+
+	for _, node := range inventory.Nodes {
+		e := entry{
+			ID:       node.UUID,
+			UUID:     node.Extra.SystemUUID,
+			Hostname: node.Name,
+			BootMAC:  bootMACs[node.UUID],
+		}
+
+		for _, capability := range strings.Split(node.Properties.Capabilities, ",") {
+			parts := strings.SplitN(capability, ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+
+			if e.Labels == nil {
+				e.Labels = map[string]string{}
+			}
+
+			e.Labels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+
+		switch info := node.DriverInfo; {
+		case !strings.HasSuffix(node.Driver, "ipmi"):
+			warnings = append(warnings, fmt.Sprintf("%s %s: driver %q is not supported, BMC is not imported", FormatIronic, e.ID, node.Driver))
+		case info.IPMIPassword == ironicMaskedPassword:
+			warnings = append(warnings, fmt.Sprintf("%s %s: IPMI password is masked by the Ironic API, BMC is not imported", FormatIronic, e.ID))
+		default:
+			e.BMC = &metalv1alpha1.BMC{
+				Endpoint: info.IPMIAddress,
+				User:     info.IPMIUsername,
+				Pass:     info.IPMIPassword,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, warnings, nil
+}
@@ -0,0 +1,111 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// maasMachine is the subset of the MaaS machine used by the import.
+type maasMachine struct {
+	SystemID      string `json:"system_id"`
+	Hostname      string `json:"hostname"`
+	HardwareUUID  string `json:"hardware_uuid"`
+	PowerType     string `json:"power_type"`
+	BootInterface *struct {
+		MACAddress string `json:"mac_address"`
+	} `json:"boot_interface"`
+	TagNames []string `json:"tag_names"`
+}
+
+// maasPowerParameters is the subset of the MaaS power parameters used by the import.
+type maasPowerParameters struct {
+	PowerAddress string `json:"power_address"`
+	PowerUser    string `json:"power_user"`
+	PowerPass    string `json:"power_pass"`
+}
+
+// parseMaaS converts the MaaS machines, tags become the labels with the value "true".
+//
+// Documents are either the list of machines (`maas machines read`), or the power parameters keyed by the system ID
+// (`maas machines power-parameters`).
+func parseMaaS(documents [][]byte) ([]entry, []string, error) {
+	var (
+		machines []maasMachine
+		power    = map[string]maasPowerParameters{}
+	)
+
+	for _, document := range documents {
+		document = bytes.TrimSpace(document)
+
+		if bytes.HasPrefix(document, []byte("[")) {
+			var list []maasMachine
+
+			if err := json.Unmarshal(document, &list); err != nil {
+				return nil, nil, fmt.Errorf("error parsing MaaS machines: %w", err)
+			}
+
+			machines = append(machines, list...)
+
+			continue
+		}
+
+		var params map[string]maasPowerParameters
+
+		if err := json.Unmarshal(document, &params); err != nil {
+			return nil, nil, fmt.Errorf("error parsing MaaS power parameters: %w", err)
+		}
+
+		for id, p := range params {
+			power[id] = p
+		}
+	}
+
+	var (
+		entries  []entry
+		warnings []string
+	)
+
+	for _, machine := range machines {
+		e := entry{
+			ID:       machine.SystemID,
+			UUID:     machine.HardwareUUID,
+			Hostname: machine.Hostname,
+		}
+
+		if machine.BootInterface != nil {
+			e.BootMAC = machine.BootInterface.MACAddress
+		}
+
+		for _, tag := range machine.TagNames {
+			if e.Labels == nil {
+				e.Labels = map[string]string{}
+			}
+
+			e.Labels[tag] = "true"
+		}
+
+		switch p, ok := power[machine.SystemID]; {
+		case machine.PowerType != "ipmi":
+			warnings = append(warnings, fmt.Sprintf("%s %s: power type %q is not supported, BMC is not imported", FormatMaaS, e.ID, machine.PowerType))
+		case !ok:
+			warnings = append(warnings, fmt.Sprintf("%s %s: power parameters are missing, BMC is not imported", FormatMaaS, e.ID))
+		default:
+			e.BMC = &metalv1alpha1.BMC{
+				Endpoint: p.PowerAddress,
+				User:     p.PowerUser,
+				Pass:     p.PowerPass,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, warnings, nil
+}
@@ -0,0 +1,173 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package importer
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// tinkerbellObject is the subset of the Tinkerbell Hardware, Rufio Machine and Secret resources used by the import.
+type tinkerbellObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		// Hardware
+		BMCRef *struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"bmcRef"`
+		Interfaces []struct {
+			DHCP *struct {
+				MAC      string `json:"mac"`
+				Hostname string `json:"hostname"`
+			} `json:"dhcp"`
+			Netboot *struct {
+				AllowPXE *bool `json:"allowPXE"`
+			} `json:"netboot"`
+		} `json:"interfaces"`
+		Metadata *struct {
+			Instance *struct {
+				ID       string `json:"id"`
+				Hostname string `json:"hostname"`
+			} `json:"instance"`
+		} `json:"metadata"`
+
+		// Machine
+		Connection *struct {
+			Host          string `json:"host"`
+			AuthSecretRef struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"authSecretRef"`
+		} `json:"connection"`
+	} `json:"spec"`
+
+	// Secret
+	Data map[string][]byte `json:"data"`
+
+	// List
+	Items []tinkerbellObject `json:"items"`
+}
+
+// parseTinkerbell converts the Hardware resources, BMC credentials are looked up in the Machine resources and their Secrets.
+//
+// Documents are YAML or JSON, either the single resources, multi-document YAML or the lists.
+func parseTinkerbell(documents [][]byte) ([]entry, []string, error) {
+	var objects []tinkerbellObject
+
+	for _, document := range documents {
+		for _, part := range bytes.Split(document, []byte("\n---")) {
+			if len(bytes.TrimSpace(part)) == 0 {
+				continue
+			}
+
+			var obj tinkerbellObject
+
+			if err := yaml.Unmarshal(part, &obj); err != nil {
+				return nil, nil, fmt.Errorf("error parsing Tinkerbell resources: %w", err)
+			}
+
+			objects = append(objects, obj)
+			objects = append(objects, obj.Items...)
+		}
+	}
+
+	machines := map[string]*tinkerbellObject{}
+	secrets := map[string]*tinkerbellObject{}
+
+	for i := range objects {
+		obj := &objects[i]
+
+		switch obj.Kind {
+		case "Machine":
+			machines[obj.Metadata.Namespace+"/"+obj.Metadata.Name] = obj
+		case "Secret":
+			secrets[obj.Metadata.Namespace+"/"+obj.Metadata.Name] = obj
+		}
+	}
+
+	var (
+		entries  []entry
+		warnings []string
+	)
+
+	for _, obj := range objects {
+		if obj.Kind != "Hardware" {
+			continue
+		}
+
+		e := entry{
+			ID:     obj.Metadata.Name,
+			Labels: obj.Metadata.Labels,
+		}
+
+		if obj.Spec.Metadata != nil && obj.Spec.Metadata.Instance != nil {
+			e.UUID = obj.Spec.Metadata.Instance.ID
+			e.Hostname = obj.Spec.Metadata.Instance.Hostname
+		}
+
+		for _, iface := range obj.Spec.Interfaces {
+			if iface.DHCP == nil || (iface.Netboot != nil && iface.Netboot.AllowPXE != nil && !*iface.Netboot.AllowPXE) {
+				continue
+			}
+
+			e.BootMAC = iface.DHCP.MAC
+
+			if e.Hostname == "" {
+				e.Hostname = iface.DHCP.Hostname
+			}
+
+			break
+		}
+
+		if obj.Spec.BMCRef != nil {
+			bmc, err := tinkerbellBMC(machines, secrets, obj.Metadata.Namespace, obj.Spec.BMCRef.Name)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s %s: %s", FormatTinkerbell, e.ID, err))
+			}
+
+			e.BMC = bmc
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, warnings, nil
+}
+
+// tinkerbellBMC builds the BMC from the Machine and its auth Secret.
+func tinkerbellBMC(machines, secrets map[string]*tinkerbellObject, namespace, name string) (*metalv1alpha1.BMC, error) {
+	machine, ok := machines[namespace+"/"+name]
+	if !ok || machine.Spec.Connection == nil {
+		return nil, fmt.Errorf("BMC machine %q is missing, BMC is not imported", name)
+	}
+
+	connection := machine.Spec.Connection
+
+	secretNamespace := connection.AuthSecretRef.Namespace
+	if secretNamespace == "" {
+		secretNamespace = namespace
+	}
+
+	secret, ok := secrets[secretNamespace+"/"+connection.AuthSecretRef.Name]
+	if !ok {
+		return nil, fmt.Errorf("BMC secret %q is missing, BMC is not imported", connection.AuthSecretRef.Name)
+	}
+
+	return &metalv1alpha1.BMC{
+		Endpoint: connection.Host,
+		User:     string(secret.Data["username"]),
+		Pass:     string(secret.Data["password"]),
+	}, nil
+}
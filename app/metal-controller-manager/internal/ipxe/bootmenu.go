@@ -0,0 +1,118 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ipxe
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"text/template"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// bootMenuTemplate chains back to the iPXE endpoint with the chosen option:
+// "menu=skip" picks the environment as usual, "env=<name>" boots the specific environment.
+var bootMenuTemplate = template.Must(template.New("iPXE boot menu").Parse(`#!ipxe
+menu Sidero: {{ .Server }}
+item default Default
+item local Boot from local disk
+item --gap Environments:
+{{ range .Environments }}item env-{{ . }} {{ . }}
+{{ end }}choose --default default --timeout {{ .Timeout }} target || goto default
+goto ${target}
+
+:default
+chain ipxe?uuid=${uuid}&mac=${mac:hexhyp}&menu=skip
+
+:local
+exit
+{{ range .Environments }}
+:env-{{ . }}
+chain ipxe?uuid=${uuid}&mac=${mac:hexhyp}&env={{ . }}
+{{ end }}`))
+
+// bootMenuHandler serves the interactive boot menu (or the environment chosen in the menu) for the servers with the boot menu enabled.
+//
+// It returns false if the request should be handled as usual.
+func bootMenuHandler(w http.ResponseWriter, labels map[string]string) bool {
+	if labels["menu"] == "skip" {
+		return false
+	}
+
+	server, _, err := lookupServer(labels["uuid"])
+	if err != nil || server == nil || !server.Spec.BootMenu {
+		return false
+	}
+
+	if name := labels["env"]; name != "" {
+		bootMenuEnvironmentHandler(w, server, name)
+
+		return true
+	}
+
+	var envList metalv1alpha1.EnvironmentList
+
+	if err = c.List(context.Background(), &envList); err != nil {
+		log.Printf("Error listing environments: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return true
+	}
+
+	envs := make([]string, 0, len(envList.Items))
+
+	for _, env := range envList.Items {
+		envs = append(envs, env.Name)
+	}
+
+	sort.Strings(envs)
+
+	if err = bootMenuTemplate.Execute(w, struct {
+		Server       string
+		Environments []string
+		Timeout      int64
+	}{
+		Server:       server.Name,
+		Environments: envs,
+		Timeout:      bootMenuTimeout.Milliseconds(),
+	}); err != nil {
+		log.Printf("error rendering boot menu: %v", err)
+	}
+
+	return true
+}
+
+// bootMenuEnvironmentHandler boots the environment chosen in the boot menu.
+//
+// Server is not marked as PXE booted, as the environment wasn't picked by Sidero.
+func bootMenuEnvironmentHandler(w http.ResponseWriter, server *metalv1alpha1.Server, name string) {
+	env := &metalv1alpha1.Environment{}
+
+	if err := c.Get(context.Background(), client.ObjectKey{Name: name}, env); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Printf("Environment not found: %v", err)
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		log.Printf("%v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	log.Printf("Using %q environment for %q chosen in the boot menu", env.Name, server.Name)
+
+	if err := renderBootConfig(w, ipxeFormat, withProvisioningInterface(env, server), "", ""); err != nil {
+		log.Printf("error rendering boot config: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
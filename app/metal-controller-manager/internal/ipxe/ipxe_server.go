@@ -12,8 +12,10 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"text/template"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -27,6 +29,7 @@ import (
 
 	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
 	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/health"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/server"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
 )
@@ -37,12 +40,12 @@ var (
 )
 
 const bootFile = `#!ipxe
-chain ipxe?uuid=${uuid}&mac=${mac:hexhyp}&domain=${domain}&hostname=${hostname}&serial=${serial}
+chain ipxe?uuid=${uuid}&mac=${mac:hexhyp}&domain=${domain}&hostname=${hostname}&serial=${serial}&ip=${ip}&netmask=${netmask}&gateway=${gateway}
 `
 
 var ipxeTemplate = template.Must(template.New("iPXE config").Parse(`#!ipxe
-kernel /env/{{ .Env.Name }}/{{ .KernelAsset }} {{range $arg := .Env.Spec.Kernel.Args}} {{$arg}}{{end}}
-initrd /env/{{ .Env.Name }}/{{ .InitrdAsset }}
+kernel {{ .AssetsURL }}/{{ .Env.Name }}/{{ .KernelAsset }} {{range $arg := .Env.Spec.Kernel.Args}} {{$arg}}{{end}}
+initrd {{ .AssetsURL }}/{{ .Env.Name }}/{{ .InitrdAsset }}
 boot
 `))
 
@@ -50,28 +53,63 @@ const ipxeBootFromDisk = `#!ipxe
 exit
 `
 
+// UnknownServerPolicy defines how the servers without the Server resource are booted.
+type UnknownServerPolicy string
+
+const (
+	// UnknownServerRegister boots the agent, which registers the server.
+	UnknownServerRegister UnknownServerPolicy = "Register"
+	// UnknownServerChainload chainloads the foreign boot URL (iPXE only), e.g. the boot server of another provisioning system.
+	UnknownServerChainload UnknownServerPolicy = "Chainload"
+	// UnknownServerBootFromDisk makes the server boot from the local disk.
+	UnknownServerBootFromDisk UnknownServerPolicy = "BootFromDisk"
+	// UnknownServerIgnore responds with 404, so that the firmware proceeds with the next boot device.
+	UnknownServerIgnore UnknownServerPolicy = "Ignore"
+)
+
 var (
 	apiEndpoint          string
 	extraAgentKernelArgs string
+	agentAssetsURL       string
+	bootMenuTimeout      time.Duration
+	unknownServerPolicy  = UnknownServerRegister
+	unknownServerURL     string
+	strictMode           bool
 	c                    client.Client
+	attestationSecret    types.NamespacedName
 )
 
 func bootFileHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, bootFile)
 }
 
-//nolint: unparam
-func bootFromDiskHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprint(w, ipxeBootFromDisk)
+func ipxeHandler(w http.ResponseWriter, r *http.Request) {
+	labels := labelsFromRequest(r)
+
+	if bootMenuTimeout > 0 && bootMenuHandler(w, labels) {
+		return
+	}
+
+	bootConfigHandler(w, r, labels, ipxeFormat)
 }
 
-func ipxeHandler(w http.ResponseWriter, r *http.Request) {
+// bootConfigHandler renders the boot configuration of the server in the format of the network bootloader.
+func bootConfigHandler(w http.ResponseWriter, r *http.Request, labels map[string]string, format bootConfigFormat) {
 	var err error
 
-	labels := labelsFromRequest(r)
-
 	uuid := labels["uuid"]
 
+	// bootloaders which can't read SMBIOS UUID are identified by the MAC address
+	if uuid == "" && labels["mac"] != "" {
+		uuid, err = lookupServerUUID(labels["mac"])
+		if err != nil {
+			log.Printf("Error looking up server by MAC %q: %v", labels["mac"], err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+	}
+
 	server, serverBinding, err := lookupServer(uuid)
 	if err != nil {
 		log.Printf("Error looking up server: %v", err)
@@ -80,11 +118,47 @@ func ipxeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	env, err := newEnvironment(server, serverBinding)
+	if strictMode {
+		if reason := strictDenyReason(server, serverBinding); reason != "" {
+			log.Printf("DENIED: server %q (MAC %q, remote %q) is %s, not booting in the strict mode", uuid, labels["mac"], r.RemoteAddr, reason)
+			DeniedBoots.WithLabelValues(reason).Inc()
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+	}
+
+	if server == nil && unknownServerPolicy != UnknownServerRegister {
+		unknownServerHandler(w, uuid, labels["mac"], format)
+
+		return
+	}
+
+	if server != nil && labels["mac"] != "" && server.Status.PXEInterface != labels["mac"] {
+		if err = recordPXEInterface(server, labels["mac"]); err != nil {
+			log.Printf("error recording PXE interface of server %q: %s", uuid, err)
+		}
+	}
+
+	if server != nil && labels["ip"] != "" {
+		if err = recordProvisioningNetwork(server, labels["ip"], labels["netmask"], labels["gateway"]); err != nil {
+			log.Printf("error recording provisioning network of server %q: %s", uuid, err)
+		}
+	}
+
+	ip := clientIP(r, labels)
+
+	site, err := lookupSite(server, ip)
+	if err != nil {
+		// boot proceeds with the global endpoint and environment
+		log.Printf("error looking up site: %v", err)
+	}
+
+	env, err := newEnvironment(server, serverBinding, site)
 	if err != nil {
 		if errors.Is(err, ErrBootFromDisk) {
 			log.Printf("Server %q booting from disk", uuid)
-			bootFromDiskHandler(w, r)
+			fmt.Fprint(w, format.bootFromDisk)
 
 			return
 		}
@@ -109,61 +183,183 @@ func ipxeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if server != nil && env.Name != "agent" {
+		env = withProvisioningInterface(env, server)
+	}
+
+	if env.Name == "agent" && server != nil && attestationSecret.Name != "" {
+		if err = withAgentToken(env, server); err != nil {
+			// the agent still boots, its wipe attestations are recorded unsigned
+			log.Printf("error issuing agent token for %q: %v", uuid, err)
+		}
+	}
+
 	if server != nil {
 		log.Printf("Using %q environment for %q", env.Name, server.Name)
 	} else {
 		log.Printf("Using %q environment", env.Name)
 	}
 
+	var assetsURL string
+
+	if env.Name == "agent" {
+		assetsURL = agentAssets(site)
+
+		if assetsURL != "" {
+			log.Printf("Using agent assets from %q", assetsURL)
+		}
+	} else {
+		cache, err := lookupAssetCache(ip, env)
+		if err != nil {
+			// assets are served by Sidero itself
+			log.Printf("error looking up asset cache: %v", err)
+		}
+
+		if cache != nil {
+			log.Printf("Using asset cache %q for %q environment", cache.Name, env.Name)
+
+			assetsURL = cache.Spec.URL
+		}
+	}
+
+	if err = renderBootConfig(w, format, env, r.Host, assetsURL); err != nil {
+		log.Printf("error rendering boot config: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if env.ObjectMeta.Name != "agent" {
+		if err = markAsPXEBooted(server); err != nil {
+			log.Printf("error marking server as PXE booted: %s", err)
+		}
+	}
+}
+
+// unknownServerHandler boots the server without the Server resource according to the unknown server policy.
+func unknownServerHandler(w http.ResponseWriter, uuid, mac string, format bootConfigFormat) {
+	switch {
+	case unknownServerPolicy == UnknownServerBootFromDisk:
+		log.Printf("Unknown server %q (MAC %q) booting from disk", uuid, mac)
+		fmt.Fprint(w, format.bootFromDisk)
+	case unknownServerPolicy == UnknownServerChainload && format.chainload != "":
+		log.Printf("Unknown server %q (MAC %q) chainloading %q", uuid, mac, unknownServerURL)
+		fmt.Fprintf(w, format.chainload, unknownServerURL)
+	default:
+		log.Printf("Unknown server %q (MAC %q) ignored", uuid, mac)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// renderBootConfig renders the boot configuration of the environment.
+//
+// Assets are fetched from the assets URL (asset cache or external agent assets server), if set, and from Sidero otherwise.
+func renderBootConfig(w http.ResponseWriter, format bootConfigFormat, env *metalv1alpha1.Environment, host, assetsURL string) error {
 	args := struct {
 		Env         *metalv1alpha1.Environment
 		KernelAsset string
 		InitrdAsset string
+		Host        string
+		AssetsURL   string
+		AssetsHost  string
+		AssetsPath  string
 	}{
 		Env:         env,
 		KernelAsset: constants.KernelAsset,
 		InitrdAsset: constants.InitrdAsset,
+		Host:        host,
+		AssetsURL:   "/env",
+		AssetsHost:  host,
+		AssetsPath:  "/env",
 	}
 
-	var buf bytes.Buffer
-
-	err = ipxeTemplate.Execute(&buf, args)
-	if err != nil {
-		log.Printf("error rendering template: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
+	if assetsURL != "" {
+		u, err := url.Parse(assetsURL)
+		if err != nil {
+			return fmt.Errorf("error parsing assets URL: %w", err)
+		}
 
-		return
+		args.AssetsHost = u.Host
+		args.AssetsPath = strings.TrimSuffix(u.Path, "/")
+		args.AssetsURL = "http://" + args.AssetsHost + args.AssetsPath
 	}
 
-	if _, err := buf.WriteTo(w); err != nil {
-		log.Printf("error writing to response: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
+	var buf bytes.Buffer
 
-		return
+	if err := format.template.Execute(&buf, args); err != nil {
+		return err
 	}
 
-	if env.ObjectMeta.Name != "agent" {
-		if err = markAsPXEBooted(server); err != nil {
-			log.Printf("error marking server as PXE booted: %s", err)
-		}
-	}
+	_, err := buf.WriteTo(w)
+
+	return err
 }
 
-func ServeIPXE(endpoint, args string, mgrClient client.Client) error {
+func ServeIPXE(endpoint, args, agentAssets string, menuTimeout time.Duration, unknownPolicy UnknownServerPolicy, unknownURL string, strict bool, mgrClient client.Client,
+	attestation types.NamespacedName) error {
 	apiEndpoint = endpoint
 	extraAgentKernelArgs = args
+	agentAssetsURL = agentAssets
+	bootMenuTimeout = menuTimeout
+	unknownServerPolicy = unknownPolicy
+	unknownServerURL = unknownURL
+	strictMode = strict
 	c = mgrClient
+	attestationSecret = attestation
 
 	mux := http.NewServeMux()
 
 	mux.Handle("/boot.ipxe", logRequest(http.HandlerFunc(bootFileHandler)))
 	mux.Handle("/ipxe", logRequest(http.HandlerFunc(ipxeHandler)))
+	mux.Handle("/grub.cfg", logRequest(http.HandlerFunc(grubBootFileHandler)))
+	mux.Handle("/grub", logRequest(http.HandlerFunc(grubHandler)))
+	mux.Handle("/pxelinux.cfg/", logRequest(http.HandlerFunc(pxelinuxHandler)))
+	mux.Handle("/env/agent/"+constants.InitrdAsset, logRequest(http.HandlerFunc(agentInitrdHandler)))
 	mux.Handle("/env/", logRequest(http.StripPrefix("/env/", http.FileServer(http.Dir("/var/lib/sidero/env")))))
 	mux.Handle("/tftp/", logRequest(http.StripPrefix("/tftp/", http.FileServer(http.Dir("/var/lib/sidero/tftp")))))
+	mux.Handle("/healthz", localOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	lis, err := net.Listen("tcp", ":8081")
+	if err != nil {
+		return err
+	}
+
+	health.IPXE.Listening()
 
 	log.Println("Listening...")
 
-	return http.ListenAndServe(":8081", mux)
+	return http.Serve(lis, gated(mux))
+}
+
+// gated answers the boot requests only while the boot services are served by this installation.
+func gated(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" && !health.Serving.Open() {
+			http.Error(w, "boot services are served by the active Sidero installation", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// localOnly restricts the handler to the loopback clients.
+//
+// Boot port is exposed to the provisioning network, operational endpoints are served on their own listeners.
+func localOnly(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
 }
 
 func logRequest(next http.Handler) http.Handler {
@@ -206,6 +402,10 @@ func parseMAC(s string) (net.HardwareAddr, error) {
 }
 
 func lookupServer(uuid string) (*metalv1alpha1.Server, *infrav1.ServerBinding, error) {
+	if uuid == "" {
+		return nil, nil, nil
+	}
+
 	key := client.ObjectKey{
 		Name: uuid,
 	}
@@ -234,31 +434,50 @@ func lookupServer(uuid string) (*metalv1alpha1.Server, *infrav1.ServerBinding, e
 }
 
 // newEnvironment handles which env CRD we'll respect for a given server.
-// specied in the server spec overrides everything, specified in the server class overrides default, default is default :).
-func newEnvironment(server *metalv1alpha1.Server, serverBinding *infrav1.ServerBinding) (env *metalv1alpha1.Environment, err error) {
+// specied in the server spec overrides everything, specified in the server class overrides the site one,
+// specified in the site overrides default, default is default :).
+func newEnvironment(server *metalv1alpha1.Server, serverBinding *infrav1.ServerBinding, site *metalv1alpha1.Site) (env *metalv1alpha1.Environment, err error) {
+	diskless, err := isDiskless(serverBinding)
+	if err != nil {
+		return nil, err
+	}
+
 	// NB: The order of this switch statement is important. It defines the
 	// precedence of which environment to boot.
 	switch {
 	case server == nil:
-		return newAgentEnvironment(), nil
+		return newAgentEnvironment(site), nil
 	case serverBinding == nil && !server.Status.IsClean:
-		return newAgentEnvironment(), nil
+		return newAgentEnvironment(site), nil
 	case serverBinding == nil:
 		return nil, ErrNotInUse
-	case conditions.Has(server, metalv1alpha1.ConditionPXEBooted) && !server.Spec.PXEBootAlways:
-		return nil, ErrBootFromDisk
-	case server.Spec.EnvironmentRef != nil:
-		env, err = newEnvironmentFromServer(server)
+	case diskless:
+		// diskless servers have nothing to boot from, including the reboots of the Talos upgrade
+		env, err = newAllocatedEnvironment(server, serverBinding)
 		if err != nil {
 			return nil, err
 		}
-	case serverBinding.Spec.ServerClassRef != nil:
-		env, err = newEnvironmentFromServerClass(serverBinding)
+	case isUpgrading(serverBinding):
+		// server is rebooted by the Talos upgrade, booting from the network would revert (or reinstall) Talos
+		return nil, ErrBootFromDisk
+	case (conditions.Has(server, metalv1alpha1.ConditionPXEBooted) || conditions.IsTrue(server, metalv1alpha1.ConditionAdopted)) && !server.Spec.PXEBootAlways:
+		// adopted servers were installed by other means, and they never PXE booted into the cluster environment
+		return nil, ErrBootFromDisk
+	default:
+		env, err = newAllocatedEnvironment(server, serverBinding)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if env == nil && site != nil && site.Spec.EnvironmentRef != nil {
+		env = &metalv1alpha1.Environment{}
+
+		if err = c.Get(context.Background(), types.NamespacedName{Namespace: "", Name: site.Spec.EnvironmentRef.Name}, env); err != nil {
+			return nil, err
+		}
+	}
+
 	if env == nil {
 		env, err = newDefaultEnvironment()
 		if err != nil {
@@ -273,7 +492,48 @@ func newEnvironment(server *metalv1alpha1.Server, serverBinding *infrav1.ServerB
 	return env, nil
 }
 
-func newAgentEnvironment() *metalv1alpha1.Environment {
+// newAllocatedEnvironment returns the environment set for the allocated server, if any.
+func newAllocatedEnvironment(server *metalv1alpha1.Server, serverBinding *infrav1.ServerBinding) (*metalv1alpha1.Environment, error) {
+	switch {
+	case server.Spec.EnvironmentRef != nil:
+		return newEnvironmentFromServer(server)
+	case serverBinding.Spec.ServerClassRef != nil:
+		return newEnvironmentFromServerClass(server, serverBinding)
+	default:
+		return nil, nil
+	}
+}
+
+// isDiskless checks whether the server is allocated from the diskless server class.
+func isDiskless(serverBinding *infrav1.ServerBinding) (bool, error) {
+	if serverBinding == nil || serverBinding.Spec.ServerClassRef == nil {
+		return false, nil
+	}
+
+	serverClass := &metalv1alpha1.ServerClass{}
+
+	if err := c.Get(context.Background(), types.NamespacedName{Name: serverBinding.Spec.ServerClassRef.Name}, serverClass); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	return serverClass.Spec.Diskless, nil
+}
+
+// isUpgrading checks whether the machine the server is bound to is being upgraded.
+func isUpgrading(serverBinding *infrav1.ServerBinding) bool {
+	_, upgrading := serverBinding.Annotations[infrav1.UpgradeInProgressAnnotation]
+
+	return upgrading
+}
+
+// newAgentEnvironment builds the environment of the agent, the endpoint of the site is advertised to the agent, if set.
+func newAgentEnvironment(site *metalv1alpha1.Site) *metalv1alpha1.Environment {
+	endpoint := apiEndpoint
+
+	if site != nil && site.Spec.APIEndpoint != "" {
+		endpoint = site.Spec.APIEndpoint
+	}
+
 	args := []string{
 		"initrd=initramfs.xz",
 		"page_poison=1",
@@ -289,7 +549,7 @@ func newAgentEnvironment() *metalv1alpha1.Environment {
 		"console=tty0",
 		"console=ttyS0",
 		"printk.devkmsg=on",
-		fmt.Sprintf("%s=%s:%s", constants.AgentEndpointArg, apiEndpoint, server.Port),
+		fmt.Sprintf("%s=%s:%s", constants.AgentEndpointArg, endpoint, server.Port),
 	}
 
 	cmdline := procfs.NewCmdline(strings.Join(args, " "))
@@ -314,6 +574,117 @@ func newAgentEnvironment() *metalv1alpha1.Environment {
 	return env
 }
 
+// withAgentToken issues the one-time token the agent authenticates the wipe report with, and passes it to the agent environment.
+//
+// Each boot of the agent replaces the token issued previously, so the token leaked from the earlier boot can't be replayed.
+func withAgentToken(env *metalv1alpha1.Environment, s *metalv1alpha1.Server) error {
+	token, hash, err := server.NewAgentToken()
+	if err != nil {
+		return err
+	}
+
+	patchHelper, err := patch.NewHelper(s, c)
+	if err != nil {
+		return err
+	}
+
+	s.Status.AgentTokenHash = hash
+
+	if err = patchHelper.Patch(context.Background(), s); err != nil {
+		return err
+	}
+
+	env.Spec.Kernel.Args = append(env.Spec.Kernel.Args, fmt.Sprintf("%s=%s", constants.AgentTokenArg, token))
+
+	return nil
+}
+
+// agentAssets returns the URL of the external server hosting the agent assets, the URL of the site takes precedence.
+//
+// Empty URL means the agent assets are served by Sidero.
+func agentAssets(site *metalv1alpha1.Site) string {
+	if site != nil && site.Spec.AgentAssetsURL != "" {
+		return site.Spec.AgentAssetsURL
+	}
+
+	return agentAssetsURL
+}
+
+// clientIP returns the IP address of the booting server, as reported by iPXE or as seen by the server.
+func clientIP(r *http.Request, labels map[string]string) net.IP {
+	if ip := net.ParseIP(labels["ip"]); ip != nil {
+		return ip
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return nil
+	}
+
+	return net.ParseIP(host)
+}
+
+// lookupSite finds the site of the server by the site label, or by the IP address of the server
+// if the server is not registered yet or is not labeled.
+func lookupSite(server *metalv1alpha1.Server, ip net.IP) (*metalv1alpha1.Site, error) {
+	ctx := context.Background()
+
+	if server != nil && server.Labels[metalv1alpha1.SiteLabel] != "" {
+		site := &metalv1alpha1.Site{}
+
+		err := c.Get(ctx, types.NamespacedName{Name: server.Labels[metalv1alpha1.SiteLabel]}, site)
+		if err == nil {
+			return site, nil
+		}
+
+		// server might be labeled before the site is created
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	if ip == nil {
+		return nil, nil
+	}
+
+	var sites metalv1alpha1.SiteList
+
+	if err := c.List(ctx, &sites); err != nil {
+		return nil, err
+	}
+
+	for i := range sites.Items {
+		if sites.Items[i].Contains(ip) {
+			return &sites.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// lookupAssetCache finds the asset cache of the server subnet holding the current assets of the environment.
+//
+// Agent environment is not cached, as its initramfs is assembled on the fly.
+func lookupAssetCache(ip net.IP, env *metalv1alpha1.Environment) (*metalv1alpha1.AssetCache, error) {
+	if ip == nil || env.Name == "agent" {
+		return nil, nil
+	}
+
+	var caches metalv1alpha1.AssetCacheList
+
+	if err := c.List(context.Background(), &caches); err != nil {
+		return nil, err
+	}
+
+	for i := range caches.Items {
+		if caches.Items[i].Contains(ip) && caches.Items[i].Caches(env) {
+			return &caches.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
 func newDefaultEnvironment() (env *metalv1alpha1.Environment, err error) {
 	env = &metalv1alpha1.Environment{}
 
@@ -334,17 +705,17 @@ func newEnvironmentFromServer(server *metalv1alpha1.Server) (env *metalv1alpha1.
 	return env, nil
 }
 
-func newEnvironmentFromServerClass(serverBinding *infrav1.ServerBinding) (env *metalv1alpha1.Environment, err error) {
+func newEnvironmentFromServerClass(server *metalv1alpha1.Server, serverBinding *infrav1.ServerBinding) (env *metalv1alpha1.Environment, err error) {
 	serverClassResource := &metalv1alpha1.ServerClass{}
 
 	if err := c.Get(context.Background(), types.NamespacedName{Namespace: serverBinding.Spec.ServerClassRef.Namespace, Name: serverBinding.Spec.ServerClassRef.Name}, serverClassResource); err != nil {
 		return nil, err
 	}
 
-	if serverClassResource.Spec.EnvironmentRef != nil {
+	if envRef := serverClassResource.EnvironmentFor(server.Name); envRef != nil {
 		env = &metalv1alpha1.Environment{}
 
-		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "", Name: serverClassResource.Spec.EnvironmentRef.Name}, env); err != nil {
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "", Name: envRef.Name}, env); err != nil {
 			return nil, err
 		}
 	}
@@ -352,6 +723,63 @@ func newEnvironmentFromServerClass(serverBinding *infrav1.ServerBinding) (env *m
 	return env, nil
 }
 
+// withProvisioningInterface configures the provisioning interface of the server via kernel args.
+//
+// Explicit network configuration in the environment kernel args is preserved.
+func withProvisioningInterface(env *metalv1alpha1.Environment, server *metalv1alpha1.Server) *metalv1alpha1.Environment {
+	name := server.ProvisioningInterfaceName()
+	if name == "" {
+		return env
+	}
+
+	for _, arg := range env.Spec.Kernel.Args {
+		if strings.HasPrefix(arg, "ip=") {
+			return env
+		}
+	}
+
+	env = env.DeepCopy()
+	env.Spec.Kernel.Args = append(env.Spec.Kernel.Args, fmt.Sprintf("ip=:::::%s:dhcp", name))
+
+	return env
+}
+
+func recordPXEInterface(server *metalv1alpha1.Server, mac string) error {
+	patchHelper, err := patch.NewHelper(server, c)
+	if err != nil {
+		return err
+	}
+
+	server.Status.PXEInterface = mac
+
+	return patchHelper.Patch(context.Background(), server)
+}
+
+// recordProvisioningNetwork labels the server with the provisioning network it got the DHCP lease from.
+func recordProvisioningNetwork(s *metalv1alpha1.Server, ip, netmask, gateway string) error {
+	mask := net.ParseIP(netmask).To4()
+	if mask == nil {
+		return nil
+	}
+
+	ones, bits := net.IPMask(mask).Size()
+	if bits == 0 {
+		// non-canonical netmask
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(s, c)
+	if err != nil {
+		return err
+	}
+
+	if !server.UpdateProvisioningNetworkLabels(s, fmt.Sprintf("%s/%d", ip, ones), gateway) {
+		return nil
+	}
+
+	return patchHelper.Patch(context.Background(), s)
+}
+
 func markAsPXEBooted(server *metalv1alpha1.Server) error {
 	patchHelper, err := patch.NewHelper(server, c)
 	if err != nil {
@@ -0,0 +1,811 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package ipxe
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+const testUUID = "4c4c4544-0035-3010-8030-c4c04f4a4e32"
+
+func testServer(pxeBooted, pxeBootAlways bool) *metalv1alpha1.Server {
+	server := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testUUID,
+		},
+		Spec: metalv1alpha1.ServerSpec{
+			Accepted:      true,
+			PXEBootAlways: pxeBootAlways,
+		},
+		Status: metalv1alpha1.ServerStatus{
+			InUse: true,
+		},
+	}
+
+	if pxeBooted {
+		server.Status.Conditions = clusterv1.Conditions{
+			{
+				Type:   metalv1alpha1.ConditionPXEBooted,
+				Status: corev1.ConditionTrue,
+			},
+		}
+	}
+
+	return server
+}
+
+func testAdoptedServer(pxeBootAlways bool) *metalv1alpha1.Server {
+	server := testServer(false, pxeBootAlways)
+	server.Status.Conditions = clusterv1.Conditions{
+		{
+			Type:   metalv1alpha1.ConditionAdopted,
+			Status: corev1.ConditionTrue,
+		},
+	}
+
+	return server
+}
+
+func testServerBinding(upgrading bool) *infrav1.ServerBinding {
+	serverBinding := &infrav1.ServerBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testUUID,
+		},
+	}
+
+	if upgrading {
+		serverBinding.Annotations = map[string]string{
+			infrav1.UpgradeInProgressAnnotation: "v0.8.0",
+		}
+	}
+
+	return serverBinding
+}
+
+func testDisklessServerBinding(upgrading bool) *infrav1.ServerBinding {
+	serverBinding := testServerBinding(upgrading)
+	serverBinding.Spec.ServerClassRef = &corev1.ObjectReference{
+		Name: "diskless",
+	}
+
+	return serverBinding
+}
+
+func Test_ipxeHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		server        *metalv1alpha1.Server
+		serverBinding *infrav1.ServerBinding
+		wantDisk      bool
+	}{
+		{
+			name:          "first boot is provisioned",
+			server:        testServer(false, false),
+			serverBinding: testServerBinding(false),
+			wantDisk:      false,
+		},
+		{
+			name:          "reboot boots from disk",
+			server:        testServer(true, false),
+			serverBinding: testServerBinding(false),
+			wantDisk:      true,
+		},
+		{
+			name:          "pxe boot always is provisioned",
+			server:        testServer(true, true),
+			serverBinding: testServerBinding(false),
+			wantDisk:      false,
+		},
+		{
+			name:          "reboot during upgrade boots from disk",
+			server:        testServer(true, false),
+			serverBinding: testServerBinding(true),
+			wantDisk:      true,
+		},
+		{
+			name:          "reboot during upgrade with pxe boot always boots from disk",
+			server:        testServer(true, true),
+			serverBinding: testServerBinding(true),
+			wantDisk:      true,
+		},
+		{
+			name:          "reboot during upgrade without pxe booted condition boots from disk",
+			server:        testServer(false, false),
+			serverBinding: testServerBinding(true),
+			wantDisk:      true,
+		},
+		{
+			name:          "adopted server boots from disk",
+			server:        testAdoptedServer(false),
+			serverBinding: testServerBinding(false),
+			wantDisk:      true,
+		},
+		{
+			name:          "adopted server with pxe boot always is provisioned",
+			server:        testAdoptedServer(true),
+			serverBinding: testServerBinding(false),
+			wantDisk:      false,
+		},
+		{
+			name:          "diskless reboot is provisioned",
+			server:        testServer(true, false),
+			serverBinding: testDisklessServerBinding(false),
+			wantDisk:      false,
+		},
+		{
+			name:          "diskless reboot during upgrade is provisioned",
+			server:        testServer(true, false),
+			serverBinding: testDisklessServerBinding(true),
+			wantDisk:      false,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &metalv1alpha1.Environment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "default",
+				},
+			}
+
+			diskless := &metalv1alpha1.ServerClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "diskless",
+				},
+				Spec: metalv1alpha1.ServerClassSpec{
+					Diskless: true,
+				},
+			}
+
+			c = fake.NewFakeClientWithScheme(scheme, tt.server, tt.serverBinding, env, diskless)
+
+			req := httptest.NewRequest(http.MethodGet, "/ipxe?uuid="+testUUID, nil)
+			w := httptest.NewRecorder()
+
+			ipxeHandler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status code %d", w.Code)
+			}
+
+			body := w.Body.String()
+
+			if gotDisk := body == ipxeBootFromDisk; gotDisk != tt.wantDisk {
+				t.Errorf("boot from disk = %v, want %v, script:\n%s", gotDisk, tt.wantDisk, body)
+			}
+
+			if !tt.wantDisk && !strings.Contains(body, "/env/default/") {
+				t.Errorf("unexpected environment, script:\n%s", body)
+			}
+		})
+	}
+}
+
+func Test_siteEndpoint(t *testing.T) {
+	site := &metalv1alpha1.Site{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "site-a",
+		},
+		Spec: metalv1alpha1.SiteSpec{
+			Subnets:     []string{"10.5.0.0/16"},
+			APIEndpoint: "10.5.0.10",
+		},
+	}
+
+	labeledServer := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   testUUID,
+			Labels: map[string]string{metalv1alpha1.SiteLabel: "site-a"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		server       *metalv1alpha1.Server
+		ip           string
+		wantEndpoint string
+	}{
+		{
+			name:         "new server at the site",
+			ip:           "10.5.1.2",
+			wantEndpoint: "10.5.0.10",
+		},
+		{
+			name:         "new server outside of the sites",
+			ip:           "10.6.1.2",
+			wantEndpoint: "sidero.example.com",
+		},
+		{
+			name:         "labeled server",
+			server:       labeledServer,
+			ip:           "10.6.1.2",
+			wantEndpoint: "10.5.0.10",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	apiEndpoint = "sidero.example.com"
+
+	defer func() { apiEndpoint = "" }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []runtime.Object{site}
+
+			if tt.server != nil {
+				objs = append(objs, tt.server.DeepCopy())
+			}
+
+			c = fake.NewFakeClientWithScheme(scheme, objs...)
+
+			req := httptest.NewRequest(http.MethodGet, "/ipxe?uuid="+testUUID+"&ip="+tt.ip, nil)
+			w := httptest.NewRecorder()
+
+			ipxeHandler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status code %d", w.Code)
+			}
+
+			if want := "sidero.endpoint=" + tt.wantEndpoint + ":"; !strings.Contains(w.Body.String(), want) {
+				t.Errorf("%q not found in the script:\n%s", want, w.Body.String())
+			}
+		})
+	}
+}
+
+func Test_agentAssets(t *testing.T) {
+	site := &metalv1alpha1.Site{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "site-a",
+		},
+		Spec: metalv1alpha1.SiteSpec{
+			Subnets:        []string{"10.5.0.0/16"},
+			AgentAssetsURL: "http://10.5.0.20/approved/",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		global   string
+		ip       string
+		wantURLs []string
+	}{
+		{
+			name:     "built-in assets",
+			ip:       "10.6.1.2",
+			wantURLs: []string{"kernel /env/agent/vmlinuz", "initrd /env/agent/initramfs.xz"},
+		},
+		{
+			name:     "global assets server",
+			global:   "http://artifacts.example.com/sidero",
+			ip:       "10.6.1.2",
+			wantURLs: []string{"kernel http://artifacts.example.com/sidero/agent/vmlinuz", "initrd http://artifacts.example.com/sidero/agent/initramfs.xz"},
+		},
+		{
+			name:     "site assets server",
+			global:   "http://artifacts.example.com/sidero",
+			ip:       "10.5.1.2",
+			wantURLs: []string{"kernel http://10.5.0.20/approved/agent/vmlinuz", "initrd http://10.5.0.20/approved/agent/initramfs.xz"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			agentAssetsURL = tt.global
+
+			defer func() { agentAssetsURL = "" }()
+
+			c = fake.NewFakeClientWithScheme(scheme, site)
+
+			req := httptest.NewRequest(http.MethodGet, "/ipxe?uuid="+testUUID+"&ip="+tt.ip, nil)
+			w := httptest.NewRecorder()
+
+			ipxeHandler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status code %d", w.Code)
+			}
+
+			for _, want := range tt.wantURLs {
+				if !strings.Contains(w.Body.String(), want) {
+					t.Errorf("%q not found in the script:\n%s", want, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func Test_withProvisioningInterface(t *testing.T) {
+	interfaces := []metalv1alpha1.NetworkInterface{
+		{Name: "eth0", MAC: "52:54:00:ab:cd:01"},
+		{Name: "eth1", MAC: "52:54:00:ab:cd:02"},
+	}
+
+	tests := []struct {
+		name                  string
+		args                  []string
+		provisioningInterface string
+		pxeInterface          string
+		want                  []string
+	}{
+		{
+			name: "unknown interface",
+			args: []string{"console=tty0"},
+			want: []string{"console=tty0"},
+		},
+		{
+			name:         "pxe interface",
+			args:         []string{"console=tty0"},
+			pxeInterface: "52:54:00:ab:cd:01",
+			want:         []string{"console=tty0", "ip=:::::eth0:dhcp"},
+		},
+		{
+			name:                  "explicit interface overrides pxe interface",
+			args:                  []string{"console=tty0"},
+			provisioningInterface: "52:54:00:AB:CD:02",
+			pxeInterface:          "52:54:00:ab:cd:01",
+			want:                  []string{"console=tty0", "ip=:::::eth1:dhcp"},
+		},
+		{
+			name:                  "interface not discovered by the agent",
+			args:                  []string{"console=tty0"},
+			provisioningInterface: "52:54:00:ab:cd:03",
+			want:                  []string{"console=tty0"},
+		},
+		{
+			name:         "explicit network configuration is preserved",
+			args:         []string{"ip=dhcp"},
+			pxeInterface: "52:54:00:ab:cd:01",
+			want:         []string{"ip=dhcp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &metalv1alpha1.Environment{
+				Spec: metalv1alpha1.EnvironmentSpec{
+					Kernel: metalv1alpha1.Kernel{
+						Args: tt.args,
+					},
+				},
+			}
+
+			server := &metalv1alpha1.Server{
+				Spec: metalv1alpha1.ServerSpec{
+					ProvisioningInterface: tt.provisioningInterface,
+				},
+				Status: metalv1alpha1.ServerStatus{
+					NetworkInterfaces: interfaces,
+					PXEInterface:      tt.pxeInterface,
+				},
+			}
+
+			got := withProvisioningInterface(env, server).Spec.Kernel.Args
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withProvisioningInterface() = %v, want %v", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(env.Spec.Kernel.Args, tt.args) {
+				t.Errorf("original environment was modified: %v", env.Spec.Kernel.Args)
+			}
+		})
+	}
+}
+
+func Test_unknownServerHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		unknownServerPolicy = UnknownServerRegister
+		unknownServerURL = ""
+	}()
+
+	for _, tt := range []struct {
+		name       string
+		policy     UnknownServerPolicy
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "register",
+			policy:     UnknownServerRegister,
+			path:       "/ipxe?uuid=" + testUUID,
+			wantStatus: http.StatusOK,
+			wantBody:   "/env/agent/",
+		},
+		{
+			name:       "chainload",
+			policy:     UnknownServerChainload,
+			path:       "/ipxe?uuid=" + testUUID,
+			wantStatus: http.StatusOK,
+			wantBody:   "chain --autofree http://10.5.0.1/boot.ipxe\n",
+		},
+		{
+			name:       "chainload unsupported",
+			policy:     UnknownServerChainload,
+			path:       "/grub?mac=52:54:00:12:34:56",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "boot from disk",
+			policy:     UnknownServerBootFromDisk,
+			path:       "/ipxe?uuid=" + testUUID,
+			wantStatus: http.StatusOK,
+			wantBody:   ipxeBootFromDisk,
+		},
+		{
+			name:       "ignore",
+			policy:     UnknownServerIgnore,
+			path:       "/ipxe?uuid=" + testUUID,
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			unknownServerPolicy = tt.policy
+			unknownServerURL = "http://10.5.0.1/boot.ipxe"
+
+			c = fake.NewFakeClientWithScheme(scheme)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			if strings.HasPrefix(tt.path, "/grub") {
+				grubHandler(w, req)
+			} else {
+				ipxeHandler(w, req)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("unexpected status code %d", w.Code)
+			}
+
+			if body := w.Body.String(); !strings.Contains(body, tt.wantBody) {
+				t.Errorf("unexpected script:\n%s", body)
+			}
+		})
+	}
+}
+
+func Test_strictMode(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	strictMode = true
+
+	defer func() {
+		strictMode = false
+	}()
+
+	notAccepted := testServer(false, false)
+	notAccepted.Spec.Accepted = false
+
+	clean := testServer(false, false)
+	clean.Status.IsClean = true
+
+	dirty := testServer(false, false)
+
+	for _, tt := range []struct {
+		name       string
+		objects    []runtime.Object
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "unknown",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "not accepted",
+			objects:    []runtime.Object{notAccepted, testServerBinding(false)},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "not allocated",
+			objects:    []runtime.Object{clean},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "wipe",
+			objects:    []runtime.Object{dirty},
+			wantStatus: http.StatusOK,
+			wantBody:   "/env/agent/",
+		},
+		{
+			name:       "allocated",
+			objects:    []runtime.Object{testServer(false, false), testServerBinding(false)},
+			wantStatus: http.StatusOK,
+			wantBody:   "/env/default/",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &metalv1alpha1.Environment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "default",
+				},
+			}
+
+			c = fake.NewFakeClientWithScheme(scheme, append(tt.objects, env)...)
+
+			req := httptest.NewRequest(http.MethodGet, "/ipxe?uuid="+testUUID, nil)
+			w := httptest.NewRecorder()
+
+			ipxeHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("unexpected status code %d", w.Code)
+			}
+
+			if body := w.Body.String(); !strings.Contains(body, tt.wantBody) {
+				t.Errorf("unexpected script:\n%s", body)
+			}
+		})
+	}
+}
+
+func Test_grubHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	env := &metalv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "default",
+		},
+		Spec: metalv1alpha1.EnvironmentSpec{
+			Kernel: metalv1alpha1.Kernel{
+				Args: []string{"console=hvc0"},
+			},
+		},
+	}
+
+	c = fake.NewFakeClientWithScheme(scheme, testServer(false, false), testServerBinding(false), env)
+
+	req := httptest.NewRequest(http.MethodGet, "http://sidero:8081/grub?uuid="+testUUID, nil)
+	w := httptest.NewRecorder()
+
+	grubHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d", w.Code)
+	}
+
+	if body := w.Body.String(); !strings.Contains(body, "linux (http,sidero:8081)/env/default/vmlinuz console=hvc0\n") {
+		t.Errorf("unexpected config:\n%s", body)
+	}
+
+	// server is marked as PXE booted, so it boots from disk next time
+	w = httptest.NewRecorder()
+
+	grubHandler(w, req)
+
+	if body := w.Body.String(); body != grubFormat.bootFromDisk {
+		t.Errorf("expected boot from disk, config:\n%s", body)
+	}
+}
+
+func Test_bootMenuHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	server := testServer(true, false)
+	server.Spec.BootMenu = true
+
+	env := &metalv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "default",
+		},
+	}
+
+	c = fake.NewFakeClientWithScheme(scheme, server, testServerBinding(false), env)
+
+	bootMenuTimeout = 10 * time.Second
+	defer func() { bootMenuTimeout = 0 }()
+
+	for _, tt := range []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "menu",
+			query: "",
+			want:  "item env-default default\n",
+		},
+		{
+			name:  "menu timeout",
+			query: "",
+			want:  "choose --default default --timeout 10000 target",
+		},
+		{
+			name:  "chosen environment",
+			query: "&env=default",
+			want:  "kernel /env/default/vmlinuz",
+		},
+		{
+			name:  "default",
+			query: "&menu=skip",
+			want:  ipxeBootFromDisk,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ipxe?uuid="+testUUID+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			ipxeHandler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status code %d", w.Code)
+			}
+
+			if body := w.Body.String(); !strings.Contains(body, tt.want) {
+				t.Errorf("expected %q in script:\n%s", tt.want, body)
+			}
+		})
+	}
+}
+
+func Test_assetCache(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	env := &metalv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "default",
+		},
+		Spec: metalv1alpha1.EnvironmentSpec{
+			Kernel: metalv1alpha1.Kernel{Asset: metalv1alpha1.Asset{URL: "http://example.com/v2/vmlinuz"}},
+			Initrd: metalv1alpha1.Initrd{Asset: metalv1alpha1.Asset{URL: "http://example.com/v2/initramfs.xz"}},
+		},
+	}
+
+	current := &metalv1alpha1.AssetCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rack-1",
+		},
+		Spec: metalv1alpha1.AssetCacheSpec{
+			URL:     "http://10.5.1.2:8080/sidero/",
+			Subnets: []string{"10.5.1.0/24"},
+		},
+		Status: metalv1alpha1.AssetCacheStatus{
+			Environments: []metalv1alpha1.CachedEnvironment{
+				{Name: "default", Kernel: "http://example.com/v2/vmlinuz", Initrd: "http://example.com/v2/initramfs.xz"},
+			},
+		},
+	}
+
+	stale := &metalv1alpha1.AssetCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rack-2",
+		},
+		Spec: metalv1alpha1.AssetCacheSpec{
+			URL:     "http://10.5.2.2:8080",
+			Subnets: []string{"10.5.2.0/24"},
+		},
+		Status: metalv1alpha1.AssetCacheStatus{
+			Environments: []metalv1alpha1.CachedEnvironment{
+				{Name: "default", Kernel: "http://example.com/v1/vmlinuz", Initrd: "http://example.com/v1/initramfs.xz"},
+			},
+		},
+	}
+
+	c = fake.NewFakeClientWithScheme(scheme, testServer(false, true), testServerBinding(false), env, current, stale)
+
+	for _, tt := range []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{
+			name: "cached",
+			ip:   "10.5.1.10",
+			want: "kernel http://10.5.1.2:8080/sidero/default/vmlinuz",
+		},
+		{
+			name: "stale cache",
+			ip:   "10.5.2.10",
+			want: "kernel /env/default/vmlinuz",
+		},
+		{
+			name: "no cache",
+			ip:   "10.5.3.10",
+			want: "kernel /env/default/vmlinuz",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ipxe?uuid="+testUUID+"&ip="+tt.ip, nil)
+			w := httptest.NewRecorder()
+
+			ipxeHandler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status code %d", w.Code)
+			}
+
+			if body := w.Body.String(); !strings.Contains(body, tt.want) {
+				t.Errorf("expected %q in script:\n%s", tt.want, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ipxe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// bootConfigFormat describes the boot configuration syntax of the network bootloader.
+type bootConfigFormat struct {
+	template     *template.Template
+	bootFromDisk string
+	// chainload is the format of the config chainloading the URL, empty if the bootloader can't chainload.
+	chainload string
+}
+
+var ipxeFormat = bootConfigFormat{
+	template:     ipxeTemplate,
+	bootFromDisk: ipxeBootFromDisk,
+	chainload: `#!ipxe
+chain --autofree %s
+`,
+}
+
+// GRUB (grub-ieee1275 on POWER/OpenFirmware machines) loads grub.cfg from the TFTP prefix,
+// which should be set to chain the config from Sidero: "configfile (http,<sidero>:8081)/grub.cfg".
+const grubBootFile = `set timeout=0
+configfile (http,{{ .Host }})/grub?mac=${net_default_mac}
+`
+
+var grubBootFileTemplate = template.Must(template.New("GRUB boot file").Parse(grubBootFile))
+
+var grubFormat = bootConfigFormat{
+	template: template.Must(template.New("GRUB config").Parse(`set timeout=0
+set default=0
+
+menuentry "{{ .Env.Name }}" {
+	linux (http,{{ .AssetsHost }}){{ .AssetsPath }}/{{ .Env.Name }}/{{ .KernelAsset }}{{range $arg := .Env.Spec.Kernel.Args}} {{$arg}}{{end}}
+	initrd (http,{{ .AssetsHost }}){{ .AssetsPath }}/{{ .Env.Name }}/{{ .InitrdAsset }}
+}
+`)),
+	// returning to the firmware makes it proceed with the next boot device
+	bootFromDisk: `exit
+`,
+}
+
+// Petitboot (OpenPOWER firmware bootloader) fetches pxelinux-style configs from the path prefix
+// set with the DHCP option 210, trying the "01-<mac>" file among others.
+var petitbootFormat = bootConfigFormat{
+	template: template.Must(template.New("pxelinux config").Parse(`DEFAULT {{ .Env.Name }}
+
+LABEL {{ .Env.Name }}
+	KERNEL http://{{ .AssetsHost }}{{ .AssetsPath }}/{{ .Env.Name }}/{{ .KernelAsset }}
+	INITRD http://{{ .AssetsHost }}{{ .AssetsPath }}/{{ .Env.Name }}/{{ .InitrdAsset }}
+	APPEND{{range $arg := .Env.Spec.Kernel.Args}} {{$arg}}{{end}}
+`)),
+	// petitboot boots the default disk entry if network config has no entries
+	bootFromDisk: `# boot from disk
+`,
+}
+
+func grubBootFileHandler(w http.ResponseWriter, r *http.Request) {
+	if err := grubBootFileTemplate.Execute(w, struct{ Host string }{r.Host}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func grubHandler(w http.ResponseWriter, r *http.Request) {
+	bootConfigHandler(w, r, labelsFromRequest(r), grubFormat)
+}
+
+// pxelinuxHandler serves pxelinux.cfg/01-<mac> files.
+//
+// Other files looked up by the bootloader (UUID, IP address, default) are not found, so that it falls back to the MAC-based one.
+func pxelinuxHandler(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+
+	if !strings.HasPrefix(name, "01-") {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	mac, err := parseMAC(strings.TrimPrefix(name, "01-"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	bootConfigHandler(w, r, map[string]string{"mac": mac.String()}, petitbootFormat)
+}
+
+// lookupServerUUID finds the server by the MAC address of one of its network interfaces.
+//
+// Empty UUID is returned if the server is not registered yet.
+func lookupServerUUID(mac string) (string, error) {
+	var serverList metalv1alpha1.ServerList
+
+	if err := c.List(context.Background(), &serverList, client.MatchingFields{metalv1alpha1.ServerMACAddressField: mac}); err != nil {
+		return "", err
+	}
+
+	switch len(serverList.Items) {
+	case 0:
+		return "", nil
+	case 1:
+		return serverList.Items[0].Name, nil
+	default:
+		return "", fmt.Errorf("MAC address %q belongs to %d servers", mac, len(serverList.Items))
+	}
+}
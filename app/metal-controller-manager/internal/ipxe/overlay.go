@@ -0,0 +1,117 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ipxe
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
+)
+
+var (
+	agentInitrdPath        = filepath.Join(constants.DataDirectory, "env", "agent", constants.InitrdAsset)
+	agentOverlaysDirectory = constants.AgentOverlaysDirectory
+)
+
+// cpioAlignment is the alignment of the uncompressed cpio archives in the initramfs.
+const cpioAlignment = 4
+
+type initrdPart struct {
+	path string
+	size int64
+}
+
+// padding returns the number of zero bytes appended after the part, the kernel skips them between the archives.
+func (part initrdPart) padding() int64 {
+	return (cpioAlignment - part.size%cpioAlignment) % cpioAlignment
+}
+
+// agentInitrdHandler serves the agent initramfs with the overlays appended.
+//
+// Kernel unpacks the concatenated cpio archives (compressed or not) one after another, so that the files
+// of the overlays (kernel modules, firmware) are added to the stock agent initramfs without rebuilding it.
+func agentInitrdHandler(w http.ResponseWriter, r *http.Request) {
+	parts, err := agentInitrdParts()
+	if err != nil {
+		log.Printf("error listing agent initramfs overlays: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	var size int64
+
+	for _, part := range parts {
+		size += part.size + part.padding()
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	for _, part := range parts {
+		if err = writeInitrdPart(w, part); err != nil {
+			log.Printf("error serving agent initramfs part %q: %v", part.path, err)
+
+			return
+		}
+	}
+}
+
+// agentInitrdParts lists the stock agent initramfs followed by the overlays in the lexical order.
+func agentInitrdParts() ([]initrdPart, error) {
+	info, err := os.Stat(agentInitrdPath)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []initrdPart{{path: agentInitrdPath, size: info.Size()}}
+
+	overlays, err := ioutil.ReadDir(agentOverlaysDirectory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return parts, nil
+		}
+
+		return nil, err
+	}
+
+	for _, overlay := range overlays {
+		if !overlay.Mode().IsRegular() || strings.HasPrefix(overlay.Name(), ".") {
+			continue
+		}
+
+		parts = append(parts, initrdPart{path: filepath.Join(agentOverlaysDirectory, overlay.Name()), size: overlay.Size()})
+	}
+
+	return parts, nil
+}
+
+func writeInitrdPart(w io.Writer, part initrdPart) error {
+	f, err := os.Open(part.path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	if _, err = io.CopyN(w, f, part.size); err != nil {
+		return err
+	}
+
+	_, err = w.Write(make([]byte, part.padding()))
+
+	return err
+}
@@ -0,0 +1,64 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ipxe
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestAgentInitrdHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sidero-ipxe")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir) //nolint: errcheck
+
+	defer func(initrd, overlays string) {
+		agentInitrdPath, agentOverlaysDirectory = initrd, overlays
+	}(agentInitrdPath, agentOverlaysDirectory)
+
+	agentInitrdPath = filepath.Join(dir, "initramfs.xz")
+	agentOverlaysDirectory = filepath.Join(dir, "overlays")
+
+	for path, contents := range map[string]string{
+		agentInitrdPath: "base1",
+		filepath.Join(agentOverlaysDirectory, "20-firmware.cpio"):   "firmware",
+		filepath.Join(agentOverlaysDirectory, "10-modules.cpio.gz"): "mod",
+		filepath.Join(agentOverlaysDirectory, ".hidden"):            "hidden",
+	} {
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = ioutil.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []byte("base1\x00\x00\x00mod\x00firmware")
+
+	w := httptest.NewRecorder()
+	agentInitrdHandler(w, httptest.NewRequest(http.MethodGet, "/env/agent/initramfs.xz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+
+	if got := w.Body.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("agentInitrdHandler() = %q, want %q", got, want)
+	}
+
+	if got := w.Header().Get("Content-Length"); got != strconv.Itoa(len(want)) {
+		t.Errorf("Content-Length = %s, want %d", got, len(want))
+	}
+}
@@ -0,0 +1,41 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ipxe
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// Reasons of the boot denials in the strict mode.
+const (
+	denyUnknown      = "unknown"
+	denyNotAccepted  = "not-accepted"
+	denyNotAllocated = "not-allocated"
+)
+
+// DeniedBoots counts the boot requests denied in the strict mode by the reason.
+var DeniedBoots = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "sidero_ipxe_denied_boots_total",
+	Help: "Number of boot requests denied in the strict mode by the reason.",
+}, []string{"reason"})
+
+// strictDenyReason returns the reason the server is not booted in the strict mode, if any.
+//
+// Accepted servers which are not wiped yet still boot the agent, as they are wiped before the allocation.
+func strictDenyReason(server *metalv1alpha1.Server, serverBinding *infrav1.ServerBinding) string {
+	switch {
+	case server == nil:
+		return denyUnknown
+	case !server.Spec.Accepted:
+		return denyNotAccepted
+	case serverBinding == nil && server.Status.IsClean:
+		return denyNotAllocated
+	default:
+		return ""
+	}
+}
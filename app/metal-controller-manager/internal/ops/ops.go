@@ -0,0 +1,109 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package ops serves the operational endpoints (metrics, search APIs, pprof) on the listener of their own,
+// optionally over TLS and with the bearer token authentication.
+package ops
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Server serves the metrics and the extra operational endpoints.
+type Server struct {
+	reader      client.Reader
+	tokenSecret types.NamespacedName
+	mux         *http.ServeMux
+}
+
+// NewServer creates the server of the controller-runtime metrics.
+//
+// Requests are authenticated with the token of the secret, empty secret name disables the authentication.
+func NewServer(reader client.Reader, tokenSecret types.NamespacedName) *Server {
+	s := &Server{
+		reader:      reader,
+		tokenSecret: tokenSecret,
+		mux:         http.NewServeMux(),
+	}
+
+	s.mux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.HTTPErrorOnError,
+	}))
+
+	return s
+}
+
+// Handle registers the handler of the extra endpoint, it has the same signature as the manager AddMetricsExtraHandler.
+func (s *Server) Handle(path string, handler http.Handler) error {
+	if path == "/metrics" {
+		return fmt.Errorf("overriding builtin /metrics endpoint is not allowed")
+	}
+
+	s.mux.Handle(path, handler)
+
+	return nil
+}
+
+// Serve the endpoints on the address.
+//
+// Endpoints are served over TLS if the certificate and key files are set.
+func (s *Server) Serve(addr, certFile, keyFile string) error {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: s,
+	}
+
+	if certFile != "" && keyFile != "" {
+		return srv.ListenAndServeTLS(certFile, keyFile)
+	}
+
+	return srv.ListenAndServe()
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.tokenSecret.Name != "" {
+		if status, err := s.authenticate(r); err != nil {
+			log.Printf("ops request %s from %s rejected: %s", r.URL.Path, r.RemoteAddr, err)
+
+			http.Error(w, http.StatusText(status), status)
+
+			return
+		}
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// authenticate checks the bearer token against the token of the secret.
+func (s *Server) authenticate(r *http.Request) (int, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return http.StatusUnauthorized, fmt.Errorf("missing token")
+	}
+
+	var secret corev1.Secret
+
+	if err := s.reader.Get(r.Context(), s.tokenSecret, &secret); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	expected := secret.Data["token"]
+
+	if len(expected) == 0 || subtle.ConstantTimeCompare(expected, []byte(token)) != 1 {
+		return http.StatusUnauthorized, fmt.Errorf("invalid token")
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package ops
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestServerAuthentication(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "sidero-system", Name: "metrics-token"},
+		Data:       map[string][]byte{"token": []byte("secret")},
+	}
+
+	reader := fake.NewFakeClientWithScheme(scheme, secret)
+
+	for _, tt := range []struct {
+		name           string
+		tokenSecret    types.NamespacedName
+		authorization  string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "no auth",
+			path:           "/metrics",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing token",
+			tokenSecret:    types.NamespacedName{Namespace: "sidero-system", Name: "metrics-token"},
+			path:           "/metrics",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid token",
+			tokenSecret:    types.NamespacedName{Namespace: "sidero-system", Name: "metrics-token"},
+			authorization:  "Bearer wrong",
+			path:           "/metrics",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "valid token",
+			tokenSecret:    types.NamespacedName{Namespace: "sidero-system", Name: "metrics-token"},
+			authorization:  "Bearer secret",
+			path:           "/metrics",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "extra handler",
+			tokenSecret:    types.NamespacedName{Namespace: "sidero-system", Name: "metrics-token"},
+			authorization:  "Bearer secret",
+			path:           "/search",
+			expectedStatus: http.StatusTeapot,
+		},
+		{
+			name:           "extra handler unauthenticated",
+			tokenSecret:    types.NamespacedName{Namespace: "sidero-system", Name: "metrics-token"},
+			path:           "/search",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(reader, tt.tokenSecret)
+
+			if err := s.Handle("/search", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			})); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
@@ -15,17 +15,28 @@ import (
 	"time"
 
 	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/proxy"
 )
 
 // Client provides management over simple API.
 type Client struct {
-	endpoint string
+	endpoint   string
+	httpClient *http.Client
 }
 
 // NewClient returns new API client to manage metal machine.
-func NewClient(spec metalv1alpha1.ManagementAPI) (*Client, error) {
+//
+// If the proxy is set, requests are sent via the proxy.
+func NewClient(spec metalv1alpha1.ManagementAPI, p *proxy.Proxy) (*Client, error) {
+	httpClient := http.DefaultClient
+
+	if p != nil {
+		httpClient = p.HTTPClient()
+	}
+
 	return &Client{
-		endpoint: spec.Endpoint,
+		endpoint:   spec.Endpoint,
+		httpClient: httpClient,
 	}, nil
 }
 
@@ -48,7 +59,7 @@ func (c *Client) postRequest(path string) error {
 		return err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -77,6 +88,11 @@ func (c *Client) PowerOff() error {
 	return c.postRequest("/poweroff")
 }
 
+// Shutdown will power off a given machine, as the API has no graceful shutdown.
+func (c *Client) Shutdown() error {
+	return c.PowerOff()
+}
+
 // PowerCycle will power cycle a given machine.
 func (c *Client) PowerCycle() error {
 	return c.postRequest("/reboot")
@@ -106,7 +122,7 @@ func (c *Client) IsPoweredOn() (bool, error) {
 		return false, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return false, err
 	}
@@ -5,21 +5,60 @@
 package ipmi
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
 	goipmi "github.com/pensando/goipmi"
 
 	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/proxy"
 )
 
 // Note (rsmitty): This pkg is pretty sparse right now, but I wanted to go ahead and create
 // it in case we want to do something more complex w/ IPMI in the future.
 
+// ErrAuthFailed is returned when the BMC rejects the credentials.
+var ErrAuthFailed = errors.New("BMC authentication failed")
+
+// remoteTimeout limits the time ipmitool runs on the jump host.
+const remoteTimeout = 30 * time.Second
+
+// authFailureMessages are printed by ipmitool when the BMC rejects the credentials.
+var authFailureMessages = []string{
+	"unauthorized name",
+	"hmac is invalid",
+	"invalid user name",
+	"invalid password",
+}
+
 // Client is a holder for the IPMIClient.
 type Client struct {
 	IPMIClient *goipmi.Client
+
+	conn *goipmi.Connection
+
+	// jumpHost runs ipmitool on the SSH jump host, if set.
+	jumpHost *proxy.Proxy
+
+	quirks Quirks
 }
 
 // NewClient creates an ipmi client to use.
-func NewClient(bmcInfo metalv1alpha1.BMC) (*Client, error) {
+//
+// If the jump host is set, ipmitool is run on the jump host, as IPMI runs over UDP, which can't be proxied.
+// Power and boot device sequences are adjusted with the vendor quirks (see QuirksFor).
+func NewClient(bmcInfo metalv1alpha1.BMC, jumpHost *proxy.Proxy, quirks Quirks) (*Client, error) {
+	if jumpHost != nil && jumpHost.Type != metalv1alpha1.BMCProxySSH {
+		return nil, fmt.Errorf("IPMI can't be proxied via %s proxy, only SSH jump hosts are supported", jumpHost.Type)
+	}
+
 	conn := &goipmi.Connection{
 		Hostname:  bmcInfo.Endpoint,
 		Username:  bmcInfo.User,
@@ -32,7 +71,7 @@ func NewClient(bmcInfo metalv1alpha1.BMC) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{IPMIClient: ipmiClient}, nil
+	return &Client{IPMIClient: ipmiClient, conn: conn, jumpHost: jumpHost, quirks: quirks}, nil
 }
 
 // Note (rsmitty): I think checking this system power isn't really necessary, but we may want
@@ -40,16 +79,43 @@ func NewClient(bmcInfo metalv1alpha1.BMC) (*Client, error) {
 
 // PowerOn will power on a given machine.
 func (c *Client) PowerOn() error {
-	return c.IPMIClient.Control(goipmi.ControlPowerUp)
+	if c.jumpHost != nil {
+		return c.remoteControl("on")
+	}
+
+	return wrapError(c.IPMIClient.Control(goipmi.ControlPowerUp))
 }
 
 // PowerOff will power off a given machine.
 func (c *Client) PowerOff() error {
-	return c.IPMIClient.Control(goipmi.ControlPowerDown)
+	if c.jumpHost != nil {
+		return c.remoteControl("off")
+	}
+
+	return wrapError(c.IPMIClient.Control(goipmi.ControlPowerDown))
+}
+
+// Shutdown requests the operating system of a given machine to shut down (ACPI soft off).
+func (c *Client) Shutdown() error {
+	if c.jumpHost != nil {
+		return c.remoteControl("soft")
+	}
+
+	return wrapError(c.IPMIClient.Control(goipmi.ControlPowerAcpiSoft))
 }
 
 // IsPoweredOn checks current power state.
 func (c *Client) IsPoweredOn() (bool, error) {
+	if c.jumpHost != nil {
+		// "Chassis Power is on"
+		output, err := c.remote("chassis", "power", "status")
+		if err != nil {
+			return false, err
+		}
+
+		return strings.HasSuffix(strings.TrimSpace(output), " on"), nil
+	}
+
 	status, err := c.Status()
 	if err != nil {
 		return false, err
@@ -60,11 +126,31 @@ func (c *Client) IsPoweredOn() (bool, error) {
 
 // PowerCycle will power cycle a given machine.
 func (c *Client) PowerCycle() error {
-	return c.IPMIClient.Control(goipmi.ControlPowerCycle)
+	if c.quirks.PowerCycleOffOn {
+		if err := c.PowerOff(); err != nil {
+			return err
+		}
+
+		time.Sleep(c.quirks.PowerOffDelay)
+
+		return c.PowerOn()
+	}
+
+	if c.jumpHost != nil {
+		return c.remoteControl("cycle")
+	}
+
+	return wrapError(c.IPMIClient.Control(goipmi.ControlPowerCycle))
 }
 
 // Status fetches the chassis status.
+//
+// Status is not available via the jump host.
 func (c *Client) Status() (*goipmi.ChassisStatusResponse, error) {
+	if c.jumpHost != nil {
+		return nil, errors.New("chassis status is not available via the jump host")
+	}
+
 	req := &goipmi.Request{
 		NetworkFunction: goipmi.NetworkFunctionChassis,
 		Command:         goipmi.CommandChassisStatus,
@@ -75,7 +161,7 @@ func (c *Client) Status() (*goipmi.ChassisStatusResponse, error) {
 
 	err := c.IPMIClient.Send(req, res)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return res, nil
@@ -83,10 +169,155 @@ func (c *Client) Status() (*goipmi.ChassisStatusResponse, error) {
 
 // SetPXE makes sure the node will pxe boot next time.
 func (c *Client) SetPXE() error {
-	return c.IPMIClient.SetBootDeviceEFI(goipmi.BootDevicePxe)
+	if err := c.setPXE(); err != nil {
+		return err
+	}
+
+	time.Sleep(c.quirks.BootDeviceDelay)
+
+	return nil
+}
+
+func (c *Client) setPXE() error {
+	if c.jumpHost != nil {
+		args := []string{"chassis", "bootdev", "pxe"}
+
+		if !c.quirks.LegacyBootDevice {
+			args = append(args, "options=efiboot")
+		}
+
+		_, err := c.remote(args...)
+
+		return err
+	}
+
+	if c.quirks.LegacyBootDevice {
+		return wrapError(c.IPMIClient.SetBootDevice(goipmi.BootDevicePxe))
+	}
+
+	return wrapError(c.IPMIClient.SetBootDeviceEFI(goipmi.BootDevicePxe))
 }
 
 // IsFake returns false.
 func (c *Client) IsFake() bool {
 	return false
 }
+
+// remote runs ipmitool on the SSH jump host.
+//
+// Password is passed via stdin, so that it doesn't show up in the process list of the jump host.
+func (c *Client) remote(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+
+	command := proxy.Quote(append(append(c.ipmitoolArgs(), "-f", "/dev/stdin"), args...)...)
+
+	output, err := c.jumpHost.Run(ctx, command, []byte(c.conn.Password))
+	if err != nil {
+		return "", wrapError(fmt.Errorf("error running ipmitool on the jump host: %w", err))
+	}
+
+	return string(output), nil
+}
+
+// ipmitoolArgs returns the ipmitool command with the connection options, except for the password.
+//
+// The port is passed only if it's set, the same way goipmi does.
+func (c *Client) ipmitoolArgs() []string {
+	args := []string{"ipmitool", "-I", c.conn.Interface, "-H", c.conn.Hostname, "-U", c.conn.Username}
+
+	if c.conn.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(c.conn.Port))
+	}
+
+	return args
+}
+
+func (c *Client) remoteControl(action string) error {
+	_, err := c.remote("chassis", "power", action)
+
+	return err
+}
+
+// wrapError marks the errors caused by the rejected credentials with ErrAuthFailed.
+//
+// ipmitool doesn't report the completion code, so the error is matched on the ipmitool output.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	message := strings.ToLower(err.Error())
+
+	for _, authFailureMessage := range authFailureMessages {
+		if strings.Contains(message, authFailureMessage) {
+			return fmt.Errorf("%w: %s", ErrAuthFailed, err)
+		}
+	}
+
+	return err
+}
+
+// Sensor is a reading of the BMC sensor.
+type Sensor struct {
+	Name  string
+	Value float64
+	Unit  string
+}
+
+// Sensors reads the BMC sensors (temperatures, fans, power, etc.).
+//
+// goipmi doesn't implement SDR access, so ipmitool is invoked directly, sensors without a reading are skipped.
+func (c *Client) Sensors() ([]Sensor, error) {
+	if c.jumpHost != nil {
+		output, err := c.remote("-c", "sdr", "list", "full")
+		if err != nil {
+			return nil, err
+		}
+
+		return parseSensors(output), nil
+	}
+
+	// password is passed via the environment, so that it doesn't show up in the errors
+	args := append(c.ipmitoolArgs(), "-E", "-c", "sdr", "list", "full")
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), "IPMI_PASSWORD="+c.conn.Password)
+
+	var stdout, stderr bytes.Buffer
+
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, wrapError(fmt.Errorf("error reading sensors: %s (%w)", strings.TrimSpace(stderr.String()), err))
+	}
+
+	return parseSensors(stdout.String()), nil
+}
+
+// parseSensors parses the CSV output of `ipmitool -c sdr list full`: name, value, unit, status.
+func parseSensors(output string) []Sensor {
+	var sensors []Sensor
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			// no reading ("na", "disabled") or a discrete sensor
+			continue
+		}
+
+		sensors = append(sensors, Sensor{
+			Name:  strings.TrimSpace(fields[0]),
+			Value: value,
+			Unit:  strings.TrimSpace(fields[2]),
+		})
+	}
+
+	return sensors
+}
@@ -0,0 +1,151 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package ipmi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	goipmi "github.com/pensando/goipmi"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func Test_wrapError(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		err        error
+		authFailed bool
+	}{
+		{
+			name:       "unauthorized name",
+			err:        errors.New("run /usr/bin/ipmitool -I lanplus ... RAKP 2 message indicates an error : unauthorized name (exit status 1)"),
+			authFailed: true,
+		},
+		{
+			name:       "invalid hmac",
+			err:        errors.New("run /usr/bin/ipmitool -I lanplus ... RAKP 2 HMAC is invalid (exit status 1)"),
+			authFailed: true,
+		},
+		{
+			name: "timeout",
+			err:  errors.New("run /usr/bin/ipmitool -I lanplus ... Unable to establish IPMI v2 / RMCP+ session (exit status 1)"),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapError(tt.err)
+
+			if errors.Is(err, ErrAuthFailed) != tt.authFailed {
+				t.Errorf("wrapError() = %v, authFailed %v", err, tt.authFailed)
+			}
+		})
+	}
+
+	if wrapError(nil) != nil {
+		t.Error("wrapError(nil) != nil")
+	}
+}
+
+func Test_parseSensors(t *testing.T) {
+	output := `CPU1 Temp,42,degrees C,ok
+FAN1,3600,RPM,ok
+FAN2,na,RPM,ns
+PS1 Input Power,180,Watts,ok
+12V,12.19,Volts,ok
+Chassis Intru,0x00,discrete,ok
+`
+
+	expected := []Sensor{
+		{Name: "CPU1 Temp", Value: 42, Unit: "degrees C"},
+		{Name: "FAN1", Value: 3600, Unit: "RPM"},
+		{Name: "PS1 Input Power", Value: 180, Unit: "Watts"},
+		{Name: "12V", Value: 12.19, Unit: "Volts"},
+	}
+
+	if sensors := parseSensors(output); !reflect.DeepEqual(sensors, expected) {
+		t.Errorf("parseSensors() = %v, want %v", sensors, expected)
+	}
+}
+
+func TestQuirksProfile(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		spec     metalv1alpha1.ServerSpec
+		expected metalv1alpha1.BMCQuirks
+	}{
+		{
+			name:     "no system information",
+			expected: metalv1alpha1.BMCQuirksGeneric,
+		},
+		{
+			name:     "supermicro",
+			spec:     metalv1alpha1.ServerSpec{SystemInformation: &metalv1alpha1.SystemInformation{Manufacturer: "Supermicro"}},
+			expected: metalv1alpha1.BMCQuirksSupermicro,
+		},
+		{
+			name:     "dell",
+			spec:     metalv1alpha1.ServerSpec{SystemInformation: &metalv1alpha1.SystemInformation{Manufacturer: "Dell Inc."}},
+			expected: metalv1alpha1.BMCQuirksDell,
+		},
+		{
+			name:     "hp",
+			spec:     metalv1alpha1.ServerSpec{SystemInformation: &metalv1alpha1.SystemInformation{Manufacturer: "HP"}},
+			expected: metalv1alpha1.BMCQuirksHPE,
+		},
+		{
+			name:     "hpe",
+			spec:     metalv1alpha1.ServerSpec{SystemInformation: &metalv1alpha1.SystemInformation{Manufacturer: "HPE"}},
+			expected: metalv1alpha1.BMCQuirksHPE,
+		},
+		{
+			name:     "unknown vendor",
+			spec:     metalv1alpha1.ServerSpec{SystemInformation: &metalv1alpha1.SystemInformation{Manufacturer: "QEMU"}},
+			expected: metalv1alpha1.BMCQuirksGeneric,
+		},
+		{
+			name: "explicit profile",
+			spec: metalv1alpha1.ServerSpec{
+				BMC:               &metalv1alpha1.BMC{Quirks: metalv1alpha1.BMCQuirksGeneric},
+				SystemInformation: &metalv1alpha1.SystemInformation{Manufacturer: "Dell Inc."},
+			},
+			expected: metalv1alpha1.BMCQuirksGeneric,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if profile := QuirksProfile(&tt.spec); profile != tt.expected {
+				t.Errorf("QuirksProfile() = %q, want %q", profile, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClient_ipmitoolArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		conn     goipmi.Connection
+		expected []string
+	}{
+		{
+			name:     "default port",
+			conn:     goipmi.Connection{Hostname: "10.0.0.25", Username: "admin", Interface: "lanplus"},
+			expected: []string{"ipmitool", "-I", "lanplus", "-H", "10.0.0.25", "-U", "admin"},
+		},
+		{
+			name:     "custom port",
+			conn:     goipmi.Connection{Hostname: "10.0.0.25", Port: 6230, Username: "admin", Interface: "lanplus"},
+			expected: []string{"ipmitool", "-I", "lanplus", "-H", "10.0.0.25", "-U", "admin", "-p", "6230"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{conn: &tt.conn}
+
+			if args := c.ipmitoolArgs(); !reflect.DeepEqual(args, tt.expected) {
+				t.Errorf("ipmitoolArgs() = %v, want %v", args, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ipmi
+
+import (
+	"strings"
+	"time"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// Quirks adjust the IPMI sequences to the peculiarities of the BMC vendor.
+type Quirks struct {
+	// LegacyBootDevice sets the boot device without the EFI boot flag.
+	LegacyBootDevice bool
+	// BootDeviceDelay is the time to wait after setting the boot device,
+	// as some BMCs drop the boot device set right before the power change.
+	BootDeviceDelay time.Duration
+	// PowerCycleOffOn replaces the power cycle with the power off and the power on.
+	PowerCycleOffOn bool
+	// PowerOffDelay is the time to wait between the power off and the power on of PowerCycleOffOn.
+	PowerOffDelay time.Duration
+}
+
+var quirksProfiles = map[metalv1alpha1.BMCQuirks]Quirks{
+	metalv1alpha1.BMCQuirksGeneric: {},
+	metalv1alpha1.BMCQuirksSupermicro: {
+		BootDeviceDelay: 2 * time.Second,
+	},
+	metalv1alpha1.BMCQuirksDell: {
+		PowerCycleOffOn: true,
+		PowerOffDelay:   5 * time.Second,
+	},
+	metalv1alpha1.BMCQuirksHPE: {
+		LegacyBootDevice: true,
+		BootDeviceDelay:  time.Second,
+	},
+}
+
+// QuirksProfile returns the quirks profile of the BMC: the one set explicitly, or the one detected from the SMBIOS manufacturer.
+func QuirksProfile(spec *metalv1alpha1.ServerSpec) metalv1alpha1.BMCQuirks {
+	if spec.BMC != nil && spec.BMC.Quirks != "" {
+		return spec.BMC.Quirks
+	}
+
+	if spec.SystemInformation == nil {
+		return metalv1alpha1.BMCQuirksGeneric
+	}
+
+	manufacturer := strings.ToLower(strings.TrimSpace(spec.SystemInformation.Manufacturer))
+
+	switch {
+	case strings.HasPrefix(manufacturer, "supermicro"):
+		return metalv1alpha1.BMCQuirksSupermicro
+	case strings.HasPrefix(manufacturer, "dell"):
+		return metalv1alpha1.BMCQuirksDell
+	case manufacturer == "hp", strings.HasPrefix(manufacturer, "hpe"), strings.HasPrefix(manufacturer, "hewlett"):
+		return metalv1alpha1.BMCQuirksHPE
+	default:
+		return metalv1alpha1.BMCQuirksGeneric
+	}
+}
+
+// QuirksFor returns the quirks of the profile, unknown profiles have no quirks.
+func QuirksFor(profile metalv1alpha1.BMCQuirks) Quirks {
+	return quirksProfiles[profile]
+}
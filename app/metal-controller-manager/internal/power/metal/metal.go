@@ -6,15 +6,26 @@
 package metal
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	"github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/api"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/ipmi"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/proxy"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/redfish"
 )
 
 // ManagementClient control power and boot order of metal machine.
 type ManagementClient interface {
 	PowerOn() error
 	PowerOff() error
+	Shutdown() error
 	PowerCycle() error
 	IsPoweredOn() (bool, error)
 	SetPXE() error
@@ -22,13 +33,104 @@ type ManagementClient interface {
 }
 
 // NewManagementClient builds ManagementClient from the server spec.
-func NewManagementClient(spec *v1alpha1.ServerSpec) (ManagementClient, error) {
-	switch {
-	case spec.BMC != nil:
-		return ipmi.NewClient(*spec.BMC)
-	case spec.ManagementAPI != nil:
-		return api.NewClient(*spec.ManagementAPI)
-	default:
+//
+// If the server has several power management paths configured, path selects the one to use (see Paths).
+// If the proxy is set, the BMC and the management API are reached via the proxy (see LoadProxy).
+func NewManagementClient(spec *v1alpha1.ServerSpec, path int, p *proxy.Proxy) (ManagementClient, error) {
+	paths := Paths(spec)
+
+	if len(paths) == 0 {
 		return fakeClient{}, nil
 	}
+
+	if path >= len(paths) {
+		path = len(paths) - 1
+	}
+
+	switch paths[path] {
+	case PathIPMI:
+		return ipmi.NewClient(*spec.BMC, p, ipmi.QuirksFor(ipmi.QuirksProfile(spec)))
+	case PathRedfish:
+		return redfish.NewClient(*spec.BMC, p, ipmi.QuirksFor(ipmi.QuirksProfile(spec)).LegacyBootDevice)
+	default:
+		return api.NewClient(*spec.ManagementAPI, p)
+	}
+}
+
+// HealthReporter is implemented by the management clients which report the health of the server.
+type HealthReporter interface {
+	Health() (string, error)
+}
+
+// LoadProxy resolves the BMCProxy of the server, it returns nil if the server doesn't use the proxy.
+//
+// The BMCProxy set on the server takes precedence over the BMCProxy of the server site.
+func LoadProxy(ctx context.Context, r client.Reader, server *v1alpha1.Server) (*proxy.Proxy, error) {
+	name := server.Spec.BMCProxy
+
+	if siteName := server.Labels[v1alpha1.SiteLabel]; name == "" && siteName != "" {
+		var site v1alpha1.Site
+
+		if err := r.Get(ctx, types.NamespacedName{Name: siteName}, &site); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("error getting site %q: %w", siteName, err)
+			}
+		} else {
+			name = site.Spec.BMCProxy
+		}
+	}
+
+	if name == "" {
+		return nil, nil
+	}
+
+	return proxy.Load(ctx, r, name)
+}
+
+// Power management paths.
+const (
+	PathIPMI          = "IPMI"
+	PathRedfish       = "Redfish"
+	PathManagementAPI = "ManagementAPI"
+)
+
+// Paths lists the power management paths configured for the server, in the order of preference.
+//
+// BMC interface and its fallback interface are separate paths, so that the BMC is tried over both protocols.
+func Paths(spec *v1alpha1.ServerSpec) []string {
+	var paths []string
+
+	if spec.BMC != nil {
+		primary := bmcPath(spec.BMC.Interface)
+		paths = append(paths, primary)
+
+		if spec.BMC.FallbackInterface != "" {
+			if fallback := bmcPath(spec.BMC.FallbackInterface); fallback != primary {
+				paths = append(paths, fallback)
+			}
+		}
+	}
+
+	if spec.ManagementAPI != nil {
+		paths = append(paths, PathManagementAPI)
+	}
+
+	return paths
+}
+
+func bmcPath(iface v1alpha1.BMCInterface) string {
+	if iface == v1alpha1.BMCInterfaceRedfish {
+		return PathRedfish
+	}
+
+	return PathIPMI
+}
+
+// FailureReason returns the reason of the management client failure to be set on the conditions and events.
+func FailureReason(err error) string {
+	if errors.Is(err, ipmi.ErrAuthFailed) || errors.Is(err, redfish.ErrAuthFailed) {
+		return v1alpha1.BMCAuthFailedReason
+	}
+
+	return v1alpha1.PowerManagementFailedReason
 }
@@ -0,0 +1,66 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package metal_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/metal"
+)
+
+func TestPaths(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		spec     v1alpha1.ServerSpec
+		expected []string
+	}{
+		{
+			name: "no power management",
+		},
+		{
+			name:     "IPMI",
+			spec:     v1alpha1.ServerSpec{BMC: &v1alpha1.BMC{}},
+			expected: []string{metal.PathIPMI},
+		},
+		{
+			name:     "IPMI with Redfish fallback",
+			spec:     v1alpha1.ServerSpec{BMC: &v1alpha1.BMC{FallbackInterface: v1alpha1.BMCInterfaceRedfish}},
+			expected: []string{metal.PathIPMI, metal.PathRedfish},
+		},
+		{
+			name: "Redfish with IPMI fallback",
+			spec: v1alpha1.ServerSpec{BMC: &v1alpha1.BMC{
+				Interface:         v1alpha1.BMCInterfaceRedfish,
+				FallbackInterface: v1alpha1.BMCInterfaceIPMI,
+			}},
+			expected: []string{metal.PathRedfish, metal.PathIPMI},
+		},
+		{
+			name: "same fallback",
+			spec: v1alpha1.ServerSpec{BMC: &v1alpha1.BMC{
+				Interface:         v1alpha1.BMCInterfaceIPMI,
+				FallbackInterface: v1alpha1.BMCInterfaceIPMI,
+			}},
+			expected: []string{metal.PathIPMI},
+		},
+		{
+			name: "all paths",
+			spec: v1alpha1.ServerSpec{
+				BMC:           &v1alpha1.BMC{FallbackInterface: v1alpha1.BMCInterfaceRedfish},
+				ManagementAPI: &v1alpha1.ManagementAPI{Endpoint: "10.0.0.25:8000"},
+			},
+			expected: []string{metal.PathIPMI, metal.PathRedfish, metal.PathManagementAPI},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if paths := metal.Paths(&tt.spec); !reflect.DeepEqual(paths, tt.expected) {
+				t.Errorf("Paths() = %v, want %v", paths, tt.expected)
+			}
+		})
+	}
+}
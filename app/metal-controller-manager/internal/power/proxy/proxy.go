@@ -0,0 +1,370 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package proxy provides access to the BMC networks via the jump hosts and the proxies.
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// Secret keys of the proxy credentials.
+const (
+	PasswordKey = "password"
+	HostKeyKey  = "host-key"
+)
+
+// handshakeTimeout limits the time to establish the connection to the jump host or the proxy.
+const handshakeTimeout = 10 * time.Second
+
+// Proxy is the BMCProxy resolved with the credentials.
+type Proxy struct {
+	Type     metalv1alpha1.BMCProxyType
+	Address  string
+	User     string
+	Password string
+	SSHKey   []byte
+	HostKey  []byte
+
+	mu         sync.Mutex
+	sshClient  *ssh.Client
+	httpClient *http.Client
+}
+
+// proxies keeps the loaded proxies by the BMCProxy name, so that the connection to the SSH jump host
+// and the HTTP transport are shared by all the servers behind the same proxy.
+var proxies = struct {
+	sync.Mutex
+
+	m map[string]*Proxy
+}{
+	m: map[string]*Proxy{},
+}
+
+// Load fetches the BMCProxy by name along with its credentials.
+func Load(ctx context.Context, r client.Reader, name string) (*Proxy, error) {
+	var bmcProxy metalv1alpha1.BMCProxy
+
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, &bmcProxy); err != nil {
+		return nil, fmt.Errorf("error getting BMC proxy %q: %w", name, err)
+	}
+
+	p := &Proxy{
+		Type:    bmcProxy.Spec.Type,
+		Address: bmcProxy.Spec.Address,
+		User:    bmcProxy.Spec.User,
+	}
+
+	if ref := bmcProxy.Spec.SecretRef; ref != nil {
+		var secret corev1.Secret
+
+		if err := r.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, &secret); err != nil {
+			return nil, fmt.Errorf("error getting BMC proxy %q credentials: %w", name, err)
+		}
+
+		p.Password = string(secret.Data[PasswordKey])
+		p.SSHKey = secret.Data[corev1.SSHAuthPrivateKey]
+		p.HostKey = secret.Data[HostKeyKey]
+	}
+
+	proxies.Lock()
+	defer proxies.Unlock()
+
+	if cached, ok := proxies.m[name]; ok {
+		if cached.sameSettings(p) {
+			return cached, nil
+		}
+
+		// the BMCProxy or its credentials were updated
+		cached.Close()
+	}
+
+	proxies.m[name] = p
+
+	return p, nil
+}
+
+// Close closes the shared connection to the SSH jump host and the idle HTTP connections.
+func (p *Proxy) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sshClient != nil {
+		p.sshClient.Close() //nolint: errcheck
+
+		p.sshClient = nil
+	}
+
+	if p.httpClient != nil {
+		p.httpClient.CloseIdleConnections()
+	}
+}
+
+func (p *Proxy) sameSettings(other *Proxy) bool {
+	return p.Type == other.Type &&
+		p.Address == other.Address &&
+		p.User == other.User &&
+		p.Password == other.Password &&
+		bytes.Equal(p.SSHKey, other.SSHKey) &&
+		bytes.Equal(p.HostKey, other.HostKey)
+}
+
+// DialContext connects to the address via the proxy.
+func (p *Proxy) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	switch p.Type {
+	case metalv1alpha1.BMCProxySSH:
+		return p.dialViaSSH(ctx, network, address)
+	case metalv1alpha1.BMCProxySOCKS5:
+		var auth *proxy.Auth
+
+		if p.User != "" {
+			auth = &proxy.Auth{User: p.User, Password: p.Password}
+		}
+
+		dialer, err := proxy.SOCKS5("tcp", p.Address, auth, &net.Dialer{Timeout: handshakeTimeout})
+		if err != nil {
+			return nil, err
+		}
+
+		return dialer.(proxy.ContextDialer).DialContext(ctx, network, address)
+	case metalv1alpha1.BMCProxyHTTPConnect:
+		return p.dialHTTPConnect(ctx, address)
+	default:
+		return nil, fmt.Errorf("unsupported BMC proxy type %q", p.Type)
+	}
+}
+
+// HTTPClient returns the HTTP client which sends the requests via the proxy.
+//
+// The client is shared by all the callers of the proxy, so the connections are reused.
+func (p *Proxy) HTTPClient() *http.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.httpClient == nil {
+		p.httpClient = &http.Client{
+			Transport: &http.Transport{
+				DialContext:     p.DialContext,
+				IdleConnTimeout: 90 * time.Second,
+			},
+		}
+	}
+
+	return p.httpClient
+}
+
+// Run runs the command on the SSH jump host, and returns its output.
+func (p *Proxy) Run(ctx context.Context, command string, stdin []byte) ([]byte, error) {
+	if p.Type != metalv1alpha1.BMCProxySSH {
+		return nil, fmt.Errorf("commands can't be run via %s proxy", p.Type)
+	}
+
+	sshClient, err := p.dialSSH(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer sshClient.Close() //nolint: errcheck
+
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	defer session.Close() //nolint: errcheck
+
+	var stdout, stderr bytes.Buffer
+
+	session.Stdin = bytes.NewReader(stdin)
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			// closing the connection aborts the command
+			sshClient.Close() //nolint: errcheck
+		case <-done:
+		}
+	}()
+
+	if err = session.Run(command); err != nil {
+		return nil, fmt.Errorf("%s (%w)", strings.TrimSpace(stderr.String()), err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// dialViaSSH forwards the connection via the shared connection to the SSH jump host.
+//
+// If the shared connection turns out to be broken, it's dialed again once; the jump host refusing
+// to forward the connection doesn't affect the other connections.
+func (p *Proxy) dialViaSSH(ctx context.Context, network, address string) (net.Conn, error) {
+	for attempt := 0; ; attempt++ {
+		sshClient, reused, err := p.sharedSSH(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := sshClient.Dial(network, address)
+		if err == nil {
+			return conn, nil
+		}
+
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) {
+			return nil, err
+		}
+
+		p.forgetSSH(sshClient)
+
+		if !reused || attempt > 0 {
+			return nil, err
+		}
+	}
+}
+
+func (p *Proxy) sharedSSH(ctx context.Context) (sshClient *ssh.Client, reused bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sshClient != nil {
+		return p.sshClient, true, nil
+	}
+
+	if p.sshClient, err = p.dialSSH(ctx); err != nil {
+		return nil, false, err
+	}
+
+	return p.sshClient, false, nil
+}
+
+func (p *Proxy) forgetSSH(sshClient *ssh.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sshClient == sshClient {
+		p.sshClient = nil
+	}
+
+	sshClient.Close() //nolint: errcheck
+}
+
+func (p *Proxy) dialSSH(ctx context.Context) (*ssh.Client, error) {
+	signer, err := ssh.ParsePrivateKey(p.SSHKey)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SSH private key: %w", err)
+	}
+
+	if len(p.HostKey) == 0 {
+		return nil, errors.New("SSH jump host key is not set")
+	}
+
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey(p.HostKey)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SSH jump host key: %w", err)
+	}
+
+	config := &ssh.ClientConfig{
+		User:            p.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+	}
+
+	conn, err := (&net.Dialer{Timeout: handshakeTimeout}).DialContext(ctx, "tcp", p.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout)) //nolint: errcheck
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, p.Address, config)
+	if err != nil {
+		conn.Close() //nolint: errcheck
+
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Time{}) //nolint: errcheck
+
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+func (p *Proxy) dialHTTPConnect(ctx context.Context, address string) (net.Conn, error) {
+	conn, err := (&net.Dialer{Timeout: handshakeTimeout}).DialContext(ctx, "tcp", p.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Host: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+
+	if p.User != "" {
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(p.User+":"+p.Password)))
+	}
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout)) //nolint: errcheck
+
+	if err = req.Write(conn); err != nil {
+		conn.Close() //nolint: errcheck
+
+		return nil, err
+	}
+
+	// the target doesn't send anything before the request, so nothing is lost in the buffer
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close() //nolint: errcheck
+
+		return nil, err
+	}
+
+	// response body is not closed, as for the successful response the body is the tunnel itself
+	if resp.StatusCode != http.StatusOK {
+		conn.Close() //nolint: errcheck
+
+		return nil, fmt.Errorf("proxy refused the connection: %s", resp.Status)
+	}
+
+	conn.SetDeadline(time.Time{}) //nolint: errcheck
+
+	return conn, nil
+}
+
+// Quote joins the command line arguments quoting them for the POSIX shell.
+func Quote(args ...string) string {
+	quoted := make([]string, len(args))
+
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,350 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package proxy_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/proxy"
+)
+
+func TestQuote(t *testing.T) {
+	got := proxy.Quote("ipmitool", "-U", "admin", "-H", "it's; rm -rf /")
+	want := `'ipmitool' '-U' 'admin' '-H' 'it'\''s; rm -rf /'`
+
+	if got != want {
+		t.Errorf("Quote() = %s, want %s", got, want)
+	}
+}
+
+func TestHTTPConnect(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok") //nolint: errcheck
+	}))
+	defer target.Close()
+
+	var connected string
+
+	connectProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if r.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("sidero:secret")) {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+
+			return
+		}
+
+		connected = r.Host
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+
+		defer upstream.Close() //nolint: errcheck
+
+		w.WriteHeader(http.StatusOK)
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close() //nolint: errcheck
+
+		go func() {
+			io.Copy(upstream, conn) //nolint: errcheck
+			upstream.Close()        //nolint: errcheck
+		}()
+
+		io.Copy(conn, upstream) //nolint: errcheck
+	}))
+	defer connectProxy.Close()
+
+	p := &proxy.Proxy{
+		Type:     metalv1alpha1.BMCProxyHTTPConnect,
+		Address:  connectProxy.Listener.Addr().String(),
+		User:     "sidero",
+		Password: "secret",
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, target.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := p.HTTPClient()
+	defer httpClient.CloseIdleConnections()
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close() //nolint: errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "ok" {
+		t.Errorf("unexpected response %q", body)
+	}
+
+	if connected != target.Listener.Addr().String() {
+		t.Errorf("proxy connected to %q, want %q", connected, target.Listener.Addr().String())
+	}
+}
+
+func TestSSHConnectionShared(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok") //nolint: errcheck
+	}))
+	defer target.Close()
+
+	jumpHost, hostKey, connections := startJumpHost(t)
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: clientKeyDER})
+
+	scheme := runtime.NewScheme()
+
+	if err = corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "jump-host"},
+		Data: map[string][]byte{
+			corev1.SSHAuthPrivateKey: clientKeyPEM,
+			proxy.HostKeyKey:         ssh.MarshalAuthorizedKey(hostKey),
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme,
+		&metalv1alpha1.BMCProxy{
+			ObjectMeta: metav1.ObjectMeta{Name: "jump-host"},
+			Spec: metalv1alpha1.BMCProxySpec{
+				Type:      metalv1alpha1.BMCProxySSH,
+				Address:   jumpHost,
+				User:      "sidero",
+				SecretRef: &corev1.SecretReference{Namespace: "default", Name: "jump-host"},
+			},
+		},
+		secret,
+	)
+
+	ctx := context.Background()
+
+	get := func(p *proxy.Proxy) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// new transport each time, as the Redfish client does
+		httpClient := &http.Client{Transport: &http.Transport{DialContext: p.DialContext, DisableKeepAlives: true}}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer resp.Body.Close() //nolint: errcheck
+
+		if body, _ := io.ReadAll(resp.Body); string(body) != "ok" { //nolint: errcheck
+			t.Errorf("unexpected response %q", body)
+		}
+	}
+
+	p1, err := proxy.Load(ctx, c, "jump-host")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer p1.Close()
+
+	p2, err := proxy.Load(ctx, c, "jump-host")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p1 != p2 {
+		t.Error("proxy is loaded again with the same settings")
+	}
+
+	if p1.HTTPClient() != p2.HTTPClient() {
+		t.Error("HTTP client is not shared")
+	}
+
+	get(p1)
+	get(p2)
+
+	if n := atomic.LoadInt32(connections); n != 1 {
+		t.Errorf("%d SSH connections to the jump host, want 1", n)
+	}
+
+	// connection is dialed again once closed
+	p1.Close()
+
+	get(p1)
+
+	if n := atomic.LoadInt32(connections); n != 2 {
+		t.Errorf("%d SSH connections to the jump host, want 2", n)
+	}
+
+	secret.Data[proxy.PasswordKey] = []byte("changed")
+
+	if err = c.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	p3, err := proxy.Load(ctx, c, "jump-host")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer p3.Close()
+
+	if p3 == p1 {
+		t.Error("proxy is not reloaded after the credentials change")
+	}
+}
+
+// startJumpHost runs the SSH server which forwards the connections, and counts the SSH connections.
+func startJumpHost(t *testing.T) (string, ssh.PublicKey, *int32) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+	}
+
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { l.Close() }) //nolint: errcheck
+
+	var connections int32
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+
+				atomic.AddInt32(&connections, 1)
+
+				go ssh.DiscardRequests(reqs)
+
+				for newChannel := range chans {
+					go forward(newChannel)
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().String(), signer.PublicKey(), &connections
+}
+
+func forward(newChannel ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+
+	if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &payload) != nil {
+		newChannel.Reject(ssh.UnknownChannelType, "unsupported channel") //nolint: errcheck
+
+		return
+	}
+
+	upstream, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error()) //nolint: errcheck
+
+		return
+	}
+
+	channel, reqs, err := newChannel.Accept()
+	if err != nil {
+		upstream.Close() //nolint: errcheck
+
+		return
+	}
+
+	go ssh.DiscardRequests(reqs)
+
+	go func() {
+		io.Copy(upstream, channel) //nolint: errcheck
+		upstream.Close()           //nolint: errcheck
+	}()
+
+	io.Copy(channel, upstream) //nolint: errcheck
+	channel.Close()            //nolint: errcheck
+}
@@ -0,0 +1,298 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package redfish provides metal machine management via the Redfish API of the BMC.
+package redfish
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/proxy"
+)
+
+// ErrAuthFailed is returned when the BMC rejects the credentials.
+var ErrAuthFailed = errors.New("BMC authentication failed")
+
+// requestTimeout limits the time of a single Redfish request.
+const requestTimeout = 10 * time.Second
+
+// systemsPath is the collection of the computer systems managed by the BMC.
+const systemsPath = "/redfish/v1/Systems"
+
+// Reset types of the ComputerSystem.Reset action.
+const (
+	resetOn               = "On"
+	resetForceOff         = "ForceOff"
+	resetGracefulShutdown = "GracefulShutdown"
+	resetForceRestart     = "ForceRestart"
+)
+
+// Boot modes of the boot source override.
+const (
+	bootModeUEFI   = "UEFI"
+	bootModeLegacy = "Legacy"
+)
+
+// systemPaths caches the paths of the computer systems discovered by the base URL of the BMC,
+// as the client is created on each reconcile.
+var systemPaths = struct {
+	sync.Mutex
+	m map[string]string
+}{
+	m: map[string]string{},
+}
+
+// Client manages the server via the Redfish API of the BMC.
+type Client struct {
+	baseURL    string
+	user       string
+	pass       string
+	httpClient *http.Client
+	bootMode   string
+}
+
+// computerSystem is the subset of the Redfish ComputerSystem resource used by the client.
+type computerSystem struct {
+	PowerState string `json:"PowerState"`
+	Status     struct {
+		Health string `json:"Health"`
+	} `json:"Status"`
+}
+
+// NewClient returns new Redfish client to manage metal machine.
+//
+// The endpoint is either the address of the BMC (HTTPS is used) or the URL.
+// If the proxy is set, requests are sent via the proxy (any proxy type works, as Redfish runs over TCP).
+// The server is PXE booted in UEFI mode, unless legacyBoot is set (see ipmi.Quirks).
+func NewClient(bmcInfo metalv1alpha1.BMC, p *proxy.Proxy, legacyBoot bool) (*Client, error) {
+	baseURL := bmcInfo.Endpoint
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "https://" + baseURL
+	}
+
+	transport := &http.Transport{
+		// the client is short-lived, idle connections would be leaked with each new transport
+		DisableKeepAlives: true,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: bmcInfo.InsecureSkipVerify, //nolint: gosec
+		},
+	}
+
+	if p != nil {
+		transport.DialContext = p.DialContext
+	}
+
+	bootMode := bootModeUEFI
+	if legacyBoot {
+		bootMode = bootModeLegacy
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		user:       bmcInfo.User,
+		pass:       bmcInfo.Pass,
+		httpClient: &http.Client{Transport: transport},
+		bootMode:   bootMode,
+	}, nil
+}
+
+func (c *Client) do(method, path string, body, out interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	var reqBody io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(c.user, c.pass)
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrAuthFailed, resp.Status)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("redfish error: %s %s: %s", method, path, resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// system returns the path of the computer system, the first system of the BMC is managed.
+func (c *Client) system() (string, error) {
+	systemPaths.Lock()
+	path, ok := systemPaths.m[c.baseURL]
+	systemPaths.Unlock()
+
+	if ok {
+		return path, nil
+	}
+
+	var systems struct {
+		Members []struct {
+			ID string `json:"@odata.id"`
+		} `json:"Members"`
+	}
+
+	if err := c.do(http.MethodGet, systemsPath, nil, &systems); err != nil {
+		return "", err
+	}
+
+	if len(systems.Members) == 0 || systems.Members[0].ID == "" {
+		return "", errors.New("redfish error: no computer systems found")
+	}
+
+	path = systems.Members[0].ID
+
+	systemPaths.Lock()
+	systemPaths.m[c.baseURL] = path
+	systemPaths.Unlock()
+
+	return path, nil
+}
+
+// onSystem runs the request against the computer system, the cached system path is discovered again after the failure
+// (e.g. the BMC was replaced or reconfigured).
+func (c *Client) onSystem(f func(system string) error) error {
+	system, err := c.system()
+	if err != nil {
+		return err
+	}
+
+	if err = f(system); err != nil {
+		systemPaths.Lock()
+		delete(systemPaths.m, c.baseURL)
+		systemPaths.Unlock()
+	}
+
+	return err
+}
+
+func (c *Client) reset(resetType string) error {
+	return c.onSystem(func(system string) error {
+		return c.do(http.MethodPost, system+"/Actions/ComputerSystem.Reset", map[string]string{"ResetType": resetType}, nil)
+	})
+}
+
+func (c *Client) computerSystem() (*computerSystem, error) {
+	var cs computerSystem
+
+	if err := c.onSystem(func(system string) error {
+		return c.do(http.MethodGet, system, nil, &cs)
+	}); err != nil {
+		return nil, err
+	}
+
+	return &cs, nil
+}
+
+// PowerOn will power on a given machine.
+func (c *Client) PowerOn() error {
+	return c.reset(resetOn)
+}
+
+// PowerOff will power off a given machine.
+func (c *Client) PowerOff() error {
+	return c.reset(resetForceOff)
+}
+
+// Shutdown requests the operating system of a given machine to shut down.
+func (c *Client) Shutdown() error {
+	return c.reset(resetGracefulShutdown)
+}
+
+// PowerCycle will power cycle a given machine.
+//
+// Powered off machine is powered on, as the restart of the powered off system is rejected by many BMCs.
+func (c *Client) PowerCycle() error {
+	poweredOn, err := c.IsPoweredOn()
+	if err != nil {
+		return err
+	}
+
+	if !poweredOn {
+		return c.PowerOn()
+	}
+
+	return c.reset(resetForceRestart)
+}
+
+// IsPoweredOn checks current power state.
+func (c *Client) IsPoweredOn() (bool, error) {
+	cs, err := c.computerSystem()
+	if err != nil {
+		return false, err
+	}
+
+	return cs.PowerState == "On", nil
+}
+
+// SetPXE makes sure the node will pxe boot next time.
+func (c *Client) SetPXE() error {
+	return c.onSystem(func(system string) error {
+		return c.do(http.MethodPatch, system, map[string]interface{}{
+			"Boot": map[string]string{
+				"BootSourceOverrideTarget":  "Pxe",
+				"BootSourceOverrideEnabled": "Once",
+				"BootSourceOverrideMode":    c.bootMode,
+			},
+		}, nil)
+	})
+}
+
+// Health returns the health of the computer system reported by the BMC, e.g. "OK", "Warning" or "Critical".
+func (c *Client) Health() (string, error) {
+	cs, err := c.computerSystem()
+	if err != nil {
+		return "", err
+	}
+
+	return cs.Status.Health, nil
+}
+
+// IsFake returns false.
+func (c *Client) IsFake() bool {
+	return false
+}
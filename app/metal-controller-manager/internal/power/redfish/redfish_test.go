@@ -0,0 +1,209 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package redfish_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/redfish"
+)
+
+func TestClient(t *testing.T) {
+	var (
+		powerState = "Off"
+		resets     []string
+		boot       map[string]string
+	)
+
+	bmc := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /redfish/v1/Systems":
+			io.WriteString(w, `{"Members":[{"@odata.id":"/redfish/v1/Systems/System.Embedded.1"}]}`) //nolint: errcheck
+		case "GET /redfish/v1/Systems/System.Embedded.1":
+			json.NewEncoder(w).Encode(map[string]interface{}{ //nolint: errcheck
+				"PowerState": powerState,
+				"Status":     map[string]string{"Health": "Warning"},
+			})
+		case "PATCH /redfish/v1/Systems/System.Embedded.1":
+			var body struct {
+				Boot map[string]string
+			}
+
+			json.NewDecoder(r.Body).Decode(&body) //nolint: errcheck
+
+			boot = body.Boot
+
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /redfish/v1/Systems/System.Embedded.1/Actions/ComputerSystem.Reset":
+			var body struct {
+				ResetType string
+			}
+
+			json.NewDecoder(r.Body).Decode(&body) //nolint: errcheck
+
+			resets = append(resets, body.ResetType)
+
+			if body.ResetType == "On" {
+				powerState = "On"
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer bmc.Close()
+
+	client, err := redfish.NewClient(metalv1alpha1.BMC{
+		Endpoint:           bmc.Listener.Addr().String(),
+		User:               "admin",
+		Pass:               "secret",
+		Interface:          metalv1alpha1.BMCInterfaceRedfish,
+		InsecureSkipVerify: true,
+	}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = client.SetPXE(); err != nil {
+		t.Fatal(err)
+	}
+
+	if boot["BootSourceOverrideTarget"] != "Pxe" || boot["BootSourceOverrideEnabled"] != "Once" || boot["BootSourceOverrideMode"] != "UEFI" {
+		t.Errorf("unexpected boot override %v", boot)
+	}
+
+	// powered off server is powered on
+	if err = client.PowerCycle(); err != nil {
+		t.Fatal(err)
+	}
+
+	poweredOn, err := client.IsPoweredOn()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !poweredOn {
+		t.Error("expected server to be powered on")
+	}
+
+	if err = client.PowerCycle(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = client.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"On", "ForceRestart", "GracefulShutdown"}; !reflect.DeepEqual(resets, want) {
+		t.Errorf("resets = %v, want %v", resets, want)
+	}
+
+	health, err := client.Health()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if health != "Warning" {
+		t.Errorf("health = %q, want Warning", health)
+	}
+
+	client, err = redfish.NewClient(metalv1alpha1.BMC{
+		Endpoint:           bmc.URL,
+		User:               "admin",
+		Pass:               "wrong",
+		InsecureSkipVerify: true,
+	}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.IsPoweredOn(); !errors.Is(err, redfish.ErrAuthFailed) {
+		t.Errorf("expected auth failure, got %v", err)
+	}
+}
+
+func TestClientDiscovery(t *testing.T) {
+	var (
+		discoveries int
+		keepAlive   bool
+		system      = "/redfish/v1/Systems/1"
+	)
+
+	bmc := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keepAlive = keepAlive || !r.Close
+
+		switch r.URL.Path {
+		case "/redfish/v1/Systems":
+			discoveries++
+
+			json.NewEncoder(w).Encode(map[string]interface{}{ //nolint: errcheck
+				"Members": []map[string]string{{"@odata.id": system}},
+			})
+		case system:
+			io.WriteString(w, `{"PowerState":"On"}`) //nolint: errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer bmc.Close()
+
+	isPoweredOn := func() error {
+		// new client for each call, as created on each reconcile
+		client, err := redfish.NewClient(metalv1alpha1.BMC{
+			Endpoint:           bmc.URL,
+			InsecureSkipVerify: true,
+		}, nil, false)
+		if err != nil {
+			return err
+		}
+
+		_, err = client.IsPoweredOn()
+
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := isPoweredOn(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if discoveries != 1 {
+		t.Errorf("discoveries = %d, want 1", discoveries)
+	}
+
+	// the system is moved, the request to the cached path fails once, and the system is discovered again
+	system = "/redfish/v1/Systems/2"
+
+	if err := isPoweredOn(); err == nil {
+		t.Fatal("expected the request to the moved system to fail")
+	}
+
+	if err := isPoweredOn(); err != nil {
+		t.Fatal(err)
+	}
+
+	if discoveries != 2 {
+		t.Errorf("discoveries = %d, want 2", discoveries)
+	}
+
+	if keepAlive {
+		t.Error("connections are kept alive")
+	}
+}
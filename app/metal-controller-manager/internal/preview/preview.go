@@ -0,0 +1,157 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package preview implements the allocation preview API.
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/allocation"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// Result describes the servers which would be allocated for the new machines.
+type Result struct {
+	ServerClass string `json:"serverClass"`
+	Requested   int    `json:"requested"`
+	// Free is the number of servers ready to be allocated.
+	Free int `json:"free"`
+	// Spares is the number of free servers reserved for the remediation, which are not allocated on scale up.
+	Spares int `json:"spares"`
+	// Sufficient is true if all the requested machines would be allocated a server.
+	Sufficient bool `json:"sufficient"`
+	// Selected lists the servers which would be allocated, in the allocation order.
+	Selected []string `json:"selected"`
+	// Excluded lists the servers matching the ServerClass which can't be allocated.
+	Excluded []allocation.Exclusion `json:"excluded,omitempty"`
+}
+
+// Preview reports which servers of the ServerClass would be allocated to the count of new machines.
+//
+// Servers are picked by the same code as the MetalMachine controller uses, with the remediation spares kept aside.
+// The metal machine (might be nil) sets the cluster and the role of the new machines for the SpreadByLabel strategy,
+// the Ordinal strategy is not previewed, as the ordinals are assigned on the allocation.
+// Preview doesn't reserve anything, the servers might be allocated by other machines in the meantime.
+func Preview(ctx context.Context, c client.Reader, serverClassName string, count int, metalMachine *infrav1.MetalMachine) (*Result, error) {
+	var serverClass metalv1alpha1.ServerClass
+
+	if err := c.Get(ctx, types.NamespacedName{Name: serverClassName}, &serverClass); err != nil {
+		return nil, err
+	}
+
+	free, excluded, err := allocation.FreeServers(ctx, c, &serverClass, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = allocation.Order(ctx, c, &serverClass, metalMachine, free); err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		ServerClass: serverClass.Name,
+		Requested:   count,
+		Free:        len(free),
+		Spares:      serverClass.Spec.RemediationSpares,
+		Selected:    []string{},
+		Excluded:    excluded,
+	}
+
+	allocatable := len(free) - result.Spares
+	if allocatable < 0 {
+		allocatable = 0
+	}
+
+	if allocatable > count {
+		allocatable = count
+	}
+
+	for _, server := range free[:allocatable] {
+		result.Selected = append(result.Selected, server.Name)
+	}
+
+	result.Sufficient = allocatable == count
+
+	return result, nil
+}
+
+// NewHandler returns HTTP handler for the allocation preview API.
+//
+// Query parameters: serverclass, count, and optionally cluster (namespace/name) and controlplane (true/false)
+// to preview the SpreadByLabel strategy for the machines of the cluster.
+func NewHandler(c client.Reader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := r.URL.Query()
+
+		serverClass := values.Get("serverclass")
+		if serverClass == "" {
+			http.Error(w, "serverclass is required", http.StatusBadRequest)
+
+			return
+		}
+
+		count, err := strconv.Atoi(values.Get("count"))
+		if err != nil || count < 1 {
+			http.Error(w, fmt.Sprintf("count should be a positive number, got %q", values.Get("count")), http.StatusBadRequest)
+
+			return
+		}
+
+		var metalMachine *infrav1.MetalMachine
+
+		if cluster := values.Get("cluster"); cluster != "" {
+			parts := strings.SplitN(cluster, "/", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				http.Error(w, fmt.Sprintf("cluster should be namespace/name, got %q", cluster), http.StatusBadRequest)
+
+				return
+			}
+
+			metalMachine = &infrav1.MetalMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: parts[0],
+					Labels:    map[string]string{capiv1.ClusterLabelName: parts[1]},
+				},
+			}
+
+			if values.Get("controlplane") == "true" {
+				metalMachine.Labels[capiv1.MachineControlPlaneLabelName] = ""
+			}
+		}
+
+		result, err := Preview(r.Context(), c, serverClass, count, metalMachine)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				http.Error(w, fmt.Sprintf("serverclass %q not found", serverClass), http.StatusNotFound)
+
+				return
+			}
+
+			log.Printf("allocation preview failed: %s", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err = json.NewEncoder(w).Encode(result); err != nil {
+			log.Printf("failed to write allocation preview: %s", err)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package preview
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/pkg/allocation"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func testServer(name string, clean bool) *metalv1alpha1.Server {
+	return &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: metalv1alpha1.ServerSpec{
+			Accepted: true,
+		},
+		Status: metalv1alpha1.ServerStatus{
+			IsClean: clean,
+		},
+	}
+}
+
+func TestPreview(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	serverClass := &metalv1alpha1.ServerClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "workers",
+		},
+		Spec: metalv1alpha1.ServerClassSpec{
+			RemediationSpares: 1,
+		},
+		Status: metalv1alpha1.ServerClassStatus{
+			ServersAvailable: []string{"server-a", "server-b", "server-c", "server-d", "server-e"},
+			ServersInUse:     []string{"server-f"},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme,
+		serverClass,
+		testServer("server-a", true),
+		testServer("server-b", false),
+		testServer("server-c", true),
+		testServer("server-d", true),
+		testServer("server-e", true),
+		&infrav1.ServerBinding{ObjectMeta: metav1.ObjectMeta{Name: "server-e"}},
+	)
+
+	for _, tt := range []struct {
+		name       string
+		count      int
+		selected   []string
+		sufficient bool
+	}{
+		{
+			name:       "enough servers",
+			count:      2,
+			selected:   []string{"server-a", "server-c"},
+			sufficient: true,
+		},
+		{
+			name:       "spares are kept aside",
+			count:      3,
+			selected:   []string{"server-a", "server-c"},
+			sufficient: false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Preview(context.Background(), c, "workers", tt.count, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(result.Selected, tt.selected) {
+				t.Errorf("selected = %v, want %v", result.Selected, tt.selected)
+			}
+
+			if result.Sufficient != tt.sufficient {
+				t.Errorf("sufficient = %v, want %v", result.Sufficient, tt.sufficient)
+			}
+
+			expectedExcluded := []allocation.Exclusion{
+				{Server: "server-f", Reason: allocation.ReasonInUse},
+				{Server: "server-b", Reason: allocation.ReasonNotClean},
+				{Server: "server-e", Reason: allocation.ReasonBound},
+			}
+
+			if !reflect.DeepEqual(result.Excluded, expectedExcluded) {
+				t.Errorf("excluded = %v, want %v", result.Excluded, expectedExcluded)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewHandler(fake.NewFakeClientWithScheme(scheme, &metalv1alpha1.ServerClass{ObjectMeta: metav1.ObjectMeta{Name: "workers"}}))
+
+	for _, tt := range []struct {
+		query string
+		code  int
+	}{
+		{query: "serverclass=workers&count=1", code: http.StatusOK},
+		{query: "serverclass=workers&count=1&cluster=default/management&controlplane=true", code: http.StatusOK},
+		{query: "serverclass=workers&count=1&cluster=management", code: http.StatusBadRequest},
+		{query: "serverclass=workers&count=0", code: http.StatusBadRequest},
+		{query: "count=1", code: http.StatusBadRequest},
+		{query: "serverclass=missing&count=1", code: http.StatusNotFound},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/allocation-preview?"+tt.query, nil))
+
+			if w.Code != tt.code {
+				t.Errorf("code = %d, want %d: %s", w.Code, tt.code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package search implements the inventory search API.
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// Query is the set of Server attributes to search for.
+//
+// Empty attributes are ignored, all the other attributes should match.
+type Query struct {
+	UUID   string
+	MAC    string
+	BMC    string
+	Serial string
+}
+
+// Result describes the Server found by the search.
+type Result struct {
+	Name         string   `json:"name"`
+	Accepted     bool     `json:"accepted"`
+	InUse        bool     `json:"inUse"`
+	IsClean      bool     `json:"isClean"`
+	BMCEndpoint  string   `json:"bmcEndpoint,omitempty"`
+	SerialNumber string   `json:"serialNumber,omitempty"`
+	MACs         []string `json:"macs,omitempty"`
+}
+
+// Search finds the Servers matching the query.
+//
+// Servers are looked up via the field indexes (see ServerReconciler), the rest of the attributes are matched in place.
+func Search(ctx context.Context, c client.Reader, q Query) ([]Result, error) {
+	var (
+		servers []metalv1alpha1.Server
+		err     error
+	)
+
+	switch {
+	case q.UUID != "":
+		var server metalv1alpha1.Server
+
+		if err = c.Get(ctx, client.ObjectKey{Name: q.UUID}, &server); err != nil {
+			if apierrors.IsNotFound(err) {
+				return []Result{}, nil
+			}
+
+			return nil, err
+		}
+
+		servers = append(servers, server)
+	case q.MAC != "":
+		if servers, err = list(ctx, c, metalv1alpha1.ServerMACAddressField, q.MAC); err != nil {
+			return nil, err
+		}
+	case q.BMC != "":
+		if servers, err = list(ctx, c, metalv1alpha1.ServerBMCEndpointField, q.BMC); err != nil {
+			return nil, err
+		}
+	case q.Serial != "":
+		if servers, err = list(ctx, c, metalv1alpha1.ServerSerialNumberField, q.Serial); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("at least one search attribute is required")
+	}
+
+	results := []Result{}
+
+	for i := range servers {
+		if q.matches(&servers[i]) {
+			results = append(results, newResult(&servers[i]))
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	return results, nil
+}
+
+func list(ctx context.Context, c client.Reader, field, value string) ([]metalv1alpha1.Server, error) {
+	var serverList metalv1alpha1.ServerList
+
+	if err := c.List(ctx, &serverList, client.MatchingFields{field: value}); err != nil {
+		return nil, err
+	}
+
+	return serverList.Items, nil
+}
+
+func (q Query) matches(server *metalv1alpha1.Server) bool {
+	if q.UUID != "" && server.Name != q.UUID {
+		return false
+	}
+
+	if q.MAC != "" {
+		found := false
+
+		for _, iface := range server.Status.NetworkInterfaces {
+			if iface.MAC == q.MAC {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if q.BMC != "" && (server.Spec.BMC == nil || server.Spec.BMC.Endpoint != q.BMC) {
+		return false
+	}
+
+	if q.Serial != "" && (server.Spec.SystemInformation == nil || server.Spec.SystemInformation.SerialNumber != q.Serial) {
+		return false
+	}
+
+	return true
+}
+
+func newResult(server *metalv1alpha1.Server) Result {
+	result := Result{
+		Name:     server.Name,
+		Accepted: server.Spec.Accepted,
+		InUse:    server.Status.InUse,
+		IsClean:  server.Status.IsClean,
+	}
+
+	if server.Spec.BMC != nil {
+		result.BMCEndpoint = server.Spec.BMC.Endpoint
+	}
+
+	if server.Spec.SystemInformation != nil {
+		result.SerialNumber = server.Spec.SystemInformation.SerialNumber
+	}
+
+	for _, iface := range server.Status.NetworkInterfaces {
+		result.MACs = append(result.MACs, iface.MAC)
+	}
+
+	return result
+}
+
+// NewHandler returns HTTP handler for the search API.
+//
+// Query parameters: uuid, mac, bmc, serial.
+func NewHandler(c client.Reader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := r.URL.Query()
+
+		q := Query{
+			UUID:   values.Get("uuid"),
+			MAC:    values.Get("mac"),
+			BMC:    values.Get("bmc"),
+			Serial: values.Get("serial"),
+		}
+
+		if q.MAC != "" {
+			// MAC addresses are stored in the canonical form
+			mac, err := net.ParseMAC(q.MAC)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid MAC address: %s", err), http.StatusBadRequest)
+
+				return
+			}
+
+			q.MAC = mac.String()
+		}
+
+		if q == (Query{}) {
+			http.Error(w, "at least one of uuid, mac, bmc, serial is required", http.StatusBadRequest)
+
+			return
+		}
+
+		results, err := Search(r.Context(), c, q)
+		if err != nil {
+			log.Printf("search failed: %s", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err = json.NewEncoder(w).Encode(results); err != nil {
+			log.Printf("failed to write search results: %s", err)
+		}
+	})
+}
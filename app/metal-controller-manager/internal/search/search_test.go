@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func testServer(name, bmc, serial, mac string) *metalv1alpha1.Server {
+	return &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: metalv1alpha1.ServerSpec{
+			BMC: &metalv1alpha1.BMC{
+				Endpoint: bmc,
+			},
+			SystemInformation: &metalv1alpha1.SystemInformation{
+				SerialNumber: serial,
+			},
+		},
+		Status: metalv1alpha1.ServerStatus{
+			NetworkInterfaces: []metalv1alpha1.NetworkInterface{
+				{Name: "eth0", MAC: mac},
+			},
+		},
+	}
+}
+
+func TestSearch(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	// fake client ignores field selectors, so all the servers are matched in place
+	c := fake.NewFakeClientWithScheme(scheme,
+		testServer("server-a", "10.2.3.4", "SN1", "52:54:00:ab:cd:01"),
+		testServer("server-b", "10.2.3.5", "SN2", "52:54:00:ab:cd:02"),
+	)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{
+			name:  "uuid",
+			query: Query{UUID: "server-b"},
+			want:  []string{"server-b"},
+		},
+		{
+			name:  "unknown uuid",
+			query: Query{UUID: "server-c"},
+			want:  []string{},
+		},
+		{
+			name:  "bmc",
+			query: Query{BMC: "10.2.3.4"},
+			want:  []string{"server-a"},
+		},
+		{
+			name:  "mac",
+			query: Query{MAC: "52:54:00:ab:cd:02"},
+			want:  []string{"server-b"},
+		},
+		{
+			name:  "all attributes should match",
+			query: Query{MAC: "52:54:00:ab:cd:01", Serial: "SN2"},
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Search(context.Background(), c, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+
+			for _, result := range results {
+				got = append(got, result.Name)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package sensors implements Prometheus exporter of the BMC sensor readings.
+package sensors
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/ipmi"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/metal"
+)
+
+// pollConcurrency limits the number of BMCs polled at the same time.
+const pollConcurrency = 10
+
+var labels = []string{"server", "cluster", "sensor"}
+
+var (
+	temperatureDesc = prometheus.NewDesc("sidero_bmc_temperature_celsius", "BMC temperature sensor reading.", labels, nil)
+	fanDesc         = prometheus.NewDesc("sidero_bmc_fan_speed_rpm", "BMC fan speed sensor reading.", labels, nil)
+	powerDesc       = prometheus.NewDesc("sidero_bmc_power_watts", "BMC power sensor reading.", labels, nil)
+	voltageDesc     = prometheus.NewDesc("sidero_bmc_voltage_volts", "BMC voltage sensor reading.", labels, nil)
+	sensorDesc      = prometheus.NewDesc("sidero_bmc_sensor_value", "BMC sensor reading in other units.", append(labels, "unit"), nil)
+	upDesc          = prometheus.NewDesc("sidero_bmc_up", "Whether the BMC sensors were read successfully.", []string{"server", "cluster"}, nil)
+)
+
+type serverReadings struct {
+	cluster string
+	sensors []ipmi.Sensor
+	up      bool
+}
+
+// Exporter polls the BMC sensors of the servers, and publishes the readings as Prometheus metrics.
+//
+// BMCs are polled in the background, as reading the sensors takes several seconds per server,
+// scrapes return the last readings.
+type Exporter struct {
+	Client client.Client
+	// APIReader reads the BMC proxy credentials, so that the secrets are not cached.
+	APIReader client.Reader
+	Log       logr.Logger
+	Interval  time.Duration
+
+	mu       sync.Mutex
+	readings map[string]serverReadings
+}
+
+// Start implements manager.Runnable.
+func (e *Exporter) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		e.poll(context.Background())
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Exporter) poll(ctx context.Context) {
+	var servers metalv1alpha1.ServerList
+
+	if err := e.Client.List(ctx, &servers); err != nil {
+		e.Log.Error(err, "failed to list servers")
+
+		return
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		sem      = make(chan struct{}, pollConcurrency)
+		readings = make(map[string]serverReadings, len(servers.Items))
+	)
+
+	for i := range servers.Items {
+		server := &servers.Items[i]
+
+		if server.Spec.BMC == nil || server.Spec.BMC.Interface == metalv1alpha1.BMCInterfaceRedfish {
+			// sensors are only read over IPMI
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			r := e.read(ctx, server)
+
+			mu.Lock()
+			readings[server.Name] = r
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	e.mu.Lock()
+	e.readings = readings
+	e.mu.Unlock()
+}
+
+func (e *Exporter) read(ctx context.Context, server *metalv1alpha1.Server) serverReadings {
+	var r serverReadings
+
+	var serverBinding infrav1.ServerBinding
+
+	if err := e.Client.Get(ctx, types.NamespacedName{Name: server.Name}, &serverBinding); err == nil {
+		r.cluster = serverBinding.Labels[capiv1.ClusterLabelName]
+	}
+
+	bmcProxy, err := metal.LoadProxy(ctx, e.APIReader, server)
+	if err != nil {
+		e.Log.Error(err, "failed to load BMC proxy", "server", server.Name)
+
+		return r
+	}
+
+	// vendor quirks only adjust the power sequences
+	ipmiClient, err := ipmi.NewClient(*server.Spec.BMC, bmcProxy, ipmi.Quirks{})
+	if err != nil {
+		e.Log.Error(err, "failed to create IPMI client", "server", server.Name)
+
+		return r
+	}
+
+	r.sensors, err = ipmiClient.Sensors()
+	if err != nil {
+		e.Log.Info("failed to read BMC sensors", "server", server.Name, "error", err.Error())
+
+		return r
+	}
+
+	r.up = true
+
+	return r
+}
+
+// Describe implements prometheus.Collector.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{temperatureDesc, fanDesc, powerDesc, voltageDesc, sensorDesc, upDesc} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for server, r := range e.readings {
+		up := 0.0
+		if r.up {
+			up = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, up, server, r.cluster)
+
+		seen := make(map[string]struct{}, len(r.sensors))
+
+		for _, sensor := range r.sensors {
+			// some BMCs report several sensors with the same name, only the first one is exported
+			if _, ok := seen[sensor.Name]; ok {
+				continue
+			}
+
+			seen[sensor.Name] = struct{}{}
+
+			switch strings.ToLower(sensor.Unit) {
+			case "degrees c":
+				ch <- prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, sensor.Value, server, r.cluster, sensor.Name)
+			case "rpm":
+				ch <- prometheus.MustNewConstMetric(fanDesc, prometheus.GaugeValue, sensor.Value, server, r.cluster, sensor.Name)
+			case "watts":
+				ch <- prometheus.MustNewConstMetric(powerDesc, prometheus.GaugeValue, sensor.Value, server, r.cluster, sensor.Name)
+			case "volts":
+				ch <- prometheus.MustNewConstMetric(voltageDesc, prometheus.GaugeValue, sensor.Value, server, r.cluster, sensor.Name)
+			default:
+				ch <- prometheus.MustNewConstMetric(sensorDesc, prometheus.GaugeValue, sensor.Value, server, r.cluster, sensor.Name, sensor.Unit)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package server
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// agentLogsPrefix is the prefix of the agent logs ConfigMap names, see AgentLogsConfigMapName.
+const agentLogsPrefix = "agent-logs-"
+
+// AgentLogsCollector removes the agent logs of the servers which don't exist.
+//
+// Agent logs ConfigMaps are owned by the servers, so they are removed along with the servers,
+// but the logs of the servers which never registered (e.g. failed before CreateServer, or were rejected) have no owner.
+type AgentLogsCollector struct {
+	Client controllerclient.Client
+	// APIReader lists the ConfigMaps, so that the ConfigMaps are not cached.
+	APIReader controllerclient.Reader
+	Log       logr.Logger
+	Namespace string
+	// Retention is the minimum age of the orphaned agent logs before they are removed.
+	Retention time.Duration
+}
+
+// Start implements manager.Runnable.
+func (c *AgentLogsCollector) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(c.Retention)
+	defer ticker.Stop()
+
+	for {
+		c.collect(context.Background())
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *AgentLogsCollector) collect(ctx context.Context) {
+	var configMaps corev1.ConfigMapList
+
+	if err := c.APIReader.List(ctx, &configMaps, controllerclient.InNamespace(c.Namespace)); err != nil {
+		c.Log.Error(err, "failed to list agent logs")
+
+		return
+	}
+
+	for i := range configMaps.Items {
+		cm := &configMaps.Items[i]
+
+		if !strings.HasPrefix(cm.Name, agentLogsPrefix) || len(cm.OwnerReferences) > 0 || time.Since(cm.CreationTimestamp.Time) < c.Retention {
+			continue
+		}
+
+		err := c.Client.Get(ctx, types.NamespacedName{Name: strings.TrimPrefix(cm.Name, agentLogsPrefix)}, &metalv1alpha1.Server{})
+		if err == nil || !apierrors.IsNotFound(err) {
+			// server registered since, the owner is set on the next logs report
+			continue
+		}
+
+		if err = c.Client.Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+			c.Log.Error(err, "failed to remove agent logs", "configmap", cm.Name)
+
+			continue
+		}
+
+		c.Log.Info("removed agent logs of unknown server", "configmap", cm.Name)
+	}
+}
@@ -0,0 +1,99 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package server
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestAgentLogsCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		corev1.AddToScheme,
+		metalv1alpha1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	recent := metav1.NewTime(time.Now().Add(-time.Minute))
+
+	configMap := func(namespace, name string, created metav1.Time, owned bool) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         namespace,
+				Name:              name,
+				CreationTimestamp: created,
+			},
+		}
+
+		if owned {
+			cm.OwnerReferences = []metav1.OwnerReference{{APIVersion: metalv1alpha1.GroupVersion.String(), Kind: "Server", Name: "owner"}}
+		}
+
+		return cm
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme,
+		&metalv1alpha1.Server{ObjectMeta: metav1.ObjectMeta{Name: "registered"}},
+		configMap("sidero-system", AgentLogsConfigMapName("unknown"), old, false),
+		configMap("sidero-system", AgentLogsConfigMapName("recent"), recent, false),
+		configMap("sidero-system", AgentLogsConfigMapName("owned"), old, true),
+		configMap("sidero-system", AgentLogsConfigMapName("registered"), old, false),
+		configMap("sidero-system", "unrelated", old, false),
+		configMap("default", AgentLogsConfigMapName("other-namespace"), old, false),
+	)
+
+	collector := &AgentLogsCollector{
+		Client:    c,
+		APIReader: c,
+		Log:       log.NullLogger{},
+		Namespace: "sidero-system",
+		Retention: time.Hour,
+	}
+
+	collector.collect(context.Background())
+
+	var configMaps corev1.ConfigMapList
+
+	if err := c.List(context.Background(), &configMaps); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(configMaps.Items))
+
+	for _, cm := range configMaps.Items {
+		names = append(names, cm.Namespace+"/"+cm.Name)
+	}
+
+	sort.Strings(names)
+
+	expected := []string{
+		"default/" + AgentLogsConfigMapName("other-namespace"),
+		"sidero-system/" + AgentLogsConfigMapName("owned"),
+		"sidero-system/" + AgentLogsConfigMapName("recent"),
+		"sidero-system/" + AgentLogsConfigMapName("registered"),
+		"sidero-system/unrelated",
+	}
+
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("remaining configmaps %v, want %v", names, expected)
+	}
+}
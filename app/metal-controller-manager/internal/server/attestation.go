@@ -0,0 +1,172 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package server
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
+)
+
+// AttestationKeyName is the key of the attestation Secret holding the PEM encoded (PKCS #8) Ed25519 private key.
+const AttestationKeyName = "attestation.key"
+
+// attestationPayload is the signed document of the wipe attestation.
+type attestationPayload struct {
+	Server    string                   `json:"server"`
+	Timestamp time.Time                `json:"timestamp"`
+	Disks     []metalv1alpha1.DiskWipe `json:"disks"`
+}
+
+// wipeAttestation converts the wipe reported by the agent into the unsigned attestation.
+//
+// Agents which don't report the wiped disks don't produce the attestation.
+func wipeAttestation(in *api.MarkServerAsWipedRequest, now time.Time) (*metalv1alpha1.WipeAttestation, error) {
+	if len(in.GetDisk()) == 0 {
+		return nil, nil
+	}
+
+	timestamp := now
+	if in.GetTimestamp() > 0 {
+		timestamp = time.Unix(in.GetTimestamp(), 0)
+	}
+
+	payload := attestationPayload{
+		Server:    in.GetUuid(),
+		Timestamp: timestamp.UTC(),
+		Disks:     make([]metalv1alpha1.DiskWipe, 0, len(in.GetDisk())),
+	}
+
+	for _, disk := range in.GetDisk() {
+		payload.Disks = append(payload.Disks, metalv1alpha1.DiskWipe{
+			Name:       disk.GetName(),
+			Serial:     disk.GetSerial(),
+			Partition:  disk.GetPartition(),
+			Method:     disk.GetMethod(),
+			SampleHash: disk.GetSampleHash(),
+			Samples:    int(disk.GetSamples()),
+			Zeroed:     disk.GetZeroed(),
+		})
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &metalv1alpha1.WipeAttestation{
+		Timestamp: v1.NewTime(payload.Timestamp),
+		Disks:     payload.Disks,
+		Payload:   data,
+	}, nil
+}
+
+// signAttestation signs the attestation payload with the key.
+func signAttestation(attestation *metalv1alpha1.WipeAttestation, key ed25519.PrivateKey) error {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return err
+	}
+
+	fingerprint := sha256.Sum256(der)
+
+	attestation.Signature = ed25519.Sign(key, attestation.Payload)
+	attestation.KeyID = hex.EncodeToString(fingerprint[:])
+
+	return nil
+}
+
+// NewAgentToken returns the random one-time token the agent booted for the server authenticates its wipe report with,
+// and the hash of the token to be recorded in the AgentTokenHash field of the Server status.
+//
+// The token is handed out to the agent via the kernel arguments of the agent environment (see constants.AgentTokenArg),
+// only the hash is stored, so the token can't be read back from the Server.
+func NewAgentToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	token = hex.EncodeToString(buf)
+
+	return token, agentTokenHash(token), nil
+}
+
+func agentTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// agentAuthenticated checks whether the request carries the token with the hash issued to the agent of the server.
+func agentAuthenticated(ctx context.Context, hash string) bool {
+	if hash == "" {
+		return false
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+
+	tokens := md.Get(constants.AgentTokenMetadataKey)
+	if len(tokens) != 1 {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(agentTokenHash(tokens[0])), []byte(hash)) == 1
+}
+
+// LoadAttestationKey reads the signing key from the attestation Secret.
+//
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+func LoadAttestationKey(ctx context.Context, r client.Reader, name types.NamespacedName) (ed25519.PrivateKey, error) {
+	var secret corev1.Secret
+
+	if err := r.Get(ctx, name, &secret); err != nil {
+		return nil, err
+	}
+
+	return parseAttestationKey(secret.Data[AttestationKeyName])
+}
+
+func parseAttestationKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", AttestationKeyName)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ed25519Key, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("attestation key is not an Ed25519 key")
+	}
+
+	return ed25519Key, nil
+}
@@ -0,0 +1,110 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package server
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// gpuVendors maps the PCI vendor IDs of the GPU vendors to the names.
+var gpuVendors = map[string]string{
+	"10de": "NVIDIA",
+	"1002": "AMD",
+	"8086": "Intel",
+}
+
+// onboardVGAVendors lists the PCI vendor IDs of the onboard BMC graphics, which are not GPUs.
+var onboardVGAVendors = map[string]struct{}{
+	"1a03": {}, // ASPEED
+	"102b": {}, // Matrox
+}
+
+type gpuModel struct {
+	name string
+	vram string
+}
+
+// gpuModels maps the PCI vendor and device IDs of the datacenter GPUs to the models.
+//
+// The agent runs without the GPU drivers, so the model name and the memory can't be read from the device.
+var gpuModels = map[string]gpuModel{
+	"10de:1db1": {"V100-SXM2-16GB", "16Gi"},
+	"10de:1db4": {"V100-PCIE-16GB", "16Gi"},
+	"10de:1db5": {"V100-SXM2-32GB", "32Gi"},
+	"10de:1db6": {"V100-PCIE-32GB", "32Gi"},
+	"10de:1eb8": {"T4", "16Gi"},
+	"10de:20b0": {"A100-SXM4-40GB", "40Gi"},
+	"10de:20b2": {"A100-SXM4-80GB", "80Gi"},
+	"10de:20b5": {"A100-PCIE-80GB", "80Gi"},
+	"10de:20f1": {"A100-PCIE-40GB", "40Gi"},
+	"10de:20b7": {"A30", "24Gi"},
+	"10de:2235": {"A40", "48Gi"},
+	"10de:2236": {"A10", "24Gi"},
+	"10de:2330": {"H100-SXM5-80GB", "80Gi"},
+	"10de:2331": {"H100-PCIE-80GB", "80Gi"},
+	"10de:27b8": {"L4", "24Gi"},
+	"1002:738c": {"MI100", "32Gi"},
+}
+
+// gpus groups the GPUs among the PCI devices by the model.
+func gpus(devices []metalv1alpha1.PCIDevice) []metalv1alpha1.GPUInformation {
+	var result []metalv1alpha1.GPUInformation
+
+	index := map[string]int{}
+
+	for i := range devices {
+		device := &devices[i]
+
+		if device.Type != metalv1alpha1.PCIDeviceTypeGPU {
+			continue
+		}
+
+		if _, ok := onboardVGAVendors[device.VendorID]; ok {
+			continue
+		}
+
+		key := device.VendorID + ":" + device.DeviceID
+
+		if i, ok := index[key]; ok {
+			result[i].Count++
+
+			continue
+		}
+
+		gpu := metalv1alpha1.GPUInformation{
+			Vendor: device.VendorID,
+			Model:  device.DeviceID,
+			Count:  1,
+		}
+
+		if vendor, ok := gpuVendors[device.VendorID]; ok {
+			gpu.Vendor = vendor
+		}
+
+		if model, ok := gpuModels[key]; ok {
+			vram := resource.MustParse(model.vram)
+
+			gpu.Model = model.name
+			gpu.VRAM = &vram
+		}
+
+		index[key] = len(result)
+		result = append(result, gpu)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Vendor != result[j].Vendor {
+			return result[i].Vendor < result[j].Vendor
+		}
+
+		return result[i].Model < result[j].Model
+	})
+
+	return result
+}
@@ -6,27 +6,36 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/tools/reference"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/health"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
 )
 
 const (
@@ -36,13 +45,20 @@ const (
 type server struct {
 	api.UnimplementedAgentServer
 
-	autoAccept   bool
-	insecureWipe bool
+	autoAccept      bool
+	insecureWipe    bool
+	requireApproval bool
 
 	c             controllerclient.Client
 	scheme        *runtime.Scheme
 	recorder      record.EventRecorder
 	rebootTimeout time.Duration
+	maxClockSkew  time.Duration
+	pxeMode       metalv1alpha1.PXEMode
+
+	apiReader          controllerclient.Reader
+	agentLogsNamespace string
+	attestationSecret  types.NamespacedName
 }
 
 // CreateServer implements api.AgentServer.
@@ -94,21 +110,374 @@ func (s *server) CreateServer(ctx context.Context, in *api.CreateServerRequest)
 		log.Printf("Added %s", in.GetSystemInformation().GetUuid())
 	}
 
+	interfaces := networkInterfaces(in.GetNetworkInterface())
+	devices := pciDevices(in.GetPciDevice())
+	reportedDisks := disks(in.GetDisk())
+	reportedMemory := memory(in.GetMemory())
+
+	labeled := obj.DeepCopy()
+	UpdateProvisioningNetworkLabels(labeled, in.GetProvisioningAddress(), in.GetProvisioningGateway())
+	UpdateHardwareLabels(labeled)
+
+	// inventory time is recorded on every registration, so the server is always patched
+	patchHelper, err := patch.NewHelper(obj, s.c)
+	if err != nil {
+		return nil, err
+	}
+
+	now := v1.Now()
+
+	obj.Labels = labeled.Labels
+
+	// counts are refreshed on every registration, as the servers registered by the older agents don't have them
+	if obj.Spec.CPU != nil {
+		obj.Spec.CPU.Cores = int(in.GetCpu().GetCores())
+		obj.Spec.CPU.Threads = int(in.GetCpu().GetThreads())
+		obj.Spec.CPU.FrequencyMHz = int(in.GetCpu().GetFrequencyMhz())
+	}
+
+	obj.Spec.GPUs = gpus(devices)
+
+	obj.Status.NetworkInterfaces = interfaces
+	obj.Status.PCIDevices = devices
+	obj.Status.Disks = reportedDisks
+	obj.Status.Memory = reportedMemory
+	obj.Status.LastInventoryTime = &now
+
+	if err = patchHelper.Patch(ctx, obj); err != nil {
+		return nil, err
+	}
+
+	duplicates, err := s.duplicateMACServers(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
 	resp := &api.CreateServerResponse{}
 
+	if len(duplicates) > 0 {
+		// server controller flags the server with the DuplicateMAC condition
+		log.Printf("Server %q shares MAC addresses with servers %q, skipping wipe", obj.Name, duplicates)
+
+		return resp, nil
+	}
+
+	pxeMode := obj.Spec.PXEMode
+	if pxeMode == "" {
+		pxeMode = s.pxeMode
+	}
+
+	if pxeMode == metalv1alpha1.PXEModeOneShot && obj.Spec.Accepted {
+		// server boots from disk by default, so make sure it PXE boots next time to get provisioned
+		resp.SetBootNext = true
+	}
+
 	// Only return a wipe directive is the server is not clean *AND* it has been accepted.
 	// This avoids the possibility of a random device PXE booting against us, registering, then getting blown away.
 	if !obj.Status.IsClean && obj.Spec.Accepted {
+		if s.requireApproval && !obj.WipeApproved() {
+			// server controller requests the approval
+			log.Printf("Server %q needs wipe, waiting for the approval", obj.Name)
+
+			return resp, nil
+		}
+
 		log.Printf("Server %q needs wipe", obj.Name)
 
 		resp.Wipe = true
 		resp.InsecureWipe = s.insecureWipe
 		resp.RebootTimeout = s.rebootTimeout.Seconds()
+		resp.WipeScope = string(obj.Status.PendingWipeScope)
+		resp.VerifyDisk = verifyDisks(obj)
 	}
 
 	return resp, nil
 }
 
+// verifyDisks returns the disks of the last wipe attestation the agent compares the disks with in the Verify wipe scope.
+func verifyDisks(obj *metalv1alpha1.Server) []*api.DiskWipe {
+	if obj.Status.PendingWipeScope != metalv1alpha1.WipeScopeVerify || obj.Status.WipeAttestation == nil {
+		return nil
+	}
+
+	var disks []*api.DiskWipe
+
+	for _, disk := range obj.Status.WipeAttestation.Disks {
+		if disk.Partition != "" || disk.SampleHash == "" {
+			continue
+		}
+
+		disks = append(disks, &api.DiskWipe{
+			Name:       disk.Name,
+			Serial:     disk.Serial,
+			SampleHash: disk.SampleHash,
+			Samples:    uint32(disk.Samples),
+		})
+	}
+
+	return disks
+}
+
+// duplicateMACServers returns the names of other servers sharing MAC addresses with the server.
+func (s *server) duplicateMACServers(ctx context.Context, obj *metalv1alpha1.Server) ([]string, error) {
+	var duplicates []string
+
+	for _, iface := range obj.Status.NetworkInterfaces {
+		var serverList metalv1alpha1.ServerList
+
+		if err := s.c.List(ctx, &serverList, controllerclient.MatchingFields{metalv1alpha1.ServerMACAddressField: iface.MAC}); err != nil {
+			return nil, err
+		}
+
+		for _, server := range serverList.Items {
+			if server.Name != obj.Name {
+				duplicates = append(duplicates, server.Name)
+			}
+		}
+	}
+
+	return duplicates, nil
+}
+
+// networkInterfaces converts the interfaces reported by the agent, sorted by name.
+func networkInterfaces(in []*api.NetworkInterface) []metalv1alpha1.NetworkInterface {
+	var interfaces []metalv1alpha1.NetworkInterface
+
+	for _, iface := range in {
+		interfaces = append(interfaces, metalv1alpha1.NetworkInterface{
+			Name:     iface.GetName(),
+			MAC:      strings.ToLower(iface.GetMac()),
+			Speed:    int(iface.GetSpeed()),
+			Driver:   iface.GetDriver(),
+			VendorID: strings.ToLower(strings.TrimPrefix(iface.GetVendorId(), "0x")),
+			DeviceID: strings.ToLower(strings.TrimPrefix(iface.GetDeviceId(), "0x")),
+		})
+	}
+
+	sort.Slice(interfaces, func(i, j int) bool {
+		return interfaces[i].Name < interfaces[j].Name
+	})
+
+	return interfaces
+}
+
+// pciDevices converts the PCI devices reported by the agent, sorted by address.
+func pciDevices(in []*api.PCIDevice) []metalv1alpha1.PCIDevice {
+	var devices []metalv1alpha1.PCIDevice
+
+	for _, device := range in {
+		class := strings.ToLower(strings.TrimPrefix(device.GetClass(), "0x"))
+
+		devices = append(devices, metalv1alpha1.PCIDevice{
+			Address: device.GetAddress(),
+			PCIDeviceInformation: metalv1alpha1.PCIDeviceInformation{
+				Type:              pciDeviceType(class),
+				Class:             class,
+				VendorID:          strings.ToLower(strings.TrimPrefix(device.GetVendorId(), "0x")),
+				DeviceID:          strings.ToLower(strings.TrimPrefix(device.GetDeviceId(), "0x")),
+				SubsystemVendorID: strings.ToLower(strings.TrimPrefix(device.GetSubsystemVendorId(), "0x")),
+				SubsystemDeviceID: strings.ToLower(strings.TrimPrefix(device.GetSubsystemDeviceId(), "0x")),
+				Driver:            device.GetDriver(),
+				Model:             device.GetModel(),
+			},
+			Namespaces: int(device.GetNamespaces()),
+		})
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Address < devices[j].Address
+	})
+
+	return devices
+}
+
+// disks converts the disks reported by the agent, sorted by name.
+func disks(in []*api.Disk) []metalv1alpha1.Disk {
+	var disks []metalv1alpha1.Disk
+
+	for _, disk := range in {
+		disks = append(disks, metalv1alpha1.Disk{
+			Name:       disk.GetName(),
+			Size:       disk.GetSize(),
+			Model:      disk.GetModel(),
+			Serial:     disk.GetSerial(),
+			WWID:       disk.GetWwid(),
+			Rotational: disk.GetRotational(),
+		})
+	}
+
+	sort.Slice(disks, func(i, j int) bool {
+		return disks[i].Name < disks[j].Name
+	})
+
+	return disks
+}
+
+// memory converts the memory reported by the agent, memory is unknown if no modules were reported.
+func memory(in *api.Memory) *metalv1alpha1.MemoryInformation {
+	if in.GetModules() == 0 {
+		return nil
+	}
+
+	return &metalv1alpha1.MemoryInformation{
+		Size:    in.GetSize(),
+		Modules: int(in.GetModules()),
+	}
+}
+
+// pciDeviceType maps the PCI class code to the device type.
+func pciDeviceType(class string) string {
+	switch {
+	case strings.HasPrefix(class, "0108"):
+		return metalv1alpha1.PCIDeviceTypeNVMe
+	case strings.HasPrefix(class, "0107"):
+		return metalv1alpha1.PCIDeviceTypeSAS
+	case strings.HasPrefix(class, "0104"):
+		return metalv1alpha1.PCIDeviceTypeRAID
+	case strings.HasPrefix(class, "0c04"):
+		return metalv1alpha1.PCIDeviceTypeFibreChannel
+	case strings.HasPrefix(class, "02"):
+		return metalv1alpha1.PCIDeviceTypeNetwork
+	case strings.HasPrefix(class, "03"):
+		return metalv1alpha1.PCIDeviceTypeGPU
+	case strings.HasPrefix(class, "0b40"), strings.HasPrefix(class, "12"):
+		// co-processors and processing accelerators (FPGAs, AI accelerators)
+		return metalv1alpha1.PCIDeviceTypeAccelerator
+	default:
+		return metalv1alpha1.PCIDeviceTypeOther
+	}
+}
+
+// UpdateProvisioningNetworkLabels sets the provisioning network labels of the server from the IPv4 address (in CIDR notation)
+// and the default gateway the server got via DHCP.
+//
+// It returns true if the labels were changed.
+func UpdateProvisioningNetworkLabels(obj *metalv1alpha1.Server, address, gateway string) bool {
+	ip, network, err := net.ParseCIDR(address)
+	if err != nil || ip.To4() == nil {
+		return false
+	}
+
+	labels := map[string]string{
+		metalv1alpha1.ProvisioningSubnetLabel:  strings.ReplaceAll(network.String(), "/", "-"),
+		metalv1alpha1.ProvisioningGatewayLabel: "",
+	}
+
+	if gw := net.ParseIP(gateway); gw != nil && gw.To4() != nil && !gw.IsUnspecified() {
+		labels[metalv1alpha1.ProvisioningGatewayLabel] = gw.String()
+	}
+
+	changed := false
+
+	for key, value := range labels {
+		current, ok := obj.Labels[key]
+
+		switch {
+		case value == "" && ok:
+			delete(obj.Labels, key)
+		case value != "" && current != value:
+			if obj.Labels == nil {
+				obj.Labels = map[string]string{}
+			}
+
+			obj.Labels[key] = value
+		default:
+			continue
+		}
+
+		changed = true
+	}
+
+	return changed
+}
+
+// UpdateHardwareLabels sets the hardware labels of the server from its SystemInformation and CPU,
+// labels of the unknown attributes are removed.
+//
+// It returns true if the labels were changed.
+func UpdateHardwareLabels(obj *metalv1alpha1.Server) bool {
+	labels := map[string]string{
+		metalv1alpha1.ManufacturerLabel:    "",
+		metalv1alpha1.ProductLabel:         "",
+		metalv1alpha1.FamilyLabel:          "",
+		metalv1alpha1.CPUManufacturerLabel: "",
+		metalv1alpha1.CPUModelLabel:        "",
+	}
+
+	if info := obj.Spec.SystemInformation; info != nil {
+		labels[metalv1alpha1.ManufacturerLabel] = labelValue(info.Manufacturer)
+		labels[metalv1alpha1.ProductLabel] = labelValue(info.ProductName)
+		labels[metalv1alpha1.FamilyLabel] = labelValue(info.Family)
+	}
+
+	if cpu := obj.Spec.CPU; cpu != nil {
+		labels[metalv1alpha1.CPUManufacturerLabel] = labelValue(cpu.Manufacturer)
+		labels[metalv1alpha1.CPUModelLabel] = labelValue(cpu.Version)
+	}
+
+	changed := false
+
+	for key, value := range labels {
+		current, ok := obj.Labels[key]
+
+		switch {
+		case value == "" && ok:
+			delete(obj.Labels, key)
+		case value != "" && current != value:
+			if obj.Labels == nil {
+				obj.Labels = map[string]string{}
+			}
+
+			obj.Labels[key] = value
+		default:
+			continue
+		}
+
+		changed = true
+	}
+
+	return changed
+}
+
+// labelValue converts the hardware attribute to the label value: invalid characters are replaced with "-",
+// and the value is truncated to 63 characters.
+func labelValue(s string) string {
+	var sb strings.Builder
+
+	dash := false
+
+	for _, r := range strings.TrimSpace(s) {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			sb.WriteRune(r)
+
+			dash = false
+
+			continue
+		}
+
+		// collapse runs of invalid characters, e.g. "Intel(R) Xeon(R)" becomes "Intel-R-Xeon-R"
+		if !dash {
+			sb.WriteByte('-')
+
+			dash = true
+		}
+	}
+
+	trim := func(v string) string {
+		return strings.TrimFunc(v, func(r rune) bool {
+			return r == '.' || r == '_' || r == '-'
+		})
+	}
+
+	value := trim(sb.String())
+
+	if len(value) > validation.LabelValueMaxLength {
+		value = trim(value[:validation.LabelValueMaxLength])
+	}
+
+	return value
+}
+
 // MarkServerAsWiped implements api.AgentServer.
 func (s *server) MarkServerAsWiped(ctx context.Context, in *api.MarkServerAsWipedRequest) (*api.MarkServerAsWipedResponse, error) {
 	obj := &metalv1alpha1.Server{}
@@ -122,21 +491,54 @@ func (s *server) MarkServerAsWiped(ctx context.Context, in *api.MarkServerAsWipe
 		return nil, err
 	}
 
+	attestation, err := wipeAttestation(in, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := reference.GetReference(s.scheme, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if attestation != nil && s.attestationSecret.Name != "" {
+		// the wipe is not blocked by the signing failure, unsigned attestation is still recorded
+		key, err := LoadAttestationKey(ctx, s.apiReader, s.attestationSecret)
+		if err == nil && !agentAuthenticated(ctx, obj.Status.AgentTokenHash) {
+			// only the reports of the agents booted by Sidero for the server are signed
+			err = errors.New("agent token is missing, invalid or already used")
+		}
+
+		if err == nil {
+			err = signAttestation(attestation, key)
+		}
+
+		if err != nil {
+			s.recorder.Event(ref, corev1.EventTypeWarning, "Wipe Attestation", fmt.Sprintf("Failed to sign wipe attestation: %s.", err))
+		}
+	}
+
 	obj.Status.IsClean = true
+	obj.Status.PendingWipeScope = ""
+	// the token is single use, the next agent boot issues a new one
+	obj.Status.AgentTokenHash = ""
+
+	if attestation != nil {
+		obj.Status.WipeAttestation = attestation
+	}
 
 	conditions.MarkTrue(obj, metalv1alpha1.ConditionPowerCycle)
 
+	// remove the condition in case it was already set to make sure LastTransitionTime will be updated
+	conditions.Delete(obj, metalv1alpha1.ConditionWiped)
+	conditions.MarkTrue(obj, metalv1alpha1.ConditionWiped)
+
 	if err := patchHelper.Patch(ctx, obj, patch.WithOwnedConditions{
-		Conditions: []clusterv1.ConditionType{metalv1alpha1.ConditionPowerCycle},
+		Conditions: []clusterv1.ConditionType{metalv1alpha1.ConditionPowerCycle, metalv1alpha1.ConditionWiped},
 	}); err != nil {
 		return nil, err
 	}
 
-	ref, err := reference.GetReference(s.scheme, obj)
-	if err != nil {
-		return nil, err
-	}
-
 	s.recorder.Event(ref, corev1.EventTypeNormal, "Server Wipe", "Server wiped via agent.")
 
 	resp := &api.MarkServerAsWipedResponse{}
@@ -157,75 +559,119 @@ func (s *server) ReconcileServerAddresses(ctx context.Context, in *api.Reconcile
 		return nil, err
 	}
 
-	if func() bool {
-		oldIPs := make(map[corev1.NodeAddressType]map[string]struct{})
-		for _, addr := range obj.Status.Addresses {
-			if oldIPs[addr.Type] == nil {
-				oldIPs[addr.Type] = make(map[string]struct{})
-			}
-			oldIPs[addr.Type][addr.Address] = struct{}{}
+	addresses := mergeAddresses(obj.Status.Addresses, in.GetAddress())
+
+	if !reflect.DeepEqual(obj.Status.Addresses, addresses) {
+		obj.Status.Addresses = addresses
+
+		if err := patchHelper.Patch(ctx, obj); err != nil {
+			return nil, err
 		}
+	}
 
-		newIPs := make(map[corev1.NodeAddressType]map[string]struct{})
-		for _, addr := range in.GetAddress() {
-			if newIPs[corev1.NodeAddressType(addr.GetType())] == nil {
-				newIPs[corev1.NodeAddressType(addr.GetType())] = make(map[string]struct{})
-			}
-			newIPs[corev1.NodeAddressType(addr.GetType())][addr.GetAddress()] = struct{}{}
+	resp := &api.ReconcileServerAddressesResponse{}
+
+	return resp, nil
+}
+
+// mergeAddresses overwrites the current addresses of the types reported by the agent.
+//
+// Addresses of the types which were not reported are kept. Resulting list is sorted,
+// so that the same set of addresses always results in the same status.
+func mergeAddresses(current []corev1.NodeAddress, reported []*api.Address) []corev1.NodeAddress {
+	reportedTypes := make(map[corev1.NodeAddressType]struct{})
+	seen := make(map[corev1.NodeAddress]struct{})
+
+	var addresses []corev1.NodeAddress
+
+	for _, addr := range reported {
+		address := corev1.NodeAddress{
+			Type:    corev1.NodeAddressType(addr.GetType()),
+			Address: addr.GetAddress(),
 		}
 
-		changed := false
+		reportedTypes[address.Type] = struct{}{}
 
-		for typ := range newIPs {
-			if oldIPs[typ] == nil {
-				changed = true
-				break
-			}
+		if _, ok := seen[address]; ok {
+			continue
+		}
 
-			if !reflect.DeepEqual(newIPs[typ], oldIPs[typ]) {
-				changed = true
-				break
-			}
+		seen[address] = struct{}{}
+
+		addresses = append(addresses, address)
+	}
+
+	for _, address := range current {
+		if _, ok := reportedTypes[address.Type]; ok {
+			continue
 		}
 
-		if !changed {
-			return false
+		if _, ok := seen[address]; ok {
+			continue
 		}
 
-		obj.Status.Addresses = nil
+		seen[address] = struct{}{}
 
-		// overwrite any types submitted from the agent
-		for typ := range newIPs {
-			for addr := range newIPs[typ] {
-				obj.Status.Addresses = append(obj.Status.Addresses, corev1.NodeAddress{
-					Type:    typ,
-					Address: addr,
-				})
-			}
+		addresses = append(addresses, address)
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		if addresses[i].Type != addresses[j].Type {
+			return addresses[i].Type < addresses[j].Type
 		}
 
-		// keep any types which were not sent by the agent
-		for typ := range oldIPs {
-			if newIPs[typ] != nil {
-				continue
-			}
+		return addresses[i].Address < addresses[j].Address
+	})
 
-			for addr := range oldIPs[typ] {
-				obj.Status.Addresses = append(obj.Status.Addresses, corev1.NodeAddress{
-					Type:    typ,
-					Address: addr,
-				})
-			}
+	return addresses
+}
+
+// ReconcileServerLLDPNeighbors implements api.AgentServer.
+func (s *server) ReconcileServerLLDPNeighbors(ctx context.Context, in *api.ReconcileServerLLDPNeighborsRequest) (*api.ReconcileServerLLDPNeighborsResponse, error) {
+	obj := &metalv1alpha1.Server{}
+
+	if err := s.c.Get(ctx, types.NamespacedName{Name: in.GetUuid()}, obj); err != nil {
+		return nil, err
+	}
+
+	patchHelper, err := patch.NewHelper(obj, s.c)
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := make([]metalv1alpha1.LLDPNeighbor, 0, len(in.GetNeighbor()))
+
+	for _, neighbor := range in.GetNeighbor() {
+		neighbors = append(neighbors, metalv1alpha1.LLDPNeighbor{
+			Interface:       neighbor.GetInterface(),
+			ChassisID:       neighbor.GetChassisId(),
+			PortID:          neighbor.GetPortId(),
+			PortDescription: neighbor.GetPortDescription(),
+			SystemName:      neighbor.GetSystemName(),
+		})
+	}
+
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].Interface != neighbors[j].Interface {
+			return neighbors[i].Interface < neighbors[j].Interface
 		}
 
-		return true
-	}() {
+		return neighbors[i].ChassisID < neighbors[j].ChassisID
+	})
+
+	if len(neighbors) == 0 {
+		neighbors = nil
+	}
+
+	if !reflect.DeepEqual(obj.Status.LLDPNeighbors, neighbors) {
+		obj.Status.LLDPNeighbors = neighbors
+
 		if err := patchHelper.Patch(ctx, obj); err != nil {
 			return nil, err
 		}
 	}
 
-	resp := &api.ReconcileServerAddressesResponse{}
+	resp := &api.ReconcileServerLLDPNeighborsResponse{}
 
 	return resp, nil
 }
@@ -258,21 +704,233 @@ func (s *server) Heartbeat(ctx context.Context, in *api.HeartbeatRequest) (*api.
 	return resp, nil
 }
 
-func Serve(c controllerclient.Client, recorder record.EventRecorder, scheme *runtime.Scheme, autoAccept, insecureWipe bool, rebootTimeout time.Duration) error {
+// ReconcileServerClock implements api.AgentServer.
+//
+// Agent reports the hardware clock of the server, and it corrects the clock using the time
+// returned in the response, so the condition is cleared once the agent reports the clock again.
+func (s *server) ReconcileServerClock(ctx context.Context, in *api.ReconcileServerClockRequest) (*api.ReconcileServerClockResponse, error) {
+	now := time.Now()
+
+	obj := &metalv1alpha1.Server{}
+
+	if err := s.c.Get(ctx, types.NamespacedName{Name: in.GetUuid()}, obj); err != nil {
+		return nil, err
+	}
+
+	patchHelper, err := patch.NewHelper(obj, s.c)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := reference.GetReference(s.scheme, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	skew := time.Unix(0, in.GetTime()).Sub(now)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	switch {
+	case skew > s.maxClockSkew:
+		message := fmt.Sprintf("Server clock is off by %s.", skew.Round(time.Second))
+
+		conditions.Set(obj, &clusterv1.Condition{
+			Type:     metalv1alpha1.ConditionClockSkewed,
+			Status:   corev1.ConditionTrue,
+			Severity: clusterv1.ConditionSeverityWarning,
+			Reason:   "ClockSkewed",
+			Message:  message,
+		})
+
+		s.recorder.Event(ref, corev1.EventTypeWarning, "Server Management", message)
+	case conditions.IsTrue(obj, metalv1alpha1.ConditionClockSkewed):
+		conditions.Delete(obj, metalv1alpha1.ConditionClockSkewed)
+
+		s.recorder.Event(ref, corev1.EventTypeNormal, "Server Management", "Server clock corrected via agent.")
+	default:
+		conditions.Delete(obj, metalv1alpha1.ConditionClockSkewed)
+	}
+
+	if err := patchHelper.Patch(ctx, obj, patch.WithOwnedConditions{
+		Conditions: []clusterv1.ConditionType{metalv1alpha1.ConditionClockSkewed},
+	}); err != nil {
+		return nil, err
+	}
+
+	resp := &api.ReconcileServerClockResponse{
+		Time:    time.Now().UnixNano(),
+		MaxSkew: s.maxClockSkew.Seconds(),
+	}
+
+	return resp, nil
+}
+
+// Agent logs are stored in the ConfigMap per server, logs of the previous agent boot are kept as well.
+//
+// Each of the logs is limited to constants.AgentLogsSize, so the ConfigMap stays within the object size limit.
+const (
+	agentLogsKey         = "agent.log"
+	agentLogsPreviousKey = "previous.log"
+	agentLogsBootIDKey   = "metal.sidero.dev/boot-id"
+	agentLogsLabel       = "metal.sidero.dev/agent-logs"
+)
+
+// agentLogsBootIDLength is the maximum length of the boot ID, agent reports the kernel boot ID (an UUID).
+const agentLogsBootIDLength = 64
+
+// AgentLogsConfigMapName returns the name of the ConfigMap with the agent logs of the server.
+func AgentLogsConfigMapName(uuid string) string {
+	return agentLogsPrefix + uuid
+}
+
+// +kubebuilder:rbac:groups="",namespace=sidero-system,resources=configmaps,verbs=get;list;create;update;delete
+
+// ReportLogs implements api.AgentServer.
+//
+// Agent reports all the logs of the current boot each time, so that the logs are stored even if some of the reports are lost.
+// The endpoint is not authenticated, so the number of the logs stored for the servers which are not registered is limited.
+func (s *server) ReportLogs(ctx context.Context, in *api.ReportLogsRequest) (*api.ReportLogsResponse, error) {
+	resp := &api.ReportLogsResponse{}
+
+	if s.agentLogsNamespace == "" {
+		return resp, nil
+	}
+
+	name := AgentLogsConfigMapName(in.GetUuid())
+
+	if errs := validation.IsDNS1123Subdomain(name); in.GetUuid() == "" || len(errs) > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid server UUID %q", in.GetUuid())
+	}
+
+	if len(in.GetBootId()) > agentLogsBootIDLength {
+		return nil, status.Errorf(codes.InvalidArgument, "boot ID is longer than %d characters", agentLogsBootIDLength)
+	}
+
+	logs := in.GetLogs()
+	if len(logs) > constants.AgentLogsSize {
+		logs = logs[len(logs)-constants.AgentLogsSize:]
+	}
+
+	// server might not be registered yet, the owner reference is set once it is
+	obj := &metalv1alpha1.Server{}
+
+	err := s.c.Get(ctx, types.NamespacedName{Name: in.GetUuid()}, obj)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	registered := err == nil
+
+	cm := &corev1.ConfigMap{}
+
+	err = s.apiReader.Get(ctx, types.NamespacedName{Namespace: s.agentLogsNamespace, Name: name}, cm)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	create := apierrors.IsNotFound(err)
+
+	if create {
+		if !registered {
+			if err = s.checkUnknownAgentLogs(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		cm = &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Namespace: s.agentLogsNamespace,
+				Name:      name,
+				Labels:    map[string]string{agentLogsLabel: ""},
+			},
+		}
+	}
+
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+
+	if bootID, ok := cm.Annotations[agentLogsBootIDKey]; ok && bootID != in.GetBootId() {
+		cm.Data[agentLogsPreviousKey] = cm.Data[agentLogsKey]
+	}
+
+	cm.Annotations[agentLogsBootIDKey] = in.GetBootId()
+	cm.Data[agentLogsKey] = logs
+
+	if registered {
+		if err = controllerutil.SetOwnerReference(obj, cm, s.scheme); err != nil {
+			return nil, err
+		}
+	}
+
+	if create {
+		err = s.c.Create(ctx, cm)
+	} else {
+		err = s.c.Update(ctx, cm)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// checkUnknownAgentLogs refuses to store the logs of one more server which is not registered, once there are too many of them.
+//
+// Logs of the servers which never register are removed by AgentLogsCollector after the retention period.
+func (s *server) checkUnknownAgentLogs(ctx context.Context) error {
+	var configMaps corev1.ConfigMapList
+
+	if err := s.apiReader.List(ctx, &configMaps, controllerclient.InNamespace(s.agentLogsNamespace), controllerclient.HasLabels{agentLogsLabel}); err != nil {
+		return err
+	}
+
+	unknown := 0
+
+	for _, cm := range configMaps.Items {
+		if len(cm.OwnerReferences) == 0 {
+			unknown++
+		}
+	}
+
+	if unknown >= constants.AgentLogsMaxUnknownServers {
+		return status.Errorf(codes.ResourceExhausted, "agent logs are stored for %d unknown servers already", unknown)
+	}
+
+	return nil
+}
+
+func Serve(c controllerclient.Client, apiReader controllerclient.Reader, recorder record.EventRecorder, scheme *runtime.Scheme, autoAccept, insecureWipe, requireApproval bool, rebootTimeout, maxClockSkew time.Duration, pxeMode metalv1alpha1.PXEMode, agentLogsNamespace string, attestationSecret types.NamespacedName) error {
 	lis, err := net.Listen("tcp", ":"+Port)
 	if err != nil {
 		return fmt.Errorf("failed to listen: %v", err)
 	}
 
-	s := grpc.NewServer()
+	health.API.Listening()
+
+	s := grpc.NewServer(grpc.UnaryInterceptor(gated))
 
 	api.RegisterAgentServer(s, &server{
-		autoAccept:    autoAccept,
-		insecureWipe:  insecureWipe,
-		c:             c,
-		scheme:        scheme,
-		recorder:      recorder,
-		rebootTimeout: rebootTimeout,
+		autoAccept:      autoAccept,
+		insecureWipe:    insecureWipe,
+		requireApproval: requireApproval,
+		c:               c,
+		scheme:          scheme,
+		recorder:        recorder,
+		rebootTimeout:   rebootTimeout,
+		maxClockSkew:    maxClockSkew,
+		pxeMode:         pxeMode,
+
+		apiReader:          apiReader,
+		agentLogsNamespace: agentLogsNamespace,
+		attestationSecret:  attestationSecret,
 	})
 
 	if err := s.Serve(lis); err != nil {
@@ -281,3 +939,12 @@ func Serve(c controllerclient.Client, recorder record.EventRecorder, scheme *run
 
 	return nil
 }
+
+// gated answers the agent requests only while the boot services are served by this installation.
+func gated(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !health.Serving.Open() {
+		return nil, status.Error(codes.Unavailable, "boot services are served by the active Sidero installation")
+	}
+
+	return handler(ctx, req)
+}
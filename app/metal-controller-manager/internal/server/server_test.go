@@ -0,0 +1,902 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package server
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/api"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
+)
+
+func Test_mergeAddresses(t *testing.T) {
+	type args struct {
+		current  []corev1.NodeAddress
+		reported []*api.Address
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want []corev1.NodeAddress
+	}{
+		{
+			name: "nothing reported",
+			args: args{},
+			want: nil,
+		},
+		{
+			name: "reported addresses are sorted",
+			args: args{
+				reported: []*api.Address{
+					{Type: "InternalIP", Address: "172.20.0.3"},
+					{Type: "Hostname", Address: "node"},
+					{Type: "InternalIP", Address: "172.20.0.2"},
+				},
+			},
+			want: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "node"},
+				{Type: corev1.NodeInternalIP, Address: "172.20.0.2"},
+				{Type: corev1.NodeInternalIP, Address: "172.20.0.3"},
+			},
+		},
+		{
+			name: "duplicates are removed",
+			args: args{
+				reported: []*api.Address{
+					{Type: "InternalIP", Address: "172.20.0.2"},
+					{Type: "InternalIP", Address: "172.20.0.2"},
+				},
+			},
+			want: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "172.20.0.2"},
+			},
+		},
+		{
+			name: "reported types are overwritten, others are kept",
+			args: args{
+				current: []corev1.NodeAddress{
+					{Type: corev1.NodeInternalIP, Address: "172.20.0.4"},
+					{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+				},
+				reported: []*api.Address{
+					{Type: "InternalIP", Address: "172.20.0.2"},
+				},
+			},
+			want: []corev1.NodeAddress{
+				{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+				{Type: corev1.NodeInternalIP, Address: "172.20.0.2"},
+			},
+		},
+		{
+			name: "same addresses in different order are stable",
+			args: args{
+				current: []corev1.NodeAddress{
+					{Type: corev1.NodeInternalIP, Address: "172.20.0.2"},
+					{Type: corev1.NodeInternalIP, Address: "172.20.0.3"},
+				},
+				reported: []*api.Address{
+					{Type: "InternalIP", Address: "172.20.0.3"},
+					{Type: "InternalIP", Address: "172.20.0.2"},
+				},
+			},
+			want: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "172.20.0.2"},
+				{Type: corev1.NodeInternalIP, Address: "172.20.0.3"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeAddresses(tt.args.current, tt.args.reported); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeAddresses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_networkInterfaces(t *testing.T) {
+	got := networkInterfaces([]*api.NetworkInterface{
+		{Name: "eth1", Mac: "52:54:00:AB:CD:02"},
+		{Name: "eth0", Mac: "52:54:00:ab:cd:01"},
+	})
+
+	want := []metalv1alpha1.NetworkInterface{
+		{Name: "eth0", MAC: "52:54:00:ab:cd:01"},
+		{Name: "eth1", MAC: "52:54:00:ab:cd:02"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("networkInterfaces() = %v, want %v", got, want)
+	}
+}
+
+func Test_pciDevices(t *testing.T) {
+	got := pciDevices([]*api.PCIDevice{
+		{Address: "0000:5e:00.0", Class: "0x120000", VendorId: "0x1C2C", DeviceId: "0x1000"},
+		{Address: "0000:3b:00.0", Class: "0x010802", VendorId: "0x144d", DeviceId: "0xa808", Driver: "nvme", Model: "Samsung SSD 970", Namespaces: 2},
+	})
+
+	want := []metalv1alpha1.PCIDevice{
+		{
+			Address: "0000:3b:00.0",
+			PCIDeviceInformation: metalv1alpha1.PCIDeviceInformation{
+				Type:     metalv1alpha1.PCIDeviceTypeNVMe,
+				Class:    "010802",
+				VendorID: "144d",
+				DeviceID: "a808",
+				Driver:   "nvme",
+				Model:    "Samsung SSD 970",
+			},
+			Namespaces: 2,
+		},
+		{
+			Address: "0000:5e:00.0",
+			PCIDeviceInformation: metalv1alpha1.PCIDeviceInformation{
+				Type:     metalv1alpha1.PCIDeviceTypeAccelerator,
+				Class:    "120000",
+				VendorID: "1c2c",
+				DeviceID: "1000",
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pciDevices() = %v, want %v", got, want)
+	}
+}
+
+func Test_UpdateProvisioningNetworkLabels(t *testing.T) {
+	obj := &metalv1alpha1.Server{}
+
+	if !UpdateProvisioningNetworkLabels(obj, "10.5.0.23/24", "10.5.0.1") {
+		t.Fatal("expected labels to be changed")
+	}
+
+	want := map[string]string{
+		metalv1alpha1.ProvisioningSubnetLabel:  "10.5.0.0-24",
+		metalv1alpha1.ProvisioningGatewayLabel: "10.5.0.1",
+	}
+
+	if !reflect.DeepEqual(obj.Labels, want) {
+		t.Errorf("labels = %v, want %v", obj.Labels, want)
+	}
+
+	if UpdateProvisioningNetworkLabels(obj, "10.5.0.42/24", "10.5.0.1") {
+		t.Error("expected labels to be unchanged")
+	}
+
+	if !UpdateProvisioningNetworkLabels(obj, "10.5.0.23/24", "0.0.0.0") {
+		t.Fatal("expected gateway label to be removed")
+	}
+
+	if _, ok := obj.Labels[metalv1alpha1.ProvisioningGatewayLabel]; ok {
+		t.Errorf("unexpected gateway label: %v", obj.Labels)
+	}
+
+	if UpdateProvisioningNetworkLabels(obj, "", "") {
+		t.Error("expected labels to be unchanged without the address")
+	}
+}
+
+func Test_UpdateHardwareLabels(t *testing.T) {
+	obj := &metalv1alpha1.Server{
+		Spec: metalv1alpha1.ServerSpec{
+			SystemInformation: &metalv1alpha1.SystemInformation{
+				Manufacturer: "Dell Inc.",
+				ProductName:  "PowerEdge R630",
+			},
+			CPU: &metalv1alpha1.CPUInformation{
+				Manufacturer: "Intel(R) Corporation",
+				Version:      "Intel(R) Xeon(R) CPU E5-2630 v3 @ 2.40GHz",
+			},
+		},
+	}
+
+	if !UpdateHardwareLabels(obj) {
+		t.Fatal("expected labels to be changed")
+	}
+
+	want := map[string]string{
+		metalv1alpha1.ManufacturerLabel:    "Dell-Inc",
+		metalv1alpha1.ProductLabel:         "PowerEdge-R630",
+		metalv1alpha1.CPUManufacturerLabel: "Intel-R-Corporation",
+		metalv1alpha1.CPUModelLabel:        "Intel-R-Xeon-R-CPU-E5-2630-v3-2.40GHz",
+	}
+
+	if !reflect.DeepEqual(obj.Labels, want) {
+		t.Errorf("labels = %v, want %v", obj.Labels, want)
+	}
+
+	if UpdateHardwareLabels(obj) {
+		t.Error("expected labels to be unchanged")
+	}
+
+	obj.Spec.CPU = nil
+
+	if !UpdateHardwareLabels(obj) {
+		t.Fatal("expected CPU labels to be removed")
+	}
+
+	if _, ok := obj.Labels[metalv1alpha1.CPUModelLabel]; ok {
+		t.Errorf("unexpected CPU model label: %v", obj.Labels)
+	}
+}
+
+func Test_labelValue(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want string
+	}{
+		{"Supermicro", "Supermicro"},
+		{" (To Be Filled By O.E.M.) ", "To-Be-Filled-By-O.E.M"},
+		{"!!!", ""},
+		{strings.Repeat("a", 70), strings.Repeat("a", 63)},
+		{strings.Repeat("a", 62) + " b", strings.Repeat("a", 62)},
+	} {
+		if got := labelValue(tt.in); got != tt.want {
+			t.Errorf("labelValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func Test_wipeAttestation(t *testing.T) {
+	attestation, err := wipeAttestation(&api.MarkServerAsWipedRequest{Uuid: "4c4c4544-0035-5910-804b-b2c04f4e4d32"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attestation != nil {
+		t.Fatal("expected no attestation without the wiped disks")
+	}
+
+	attestation, err = wipeAttestation(&api.MarkServerAsWipedRequest{
+		Uuid:      "4c4c4544-0035-5910-804b-b2c04f4e4d32",
+		Timestamp: 1600000000,
+		Disk: []*api.DiskWipe{
+			{Name: "sda", Serial: "S4EVNX0N", Method: "blksecdiscard", SampleHash: "00ff", Samples: 16, Zeroed: true},
+		},
+	}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parseAttestationKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = signAttestation(attestation, parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ed25519.Verify(key.Public().(ed25519.PublicKey), attestation.Payload, attestation.Signature) {
+		t.Error("signature doesn't verify")
+	}
+
+	want := `{"server":"4c4c4544-0035-5910-804b-b2c04f4e4d32","timestamp":"2020-09-13T12:26:40Z",` +
+		`"disks":[{"name":"sda","serial":"S4EVNX0N","method":"blksecdiscard","sampleHash":"00ff","samples":16,"zeroed":true}]}`
+
+	if string(attestation.Payload) != want {
+		t.Errorf("payload = %s", attestation.Payload)
+	}
+
+	if len(attestation.KeyID) != 64 {
+		t.Errorf("unexpected key ID %q", attestation.KeyID)
+	}
+}
+
+func Test_agentAuthenticated(t *testing.T) {
+	token, hash, err := NewAgentToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherToken, _, err := NewAgentToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token == otherToken {
+		t.Fatal("tokens are not random")
+	}
+
+	withToken := func(tokens ...string) context.Context {
+		md := metadata.MD{}
+
+		for _, token := range tokens {
+			md.Append(constants.AgentTokenMetadataKey, token)
+		}
+
+		return metadata.NewIncomingContext(context.Background(), md)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		ctx      context.Context
+		hash     string
+		expected bool
+	}{
+		{
+			name:     "valid token",
+			ctx:      withToken(token),
+			hash:     hash,
+			expected: true,
+		},
+		{
+			name: "no metadata",
+			ctx:  context.Background(),
+			hash: hash,
+		},
+		{
+			name: "no token",
+			ctx:  withToken(),
+			hash: hash,
+		},
+		{
+			name: "token not issued for the server",
+			ctx:  withToken(otherToken),
+			hash: hash,
+		},
+		{
+			name: "hash in place of token",
+			ctx:  withToken(hash),
+			hash: hash,
+		},
+		{
+			name: "token already used",
+			ctx:  withToken(token),
+		},
+		{
+			name: "several tokens",
+			ctx:  withToken(token, otherToken),
+			hash: hash,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if authenticated := agentAuthenticated(tt.ctx, tt.hash); authenticated != tt.expected {
+				t.Errorf("agentAuthenticated() = %v, want %v", authenticated, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMarkServerAsWipedAgentToken(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, hash, err := NewAgentToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const uuid = "4c4c4544-0035-5910-804b-b2c04f4e4d32"
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "sidero-system", Name: "wipe-attestation"},
+		Data:       map[string][]byte{AttestationKeyName: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})},
+	}
+
+	obj := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{Name: uuid},
+		Status:     metalv1alpha1.ServerStatus{AgentTokenHash: hash},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, secret, obj)
+
+	s := &server{
+		c:                 c,
+		scheme:            scheme,
+		recorder:          record.NewFakeRecorder(10),
+		apiReader:         c,
+		attestationSecret: types.NamespacedName{Namespace: "sidero-system", Name: "wipe-attestation"},
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(constants.AgentTokenMetadataKey, token))
+	req := &api.MarkServerAsWipedRequest{
+		Uuid: uuid,
+		Disk: []*api.DiskWipe{{Name: "sda", Method: "blksecdiscard", SampleHash: "00ff", Samples: 16, Zeroed: true}},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		signed bool
+	}{
+		{
+			name:   "token issued for the agent",
+			signed: true,
+		},
+		{
+			name: "token replayed",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.MarkServerAsWiped(ctx, req); err != nil {
+				t.Fatal(err)
+			}
+
+			var wiped metalv1alpha1.Server
+
+			if err := c.Get(context.Background(), types.NamespacedName{Name: uuid}, &wiped); err != nil {
+				t.Fatal(err)
+			}
+
+			if wiped.Status.AgentTokenHash != "" {
+				t.Error("agent token is not consumed")
+			}
+
+			if signed := len(wiped.Status.WipeAttestation.Signature) > 0; signed != tt.signed {
+				t.Errorf("signed = %v, want %v", signed, tt.signed)
+			}
+		})
+	}
+}
+
+func Test_gpus(t *testing.T) {
+	gpu := func(address, vendor, device string) metalv1alpha1.PCIDevice {
+		return metalv1alpha1.PCIDevice{
+			Address: address,
+			PCIDeviceInformation: metalv1alpha1.PCIDeviceInformation{
+				Type:     metalv1alpha1.PCIDeviceTypeGPU,
+				VendorID: vendor,
+				DeviceID: device,
+			},
+		}
+	}
+
+	result := gpus([]metalv1alpha1.PCIDevice{
+		gpu("0000:03:00.0", "1a03", "2000"),
+		gpu("0000:07:00.0", "10de", "20b0"),
+		gpu("0000:0f:00.0", "10de", "20b0"),
+		gpu("0000:47:00.0", "10de", "abcd"),
+		{Address: "0000:4b:00.0", PCIDeviceInformation: metalv1alpha1.PCIDeviceInformation{Type: metalv1alpha1.PCIDeviceTypeNetwork, VendorID: "15b3"}},
+	})
+
+	if len(result) != 2 {
+		t.Fatalf("unexpected GPUs: %+v", result)
+	}
+
+	if result[0].Model != "A100-SXM4-40GB" || result[0].Count != 2 || result[0].VRAM == nil || result[0].VRAM.String() != "40Gi" {
+		t.Errorf("unexpected GPU: %+v", result[0])
+	}
+
+	if result[1].Vendor != "NVIDIA" || result[1].Model != "abcd" || result[1].Count != 1 || result[1].VRAM != nil {
+		t.Errorf("unexpected GPU: %+v", result[1])
+	}
+}
+
+func Test_verifyDisks(t *testing.T) {
+	attestation := &metalv1alpha1.WipeAttestation{
+		Disks: []metalv1alpha1.DiskWipe{
+			{Name: "sda", Serial: "S4EVNX0N", Method: "blksecdiscard", SampleHash: "00ff", Samples: 16, Zeroed: true},
+			{Name: "sdb", Partition: "STATE", Method: "blkzeroout", SampleHash: "ff00", Samples: 16, Zeroed: true},
+			{Name: "sdc", Method: "fast"},
+		},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		scope    metalv1alpha1.WipeScope
+		expected []string
+	}{
+		{
+			name:  "full wipe",
+			scope: "",
+		},
+		{
+			name:  "system wipe",
+			scope: metalv1alpha1.WipeScopeSystem,
+		},
+		{
+			name:     "verify",
+			scope:    metalv1alpha1.WipeScopeVerify,
+			expected: []string{"sda"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &metalv1alpha1.Server{
+				Status: metalv1alpha1.ServerStatus{
+					PendingWipeScope: tt.scope,
+					WipeAttestation:  attestation,
+				},
+			}
+
+			var names []string
+
+			for _, disk := range verifyDisks(server) {
+				names = append(names, disk.GetName())
+
+				if disk.GetSampleHash() == "" {
+					t.Errorf("disk %q has no sample hash", disk.GetName())
+				}
+			}
+
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("verified disks = %v, want %v", names, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReportLogs(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		registered = "4c4c4544-0035-5910-804b-b2c04f4e4d32"
+		unknown    = "4c4c4544-0035-5910-804b-b2c04f4e4d33"
+	)
+
+	// logs of the servers which are not registered, stored already
+	unknownLogs := func(count int) []runtime.Object {
+		objects := []runtime.Object{
+			&metalv1alpha1.Server{ObjectMeta: metav1.ObjectMeta{Name: registered, UID: "1"}},
+		}
+
+		for i := 0; i < count; i++ {
+			objects = append(objects, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "sidero-system",
+					Name:      AgentLogsConfigMapName(strings.Repeat("0", i+1)),
+					Labels:    map[string]string{agentLogsLabel: ""},
+				},
+			})
+		}
+
+		return objects
+	}
+
+	for _, tt := range []struct {
+		name    string
+		objects []runtime.Object
+		req     *api.ReportLogsRequest
+
+		code  codes.Code
+		owned bool
+	}{
+		{
+			name:    "registered server",
+			objects: unknownLogs(0),
+			req:     &api.ReportLogsRequest{Uuid: registered, BootId: "boot", Logs: "booted"},
+			owned:   true,
+		},
+		{
+			name:    "unknown server",
+			objects: unknownLogs(constants.AgentLogsMaxUnknownServers - 1),
+			req:     &api.ReportLogsRequest{Uuid: unknown, BootId: "boot", Logs: "booted"},
+		},
+		{
+			name:    "too many unknown servers",
+			objects: unknownLogs(constants.AgentLogsMaxUnknownServers),
+			req:     &api.ReportLogsRequest{Uuid: unknown, BootId: "boot", Logs: "booted"},
+			code:    codes.ResourceExhausted,
+		},
+		{
+			name:    "registered server with too many unknown servers",
+			objects: unknownLogs(constants.AgentLogsMaxUnknownServers),
+			req:     &api.ReportLogsRequest{Uuid: registered, BootId: "boot", Logs: "booted"},
+			owned:   true,
+		},
+		{
+			name: "invalid UUID",
+			req:  &api.ReportLogsRequest{Uuid: "../secrets", BootId: "boot", Logs: "booted"},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "no UUID",
+			req:  &api.ReportLogsRequest{BootId: "boot", Logs: "booted"},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "long boot ID",
+			req:  &api.ReportLogsRequest{Uuid: registered, BootId: strings.Repeat("0", 65), Logs: "booted"},
+			code: codes.InvalidArgument,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.objects...)
+
+			s := &server{
+				c:                  c,
+				scheme:             scheme,
+				apiReader:          c,
+				agentLogsNamespace: "sidero-system",
+			}
+
+			_, err := s.ReportLogs(context.Background(), tt.req)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("ReportLogs() code = %s, want %s: %v", code, tt.code, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			var cm corev1.ConfigMap
+
+			if err = c.Get(context.Background(), types.NamespacedName{Namespace: "sidero-system", Name: AgentLogsConfigMapName(tt.req.Uuid)}, &cm); err != nil {
+				t.Fatal(err)
+			}
+
+			if owned := len(cm.OwnerReferences) > 0; owned != tt.owned {
+				t.Errorf("owned = %v, want %v", owned, tt.owned)
+			}
+		})
+	}
+}
+
+func TestReportLogsBoots(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	const uuid = "4c4c4544-0035-5910-804b-b2c04f4e4d32"
+
+	c := fake.NewFakeClientWithScheme(scheme)
+
+	s := &server{
+		c:                  c,
+		scheme:             scheme,
+		apiReader:          c,
+		agentLogsNamespace: "sidero-system",
+	}
+
+	ctx := context.Background()
+
+	long := strings.Repeat("x", constants.AgentLogsSize) + "tail"
+
+	for _, tt := range []struct {
+		bootID   string
+		logs     string
+		current  string
+		previous string
+	}{
+		{bootID: "1", logs: "first", current: "first"},
+		{bootID: "1", logs: "first boot", current: "first boot"},
+		{bootID: "2", logs: "second", current: "second", previous: "first boot"},
+		{bootID: "3", logs: long, current: long[len(long)-constants.AgentLogsSize:], previous: "second"},
+	} {
+		if _, err := s.ReportLogs(ctx, &api.ReportLogsRequest{Uuid: uuid, BootId: tt.bootID, Logs: tt.logs}); err != nil {
+			t.Fatal(err)
+		}
+
+		var cm corev1.ConfigMap
+
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "sidero-system", Name: AgentLogsConfigMapName(uuid)}, &cm); err != nil {
+			t.Fatal(err)
+		}
+
+		if cm.Data[agentLogsKey] != tt.current {
+			t.Errorf("boot %s: unexpected current logs of %d bytes", tt.bootID, len(cm.Data[agentLogsKey]))
+		}
+
+		if cm.Data[agentLogsPreviousKey] != tt.previous {
+			t.Errorf("boot %s: previous logs = %q, want %q", tt.bootID, cm.Data[agentLogsPreviousKey], tt.previous)
+		}
+	}
+}
+
+func TestReconcileServerClock(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	const uuid = "4c4c4544-0035-5910-804b-b2c04f4e4d32"
+
+	c := fake.NewFakeClientWithScheme(scheme, &metalv1alpha1.Server{ObjectMeta: metav1.ObjectMeta{Name: uuid}})
+	recorder := record.NewFakeRecorder(10)
+
+	s := &server{
+		c:            c,
+		scheme:       scheme,
+		recorder:     recorder,
+		maxClockSkew: time.Minute,
+	}
+
+	for _, tt := range []struct {
+		name          string
+		skew          time.Duration
+		expectSkewed  bool
+		expectedEvent string
+	}{
+		{
+			name:          "clock behind",
+			skew:          -time.Hour,
+			expectSkewed:  true,
+			expectedEvent: "Warning Server Management Server clock is off by 1h0m0s.",
+		},
+		{
+			name:          "clock corrected",
+			skew:          time.Second,
+			expectedEvent: "Normal Server Management Server clock corrected via agent.",
+		},
+		{
+			name: "clock in sync",
+		},
+		{
+			name:          "clock ahead",
+			skew:          2 * time.Minute,
+			expectSkewed:  true,
+			expectedEvent: "Warning Server Management Server clock is off by 2m0s.",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.ReconcileServerClock(context.Background(), &api.ReconcileServerClockRequest{
+				Uuid: uuid,
+				Time: time.Now().Add(tt.skew).UnixNano(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.GetMaxSkew() != 60 {
+				t.Errorf("max skew = %v, want 60", resp.GetMaxSkew())
+			}
+
+			if reference := time.Unix(0, resp.GetTime()); time.Since(reference) > time.Minute || time.Since(reference) < 0 {
+				t.Errorf("reference time %s is not the current time", reference)
+			}
+
+			var obj metalv1alpha1.Server
+
+			if err := c.Get(context.Background(), types.NamespacedName{Name: uuid}, &obj); err != nil {
+				t.Fatal(err)
+			}
+
+			if skewed := conditions.IsTrue(&obj, metalv1alpha1.ConditionClockSkewed); skewed != tt.expectSkewed {
+				t.Errorf("ClockSkewed = %v, want %v", skewed, tt.expectSkewed)
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if event != tt.expectedEvent {
+					t.Errorf("event %q, want %q", event, tt.expectedEvent)
+				}
+			default:
+				if tt.expectedEvent != "" {
+					t.Errorf("event %q is not recorded", tt.expectedEvent)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateServerPXEMode(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	const uuid = "4c4c4544-0035-5910-804b-b2c04f4e4d32"
+
+	for _, tt := range []struct {
+		name           string
+		defaultMode    metalv1alpha1.PXEMode
+		serverMode     metalv1alpha1.PXEMode
+		accepted       bool
+		expectBootNext bool
+	}{
+		{
+			name:        "boot order",
+			defaultMode: metalv1alpha1.PXEModeBootOrder,
+			accepted:    true,
+		},
+		{
+			name:           "one-shot",
+			defaultMode:    metalv1alpha1.PXEModeOneShot,
+			accepted:       true,
+			expectBootNext: true,
+		},
+		{
+			name:           "server overrides the default mode",
+			defaultMode:    metalv1alpha1.PXEModeBootOrder,
+			serverMode:     metalv1alpha1.PXEModeOneShot,
+			accepted:       true,
+			expectBootNext: true,
+		},
+		{
+			name:        "server overrides the one-shot default mode",
+			defaultMode: metalv1alpha1.PXEModeOneShot,
+			serverMode:  metalv1alpha1.PXEModeBootOrder,
+			accepted:    true,
+		},
+		{
+			name:        "not accepted",
+			defaultMode: metalv1alpha1.PXEModeOneShot,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{
+				c: fake.NewFakeClientWithScheme(scheme, &metalv1alpha1.Server{
+					ObjectMeta: metav1.ObjectMeta{Name: uuid},
+					Spec: metalv1alpha1.ServerSpec{
+						Accepted: tt.accepted,
+						PXEMode:  tt.serverMode,
+					},
+					Status: metalv1alpha1.ServerStatus{IsClean: true},
+				}),
+				scheme:   scheme,
+				recorder: record.NewFakeRecorder(10),
+				pxeMode:  tt.defaultMode,
+			}
+
+			resp, err := s.CreateServer(context.Background(), &api.CreateServerRequest{
+				SystemInformation: &api.SystemInformation{Uuid: uuid},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.GetSetBootNext() != tt.expectBootNext {
+				t.Errorf("set boot next = %v, want %v", resp.GetSetBootNext(), tt.expectBootNext)
+			}
+		})
+	}
+}
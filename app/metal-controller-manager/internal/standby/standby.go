@@ -0,0 +1,417 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package standby mirrors the inventory of the active Sidero installation, so that the standby installation
+// can take over the boot services while the active one is down.
+package standby
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/export"
+)
+
+// Mirror keeps the inventory of the active installation in sync, and tracks whether the active installation is reachable.
+//
+// Mirrored objects are labeled with MirroredLabel, local objects of the same name are never overwritten.
+type Mirror struct {
+	Client client.Client
+	// APIReader reads the token secret bypassing the cache.
+	APIReader client.Reader
+	Log       logr.Logger
+
+	// Endpoint is the export API endpoint of the active installation.
+	Endpoint string
+	// Secret holds the inventory token ("token" key) and optionally the CA of the export API ("ca.crt" key).
+	Secret types.NamespacedName
+	// Interval is the interval between the inventory syncs.
+	Interval time.Duration
+	// FailoverTimeout is the time the active installation should stay unreachable before the standby takes over.
+	FailoverTimeout time.Duration
+
+	mu               sync.Mutex
+	lastSync         time.Time
+	unreachableSince time.Time
+	failedOver       bool
+}
+
+// Start implements manager.Runnable.
+func (m *Mirror) Start(stop <-chan struct{}) error {
+	m.mu.Lock()
+	// the failover timeout starts with the first failed sync, so the active installation is given the whole timeout
+	m.lastSync = time.Now()
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.sync(context.Background()); err != nil {
+			m.Log.Error(err, "failed to sync inventory of the active Sidero installation")
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Ready implements healthz.Checker, it fails while the active installation is reachable,
+// so that the boot services of the standby installation are only advertised after the failover.
+func (m *Mirror) Ready(_ *http.Request) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.lastSync.IsZero() {
+		return fmt.Errorf("standby is starting")
+	}
+
+	if !m.failedOver {
+		return fmt.Errorf("active Sidero installation %s is reachable", m.Endpoint)
+	}
+
+	return nil
+}
+
+// Active reports whether the standby took over the boot services.
+func (m *Mirror) Active() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.failedOver
+}
+
+func (m *Mirror) checkFailover() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.unreachableSince.IsZero() {
+		m.unreachableSince = time.Now()
+	}
+
+	if !m.failedOver && time.Since(m.unreachableSince) > m.FailoverTimeout {
+		m.failedOver = true
+
+		m.Log.Info("active Sidero installation is unreachable, taking over the boot services", "endpoint", m.Endpoint, "lastSync", m.lastSync)
+	}
+}
+
+// sync fetches and applies the inventory, only the active installation being unreachable counts towards the failover.
+//
+// Errors reading the token secret from the local cluster are retried on the next sync.
+func (m *Mirror) sync(ctx context.Context) error {
+	exportClient, err := m.exportClient(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading standby secret: %w", err)
+	}
+
+	inventory, err := exportClient.Inventory(ctx)
+	if err != nil {
+		m.checkFailover()
+
+		return err
+	}
+
+	m.mu.Lock()
+
+	m.lastSync = time.Now()
+	m.unreachableSince = time.Time{}
+
+	if m.failedOver {
+		m.failedOver = false
+
+		m.Log.Info("active Sidero installation is reachable again, handing the boot services back", "endpoint", m.Endpoint)
+	}
+
+	m.mu.Unlock()
+
+	return m.apply(ctx, inventory)
+}
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+func (m *Mirror) exportClient(ctx context.Context) (*export.Client, error) {
+	var secret corev1.Secret
+
+	if err := m.APIReader.Get(ctx, m.Secret, &secret); err != nil {
+		return nil, err
+	}
+
+	token, ok := secret.Data["token"]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s doesn't have token", secret.Namespace, secret.Name)
+	}
+
+	return export.NewClient(m.Endpoint, "", string(token), secret.Data["ca.crt"])
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers/status,verbs=get;update
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclasses,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=environments,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=sites,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=serverbindings,verbs=get;list;watch;create;update;delete
+
+// apply mirrors the inventory, and removes the mirrored objects which are gone from the active installation.
+func (m *Mirror) apply(ctx context.Context, inventory *export.Inventory) error {
+	var (
+		servers        []runtime.Object
+		serverBindings []runtime.Object
+		serverClasses  []runtime.Object
+		environments   []runtime.Object
+		sites          []runtime.Object
+	)
+
+	for i := range inventory.Servers {
+		servers = append(servers, &inventory.Servers[i])
+	}
+
+	for i := range inventory.ServerBindings {
+		serverBindings = append(serverBindings, &inventory.ServerBindings[i])
+	}
+
+	for i := range inventory.ServerClasses {
+		serverClasses = append(serverClasses, &inventory.ServerClasses[i])
+	}
+
+	for i := range inventory.Environments {
+		environments = append(environments, &inventory.Environments[i])
+	}
+
+	for i := range inventory.Sites {
+		sites = append(sites, &inventory.Sites[i])
+	}
+
+	// status is only mirrored for the servers, the status of the other resources is derived by the standby controllers
+	for _, kind := range []struct {
+		list    runtime.Object
+		objects []runtime.Object
+		status  bool
+	}{
+		{&metalv1alpha1.ServerList{}, servers, true},
+		{&infrav1.ServerBindingList{}, serverBindings, false},
+		{&metalv1alpha1.ServerClassList{}, serverClasses, false},
+		{&metalv1alpha1.EnvironmentList{}, environments, false},
+		{&metalv1alpha1.SiteList{}, sites, false},
+	} {
+		if err := m.mirrorKind(ctx, kind.list, kind.objects, kind.status); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Mirror) mirrorKind(ctx context.Context, list runtime.Object, objects []runtime.Object, status bool) error {
+	mirrored := map[string]struct{}{}
+
+	for _, obj := range objects {
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+
+		// objects being deleted are gone from the active installation
+		if objMeta.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		mirrored[objMeta.GetName()] = struct{}{}
+
+		if err = m.mirror(ctx, obj, status); err != nil {
+			return fmt.Errorf("error mirroring %q: %w", objMeta.GetName(), err)
+		}
+	}
+
+	if err := m.Client.List(ctx, list, client.MatchingLabels{metalv1alpha1.MirroredLabel: "true"}); err != nil {
+		return err
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range items {
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := mirrored[objMeta.GetName()]; ok {
+			continue
+		}
+
+		if err = m.Client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+
+		m.Log.Info("removed mirrored object", "kind", fmt.Sprintf("%T", obj), "name", objMeta.GetName())
+	}
+
+	return nil
+}
+
+// mirror creates or updates the local copy of the object.
+func (m *Mirror) mirror(ctx context.Context, obj runtime.Object, status bool) error {
+	desired := obj.DeepCopyObject()
+
+	desiredMeta, err := meta.Accessor(desired)
+	if err != nil {
+		return err
+	}
+
+	// local copy doesn't carry the identity and the ownership of the active object
+	desiredMeta.SetResourceVersion("")
+	desiredMeta.SetUID("")
+	desiredMeta.SetSelfLink("")
+	desiredMeta.SetGeneration(0)
+	desiredMeta.SetCreationTimestamp(metav1.Time{})
+	desiredMeta.SetManagedFields(nil)
+	desiredMeta.SetOwnerReferences(nil)
+	desiredMeta.SetFinalizers(nil)
+
+	labels := desiredMeta.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	labels[metalv1alpha1.MirroredLabel] = "true"
+	desiredMeta.SetLabels(labels)
+
+	// decoding into a copy of the desired object would merge its labels
+	existing := reflect.New(reflect.TypeOf(desired).Elem()).Interface().(runtime.Object)
+
+	err = m.Client.Get(ctx, types.NamespacedName{Namespace: desiredMeta.GetNamespace(), Name: desiredMeta.GetName()}, existing)
+
+	switch {
+	case apierrors.IsNotFound(err):
+		return m.write(ctx, desired, func(obj runtime.Object) error { return m.Client.Create(ctx, obj) }, status)
+	case err != nil:
+		return err
+	}
+
+	existingMeta, err := meta.Accessor(existing)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := existingMeta.GetLabels()[metalv1alpha1.MirroredLabel]; !ok {
+		m.Log.Info("local object shadows the mirrored one, skipping", "kind", fmt.Sprintf("%T", existing), "name", existingMeta.GetName())
+
+		return nil
+	}
+
+	keepBMCCredentials(existing, desired)
+
+	same, err := sameObjects(existing, desired, status)
+	if err != nil || same {
+		return err
+	}
+
+	desiredMeta.SetResourceVersion(existingMeta.GetResourceVersion())
+
+	return m.write(ctx, desired, func(obj runtime.Object) error { return m.Client.Update(ctx, obj) }, status)
+}
+
+// keepBMCCredentials keeps the BMC credentials of the mirrored server, if the active installation doesn't export them.
+//
+// Credentials are then set on the standby installation by the operator.
+func keepBMCCredentials(existing, desired runtime.Object) {
+	existingServer, ok := existing.(*metalv1alpha1.Server)
+	if !ok || existingServer.Spec.BMC == nil {
+		return
+	}
+
+	desiredServer, ok := desired.(*metalv1alpha1.Server)
+	if !ok || desiredServer.Spec.BMC == nil || desiredServer.Spec.BMC.User != "" || desiredServer.Spec.BMC.Pass != "" {
+		return
+	}
+
+	desiredServer.Spec.BMC.User = existingServer.Spec.BMC.User
+	desiredServer.Spec.BMC.Pass = existingServer.Spec.BMC.Pass
+}
+
+// write creates or updates the object, and then updates its status, if the status is mirrored.
+func (m *Mirror) write(ctx context.Context, desired runtime.Object, write func(runtime.Object) error, status bool) error {
+	written := desired.DeepCopyObject()
+
+	if err := write(written); err != nil {
+		return err
+	}
+
+	if !status {
+		return nil
+	}
+
+	writtenMeta, err := meta.Accessor(written)
+	if err != nil {
+		return err
+	}
+
+	desiredMeta, err := meta.Accessor(desired)
+	if err != nil {
+		return err
+	}
+
+	desiredMeta.SetResourceVersion(writtenMeta.GetResourceVersion())
+
+	return m.Client.Status().Update(ctx, desired)
+}
+
+// sameObjects compares the local copy with the mirrored object, ignoring the fields set by the local API server.
+func sameObjects(existing, desired runtime.Object, status bool) (bool, error) {
+	a, err := comparableFields(existing, status)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := comparableFields(desired, status)
+	if err != nil {
+		return false, err
+	}
+
+	return equality.Semantic.DeepEqual(a, b), nil
+}
+
+func comparableFields(obj runtime.Object, status bool) (map[string]interface{}, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata, ok := u["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"resourceVersion", "uid", "selfLink", "generation", "creationTimestamp", "managedFields", "ownerReferences", "finalizers"} {
+			delete(metadata, field)
+		}
+	}
+
+	delete(u, "apiVersion")
+	delete(u, "kind")
+
+	if !status {
+		delete(u, "status")
+	}
+
+	return u, nil
+}
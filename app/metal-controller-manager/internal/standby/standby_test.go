@@ -0,0 +1,208 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package standby
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/export"
+)
+
+func TestMirrorApply(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	local := &metalv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec:       metalv1alpha1.EnvironmentSpec{Kernel: metalv1alpha1.Kernel{Args: []string{"local"}}},
+	}
+
+	gone := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "server-gone",
+			Labels: map[string]string{metalv1alpha1.MirroredLabel: "true"},
+		},
+	}
+
+	// credentials of the mirrored server set on the standby, as the active installation doesn't export them
+	withCredentials := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "server-2",
+			Labels: map[string]string{metalv1alpha1.MirroredLabel: "true"},
+		},
+		Spec: metalv1alpha1.ServerSpec{
+			BMC: &metalv1alpha1.BMC{Endpoint: "10.0.0.25", User: "admin", Pass: "secret"},
+		},
+	}
+
+	m := &Mirror{
+		Client: fake.NewFakeClientWithScheme(scheme, local, gone, withCredentials),
+		Log:    log.NullLogger{},
+	}
+
+	inventory := &export.Inventory{
+		Servers: []metalv1alpha1.Server{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "server-1",
+					UID:             "active-uid",
+					ResourceVersion: "42",
+					Finalizers:      []string{"storage.finalizers.server.k8s.io"},
+				},
+				Spec:   metalv1alpha1.ServerSpec{Accepted: true},
+				Status: metalv1alpha1.ServerStatus{InUse: true},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "server-2"},
+				Spec: metalv1alpha1.ServerSpec{
+					Accepted: true,
+					BMC:      &metalv1alpha1.BMC{Endpoint: "10.0.0.26"},
+				},
+			},
+		},
+		ServerBindings: []infrav1.ServerBinding{
+			{ObjectMeta: metav1.ObjectMeta{Name: "server-1"}},
+		},
+		Environments: []metalv1alpha1.Environment{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec:       metalv1alpha1.EnvironmentSpec{Kernel: metalv1alpha1.Kernel{Args: []string{"active"}}},
+			},
+		},
+	}
+
+	ctx := context.Background()
+
+	if err := m.apply(ctx, inventory); err != nil {
+		t.Fatal(err)
+	}
+
+	var server metalv1alpha1.Server
+
+	if err := m.Client.Get(ctx, types.NamespacedName{Name: "server-1"}, &server); err != nil {
+		t.Fatal(err)
+	}
+
+	// second pass finds everything in sync
+	if err := m.apply(ctx, inventory); err != nil {
+		t.Fatal(err)
+	}
+
+	resourceVersion := server.ResourceVersion
+
+	if err := m.Client.Get(ctx, types.NamespacedName{Name: "server-1"}, &server); err != nil {
+		t.Fatal(err)
+	}
+
+	if server.ResourceVersion != resourceVersion {
+		t.Errorf("mirrored server updated without changes")
+	}
+
+	if server.Labels[metalv1alpha1.MirroredLabel] != "true" || !server.Spec.Accepted || !server.Status.InUse {
+		t.Errorf("server not mirrored: %+v", server)
+	}
+
+	if len(server.Finalizers) != 0 || server.UID == "active-uid" {
+		t.Errorf("server metadata of the active installation mirrored: %+v", server.ObjectMeta)
+	}
+
+	if err := m.Client.Get(ctx, types.NamespacedName{Name: "server-2"}, &server); err != nil {
+		t.Fatal(err)
+	}
+
+	if bmc := server.Spec.BMC; bmc.Endpoint != "10.0.0.26" || bmc.User != "admin" || bmc.Pass != "secret" {
+		t.Errorf("BMC of the mirrored server not updated with the local credentials kept: %+v", bmc)
+	}
+
+	if err := m.Client.Get(ctx, types.NamespacedName{Name: "server-1"}, &infrav1.ServerBinding{}); err != nil {
+		t.Errorf("server binding not mirrored: %s", err)
+	}
+
+	var env metalv1alpha1.Environment
+
+	if err := m.Client.Get(ctx, types.NamespacedName{Name: "default"}, &env); err != nil {
+		t.Fatal(err)
+	}
+
+	if env.Spec.Kernel.Args[0] != "local" {
+		t.Errorf("local environment overwritten: %v", env.Spec.Kernel.Args)
+	}
+
+	if err := m.Client.Get(ctx, types.NamespacedName{Name: "server-gone"}, &metalv1alpha1.Server{}); !apierrors.IsNotFound(err) {
+		t.Errorf("mirrored server gone from the active installation not removed: %v", err)
+	}
+}
+
+func TestMirrorFailover(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	active := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer active.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "sidero-system", Name: "standby"},
+		Data:       map[string][]byte{"token": []byte("token")},
+	}
+
+	for _, tt := range []struct {
+		name       string
+		objects    []runtime.Object
+		failedOver bool
+	}{
+		{
+			name:       "active installation is unreachable",
+			objects:    []runtime.Object{secret},
+			failedOver: true,
+		},
+		{
+			name: "secret can't be read",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mirror{
+				APIReader: fake.NewFakeClientWithScheme(scheme, tt.objects...),
+				Log:       log.NullLogger{},
+				Endpoint:  active.URL,
+				Secret:    types.NamespacedName{Namespace: "sidero-system", Name: "standby"},
+			}
+
+			for i := 0; i < 2; i++ {
+				if err := m.sync(context.Background()); err == nil {
+					t.Fatal("sync succeeded")
+				}
+			}
+
+			if m.Active() != tt.failedOver {
+				t.Errorf("failed over = %v, want %v", m.Active(), tt.failedOver)
+			}
+		})
+	}
+}
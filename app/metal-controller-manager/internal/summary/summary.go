@@ -0,0 +1,207 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package summary implements the fleet summary API.
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// Server phases.
+const (
+	// PhasePending is the server which is not accepted yet.
+	PhasePending = "Pending"
+	// PhaseWiping is the accepted server which is not in use and not wiped yet.
+	PhaseWiping = "Wiping"
+	// PhaseAvailable is the accepted clean server which is not in use.
+	PhaseAvailable = "Available"
+	// PhaseAllocated is the server in use.
+	PhaseAllocated = "Allocated"
+)
+
+// Counts is the number of the servers in each phase.
+type Counts struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Wiping    int `json:"wiping"`
+	Available int `json:"available"`
+	Allocated int `json:"allocated"`
+}
+
+func (c *Counts) add(phase string) {
+	c.Total++
+
+	switch phase {
+	case PhasePending:
+		c.Pending++
+	case PhaseWiping:
+		c.Wiping++
+	case PhaseAvailable:
+		c.Available++
+	case PhaseAllocated:
+		c.Allocated++
+	}
+}
+
+// Summary is the fleet summary.
+type Summary struct {
+	// GeneratedAt is the time the summary was computed at.
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Servers counts all the servers.
+	Servers Counts `json:"servers"`
+	// ServerClasses counts the servers of each ServerClass, as reported in the ServerClass status.
+	ServerClasses map[string]Counts `json:"serverClasses"`
+	// Sites counts the servers of each Site, by the site label of the servers.
+	Sites map[string]Counts `json:"sites"`
+	// Power counts the servers by the power state.
+	Power map[string]int `json:"power"`
+}
+
+// Phase returns the phase of the server.
+func Phase(server *metalv1alpha1.Server) string {
+	switch {
+	case !server.Spec.Accepted:
+		return PhasePending
+	case server.Status.InUse:
+		return PhaseAllocated
+	case !server.Status.IsClean:
+		return PhaseWiping
+	default:
+		return PhaseAvailable
+	}
+}
+
+// Compute builds the summary of the servers and the serverclasses.
+func Compute(servers []metalv1alpha1.Server, classes []metalv1alpha1.ServerClass, now time.Time) *Summary {
+	summary := &Summary{
+		GeneratedAt:   now.UTC(),
+		ServerClasses: make(map[string]Counts, len(classes)),
+		Sites:         map[string]Counts{},
+		Power:         map[string]int{},
+	}
+
+	for i := range servers {
+		server := &servers[i]
+		phase := Phase(server)
+
+		summary.Servers.add(phase)
+
+		if site, ok := server.Labels[metalv1alpha1.SiteLabel]; ok {
+			counts := summary.Sites[site]
+			counts.add(phase)
+			summary.Sites[site] = counts
+		}
+
+		power := server.Status.Power
+		if power == "" {
+			power = "unknown"
+		}
+
+		summary.Power[power]++
+	}
+
+	for i := range classes {
+		status := &classes[i].Status
+
+		// wiping servers are listed as available as well
+		summary.ServerClasses[classes[i].Name] = Counts{
+			Total:     len(status.ServersAvailable) + len(status.ServersInUse) + len(status.ServersPendingAcceptance),
+			Pending:   len(status.ServersPendingAcceptance),
+			Wiping:    len(status.ServersWipingInProgress),
+			Available: len(status.ServersAvailable) - len(status.ServersWipingInProgress),
+			Allocated: len(status.ServersInUse),
+		}
+	}
+
+	return summary
+}
+
+// Collector precomputes the fleet summary in the background, so that the dashboards polling the summary
+// don't list all the servers on each request.
+type Collector struct {
+	Client   client.Client
+	Log      logr.Logger
+	Interval time.Duration
+
+	mu      sync.Mutex
+	summary []byte
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+//
+// The summary is computed from the shared cache on every replica, so that any replica can serve it.
+func (c *Collector) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable.
+func (c *Collector) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.collect(context.Background())
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Collector) collect(ctx context.Context) {
+	var servers metalv1alpha1.ServerList
+
+	if err := c.Client.List(ctx, &servers); err != nil {
+		c.Log.Error(err, "failed to list servers")
+
+		return
+	}
+
+	var classes metalv1alpha1.ServerClassList
+
+	if err := c.Client.List(ctx, &classes); err != nil {
+		c.Log.Error(err, "failed to list serverclasses")
+
+		return
+	}
+
+	data, err := json.Marshal(Compute(servers.Items, classes.Items, time.Now()))
+	if err != nil {
+		c.Log.Error(err, "failed to marshal summary")
+
+		return
+	}
+
+	c.mu.Lock()
+	c.summary = data
+	c.mu.Unlock()
+}
+
+// ServeHTTP implements http.Handler, it serves the last computed summary.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	data := c.summary
+	c.mu.Unlock()
+
+	if data == nil {
+		http.Error(w, "summary is not computed yet", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data) //nolint: errcheck
+}
@@ -0,0 +1,69 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package summary_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/summary"
+)
+
+func TestCompute(t *testing.T) {
+	server := func(name, site string, accepted, inUse, clean bool, power string) metalv1alpha1.Server {
+		s := metalv1alpha1.Server{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       metalv1alpha1.ServerSpec{Accepted: accepted},
+			Status:     metalv1alpha1.ServerStatus{InUse: inUse, IsClean: clean, Power: power},
+		}
+
+		if site != "" {
+			s.Labels = map[string]string{metalv1alpha1.SiteLabel: site}
+		}
+
+		return s
+	}
+
+	servers := []metalv1alpha1.Server{
+		server("a", "dc1", false, false, false, ""),
+		server("b", "dc1", true, false, false, "on"),
+		server("c", "dc2", true, false, true, "off"),
+		server("d", "", true, true, false, "on"),
+	}
+
+	classes := []metalv1alpha1.ServerClass{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "workers"},
+			Status: metalv1alpha1.ServerClassStatus{
+				ServersAvailable:         []string{"b", "c"},
+				ServersInUse:             []string{"d"},
+				ServersPendingAcceptance: []string{"a"},
+				ServersWipingInProgress:  []string{"b"},
+			},
+		},
+	}
+
+	s := summary.Compute(servers, classes, time.Now())
+
+	if want := (summary.Counts{Total: 4, Pending: 1, Wiping: 1, Available: 1, Allocated: 1}); s.Servers != want {
+		t.Errorf("unexpected servers summary %+v", s.Servers)
+	}
+
+	if want := map[string]summary.Counts{"workers": {Total: 4, Pending: 1, Wiping: 1, Available: 1, Allocated: 1}}; !reflect.DeepEqual(s.ServerClasses, want) {
+		t.Errorf("unexpected serverclasses summary %+v", s.ServerClasses)
+	}
+
+	if want := map[string]summary.Counts{"dc1": {Total: 2, Pending: 1, Wiping: 1}, "dc2": {Total: 1, Available: 1}}; !reflect.DeepEqual(s.Sites, want) {
+		t.Errorf("unexpected sites summary %+v", s.Sites)
+	}
+
+	if want := map[string]int{"on": 2, "off": 1, "unknown": 1}; !reflect.DeepEqual(s.Power, want) {
+		t.Errorf("unexpected power summary %+v", s.Power)
+	}
+}
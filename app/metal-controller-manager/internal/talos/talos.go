@@ -0,0 +1,212 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package talos provides access to the hardware information of the running Talos nodes.
+package talos
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/talos-systems/talos/pkg/machinery/api/machine"
+	talosconfig "github.com/talos-systems/talos/pkg/machinery/client/config"
+	talosconstants "github.com/talos-systems/talos/pkg/machinery/constants"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// HardwareInfo describes the node hardware as seen by Talos.
+type HardwareInfo struct {
+	UUID              string
+	Hostname          string
+	SystemInformation metalv1alpha1.SystemInformation
+	CPU               metalv1alpha1.CPUInformation
+}
+
+// Client talks to the Talos API of a single node.
+type Client struct {
+	conn    *grpc.ClientConn
+	machine machine.MachineServiceClient
+}
+
+// NewClient connects to the Talos API at the endpoint using credentials from the talosconfig.
+func NewClient(ctx context.Context, endpoint string, talosConfig []byte) (*Client, error) {
+	cfg, err := talosconfig.FromBytes(talosConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing talosconfig: %w", err)
+	}
+
+	configContext := cfg.Contexts[cfg.Context]
+	if configContext == nil {
+		return nil, fmt.Errorf("context %q is not defined in talosconfig", cfg.Context)
+	}
+
+	tlsConfig, err := tlsConfig(configContext)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err = net.SplitHostPort(endpoint); err != nil {
+		endpoint = net.JoinHostPort(endpoint, strconv.Itoa(talosconstants.ApidPort))
+	}
+
+	conn, err := grpc.DialContext(ctx, endpoint, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:    conn,
+		machine: machine.NewMachineServiceClient(conn),
+	}, nil
+}
+
+// Close the client connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// HardwareInfo reads the node hardware information.
+func (c *Client) HardwareInfo(ctx context.Context) (*HardwareInfo, error) {
+	info := &HardwareInfo{}
+
+	dmi := map[string]*string{
+		"product_uuid":    &info.UUID,
+		"sys_vendor":      &info.SystemInformation.Manufacturer,
+		"product_name":    &info.SystemInformation.ProductName,
+		"product_version": &info.SystemInformation.Version,
+		"product_serial":  &info.SystemInformation.SerialNumber,
+		"product_sku":     &info.SystemInformation.SKUNumber,
+		"product_family":  &info.SystemInformation.Family,
+	}
+
+	for name, value := range dmi {
+		contents, err := c.readFile(ctx, "/sys/class/dmi/id/"+name)
+		if err != nil {
+			if name == "product_uuid" {
+				return nil, fmt.Errorf("error reading system UUID: %w", err)
+			}
+
+			// not all the fields are available on every platform
+			continue
+		}
+
+		*value = strings.TrimSpace(string(contents))
+	}
+
+	info.UUID = strings.ToLower(info.UUID)
+
+	hostnameResp, err := c.machine.Hostname(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading hostname: %w", err)
+	}
+
+	for _, msg := range hostnameResp.GetMessages() {
+		info.Hostname = msg.GetHostname()
+	}
+
+	cpuResp, err := c.machine.CPUInfo(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading CPU info: %w", err)
+	}
+
+	// each entry is the logical CPU, cores are identified by the physical and core IDs
+	cores := map[string]struct{}{}
+
+	for _, msg := range cpuResp.GetMessages() {
+		for _, cpu := range msg.GetCpuInfo() {
+			if info.CPU.Manufacturer == "" {
+				info.CPU.Manufacturer = cpu.GetVendorId()
+				info.CPU.Version = cpu.GetModelName()
+			}
+
+			info.CPU.Threads++
+
+			cores[cpu.GetPhysicalId()+"/"+cpu.GetCoreId()] = struct{}{}
+		}
+	}
+
+	info.CPU.Cores = len(cores)
+
+	return info, nil
+}
+
+// MachineConfig reads the machine configuration the node is running with.
+func (c *Client) MachineConfig(ctx context.Context) ([]byte, error) {
+	return c.readFile(ctx, talosconstants.ConfigPath)
+}
+
+func (c *Client) readFile(ctx context.Context, path string) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.machine.Read(ctx, &machine.ReadRequest{Path: path})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	for {
+		data, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return buf.Bytes(), nil
+			}
+
+			return nil, err
+		}
+
+		if data.GetMetadata().GetError() != "" {
+			return nil, errors.New(data.GetMetadata().GetError())
+		}
+
+		buf.Write(data.GetBytes())
+	}
+}
+
+func tlsConfig(configContext *talosconfig.Context) (*tls.Config, error) {
+	ca, err := base64.StdEncoding.DecodeString(configContext.CA)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding CA: %w", err)
+	}
+
+	crt, err := base64.StdEncoding.DecodeString(configContext.Crt)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding certificate: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(configContext.Key)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding key: %w", err)
+	}
+
+	certificate, err := tls.X509KeyPair(crt, key)
+	if err != nil {
+		return nil, fmt.Errorf("error loading client certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("failed to load CA")
+	}
+
+	return &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -5,13 +5,25 @@
 package tftp
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/pin/tftp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/health"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
 )
 
 // cleanPath makes a path safe for use with filepath.Join. This is done by not
@@ -43,11 +55,19 @@ func cleanPath(path string) string {
 	return filepath.Clean(path)
 }
 
+var (
+	root           string
+	filesConfigMap types.NamespacedName
+	reader         client.Reader
+)
+
 // readHandler is called when client starts file download from server.
 func readHandler(filename string, rf io.ReaderFrom) error {
-	filename = filepath.Join("/var/lib/sidero/tftp", cleanPath(filename))
+	if !health.Serving.Open() {
+		return fmt.Errorf("%s: boot services are served by the active Sidero installation", filename)
+	}
 
-	file, err := os.Open(filename)
+	file, err := open(cleanPath(filename))
 	if err != nil {
 		log.Printf("%v", err)
 
@@ -68,8 +88,66 @@ func readHandler(filename string, rf io.ReaderFrom) error {
 	return nil
 }
 
-func ServeTFTP() error {
-	if err := os.MkdirAll("/var/lib/sidero/tftp", 0o777); err != nil {
+// open looks up the file in the files ConfigMap first, then in the TFTP root, and then in the built-in files (iPXE binaries).
+func open(name string) (io.ReadCloser, error) {
+	if data, ok := configMapFile(name); ok {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	for _, dir := range []string{root, constants.TFTPDirectory} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err == nil {
+			return file, nil
+		}
+
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("file not found: %q", name)
+}
+
+// configMapFile returns the file contents from the files ConfigMap.
+//
+// ConfigMap is read on each request, so the changes are picked up immediately.
+// Failure to read the ConfigMap is logged, and the file is looked up in the TFTP root instead.
+func configMapFile(name string) ([]byte, bool) {
+	if filesConfigMap.Name == "" {
+		return nil, false
+	}
+
+	var configMap corev1.ConfigMap
+
+	if err := reader.Get(context.Background(), filesConfigMap, &configMap); err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Printf("failed to get configmap %s: %v", filesConfigMap, err)
+		}
+
+		return nil, false
+	}
+
+	if data, ok := configMap.BinaryData[name]; ok {
+		return data, true
+	}
+
+	if data, ok := configMap.Data[name]; ok {
+		return []byte(data), true
+	}
+
+	return nil, false
+}
+
+// +kubebuilder:rbac:groups="",namespace=sidero-system,resources=configmaps,verbs=get
+
+// ServeTFTP serves the files from the TFTP root directory (falling back to the built-in files),
+// and the files published via the ConfigMap (if set), with the ConfigMap keys being the file names.
+func ServeTFTP(rootDir string, configMap types.NamespacedName, apiReader client.Reader) error {
+	root = rootDir
+	filesConfigMap = configMap
+	reader = apiReader
+
+	if err := os.MkdirAll(constants.TFTPDirectory, 0o777); err != nil {
 		return err
 	}
 
@@ -84,9 +162,17 @@ func ServeTFTP() error {
 	s.EnableSinglePort()
 	s.SetTimeout(5 * time.Second)
 
-	if err := s.ListenAndServe(":69"); err != nil {
+	addr, err := net.ResolveUDPAddr("udp", ":69")
+	if err != nil {
 		return err
 	}
 
-	return nil
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	health.TFTP.Listening()
+
+	return s.Serve(conn)
 }
@@ -0,0 +1,155 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package tftp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_cleanPath(t *testing.T) {
+	for _, tt := range []struct {
+		path     string
+		expected string
+	}{
+		{path: "", expected: ""},
+		{path: "undionly.kpxe", expected: "undionly.kpxe"},
+		{path: "/undionly.kpxe", expected: "/undionly.kpxe"},
+		{path: "pxelinux.cfg/default", expected: "pxelinux.cfg/default"},
+		{path: "../../etc/passwd", expected: "etc/passwd"},
+		{path: "/../../etc/passwd", expected: "/etc/passwd"},
+		{path: "a/../../b", expected: "b"},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := cleanPath(tt.path); got != tt.expected {
+				t.Errorf("cleanPath(%q) = %q, want %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_open(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir) //nolint: errcheck
+
+	for name, contents := range map[string]string{
+		"undionly.kpxe":  "root undionly.kpxe",
+		"boot/grub.cfg":  "root grub.cfg",
+		"overridden.cfg": "root overridden.cfg",
+	} {
+		if err = os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scheme := runtime.NewScheme()
+
+	if err = corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	root = dir
+	reader = fake.NewFakeClientWithScheme(scheme, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "sidero-system", Name: "tftp-files"},
+		Data: map[string]string{
+			"overridden.cfg": "configmap overridden.cfg",
+		},
+		BinaryData: map[string][]byte{
+			"firmware.bin": {0xde, 0xad, 0xbe, 0xef},
+		},
+	})
+
+	defer func() {
+		root = ""
+		filesConfigMap = types.NamespacedName{}
+		reader = nil
+	}()
+
+	for _, tt := range []struct {
+		name      string
+		configMap types.NamespacedName
+		expected  string
+		notFound  bool
+	}{
+		{
+			name:     "undionly.kpxe",
+			expected: "root undionly.kpxe",
+		},
+		{
+			name:     "boot/grub.cfg",
+			expected: "root grub.cfg",
+		},
+		{
+			name:     "overridden.cfg",
+			expected: "root overridden.cfg",
+		},
+		{
+			name:      "overridden.cfg",
+			configMap: types.NamespacedName{Namespace: "sidero-system", Name: "tftp-files"},
+			expected:  "configmap overridden.cfg",
+		},
+		{
+			name:      "firmware.bin",
+			configMap: types.NamespacedName{Namespace: "sidero-system", Name: "tftp-files"},
+			expected:  "\xde\xad\xbe\xef",
+		},
+		{
+			name:      "undionly.kpxe",
+			configMap: types.NamespacedName{Namespace: "sidero-system", Name: "missing"},
+			expected:  "root undionly.kpxe",
+		},
+		{
+			name:     "missing.cfg",
+			notFound: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			filesConfigMap = tt.configMap
+
+			file, err := open(cleanPath(tt.name))
+			if tt.notFound {
+				if err == nil {
+					file.Close() //nolint: errcheck
+
+					t.Fatal("missing file is found")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer file.Close() //nolint: errcheck
+
+			contents, err := ioutil.ReadAll(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(contents) != tt.expected {
+				t.Errorf("contents = %q, want %q", contents, tt.expected)
+			}
+		})
+	}
+}
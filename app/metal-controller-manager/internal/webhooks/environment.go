@@ -0,0 +1,171 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package webhooks implements admission webhooks of the metal controller manager.
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// EnvironmentValidatorPath is the path the Environment validating webhook is served at.
+const EnvironmentValidatorPath = "/validate-metal-sidero-dev-v1alpha1-environment"
+
+// EnvironmentValidator blocks changes to the assets (and deletion) of the Environments which are used by the servers being provisioned.
+//
+// Server is being provisioned from the moment it is allocated until its MetalMachine becomes ready,
+// replacing the assets in the meantime might break the boot (e.g. the server gets 404 fetching the initrd).
+type EnvironmentValidator struct {
+	Client client.Client
+
+	decoder *admission.Decoder
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *EnvironmentValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *EnvironmentValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Update && req.Operation != admissionv1beta1.Delete {
+		return admission.Allowed("")
+	}
+
+	var old metalv1alpha1.Environment
+
+	if err := v.decoder.DecodeRaw(req.OldObject, &old); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	forced := isForced(&old)
+
+	if req.Operation == admissionv1beta1.Update {
+		var env metalv1alpha1.Environment
+
+		if err := v.decoder.DecodeRaw(req.Object, &env); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		if !assetsChanged(&old, &env) {
+			return admission.Allowed("")
+		}
+
+		forced = isForced(&env)
+	}
+
+	if forced {
+		return admission.Allowed("forced with " + metalv1alpha1.ForceChangeAnnotation)
+	}
+
+	servers, err := v.provisioningServers(ctx, old.Name)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if len(servers) > 0 {
+		return admission.Denied(fmt.Sprintf("environment %q is used by the servers being provisioned: %s; wait for the provisioning to finish or set the %q annotation",
+			old.Name, strings.Join(servers, ", "), metalv1alpha1.ForceChangeAnnotation))
+	}
+
+	return admission.Allowed("")
+}
+
+// provisioningServers returns the names of the servers being provisioned from the environment.
+func (v *EnvironmentValidator) provisioningServers(ctx context.Context, envName string) ([]string, error) {
+	var serverBindings infrav1.ServerBindingList
+
+	if err := v.Client.List(ctx, &serverBindings); err != nil {
+		return nil, err
+	}
+
+	var servers []string
+
+	for i := range serverBindings.Items {
+		serverBinding := &serverBindings.Items[i]
+
+		var metalMachine infrav1.MetalMachine
+
+		if err := v.Client.Get(ctx, types.NamespacedName{Namespace: serverBinding.Spec.MetalMachineRef.Namespace, Name: serverBinding.Spec.MetalMachineRef.Name}, &metalMachine); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if metalMachine.Status.Ready {
+			continue
+		}
+
+		name, err := v.serverEnvironment(ctx, serverBinding)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == envName {
+			servers = append(servers, serverBinding.Name)
+		}
+	}
+
+	sort.Strings(servers)
+
+	return servers, nil
+}
+
+// serverEnvironment returns the name of the environment the bound server boots, with the same precedence as the iPXE server.
+func (v *EnvironmentValidator) serverEnvironment(ctx context.Context, serverBinding *infrav1.ServerBinding) (string, error) {
+	var server metalv1alpha1.Server
+
+	if err := v.Client.Get(ctx, types.NamespacedName{Name: serverBinding.Name}, &server); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+
+	if server.Spec.EnvironmentRef != nil {
+		return server.Spec.EnvironmentRef.Name, nil
+	}
+
+	if serverBinding.Spec.ServerClassRef != nil {
+		var serverClass metalv1alpha1.ServerClass
+
+		if err := v.Client.Get(ctx, types.NamespacedName{Name: serverBinding.Spec.ServerClassRef.Name}, &serverClass); err != nil && !apierrors.IsNotFound(err) {
+			return "", err
+		}
+
+		if envRef := serverClass.EnvironmentFor(server.Name); envRef != nil {
+			return envRef.Name, nil
+		}
+	}
+
+	return "default", nil
+}
+
+// assetsChanged checks whether the kernel or initrd of the environment was replaced.
+func assetsChanged(old, env *metalv1alpha1.Environment) bool {
+	return old.Spec.Kernel.URL != env.Spec.Kernel.URL ||
+		old.Spec.Kernel.SHA512 != env.Spec.Kernel.SHA512 ||
+		old.Spec.Initrd.URL != env.Spec.Initrd.URL ||
+		old.Spec.Initrd.SHA512 != env.Spec.Initrd.SHA512
+}
+
+func isForced(env *metalv1alpha1.Environment) bool {
+	_, ok := env.Annotations[metalv1alpha1.ForceChangeAnnotation]
+
+	return ok
+}
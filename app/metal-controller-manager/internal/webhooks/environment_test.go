@@ -0,0 +1,158 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func testEnvironment(kernelURL string, forced bool) *metalv1alpha1.Environment {
+	env := &metalv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "default",
+		},
+	}
+
+	env.Spec.Kernel.URL = kernelURL
+
+	if forced {
+		env.Annotations = map[string]string{metalv1alpha1.ForceChangeAnnotation: ""}
+	}
+
+	return env
+}
+
+func TestEnvironmentValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverBinding := &infrav1.ServerBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "server-1",
+		},
+		Spec: infrav1.ServerBindingSpec{
+			MetalMachineRef: corev1.ObjectReference{Namespace: "default", Name: "machine-1"},
+		},
+	}
+
+	server := &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "server-1",
+		},
+	}
+
+	withArgs := testEnvironment("http://a/vmlinuz", false)
+	withArgs.Spec.Kernel.Args = []string{"console=tty0"}
+
+	for _, tt := range []struct {
+		name      string
+		operation admissionv1beta1.Operation
+		old       *metalv1alpha1.Environment
+		new       *metalv1alpha1.Environment
+		ready     bool
+		allowed   bool
+	}{
+		{
+			name:      "args change",
+			operation: admissionv1beta1.Update,
+			old:       testEnvironment("http://a/vmlinuz", false),
+			new:       withArgs,
+			allowed:   true,
+		},
+		{
+			name:      "asset change while provisioning",
+			operation: admissionv1beta1.Update,
+			old:       testEnvironment("http://a/vmlinuz", false),
+			new:       testEnvironment("http://b/vmlinuz", false),
+			allowed:   false,
+		},
+		{
+			name:      "forced asset change while provisioning",
+			operation: admissionv1beta1.Update,
+			old:       testEnvironment("http://a/vmlinuz", false),
+			new:       testEnvironment("http://b/vmlinuz", true),
+			allowed:   true,
+		},
+		{
+			name:      "asset change after provisioning",
+			operation: admissionv1beta1.Update,
+			old:       testEnvironment("http://a/vmlinuz", false),
+			new:       testEnvironment("http://b/vmlinuz", false),
+			ready:     true,
+			allowed:   true,
+		},
+		{
+			name:      "delete while provisioning",
+			operation: admissionv1beta1.Delete,
+			old:       testEnvironment("http://a/vmlinuz", false),
+			allowed:   false,
+		},
+		{
+			name:      "forced delete while provisioning",
+			operation: admissionv1beta1.Delete,
+			old:       testEnvironment("http://a/vmlinuz", true),
+			allowed:   true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			metalMachine := &infrav1.MetalMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "machine-1",
+				},
+				Status: infrav1.MetalMachineStatus{
+					Ready: tt.ready,
+				},
+			}
+
+			v := &EnvironmentValidator{
+				Client: fake.NewFakeClientWithScheme(scheme, serverBinding, server, metalMachine),
+			}
+
+			if err := v.InjectDecoder(decoder); err != nil {
+				t.Fatal(err)
+			}
+
+			req := admission.Request{}
+			req.Operation = tt.operation
+			req.OldObject.Raw, _ = json.Marshal(tt.old) //nolint: errcheck
+
+			if tt.new != nil {
+				req.Object.Raw, _ = json.Marshal(tt.new) //nolint: errcheck
+			}
+
+			resp := v.Handle(context.Background(), req)
+
+			if resp.Allowed != tt.allowed {
+				t.Errorf("allowed = %v, want %v: %v", resp.Allowed, tt.allowed, resp.Result)
+			}
+		})
+	}
+}
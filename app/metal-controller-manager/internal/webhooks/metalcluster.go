@@ -0,0 +1,71 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+)
+
+// MetalClusterRequesterPath is the path the MetalCluster mutating webhook is served at.
+const MetalClusterRequesterPath = "/mutate-infrastructure-cluster-x-k8s-io-v1alpha3-metalcluster"
+
+// MetalClusterRequester records the user requesting the cluster power off.
+//
+// The value of the MetalCluster PowerOffAnnotation is replaced with the name of the user setting it (so the API server
+// authentication vouches for it), the Cluster API provider copies the value to the servers as the power off requester.
+type MetalClusterRequester struct {
+	decoder *admission.Decoder
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (m *MetalClusterRequester) InjectDecoder(d *admission.Decoder) error {
+	m.decoder = d
+
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (m *MetalClusterRequester) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return admission.Allowed("")
+	}
+
+	var old, metalCluster infrav1.MetalCluster
+
+	if err := m.decoder.DecodeRaw(req.Object, &metalCluster); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1beta1.Update {
+		if err := m.decoder.DecodeRaw(req.OldObject, &old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	value, ok := metalCluster.Annotations[infrav1.PowerOffAnnotation]
+	if !ok || value == req.UserInfo.Username {
+		return admission.Allowed("")
+	}
+
+	if oldValue, wasSet := old.Annotations[infrav1.PowerOffAnnotation]; wasSet && oldValue == value {
+		return admission.Allowed("")
+	}
+
+	metalCluster.Annotations[infrav1.PowerOffAnnotation] = req.UserInfo.Username
+
+	marshalled, err := json.Marshal(&metalCluster)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshalled)
+}
@@ -0,0 +1,116 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+)
+
+func testMetalCluster(annotations map[string]string) *infrav1.MetalCluster {
+	return &infrav1.MetalCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "management",
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestMetalClusterRequester(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		user    string
+		old     *infrav1.MetalCluster
+		new     *infrav1.MetalCluster
+		patched bool
+	}{
+		{
+			name: "power off not requested",
+			user: "alice",
+			old:  testMetalCluster(nil),
+			new:  testMetalCluster(nil),
+		},
+		{
+			name:    "power off requested",
+			user:    "alice",
+			old:     testMetalCluster(nil),
+			new:     testMetalCluster(map[string]string{infrav1.PowerOffAnnotation: "true"}),
+			patched: true,
+		},
+		{
+			name:    "power off requested on behalf of another user",
+			user:    "alice",
+			old:     testMetalCluster(nil),
+			new:     testMetalCluster(map[string]string{infrav1.PowerOffAnnotation: "bob"}),
+			patched: true,
+		},
+		{
+			name: "power off requested with the user name",
+			user: "alice",
+			old:  testMetalCluster(nil),
+			new:  testMetalCluster(map[string]string{infrav1.PowerOffAnnotation: "alice"}),
+		},
+		{
+			name: "unrelated change",
+			user: "system:serviceaccount:sidero-system:default",
+			old:  testMetalCluster(map[string]string{infrav1.PowerOffAnnotation: "alice"}),
+			new: testMetalCluster(map[string]string{
+				infrav1.PowerOffAnnotation: "alice",
+				"example.com/owner":        "team-a",
+			}),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MetalClusterRequester{}
+
+			if err := m.InjectDecoder(decoder); err != nil {
+				t.Fatal(err)
+			}
+
+			req := admission.Request{}
+			req.Operation = admissionv1beta1.Update
+			req.UserInfo.Username = tt.user
+			req.OldObject.Raw, _ = json.Marshal(tt.old) //nolint: errcheck
+			req.Object.Raw, _ = json.Marshal(tt.new)    //nolint: errcheck
+
+			resp := m.Handle(context.Background(), req)
+
+			if !resp.Allowed {
+				t.Fatalf("request denied: %v", resp.Result)
+			}
+
+			if patched := len(resp.Patches) > 0; patched != tt.patched {
+				t.Fatalf("patched = %v, want %v: %v", patched, tt.patched, resp.Patches)
+			}
+
+			for _, patch := range resp.Patches {
+				if patch.Value != tt.user {
+					t.Errorf("requester = %v, want %q", patch.Value, tt.user)
+				}
+			}
+		})
+	}
+}
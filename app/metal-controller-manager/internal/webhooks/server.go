@@ -0,0 +1,134 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// ServerValidatorPath is the path the Server validating webhook is served at.
+const ServerValidatorPath = "/validate-metal-sidero-dev-v1alpha1-server"
+
+// signedAnnotations are set to the name of the user setting them.
+var signedAnnotations = []string{
+	metalv1alpha1.PowerOffAnnotation,
+	metalv1alpha1.PowerOffApprovedByAnnotation,
+	metalv1alpha1.WipeApprovedByAnnotation,
+	metalv1alpha1.DeleteApprovedByAnnotation,
+}
+
+// ServerValidator enforces the two-person rule for the destructive actions on the servers.
+//
+// Power off request and the approvals should be set to the name of the user setting them (so the API server
+// authentication vouches for them), and the power off and the deletion should be approved by another user.
+// Trusted requesters (the Cluster API provider) request the power off on behalf of other users (the cluster power off,
+// where the requester is recorded by the MetalCluster webhook), so the request value is not checked for them.
+// Removing the annotations is always allowed.
+type ServerValidator struct {
+	// RequireApproval enables the validation, it follows the --require-approval flag.
+	RequireApproval bool
+	// TrustedRequesters are the user names allowed to request the power off on behalf of other users
+	// and to delete the servers without the approval (the Sidero service accounts).
+	TrustedRequesters []string
+
+	decoder *admission.Decoder
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *ServerValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *ServerValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if !v.RequireApproval {
+		return admission.Allowed("")
+	}
+
+	user := req.UserInfo.Username
+
+	switch req.Operation {
+	case admissionv1beta1.Create, admissionv1beta1.Update:
+	case admissionv1beta1.Delete:
+		return v.handleDelete(req, user)
+	default:
+		return admission.Allowed("")
+	}
+
+	var old, server metalv1alpha1.Server
+
+	if err := v.decoder.DecodeRaw(req.Object, &server); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1beta1.Update {
+		if err := v.decoder.DecodeRaw(req.OldObject, &old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	for _, annotation := range signedAnnotations {
+		value, ok := server.Annotations[annotation]
+		if !ok {
+			continue
+		}
+
+		if oldValue, wasSet := old.Annotations[annotation]; wasSet && oldValue == value {
+			continue
+		}
+
+		if annotation == metalv1alpha1.PowerOffAnnotation && v.trusted(user) {
+			continue
+		}
+
+		if value != user {
+			return admission.Denied(fmt.Sprintf("annotation %q should be set to the name of the user setting it: %q", annotation, user))
+		}
+
+		if annotation == metalv1alpha1.PowerOffApprovedByAnnotation && value == server.Annotations[metalv1alpha1.PowerOffAnnotation] {
+			return admission.Denied(fmt.Sprintf("power off of the server %q should be approved by another user", server.Name))
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+// handleDelete allows the deletion of the server only once it's approved by another user.
+func (v *ServerValidator) handleDelete(req admission.Request, user string) admission.Response {
+	if v.trusted(user) {
+		return admission.Allowed("")
+	}
+
+	var server metalv1alpha1.Server
+
+	if err := v.decoder.DecodeRaw(req.OldObject, &server); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if !server.DeleteApproved(user) {
+		return admission.Denied(fmt.Sprintf("deletion of the server %q should be approved by another user with annotation %q", server.Name, metalv1alpha1.DeleteApprovedByAnnotation))
+	}
+
+	return admission.Allowed("")
+}
+
+func (v *ServerValidator) trusted(user string) bool {
+	for _, requester := range v.TrustedRequesters {
+		if user == requester {
+			return true
+		}
+	}
+
+	return false
+}
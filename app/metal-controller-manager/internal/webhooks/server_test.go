@@ -0,0 +1,243 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func testServer(annotations map[string]string) *metalv1alpha1.Server {
+	return &metalv1alpha1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "server-1",
+			Annotations: annotations,
+		},
+	}
+}
+
+var trustedRequesters = []string{"system:serviceaccount:sidero-system:default"}
+
+func TestServerValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name            string
+		requireApproval bool
+		user            string
+		old             *metalv1alpha1.Server
+		new             *metalv1alpha1.Server
+		allowed         bool
+	}{
+		{
+			name:    "approval not required",
+			user:    "alice",
+			old:     testServer(nil),
+			new:     testServer(map[string]string{metalv1alpha1.PowerOffAnnotation: "true"}),
+			allowed: true,
+		},
+		{
+			name:            "power off requested",
+			requireApproval: true,
+			user:            "alice",
+			old:             testServer(nil),
+			new:             testServer(map[string]string{metalv1alpha1.PowerOffAnnotation: "alice"}),
+			allowed:         true,
+		},
+		{
+			name:            "power off requested on behalf of another user",
+			requireApproval: true,
+			user:            "alice",
+			old:             testServer(nil),
+			new:             testServer(map[string]string{metalv1alpha1.PowerOffAnnotation: "bob"}),
+			allowed:         false,
+		},
+		{
+			name:            "power off requested by the controller",
+			requireApproval: true,
+			user:            "system:serviceaccount:sidero-system:default",
+			old:             testServer(nil),
+			new:             testServer(map[string]string{metalv1alpha1.PowerOffAnnotation: "alice"}),
+			allowed:         true,
+		},
+		{
+			name:            "power off requested by another service account",
+			requireApproval: true,
+			user:            "system:serviceaccount:default:default",
+			old:             testServer(nil),
+			new:             testServer(map[string]string{metalv1alpha1.PowerOffAnnotation: "alice"}),
+			allowed:         false,
+		},
+		{
+			name:            "power off approved",
+			requireApproval: true,
+			user:            "bob",
+			old:             testServer(map[string]string{metalv1alpha1.PowerOffAnnotation: "alice"}),
+			new: testServer(map[string]string{
+				metalv1alpha1.PowerOffAnnotation:           "alice",
+				metalv1alpha1.PowerOffApprovedByAnnotation: "bob",
+			}),
+			allowed: true,
+		},
+		{
+			name:            "power off approved by the requester",
+			requireApproval: true,
+			user:            "alice",
+			old:             testServer(map[string]string{metalv1alpha1.PowerOffAnnotation: "alice"}),
+			new: testServer(map[string]string{
+				metalv1alpha1.PowerOffAnnotation:           "alice",
+				metalv1alpha1.PowerOffApprovedByAnnotation: "alice",
+			}),
+			allowed: false,
+		},
+		{
+			name:            "wipe approved on behalf of another user",
+			requireApproval: true,
+			user:            "alice",
+			old:             testServer(nil),
+			new:             testServer(map[string]string{metalv1alpha1.WipeApprovedByAnnotation: "bob"}),
+			allowed:         false,
+		},
+		{
+			name:            "approval removed",
+			requireApproval: true,
+			user:            "system:serviceaccount:sidero-system:default",
+			old:             testServer(map[string]string{metalv1alpha1.WipeApprovedByAnnotation: "bob"}),
+			new:             testServer(nil),
+			allowed:         true,
+		},
+		{
+			name:            "unrelated change",
+			requireApproval: true,
+			user:            "system:serviceaccount:sidero-system:default",
+			old:             testServer(map[string]string{metalv1alpha1.WipeApprovedByAnnotation: "bob"}),
+			new: testServer(map[string]string{
+				metalv1alpha1.WipeApprovedByAnnotation: "bob",
+				"example.com/owner":                    "team-a",
+			}),
+			allowed: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &ServerValidator{RequireApproval: tt.requireApproval, TrustedRequesters: trustedRequesters}
+
+			if err := v.InjectDecoder(decoder); err != nil {
+				t.Fatal(err)
+			}
+
+			req := admission.Request{}
+			req.Operation = admissionv1beta1.Update
+			req.UserInfo.Username = tt.user
+			req.OldObject.Raw, _ = json.Marshal(tt.old) //nolint: errcheck
+			req.Object.Raw, _ = json.Marshal(tt.new)    //nolint: errcheck
+
+			resp := v.Handle(context.Background(), req)
+
+			if resp.Allowed != tt.allowed {
+				t.Errorf("allowed = %v, want %v: %v", resp.Allowed, tt.allowed, resp.Result)
+			}
+		})
+	}
+}
+
+func TestServerValidatorDelete(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name            string
+		requireApproval bool
+		user            string
+		server          *metalv1alpha1.Server
+		allowed         bool
+	}{
+		{
+			name:    "approval not required",
+			user:    "alice",
+			server:  testServer(nil),
+			allowed: true,
+		},
+		{
+			name:            "not approved",
+			requireApproval: true,
+			user:            "alice",
+			server:          testServer(nil),
+			allowed:         false,
+		},
+		{
+			name:            "approved",
+			requireApproval: true,
+			user:            "alice",
+			server:          testServer(map[string]string{metalv1alpha1.DeleteApprovedByAnnotation: "bob"}),
+			allowed:         true,
+		},
+		{
+			name:            "approved by the deleting user",
+			requireApproval: true,
+			user:            "alice",
+			server:          testServer(map[string]string{metalv1alpha1.DeleteApprovedByAnnotation: "alice"}),
+			allowed:         false,
+		},
+		{
+			name:            "deleted by the controller",
+			requireApproval: true,
+			user:            "system:serviceaccount:sidero-system:default",
+			server:          testServer(nil),
+			allowed:         true,
+		},
+		{
+			name:            "deleted by another service account",
+			requireApproval: true,
+			user:            "system:serviceaccount:default:default",
+			server:          testServer(nil),
+			allowed:         false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &ServerValidator{RequireApproval: tt.requireApproval, TrustedRequesters: trustedRequesters}
+
+			if err := v.InjectDecoder(decoder); err != nil {
+				t.Fatal(err)
+			}
+
+			req := admission.Request{}
+			req.Operation = admissionv1beta1.Delete
+			req.UserInfo.Username = tt.user
+			req.OldObject.Raw, _ = json.Marshal(tt.server) //nolint: errcheck
+
+			resp := v.Handle(context.Background(), req)
+
+			if resp.Allowed != tt.allowed {
+				t.Errorf("allowed = %v, want %v: %v", resp.Allowed, tt.allowed, resp.Result)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/expression"
+)
+
+// ServerClassValidatorPath is the path the ServerClass validating webhook is served at.
+const ServerClassValidatorPath = "/validate-metal-sidero-dev-v1alpha1-serverclass"
+
+// nodeUUIDLabel is set on the nodes by the metadata server, ServerClass can't override it.
+const nodeUUIDLabel = "metal.sidero.dev/uuid"
+
+// ServerClassValidator rejects the ServerClasses with the qualifiers which are obviously broken.
+//
+// Broken qualifiers don't fail the reconciliation, they silently match no servers instead, so the mistake is only noticed
+// when the cluster doesn't scale.
+type ServerClassValidator struct {
+	decoder *admission.Decoder
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *ServerClassValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *ServerClassValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return admission.Allowed("")
+	}
+
+	var serverClass metalv1alpha1.ServerClass
+
+	if err := v.decoder.DecodeRaw(req.Object, &serverClass); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if problems := qualifiersProblems(&serverClass.Spec.Qualifiers); len(problems) > 0 {
+		return admission.Denied(fmt.Sprintf("serverclass %q qualifiers are invalid: %s", serverClass.Name, strings.Join(problems, "; ")))
+	}
+
+	if problems := nodeProblems(&serverClass.Spec); len(problems) > 0 {
+		return admission.Denied(fmt.Sprintf("serverclass %q node labels and taints are invalid: %s", serverClass.Name, strings.Join(problems, "; ")))
+	}
+
+	for _, name := range serverClass.Spec.IncludeClasses {
+		if name == serverClass.Name {
+			return admission.Denied(fmt.Sprintf("serverclass %q includes itself", serverClass.Name))
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+// qualifiersProblems describes the problems of the qualifiers and the exclusions.
+func qualifiersProblems(qualifiers *metalv1alpha1.Qualifiers) []string {
+	problems := selectorProblems("qualifiers", qualifiers)
+
+	if qualifiers.Exclude == nil {
+		return problems
+	}
+
+	exclusion := qualifiers.Exclude.Qualifiers()
+
+	problems = append(problems, selectorProblems("qualifiers.exclude", &exclusion)...)
+
+	if excludesEverything(qualifiers) {
+		problems = append(problems, "qualifiers.exclude removes all the servers matched by the qualifiers")
+	}
+
+	return problems
+}
+
+// selectorProblems describes the problems of the qualifiers which make them fail to match.
+func selectorProblems(prefix string, qualifiers *metalv1alpha1.Qualifiers) []string {
+	var problems []string
+
+	for i := range qualifiers.CPU {
+		if err := metalv1alpha1.ValidatePartial(&qualifiers.CPU[i]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.cpu[%d]: %s", prefix, i, err))
+		}
+
+		if cpu := &qualifiers.CPU[i]; cpu.MaxThreads > 0 && cpu.MinThreads > cpu.MaxThreads {
+			problems = append(problems, fmt.Sprintf("%s.cpu[%d]: minThreads %d is greater than maxThreads %d", prefix, i, cpu.MinThreads, cpu.MaxThreads))
+		}
+	}
+
+	for i := range qualifiers.SystemInformation {
+		if err := metalv1alpha1.ValidatePartial(&qualifiers.SystemInformation[i]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.systemInformation[%d]: %s", prefix, i, err))
+		}
+	}
+
+	for i := range qualifiers.PCIDevices {
+		if err := metalv1alpha1.ValidatePartial(&qualifiers.PCIDevices[i]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.pciDevices[%d]: %s", prefix, i, err))
+		}
+	}
+
+	for i := range qualifiers.GPUs {
+		if err := metalv1alpha1.ValidatePartial(&qualifiers.GPUs[i]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.gpus[%d]: %s", prefix, i, err))
+		}
+	}
+
+	for i, selector := range qualifiers.LabelSelectors {
+		if len(selector) == 0 {
+			problems = append(problems, fmt.Sprintf("%s.labelSelectors[%d] is empty and matches no servers", prefix, i))
+
+			continue
+		}
+
+		for j := 0; j < i; j++ {
+			if reflect.DeepEqual(selector, qualifiers.LabelSelectors[j]) {
+				problems = append(problems, fmt.Sprintf("%s.labelSelectors[%d] duplicates labelSelectors[%d]", prefix, i, j))
+
+				break
+			}
+		}
+	}
+
+	if qualifiers.Expression != "" {
+		if _, err := expression.Compile(qualifiers.Expression); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.expression: %s", prefix, err))
+		} else if unknown, err := expression.UnknownFields(qualifiers.Expression); err == nil && len(unknown) > 0 {
+			problems = append(problems, fmt.Sprintf("%s.expression references unknown fields: %s", prefix, strings.Join(unknown, ", ")))
+		}
+	}
+
+	return problems
+}
+
+// nodeProblems describes the problems of the node labels and taints.
+//
+// Labels and taints are passed to the kubelet as the comma-separated flags, so anything which is not a valid
+// label breaks the kubelet arguments instead of failing the node registration.
+func nodeProblems(spec *metalv1alpha1.ServerClassSpec) []string {
+	var problems []string
+
+	keys := make([]string, 0, len(spec.NodeLabels))
+
+	for key := range spec.NodeLabels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, msg := range validation.IsQualifiedName(key) {
+			problems = append(problems, fmt.Sprintf("nodeLabels key %q: %s", key, msg))
+		}
+
+		for _, msg := range validation.IsValidLabelValue(spec.NodeLabels[key]) {
+			problems = append(problems, fmt.Sprintf("nodeLabels[%q] value %q: %s", key, spec.NodeLabels[key], msg))
+		}
+
+		if key == nodeUUIDLabel {
+			problems = append(problems, fmt.Sprintf("nodeLabels key %q is reserved", key))
+		}
+	}
+
+	for i, taint := range spec.NodeTaints {
+		for _, msg := range validation.IsQualifiedName(taint.Key) {
+			problems = append(problems, fmt.Sprintf("nodeTaints[%d].key %q: %s", i, taint.Key, msg))
+		}
+
+		for _, msg := range validation.IsValidLabelValue(taint.Value) {
+			problems = append(problems, fmt.Sprintf("nodeTaints[%d].value %q: %s", i, taint.Value, msg))
+		}
+
+		switch taint.Effect {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			problems = append(problems, fmt.Sprintf("nodeTaints[%d].effect %q is not one of NoSchedule, PreferNoSchedule, NoExecute", i, taint.Effect))
+		}
+	}
+
+	return problems
+}
+
+// excludesEverything checks whether every server matched by the qualifiers is excluded.
+//
+// It's the case if each exclusion qualifier either repeats the qualifier, or matches any server
+// (e.g. the empty CPU entry).
+func excludesEverything(qualifiers *metalv1alpha1.Qualifiers) bool {
+	if qualifiers.Exclude == nil || qualifiers.Exclude.IsEmpty() {
+		return false
+	}
+
+	exclusion := reflect.ValueOf(qualifiers.Exclude.Qualifiers())
+	included := reflect.ValueOf(*qualifiers)
+
+	for i := 0; i < exclusion.NumField(); i++ {
+		name := exclusion.Type().Field(i).Name
+		field := exclusion.Field(i)
+
+		if name == "Exclude" || field.IsZero() {
+			continue
+		}
+
+		if reflect.DeepEqual(field.Interface(), included.FieldByName(name).Interface()) {
+			continue
+		}
+
+		if (name == "CPU" || name == "SystemInformation") && hasEmptyEntry(field) {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// hasEmptyEntry checks whether the list has the entry without any fields set, such entry matches any server.
+func hasEmptyEntry(list reflect.Value) bool {
+	for i := 0; i < list.Len(); i++ {
+		if list.Index(i).IsZero() {
+			return true
+		}
+	}
+
+	return false
+}
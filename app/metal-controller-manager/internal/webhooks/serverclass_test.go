@@ -0,0 +1,188 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestServerClassValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		qualifiers metalv1alpha1.Qualifiers
+		nodeLabels map[string]string
+		nodeTaints []corev1.Taint
+		allowed    bool
+	}{
+		{
+			name:    "empty qualifiers",
+			allowed: true,
+		},
+		{
+			name: "valid qualifiers",
+			qualifiers: metalv1alpha1.Qualifiers{
+				SystemInformation: []metalv1alpha1.SystemInformation{{Manufacturer: "Dell Inc.", ProductName: "~PowerEdge R6.*"}},
+				LabelSelectors:    []map[string]string{{"rack": "r1"}, {"rack": "r2"}},
+				Expression:        "server.status.memory.size > 0",
+				Exclude: &metalv1alpha1.ExclusionQualifiers{
+					LabelSelectors: []map[string]string{{"broken": "true"}},
+				},
+			},
+			allowed: true,
+		},
+		{
+			name: "invalid regex",
+			qualifiers: metalv1alpha1.Qualifiers{
+				SystemInformation: []metalv1alpha1.SystemInformation{{ProductName: "~PowerEdge R6("}},
+			},
+		},
+		{
+			name: "invalid exclusion regex",
+			qualifiers: metalv1alpha1.Qualifiers{
+				Exclude: &metalv1alpha1.ExclusionQualifiers{
+					CPU: []metalv1alpha1.CPUInformation{{Version: "~[a-"}},
+				},
+			},
+		},
+		{
+			name: "inverted thread range",
+			qualifiers: metalv1alpha1.Qualifiers{
+				CPU: []metalv1alpha1.CPUInformation{{MinThreads: 64, MaxThreads: 32}},
+			},
+		},
+		{
+			name: "duplicate label selectors",
+			qualifiers: metalv1alpha1.Qualifiers{
+				LabelSelectors: []map[string]string{{"rack": "r1"}, {"rack": "r1"}},
+			},
+		},
+		{
+			name: "empty label selector",
+			qualifiers: metalv1alpha1.Qualifiers{
+				LabelSelectors: []map[string]string{{}},
+			},
+		},
+		{
+			name: "invalid expression",
+			qualifiers: metalv1alpha1.Qualifiers{
+				Expression: "server.status.memory.size >",
+			},
+		},
+		{
+			name: "unknown expression field",
+			qualifiers: metalv1alpha1.Qualifiers{
+				Expression: "server.status.memroy.size > 0",
+			},
+		},
+		{
+			name: "exclusion repeats qualifiers",
+			qualifiers: metalv1alpha1.Qualifiers{
+				LabelSelectors: []map[string]string{{"rack": "r1"}},
+				Exclude: &metalv1alpha1.ExclusionQualifiers{
+					LabelSelectors: []map[string]string{{"rack": "r1"}},
+				},
+			},
+		},
+		{
+			name: "exclusion matches any server",
+			qualifiers: metalv1alpha1.Qualifiers{
+				Exclude: &metalv1alpha1.ExclusionQualifiers{
+					CPU: []metalv1alpha1.CPUInformation{{}},
+				},
+			},
+		},
+		{
+			name: "empty exclusion",
+			qualifiers: metalv1alpha1.Qualifiers{
+				Exclude: &metalv1alpha1.ExclusionQualifiers{},
+			},
+			allowed: true,
+		},
+		{
+			name:       "valid node labels and taints",
+			nodeLabels: map[string]string{"example.com/pool": "workers", "gpu": ""},
+			nodeTaints: []corev1.Taint{
+				{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "example.com/maintenance", Effect: corev1.TaintEffectNoExecute},
+			},
+			allowed: true,
+		},
+		{
+			name:       "node label value with comma",
+			nodeLabels: map[string]string{"pool": "workers,node-role.kubernetes.io/master=true"},
+		},
+		{
+			name:       "invalid node label key",
+			nodeLabels: map[string]string{"pool=workers": "true"},
+		},
+		{
+			name:       "reserved node label",
+			nodeLabels: map[string]string{"metal.sidero.dev/uuid": "4c4c4544-0035-5a10-8056-c7c04f4b4a32"},
+		},
+		{
+			name:       "node taint value with colon",
+			nodeTaints: []corev1.Taint{{Key: "dedicated", Value: "gpu:NoSchedule", Effect: corev1.TaintEffectNoSchedule}},
+		},
+		{
+			name:       "invalid node taint key",
+			nodeTaints: []corev1.Taint{{Key: "dedicated gpu", Effect: corev1.TaintEffectNoSchedule}},
+		},
+		{
+			name:       "missing node taint effect",
+			nodeTaints: []corev1.Taint{{Key: "dedicated", Value: "gpu"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &ServerClassValidator{}
+
+			if err := v.InjectDecoder(decoder); err != nil {
+				t.Fatal(err)
+			}
+
+			serverClass := &metalv1alpha1.ServerClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "workers",
+				},
+				Spec: metalv1alpha1.ServerClassSpec{
+					Qualifiers: tt.qualifiers,
+					NodeLabels: tt.nodeLabels,
+					NodeTaints: tt.nodeTaints,
+				},
+			}
+
+			req := admission.Request{}
+			req.Operation = admissionv1beta1.Create
+			req.Object.Raw, _ = json.Marshal(serverClass) //nolint: errcheck
+
+			resp := v.Handle(context.Background(), req)
+
+			if resp.Allowed != tt.allowed {
+				t.Errorf("allowed = %v, want %v: %v", resp.Allowed, tt.allowed, resp.Result)
+			}
+		})
+	}
+}
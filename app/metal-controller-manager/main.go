@@ -7,33 +7,54 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/http"
+	"net/http/pprof"
 	"os"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/tools/record"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	infrav1 "github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
 	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/controllers"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/chargeback"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/export"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/health"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/ipxe"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/ops"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/power/api"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/preview"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/search"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/sensors"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/server"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/standby"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/summary"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/tftp"
+	"github.com/talos-systems/sidero/app/metal-controller-manager/internal/webhooks"
 	"github.com/talos-systems/sidero/app/metal-controller-manager/pkg/constants"
 	// +kubebuilder:scaffold:imports
 )
 
 const (
 	defaultMaxConcurrentReconciles = 10
+
+	defaultPriorityMaxConcurrentReconciles = 2
 )
 
 var (
@@ -47,30 +68,128 @@ func init() {
 
 	_ = metalv1alpha1.AddToScheme(scheme)
 	_ = infrav1.AddToScheme(scheme)
+	_ = capiv1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var (
 		metricsAddr          string
+		metricsCertFile      string
+		metricsKeyFile       string
+		metricsTokenSecret   string
+		healthProbeAddr      string
+		enablePprof          bool
 		apiEndpoint          string
 		extraAgentKernelArgs string
+		agentAssetsURL       string
 		enableLeaderElection bool
 		autoAcceptServers    bool
 		insecureWipe         bool
+		requireApproval      bool
+		trustedRequesters    string
 		serverRebootTimeout  time.Duration
+		cleanVerifyInterval  time.Duration
+		inventoryTTL         time.Duration
+		inventoryRefresh     bool
+		wipeTimeout          time.Duration
+		wipeRetries          int
+		powerRetries         int
+		powerRetryInterval   time.Duration
+		maxClockSkew         time.Duration
+		pxeMode              string
+		allocationHistory    int
+		priorityReconciles   int
+		hardwareProfiler     bool
+		classMemberships     bool
+		classStatusInterval  time.Duration
+		snapshotNamespace    string
+		snapshotHistory      int
+		tftpRoot             string
+		tftpFilesConfigMap   string
+		bmcSensorsInterval   time.Duration
+		summaryInterval      time.Duration
+		agentLogsNamespace   string
+		agentLogsRetention   time.Duration
+		chargebackArchiveNS  string
+		attestationSecret    string
+		bootMenuTimeout      time.Duration
+		unknownServerPolicy  string
+		unknownServerURL     string
+		strictMode           bool
+		exportAPIAddr        string
+		exportAPICertFile    string
+		exportAPIKeyFile     string
+		exportBMCCredentials bool
+		inventorySecretName  string
+		standbyOf            string
+		standbySecretName    string
+		standbySyncInterval  time.Duration
+		standbyFailover      time.Duration
+		enableWebhooks       bool
 
 		testPowerSimulatedExplicitFailureProb float64
 		testPowerSimulatedSilentFailureProb   float64
 	)
 
 	flag.StringVar(&apiEndpoint, "api-endpoint", "", "The endpoint used by the discovery environment.")
-	flag.StringVar(&metricsAddr, "metrics-addr", ":8081", "The address the metric endpoint binds to.")
+	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&metricsCertFile, "metrics-tls-cert-file", "", "TLS certificate file for the metric endpoint.")
+	flag.StringVar(&metricsKeyFile, "metrics-tls-key-file", "", "TLS key file for the metric endpoint.")
+	flag.StringVar(&metricsTokenSecret, "metrics-token-secret", "", "Secret (namespace/name) with the bearer token required by the metric endpoint (empty disables the authentication).")
+	flag.StringVar(&healthProbeAddr, "health-probe-addr", ":9440", "The address the health probe endpoints (/healthz, /readyz) bind to.")
+	flag.BoolVar(&enablePprof, "enable-pprof", false, "Serve pprof profiles under /debug/pprof/ along with the metrics.")
 	flag.StringVar(&extraAgentKernelArgs, "extra-agent-kernel-args", "", "A comma delimited list of key-value pairs to be added to the agent environment kernel parameters.")
+	flag.StringVar(&agentAssetsURL, "agent-assets-url", "", "Base URL of the external server hosting the agent kernel and initramfs (empty serves the built-in agent assets).")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false, "Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&autoAcceptServers, "auto-accept-servers", false, "Add servers as 'accepted' when they register with Sidero API.")
 	flag.BoolVar(&insecureWipe, "insecure-wipe", true, "Wipe head of the disk only (if false, wipe whole disk).")
+	flag.BoolVar(&requireApproval, "require-approval", false, "Hold the wipes and the power offs of the allocated servers until they are approved with the server annotations.")
+	flag.StringVar(&trustedRequesters, "trusted-requesters", "system:serviceaccount:sidero-system:default",
+		"A comma delimited list of the users allowed to request the server power off on behalf of other users and to delete the servers without the approval.")
 	flag.DurationVar(&serverRebootTimeout, "server-reboot-timeout", constants.DefaultServerRebootTimeout, "Timeout to wait for the server to restart and start wipe.")
+	flag.DurationVar(&cleanVerifyInterval, "clean-verification-interval", 0, "Interval to re-wipe clean unallocated servers to verify they weren't modified out of band (0 disables verification).")
+	flag.DurationVar(&inventoryTTL, "inventory-ttl", 0, "Maximum age of the server hardware inventory before the server is marked as stale (0 disables the check).")
+	flag.BoolVar(&inventoryRefresh, "inventory-refresh", false, "Wipe the stale unallocated servers again to refresh the hardware inventory.")
+	flag.DurationVar(&wipeTimeout, "wipe-timeout", 0, "Timeout for the server to be wiped, stalled servers are power cycled and eventually quarantined (0 disables the timeout).")
+	flag.IntVar(&wipeRetries, "wipe-retries", 3, "Number of times the stalled wipe is retried before the server is quarantined.")
+	flag.IntVar(&powerRetries, "power-retries", 10, "Number of consecutive power management failures before switching to the next power management path (BMC, management API), and finally requesting manual intervention (0 retries indefinitely).")
+	flag.DurationVar(&powerRetryInterval, "power-retry-interval", constants.DefaultRequeueAfter,
+		"Minimum interval between the counted power management failures, doubled with each failure (up to 10m).")
+	flag.DurationVar(&maxClockSkew, "max-clock-skew", constants.DefaultMaxClockSkew, "Maximum allowed skew of the server hardware clock, skewed clocks are corrected by the agent.")
+	flag.StringVar(&pxeMode, "pxe-mode", string(metalv1alpha1.PXEModeBootOrder), "Default PXE mode of the servers: 'BootOrder' (network first in the boot order) or 'OneShot' (boot from disk by default, one-shot network boot).")
+	flag.IntVar(&allocationHistory, "allocation-history-size", 10, "Number of allocations to keep in the server allocation history.")
+	flag.IntVar(&priorityReconciles, "priority-max-concurrent-reconciles", defaultPriorityMaxConcurrentReconciles,
+		"Number of workers processing the operator-initiated server changes ahead of the background reconciles (0 disables the priority queue).")
+	flag.StringVar(&unknownServerPolicy, "unknown-server-policy", string(ipxe.UnknownServerRegister),
+		"How the servers without the Server resource are booted: 'Register' (boot the agent), 'Chainload' (chainload --unknown-server-url), 'BootFromDisk' or 'Ignore'.")
+	flag.StringVar(&unknownServerURL, "unknown-server-url", "", "Boot URL chainloaded by the unknown servers with the 'Chainload' unknown server policy.")
+	flag.BoolVar(&strictMode, "strict-mode", false, "Deny booting the servers which are unknown, not accepted, or not allocated (except for the wipe of the accepted servers).")
+	flag.DurationVar(&bootMenuTimeout, "boot-menu-timeout", 0, "Timeout of the interactive iPXE boot menu for the servers with the boot menu enabled (0 disables the boot menu).")
+	flag.DurationVar(&classStatusInterval, "serverclass-status-interval", constants.DefaultServerClassStatusInterval, "Minimum interval between the ServerClass status updates, server changes within the interval are batched (0 disables batching).")
+	flag.StringVar(&snapshotNamespace, "serverclass-snapshot-namespace", "", "Namespace of the ConfigMaps the ServerClass match snapshots are written to (empty disables the snapshots).")
+	flag.IntVar(&snapshotHistory, "serverclass-snapshot-history", constants.DefaultServerClassSnapshotHistory, "Number of the match snapshots kept per ServerClass.")
+	flag.BoolVar(&classMemberships, "enable-serverclass-memberships", false, "Create a ServerClassMembership resource for each server listed in the ServerClass status.")
+	flag.BoolVar(&hardwareProfiler, "enable-hardware-profiler", false, "Propose ServerClasses for the servers not matched by any ServerClass, grouped by the hardware profile.")
+	flag.StringVar(&exportAPIAddr, "export-api-addr", "", "The address the ServerClass export API binds to (empty disables the export API).")
+	flag.StringVar(&exportAPICertFile, "export-api-tls-cert-file", "", "TLS certificate file for the ServerClass export API.")
+	flag.StringVar(&exportAPIKeyFile, "export-api-tls-key-file", "", "TLS key file for the ServerClass export API.")
+	flag.BoolVar(&exportBMCCredentials, "export-api-include-bmc-credentials", false, "Include the BMC credentials of the servers in the export API responses (requires TLS).")
+	flag.StringVar(&inventorySecretName, "export-api-inventory-secret", "", "Secret (namespace/name) with the token the standby Sidero installation fetches the inventory with (empty disables the inventory).")
+	flag.StringVar(&standbyOf, "standby-of", "", "Export API endpoint of the active Sidero installation, runs as the standby mirroring its inventory (empty runs as the active installation).")
+	flag.StringVar(&standbySecretName, "standby-secret", "", "Secret (namespace/name) with the inventory token and the export API CA of the active Sidero installation.")
+	flag.DurationVar(&standbySyncInterval, "standby-sync-interval", constants.DefaultStandbySyncInterval, "Interval to sync the inventory of the active Sidero installation.")
+	flag.DurationVar(&standbyFailover, "standby-failover-timeout", constants.DefaultStandbyFailoverTimeout, "Time the active Sidero installation should stay unreachable before the standby takes over the boot services.")
+	flag.StringVar(&tftpRoot, "tftp-root", constants.TFTPDirectory, "Directory served by the TFTP server, built-in iPXE binaries are served if missing in the directory.")
+	flag.StringVar(&tftpFilesConfigMap, "tftp-files-configmap", "", "ConfigMap (namespace/name) with additional files served by the TFTP server, keys are the file names.")
+	flag.DurationVar(&bmcSensorsInterval, "bmc-sensors-interval", 0, "Interval to poll the BMC sensors of the servers, readings are exported as Prometheus metrics (0 disables the exporter).")
+	flag.DurationVar(&summaryInterval, "summary-interval", constants.DefaultSummaryInterval, "Interval to recompute the fleet summary served by the summary API (0 disables the summary API).")
+	flag.StringVar(&attestationSecret, "wipe-attestation-secret", "", "Secret (namespace/name) with the Ed25519 key the wipe attestations are signed with (empty records the attestations unsigned).")
+	flag.StringVar(&chargebackArchiveNS, "chargeback-archive-namespace", constants.DefaultChargebackArchiveNamespace,
+		"Namespace of the ConfigMaps the allocation history of the deleted servers is archived to for the chargeback report (empty disables the archive).")
+	flag.StringVar(&agentLogsNamespace, "agent-logs-namespace", constants.DefaultAgentLogsNamespace, "Namespace of the ConfigMaps the agent logs are stored in (empty disables storing the agent logs).")
+	flag.DurationVar(&agentLogsRetention, "agent-logs-retention", constants.DefaultAgentLogsRetention, "Minimum age of the agent logs of unknown servers before they are removed (0 keeps the logs).")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Enable admission webhooks (requires the webhook server certificate).")
 	flag.Float64Var(&testPowerSimulatedExplicitFailureProb, "test-power-simulated-explicit-failure-prob", 0, "Test failure simulation setting.")
 	flag.Float64Var(&testPowerSimulatedSilentFailureProb, "test-power-simulated-silent-failure-prob", 0, "Test failure simulation setting.")
 
@@ -83,12 +202,51 @@ func main() {
 		o.Development = true
 	}))
 
+	switch metalv1alpha1.PXEMode(pxeMode) {
+	case metalv1alpha1.PXEModeBootOrder, metalv1alpha1.PXEModeOneShot:
+	default:
+		setupLog.Error(fmt.Errorf("unsupported PXE mode %q", pxeMode), "invalid flags")
+		os.Exit(1)
+	}
+
+	if priorityReconciles < 0 {
+		setupLog.Error(fmt.Errorf("negative number of priority workers %d", priorityReconciles), "invalid flags")
+		os.Exit(1)
+	}
+
+	switch ipxe.UnknownServerPolicy(unknownServerPolicy) {
+	case ipxe.UnknownServerRegister, ipxe.UnknownServerBootFromDisk, ipxe.UnknownServerIgnore:
+	case ipxe.UnknownServerChainload:
+		if unknownServerURL == "" {
+			setupLog.Error(fmt.Errorf("unknown server policy %q requires --unknown-server-url", unknownServerPolicy), "invalid flags")
+			os.Exit(1)
+		}
+	default:
+		setupLog.Error(fmt.Errorf("unsupported unknown server policy %q", unknownServerPolicy), "invalid flags")
+		os.Exit(1)
+	}
+
+	metricsSecret, err := parseNamespacedName(metricsTokenSecret)
+	if err != nil {
+		setupLog.Error(err, "invalid metrics token secret")
+		os.Exit(1)
+	}
+
+	// metrics are served by the ops server instead of the manager if the TLS or the authentication is enabled
+	secureMetrics := metricsCertFile != "" || metricsSecret.Name != ""
+
+	managerMetricsAddr := metricsAddr
+	if secureMetrics {
+		managerMetricsAddr = "0"
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:             scheme,
-		MetricsBindAddress: metricsAddr,
-		LeaderElection:     enableLeaderElection,
-		LeaderElectionID:   "controller-leader-election-metal-controller-manager",
-		Port:               9443,
+		Scheme:                 scheme,
+		MetricsBindAddress:     managerMetricsAddr,
+		HealthProbeBindAddress: healthProbeAddr,
+		LeaderElection:         enableLeaderElection,
+		LeaderElectionID:       "controller-leader-election-metal-controller-manager",
+		Port:                   9443,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -120,6 +278,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	var chargebackArchive *chargeback.Archive
+
+	if chargebackArchiveNS != "" {
+		chargebackArchive = &chargeback.Archive{
+			Reader:    mgr.GetAPIReader(),
+			Namespace: chargebackArchiveNS,
+		}
+	}
+
 	if err = (&controllers.ServerReconciler{
 		Client:        mgr.GetClient(),
 		Log:           ctrl.Log.WithName("controllers").WithName("Server"),
@@ -127,60 +294,351 @@ func main() {
 		APIReader:     mgr.GetAPIReader(),
 		Recorder:      recorder,
 		RebootTimeout: serverRebootTimeout,
+
+		CleanVerificationInterval: cleanVerifyInterval,
+		WipeTimeout:               wipeTimeout,
+		WipeRetries:               wipeRetries,
+		PowerRetries:              powerRetries,
+		PowerRetryInterval:        powerRetryInterval,
+		AllocationHistorySize:     allocationHistory,
+
+		PriorityMaxConcurrentReconciles: priorityReconciles,
+		RequireApproval:                 requireApproval,
+		Standby:                         standbyOf != "",
+		InventoryTTL:                    inventoryTTL,
+		InventoryRefresh:                inventoryRefresh,
+		ChargebackArchive:               chargebackArchive,
 	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Server")
 		os.Exit(1)
 	}
 
 	if err = (&controllers.ServerClassReconciler{
+		Client:               mgr.GetClient(),
+		Log:                  ctrl.Log.WithName("controllers").WithName("ServerClass"),
+		Scheme:               mgr.GetScheme(),
+		StatusUpdateInterval: classStatusInterval,
+		SnapshotNamespace:    snapshotNamespace,
+		SnapshotHistory:      snapshotHistory,
+		APIReader:            mgr.GetAPIReader(),
+		Memberships:          classMemberships,
+	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ServerClass")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.ServerClassCapacityReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ServerClassCapacity"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: recorder,
+	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ServerClassCapacity")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.SiteReconciler{
 		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("ServerClass"),
+		Log:    ctrl.Log.WithName("controllers").WithName("Site"),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ServerClass")
+		setupLog.Error(err, "unable to create controller", "controller", "Site")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.AssetCacheReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("AssetCache"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AssetCache")
+		os.Exit(1)
+	}
+
+	if hardwareProfiler {
+		if err = (&controllers.ServerProfilerReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ServerProfiler"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: recorder,
+		}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: 1}); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ServerProfiler")
+			os.Exit(1)
+		}
+	}
+
+	if err = (&controllers.ServerAdoptionReconciler{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ServerAdoption"),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+		Recorder:  recorder,
+	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ServerAdoption")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.ServerClassImportReconciler{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ServerClassImport"),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+		Recorder:  recorder,
+	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ServerClassImport")
 		os.Exit(1)
 	}
 	// +kubebuilder:scaffold:builder
 
-	setupLog.Info("starting TFTP server")
+	metrics.Registry.MustRegister(&chargeback.Collector{Client: mgr.GetClient()})
+	metrics.Registry.MustRegister(ipxe.DeniedBoots)
+
+	if bmcSensorsInterval > 0 {
+		exporter := &sensors.Exporter{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Log:       ctrl.Log.WithName("sensors"),
+			Interval:  bmcSensorsInterval,
+		}
+
+		metrics.Registry.MustRegister(exporter)
 
-	go func() {
-		if err := tftp.ServeTFTP(); err != nil {
-			setupLog.Error(err, "unable to start TFTP server", "controller", "Environment")
+		if err = mgr.Add(exporter); err != nil {
+			setupLog.Error(err, "unable to add BMC sensors exporter")
 			os.Exit(1)
 		}
-	}()
+	}
 
-	setupLog.Info("starting iPXE server")
+	if agentLogsNamespace != "" && agentLogsRetention > 0 {
+		if err = mgr.Add(&server.AgentLogsCollector{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Log:       ctrl.Log.WithName("agent-logs"),
+			Namespace: agentLogsNamespace,
+			Retention: agentLogsRetention,
+		}); err != nil {
+			setupLog.Error(err, "unable to add agent logs collector")
+			os.Exit(1)
+		}
+	}
 
-	go func() {
-		if apiEndpoint == "" {
-			if endpoint, ok := os.LookupEnv("API_ENDPOINT"); ok {
-				apiEndpoint = endpoint
-			} else {
-				setupLog.Error(fmt.Errorf("no api endpoint found"), "unable to start iPXE server", "controller", "Environment")
-				os.Exit(1)
-			}
+	filesConfigMap, err := parseNamespacedName(tftpFilesConfigMap)
+	if err != nil {
+		setupLog.Error(err, "invalid TFTP files configmap")
+		os.Exit(1)
+	}
+
+	if standbyOf != "" {
+		standbySecret, err := parseNamespacedName(standbySecretName)
+		if err == nil && standbySecret.Name == "" {
+			err = fmt.Errorf("standby secret is required")
 		}
 
-		if err := ipxe.ServeIPXE(apiEndpoint, extraAgentKernelArgs, mgr.GetClient()); err != nil {
-			setupLog.Error(err, "unable to start iPXE server", "controller", "Environment")
+		if err != nil {
+			setupLog.Error(err, "invalid standby secret")
+			os.Exit(1)
+		}
+
+		mirror := &standby.Mirror{
+			Client:          mgr.GetClient(),
+			APIReader:       mgr.GetAPIReader(),
+			Log:             ctrl.Log.WithName("standby"),
+			Endpoint:        standbyOf,
+			Secret:          standbySecret,
+			Interval:        standbySyncInterval,
+			FailoverTimeout: standbyFailover,
+		}
+
+		if err = mgr.Add(mirror); err != nil {
+			setupLog.Error(err, "unable to add standby mirror")
+			os.Exit(1)
+		}
+
+		// boot services of the standby are advertised and answer the requests only once the active installation is down
+		if err = mgr.AddReadyzCheck("standby", mirror.Ready); err != nil {
+			setupLog.Error(err, "unable to add readiness check", "check", "standby")
+			os.Exit(1)
+		}
+
+		health.Serving.Track(mirror.Active)
+	}
+
+	wipeAttestationSecret, err := parseNamespacedName(attestationSecret)
+	if err != nil {
+		setupLog.Error(err, "invalid wipe attestation secret")
+		os.Exit(1)
+	}
+
+	if apiEndpoint == "" {
+		if endpoint, ok := os.LookupEnv("API_ENDPOINT"); ok {
+			apiEndpoint = endpoint
+		} else {
+			setupLog.Error(fmt.Errorf("no api endpoint found"), "unable to start iPXE server", "controller", "Environment")
 			os.Exit(1)
 		}
-	}()
+	}
+
+	// boot services are started only on the leader, so that a single replica answers the boot requests
+	if err = mgr.Add(manager.RunnableFunc(func(<-chan struct{}) error {
+		setupLog.Info("starting TFTP server")
+
+		return tftp.ServeTFTP(tftpRoot, filesConfigMap, mgr.GetAPIReader())
+	})); err != nil {
+		setupLog.Error(err, "unable to add TFTP server")
+		os.Exit(1)
+	}
+
+	if err = mgr.Add(manager.RunnableFunc(func(<-chan struct{}) error {
+		setupLog.Info("starting iPXE server")
 
-	setupLog.Info("starting internal API server")
+		return ipxe.ServeIPXE(apiEndpoint, extraAgentKernelArgs, agentAssetsURL, bootMenuTimeout,
+			ipxe.UnknownServerPolicy(unknownServerPolicy), unknownServerURL, strictMode, mgr.GetClient(), wipeAttestationSecret)
+	})); err != nil {
+		setupLog.Error(err, "unable to add iPXE server")
+		os.Exit(1)
+	}
+
+	if err = mgr.Add(manager.RunnableFunc(func(<-chan struct{}) error {
+		setupLog.Info("starting internal API server")
 
-	go func() {
 		recorder := eventBroadcaster.NewRecorder(
 			mgr.GetScheme(),
 			corev1.EventSource{Component: "sidero-server"})
 
-		if err := server.Serve(mgr.GetClient(), recorder, mgr.GetScheme(), autoAcceptServers, insecureWipe, serverRebootTimeout); err != nil {
-			setupLog.Error(err, "unable to start API server", "controller", "Environment")
+		return server.Serve(mgr.GetClient(), mgr.GetAPIReader(), recorder, mgr.GetScheme(), autoAcceptServers, insecureWipe, requireApproval, serverRebootTimeout, maxClockSkew, metalv1alpha1.PXEMode(pxeMode), agentLogsNamespace, wipeAttestationSecret)
+	})); err != nil {
+		setupLog.Error(err, "unable to add API server")
+		os.Exit(1)
+	}
+
+	if exportAPIAddr != "" {
+		inventorySecret, err := parseNamespacedName(inventorySecretName)
+		if err != nil {
+			setupLog.Error(err, "invalid inventory secret")
 			os.Exit(1)
 		}
-	}()
+
+		setupLog.Info("starting export API server")
+
+		go func() {
+			recorder := eventBroadcaster.NewRecorder(
+				mgr.GetScheme(),
+				corev1.EventSource{Component: "sidero-export"})
+
+			if err := export.Serve(mgr.GetClient(), mgr.GetAPIReader(), recorder, mgr.GetScheme(), exportAPIAddr, exportAPICertFile, exportAPIKeyFile, inventorySecret,
+				exportBMCCredentials); err != nil {
+				setupLog.Error(err, "unable to start export API server")
+				os.Exit(1)
+			}
+		}()
+	}
+
+	addMetricsExtraHandler := mgr.AddMetricsExtraHandler
+
+	var opsServer *ops.Server
+
+	if secureMetrics {
+		opsServer = ops.NewServer(mgr.GetAPIReader(), metricsSecret)
+		addMetricsExtraHandler = opsServer.Handle
+	}
+
+	// search API is served along with the metrics, which are protected by the auth proxy (or by the metrics token)
+	if err = addMetricsExtraHandler("/search", search.NewHandler(mgr.GetClient())); err != nil {
+		setupLog.Error(err, "unable to add search API handler")
+		os.Exit(1)
+	}
+
+	if err = addMetricsExtraHandler("/allocation-preview", preview.NewHandler(mgr.GetClient())); err != nil {
+		setupLog.Error(err, "unable to add allocation preview API handler")
+		os.Exit(1)
+	}
+
+	if err = addMetricsExtraHandler("/serverclass-explain", controllers.NewExplainHandler(mgr.GetClient())); err != nil {
+		setupLog.Error(err, "unable to add serverclass explain API handler")
+		os.Exit(1)
+	}
+
+	if err = addMetricsExtraHandler("/chargeback", chargeback.NewHandler(mgr.GetClient(), chargebackArchive)); err != nil {
+		setupLog.Error(err, "unable to add chargeback report handler")
+		os.Exit(1)
+	}
+
+	if summaryInterval > 0 {
+		collector := &summary.Collector{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("summary"),
+			Interval: summaryInterval,
+		}
+
+		if err = mgr.Add(collector); err != nil {
+			setupLog.Error(err, "unable to add fleet summary collector")
+			os.Exit(1)
+		}
+
+		if err = addMetricsExtraHandler("/summary", collector); err != nil {
+			setupLog.Error(err, "unable to add fleet summary API handler")
+			os.Exit(1)
+		}
+	}
+
+	if err = mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to add health check")
+		os.Exit(1)
+	}
+
+	// boot services are ready once they are listening, and they are restarted if they stop responding
+	for _, listener := range health.Listeners {
+		if err = mgr.AddReadyzCheck(listener.Name, listener.Ready); err != nil {
+			setupLog.Error(err, "unable to add readiness check", "listener", listener.Name)
+			os.Exit(1)
+		}
+
+		if err = mgr.AddHealthzCheck(listener.Name, listener.Live); err != nil {
+			setupLog.Error(err, "unable to add health check", "listener", listener.Name)
+			os.Exit(1)
+		}
+	}
+
+	if enablePprof {
+		for path, handler := range map[string]http.HandlerFunc{
+			"/debug/pprof/":        pprof.Index,
+			"/debug/pprof/cmdline": pprof.Cmdline,
+			"/debug/pprof/profile": pprof.Profile,
+			"/debug/pprof/symbol":  pprof.Symbol,
+			"/debug/pprof/trace":   pprof.Trace,
+		} {
+			if err = addMetricsExtraHandler(path, handler); err != nil {
+				setupLog.Error(err, "unable to add pprof handler")
+				os.Exit(1)
+			}
+		}
+	}
+
+	if opsServer != nil {
+		setupLog.Info("starting metrics server")
+
+		go func() {
+			if err := opsServer.Serve(metricsAddr, metricsCertFile, metricsKeyFile); err != nil {
+				setupLog.Error(err, "unable to start metrics server")
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if enableWebhooks {
+		mgr.GetWebhookServer().Register(webhooks.EnvironmentValidatorPath, &webhook.Admission{
+			Handler: &webhooks.EnvironmentValidator{Client: mgr.GetClient()},
+		})
+		mgr.GetWebhookServer().Register(webhooks.ServerValidatorPath, &webhook.Admission{
+			Handler: &webhooks.ServerValidator{RequireApproval: requireApproval, TrustedRequesters: strings.Split(trustedRequesters, ",")},
+		})
+		mgr.GetWebhookServer().Register(webhooks.MetalClusterRequesterPath, &webhook.Admission{
+			Handler: &webhooks.MetalClusterRequester{},
+		})
+		mgr.GetWebhookServer().Register(webhooks.ServerClassValidatorPath, &webhook.Admission{
+			Handler: &webhooks.ServerClassValidator{},
+		})
+	}
 
 	setupLog.Info("starting manager")
 
@@ -189,3 +647,17 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// parseNamespacedName parses the "namespace/name" flag value, empty value is parsed to the empty name.
+func parseNamespacedName(value string) (types.NamespacedName, error) {
+	if value == "" {
+		return types.NamespacedName{}, nil
+	}
+
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return types.NamespacedName{}, fmt.Errorf("expected namespace/name, got %q", value)
+	}
+
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
@@ -8,12 +8,41 @@ import "time"
 
 const (
 	DataDirectory    = "/var/lib/sidero"
+	TFTPDirectory    = DataDirectory + "/tftp"
 	AgentEndpointArg = "sidero.endpoint"
 
+	// AgentTokenArg is the kernel argument with the token the agent authenticates the wipe reports with.
+	AgentTokenArg = "sidero.agent-token"
+	// AgentTokenMetadataKey is the gRPC metadata key the agent sends the token in.
+	AgentTokenMetadataKey = "x-sidero-agent-token"
+
+	// AgentOverlaysDirectory contains the cpio archives appended to the agent initramfs.
+	AgentOverlaysDirectory = DataDirectory + "/agent-overlays"
+
 	KernelAsset = "vmlinuz"
 	InitrdAsset = "initramfs.xz"
 
 	DefaultRequeueAfter = time.Second * 20
 
 	DefaultServerRebootTimeout = time.Minute * 20
+
+	DefaultMaxClockSkew = time.Minute
+
+	DefaultServerClassStatusInterval = time.Second * 5
+
+	DefaultServerClassSnapshotHistory = 10
+
+	DefaultSummaryInterval = time.Second * 10
+
+	DefaultAgentLogsNamespace = "sidero-system"
+
+	DefaultChargebackArchiveNamespace = "sidero-system"
+
+	AgentLogsSize              = 256 * 1024
+	AgentLogsInterval          = time.Second * 10
+	AgentLogsMaxUnknownServers = 100
+	DefaultAgentLogsRetention  = time.Hour * 24
+
+	DefaultStandbySyncInterval    = time.Second * 30
+	DefaultStandbyFailoverTimeout = time.Minute * 2
 )
@@ -0,0 +1,100 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "sidero_metadata_requests_total",
+		Help: "Number of metadata requests by the response status code.",
+	}, []string{"code"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sidero_metadata_request_duration_seconds",
+		Help:    "Latency of metadata requests by the response status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"code"})
+)
+
+func init() {
+	prometheus.MustRegister(requestsTotal, requestDuration)
+}
+
+type accessLogKey struct{}
+
+// accessLogEntry collects the details of the metadata request as it is being handled.
+type accessLogEntry struct {
+	start      time.Time
+	status     int
+	server     string
+	machine    string
+	patches    int
+	generation time.Duration
+	err        error
+}
+
+// accessLog returns the access log entry of the request.
+//
+// If the request is not instrumented, the entry is discarded.
+func accessLog(ctx context.Context) *accessLogEntry {
+	if entry, ok := ctx.Value(accessLogKey{}).(*accessLogEntry); ok {
+		return entry
+	}
+
+	return &accessLogEntry{start: time.Now()}
+}
+
+// accessLogWriter records the response status code.
+type accessLogWriter struct {
+	http.ResponseWriter
+
+	entry *accessLogEntry
+}
+
+func (w *accessLogWriter) WriteHeader(code int) {
+	w.entry.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// instrument records metrics and (optionally) access log for every request.
+func instrument(handler http.HandlerFunc, logRequests bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entry := &accessLogEntry{
+			start:  time.Now(),
+			status: http.StatusOK,
+			server: r.URL.Query().Get("uuid"),
+		}
+
+		handler(&accessLogWriter{ResponseWriter: w, entry: entry}, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry)))
+
+		duration := time.Since(entry.start)
+		code := strconv.Itoa(entry.status)
+
+		requestsTotal.WithLabelValues(code).Inc()
+		requestDuration.WithLabelValues(code).Observe(duration.Seconds())
+
+		if !logRequests {
+			return
+		}
+
+		var errMsg string
+
+		if entry.err != nil {
+			errMsg = entry.err.Error()
+		}
+
+		log.Printf("access: remote=%q server=%q machine=%q status=%d patches=%d generation=%s duration=%s error=%q",
+			r.RemoteAddr, entry.server, entry.machine, entry.status, entry.patches, entry.generation, duration, errMsg)
+	}
+}
@@ -0,0 +1,126 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// Output formats of the machine configuration.
+const (
+	// formatRaw returns the machine configuration as is.
+	formatRaw = "raw"
+	// formatIgnition wraps the machine configuration into the Ignition config writing it to a file.
+	formatIgnition = "ignition"
+	// formatCloudConfig wraps the machine configuration into the cloud-config writing it to a file.
+	formatCloudConfig = "cloud-config"
+)
+
+// defaultConfigPath is the path wrapped machine configuration is written to, if not set in the request.
+const defaultConfigPath = "/var/lib/sidero/config.yaml"
+
+// ignitionVersion is the version of the Ignition config spec rendered.
+const ignitionVersion = "3.2.0"
+
+// outputFormat returns the output format requested via the "format" query parameter,
+// or detected from the User-Agent of Ignition and cloud-init.
+func outputFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		switch format {
+		case formatRaw, formatIgnition, formatCloudConfig:
+			return format, nil
+		default:
+			return "", fmt.Errorf("unsupported format %q, supported formats are %q, %q and %q", format, formatRaw, formatIgnition, formatCloudConfig)
+		}
+	}
+
+	userAgent := strings.ToLower(r.UserAgent())
+
+	switch {
+	case strings.HasPrefix(userAgent, "ignition/"):
+		return formatIgnition, nil
+	case strings.HasPrefix(userAgent, "cloud-init/"):
+		return formatCloudConfig, nil
+	default:
+		return formatRaw, nil
+	}
+}
+
+// configPath returns the path wrapped machine configuration is written to, requested via the "path" query parameter.
+//
+// Ignition and cloud-init only write the files to the absolute paths, so the relative paths are rejected.
+func configPath(r *http.Request) (string, error) {
+	path := r.URL.Query().Get("path")
+
+	if path == "" {
+		return defaultConfigPath, nil
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("config path %q is not absolute", path)
+	}
+
+	return path, nil
+}
+
+// wrapConfig wraps the machine configuration into the requested format, it returns the content type of the result.
+//
+// Wrapped configuration is written to the path (see configPath) by the OS consuming it.
+func wrapConfig(decodedData []byte, format, path string) ([]byte, string, errorWithCode) {
+	switch format {
+	case formatIgnition:
+		config := map[string]interface{}{
+			"ignition": map[string]interface{}{
+				"version": ignitionVersion,
+			},
+			"storage": map[string]interface{}{
+				"files": []interface{}{
+					map[string]interface{}{
+						"path":      path,
+						"mode":      0o600,
+						"overwrite": true,
+						"contents": map[string]interface{}{
+							"source": "data:;base64," + base64.StdEncoding.EncodeToString(decodedData),
+						},
+					},
+				},
+			},
+		}
+
+		data, err := json.Marshal(config)
+		if err != nil {
+			return nil, "", errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure marshaling ignition config: %s", err)}
+		}
+
+		return data, "application/json", errorWithCode{}
+	case formatCloudConfig:
+		config := map[string]interface{}{
+			"write_files": []interface{}{
+				map[string]interface{}{
+					"path":        path,
+					"permissions": "0600",
+					"encoding":    "b64",
+					"content":     base64.StdEncoding.EncodeToString(decodedData),
+				},
+			},
+		}
+
+		data, err := yaml.Marshal(config)
+		if err != nil {
+			return nil, "", errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure marshaling cloud-config: %s", err)}
+		}
+
+		// cloud-init requires the header to recognize the cloud-config
+		return append([]byte("#cloud-config\n"), data...), "text/cloud-config", errorWithCode{}
+	default:
+		return decodedData, "", errorWithCode{}
+	}
+}
@@ -0,0 +1,158 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+func TestOutputFormat(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		query     string
+		userAgent string
+		expected  string
+		err       bool
+	}{
+		{name: "default", userAgent: "curl/7.68.0", expected: formatRaw},
+		{name: "ignition", userAgent: "Ignition/2.9.0", expected: formatIgnition},
+		{name: "cloud-init", userAgent: "Cloud-Init/20.4", expected: formatCloudConfig},
+		{name: "format overrides user agent", query: "format=raw", userAgent: "Ignition/2.9.0", expected: formatRaw},
+		{name: "ignition format", query: "format=ignition", expected: formatIgnition},
+		{name: "cloud-config format", query: "format=cloud-config", expected: formatCloudConfig},
+		{name: "unsupported format", query: "format=kickstart", err: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/configdata?"+tt.query, nil)
+			r.Header.Set("User-Agent", tt.userAgent)
+
+			format, err := outputFormat(r)
+			if (err != nil) != tt.err {
+				t.Fatalf("outputFormat() error = %v, want error %v", err, tt.err)
+			}
+
+			if format != tt.expected {
+				t.Errorf("outputFormat() = %q, want %q", format, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	for _, tt := range []struct {
+		query    string
+		expected string
+		err      bool
+	}{
+		{query: "", expected: defaultConfigPath},
+		{query: "path=/etc/talos/config.yaml", expected: "/etc/talos/config.yaml"},
+		{query: "path=config.yaml", err: true},
+		{query: "path=../config.yaml", err: true},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			path, err := configPath(httptest.NewRequest("GET", "/configdata?"+tt.query, nil))
+			if (err != nil) != tt.err {
+				t.Fatalf("configPath() error = %v, want error %v", err, tt.err)
+			}
+
+			if path != tt.expected {
+				t.Errorf("configPath() = %q, want %q", path, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWrapConfig(t *testing.T) {
+	config := []byte("version: v1alpha1\n")
+	encoded := base64.StdEncoding.EncodeToString(config)
+
+	t.Run(formatRaw, func(t *testing.T) {
+		data, contentType, ewc := wrapConfig(config, formatRaw, defaultConfigPath)
+		if ewc.errorObj != nil {
+			t.Fatal(ewc.errorObj)
+		}
+
+		if string(data) != string(config) || contentType != "" {
+			t.Errorf("raw config is modified: %q %q", data, contentType)
+		}
+	})
+
+	t.Run(formatIgnition, func(t *testing.T) {
+		data, contentType, ewc := wrapConfig(config, formatIgnition, "/etc/config.yaml")
+		if ewc.errorObj != nil {
+			t.Fatal(ewc.errorObj)
+		}
+
+		if contentType != "application/json" {
+			t.Errorf("content type = %q", contentType)
+		}
+
+		var ignition struct {
+			Ignition struct {
+				Version string `json:"version"`
+			} `json:"ignition"`
+			Storage struct {
+				Files []struct {
+					Path     string `json:"path"`
+					Contents struct {
+						Source string `json:"source"`
+					} `json:"contents"`
+				} `json:"files"`
+			} `json:"storage"`
+		}
+
+		if err := json.Unmarshal(data, &ignition); err != nil {
+			t.Fatal(err)
+		}
+
+		if ignition.Ignition.Version != ignitionVersion {
+			t.Errorf("ignition version = %q", ignition.Ignition.Version)
+		}
+
+		if len(ignition.Storage.Files) != 1 || ignition.Storage.Files[0].Path != "/etc/config.yaml" ||
+			ignition.Storage.Files[0].Contents.Source != "data:;base64,"+encoded {
+			t.Errorf("unexpected ignition files %+v", ignition.Storage.Files)
+		}
+	})
+
+	t.Run(formatCloudConfig, func(t *testing.T) {
+		data, contentType, ewc := wrapConfig(config, formatCloudConfig, "/etc/config.yaml")
+		if ewc.errorObj != nil {
+			t.Fatal(ewc.errorObj)
+		}
+
+		if contentType != "text/cloud-config" {
+			t.Errorf("content type = %q", contentType)
+		}
+
+		if !strings.HasPrefix(string(data), "#cloud-config\n") {
+			t.Error("cloud-config header is missing")
+		}
+
+		var cloudConfig struct {
+			WriteFiles []struct {
+				Path     string `json:"path"`
+				Encoding string `json:"encoding"`
+				Content  string `json:"content"`
+			} `json:"write_files"`
+		}
+
+		if err := yaml.Unmarshal(data, &cloudConfig); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(cloudConfig.WriteFiles) != 1 || cloudConfig.WriteFiles[0].Path != "/etc/config.yaml" ||
+			cloudConfig.WriteFiles[0].Encoding != "b64" || cloudConfig.WriteFiles[0].Content != encoded {
+			t.Errorf("unexpected cloud-config files %+v", cloudConfig.WriteFiles)
+		}
+	})
+}
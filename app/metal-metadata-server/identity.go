@@ -0,0 +1,168 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/crypto/curve25519"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+)
+
+const (
+	identityWireguardPrivateKey = "wireguard-private-key"
+	identityWireguardPublicKey  = "wireguard-public-key"
+)
+
+// fetchServerIdentity returns the persisted identity of the server in the cluster, generating it on the first use.
+//
+// Identity secret is owned by the MetalCluster, so it outlives the machines provisioned on the server,
+// and it's removed with the cluster.
+// Identity consists of the WireGuard key pair only, Talos machine config has no SSH host keys or per-node certificate material to persist.
+func (m *metadataConfigs) fetchServerIdentity(ctx context.Context, metalCluster *v1alpha3.MetalCluster, clusterName, serverName string) (*v1.Secret, errorWithCode) {
+	secretNSN := types.NamespacedName{
+		Namespace: metalCluster.Namespace,
+		Name:      fmt.Sprintf("%s-%s-identity", metalCluster.Name, serverName),
+	}
+
+	identity := &v1.Secret{}
+
+	err := m.client.Get(ctx, secretNSN, identity)
+	if err == nil {
+		return identity, errorWithCode{}
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure fetching identity secret %s/%s: %s", secretNSN.Namespace, secretNSN.Name, err)}
+	}
+
+	privateKey, publicKey, err := generateWireguardKey()
+	if err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure generating wireguard key: %s", err)}
+	}
+
+	identity = &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: secretNSN.Namespace,
+			Name:      secretNSN.Name,
+			Labels: map[string]string{
+				capiv1.ClusterLabelName: clusterName,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: v1alpha3.GroupVersion.String(),
+					Kind:       "MetalCluster",
+					Name:       metalCluster.Name,
+					UID:        metalCluster.UID,
+				},
+			},
+		},
+		Data: map[string][]byte{
+			identityWireguardPrivateKey: []byte(privateKey),
+			identityWireguardPublicKey:  []byte(publicKey),
+		},
+	}
+
+	if err = m.client.Create(ctx, identity); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure creating identity secret %s/%s: %s", secretNSN.Namespace, secretNSN.Name, err)}
+		}
+
+		// concurrent request generated the identity first
+		if err = m.client.Get(ctx, secretNSN, identity); err != nil {
+			return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure fetching identity secret %s/%s: %s", secretNSN.Namespace, secretNSN.Name, err)}
+		}
+	}
+
+	return identity, errorWithCode{}
+}
+
+// configureIdentity renders the persisted server identity into the bootstrap data.
+//
+// WireGuard private key is set for the WireGuard network interfaces which don't have one configured.
+func configureIdentity(decodedData []byte, identity *v1.Secret) ([]byte, errorWithCode) {
+	privateKey := string(identity.Data[identityWireguardPrivateKey])
+	if privateKey == "" {
+		return decodedData, errorWithCode{}
+	}
+
+	var config map[string]interface{}
+
+	if err := yaml.Unmarshal(decodedData, &config); err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure unmarshaling bootstrap data: %s", err)}
+	}
+
+	var interfaces []interface{}
+
+	if machine, ok := config["machine"].(map[string]interface{}); ok {
+		if network, ok := machine["network"].(map[string]interface{}); ok {
+			interfaces, _ = network["interfaces"].([]interface{})
+		}
+	}
+
+	changed := false
+
+	for _, iface := range interfaces {
+		iface, ok := iface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		wireguard, ok := iface["wireguard"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if key, _ := wireguard["privateKey"].(string); key != "" {
+			continue
+		}
+
+		wireguard["privateKey"] = privateKey
+		changed = true
+	}
+
+	if !changed {
+		return decodedData, errorWithCode{}
+	}
+
+	decodedData, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure marshaling bootstrap data: %s", err)}
+	}
+
+	return decodedData, errorWithCode{}
+}
+
+// generateWireguardKey generates the Curve25519 WireGuard key pair encoded in base64.
+func generateWireguardKey() (privateKey, publicKey string, err error) {
+	var key [curve25519.ScalarSize]byte
+
+	if _, err = rand.Read(key[:]); err != nil {
+		return "", "", err
+	}
+
+	// clamp the private key as described in https://cr.yp.to/ecdh.html
+	key[0] &= 248
+	key[31] &= 127
+	key[31] |= 64
+
+	public, err := curve25519.X25519(key[:], curve25519.Basepoint)
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key[:]), base64.StdEncoding.EncodeToString(public), nil
+}
@@ -0,0 +1,195 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/crypto/curve25519"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+)
+
+func TestGenerateWireguardKey(t *testing.T) {
+	privateKey, publicKey, err := generateWireguardKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	private, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(private) != curve25519.ScalarSize {
+		t.Fatalf("private key length = %d, want %d", len(private), curve25519.ScalarSize)
+	}
+
+	if private[0]&7 != 0 || private[31]&128 != 0 || private[31]&64 == 0 {
+		t.Errorf("private key is not clamped: %x", private)
+	}
+
+	public, err := curve25519.X25519(private, curve25519.Basepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := base64.StdEncoding.EncodeToString(public); publicKey != expected {
+		t.Errorf("public key = %q, want %q", publicKey, expected)
+	}
+
+	otherKey, _, err := generateWireguardKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otherKey == privateKey {
+		t.Error("generated keys are the same")
+	}
+}
+
+func TestConfigureIdentity(t *testing.T) {
+	identity := &v1.Secret{
+		Data: map[string][]byte{
+			identityWireguardPrivateKey: []byte("cHJpdmF0ZQ=="),
+			identityWireguardPublicKey:  []byte("cHVibGlj"),
+		},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		identity *v1.Secret
+		config   string
+		expected string
+	}{
+		{
+			name:     "no wireguard interfaces",
+			identity: identity,
+			config:   "machine:\n  network:\n    interfaces:\n    - interface: eth0\n      dhcp: true\n",
+			expected: "machine:\n  network:\n    interfaces:\n    - interface: eth0\n      dhcp: true\n",
+		},
+		{
+			name:     "no network",
+			identity: identity,
+			config:   "machine:\n  type: worker\n",
+			expected: "machine:\n  type: worker\n",
+		},
+		{
+			name:     "private key set",
+			identity: identity,
+			config:   "machine:\n  network:\n    interfaces:\n    - interface: wg0\n      wireguard:\n        listenPort: 51820\n",
+			expected: "machine:\n  network:\n    interfaces:\n    - interface: wg0\n      wireguard:\n        listenPort: 51820\n        privateKey: cHJpdmF0ZQ==\n",
+		},
+		{
+			name:     "private key configured",
+			identity: identity,
+			config:   "machine:\n  network:\n    interfaces:\n    - interface: wg0\n      wireguard:\n        privateKey: Y29uZmlndXJlZA==\n",
+			expected: "machine:\n  network:\n    interfaces:\n    - interface: wg0\n      wireguard:\n        privateKey: Y29uZmlndXJlZA==\n",
+		},
+		{
+			name:     "empty identity",
+			identity: &v1.Secret{},
+			config:   "machine:\n  network:\n    interfaces:\n    - interface: wg0\n      wireguard: {}\n",
+			expected: "machine:\n  network:\n    interfaces:\n    - interface: wg0\n      wireguard: {}\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			data, errWithCode := configureIdentity([]byte(tt.config), tt.identity)
+			if errWithCode.errorObj != nil {
+				t.Fatal(errWithCode.errorObj)
+			}
+
+			var actual, expected interface{}
+
+			if err := yaml.Unmarshal(data, &actual); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := yaml.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("configureIdentity() = %s, want %s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFetchServerIdentity(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	metalCluster := &v1alpha3.MetalCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "management",
+			UID:       "1234",
+		},
+	}
+
+	m := &metadataConfigs{
+		client: fake.NewFakeClientWithScheme(scheme),
+	}
+
+	ctx := context.Background()
+
+	identity, errWithCode := m.fetchServerIdentity(ctx, metalCluster, "cluster", "server-a")
+	if errWithCode.errorObj != nil {
+		t.Fatal(errWithCode.errorObj)
+	}
+
+	if len(identity.Data[identityWireguardPrivateKey]) == 0 || len(identity.Data[identityWireguardPublicKey]) == 0 {
+		t.Fatalf("identity keys are not generated: %v", identity.Data)
+	}
+
+	var persisted v1.Secret
+
+	if err := m.client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "management-server-a-identity"}, &persisted); err != nil {
+		t.Fatal(err)
+	}
+
+	if persisted.Labels[capiv1.ClusterLabelName] != "cluster" {
+		t.Errorf("cluster label = %q, want %q", persisted.Labels[capiv1.ClusterLabelName], "cluster")
+	}
+
+	if len(persisted.OwnerReferences) != 1 || persisted.OwnerReferences[0].Kind != "MetalCluster" || persisted.OwnerReferences[0].UID != metalCluster.UID {
+		t.Errorf("unexpected owner references %v", persisted.OwnerReferences)
+	}
+
+	// identity is stable across the requests
+	again, errWithCode := m.fetchServerIdentity(ctx, metalCluster, "cluster", "server-a")
+	if errWithCode.errorObj != nil {
+		t.Fatal(errWithCode.errorObj)
+	}
+
+	if !reflect.DeepEqual(again.Data, identity.Data) {
+		t.Error("identity changed on the second request")
+	}
+
+	// each server has the identity of its own
+	other, errWithCode := m.fetchServerIdentity(ctx, metalCluster, "cluster", "server-b")
+	if errWithCode.errorObj != nil {
+		t.Fatal(errWithCode.errorObj)
+	}
+
+	if reflect.DeepEqual(other.Data, identity.Data) {
+		t.Error("servers share the identity")
+	}
+}
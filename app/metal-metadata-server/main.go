@@ -7,19 +7,28 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"net/http/pprof"
+	"sort"
+	"strconv"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/ghodss/yaml"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/talos-systems/talos/pkg/machinery/config/configloader"
 	"github.com/talos-systems/talos/pkg/machinery/config/types/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
@@ -27,9 +36,15 @@ import (
 	"github.com/talos-systems/sidero/app/metal-metadata-server/pkg/client"
 )
 
+const bootstrapDataRetryAfter = 10 * time.Second
+
 var (
 	kubeconfigPath *string
 	port           *string
+	metricsAddr    *string
+	logRequests    *bool
+	enablePprof    *bool
+	strictMode     *bool
 )
 
 type errorWithCode struct {
@@ -39,16 +54,79 @@ type errorWithCode struct {
 
 type metadataConfigs struct {
 	client runtimeclient.Client
+	strict bool
+}
+
+// bootstrapDataError is returned when the bootstrap data is not available yet (or it's stale).
+//
+// Machine is expected to retry fetching the config, so the error is returned as a structured retryable response.
+type bootstrapDataError struct {
+	reason string
+	err    error
+}
+
+func (e *bootstrapDataError) Error() string {
+	return e.err.Error()
+}
+
+func (e *bootstrapDataError) Unwrap() error {
+	return e.err
+}
+
+// renderError is returned when the machine configuration can't be rendered from the bootstrap data.
+//
+// Retrying doesn't help, the config patches (or the machine defaults) should be fixed.
+type renderError struct {
+	err error
+}
+
+func (e *renderError) Error() string {
+	return e.err.Error()
+}
+
+func (e *renderError) Unwrap() error {
+	return e.err
 }
 
 func throwError(w http.ResponseWriter, ewc errorWithCode) {
-	http.Error(w, ewc.errorObj.Error(), ewc.errorCode)
+	if aw, ok := w.(*accessLogWriter); ok {
+		aw.entry.err = ewc.errorObj
+	}
+
 	log.Println(ewc.errorObj)
+
+	var bootstrapErr *bootstrapDataError
+
+	if !errors.As(ewc.errorObj, &bootstrapErr) {
+		http.Error(w, ewc.errorObj.Error(), ewc.errorCode)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(bootstrapDataRetryAfter.Seconds())))
+	w.WriteHeader(ewc.errorCode)
+
+	if err := json.NewEncoder(w).Encode(struct {
+		Error     string `json:"error"`
+		Reason    string `json:"reason"`
+		Retryable bool   `json:"retryable"`
+	}{
+		Error:     bootstrapErr.Error(),
+		Reason:    bootstrapErr.reason,
+		Retryable: true,
+	}); err != nil {
+		log.Printf("failed to write error: %v", err)
+	}
 }
 
 func main() {
 	kubeconfigPath = flag.String("kubeconfig-path", "", "absolute path to the kubeconfig file")
 	port = flag.String("port", "8080", "port to use for serving metadata")
+	metricsAddr = flag.String("metrics-addr", ":8081", "The address the metric endpoint binds to (empty disables metrics endpoint).")
+	logRequests = flag.Bool("access-log", true, "Log every metadata request.")
+	enablePprof = flag.Bool("enable-pprof", false, "Serve pprof profiles under /debug/pprof/ on the metrics endpoint.")
+	strictMode = flag.Bool("strict-mode", false, "Deny configs to the servers which are unknown, not accepted, or not allocated.")
 	flag.Parse()
 
 	k8sClient, err := client.NewClient(kubeconfigPath)
@@ -58,16 +136,52 @@ func main() {
 
 	mm := metadataConfigs{
 		client: k8sClient,
+		strict: *strictMode,
 	}
 
-	http.HandleFunc("/configdata", mm.FetchConfig)
-	log.Fatal(http.ListenAndServe(":"+*port, nil))
+	if *metricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+
+			if *enablePprof {
+				mux.HandleFunc("/debug/pprof/", pprof.Index)
+				mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+				mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+				mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+				mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+			}
+
+			log.Fatal(http.ListenAndServe(*metricsAddr, mux))
+		}()
+	}
+
+	// net/http/pprof registers the profiles on the default mux, so the public listener uses a mux of its own
+	mux := http.NewServeMux()
+	mux.HandleFunc("/configdata", instrument(mm.FetchConfig, *logRequests))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/readyz", mm.Ready)
+	log.Fatal(http.ListenAndServe(":"+*port, mux))
+}
+
+// Ready reports whether the metadata server can reach the Kubernetes API, as configs can't be served otherwise.
+func (m *metadataConfigs) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := m.client.List(ctx, &v1alpha3.MetalMachineList{}, runtimeclient.Limit(1)); err != nil {
+		http.Error(w, fmt.Sprintf("failed to reach Kubernetes API: %s", err), http.StatusServiceUnavailable)
+
+		return
+	}
 }
 
 func (m *metadataConfigs) FetchConfig(w http.ResponseWriter, r *http.Request) {
 	// Parse info out of incoming request
 	ctx := r.Context()
 
+	entry := accessLog(ctx)
+
 	vals := r.URL.Query()
 
 	uuid := vals.Get("uuid")
@@ -87,8 +201,45 @@ func (m *metadataConfigs) FetchConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	format, err := outputFormat(r)
+	if err != nil {
+		throwError(
+			w,
+			errorWithCode{
+				http.StatusBadRequest,
+				err,
+			},
+		)
+
+		return
+	}
+
+	path, err := configPath(r)
+	if err != nil {
+		throwError(
+			w,
+			errorWithCode{
+				http.StatusBadRequest,
+				err,
+			},
+		)
+
+		return
+	}
+
 	log.Printf("received metadata request for uuid: %s", uuid)
 
+	if m.strict {
+		if ewc := m.checkStrict(ctx, r, uuid); ewc.errorObj != nil {
+			throwError(
+				w,
+				ewc,
+			)
+
+			return
+		}
+	}
+
 	// Find serverBinding and metalMachine by server UUID.
 	metalMachine, serverBinding, ewc := m.findMetalMachineServerBinding(ctx, uuid)
 	if ewc.errorObj != nil {
@@ -119,32 +270,35 @@ func (m *metadataConfigs) FetchConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	entry.machine = ownerMachine.Namespace + "/" + ownerMachine.Name
+
 	// Dig bootstrap secret name out of owner Machine resource and fetch secret data
-	bootstrapSecretName := ownerMachine.Spec.Bootstrap.DataSecretName
+	decodedData, ewc := m.fetchBootstrapData(ctx, ownerMachine)
+	if ewc.errorObj != nil {
+		m.setBootstrapDataCondition(ctx, &metalMachine, ewc.errorObj)
 
-	if bootstrapSecretName == nil {
 		throwError(
 			w,
-			errorWithCode{
-				http.StatusNotFound,
-				fmt.Errorf(
-					"no dataSecretName present for machine %s/%s",
-					ownerMachine.Namespace,
-					ownerMachine.Name,
-				),
-			},
+			ewc,
 		)
 
 		return
 	}
 
-	decodedData, ewc := m.fetchBootstrapSecret(
-		ctx,
-		types.NamespacedName{
-			Name:      *bootstrapSecretName,
-			Namespace: ownerMachine.Namespace,
-		},
-	)
+	// renderFailed records the failure to render the machine configuration on the machine.
+	renderFailed := func(ewc errorWithCode) {
+		ewc.errorObj = &renderError{err: ewc.errorObj}
+
+		m.setBootstrapDataCondition(ctx, &metalMachine, ewc.errorObj)
+
+		throwError(
+			w,
+			ewc,
+		)
+	}
+
+	// Apply cluster-wide machine defaults, so that serverclass and server patches can override them.
+	metalCluster, ewc := m.fetchMetalCluster(ctx, ownerMachine)
 	if ewc.errorObj != nil {
 		throwError(
 			w,
@@ -154,6 +308,36 @@ func (m *metadataConfigs) FetchConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if metalCluster != nil && metalCluster.Spec.MachineDefaults != nil {
+		decodedData, ewc = applyMachineDefaults(decodedData, metalCluster.Spec.MachineDefaults)
+		if ewc.errorObj != nil {
+			renderFailed(ewc)
+
+			return
+		}
+
+		if len(metalCluster.Spec.MachineDefaults.RegistryConfigs) > 0 {
+			var registries map[string]interface{}
+
+			registries, ewc = m.fetchRegistryConfigs(ctx, metalCluster)
+			if ewc.errorObj != nil {
+				throwError(
+					w,
+					ewc,
+				)
+
+				return
+			}
+
+			decodedData, ewc = configureRegistries(decodedData, registries)
+			if ewc.errorObj != nil {
+				renderFailed(ewc)
+
+				return
+			}
+		}
+	}
+
 	// Get the server resource by the UUID that was passed in.
 	// We do this to fetch serverclass and any configPatches in the server resource that we need to handle.
 	serverObj := &metalv1alpha1.Server{}
@@ -212,22 +396,114 @@ func (m *metadataConfigs) FetchConfig(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Configure the provisioning and the workload networks (by default, DHCP on the network interface the server is provisioned through).
+	// This is done before applying patches, so that patches can override it.
+	network := serverObj.Spec.Network
+	if network == nil {
+		network = serverClassObj.Spec.Network
+	}
+
+	decodedData, ewc = configureNetwork(decodedData, serverObj.ProvisioningInterfaceName(), network)
+	if ewc.errorObj != nil {
+		renderFailed(ewc)
+
+		return
+	}
+
+	// Configure the install disk and the extra disks with the stable disk paths resolved from the server inventory.
+	// This is done before applying patches, so that patches can override it.
+	// Diskless servers have no disks to resolve, the storage is configured by the serverclass patches.
+	storage := serverObj.Spec.Storage
+	if storage == nil {
+		storage = serverClassObj.Spec.Storage
+	}
+
+	if storage != nil && !serverClassObj.Spec.Diskless {
+		decodedData, ewc = configureStorage(decodedData, serverObj, storage)
+		if ewc.errorObj != nil {
+			renderFailed(ewc)
+
+			return
+		}
+	}
+
+	// Servers in the reinstall mode are not wiped on release, so the installer should only re-create the system partitions.
+	// This is done before applying patches, so that patches can override it.
+	if serverObj.Spec.WipeMode == metalv1alpha1.WipeModeReinstall {
+		decodedData, ewc = disableInstallWipe(decodedData)
+		if ewc.errorObj != nil {
+			renderFailed(ewc)
+
+			return
+		}
+	}
+
+	// Handle patch sets referenced by serverclass object
+	decodedData, ewc = m.applyConfigPatchSets(ctx, decodedData, serverClassObj.Spec.ConfigPatchSets)
+	if ewc.errorObj != nil {
+		renderFailed(ewc)
+
+		return
+	}
+
 	// Handle patches added to serverclass object
 	if serverClassObj != nil && len(serverClassObj.Spec.ConfigPatches) > 0 {
+		entry.patches += len(serverClassObj.Spec.ConfigPatches)
+
 		decodedData, ewc = patchConfigs(decodedData, serverClassObj.Spec.ConfigPatches)
 		if ewc.errorObj != nil {
-			throwError(
-				w,
-				ewc,
-			)
+			renderFailed(ewc)
 
 			return
 		}
 	}
 
+	// Handle merge patches added to serverclass object
+	if len(serverClassObj.Spec.ConfigMergePatches) > 0 {
+		entry.patches += len(serverClassObj.Spec.ConfigMergePatches)
+
+		decodedData, ewc = mergePatchConfigs(decodedData, serverClassObj.Spec.ConfigMergePatches)
+		if ewc.errorObj != nil {
+			renderFailed(ewc)
+
+			return
+		}
+	}
+
+	// Handle patch sets referenced by server object
+	decodedData, ewc = m.applyConfigPatchSets(ctx, decodedData, serverObj.Spec.ConfigPatchSets)
+	if ewc.errorObj != nil {
+		renderFailed(ewc)
+
+		return
+	}
+
 	// Handle patches added to server object
 	if len(serverObj.Spec.ConfigPatches) > 0 {
+		entry.patches += len(serverObj.Spec.ConfigPatches)
+
 		decodedData, ewc = patchConfigs(decodedData, serverObj.Spec.ConfigPatches)
+		if ewc.errorObj != nil {
+			renderFailed(ewc)
+
+			return
+		}
+	}
+
+	// Handle patch sets referenced by metalmachine object
+	decodedData, ewc = m.applyConfigPatchSets(ctx, decodedData, metalMachine.Spec.ConfigPatchSets)
+	if ewc.errorObj != nil {
+		renderFailed(ewc)
+
+		return
+	}
+
+	// Render the persisted server identity, so that the machines rebuilt on the server keep it.
+	// This is done after applying patches, as the WireGuard interfaces are configured by the patches.
+	if metalCluster != nil && metalCluster.Spec.MachineDefaults != nil && metalCluster.Spec.MachineDefaults.PersistentIdentity {
+		var identity *v1.Secret
+
+		identity, ewc = m.fetchServerIdentity(ctx, metalCluster, ownerMachine.Spec.ClusterName, serverObj.Name)
 		if ewc.errorObj != nil {
 			throwError(
 				w,
@@ -236,11 +512,42 @@ func (m *metadataConfigs) FetchConfig(w http.ResponseWriter, r *http.Request) {
 
 			return
 		}
+
+		decodedData, ewc = configureIdentity(decodedData, identity)
+		if ewc.errorObj != nil {
+			renderFailed(ewc)
+
+			return
+		}
 	}
 
 	// Append or add a node label to kubelet extra args.
 	// We must do this so that we can map a given server resource to a k8s node in the workload cluster.
-	decodedData, ewc = labelNodes(decodedData, serverObj.Name)
+	// Node labels and taints of the serverclass are registered by the kubelet as well, so that the node is never seen without them.
+	nodeLabels := serverClassObj.Spec.NodeLabels
+
+	// ordinal of the machine is registered as well, so that the workloads can map the node to its ordinal identity
+	if ordinal, ok := metalMachine.Labels[v1alpha3.OrdinalLabel]; ok {
+		nodeLabels = make(map[string]string, len(serverClassObj.Spec.NodeLabels)+1)
+
+		for key, value := range serverClassObj.Spec.NodeLabels {
+			nodeLabels[key] = value
+		}
+
+		nodeLabels[v1alpha3.OrdinalLabel] = ordinal
+	}
+
+	decodedData, ewc = labelNodes(decodedData, serverObj.Name, nodeLabels, serverClassObj.Spec.NodeTaints)
+	if ewc.errorObj != nil {
+		renderFailed(ewc)
+
+		return
+	}
+
+	m.setBootstrapDataCondition(ctx, &metalMachine, nil)
+
+	// Wrap config data for the non-Talos operating systems
+	decodedData, contentType, ewc := wrapConfig(decodedData, format, path)
 	if ewc.errorObj != nil {
 		throwError(
 			w,
@@ -250,6 +557,12 @@ func (m *metadataConfigs) FetchConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	entry.generation = time.Since(entry.start)
+
 	// Finally return config data
 	if _, err = w.Write(decodedData); err != nil {
 		log.Printf("failed to write data: %v", err)
@@ -289,9 +602,288 @@ func patchConfigs(decodedData []byte, patches []metalv1alpha1.ConfigPatches) ([]
 	return decodedData, errorWithCode{}
 }
 
+// mergePatchConfigs is responsible for applying a set of RFC 7386 merge patches to the bootstrap data.
+func mergePatchConfigs(decodedData []byte, patches []string) ([]byte, errorWithCode) {
+	jsonDecodedData, err := yaml.YAMLToJSON(decodedData)
+	if err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure converting bootstrap data to json: %s", err)}
+	}
+
+	for i, patch := range patches {
+		var jsonPatch []byte
+
+		jsonPatch, err = yaml.YAMLToJSON([]byte(patch))
+		if err != nil {
+			return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure converting merge patch %d to json: %s", i, err)}
+		}
+
+		jsonDecodedData, err = jsonpatch.MergePatch(jsonDecodedData, jsonPatch)
+		if err != nil {
+			return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure applying merge patch %d to machine config: %s", i, err)}
+		}
+	}
+
+	decodedData, err = yaml.JSONToYAML(jsonDecodedData)
+	if err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure converting bootstrap data from json to yaml: %s", err)}
+	}
+
+	return decodedData, errorWithCode{}
+}
+
+// applyConfigPatchSets is responsible for applying patches from the ConfigPatchSets in the order they are referenced.
+func (m *metadataConfigs) applyConfigPatchSets(ctx context.Context, decodedData []byte, names []string) ([]byte, errorWithCode) {
+	for _, name := range names {
+		patchSet := &metalv1alpha1.ConfigPatchSet{}
+
+		if err := m.client.Get(ctx, types.NamespacedName{Name: name}, patchSet); err != nil {
+			return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure fetching configpatchset %s: %s", name, err)}
+		}
+
+		accessLog(ctx).patches += len(patchSet.Spec.ConfigPatches)
+
+		var ewc errorWithCode
+
+		decodedData, ewc = patchConfigs(decodedData, patchSet.Spec.ConfigPatches)
+		if ewc.errorObj != nil {
+			return nil, ewc
+		}
+	}
+
+	return decodedData, errorWithCode{}
+}
+
+// applyMachineDefaults is responsible for rendering MetalCluster machine defaults into the bootstrap data.
+func applyMachineDefaults(decodedData []byte, defaults *v1alpha3.MachineDefaults) ([]byte, errorWithCode) {
+	var config map[string]interface{}
+
+	if err := yaml.Unmarshal(decodedData, &config); err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure unmarshaling bootstrap data: %s", err)}
+	}
+
+	if len(defaults.TimeServers) > 0 {
+		setConfigValue(config, []string{"machine", "time", "servers"}, defaults.TimeServers)
+	}
+
+	if len(defaults.Nameservers) > 0 {
+		setConfigValue(config, []string{"machine", "network", "nameservers"}, defaults.Nameservers)
+	}
+
+	for registry, mirror := range defaults.RegistryMirrors {
+		setConfigValue(config, []string{"machine", "registries", "mirrors", registry, "endpoints"}, mirror.Endpoints)
+	}
+
+	decodedData, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure marshaling bootstrap data: %s", err)}
+	}
+
+	return decodedData, errorWithCode{}
+}
+
+// disableInstallWipe sets the installer to keep the contents of the install disk outside of the Talos system partitions.
+func disableInstallWipe(decodedData []byte) ([]byte, errorWithCode) {
+	var config map[string]interface{}
+
+	if err := yaml.Unmarshal(decodedData, &config); err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure unmarshaling bootstrap data: %s", err)}
+	}
+
+	setConfigValue(config, []string{"machine", "install", "wipe"}, false)
+
+	decodedData, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure marshaling bootstrap data: %s", err)}
+	}
+
+	return decodedData, errorWithCode{}
+}
+
+// configureNetwork configures the provisioning interface and the workload network in the bootstrap data.
+//
+// Provisioning interface is configured with DHCP (on the provisioning VLAN, if set), and the workload network
+// interfaces are bonded (if there are several of them) and configured with DHCP (on the workload VLAN, if set).
+// Interfaces which are already configured in the bootstrap data are not changed.
+func configureNetwork(decodedData []byte, provisioningInterface string, network *metalv1alpha1.NetworkConfig) ([]byte, errorWithCode) {
+	if network == nil {
+		network = &metalv1alpha1.NetworkConfig{}
+	}
+
+	var devices []map[string]interface{}
+
+	if provisioningInterface != "" {
+		devices = append(devices, networkDevice(provisioningInterface, network.ProvisioningVLAN))
+	}
+
+	if workload := network.Workload; workload != nil && len(workload.Interfaces) > 0 {
+		if len(workload.Interfaces) == 1 {
+			devices = append(devices, networkDevice(workload.Interfaces[0], workload.VLAN))
+		} else {
+			bondMode := workload.BondMode
+			if bondMode == "" {
+				bondMode = "802.3ad"
+			}
+
+			bond := networkDevice("bond0", workload.VLAN)
+			bond["bond"] = map[string]interface{}{
+				"mode":       bondMode,
+				"interfaces": workload.Interfaces,
+			}
+
+			devices = append(devices, bond)
+		}
+	}
+
+	if len(devices) == 0 {
+		return decodedData, errorWithCode{}
+	}
+
+	var config map[string]interface{}
+
+	if err := yaml.Unmarshal(decodedData, &config); err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure unmarshaling bootstrap data: %s", err)}
+	}
+
+	var interfaces []interface{}
+
+	if machine, ok := config["machine"].(map[string]interface{}); ok {
+		if network, ok := machine["network"].(map[string]interface{}); ok {
+			interfaces, _ = network["interfaces"].([]interface{})
+		}
+	}
+
+	configured := map[interface{}]bool{}
+
+	for _, iface := range interfaces {
+		if iface, ok := iface.(map[string]interface{}); ok {
+			configured[iface["interface"]] = true
+		}
+	}
+
+	changed := false
+
+	for _, device := range devices {
+		if configured[device["interface"]] {
+			continue
+		}
+
+		interfaces = append(interfaces, device)
+		configured[device["interface"]] = true
+		changed = true
+	}
+
+	if !changed {
+		return decodedData, errorWithCode{}
+	}
+
+	setConfigValue(config, []string{"machine", "network", "interfaces"}, interfaces)
+
+	decodedData, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure marshaling bootstrap data: %s", err)}
+	}
+
+	return decodedData, errorWithCode{}
+}
+
+// configureStorage resolves the disk selectors to the disks of the server, and configures the install disk
+// and the extra disks in the bootstrap data with the stable disk paths (/dev/disk/by-id).
+//
+// Each disk is selected at most once, the install disk is resolved first.
+func configureStorage(decodedData []byte, server *metalv1alpha1.Server, storage *metalv1alpha1.StorageConfig) ([]byte, errorWithCode) {
+	var config map[string]interface{}
+
+	if err := yaml.Unmarshal(decodedData, &config); err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure unmarshaling bootstrap data: %s", err)}
+	}
+
+	used := map[string]struct{}{}
+
+	if storage.InstallDisk != nil {
+		disk, ok := server.FindDisk(storage.InstallDisk, used)
+		if !ok {
+			return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("no disk of server %q matches the install disk selector", server.Name)}
+		}
+
+		setConfigValue(config, []string{"machine", "install", "disk"}, disk.Path())
+	}
+
+	if len(storage.ExtraDisks) > 0 {
+		var disks []interface{}
+
+		if machine, ok := config["machine"].(map[string]interface{}); ok {
+			disks, _ = machine["disks"].([]interface{})
+		}
+
+		for i := range storage.ExtraDisks {
+			extraDisk := &storage.ExtraDisks[i]
+
+			disk, ok := server.FindDisk(&extraDisk.DiskSelector, used)
+			if !ok {
+				return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("no disk of server %q matches the extra disk selector for %q", server.Name, extraDisk.Mountpoint)}
+			}
+
+			disks = append(disks, map[string]interface{}{
+				"device": disk.Path(),
+				"partitions": []interface{}{
+					map[string]interface{}{
+						"mountpoint": extraDisk.Mountpoint,
+					},
+				},
+			})
+		}
+
+		setConfigValue(config, []string{"machine", "disks"}, disks)
+	}
+
+	decodedData, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure marshaling bootstrap data: %s", err)}
+	}
+
+	return decodedData, errorWithCode{}
+}
+
+// networkDevice returns the Talos network device configured with DHCP, on the VLAN if it's set.
+func networkDevice(name string, vlan int) map[string]interface{} {
+	if vlan == 0 {
+		return map[string]interface{}{
+			"interface": name,
+			"dhcp":      true,
+		}
+	}
+
+	return map[string]interface{}{
+		"interface": name,
+		"vlans": []interface{}{
+			map[string]interface{}{
+				"vlanId": vlan,
+				"dhcp":   true,
+			},
+		},
+	}
+}
+
+// setConfigValue sets the value in the nested config map, creating intermediate maps as needed.
+func setConfigValue(config map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := config[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			config[key] = next
+		}
+
+		config = next
+	}
+
+	config[path[len(path)-1]] = value
+}
+
 // labelNodes is responsible for editing the kubelet extra args such that a given
 // server gets registered with a label containing the UUID of the server resource it's actually running on.
-func labelNodes(decodedData []byte, serverName string) ([]byte, errorWithCode) {
+//
+// Extra labels and taints are registered along with it.
+func labelNodes(decodedData []byte, serverName string, labels map[string]string, taints []v1.Taint) ([]byte, errorWithCode) {
 	configProvider, err := configloader.NewFromBytes(decodedData)
 	if err != nil {
 		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure creating config struct: %s", err)}
@@ -309,7 +901,12 @@ func labelNodes(decodedData []byte, serverName string) ([]byte, errorWithCode) {
 			Op:   "replace",
 		}
 
-		kubeletExtraArgs := config.MachineConfig.MachineKubelet.KubeletExtraArgs
+		var kubeletExtraArgs map[string]string
+
+		if config.MachineConfig.MachineKubelet != nil {
+			kubeletExtraArgs = config.MachineConfig.MachineKubelet.KubeletExtraArgs
+		}
+
 		if kubeletExtraArgs == nil {
 			patch.Op = "add"
 			kubeletExtraArgs = make(map[string]string)
@@ -321,7 +918,43 @@ func labelNodes(decodedData []byte, serverName string) ([]byte, errorWithCode) {
 			kubeletExtraArgs["node-labels"] = fmt.Sprintf("metal.sidero.dev/uuid=%s", serverName)
 		}
 
-		value, err := json.Marshal(kubeletExtraArgs)
+		keys := make([]string, 0, len(labels))
+
+		for key := range labels {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			kubeletExtraArgs["node-labels"] += fmt.Sprintf(",%s=%s", key, labels[key])
+		}
+
+		for _, taint := range taints {
+			// same syntax as kubectl taint: key[=value]:effect
+			spec := taint.Key
+			if taint.Value != "" {
+				spec += "=" + taint.Value
+			}
+
+			spec += ":" + string(taint.Effect)
+
+			if kubeletExtraArgs["register-with-taints"] != "" {
+				spec = "," + spec
+			}
+
+			kubeletExtraArgs["register-with-taints"] += spec
+		}
+
+		var patchValue interface{} = kubeletExtraArgs
+
+		// without the kubelet section, the patch has to add the whole section
+		if config.MachineConfig.MachineKubelet == nil {
+			patch.Path = "/machine/kubelet"
+			patchValue = map[string]interface{}{"extraArgs": kubeletExtraArgs}
+		}
+
+		value, err := json.Marshal(patchValue)
 		if err != nil {
 			return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure marshaling kubelet.extraArgs: %s", err)}
 		}
@@ -347,6 +980,14 @@ func (m *metadataConfigs) findMetalMachineServerBinding(ctx context.Context, ser
 		return v1alpha3.MetalMachine{}, v1alpha3.ServerBinding{}, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure getting server binding: %w", err)}
 	}
 
+	// bindings mirrored by the standby installation are served by the active one, as the machine configs are not mirrored
+	if _, ok := serverBinding.Labels[metalv1alpha1.MirroredLabel]; ok {
+		return v1alpha3.MetalMachine{}, v1alpha3.ServerBinding{}, errorWithCode{
+			http.StatusServiceUnavailable,
+			fmt.Errorf("server %s is served by the active Sidero installation", serverName),
+		}
+	}
+
 	var metalMachine v1alpha3.MetalMachine
 
 	if err = m.client.Get(ctx, types.NamespacedName{
@@ -360,8 +1001,46 @@ func (m *metadataConfigs) findMetalMachineServerBinding(ctx context.Context, ser
 	return metalMachine, serverBinding, errorWithCode{}
 }
 
-// fetchBootstrapSecret is responsible for fetching a secret that contains the bootstrap data created by our bootstrap provider.
-func (m *metadataConfigs) fetchBootstrapSecret(ctx context.Context, secretNSN types.NamespacedName) ([]byte, errorWithCode) {
+// fetchMetalCluster is responsible for looking up the MetalCluster of the machine's cluster.
+//
+// If the cluster infrastructure is not a MetalCluster, nil is returned.
+func (m *metadataConfigs) fetchMetalCluster(ctx context.Context, machine *capiv1.Machine) (*v1alpha3.MetalCluster, errorWithCode) {
+	cluster, err := util.GetClusterFromMetadata(ctx, m.client, machine.ObjectMeta)
+	if err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure fetching cluster for machine %s/%s: %w", machine.Namespace, machine.Name, err)}
+	}
+
+	infraRef := cluster.Spec.InfrastructureRef
+	if infraRef == nil || infraRef.Kind != "MetalCluster" {
+		return nil, errorWithCode{}
+	}
+
+	var metalCluster v1alpha3.MetalCluster
+
+	if err = m.client.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: infraRef.Name}, &metalCluster); err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure getting metalcluster: %w", err)}
+	}
+
+	return &metalCluster, errorWithCode{}
+}
+
+// fetchBootstrapData is responsible for fetching the bootstrap data created by our bootstrap provider for the machine.
+//
+// Bootstrap data is verified to be current: the secret should be generated for the bootstrap config the machine refers to.
+func (m *metadataConfigs) fetchBootstrapData(ctx context.Context, machine *capiv1.Machine) ([]byte, errorWithCode) {
+	notReady := func(reason string, format string, args ...interface{}) ([]byte, errorWithCode) {
+		return nil, errorWithCode{http.StatusServiceUnavailable, &bootstrapDataError{reason: reason, err: fmt.Errorf(format, args...)}}
+	}
+
+	if machine.Spec.Bootstrap.DataSecretName == nil || !machine.Status.BootstrapReady {
+		return notReady(v1alpha3.WaitingForBootstrapDataReason, "bootstrap data for machine %s/%s is not ready yet", machine.Namespace, machine.Name)
+	}
+
+	secretNSN := types.NamespacedName{
+		Name:      *machine.Spec.Bootstrap.DataSecretName,
+		Namespace: machine.Namespace,
+	}
+
 	bootstrapSecretData := &v1.Secret{}
 
 	err := m.client.Get(
@@ -371,15 +1050,65 @@ func (m *metadataConfigs) fetchBootstrapSecret(ctx context.Context, secretNSN ty
 	)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			return nil, errorWithCode{http.StatusNotFound, fmt.Errorf("bootstrap secret %s/%s not found", secretNSN.Namespace, secretNSN.Name)}
+			return notReady(v1alpha3.BootstrapSecretMissingReason, "bootstrap secret %s/%s not found", secretNSN.Namespace, secretNSN.Name)
 		}
 
 		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure fetching bootstrap secret data from secret %s/%s: %s", secretNSN.Namespace, secretNSN.Name, err)}
 	}
 
-	if _, ok := bootstrapSecretData.Data["value"]; !ok {
-		return nil, errorWithCode{http.StatusNotFound, fmt.Errorf("value key not found in bootstrap data: %s/%s", secretNSN.Namespace, secretNSN.Name)}
+	if configRef := machine.Spec.Bootstrap.ConfigRef; configRef != nil {
+		for _, ref := range bootstrapSecretData.OwnerReferences {
+			if ref.Kind != configRef.Kind {
+				continue
+			}
+
+			if ref.Name != configRef.Name || (configRef.UID != "" && ref.UID != configRef.UID) {
+				return notReady(v1alpha3.BootstrapSecretStaleReason, "bootstrap secret %s/%s belongs to %s %q, not to %s %q of the machine",
+					secretNSN.Namespace, secretNSN.Name, ref.Kind, ref.Name, configRef.Kind, configRef.Name)
+			}
+		}
+	}
+
+	if len(bootstrapSecretData.Data["value"]) == 0 {
+		return notReady(v1alpha3.WaitingForBootstrapDataReason, "value key not found in bootstrap data: %s/%s", secretNSN.Namespace, secretNSN.Name)
 	}
 
 	return bootstrapSecretData.Data["value"], errorWithCode{}
 }
+
+// setBootstrapDataCondition records whether the bootstrap data was served to the machine.
+//
+// Failure to record the condition doesn't fail the request, it's only logged.
+func (m *metadataConfigs) setBootstrapDataCondition(ctx context.Context, metalMachine *v1alpha3.MetalMachine, err error) {
+	if patchErr := m.patchBootstrapDataCondition(ctx, metalMachine, err); patchErr != nil {
+		log.Printf("failed to update metalmachine %s/%s condition: %s", metalMachine.Namespace, metalMachine.Name, patchErr)
+	}
+}
+
+func (m *metadataConfigs) patchBootstrapDataCondition(ctx context.Context, metalMachine *v1alpha3.MetalMachine, err error) error {
+	patchHelper, patchErr := patch.NewHelper(metalMachine, m.client)
+	if patchErr != nil {
+		return patchErr
+	}
+
+	var (
+		bootstrapErr *bootstrapDataError
+		renderErr    *renderError
+	)
+
+	switch {
+	case err == nil:
+		conditions.MarkTrue(metalMachine, v1alpha3.BootstrapDataReadyCondition)
+	case errors.As(err, &bootstrapErr):
+		conditions.MarkFalse(metalMachine, v1alpha3.BootstrapDataReadyCondition, bootstrapErr.reason, capiv1.ConditionSeverityWarning, "%s", bootstrapErr.Error())
+	case errors.As(err, &renderErr):
+		conditions.MarkFalse(metalMachine, v1alpha3.BootstrapDataReadyCondition, v1alpha3.ConfigRenderErrorReason, capiv1.ConditionSeverityError, "%s", renderErr.Error())
+	default:
+		// transient failure, nothing to record
+		return nil
+	}
+
+	return patchHelper.Patch(ctx, metalMachine, patch.WithOwnedConditions{
+		Conditions: []capiv1.ConditionType{v1alpha3.BootstrapDataReadyCondition},
+	})
+}
@@ -0,0 +1,316 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/talos-systems/talos/pkg/machinery/config/configloader"
+	"github.com/talos-systems/talos/pkg/machinery/config/types/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestFindMetalMachineServerBinding(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := v1alpha3.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		labels map[string]string
+		code   int
+	}{
+		{
+			name: "allocated server",
+		},
+		{
+			name:   "binding mirrored from the active installation",
+			labels: map[string]string{metalv1alpha1.MirroredLabel: "true"},
+			code:   http.StatusServiceUnavailable,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &metadataConfigs{
+				client: fake.NewFakeClientWithScheme(scheme,
+					&v1alpha3.ServerBinding{
+						ObjectMeta: metav1.ObjectMeta{Name: "server", Labels: tt.labels},
+						Spec: v1alpha3.ServerBindingSpec{
+							MetalMachineRef: corev1.ObjectReference{Namespace: "default", Name: "machine"},
+						},
+					},
+					&v1alpha3.MetalMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine"}},
+				),
+			}
+
+			_, _, ewc := m.findMetalMachineServerBinding(context.Background(), "server")
+
+			if ewc.errorCode != tt.code {
+				t.Errorf("code = %d, want %d (%v)", ewc.errorCode, tt.code, ewc.errorObj)
+			}
+		})
+	}
+}
+
+func TestLabelNodes(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		config   string
+		labels   map[string]string
+		taints   []corev1.Taint
+		expected map[string]string
+	}{
+		{
+			name:   "no kubelet extra args",
+			config: "version: v1alpha1\nmachine:\n  type: worker\n",
+			expected: map[string]string{
+				"node-labels": "metal.sidero.dev/uuid=server",
+			},
+		},
+		{
+			name:   "no kubelet extra args in the kubelet section",
+			config: "version: v1alpha1\nmachine:\n  type: worker\n  kubelet:\n    image: ghcr.io/talos-systems/kubelet:v1.20.1\n",
+			expected: map[string]string{
+				"node-labels": "metal.sidero.dev/uuid=server",
+			},
+		},
+		{
+			name:   "existing kubelet extra args",
+			config: "version: v1alpha1\nmachine:\n  type: worker\n  kubelet:\n    extraArgs:\n      node-labels: rack=a\n      rotate-server-certificates: \"true\"\n",
+			expected: map[string]string{
+				"node-labels":                "rack=a,metal.sidero.dev/uuid=server",
+				"rotate-server-certificates": "true",
+			},
+		},
+		{
+			name:   "serverclass labels and taints",
+			config: "version: v1alpha1\nmachine:\n  type: worker\n",
+			labels: map[string]string{"pool": "gpu", "example.com/tier": "a"},
+			taints: []corev1.Taint{
+				{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "example.com/maintenance", Effect: corev1.TaintEffectNoExecute},
+			},
+			expected: map[string]string{
+				"node-labels":          "metal.sidero.dev/uuid=server,example.com/tier=a,pool=gpu",
+				"register-with-taints": "dedicated=gpu:NoSchedule,example.com/maintenance:NoExecute",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, ewc := labelNodes([]byte(tt.config), "server", tt.labels, tt.taints)
+			if ewc.errorObj != nil {
+				t.Fatal(ewc.errorObj)
+			}
+
+			configProvider, err := configloader.NewFromBytes(patched)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extraArgs := configProvider.(*v1alpha1.Config).MachineConfig.MachineKubelet.KubeletExtraArgs
+
+			if !reflect.DeepEqual(extraArgs, tt.expected) {
+				t.Errorf("kubelet extra args %v, want %v", extraArgs, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyConfigPatchSets(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	patchSet := func(name string, patches ...metalv1alpha1.ConfigPatches) *metalv1alpha1.ConfigPatchSet {
+		return &metalv1alpha1.ConfigPatchSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       metalv1alpha1.ConfigPatchSetSpec{ConfigPatches: patches},
+		}
+	}
+
+	replace := func(path, value string) metalv1alpha1.ConfigPatches {
+		return metalv1alpha1.ConfigPatches{Op: "replace", Path: path, Value: apiextensions.JSON{Raw: []byte(value)}}
+	}
+
+	m := &metadataConfigs{
+		client: fake.NewFakeClientWithScheme(scheme,
+			patchSet("install", replace("/machine/install/disk", `"/dev/sda"`), replace("/machine/install/wipe", "true")),
+			patchSet("nvme", replace("/machine/install/disk", `"/dev/nvme0n1"`)),
+			patchSet("broken", metalv1alpha1.ConfigPatches{Op: "remove", Path: "/machine/network"}),
+		),
+	}
+
+	config := []byte("version: v1alpha1\nmachine:\n  type: worker\n  install:\n    disk: /dev/vda\n    wipe: false\n")
+
+	for _, tt := range []struct {
+		name     string
+		sets     []string
+		expected string
+		patches  int
+		code     int
+	}{
+		{
+			name:     "no patch sets",
+			expected: string(config),
+		},
+		{
+			name:     "patch sets are applied in order",
+			sets:     []string{"install", "nvme"},
+			expected: "machine:\n  install:\n    disk: /dev/nvme0n1\n    wipe: true\n  type: worker\nversion: v1alpha1\n",
+			patches:  3,
+		},
+		{
+			name:     "reversed order",
+			sets:     []string{"nvme", "install"},
+			expected: "machine:\n  install:\n    disk: /dev/sda\n    wipe: true\n  type: worker\nversion: v1alpha1\n",
+			patches:  3,
+		},
+		{
+			name: "missing patch set",
+			sets: []string{"install", "missing"},
+			code: http.StatusInternalServerError,
+		},
+		{
+			name: "patch doesn't apply",
+			sets: []string{"broken"},
+			code: http.StatusInternalServerError,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &accessLogEntry{}
+			ctx := context.WithValue(context.Background(), accessLogKey{}, entry)
+
+			patched, ewc := m.applyConfigPatchSets(ctx, config, tt.sets)
+
+			if ewc.errorCode != tt.code {
+				t.Fatalf("code = %d, want %d (%v)", ewc.errorCode, tt.code, ewc.errorObj)
+			}
+
+			if tt.code != 0 {
+				return
+			}
+
+			if string(patched) != tt.expected {
+				t.Errorf("patched config:\n%s\nwant:\n%s", patched, tt.expected)
+			}
+
+			if entry.patches != tt.patches {
+				t.Errorf("patches logged = %d, want %d", entry.patches, tt.patches)
+			}
+		})
+	}
+}
+
+func TestConfigureNetwork(t *testing.T) {
+	const config = "version: v1alpha1\nmachine:\n  type: worker\n"
+
+	for _, tt := range []struct {
+		name                  string
+		config                string
+		provisioningInterface string
+		network               *metalv1alpha1.NetworkConfig
+		expected              string
+	}{
+		{
+			name:     "nothing to configure",
+			config:   config,
+			expected: "null",
+		},
+		{
+			name:                  "provisioning interface",
+			config:                config,
+			provisioningInterface: "eth0",
+			expected:              "[{interface: eth0, dhcp: true}]",
+		},
+		{
+			name:                  "provisioning VLAN",
+			config:                config,
+			provisioningInterface: "eth0",
+			network:               &metalv1alpha1.NetworkConfig{ProvisioningVLAN: 100},
+			expected:              "[{interface: eth0, vlans: [{vlanId: 100, dhcp: true}]}]",
+		},
+		{
+			name:                  "workload interface",
+			config:                config,
+			provisioningInterface: "eth0",
+			network: &metalv1alpha1.NetworkConfig{
+				Workload: &metalv1alpha1.WorkloadNetwork{Interfaces: []string{"eth1"}, VLAN: 200},
+			},
+			expected: "[{interface: eth0, dhcp: true}, {interface: eth1, vlans: [{vlanId: 200, dhcp: true}]}]",
+		},
+		{
+			name:                  "workload bond",
+			config:                config,
+			provisioningInterface: "eth0",
+			network: &metalv1alpha1.NetworkConfig{
+				Workload: &metalv1alpha1.WorkloadNetwork{Interfaces: []string{"eth1", "eth2"}},
+			},
+			expected: "[{interface: eth0, dhcp: true}, {interface: bond0, dhcp: true, bond: {mode: 802.3ad, interfaces: [eth1, eth2]}}]",
+		},
+		{
+			name:                  "workload bond mode",
+			config:                config,
+			provisioningInterface: "eth0",
+			network: &metalv1alpha1.NetworkConfig{
+				Workload: &metalv1alpha1.WorkloadNetwork{Interfaces: []string{"eth1", "eth2"}, BondMode: "active-backup"},
+			},
+			expected: "[{interface: eth0, dhcp: true}, {interface: bond0, dhcp: true, bond: {mode: active-backup, interfaces: [eth1, eth2]}}]",
+		},
+		{
+			name:                  "interfaces configured in the bootstrap data",
+			config:                config + "  network:\n    interfaces:\n      - interface: eth0\n        cidr: 10.5.0.2/24\n",
+			provisioningInterface: "eth0",
+			network: &metalv1alpha1.NetworkConfig{
+				Workload: &metalv1alpha1.WorkloadNetwork{Interfaces: []string{"eth1"}},
+			},
+			expected: "[{interface: eth0, cidr: 10.5.0.2/24}, {interface: eth1, dhcp: true}]",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, ewc := configureNetwork([]byte(tt.config), tt.provisioningInterface, tt.network)
+			if ewc.errorObj != nil {
+				t.Fatal(ewc.errorObj)
+			}
+
+			var (
+				rendered struct {
+					Machine struct {
+						Network struct {
+							Interfaces interface{} `json:"interfaces"`
+						} `json:"network"`
+					} `json:"machine"`
+				}
+				expected interface{}
+			)
+
+			if err := yaml.Unmarshal(patched, &rendered); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := yaml.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(rendered.Machine.Network.Interfaces, expected) {
+				t.Errorf("interfaces %v, want %v", rendered.Machine.Network.Interfaces, expected)
+			}
+		})
+	}
+}
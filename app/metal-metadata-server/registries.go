@@ -0,0 +1,176 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+)
+
+const (
+	registryUsernameKey = "username"
+	registryPasswordKey = "password"
+	registryCAKey       = "ca.crt"
+)
+
+// dockerConfigAuth is the registry entry of the image pull secret.
+type dockerConfigAuth struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+}
+
+// fetchRegistryConfigs resolves the secrets of the MetalCluster registry configs into the machine config registry settings.
+func (m *metadataConfigs) fetchRegistryConfigs(ctx context.Context, metalCluster *v1alpha3.MetalCluster) (map[string]interface{}, errorWithCode) {
+	configs := make(map[string]interface{}, len(metalCluster.Spec.MachineDefaults.RegistryConfigs))
+
+	for registry, registryConfig := range metalCluster.Spec.MachineDefaults.RegistryConfigs {
+		config := map[string]interface{}{}
+		tls := map[string]interface{}{}
+
+		if registryConfig.AuthSecretRef != nil {
+			secret, ewc := m.fetchRegistrySecret(ctx, metalCluster.Namespace, registryConfig.AuthSecretRef.Name)
+			if ewc.errorObj != nil {
+				return nil, ewc
+			}
+
+			auth, err := registryAuth(registry, secret)
+			if err != nil {
+				return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure reading registry %s credentials from secret %s/%s: %s", registry, secret.Namespace, secret.Name, err)}
+			}
+
+			config["auth"] = auth
+		}
+
+		if registryConfig.CASecretRef != nil {
+			secret, ewc := m.fetchRegistrySecret(ctx, metalCluster.Namespace, registryConfig.CASecretRef.Name)
+			if ewc.errorObj != nil {
+				return nil, ewc
+			}
+
+			ca := secret.Data[registryCAKey]
+			if len(ca) == 0 {
+				return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("registry %s CA secret %s/%s has no %q key", registry, secret.Namespace, secret.Name, registryCAKey)}
+			}
+
+			// machine config stores the CA as base64 encoded bytes
+			tls["ca"] = base64.StdEncoding.EncodeToString(ca)
+		}
+
+		if registryConfig.InsecureSkipVerify {
+			tls["insecureSkipVerify"] = true
+		}
+
+		if len(tls) > 0 {
+			config["tls"] = tls
+		}
+
+		configs[registry] = config
+	}
+
+	return configs, errorWithCode{}
+}
+
+func (m *metadataConfigs) fetchRegistrySecret(ctx context.Context, namespace, name string) (*v1.Secret, errorWithCode) {
+	secret := &v1.Secret{}
+
+	if err := m.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure fetching registry secret %s/%s: %s", namespace, name, err)}
+	}
+
+	return secret, errorWithCode{}
+}
+
+// registryAuth returns the machine config credentials of the registry from the username/password or the image pull secret.
+func registryAuth(registry string, secret *v1.Secret) (map[string]interface{}, error) {
+	if secret.Type != v1.SecretTypeDockerConfigJson {
+		username, password := string(secret.Data[registryUsernameKey]), string(secret.Data[registryPasswordKey])
+		if username == "" {
+			return nil, fmt.Errorf("%q key is missing", registryUsernameKey)
+		}
+
+		return map[string]interface{}{
+			"username": username,
+			"password": password,
+		}, nil
+	}
+
+	var dockerConfig struct {
+		Auths map[string]dockerConfigAuth `json:"auths"`
+	}
+
+	if err := json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], &dockerConfig); err != nil {
+		return nil, err
+	}
+
+	for server, entry := range dockerConfig.Auths {
+		if registryHost(server) != registryHost(registry) {
+			continue
+		}
+
+		auth := map[string]interface{}{}
+
+		for key, value := range map[string]string{
+			"username":      entry.Username,
+			"password":      entry.Password,
+			"auth":          entry.Auth,
+			"identityToken": entry.IdentityToken,
+		} {
+			if value != "" {
+				auth[key] = value
+			}
+		}
+
+		return auth, nil
+	}
+
+	return nil, fmt.Errorf("no credentials for the registry in %q", v1.DockerConfigJsonKey)
+}
+
+// registryHost normalizes the registry name for matching the image pull secret entries.
+//
+// Entries might be keyed by the URL (e.g. "https://index.docker.io/v1/"), and Docker Hub is known by several hosts.
+func registryHost(registry string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(registry), "https://"), "http://")
+	host = strings.SplitN(host, "/", 2)[0]
+
+	switch host {
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	default:
+		return host
+	}
+}
+
+// configureRegistries renders the registry credentials and TLS settings into the bootstrap data.
+func configureRegistries(decodedData []byte, configs map[string]interface{}) ([]byte, errorWithCode) {
+	var config map[string]interface{}
+
+	if err := yaml.Unmarshal(decodedData, &config); err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure unmarshaling bootstrap data: %s", err)}
+	}
+
+	for registry, registryConfig := range configs {
+		setConfigValue(config, []string{"machine", "registries", "config", registry}, registryConfig)
+	}
+
+	decodedData, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure marshaling bootstrap data: %s", err)}
+	}
+
+	return decodedData, errorWithCode{}
+}
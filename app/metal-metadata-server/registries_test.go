@@ -0,0 +1,101 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func dockerConfigSecret(config string) *v1.Secret {
+	return &v1.Secret{
+		Type: v1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			v1.DockerConfigJsonKey: []byte(config),
+		},
+	}
+}
+
+func Test_registryAuth(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		registry string
+		secret   *v1.Secret
+		expected map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "username and password",
+			registry: "registry.example.com",
+			secret: &v1.Secret{
+				Data: map[string][]byte{
+					registryUsernameKey: []byte("user"),
+					registryPasswordKey: []byte("pass"),
+				},
+			},
+			expected: map[string]interface{}{"username": "user", "password": "pass"},
+		},
+		{
+			name:     "missing username",
+			registry: "registry.example.com",
+			secret: &v1.Secret{
+				Data: map[string][]byte{
+					registryPasswordKey: []byte("pass"),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "pull secret host",
+			registry: "registry.example.com",
+			secret:   dockerConfigSecret(`{"auths":{"registry.example.com":{"username":"user","password":"pass","auth":"dXNlcjpwYXNz"}}}`),
+			expected: map[string]interface{}{"username": "user", "password": "pass", "auth": "dXNlcjpwYXNz"},
+		},
+		{
+			name:     "pull secret url",
+			registry: "registry.example.com:5000",
+			secret:   dockerConfigSecret(`{"auths":{"https://registry.example.com:5000/v2/":{"identitytoken":"token"}}}`),
+			expected: map[string]interface{}{"identityToken": "token"},
+		},
+		{
+			name:     "docker hub url",
+			registry: "docker.io",
+			secret:   dockerConfigSecret(`{"auths":{"https://index.docker.io/v1/":{"auth":"dXNlcjpwYXNz"}}}`),
+			expected: map[string]interface{}{"auth": "dXNlcjpwYXNz"},
+		},
+		{
+			name:     "docker hub host",
+			registry: "index.docker.io",
+			secret:   dockerConfigSecret(`{"auths":{"docker.io":{"auth":"dXNlcjpwYXNz"}}}`),
+			expected: map[string]interface{}{"auth": "dXNlcjpwYXNz"},
+		},
+		{
+			name:     "other registry",
+			registry: "registry.example.com",
+			secret:   dockerConfigSecret(`{"auths":{"ghcr.io":{"auth":"dXNlcjpwYXNz"}}}`),
+			wantErr:  true,
+		},
+		{
+			name:     "malformed pull secret",
+			registry: "registry.example.com",
+			secret:   dockerConfigSecret(`{"auths":`),
+			wantErr:  true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := registryAuth(tt.registry, tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("registryAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(auth, tt.expected) {
+				t.Errorf("registryAuth() = %v, want %v", auth, tt.expected)
+			}
+		})
+	}
+}
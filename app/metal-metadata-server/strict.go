@@ -0,0 +1,71 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+// Reasons of the config denials in the strict mode.
+const (
+	denyUnknown      = "unknown"
+	denyNotAccepted  = "not-accepted"
+	denyNotAllocated = "not-allocated"
+)
+
+var deniedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "sidero_metadata_denied_total",
+	Help: "Number of metadata requests denied in the strict mode by the reason.",
+}, []string{"reason"})
+
+func init() {
+	prometheus.MustRegister(deniedTotal)
+}
+
+// checkStrict denies the config to the servers which are not accepted and allocated.
+func (m *metadataConfigs) checkStrict(ctx context.Context, r *http.Request, uuid string) errorWithCode {
+	deny := func(reason string) errorWithCode {
+		log.Printf("DENIED: server %q (remote %q) is %s, not serving config in the strict mode", uuid, r.RemoteAddr, reason)
+		deniedTotal.WithLabelValues(reason).Inc()
+
+		return errorWithCode{http.StatusForbidden, fmt.Errorf("server %q is %s, config denied", uuid, reason)}
+	}
+
+	var server metalv1alpha1.Server
+
+	if err := m.client.Get(ctx, types.NamespacedName{Name: uuid}, &server); err != nil {
+		if apierrors.IsNotFound(err) {
+			return deny(denyUnknown)
+		}
+
+		return errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure fetching server %s: %s", uuid, err)}
+	}
+
+	if !server.Spec.Accepted {
+		return deny(denyNotAccepted)
+	}
+
+	var serverBinding v1alpha3.ServerBinding
+
+	if err := m.client.Get(ctx, types.NamespacedName{Name: uuid}, &serverBinding); err != nil {
+		if apierrors.IsNotFound(err) {
+			return deny(denyNotAllocated)
+		}
+
+		return errorWithCode{http.StatusInternalServerError, fmt.Errorf("failure getting server binding: %s", err)}
+	}
+
+	return errorWithCode{}
+}
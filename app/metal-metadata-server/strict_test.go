@@ -0,0 +1,94 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// nolint: scopelint
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/talos-systems/sidero/app/cluster-api-provider-sidero/api/v1alpha3"
+	metalv1alpha1 "github.com/talos-systems/sidero/app/metal-controller-manager/api/v1alpha1"
+)
+
+func TestCheckStrict(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	if err := v1alpha3.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := metalv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	server := func(accepted bool) *metalv1alpha1.Server {
+		return &metalv1alpha1.Server{
+			ObjectMeta: metav1.ObjectMeta{Name: "server"},
+			Spec:       metalv1alpha1.ServerSpec{Accepted: accepted},
+		}
+	}
+
+	binding := &v1alpha3.ServerBinding{ObjectMeta: metav1.ObjectMeta{Name: "server"}}
+
+	for _, tt := range []struct {
+		name    string
+		objects []runtime.Object
+		code    int
+		reason  string
+	}{
+		{
+			name:   "unknown server",
+			code:   http.StatusForbidden,
+			reason: denyUnknown,
+		},
+		{
+			name:    "server not accepted",
+			objects: []runtime.Object{server(false)},
+			code:    http.StatusForbidden,
+			reason:  denyNotAccepted,
+		},
+		{
+			name:    "server not allocated",
+			objects: []runtime.Object{server(true)},
+			code:    http.StatusForbidden,
+			reason:  denyNotAllocated,
+		},
+		{
+			name:    "allocated server",
+			objects: []runtime.Object{server(true), binding},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &metadataConfigs{
+				client: fake.NewFakeClientWithScheme(scheme, tt.objects...),
+				strict: true,
+			}
+
+			var denied float64
+
+			if tt.reason != "" {
+				denied = testutil.ToFloat64(deniedTotal.WithLabelValues(tt.reason))
+			}
+
+			ewc := m.checkStrict(context.Background(), httptest.NewRequest("GET", "/configdata?uuid=server", nil), "server")
+
+			if ewc.errorCode != tt.code {
+				t.Errorf("code = %d, want %d (%v)", ewc.errorCode, tt.code, ewc.errorObj)
+			}
+
+			if tt.reason != "" && testutil.ToFloat64(deniedTotal.WithLabelValues(tt.reason)) != denied+1 {
+				t.Errorf("denial %q is not counted", tt.reason)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ require (
 	github.com/go-logr/logr v0.2.1-0.20200730175230-ee2de8da5be6
 	github.com/go-logr/zapr v0.2.0 // indirect
 	github.com/golang/protobuf v1.4.3
+	github.com/google/cel-go v0.6.0
 	github.com/google/go-cmp v0.5.4 // indirect
 	github.com/hashicorp/go-multierror v1.1.0
 	github.com/onsi/ginkgo v1.15.0
@@ -16,6 +17,7 @@ require (
 	github.com/pensando/goipmi v0.0.0-20200303170213-e858ec1cf0b5
 	github.com/pin/tftp v2.1.1-0.20200117065540-2f79be2dba4e+incompatible
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.7.1
 	github.com/talos-systems/cluster-api-bootstrap-provider-talos v0.2.0-alpha.11
 	github.com/talos-systems/cluster-api-control-plane-provider-talos v0.1.0-alpha.11
 	github.com/talos-systems/go-blockdevice v0.1.1-0.20201218174450-f2728a581972
@@ -25,10 +27,13 @@ require (
 	github.com/talos-systems/net v0.2.1-0.20210212213224-05190541b0fa
 	github.com/talos-systems/talos/pkg/machinery v0.0.0-20210401163915-1d8e9674a91b
 	go.uber.org/zap v1.14.1 // indirect
-	golang.org/x/crypto v0.0.0-20201124201722-c8d3bf9c5392 // indirect
+	golang.org/x/crypto v0.0.0-20201124201722-c8d3bf9c5392
+	golang.org/x/net v0.0.0-20210226172049-e18ecbb05110
 	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9
 	golang.org/x/sys v0.0.0-20210112080510-489259a85091
+	google.golang.org/genproto v0.0.0-20210302174412-5ede27ff9881
 	google.golang.org/grpc v1.36.0
+	google.golang.org/protobuf v1.25.0
 	k8s.io/api v0.19.3
 	k8s.io/apiextensions-apiserver v0.19.1
 	k8s.io/apimachinery v0.19.3